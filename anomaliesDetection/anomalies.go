@@ -42,9 +42,9 @@ type (
 		Date           string
 	}
 
-	// AnalyzedCost is returned by Bollinger Band algorithm and contains
-	// every necessary data for it. It also contains metadata, ignored by
-	// the algorithm.
+	// AnalyzedCost is returned by the anomaly detectors and contains
+	// every necessary data for them. It also contains metadata, ignored by
+	// the detectors.
 	AnalyzedCost struct {
 		Meta      AnalyzedCostEssentialMeta
 		Cost      float64
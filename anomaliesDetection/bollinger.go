@@ -60,19 +60,28 @@ func deviation(sigma float64, period int) float64 {
 	return deviation
 }
 
-// analyseAnomalies calculates anomalies with Bollinger Bands algorithm and
-// const values above. It consists in generating an upper band, which, if
-// exceeded, make an alert.
-func analyseAnomalies(aCosts AnalyzedCosts) AnalyzedCosts {
+// bollingerBand is the detector using the Bollinger Bands algorithm with the
+// config.AnomalyDetectionBollingerBand* values. It consists in generating an
+// upper band from the costs of the previous days, which, if exceeded, make an
+// alert.
+type bollingerBand struct{}
+
+func init() {
+	registerDetector("bollinger", bollingerBand{})
+}
+
+// history returns the period used to generate the upper band.
+func (bollingerBand) history() int {
+	return config.AnomalyDetectionBollingerBandPeriod
+}
+
+// analyse calculates anomalies with Bollinger Bands algorithm.
+func (bollingerBand) analyse(aCosts AnalyzedCosts) AnalyzedCosts {
 	for index := range aCosts {
 		if index > 0 {
 			a := &aCosts[index]
 			tempSliceSize := min(index, config.AnomalyDetectionBollingerBandPeriod)
-			tempSlice := aCosts[index-tempSliceSize : index]
-			avg := average(tempSlice)
-			sigma := sigma(tempSlice, avg)
-			deviation := deviation(sigma, tempSliceSize)
-			a.UpperBand = avg*config.AnomalyDetectionBollingerBandUpperBandCoefficient + (deviation * config.AnomalyDetectionBollingerBandStandardDeviationCoefficient)
+			a.UpperBand = bollingerUpperBand(aCosts[index-tempSliceSize : index])
 			if a.Cost > a.UpperBand {
 				a.Anomaly = true
 			}
@@ -81,6 +90,15 @@ func analyseAnomalies(aCosts AnalyzedCosts) AnalyzedCosts {
 	return aCosts
 }
 
+// bollingerUpperBand generates the upper band of the Bollinger Bands
+// algorithm for a sample of costs.
+func bollingerUpperBand(sample AnalyzedCosts) float64 {
+	avg := average(sample)
+	sigma := sigma(sample, avg)
+	deviation := deviation(sigma, len(sample))
+	return avg*config.AnomalyDetectionBollingerBandUpperBandCoefficient + (deviation * config.AnomalyDetectionBollingerBandStandardDeviationCoefficient)
+}
+
 // addPadding adds a padding if we ask from 10 to 15
 // but ES has only from 12 to 15. So 10 11 will be padded.
 func addPadding(aCosts AnalyzedCosts, dateBegin time.Time) AnalyzedCosts {
@@ -99,9 +117,9 @@ func addPadding(aCosts AnalyzedCosts, dateBegin time.Time) AnalyzedCosts {
 }
 
 // computeAnomalies calls every functions to well format
-// AnalyzedCosts and do BollingerBand.
-func computeAnomalies(ctx context.Context, aCosts AnalyzedCosts, dateBegin time.Time) AnalyzedCosts {
+// AnalyzedCosts and run the detector on them.
+func computeAnomalies(ctx context.Context, aCosts AnalyzedCosts, dateBegin time.Time, d detector) AnalyzedCosts {
 	aCosts = addPadding(aCosts, dateBegin)
-	aCosts = analyseAnomalies(aCosts)
+	aCosts = d.analyse(aCosts)
 	return aCosts
 }
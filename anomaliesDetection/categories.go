@@ -0,0 +1,109 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/olivere/elastic"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/costs/categories"
+	"github.com/trackit/trackit/db"
+)
+
+// esCategoryTypedResult is used to store the raw ElasticSearch response of
+// the values of a cost category, which are keyed buckets.
+type esCategoryTypedResult struct {
+	Buckets map[string]struct {
+		Dates struct {
+			Buckets []esProductDatesBucket `json:"buckets"`
+		} `json:"dates"`
+	} `json:"buckets"`
+}
+
+// CategoryProduct is the product under which the anomalies of a value of a
+// cost category are stored, in the format of the 'category:<NAME>' criterion
+// of the costs.
+func CategoryProduct(name, value string) string {
+	return fmt.Sprintf("%s:%s:%s", categories.Criterion, name, value)
+}
+
+// getCategoryElasticSearchParams returns the ElasticSearchFunction used to construct an
+// ElasticSearch *elastic.SearchService retrieving the cost of each value of a cost category
+// for each day. See getProductElasticSearchParams.
+func getCategoryElasticSearchParams(category categories.Category) ElasticSearchFunction {
+	return func(account string, durationBegin time.Time, durationEnd time.Time, aggregationPeriod string,
+		client *elastic.Client, index string) *elastic.SearchService {
+		query := elastic.NewBoolQuery()
+		query = query.Filter(createQueryAccountFilter(account))
+		query = query.Filter(createQueryTimeRange(durationBegin, durationEnd))
+		search := client.Search().Index(index).Size(0).Query(query)
+
+		search.Aggregation("values", category.Aggregation().
+			SubAggregation("dates", elastic.NewDateHistogramAggregation().Field("usageStartDate").ExtendedBounds(durationBegin, durationEnd).Interval(aggregationPeriod).
+				SubAggregation("cost", elastic.NewSumAggregation().Field("unblendedCost"))))
+		return search
+	}
+}
+
+// categoriesGetAnomaliesData returns the anomalies of the values of the cost
+// categories of a user. Each value is analysed as a product, see
+// CategoryProduct.
+func categoriesGetAnomaliesData(ctx context.Context, params AnomalyEsQueryParams, selection detectorSelection, userId int) (AnalyzedCosts, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	userCategories, err := categories.GetCategories(db.Db, userId)
+	if err != nil {
+		logger.Error("Failed to get cost categories.", err.Error())
+		return nil, err
+	}
+	totalAnalyzedCosts := make(AnalyzedCosts, 0)
+	for _, category := range userCategories {
+		sr, err := makeElasticSearchRequest(ctx, getCategoryElasticSearchParams(category), params)
+		if err != nil {
+			logger.Error("Failed to make elasticsearch request.", err.Error())
+			return nil, err
+		}
+		var typedCategory esCategoryTypedResult
+		if err := json.Unmarshal(*sr.Aggregations["values"], &typedCategory); err != nil {
+			logger.Error("Failed to parse elasticsearch document.", err.Error())
+			return nil, err
+		}
+		typedDocument := categoryToProducts(category, typedCategory)
+		totalAnalyzedCosts = append(totalAnalyzedCosts, productAnalyseCosts(ctx, params, selection, typedDocument)...)
+	}
+	return totalAnalyzedCosts, nil
+}
+
+// categoryToProducts turns the values of a cost category into products, so
+// that they are analysed like them.
+func categoryToProducts(category categories.Category, typedCategory esCategoryTypedResult) esProductTypedResult {
+	var typedDocument esProductTypedResult
+	values := make([]string, 0, len(typedCategory.Buckets))
+	for value := range typedCategory.Buckets {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	for _, value := range values {
+		bucket := esProductBucket{Key: CategoryProduct(category.Name, value)}
+		bucket.Dates.Buckets = typedCategory.Buckets[value].Dates.Buckets
+		typedDocument.Products.Buckets = append(typedDocument.Products.Buckets, bucket)
+	}
+	return typedDocument
+}
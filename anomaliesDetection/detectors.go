@@ -0,0 +1,119 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/trackit/trackit/models"
+)
+
+type (
+	// detector is implemented by every anomaly detection algorithm.
+	detector interface {
+		// history returns the number of days preceding a cost the
+		// algorithm needs to analyse it.
+		history() int
+		// analyse sets the upper band of every cost and flags the ones
+		// exceeding it as anomalies.
+		analyse(aCosts AnalyzedCosts) AnalyzedCosts
+	}
+
+	// detectorSelection holds the detectors selected for an AWS account.
+	// Products without a detector of their own use the account's one.
+	detectorSelection struct {
+		account  detector
+		products map[string]detector
+	}
+)
+
+// DefaultDetector is the detector used when none has been selected for an
+// AWS account or a product.
+const DefaultDetector = "bollinger"
+
+var detectors = make(map[string]detector)
+
+// registerDetector has to be called by every detectors to register them.
+func registerDetector(detectorName string, d detector) {
+	detectors[detectorName] = d
+}
+
+// ValidDetector verifies a detector with this name exists.
+func ValidDetector(detectorName string) error {
+	if _, ok := detectors[detectorName]; !ok {
+		return fmt.Errorf("%s: detector not found", detectorName)
+	}
+	return nil
+}
+
+// DetectorNames returns the sorted names of the available detectors.
+func DetectorNames() []string {
+	names := make([]string, 0, len(detectors))
+	for name := range detectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// maxDetectorHistory returns the longest history needed by a detector. It is
+// used to retrieve enough costs before the analysed period.
+func maxDetectorHistory() int {
+	var max int
+	for _, d := range detectors {
+		if h := d.history(); h > max {
+			max = h
+		}
+	}
+	return max
+}
+
+// getDetectorSelection builds the detectorSelection of an AWS account from
+// the database.
+func getDetectorSelection(db models.XODB, awsAccountId int) (detectorSelection, error) {
+	dbDetectors, err := models.AnomalyDetectorsByAwsAccountID(db, awsAccountId)
+	if err != nil {
+		return newDetectorSelection(nil), err
+	}
+	return newDetectorSelection(dbDetectors), nil
+}
+
+// newDetectorSelection builds a detectorSelection from the detectors selected
+// for an AWS account and its products. Unknown detectors are ignored.
+func newDetectorSelection(dbDetectors []*models.AnomalyDetector) detectorSelection {
+	selection := detectorSelection{
+		account:  detectors[DefaultDetector],
+		products: make(map[string]detector),
+	}
+	for _, dbDetector := range dbDetectors {
+		if d, ok := detectors[dbDetector.Detector]; !ok {
+			continue
+		} else if dbDetector.Product == "" {
+			selection.account = d
+		} else {
+			selection.products[dbDetector.Product] = d
+		}
+	}
+	return selection
+}
+
+// forProduct returns the detector to use for a product.
+func (ds detectorSelection) forProduct(product string) detector {
+	if d, ok := ds.products[product]; ok {
+		return d
+	}
+	return ds.account
+}
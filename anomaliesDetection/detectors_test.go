@@ -0,0 +1,151 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/trackit/trackit/config"
+	"github.com/trackit/trackit/models"
+)
+
+func costsOf(values ...float64) AnalyzedCosts {
+	aCosts := make(AnalyzedCosts, len(values))
+	for i, v := range values {
+		aCosts[i].Cost = v
+	}
+	return aCosts
+}
+
+func TestRobustZScoreUpperBand(t *testing.T) {
+	config.AnomalyDetectionRobustZScoreThreshold = 3.5
+	for _, tt := range []struct {
+		name     string
+		sample   AnalyzedCosts
+		expected float64
+	}{
+		{"median absolute deviation", costsOf(1, 2, 3, 4, 100), 3 + 3.5*1/madConsistencyConstant},
+		{"even sample", costsOf(1, 3), 2 + 3.5*1/madConsistencyConstant},
+		{"mean absolute deviation", costsOf(5, 5, 5, 5, 9), 5 + 3.5*0.8*meanAbsoluteDeviationConsistencyConstant},
+		{"constant costs", costsOf(2, 2, 2), 2},
+		{"empty sample", costsOf(), 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if band := robustZScoreUpperBand(tt.sample); math.Abs(band-tt.expected) > 1e-9 {
+				t.Errorf("Upper band is %f, expected %f", band, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRobustZScoreAnalyse(t *testing.T) {
+	config.AnomalyDetectionRobustZScorePeriod = 5
+	config.AnomalyDetectionRobustZScoreThreshold = 3.5
+	aCosts := robustZScore{}.analyse(costsOf(10, 12, 11, 9, 10, 10, 50))
+	if aCosts[0].Anomaly || aCosts[5].Anomaly || !aCosts[6].Anomaly {
+		t.Errorf("Unexpected anomalies %+v", aCosts)
+	}
+}
+
+func TestSameWeekdaySample(t *testing.T) {
+	config.AnomalyDetectionSeasonalityWeeks = 2
+	aCosts := make(AnalyzedCosts, 21)
+	for i := range aCosts {
+		aCosts[i].Cost = float64(i)
+	}
+	for _, tt := range []struct {
+		index    int
+		expected AnalyzedCosts
+	}{
+		{20, costsOf(13, 6)},
+		{10, costsOf(3)},
+		{7, costsOf(0)},
+		{5, costsOf()},
+	} {
+		if sample := sameWeekdaySample(aCosts, tt.index); !reflect.DeepEqual(sample, tt.expected) {
+			t.Errorf("Sample of %d is %v, expected %v", tt.index, sample, tt.expected)
+		}
+	}
+}
+
+func TestWeeklySeasonalityAnalyse(t *testing.T) {
+	config.AnomalyDetectionSeasonalityWeeks = 2
+	config.AnomalyDetectionBollingerBandPeriod = 3
+	config.AnomalyDetectionBollingerBandStandardDeviationCoefficient = 3
+	config.AnomalyDetectionBollingerBandUpperBandCoefficient = 1.05
+	// Every seventh day costs ten times more, which is only an anomaly
+	// the first time.
+	values := make([]float64, 22)
+	for i := range values {
+		values[i] = 10
+		if i%7 == 0 && i > 0 {
+			values[i] = 100
+		}
+	}
+	aCosts := weeklySeasonality{}.analyse(costsOf(values...))
+	for i, a := range aCosts {
+		if expected := i == 7; a.Anomaly != expected {
+			t.Errorf("Cost %d of %f is an anomaly: %v, expected %v", i, a.Cost, a.Anomaly, expected)
+		}
+	}
+}
+
+func TestDetectorSelection(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		dbDetectors []*models.AnomalyDetector
+		expected    map[string]detector
+	}{
+		{
+			"default",
+			nil,
+			map[string]detector{"AmazonEC2": bollingerBand{}},
+		},
+		{
+			"account and products",
+			[]*models.AnomalyDetector{
+				{Product: "", Detector: "robust-z-score"},
+				{Product: "AmazonEC2", Detector: "weekly-seasonality"},
+				{Product: "AmazonS3", Detector: "unknown"},
+			},
+			map[string]detector{
+				"AmazonEC2": weeklySeasonality{},
+				"AmazonS3":  robustZScore{},
+				"AWSLambda": robustZScore{},
+			},
+		},
+		{
+			"product only",
+			[]*models.AnomalyDetector{
+				{Product: "AmazonS3", Detector: "robust-z-score"},
+			},
+			map[string]detector{
+				"AmazonS3":  robustZScore{},
+				"AmazonEC2": bollingerBand{},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			selection := newDetectorSelection(tt.dbDetectors)
+			for product, expected := range tt.expected {
+				if d := selection.forProduct(product); d != expected {
+					t.Errorf("Detector of %s is %T, expected %T", product, d, expected)
+				}
+			}
+		})
+	}
+}
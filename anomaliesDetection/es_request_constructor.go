@@ -18,8 +18,6 @@ import (
 	"time"
 
 	"github.com/olivere/elastic"
-
-	"github.com/trackit/trackit/config"
 )
 
 const (
@@ -37,9 +35,10 @@ func createQueryAccountFilter(account string) *elastic.TermQuery {
 
 // createQueryTimeRange creates and return a new *elastic.RangeQuery based on the duration
 // defined by durationBegin and durationEnd.
-// durationBegin is reduced by period. This offset is deleted later.
+// durationBegin is reduced by the longest history needed by a detector. This
+// offset is deleted later.
 func createQueryTimeRange(durationBegin time.Time, durationEnd time.Time) *elastic.RangeQuery {
-	periodDuration := time.Duration(config.AnomalyDetectionBollingerBandPeriod) * 24 * time.Hour
+	periodDuration := time.Duration(maxDetectorHistory()) * 24 * time.Hour
 	durationBegin = durationBegin.Add(-periodDuration - 1)
 	return elastic.NewRangeQuery("usageStartDate").
 		From(durationBegin).To(durationEnd)
@@ -96,7 +95,7 @@ func getDateRangeElasticSearchParams(account string, begin bool, client *elastic
 // getAnomalyElasticSearchParams is used to construct an ElasticSearch *elastic.SearchService
 // used to retrieve the anomalies.
 // It takes as parameters :
-// 	- account string : A string representing aws account number
+// 	- account string : A string representing aws account number, or an empty string for every account
 //	- durationBeing time.Time : A time.Time struct representing the begining of the time range in the query
 //	- durationEnd time.Time : A time.Time struct representing the end of the time range in the query
 //	- client *elastic.Client : an instance of *elastic.Client that represent an Elastic Search client.
@@ -108,7 +107,9 @@ func getDateRangeElasticSearchParams(account string, begin bool, client *elastic
 func getAnomalyElasticSearchParams(account string, durationBegin time.Time,
 	durationEnd time.Time, client *elastic.Client, index string, anomalyType string) *elastic.SearchService {
 	query := elastic.NewBoolQuery()
-	query = query.Filter(elastic.NewTermQuery("account", account))
+	if account != "" {
+		query = query.Filter(elastic.NewTermQuery("account", account))
+	}
 	query = query.Filter(elastic.NewRangeQuery("date").From(durationBegin).To(durationEnd))
 	query = query.Filter(elastic.NewTermQuery("abnormal", true))
 	search := client.Search().Index(index).Type(anomalyType).Size(queryMaxSize).Sort("date", true).Query(query)
@@ -0,0 +1,328 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/olivere/elastic"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/config"
+	"github.com/trackit/trackit/costs/anomalies/anomalyFilters"
+	"github.com/trackit/trackit/costs/anomalies/anomalyType"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/es"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/notifications"
+)
+
+// notificationDays is the number of days before the end of a detection whose
+// anomalies are notified. Older anomalies are only shown by the anomalies
+// route.
+const notificationDays = 7
+
+const digestText = `TrackIt detected {{len .}} new cost anomalies on your accounts:
+{{range .}}
+- {{.AccountName}} ({{.Account}}), {{.Product}} on {{.Date.Format "2006-01-02"}}: ${{printf "%.2f" .Cost}} spent while at most ${{printf "%.2f" .MaxExpected}} was expected ({{.PrettyLevel}})
+{{- end}}
+`
+
+const digestHtml = `<p>TrackIt detected {{len .}} new cost anomalies on your accounts:</p>
+<table>
+<tr><th>Account</th><th>Product</th><th>Date</th><th>Cost</th><th>Max expected</th><th>Level</th></tr>
+{{- range .}}
+<tr><td>{{.AccountName}} ({{.Account}})</td><td>{{.Product}}</td><td>{{.Date.Format "2006-01-02"}}</td><td>${{printf "%.2f" .Cost}}</td><td>${{printf "%.2f" .MaxExpected}}</td><td>{{.PrettyLevel}}</td></tr>
+{{- end}}
+</table>
+`
+
+var (
+	digestTextTemplate = texttemplate.Must(texttemplate.New("digest").Parse(digestText))
+	digestHtmlTemplate = htmltemplate.Must(htmltemplate.New("digest").Parse(digestHtml))
+)
+
+// NotifiedAnomaly is the data of an anomaly sent along with its notification.
+type NotifiedAnomaly struct {
+	Id          string    `json:"id"`
+	Account     string    `json:"account"`
+	AccountName string    `json:"accountName"`
+	Product     string    `json:"product"`
+	Date        time.Time `json:"date"`
+	Cost        float64   `json:"cost"`
+	MaxExpected float64   `json:"maxExpected"`
+	Level       int       `json:"level"`
+	PrettyLevel string    `json:"prettyLevel"`
+}
+
+// GetAnomalyLevel returns the level of an anomaly and its pretty name,
+// depending on how much its cost exceeds the maximum expected cost. The
+// levels are set by config.AnomalyDetectionLevels.
+func GetAnomalyLevel(cost, maxExpected float64) (int, string) {
+	prettyLevels := strings.Split(config.AnomalyDetectionPrettyLevels, ",")
+	percent := (cost * 100) / maxExpected
+	levels := strings.Split(config.AnomalyDetectionLevels, ",")
+	for i, level := range levels[1:] {
+		l, _ := strconv.ParseFloat(level, 64)
+		if percent < l {
+			return i, prettyLevels[i]
+		}
+	}
+	return len(levels) - 1, prettyLevels[len(levels)-1]
+}
+
+// NotifyAnomalies sends each user owning accounts a single digest of the new
+// anomalies detected on all of them, AWS accounts and billing source accounts
+// alike. Failing to notify a user does not prevent notifying the others.
+func NotifyAnomalies(ctx context.Context, db *sql.DB) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	userIds, err := models.UserIDsWithAccounts(db)
+	if err != nil {
+		return err
+	}
+	end := time.Now().UTC()
+	for _, userId := range userIds {
+		if uErr := notifyAnomalies(ctx, userId, end); uErr != nil {
+			logger.Error("Failed to notify anomalies.", map[string]interface{}{
+				"userId": userId,
+				"error":  uErr.Error(),
+			})
+			err = uErr
+		}
+	}
+	return err
+}
+
+// notifyAnomalies sends a user the digest of the anomalies detected on their
+// accounts during the last notificationDays days before end. Recurrent,
+// snoozed and filtered anomalies, anomalies under
+// config.AnomalyEmailingMinLevel and anomalies already notified are left out.
+func notifyAnomalies(ctx context.Context, userId int, end time.Time) error {
+	params := AnomalyEsQueryParams{
+		DateEnd: end,
+		Index:   es.IndexNameForUserId(userId, IndexPrefixAnomaliesDetection),
+	}
+	if _, err := es.Client.Refresh(params.Index).Do(ctx); elastic.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	notificationBegin := end.AddDate(0, 0, -notificationDays)
+	params.DateBegin = notificationBegin.AddDate(0, -1, 0)
+	raw, err := getAnomaliesFromEs(ctx, params)
+	if err != nil {
+		return err
+	}
+	recurrent := make(map[string]bool)
+	for _, an := range transformAnomaliesToMap(raw) {
+		for _, r := range detectRecurrence(an) {
+			recurrent[r.Id] = true
+		}
+	}
+	dbUser, err := models.UserByID(db.Db, userId)
+	if err != nil {
+		return err
+	}
+	var filters anomalyType.Filters
+	if dbUser.AnomaliesFilters != nil {
+		if err := json.Unmarshal(dbUser.AnomaliesFilters, &filters); err != nil {
+			return err
+		}
+	}
+	snoozed, err := getSnoozedAnomalies(userId)
+	if err != nil {
+		return err
+	}
+	res := make(anomalyType.AnomaliesDetectionResponse)
+	for _, r := range raw {
+		date, err := time.Parse("2006-01-02T15:04:05Z", r.Source.Date)
+		if err != nil || date.Before(notificationBegin) {
+			continue
+		}
+		level, prettyLevel := GetAnomalyLevel(r.Source.Cost.Value, r.Source.Cost.MaxExpected)
+		if res[r.Source.Account] == nil {
+			res[r.Source.Account] = make(anomalyType.ProductAnomalies)
+		}
+		res[r.Source.Account][r.Source.Product] = append(res[r.Source.Account][r.Source.Product], anomalyType.ProductAnomaly{
+			Id:          r.Id,
+			Date:        date,
+			Cost:        r.Source.Cost.Value,
+			UpperBand:   r.Source.Cost.MaxExpected,
+			Abnormal:    r.Source.Abnormal,
+			Recurrent:   r.Source.Recurrent || recurrent[r.Id],
+			Snoozed:     snoozed[r.Id],
+			Level:       level,
+			PrettyLevel: prettyLevel,
+		})
+	}
+	res = anomalyFilters.Apply(filters, res)
+	accountNames, err := getAccountNamesByIdentity(userId)
+	if err != nil {
+		return err
+	}
+	var notified []NotifiedAnomaly
+	for _, an := range selectNotifiedAnomalies(res, config.AnomalyEmailingMinLevel) {
+		if emailed, err := isAnomalyAlreadyEmailed(userId, an); err != nil {
+			return err
+		} else if !emailed {
+			an.AccountName = accountNames[an.Account]
+			notified = append(notified, an)
+		}
+	}
+	if len(notified) == 0 {
+		return nil
+	}
+	return sendAnomaliesDigest(ctx, dbUser, notified)
+}
+
+// getAccountNamesByIdentity returns the names of the AWS accounts and billing
+// source accounts of a user, by identity.
+func getAccountNamesByIdentity(userId int) (map[string]string, error) {
+	res := make(map[string]string)
+	if awsAccounts, err := models.AwsAccountsByUserID(db.Db, userId); err != nil {
+		return nil, err
+	} else {
+		for _, aa := range awsAccounts {
+			res[aa.AwsIdentity] = aa.Pretty
+		}
+	}
+	if billingAccounts, err := models.BillingSourceAccountsByUserID(db.Db, userId); err != nil {
+		return nil, err
+	} else {
+		for _, ba := range billingAccounts {
+			if _, ok := res[ba.AccountID]; !ok {
+				res[ba.AccountID] = ba.Name
+			}
+		}
+	}
+	return res, nil
+}
+
+// getSnoozedAnomalies returns the ids of the anomalies snoozed by a user.
+func getSnoozedAnomalies(userId int) (map[string]bool, error) {
+	snoozings, err := models.AnomalySnoozingsByUserID(db.Db, userId)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]bool, len(snoozings))
+	for _, snoozing := range snoozings {
+		res[snoozing.AnomalyID] = true
+	}
+	return res, nil
+}
+
+// selectNotifiedAnomalies returns the anomalies of res which are neither
+// recurrent, snoozed nor filtered and whose level is at least minLevel,
+// sorted by account, date and product.
+func selectNotifiedAnomalies(res anomalyType.AnomaliesDetectionResponse, minLevel int) []NotifiedAnomaly {
+	var notified []NotifiedAnomaly
+	for account, products := range res {
+		for product, productAnomalies := range products {
+			for _, an := range productAnomalies {
+				if !an.Abnormal || an.Recurrent || an.Snoozed || an.Filtered || an.Level < minLevel {
+					continue
+				}
+				notified = append(notified, NotifiedAnomaly{
+					Id:          an.Id,
+					Account:     account,
+					Product:     product,
+					Date:        an.Date,
+					Cost:        an.Cost,
+					MaxExpected: an.UpperBand,
+					Level:       an.Level,
+					PrettyLevel: an.PrettyLevel,
+				})
+			}
+		}
+	}
+	sort.Slice(notified, func(i, j int) bool {
+		if notified[i].Account != notified[j].Account {
+			return notified[i].Account < notified[j].Account
+		} else if !notified[i].Date.Equal(notified[j].Date) {
+			return notified[i].Date.Before(notified[j].Date)
+		}
+		return notified[i].Product < notified[j].Product
+	})
+	return notified
+}
+
+// isAnomalyAlreadyEmailed checks whether an anomaly was already sent to a
+// user. Anomalies sent before anomalies were recorded by id are matched by
+// account identity, product and date, whatever the provider of the account.
+func isAnomalyAlreadyEmailed(userId int, an NotifiedAnomaly) (bool, error) {
+	if emailed, err := models.IsUserAnomalyAlreadyEmailed(db.Db, userId, an.Id); err != nil || emailed {
+		return emailed, err
+	}
+	return models.IsAnomalyAlreadyEmailed(db.Db, userId, an.Account, an.Product, an.Date)
+}
+
+// renderAnomaliesDigest renders the plain text and HTML versions of a digest
+// of anomalies.
+func renderAnomaliesDigest(notified []NotifiedAnomaly) (string, string, error) {
+	var text, html bytes.Buffer
+	if err := digestTextTemplate.Execute(&text, notified); err != nil {
+		return "", "", err
+	} else if err := digestHtmlTemplate.Execute(&html, notified); err != nil {
+		return "", "", err
+	}
+	return text.String(), html.String(), nil
+}
+
+// sendAnomaliesDigest sends a digest of anomalies to a user and records them
+// as notified. Anomalies are recorded even if some channels failed, since the
+// failures are kept in the deliveries log.
+func sendAnomaliesDigest(ctx context.Context, dbUser *models.User, notified []NotifiedAnomaly) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	text, html, err := renderAnomaliesDigest(notified)
+	if err != nil {
+		return err
+	}
+	if err := notifications.Notify(ctx, db.Db, dbUser.ID, notifications.Notification{
+		Event:    notifications.EventAnomaly,
+		Subject:  fmt.Sprintf("TrackIt detected %d new cost anomalies", len(notified)),
+		Body:     text,
+		HtmlBody: html,
+		Data:     notified,
+	}); err != nil {
+		logger.Error("Failed to notify anomalies.", map[string]interface{}{
+			"userId": dbUser.ID,
+			"error":  err.Error(),
+		})
+	}
+	for _, an := range notified {
+		emailedAnomaly := models.EmailedAnomaly{
+			UserID:    sql.NullInt64{Int64: int64(dbUser.ID), Valid: true},
+			AnomalyID: an.Id,
+			Account:   an.Account,
+			Product:   an.Product,
+			Recipient: dbUser.Email,
+			Date:      an.Date,
+		}
+		if err := emailedAnomaly.Insert(db.Db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
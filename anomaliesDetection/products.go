@@ -26,6 +26,7 @@ import (
 	"github.com/trackit/trackit/aws"
 	"github.com/trackit/trackit/aws/usageReports"
 	"github.com/trackit/trackit/config"
+	"github.com/trackit/trackit/db"
 	"github.com/trackit/trackit/es"
 )
 
@@ -57,15 +58,18 @@ type (
 		} `json:"cost"`
 	}
 
+	// esProductBucket is used to store the raw ElasticSearch response.
+	esProductBucket struct {
+		Key   string `json:"key"`
+		Dates struct {
+			Buckets []esProductDatesBucket `json:"buckets"`
+		} `json:"dates"`
+	}
+
 	// esProductTypedResult is	used to store the raw ElasticSearch response.
 	esProductTypedResult struct {
 		Products struct {
-			Buckets []struct {
-				Key   string `json:"key"`
-				Dates struct {
-					Buckets []esProductDatesBucket `json:"buckets"`
-				} `json:"dates"`
-			} `json:"buckets"`
+			Buckets []esProductBucket `json:"buckets"`
 		}
 	}
 
@@ -83,11 +87,16 @@ type (
 )
 
 // runAnomaliesDetectionForProducts will get data from ElasticSearch,
-// compute anomalies and ingest the result in ElasticSearch.
+// compute anomalies of the products and of the values of the cost categories
+// and ingest the result in ElasticSearch. The new anomalies are notified by
+// NotifyAnomalies, once for all the accounts of the user.
 func runAnomaliesDetectionForProducts(parsedParams AnomalyEsQueryParams, account aws.AwsAccount, ctx context.Context) (err error) {
-	var res AnalyzedCosts
-	if res, err = productGetAnomaliesData(ctx, parsedParams); err != nil {
-	} else if err = productSaveAnomaliesData(ctx, res, account); err != nil {
+	var res, categoriesRes AnalyzedCosts
+	var selection detectorSelection
+	if selection, err = getDetectorSelection(db.Db, account.Id); err != nil {
+	} else if res, err = productGetAnomaliesData(ctx, parsedParams, selection); err != nil {
+	} else if categoriesRes, err = categoriesGetAnomaliesData(ctx, parsedParams, selection, account.UserId); err != nil {
+	} else if err = productSaveAnomaliesData(ctx, append(res, categoriesRes...), account); err != nil {
 	} else if err = removeRecurrence(ctx, parsedParams, account); err != nil {
 	}
 	return
@@ -226,7 +235,8 @@ func productGetTotalCostByDay(typedDocument esProductTypedResult) totalCostByDay
 }
 
 // productGetAnomaliesData returns product anomalies based on query params, in JSON format.
-func productGetAnomaliesData(ctx context.Context, params AnomalyEsQueryParams) (AnalyzedCosts, error) {
+// Each product is analysed by the detector selected for it.
+func productGetAnomaliesData(ctx context.Context, params AnomalyEsQueryParams, selection detectorSelection) (AnalyzedCosts, error) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	sr, err := makeElasticSearchRequest(ctx, getProductElasticSearchParams, params)
 	if err != nil {
@@ -238,6 +248,12 @@ func productGetAnomaliesData(ctx context.Context, params AnomalyEsQueryParams) (
 		logger.Error("Failed to parse elasticsearch document.", err.Error())
 		return nil, err
 	}
+	return productAnalyseCosts(ctx, params, selection, typedDocument), nil
+}
+
+// productAnalyseCosts returns the anomalies of the daily costs of each
+// product of an ElasticSearch response.
+func productAnalyseCosts(ctx context.Context, params AnomalyEsQueryParams, selection detectorSelection, typedDocument esProductTypedResult) AnalyzedCosts {
 	totalAnalyzedCosts := make(AnalyzedCosts, 0)
 	totalCostsByDay := productGetTotalCostByDay(typedDocument)
 	highestSpendersByDay := productGetHighestSpendersByDay(typedDocument)
@@ -255,13 +271,9 @@ func productGetAnomaliesData(ctx context.Context, params AnomalyEsQueryParams) (
 				Anomaly: false,
 			})
 		}
-		aCosts = computeAnomalies(ctx, aCosts, params.DateBegin)
+		aCosts = computeAnomalies(ctx, aCosts, params.DateBegin, selection.forProduct(product.Key))
 		aCosts = deleteOffset(aCosts, params.DateBegin)
 		totalAnalyzedCosts = append(totalAnalyzedCosts, aCosts...)
 	}
-	totalAnalyzedCosts = productClearDisturbances(totalAnalyzedCosts, totalCostsByDay, highestSpendersByDay)
-	if err != nil {
-		return nil, err
-	}
-	return totalAnalyzedCosts, nil
+	return productClearDisturbances(totalAnalyzedCosts, totalCostsByDay, highestSpendersByDay)
 }
@@ -0,0 +1,107 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"math"
+	"os"
+	"sort"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/config"
+)
+
+const (
+	// madConsistencyConstant makes the median absolute deviation consistent
+	// with the standard deviation of normally distributed costs.
+	madConsistencyConstant = 0.6745
+
+	// meanAbsoluteDeviationConsistencyConstant is used instead of
+	// madConsistencyConstant when the median absolute deviation is zero.
+	meanAbsoluteDeviationConsistencyConstant = 1.253314
+)
+
+// robustZScore is the detector using the modified z-score, based on the
+// median and the median absolute deviation of the costs of the
+// config.AnomalyDetectionRobustZScorePeriod previous days. Unlike the average
+// and the standard deviation, those are not skewed by previous anomalies, such
+// as a monthly fee at the beginning of the month. A cost is an anomaly when
+// its modified z-score exceeds config.AnomalyDetectionRobustZScoreThreshold.
+type robustZScore struct{}
+
+func init() {
+	if config.AnomalyDetectionRobustZScorePeriod < 1 {
+		jsonlog.DefaultLogger.Error("The robust z-score period must be at least one day.", config.AnomalyDetectionRobustZScorePeriod)
+		os.Exit(1)
+	}
+	registerDetector("robust-z-score", robustZScore{})
+}
+
+// history returns the period used to compute the median.
+func (robustZScore) history() int {
+	return config.AnomalyDetectionRobustZScorePeriod
+}
+
+// analyse calculates anomalies with the modified z-score.
+func (robustZScore) analyse(aCosts AnalyzedCosts) AnalyzedCosts {
+	for index := range aCosts {
+		if tempSliceSize := min(index, config.AnomalyDetectionRobustZScorePeriod); tempSliceSize > 0 {
+			a := &aCosts[index]
+			a.UpperBand = robustZScoreUpperBand(aCosts[index-tempSliceSize : index])
+			if a.Cost > a.UpperBand {
+				a.Anomaly = true
+			}
+		}
+	}
+	return aCosts
+}
+
+// robustZScoreUpperBand returns the cost whose modified z-score is the
+// threshold for a sample of costs. An empty sample has no upper band.
+func robustZScoreUpperBand(sample AnalyzedCosts) float64 {
+	if len(sample) == 0 {
+		return 0
+	}
+	values := make([]float64, len(sample))
+	for i, a := range sample {
+		values[i] = a.Cost
+	}
+	med := median(values)
+	deviations := make([]float64, len(values))
+	var meanDeviation float64
+	for i, v := range values {
+		deviations[i] = math.Abs(v - med)
+		meanDeviation += deviations[i] / float64(len(values))
+	}
+	if mad := median(deviations); mad != 0 {
+		return med + config.AnomalyDetectionRobustZScoreThreshold*mad/madConsistencyConstant
+	}
+	return med + config.AnomalyDetectionRobustZScoreThreshold*meanDeviation*meanAbsoluteDeviationConsistencyConstant
+}
+
+// median returns the median of values, or 0 when there is none. values is
+// sorted in the process.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	middle := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[middle-1] + values[middle]) / 2
+	}
+	return values[middle]
+}
@@ -0,0 +1,71 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"github.com/trackit/trackit/config"
+)
+
+// daysInWeek is the number of daily costs between two same weekdays.
+const daysInWeek = 7
+
+// weeklySeasonality is the detector taking the weekly seasonality of the costs
+// into account. The upper band of a cost is generated with the Bollinger
+// Bands algorithm, but only from the costs of the same weekday in the
+// config.AnomalyDetectionSeasonalityWeeks previous weeks. This way, a Monday
+// is only compared to the previous Mondays.
+// Costs with no previous week are analysed with the regular Bollinger Bands
+// algorithm.
+type weeklySeasonality struct{}
+
+func init() {
+	registerDetector("weekly-seasonality", weeklySeasonality{})
+}
+
+// history returns the number of days in the weeks used to generate the upper
+// band.
+func (weeklySeasonality) history() int {
+	return config.AnomalyDetectionSeasonalityWeeks * daysInWeek
+}
+
+// analyse calculates anomalies from the same weekday of the previous weeks.
+func (weeklySeasonality) analyse(aCosts AnalyzedCosts) AnalyzedCosts {
+	for index := range aCosts {
+		if index > 0 {
+			a := &aCosts[index]
+			sample := sameWeekdaySample(aCosts, index)
+			if len(sample) == 0 {
+				tempSliceSize := min(index, config.AnomalyDetectionBollingerBandPeriod)
+				sample = aCosts[index-tempSliceSize : index]
+			}
+			a.UpperBand = bollingerUpperBand(sample)
+			if a.Cost > a.UpperBand {
+				a.Anomaly = true
+			}
+		}
+	}
+	return aCosts
+}
+
+// sameWeekdaySample returns the costs of the same weekday as the cost at
+// index in the previous weeks. Costs are daily and contiguous, so the same
+// weekday of the previous week is found seven costs before.
+func sameWeekdaySample(aCosts AnalyzedCosts, index int) AnalyzedCosts {
+	sample := make(AnalyzedCosts, 0, config.AnomalyDetectionSeasonalityWeeks)
+	for week := 1; week <= config.AnomalyDetectionSeasonalityWeeks && index-week*daysInWeek >= 0; week++ {
+		sample = append(sample, aCosts[index-week*daysInWeek])
+	}
+	return sample
+}
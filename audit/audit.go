@@ -0,0 +1,147 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package audit keeps an append-only record of the security- and
+// cost-relevant actions of the users: who did what, on what, from where,
+// and what it changed.
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+// Action is an action recorded in the audit log.
+type Action string
+
+const (
+	ActionAwsAccountCreate       = Action("aws_account.create")
+	ActionAwsAccountUpdate       = Action("aws_account.update")
+	ActionAwsAccountDelete       = Action("aws_account.delete")
+	ActionBillRepositoryCreate   = Action("bill_repository.create")
+	ActionBillRepositoryUpdate   = Action("bill_repository.update")
+	ActionBillRepositoryDelete   = Action("bill_repository.delete")
+	ActionSharingInvite          = Action("sharing.invite")
+	ActionSharingUpdate          = Action("sharing.update")
+	ActionSharingDelete          = Action("sharing.delete")
+	ActionAnomalySnooze          = Action("anomaly.snooze")
+	ActionAnomalyUnsnooze        = Action("anomaly.unsnooze")
+	ActionAnomaliesFilterUpdate  = Action("anomalies_filters.update")
+	ActionReportDownload         = Action("report.download")
+	ActionCostCategoryUpdate     = Action("cost_category.update")
+	ActionCostCategoryDelete     = Action("cost_category.delete")
+	ActionRedistributionUpdate   = Action("cost_redistribution.update")
+	ActionRedistributionDelete   = Action("cost_redistribution.delete")
+	ActionTagPolicyUpdate        = Action("tag_policy.update")
+	ActionTagPolicyDelete        = Action("tag_policy.delete")
+	ActionTagRemediationCreate   = Action("tag_remediation.create")
+	ActionTagRemediationApprove  = Action("tag_remediation.approve")
+	ActionTagRemediationReject   = Action("tag_remediation.reject")
+	ActionTagRemediationRollback = Action("tag_remediation.rollback")
+)
+
+// Types of the targets of the actions.
+const (
+	TargetAwsAccount       = "aws_account"
+	TargetBillRepository   = "bill_repository"
+	TargetSharedAccount    = "shared_account"
+	TargetAnomaly          = "anomaly"
+	TargetAnomaliesFilters = "anomalies_filters"
+	TargetReport           = "report"
+	TargetCostCategory     = "cost_category"
+	TargetRedistribution   = "cost_redistribution"
+	TargetTagPolicy        = "tag_policy"
+	TargetTagRemediation   = "tag_remediation"
+)
+
+var ErrFailedToRecord = errors.New("Failed to record the action in the audit log.")
+
+// Entry is an action to record in the audit log.
+type Entry struct {
+	Action     Action
+	TargetType string
+	TargetId   string
+	// OwnerId is the user whose data the action was performed on. It
+	// defaults to the authenticated user.
+	OwnerId int
+	// Before and After are the values of the target before and after the
+	// action. They are stored as JSON and may be nil.
+	Before interface{}
+	After  interface{}
+}
+
+// Record adds an entry to the audit log, in the transaction of the action so
+// that only the actions which succeeded are recorded. The actor is the
+// authenticated user, or the viewer acting as them.
+func Record(r *http.Request, tx *sql.Tx, user users.User, entry Entry) error {
+	logger := jsonlog.LoggerFromContextOrDefault(r.Context())
+	dbEntry := models.AuditLog{
+		OwnerID:    user.Id,
+		ActorID:    user.Id,
+		ActorEmail: user.Email,
+		Action:     string(entry.Action),
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetId,
+		RequestID:  routes.RequestIdFromContext(r.Context()),
+		IPAddress:  users.RequestIpAddress(r),
+		Created:    time.Now().UTC(),
+	}
+	if entry.OwnerId != 0 {
+		dbEntry.OwnerID = entry.OwnerId
+	}
+	var err error
+	if user.ViewerId != 0 {
+		var viewer *models.User
+		if viewer, err = models.UserByID(tx, user.ViewerId); err == nil {
+			dbEntry.ActorID = viewer.ID
+			dbEntry.ActorEmail = viewer.Email
+		}
+	}
+	if err == nil {
+		dbEntry.BeforeValue, err = marshalValue(entry.Before)
+	}
+	if err == nil {
+		dbEntry.AfterValue, err = marshalValue(entry.After)
+	}
+	if err == nil {
+		err = dbEntry.Insert(tx)
+	}
+	if err != nil {
+		logger.Error("Failed to record action in audit log.", map[string]interface{}{
+			"action": entry.Action,
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return ErrFailedToRecord
+	}
+	return nil
+}
+
+// marshalValue marshals the value of a target to JSON. A nil value is stored
+// as NULL.
+func marshalValue(value interface{}) ([]byte, error) {
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
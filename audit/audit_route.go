@@ -0,0 +1,185 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getAuditLog).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.QueryArgs{
+				routes.AuditActionOptionalQueryArg,
+				routes.AuditTargetTypeOptionalQueryArg,
+				routes.AuditActorIdOptionalQueryArg,
+				routes.DateBeginOptionalQueryArg,
+				routes.DateEndOptionalQueryArg,
+				routes.AuditBeforeIdOptionalQueryArg,
+				routes.AuditLimitOptionalQueryArg,
+			},
+			routes.Documentation{
+				Summary:     "get the audit log",
+				Description: "Responds with the audit log entries of the actions performed on or by the user, most recent first. Administrators get the entries of all users. The entries are paged: the next page is requested with the id of the last entry of the page as before-id. The end date is inclusive. The log can be exported as CSV with the Accept header set to text/csv.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+		routes.Documentation{
+			Summary: "query the audit log",
+		},
+	).Register("/audit")
+}
+
+const (
+	// defaultAuditLogLimit is the number of entries per page of the audit
+	// log when the route is not given a limit.
+	defaultAuditLogLimit = 100
+	// maxAuditLogLimit is the maximum number of entries per page of the
+	// audit log.
+	maxAuditLogLimit = 1000
+)
+
+var errInvalidLimit = fmt.Errorf("The limit must be between 1 and %d.", maxAuditLogLimit)
+
+// AuditEntry is an entry of the audit log as returned by the /audit route.
+type AuditEntry struct {
+	Id         int             `json:"id"`
+	OwnerId    int             `json:"ownerId"`
+	ActorId    int             `json:"actorId"`
+	ActorEmail string          `json:"actorEmail"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"targetType"`
+	TargetId   string          `json:"targetId"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestId  string          `json:"requestId"`
+	IpAddress  string          `json:"ipAddress"`
+	Created    time.Time       `json:"created"`
+}
+
+// auditEntries is the response of the /audit route. It can be exported as
+// CSV.
+type auditEntries []AuditEntry
+
+// ToCSVable generates the CSV content from auditEntries
+func (ae auditEntries) ToCSVable() [][]string {
+	csv := [][]string{{
+		"id", "created", "actorId", "actorEmail", "ownerId", "action",
+		"targetType", "targetId", "before", "after", "requestId", "ipAddress",
+	}}
+	for _, e := range ae {
+		csv = append(csv, []string{
+			strconv.Itoa(e.Id),
+			e.Created.Format(time.RFC3339),
+			strconv.Itoa(e.ActorId),
+			e.ActorEmail,
+			strconv.Itoa(e.OwnerId),
+			e.Action,
+			e.TargetType,
+			e.TargetId,
+			string(e.Before),
+			string(e.After),
+			e.RequestId,
+			e.IpAddress,
+		})
+	}
+	return csv
+}
+
+// auditEntryFromDbAuditLog builds an AuditEntry from a models.AuditLog.
+func auditEntryFromDbAuditLog(dbEntry models.AuditLog) AuditEntry {
+	return AuditEntry{
+		Id:         dbEntry.ID,
+		OwnerId:    dbEntry.OwnerID,
+		ActorId:    dbEntry.ActorID,
+		ActorEmail: dbEntry.ActorEmail,
+		Action:     dbEntry.Action,
+		TargetType: dbEntry.TargetType,
+		TargetId:   dbEntry.TargetID,
+		Before:     json.RawMessage(dbEntry.BeforeValue),
+		After:      json.RawMessage(dbEntry.AfterValue),
+		RequestId:  dbEntry.RequestID,
+		IpAddress:  dbEntry.IPAddress,
+		Created:    dbEntry.Created,
+	}
+}
+
+// auditLogFilter builds the filter of the audit log from the query
+// arguments. Administrators are not restricted to their own entries.
+func auditLogFilter(user users.User, isAdmin bool, a routes.Arguments) models.AuditLogFilter {
+	filter := models.AuditLogFilter{Limit: defaultAuditLogLimit}
+	if !isAdmin {
+		filter.UserID = user.Id
+	}
+	if action, ok := a[routes.AuditActionOptionalQueryArg].(string); ok {
+		filter.Action = action
+	}
+	if targetType, ok := a[routes.AuditTargetTypeOptionalQueryArg].(string); ok {
+		filter.TargetType = targetType
+	}
+	if actorId, ok := a[routes.AuditActorIdOptionalQueryArg].(int); ok {
+		filter.ActorID = actorId
+	}
+	if begin, ok := a[routes.DateBeginOptionalQueryArg].(time.Time); ok {
+		filter.Begin = begin
+	}
+	if end, ok := a[routes.DateEndOptionalQueryArg].(time.Time); ok {
+		filter.End = end.AddDate(0, 0, 1)
+	}
+	if beforeId, ok := a[routes.AuditBeforeIdOptionalQueryArg].(int); ok {
+		filter.BeforeID = beforeId
+	}
+	if limit, ok := a[routes.AuditLimitOptionalQueryArg].(int); ok {
+		filter.Limit = limit
+	}
+	return filter
+}
+
+// getAuditLog is a route handler which responds with the audit log entries
+// matching the query arguments.
+func getAuditLog(r *http.Request, a routes.Arguments) (int, interface{}) {
+	logger := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	filter := auditLogFilter(user, users.IsAdmin(user), a)
+	if filter.Limit < 1 || filter.Limit > maxAuditLogLimit {
+		return http.StatusBadRequest, errInvalidLimit
+	}
+	dbEntries, err := models.AuditLogsByFilter(tx, filter)
+	if err != nil {
+		logger.Error("Failed to retrieve audit log.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to retrieve audit log.")
+	}
+	res := make(auditEntries, len(dbEntries))
+	for i, dbEntry := range dbEntries {
+		res[i] = auditEntryFromDbAuditLog(*dbEntry)
+	}
+	return http.StatusOK, res
+}
@@ -0,0 +1,91 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+func TestMarshalValue(t *testing.T) {
+	if value, err := marshalValue(nil); err != nil || value != nil {
+		t.Errorf("Nil values should be stored as NULL, got %q", value)
+	}
+	if value, err := marshalValue(map[string]int{"roleId": 2}); err != nil || string(value) != `{"roleId":2}` {
+		t.Errorf("Values should be stored as JSON, got %q", value)
+	}
+}
+
+func TestAuditLogFilter(t *testing.T) {
+	user := users.User{Id: 42}
+	end := time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC)
+	a := routes.Arguments{
+		routes.AuditActionOptionalQueryArg: string(ActionSharingUpdate),
+		routes.DateEndOptionalQueryArg:     end,
+	}
+	filter := auditLogFilter(user, false, a)
+	if filter.UserID != 42 {
+		t.Errorf("Users should only get their own entries, got user %d", filter.UserID)
+	}
+	if filter.Action != "sharing.update" || filter.TargetType != "" || !filter.Begin.IsZero() {
+		t.Errorf("Filter should only restrict the given arguments, got %v", filter)
+	}
+	if !filter.End.Equal(end.AddDate(0, 0, 1)) {
+		t.Errorf("End date should be inclusive, got %v", filter.End)
+	}
+	if filter.Limit != defaultAuditLogLimit || filter.BeforeID != 0 {
+		t.Errorf("Filter should get the first page by default, got %v", filter)
+	}
+	if filter := auditLogFilter(user, true, routes.Arguments{}); filter.UserID != 0 {
+		t.Errorf("Administrators should get the entries of all users, got user %d", filter.UserID)
+	}
+	a = routes.Arguments{
+		routes.AuditBeforeIdOptionalQueryArg: 1337,
+		routes.AuditLimitOptionalQueryArg:    20,
+	}
+	if filter := auditLogFilter(user, false, a); filter.BeforeID != 1337 || filter.Limit != 20 {
+		t.Errorf("Filter should get the requested page, got %v", filter)
+	}
+}
+
+func TestAuditEntriesToCSVable(t *testing.T) {
+	entries := auditEntries{{
+		Id:         1,
+		OwnerId:    2,
+		ActorId:    3,
+		ActorEmail: "actor@example.com",
+		Action:     string(ActionSharingDelete),
+		TargetType: TargetSharedAccount,
+		TargetId:   "4",
+		Before:     json.RawMessage(`{"id":4}`),
+		RequestId:  "request",
+		IpAddress:  "192.0.2.1",
+		Created:    time.Date(2020, 3, 31, 12, 0, 0, 0, time.UTC),
+	}}
+	csv := entries.ToCSVable()
+	if len(csv) != 2 || len(csv[1]) != len(csv[0]) {
+		t.Fatalf("Expected a header and a row of the same length, got %v", csv)
+	}
+	expected := []string{"1", "2020-03-31T12:00:00Z", "3", "actor@example.com", "2", "sharing.delete", "shared_account", "4", `{"id":4}`, "", "request", "192.0.2.1"}
+	for i := range expected {
+		if csv[1][i] != expected[i] {
+			t.Errorf("Expected %q in column %s, got %q", expected[i], csv[0][i], csv[1][i])
+		}
+	}
+}
@@ -32,16 +32,17 @@ import (
 
 // AwsAccount represents a client's AWS account.
 type AwsAccount struct {
-	Id             int           `json:"id"`
-	UserId         int           `json:"-"`
-	Pretty         string        `json:"pretty"`
-	RoleArn        string        `json:"roleArn"`
-	External       string        `json:"-"`
-	Payer          bool          `json:"payer"`
-	AccountOwner   bool          `json:"accountOwner"`
-	UserPermission int           `json:"permissionLevel"`
-	AwsIdentity    string        `json:"awsIdentity"`
-	ParentId       sql.NullInt64 `json:"-"`
+	Id           int           `json:"id"`
+	UserId       int           `json:"-"`
+	Pretty       string        `json:"pretty"`
+	RoleArn      string        `json:"roleArn"`
+	External     string        `json:"-"`
+	Payer        bool          `json:"payer"`
+	AccountOwner bool          `json:"accountOwner"`
+	RoleId       int           `json:"roleId"`
+	RoleName     string        `json:"roleName"`
+	AwsIdentity  string        `json:"awsIdentity"`
+	ParentId     sql.NullInt64 `json:"-"`
 }
 
 var (
@@ -84,6 +85,9 @@ func GetAwsAccountsFromUser(u users.User, tx *sql.Tx) ([]AwsAccount, error) {
 		return nil, err
 	}
 	for _, key := range dbAwsAccounts {
+		if !u.CanAccessAccount(key.AwsIdentity) {
+			continue
+		}
 		res = append(res, AwsAccount{
 			key.ID,
 			key.UserID,
@@ -93,11 +97,18 @@ func GetAwsAccountsFromUser(u users.User, tx *sql.Tx) ([]AwsAccount, error) {
 			key.Payer,
 			true,
 			0,
+			"",
 			key.AwsIdentity,
 			key.ParentID})
 	}
 	for _, key := range dbShareAccounts {
 		dbAwsAccountById, err := models.AwsAccountByID(tx, key.AccountID)
+		if err != nil {
+			return nil, err
+		} else if !u.CanAccessAccount(dbAwsAccountById.AwsIdentity) {
+			continue
+		}
+		role, err := users.GetRoleWithId(tx, key.AccessRoleID)
 		if err != nil {
 			return nil, err
 		}
@@ -109,7 +120,8 @@ func GetAwsAccountsFromUser(u users.User, tx *sql.Tx) ([]AwsAccount, error) {
 			dbAwsAccountById.External,
 			dbAwsAccountById.Payer,
 			false,
-			key.UserPermission,
+			key.AccessRoleID,
+			role.Name,
 			dbAwsAccountById.AwsIdentity,
 			dbAwsAccountById.ParentID})
 	}
@@ -133,13 +145,26 @@ func GetAwsAccountWithIdFromUser(u users.User, aaid int, tx *sql.Tx) (AwsAccount
 	var aaz AwsAccount
 	if aa, err := GetAwsAccountWithId(aaid, tx); err != nil {
 		return aaz, err
-	} else if aa.UserId == u.Id {
+	} else if aa.UserId == u.Id && u.CanAccessAccount(aa.AwsIdentity) {
 		return aa, nil
 	} else {
 		return aaz, errors.New("aws account does not belong to the user")
 	}
 }
 
+// GetAwsAccountWithPermissionFromUser returns an AWS account if the user has
+// a permission on it, either because it belongs to them or because it is
+// shared with them with a role that has the permission.
+func GetAwsAccountWithPermissionFromUser(u users.User, aaid int, permission users.Permission, tx *sql.Tx) (AwsAccount, error) {
+	var aaz AwsAccount
+	if allowed, err := users.HasAccountPermission(tx, u, aaid, permission); err != nil {
+		return aaz, err
+	} else if !allowed {
+		return aaz, errors.New("aws account is not available to the user")
+	}
+	return GetAwsAccountWithId(aaid, tx)
+}
+
 // CreateAwsAccount registers a new AWS account for a user. It does no error
 // checking: the caller should check themselves that the role ARN exists and is
 // correctly configured.
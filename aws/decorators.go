@@ -14,8 +14,12 @@ import (
 
 // RequireAwsAccount decorates handler to require that an AwsAccount be
 // selected using RequiredQueryArgs{AwsAccountIdQueryArg}. The decorator will
-// panic if no AwsAccountIdQueryArg query argument is found.
-type RequireAwsAccountId struct{}
+// panic if no AwsAccountIdQueryArg query argument is found. Without a
+// Permission, the account must belong to the user. With one, it can also be
+// an account shared with the user with a role that has the Permission.
+type RequireAwsAccountId struct {
+	Permission users.Permission
+}
 
 type routeArgKey uint
 
@@ -28,7 +32,7 @@ func (d RequireAwsAccountId) Decorate(h routes.Handler) routes.Handler {
 	return h
 }
 
-func (_ RequireAwsAccountId) getFunc(hf routes.HandlerFunc) routes.HandlerFunc {
+func (d RequireAwsAccountId) getFunc(hf routes.HandlerFunc) routes.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request, a routes.Arguments) (int, interface{}) {
 		l := jsonlog.LoggerFromContextOrDefault(r.Context())
 		user, tx, err := getUserAndTransactionFromArguments(a)
@@ -37,7 +41,12 @@ func (_ RequireAwsAccountId) getFunc(hf routes.HandlerFunc) routes.HandlerFunc {
 			return http.StatusInternalServerError, nil
 		}
 		aaid := a[routes.AwsAccountIdQueryArg].(int)
-		aa, err := GetAwsAccountWithIdFromUser(user, aaid, tx)
+		var aa AwsAccount
+		if d.Permission == "" {
+			aa, err = GetAwsAccountWithIdFromUser(user, aaid, tx)
+		} else {
+			aa, err = GetAwsAccountWithPermissionFromUser(user, aaid, d.Permission, tx)
+		}
 		if err != nil {
 			return http.StatusNotFound, errors.New("AWS account not found")
 		} else {
@@ -0,0 +1,418 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/config"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/users"
+)
+
+// Types of the units of an AWS organization.
+const (
+	OrganizationUnitRoot = "ROOT"
+	OrganizationUnitOu   = "ORGANIZATIONAL_UNIT"
+)
+
+var ErrOrganizationUnitNotFound = errors.New("organizational unit not found")
+
+// Organization is the structure of an AWS organization as discovered from
+// its payer account: a tree of units, the roots and the organizational
+// units, which hold the accounts.
+type Organization struct {
+	Units    []OrganizationUnit    `json:"units"`
+	Accounts []OrganizationAccount `json:"accounts"`
+}
+
+// OrganizationUnit is a root or an organizational unit of an AWS
+// organization. Roots have no parent.
+type OrganizationUnit struct {
+	Id       string `json:"id"`
+	ParentId string `json:"parentId"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+}
+
+// OrganizationAccount is an account of an AWS organization, with the unit it
+// is directly in and its tags.
+type OrganizationAccount struct {
+	Id     string            `json:"id"`
+	Name   string            `json:"name"`
+	UnitId string            `json:"unitId"`
+	Tags   map[string]string `json:"tags"`
+}
+
+// Unit returns the unit of the organization with an ID.
+func (o Organization) Unit(unitId string) (OrganizationUnit, bool) {
+	for _, unit := range o.Units {
+		if unit.Id == unitId {
+			return unit, true
+		}
+	}
+	return OrganizationUnit{}, false
+}
+
+// ChildUnits returns the units directly in a unit. An empty unit ID returns
+// the roots.
+func (o Organization) ChildUnits(unitId string) []OrganizationUnit {
+	children := []OrganizationUnit{}
+	for _, unit := range o.Units {
+		if unit.ParentId == unitId {
+			children = append(children, unit)
+		}
+	}
+	return children
+}
+
+// AccountsInUnit returns the IDs of the accounts in a unit or in any of its
+// descendants.
+func (o Organization) AccountsInUnit(unitId string) []string {
+	units := map[string]bool{unitId: true}
+	for queue := []string{unitId}; len(queue) > 0; queue = queue[1:] {
+		for _, child := range o.ChildUnits(queue[0]) {
+			if !units[child.Id] {
+				units[child.Id] = true
+				queue = append(queue, child.Id)
+			}
+		}
+	}
+	accounts := []string{}
+	for _, account := range o.Accounts {
+		if units[account.UnitId] {
+			accounts = append(accounts, account.Id)
+		}
+	}
+	return accounts
+}
+
+// UnitBuckets splits the accounts in a unit by the units directly in it, for
+// the rollups per unit. Each child unit gets the accounts in it or in any of
+// its descendants and the accounts directly in the unit are keyed by the
+// unit itself. Units without accounts are left out.
+func (o Organization) UnitBuckets(unitId string) map[string][]string {
+	buckets := make(map[string][]string)
+	for _, child := range o.ChildUnits(unitId) {
+		if accounts := o.AccountsInUnit(child.Id); len(accounts) > 0 {
+			buckets[child.Id] = accounts
+		}
+	}
+	for _, account := range o.Accounts {
+		if account.UnitId == unitId {
+			buckets[unitId] = append(buckets[unitId], account.Id)
+		}
+	}
+	return buckets
+}
+
+// RootBuckets splits the accounts of the organization by the units directly
+// in its roots. See UnitBuckets.
+func (o Organization) RootBuckets() map[string][]string {
+	buckets := make(map[string][]string)
+	for _, root := range o.ChildUnits("") {
+		for unitId, accounts := range o.UnitBuckets(root.Id) {
+			buckets[unitId] = accounts
+		}
+	}
+	return buckets
+}
+
+// LowestCommonUnit returns the deepest unit holding all of the accounts. It
+// returns false if an account is not in the organization.
+func (o Organization) LowestCommonUnit(accounts []string) (OrganizationUnit, bool) {
+	var common []string
+	for i, accountId := range accounts {
+		path, ok := o.unitPath(accountId)
+		if !ok {
+			return OrganizationUnit{}, false
+		} else if i == 0 {
+			common = path
+			continue
+		}
+		length := 0
+		for length < len(common) && length < len(path) && common[length] == path[length] {
+			length++
+		}
+		common = common[:length]
+	}
+	if len(common) == 0 {
+		return OrganizationUnit{}, false
+	}
+	return o.Unit(common[len(common)-1])
+}
+
+// unitPath returns the IDs of the units from the root down to the unit an
+// account is directly in.
+func (o Organization) unitPath(accountId string) ([]string, bool) {
+	for _, account := range o.Accounts {
+		if account.Id != accountId {
+			continue
+		}
+		path := []string{}
+		for unit, ok := o.Unit(account.UnitId); ok; unit, ok = o.Unit(unit.ParentId) {
+			path = append([]string{unit.Id}, path...)
+			if len(path) > len(o.Units) {
+				return nil, false
+			}
+		}
+		return path, len(path) > 0
+	}
+	return nil, false
+}
+
+// getAwsOrganization discovers the structure of the AWS organization of a
+// payer account, walking the units from the roots down. Every listing is
+// paginated so that no unit or account is left out.
+func getAwsOrganization(ctx context.Context, aa AwsAccount) (Organization, error) {
+	var organization Organization
+	creds, err := GetTemporaryCredentials(aa, "GetAwsOrganization")
+	if err != nil {
+		return organization, err
+	}
+	sess := session.Must(session.NewSession(&aws.Config{
+		Credentials: creds,
+		Region:      aws.String(config.AwsRegion),
+	}))
+	orga := organizations.New(sess)
+	err = orga.ListRootsPagesWithContext(ctx, &organizations.ListRootsInput{}, func(page *organizations.ListRootsOutput, _ bool) bool {
+		for _, root := range page.Roots {
+			organization.Units = append(organization.Units, OrganizationUnit{
+				Id:   aws.StringValue(root.Id),
+				Name: aws.StringValue(root.Name),
+				Type: OrganizationUnitRoot,
+			})
+		}
+		return true
+	})
+	// The units are walked while they are discovered, so that every level
+	// of the tree is listed.
+	for i := 0; err == nil && i < len(organization.Units); i++ {
+		if err = listChildUnits(ctx, orga, organization.Units[i].Id, &organization); err == nil {
+			err = listUnitAccounts(ctx, orga, organization.Units[i].Id, &organization)
+		}
+	}
+	if err != nil {
+		return organization, err
+	}
+	for i := range organization.Accounts {
+		organization.Accounts[i].Tags = listAccountTags(ctx, orga, organization.Accounts[i].Id)
+	}
+	return organization, nil
+}
+
+// listChildUnits adds the organizational units directly in a unit to an
+// Organization.
+func listChildUnits(ctx context.Context, orga *organizations.Organizations, parentId string, organization *Organization) error {
+	input := organizations.ListOrganizationalUnitsForParentInput{ParentId: aws.String(parentId)}
+	return orga.ListOrganizationalUnitsForParentPagesWithContext(ctx, &input, func(page *organizations.ListOrganizationalUnitsForParentOutput, _ bool) bool {
+		for _, unit := range page.OrganizationalUnits {
+			organization.Units = append(organization.Units, OrganizationUnit{
+				Id:       aws.StringValue(unit.Id),
+				ParentId: parentId,
+				Name:     aws.StringValue(unit.Name),
+				Type:     OrganizationUnitOu,
+			})
+		}
+		return true
+	})
+}
+
+// listUnitAccounts adds the accounts directly in a unit to an Organization.
+func listUnitAccounts(ctx context.Context, orga *organizations.Organizations, parentId string, organization *Organization) error {
+	input := organizations.ListAccountsForParentInput{ParentId: aws.String(parentId)}
+	return orga.ListAccountsForParentPagesWithContext(ctx, &input, func(page *organizations.ListAccountsForParentOutput, _ bool) bool {
+		for _, account := range page.Accounts {
+			organization.Accounts = append(organization.Accounts, OrganizationAccount{
+				Id:     aws.StringValue(account.Id),
+				Name:   aws.StringValue(account.Name),
+				UnitId: parentId,
+			})
+		}
+		return true
+	})
+}
+
+// listTagsForResourceInput and listTagsForResourceOutput are the input and
+// output of the ListTagsForResource operation of AWS Organizations, which the
+// version of the SDK we use predates.
+type listTagsForResourceInput struct {
+	_          struct{} `type:"structure"`
+	NextToken  *string  `type:"string"`
+	ResourceId *string  `type:"string" required:"true"`
+}
+
+type listTagsForResourceOutput struct {
+	_         struct{} `type:"structure"`
+	NextToken *string  `type:"string"`
+	Tags      []*struct {
+		_     struct{} `type:"structure"`
+		Key   *string  `type:"string"`
+		Value *string  `type:"string"`
+	} `type:"list"`
+}
+
+// listAccountTags lists the tags of an account of an organization. Tags are
+// not essential to the structure of the organization: they are left out if
+// the payer account does not allow to list them.
+func listAccountTags(ctx context.Context, orga *organizations.Organizations, accountId string) map[string]string {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	tags := make(map[string]string)
+	input := listTagsForResourceInput{ResourceId: aws.String(accountId)}
+	for {
+		var output listTagsForResourceOutput
+		req := orga.NewRequest(&request.Operation{
+			Name:       "ListTagsForResource",
+			HTTPMethod: "POST",
+			HTTPPath:   "/",
+		}, &input, &output)
+		req.SetContext(ctx)
+		if err := req.Send(); err != nil {
+			logger.Warning("Failed to list tags of organization account.", map[string]interface{}{
+				"accountId": accountId,
+				"error":     err.Error(),
+			})
+			return tags
+		}
+		for _, tag := range output.Tags {
+			tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+		if output.NextToken == nil {
+			return tags
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// storeOrganization replaces the structure of the AWS organization of a payer
+// account in the database.
+func storeOrganization(tx *sql.Tx, aa AwsAccount, organization Organization) error {
+	if err := models.DeleteAwsOrganizationAccountsByAwsAccountID(tx, aa.Id); err != nil {
+		return err
+	} else if err := models.DeleteAwsOrganizationUnitsByAwsAccountID(tx, aa.Id); err != nil {
+		return err
+	}
+	for _, unit := range organization.Units {
+		dbUnit := models.AwsOrganizationUnit{
+			AwsAccountID: aa.Id,
+			UnitID:       unit.Id,
+			ParentUnitID: unit.ParentId,
+			Name:         unit.Name,
+			Type:         unit.Type,
+		}
+		if err := dbUnit.Insert(tx); err != nil {
+			return err
+		}
+	}
+	for _, account := range organization.Accounts {
+		tags, err := json.Marshal(account.Tags)
+		if err != nil {
+			return err
+		}
+		dbAccount := models.AwsOrganizationAccount{
+			AwsAccountID: aa.Id,
+			AccountID:    account.Id,
+			Name:         account.Name,
+			UnitID:       account.UnitId,
+			Tags:         tags,
+		}
+		if err := dbAccount.Insert(tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetOrganization returns the structure of the AWS organization of a payer
+// account as it was last discovered. It is empty if the account is not the
+// payer of an organization.
+func GetOrganization(tx *sql.Tx, aa AwsAccount) (Organization, error) {
+	organization := Organization{
+		Units:    []OrganizationUnit{},
+		Accounts: []OrganizationAccount{},
+	}
+	dbUnits, err := models.AwsOrganizationUnitsByAwsAccountID(tx, aa.Id)
+	if err != nil {
+		return organization, err
+	}
+	for _, dbUnit := range dbUnits {
+		organization.Units = append(organization.Units, OrganizationUnit{
+			Id:       dbUnit.UnitID,
+			ParentId: dbUnit.ParentUnitID,
+			Name:     dbUnit.Name,
+			Type:     dbUnit.Type,
+		})
+	}
+	dbAccounts, err := models.AwsOrganizationAccountsByAwsAccountID(tx, aa.Id)
+	if err != nil {
+		return organization, err
+	}
+	for _, dbAccount := range dbAccounts {
+		account := OrganizationAccount{
+			Id:     dbAccount.AccountID,
+			Name:   dbAccount.Name,
+			UnitId: dbAccount.UnitID,
+		}
+		if err := json.Unmarshal(dbAccount.Tags, &account.Tags); err != nil {
+			return organization, err
+		}
+		organization.Accounts = append(organization.Accounts, account)
+	}
+	return organization, nil
+}
+
+// GetOrganizationWithUnit returns the organization an organizational unit
+// is in, among the organizations of the AWS accounts available to a user.
+func GetOrganizationWithUnit(tx *sql.Tx, user users.User, unitId string) (Organization, error) {
+	dbUnits, err := models.AwsOrganizationUnitsByUnitID(tx, unitId)
+	if err != nil {
+		return Organization{}, err
+	}
+	for _, dbUnit := range dbUnits {
+		if _, ok, err := users.GetRoleOnAccount(tx, user, dbUnit.AwsAccountID); err != nil {
+			return Organization{}, err
+		} else if ok {
+			return GetOrganization(tx, AwsAccount{Id: dbUnit.AwsAccountID})
+		}
+	}
+	return Organization{}, ErrOrganizationUnitNotFound
+}
+
+// GetOrganizationsFromUser returns the organizations of the payer accounts
+// available to a user.
+func GetOrganizationsFromUser(user users.User, tx *sql.Tx) ([]Organization, error) {
+	aas, err := GetAwsAccountsFromUser(user, tx)
+	if err != nil {
+		return nil, err
+	}
+	organizations := []Organization{}
+	for _, aa := range aas {
+		if organization, err := GetOrganization(tx, aa); err != nil {
+			return nil, err
+		} else if len(organization.Units) > 0 {
+			organizations = append(organizations, organization)
+		}
+	}
+	return organizations, nil
+}
@@ -0,0 +1,97 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package aws
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+var testOrganization = Organization{
+	Units: []OrganizationUnit{
+		{Id: "r-root", Name: "Root", Type: OrganizationUnitRoot},
+		{Id: "ou-eng", ParentId: "r-root", Name: "Engineering", Type: OrganizationUnitOu},
+		{Id: "ou-web", ParentId: "ou-eng", Name: "Web", Type: OrganizationUnitOu},
+		{Id: "ou-data", ParentId: "ou-eng", Name: "Data", Type: OrganizationUnitOu},
+		{Id: "ou-sales", ParentId: "r-root", Name: "Sales", Type: OrganizationUnitOu},
+		{Id: "ou-empty", ParentId: "r-root", Name: "Empty", Type: OrganizationUnitOu},
+	},
+	Accounts: []OrganizationAccount{
+		{Id: "000000000001", UnitId: "r-root"},
+		{Id: "000000000002", UnitId: "ou-eng"},
+		{Id: "000000000003", UnitId: "ou-web"},
+		{Id: "000000000004", UnitId: "ou-web"},
+		{Id: "000000000005", UnitId: "ou-data"},
+		{Id: "000000000006", UnitId: "ou-sales"},
+	},
+}
+
+func sorted(s []string) []string {
+	sort.Strings(s)
+	return s
+}
+
+func TestAccountsInUnit(t *testing.T) {
+	for _, c := range []struct {
+		unit     string
+		accounts []string
+	}{
+		{"r-root", []string{"000000000001", "000000000002", "000000000003", "000000000004", "000000000005", "000000000006"}},
+		{"ou-eng", []string{"000000000002", "000000000003", "000000000004", "000000000005"}},
+		{"ou-web", []string{"000000000003", "000000000004"}},
+		{"ou-empty", []string{}},
+	} {
+		if accounts := sorted(testOrganization.AccountsInUnit(c.unit)); !reflect.DeepEqual(accounts, c.accounts) {
+			t.Errorf("Accounts in %s should be %v, are %v.", c.unit, c.accounts, accounts)
+		}
+	}
+}
+
+func TestUnitBuckets(t *testing.T) {
+	expected := map[string][]string{
+		"ou-eng":   {"000000000002", "000000000003", "000000000004", "000000000005"},
+		"ou-sales": {"000000000006"},
+		"r-root":   {"000000000001"},
+	}
+	buckets := testOrganization.UnitBuckets("r-root")
+	for unit := range buckets {
+		sorted(buckets[unit])
+	}
+	if !reflect.DeepEqual(buckets, expected) {
+		t.Errorf("Buckets should be %v, are %v.", expected, buckets)
+	}
+	if buckets := testOrganization.RootBuckets(); len(buckets) != len(expected) {
+		t.Errorf("Root buckets should be %v, are %v.", expected, buckets)
+	}
+}
+
+func TestLowestCommonUnit(t *testing.T) {
+	for _, c := range []struct {
+		accounts []string
+		unit     string
+		ok       bool
+	}{
+		{[]string{"000000000003", "000000000004"}, "ou-web", true},
+		{[]string{"000000000003", "000000000005"}, "ou-eng", true},
+		{[]string{"000000000002", "000000000003"}, "ou-eng", true},
+		{[]string{"000000000003", "000000000006"}, "r-root", true},
+		{[]string{"000000000003", "999999999999"}, "", false},
+	} {
+		if unit, ok := testOrganization.LowestCommonUnit(c.accounts); ok != c.ok || unit.Id != c.unit {
+			t.Errorf("Lowest common unit of %v should be %q, is %q.", c.accounts, c.unit, unit.Id)
+		}
+	}
+}
@@ -19,9 +19,11 @@ import (
 	"database/sql"
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/trackit/jsonlog"
 
+	"github.com/trackit/trackit/audit"
 	"github.com/trackit/trackit/aws"
 	"github.com/trackit/trackit/db"
 	"github.com/trackit/trackit/es"
@@ -68,6 +70,16 @@ func deleteAwsAccount(r *http.Request, a routes.Arguments) (int, interface{}) {
 			return http.StatusInternalServerError, errors.New("specified AWS account is not in user's accounts")
 		}
 	}
+	if res > 0 {
+		if err := audit.Record(r, tx, u, audit.Entry{
+			Action:     audit.ActionAwsAccountDelete,
+			TargetType: audit.TargetAwsAccount,
+			TargetId:   strconv.Itoa(aa.Id),
+			Before:     aa,
+		}); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
 	go func() {
 		for _, br := range dbAwsBillRepositories {
 			err = es.CleanByBillRepositoryId(context.Background(), aa.UserId, br.ID)
@@ -0,0 +1,60 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/aws"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getAwsOrganization).With(
+			db.RequestTransaction{db.Db},
+			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			routes.QueryArgs{routes.AwsAccountIdQueryArg},
+			aws.RequireAwsAccountId{users.PermissionViewCosts},
+			routes.Documentation{
+				Summary:     "get the aws organization of an account",
+				Description: "Responds with the roots, organizational units and accounts of the AWS organization of a payer account, with the tags of the accounts. The organization is discovered again every time the bills of the account are ingested. It is empty for accounts which are not the payer of an organization.",
+			},
+		),
+	}.H().Register("/aws/organization")
+}
+
+// getAwsOrganization is a route handler which responds with the structure of
+// the AWS organization of the account passed in query args.
+func getAwsOrganization(r *http.Request, a routes.Arguments) (int, interface{}) {
+	logger := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	organization, err := aws.GetOrganization(tx, aa)
+	if err != nil {
+		logger.Error("Failed to retrieve AWS organization.", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve AWS organization.")
+	}
+	return http.StatusOK, organization
+}
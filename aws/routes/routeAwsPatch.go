@@ -18,9 +18,11 @@ import (
 	"database/sql"
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/trackit/jsonlog"
 
+	"github.com/trackit/trackit/audit"
 	"github.com/trackit/trackit/aws"
 	"github.com/trackit/trackit/db"
 	"github.com/trackit/trackit/routes"
@@ -61,6 +63,7 @@ func patchAwsAccountWithValidBody(r *http.Request, tx *sql.Tx, user users.User,
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	awsAccount, err := aws.GetAwsAccountWithIdFromUser(user, id, tx)
 	if err == nil {
+		before := awsAccount
 		awsAccount.Pretty = body.Pretty
 		awsAccount.Payer = body.Payer
 		awsAccount.RoleArn = body.RoleArn
@@ -68,6 +71,15 @@ func patchAwsAccountWithValidBody(r *http.Request, tx *sql.Tx, user users.User,
 			logger.Error("failed to update AWS Account", err)
 			return http.StatusInternalServerError, errFailUpdateAccount
 		}
+		if err := audit.Record(r, tx, user, audit.Entry{
+			Action:     audit.ActionAwsAccountUpdate,
+			TargetType: audit.TargetAwsAccount,
+			TargetId:   strconv.Itoa(awsAccount.Id),
+			Before:     before,
+			After:      awsAccount,
+		}); err != nil {
+			return http.StatusInternalServerError, err
+		}
 	} else {
 		logger.Error("failed to get user's AWS accounts", err.Error())
 		return http.StatusInternalServerError, errors.New("failed to retrieve AWS accounts")
@@ -20,9 +20,11 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/trackit/jsonlog"
 
+	"github.com/trackit/trackit/audit"
 	"github.com/trackit/trackit/aws"
 	"github.com/trackit/trackit/db"
 	"github.com/trackit/trackit/models"
@@ -80,6 +82,14 @@ func postAwsAccountWithValidBody(r *http.Request, tx *sql.Tx, user users.User, b
 				"err":     err.Error(),
 			})
 		}
+		if err := audit.Record(r, tx, user, audit.Entry{
+			Action:     audit.ActionAwsAccountCreate,
+			TargetType: audit.TargetAwsAccount,
+			TargetId:   strconv.Itoa(account.Id),
+			After:      account,
+		}); err != nil {
+			return 500, err
+		}
 		return 200, account
 	} else {
 		switch err {
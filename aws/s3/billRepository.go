@@ -21,10 +21,12 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/trackit/jsonlog"
 
+	"github.com/trackit/trackit/audit"
 	"github.com/trackit/trackit/aws"
 	"github.com/trackit/trackit/db"
 	"github.com/trackit/trackit/es"
@@ -37,7 +39,7 @@ func init() {
 	routes.MethodMuxer{
 		http.MethodGet: routes.H(getBillRepository).With(
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
-			aws.RequireAwsAccountId{},
+			aws.RequireAwsAccountId{users.PermissionViewCosts},
 			routes.Documentation{
 				Summary:     "get aws account's bill repositories",
 				Description: "Gets the list of bill repositories for an AWS account.",
@@ -45,7 +47,7 @@ func init() {
 		),
 		http.MethodPost: routes.H(postBillRepository).With(
 			users.RequireAuthenticatedUser{users.ViewerCannot},
-			aws.RequireAwsAccountId{},
+			aws.RequireAwsAccountId{users.PermissionManageBillRepositories},
 			routes.RequestContentType{"application/json"},
 			routes.RequestBody{postBillRepositoryBody{
 				Bucket: "my-bucket",
@@ -58,7 +60,7 @@ func init() {
 		),
 		http.MethodPatch: routes.H(patchBillRepository).With(
 			users.RequireAuthenticatedUser{users.ViewerCannot},
-			aws.RequireAwsAccountId{},
+			aws.RequireAwsAccountId{users.PermissionManageBillRepositories},
 			routes.RequestContentType{"application/json"},
 			routes.QueryArgs{routes.BillPositoryQueryArg},
 			routes.RequestBody{postBillRepositoryBody{
@@ -72,7 +74,7 @@ func init() {
 		),
 		http.MethodDelete: routes.H(deleteBillRepository).With(
 			users.RequireAuthenticatedUser{users.ViewerCannot},
-			aws.RequireAwsAccountId{},
+			aws.RequireAwsAccountId{users.PermissionManageBillRepositories},
 			routes.RequestContentType{"application/json"},
 			routes.QueryArgs{routes.BillPositoryQueryArg},
 			routes.Documentation{
@@ -237,17 +239,28 @@ func postBillRepository(r *http.Request, a routes.Arguments) (int, interface{})
 		return http.StatusBadRequest, err
 	}
 	tx := a[db.Transaction].(*sql.Tx)
-	return postBillRepositoryWithValidBody(r, tx, aa, body)
+	user := a[users.AuthenticatedUser].(users.User)
+	return postBillRepositoryWithValidBody(r, tx, user, aa, body)
 }
 
 func postBillRepositoryWithValidBody(
 	r *http.Request,
 	tx *sql.Tx,
+	user users.User,
 	aa aws.AwsAccount,
 	body postBillRepositoryBody,
 ) (int, interface{}) {
 	br, err := CreateBillRepository(aa, BillRepository{Bucket: body.Bucket, Prefix: body.Prefix}, tx)
 	if err == nil {
+		if err := audit.Record(r, tx, user, audit.Entry{
+			Action:     audit.ActionBillRepositoryCreate,
+			TargetType: audit.TargetBillRepository,
+			TargetId:   strconv.Itoa(br.Id),
+			OwnerId:    aa.UserId,
+			After:      br,
+		}); err != nil {
+			return http.StatusInternalServerError, err
+		}
 		go UpdateReport(context.Background(), aa, br)
 		return http.StatusOK, br
 	} else {
@@ -272,12 +285,14 @@ func patchBillRepository(r *http.Request, a routes.Arguments) (int, interface{})
 	}
 	tx := a[db.Transaction].(*sql.Tx)
 	brId := a[routes.BillPositoryQueryArg].(int)
-	return patchBillRepositoryWithValidBody(r, tx, aa, brId, body)
+	user := a[users.AuthenticatedUser].(users.User)
+	return patchBillRepositoryWithValidBody(r, tx, user, aa, brId, body)
 }
 
 func patchBillRepositoryWithValidBody(
 	r *http.Request,
 	tx *sql.Tx,
+	user users.User,
 	aa aws.AwsAccount,
 	brId int,
 	body postBillRepositoryBody,
@@ -290,8 +305,19 @@ func patchBillRepositoryWithValidBody(
 		})
 		return http.StatusNotFound, errors.New("failed to find bill repository to update")
 	}
+	before := billRepoFromDbBillRepo(*dbBillingRepo)
 	br, err := UpdateBillRepositorySafe(dbBillingRepo, BillRepository{Id: brId, AwsAccountId: aa.Id, Bucket: body.Bucket, Prefix: body.Prefix}, tx)
 	if err == nil {
+		if err := audit.Record(r, tx, user, audit.Entry{
+			Action:     audit.ActionBillRepositoryUpdate,
+			TargetType: audit.TargetBillRepository,
+			TargetId:   strconv.Itoa(br.Id),
+			OwnerId:    aa.UserId,
+			Before:     before,
+			After:      br,
+		}); err != nil {
+			return http.StatusInternalServerError, err
+		}
 		go func() {
 			err = es.CleanByBillRepositoryId(context.Background(), aa.UserId, br.Id)
 			if err != nil {
@@ -372,8 +398,22 @@ func deleteBillRepository(r *http.Request, a routes.Arguments) (int, interface{}
 	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
 	brId := a[routes.BillPositoryQueryArg].(int)
 	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	var before BillRepository
+	if dbBr, err := models.AwsBillRepositoryByID(tx, brId); err == nil {
+		before = billRepoFromDbBillRepo(*dbBr)
+	}
 	err := DeleteBillRepositoryById(brId, tx)
 	if err == nil {
+		if err := audit.Record(r, tx, user, audit.Entry{
+			Action:     audit.ActionBillRepositoryDelete,
+			TargetType: audit.TargetBillRepository,
+			TargetId:   strconv.Itoa(brId),
+			OwnerId:    aa.UserId,
+			Before:     before,
+		}); err != nil {
+			return http.StatusInternalServerError, err
+		}
 		go func() {
 			err = es.CleanByBillRepositoryId(context.Background(), aa.UserId, brId)
 			if err != nil {
@@ -0,0 +1,95 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package s3
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// DefaultCostType is the cost type used when none is requested.
+const DefaultCostType = "unblended"
+
+// costTypeToField maps the cost types that can be requested on the cost
+// routes to the line item field holding them.
+var costTypeToField = map[string]string{
+	"unblended": "unblendedCost",
+	"blended":   "blendedCost",
+	"amortized": "amortizedCost",
+	"net":       "netUnblendedCost",
+}
+
+// GetCostTypeField returns the name of the line item field holding the cost
+// of type costType. An empty costType stands for DefaultCostType.
+func GetCostTypeField(costType string) (string, error) {
+	if costType == "" {
+		costType = DefaultCostType
+	}
+	if field, ok := costTypeToField[costType]; ok {
+		return field, nil
+	}
+	return "", fmt.Errorf("invalid cost type : %s", costType)
+}
+
+// computeCosts fills the cost columns of a LineItem which may be absent from
+// the report and computes its amortized cost.
+// Reports without discounts do not have a NetUnblendedCost column, in which
+// case the net cost is the unblended cost. The same goes for the BlendedCost
+// column of accounts which are not part of an organization.
+func computeCosts(li LineItem) LineItem {
+	if li.NetUnblendedCost == "" {
+		li.NetUnblendedCost = li.UnblendedCost
+	}
+	if li.BlendedCost == "" {
+		li.BlendedCost = li.UnblendedCost
+	}
+	li.AmortizedCost = getAmortizedCost(li)
+	return li
+}
+
+// getAmortizedCost computes the amortized cost of a LineItem, following the
+// method used by AWS Cost Explorer: usage covered by a reservation or a
+// savings plan costs its effective cost, the unused part of recurring fees is
+// kept and upfront fees are spread over the covered usage, so they count as
+// zero.
+func getAmortizedCost(li LineItem) float64 {
+	switch li.LineItemType {
+	case "DiscountedUsage":
+		return parseCost(li.EffectiveCost)
+	case "RIFee":
+		return parseCost(li.UnusedUpfrontFee) + parseCost(li.UnusedRecurringFee)
+	case "SavingsPlanCoveredUsage":
+		return parseCost(li.SavingsPlanCost)
+	case "SavingsPlanRecurringFee":
+		return parseCost(li.TotalCommitment) - parseCost(li.UsedCommitment)
+	case "SavingsPlanNegation", "SavingsPlanUpfrontFee":
+		return 0
+	case "Fee":
+		if li.ReservationArn != "" {
+			return 0
+		}
+	}
+	return parseCost(li.UnblendedCost)
+}
+
+// parseCost parses a cost column of a report. Empty or invalid values are
+// zero.
+func parseCost(cost string) float64 {
+	value, err := strconv.ParseFloat(cost, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
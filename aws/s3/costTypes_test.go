@@ -0,0 +1,51 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package s3
+
+import "testing"
+
+func TestGetAmortizedCost(t *testing.T) {
+	cases := []struct {
+		name     string
+		li       LineItem
+		expected float64
+	}{
+		{"usage", LineItem{LineItemType: "Usage", UnblendedCost: "1.5"}, 1.5},
+		{"reserved usage", LineItem{LineItemType: "DiscountedUsage", UnblendedCost: "0", EffectiveCost: "0.8"}, 0.8},
+		{"reservation fee", LineItem{LineItemType: "RIFee", UnblendedCost: "10", UnusedUpfrontFee: "1", UnusedRecurringFee: "2"}, 3},
+		{"reservation upfront fee", LineItem{LineItemType: "Fee", UnblendedCost: "1000", ReservationArn: "arn"}, 0},
+		{"other fee", LineItem{LineItemType: "Fee", UnblendedCost: "12"}, 12},
+		{"savings plan usage", LineItem{LineItemType: "SavingsPlanCoveredUsage", UnblendedCost: "2", SavingsPlanCost: "1.2"}, 1.2},
+		{"savings plan negation", LineItem{LineItemType: "SavingsPlanNegation", UnblendedCost: "-2"}, 0},
+		{"savings plan fee", LineItem{LineItemType: "SavingsPlanRecurringFee", UnblendedCost: "5", TotalCommitment: "5", UsedCommitment: "4"}, 1},
+	}
+	for _, c := range cases {
+		if res := getAmortizedCost(c.li); res != c.expected {
+			t.Errorf("%s: expected %v but got %v", c.name, c.expected, res)
+		}
+	}
+}
+
+func TestGetCostTypeField(t *testing.T) {
+	if field, err := GetCostTypeField(""); err != nil || field != "unblendedCost" {
+		t.Errorf("Expected unblendedCost but got %s (%v)", field, err)
+	}
+	if field, err := GetCostTypeField("amortized"); err != nil || field != "amortizedCost" {
+		t.Errorf("Expected amortizedCost but got %s (%v)", field, err)
+	}
+	if _, err := GetCostTypeField("invalid"); err == nil {
+		t.Errorf("Expected an error for an invalid cost type")
+	}
+}
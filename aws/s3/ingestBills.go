@@ -193,11 +193,16 @@ func ingestLineItems(ctx context.Context, bp *elastic.BulkProcessor, index strin
 				li.AvailabilityZone = "taxes"
 				li.Region = "taxes"
 			}
+			li.Provider = ProviderAws
 			li.BillRepositoryId = br.Id
 			li = extractTags(li)
+			li = computeCosts(li)
 			rq := elastic.NewBulkIndexRequest()
 			rq = rq.Index(index)
-			rq = rq.OpType(opTypeCreate)
+			// Line items have stable IDs: indexing a manifest again
+			// overwrites them with its latest content, e.g. the cost
+			// types of line items ingested before they were read.
+			rq = rq.OpType(opTypeIndex)
 			rq = rq.Type(TypeLineItem)
 			rq = rq.Id(li.EsId())
 			rq = rq.Doc(li)
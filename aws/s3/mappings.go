@@ -27,6 +27,10 @@ const TypeLineItem = "lineitem"
 const IndexPrefixLineItem = "lineitems"
 const TemplateNameLineItem = "lineitems"
 
+// ProviderAws is the provider of the line items read from Cost and Usage
+// Reports. Line items ingested before providers were introduced have none.
+const ProviderAws = "aws"
+
 // put the ElasticSearch index for *-lineitems indices at startup.
 func init() {
 	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -37,18 +41,148 @@ func init() {
 		jsonlog.DefaultLogger.Info("Put ES index lineitems.", res)
 		ctxCancel()
 	}
+	putLineItemMapping("provider", MappingLineItemProvider)
+	putLineItemMapping("cost types", MappingLineItemCostTypes)
+	putLineItemMapping("savings plans", MappingLineItemSavingsPlans)
 }
 
+// putLineItemMapping adds fields to the mapping of existing *-lineitems
+// indices, which the template does not apply to. Without it, the fields would
+// be mapped dynamically when first indexed.
+func putLineItemMapping(name, mapping string) {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+	res, err := es.Client.PutMapping().Index("*-" + IndexPrefixLineItem).Type(TypeLineItem).AllowNoIndices(true).BodyString(mapping).Do(ctx)
+	if err != nil {
+		jsonlog.DefaultLogger.Error("Failed to put ES mapping lineitems "+name+".", err)
+	} else {
+		jsonlog.DefaultLogger.Info("Put ES mapping lineitems "+name+".", res)
+	}
+}
+
+const MappingLineItemProvider = `
+{
+	"properties": {
+		"provider": {
+			"type": "keyword",
+			"norms": false
+		},
+		"billingSourceId": {
+			"type": "integer"
+		},
+		"sourceFile": {
+			"type": "keyword",
+			"norms": false
+		}
+	}
+}
+`
+
+// MappingLineItemCostTypes holds the cost columns read from Cost and Usage
+// Reports besides the unblended cost. The line items ingested before they
+// were read lack them until their bill repository is ingested again.
+const MappingLineItemCostTypes = `
+{
+	"properties": {
+		"blendedCost": {
+			"type": "float",
+			"index": false
+		},
+		"netUnblendedCost": {
+			"type": "float",
+			"index": false
+		},
+		"amortizedCost": {
+			"type": "float",
+			"index": false
+		},
+		"pricingTerm": {
+			"type": "keyword",
+			"norms": false
+		},
+		"pricingUnit": {
+			"type": "keyword",
+			"norms": false
+		},
+		"publicOnDemandCost": {
+			"type": "float",
+			"index": false
+		},
+		"leaseContractLength": {
+			"type": "keyword",
+			"norms": false
+		},
+		"offeringClass": {
+			"type": "keyword",
+			"norms": false
+		},
+		"purchaseOption": {
+			"type": "keyword",
+			"norms": false
+		},
+		"reservationArn": {
+			"type": "keyword",
+			"norms": false
+		},
+		"reservationEffectiveCost": {
+			"type": "float",
+			"index": false
+		},
+		"savingsPlanArn": {
+			"type": "keyword",
+			"norms": false
+		},
+		"savingsPlanEffectiveCost": {
+			"type": "float",
+			"index": false
+		}
+	}
+}
+`
+
+// MappingLineItemSavingsPlans holds the kind of Savings Plan of the line items
+// read from Cost and Usage Reports, which tells which discount rates their
+// Savings Plans rates are.
+const MappingLineItemSavingsPlans = `
+{
+	"properties": {
+		"savingsPlanOfferingType": {
+			"type": "keyword",
+			"norms": false
+		},
+		"savingsPlanPurchaseTerm": {
+			"type": "keyword",
+			"norms": false
+		},
+		"savingsPlanPaymentOption": {
+			"type": "keyword",
+			"norms": false
+		}
+	}
+}
+`
+
 const TemplateLineItem = `
 {
 	"template": "*-lineitems",
-	"version": 8,
+	"version": 11,
 	"mappings": {
 		"lineitem": {
 			"properties": {
+				"provider": {
+					"type": "keyword",
+					"norms": false
+				},
 				"billRepositoryId": {
 					"type": "integer"
 				},
+				"billingSourceId": {
+					"type": "integer"
+				},
+				"sourceFile": {
+					"type": "keyword",
+					"norms": false
+				},
 				"lineItemId": {
 					"type": "keyword",
 					"norms": false
@@ -109,10 +243,74 @@ const TemplateLineItem = `
 					"type": "float",
 					"index": false
 				},
+				"blendedCost": {
+					"type": "float",
+					"index": false
+				},
+				"netUnblendedCost": {
+					"type": "float",
+					"index": false
+				},
+				"amortizedCost": {
+					"type": "float",
+					"index": false
+				},
 				"taxType": {
 					"type": "keyword",
 					"norms": false
 				},
+				"pricingTerm": {
+					"type": "keyword",
+					"norms": false
+				},
+				"pricingUnit": {
+					"type": "keyword",
+					"norms": false
+				},
+				"publicOnDemandCost": {
+					"type": "float",
+					"index": false
+				},
+				"leaseContractLength": {
+					"type": "keyword",
+					"norms": false
+				},
+				"offeringClass": {
+					"type": "keyword",
+					"norms": false
+				},
+				"purchaseOption": {
+					"type": "keyword",
+					"norms": false
+				},
+				"reservationArn": {
+					"type": "keyword",
+					"norms": false
+				},
+				"reservationEffectiveCost": {
+					"type": "float",
+					"index": false
+				},
+				"savingsPlanArn": {
+					"type": "keyword",
+					"norms": false
+				},
+				"savingsPlanEffectiveCost": {
+					"type": "float",
+					"index": false
+				},
+				"savingsPlanOfferingType": {
+					"type": "keyword",
+					"norms": false
+				},
+				"savingsPlanPurchaseTerm": {
+					"type": "keyword",
+					"norms": false
+				},
+				"savingsPlanPaymentOption": {
+					"type": "keyword",
+					"norms": false
+				},
 				"usageStartDate": {
 					"type": "date"
 				},
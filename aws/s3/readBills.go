@@ -94,29 +94,50 @@ type billRepositoryWithRegion struct {
 }
 
 type LineItem struct {
-	BillRepositoryId   int               `csv:"-"                            json:"billRepositoryId"`
-	LineItemId         string            `csv:"identity/LineItemId"          json:"lineItemId"`
-	TimeInterval       string            `csv:"identity/TimeInterval"        json:"-"`
-	InvoiceId          string            `csv:"bill/InvoiceId"               json:"invoiceId"`
-	BillingPeriodStart string            `csv:"bill/BillingPeriodStartDate"  json:"-"`
-	BillingPeriodEnd   string            `csv:"bill/BillingPeriodEndDate"    json:"-"`
-	UsageAccountId     string            `csv:"lineItem/UsageAccountId"      json:"usageAccountId"`
-	LineItemType       string            `csv:"lineItem/LineItemType"        json:"lineItemType"`
-	UsageStartDate     string            `csv:"lineItem/UsageStartDate"      json:"usageStartDate"`
-	UsageEndDate       string            `csv:"lineItem/UsageEndDate"        json:"usageEndDate""`
-	ProductCode        string            `csv:"lineItem/ProductCode"         json:"productCode"`
-	UsageType          string            `csv:"lineItem/UsageType"           json:"usageType"`
-	Operation          string            `csv:"lineItem/Operation"           json:"operation"`
-	AvailabilityZone   string            `csv:"lineItem/AvailabilityZone"    json:"availabilityZone"`
-	Region             string            `csv:"product/region"               json:"region"`
-	ResourceId         string            `csv:"lineItem/ResourceId"          json:"resourceId"`
-	UsageAmount        string            `csv:"lineItem/UsageAmount"         json:"usageAmount"`
-	ServiceCode        string            `csv:"product/servicecode"          json:"serviceCode"`
-	CurrencyCode       string            `csv:"lineItem/CurrencyCode"        json:"currencyCode"`
-	UnblendedCost      string            `csv:"lineItem/UnblendedCost"       json:"unblendedCost"`
-	TaxType            string            `csv:"lineItem/TaxType"             json:"taxType"`
-	Any                map[string]string `csv:",any"                         json:"-"`
-	Tags               []LineItemTags    `csv:"-"                            json:"tags,omitempty"`
+	Provider           string            `csv:"-"                                                     json:"provider"`
+	BillRepositoryId   int               `csv:"-"                                                     json:"billRepositoryId"`
+	LineItemId         string            `csv:"identity/LineItemId"                                   json:"lineItemId"`
+	TimeInterval       string            `csv:"identity/TimeInterval"                                 json:"-"`
+	InvoiceId          string            `csv:"bill/InvoiceId"                                        json:"invoiceId"`
+	BillingPeriodStart string            `csv:"bill/BillingPeriodStartDate"                           json:"-"`
+	BillingPeriodEnd   string            `csv:"bill/BillingPeriodEndDate"                             json:"-"`
+	UsageAccountId     string            `csv:"lineItem/UsageAccountId"                               json:"usageAccountId"`
+	LineItemType       string            `csv:"lineItem/LineItemType"                                 json:"lineItemType"`
+	UsageStartDate     string            `csv:"lineItem/UsageStartDate"                               json:"usageStartDate"`
+	UsageEndDate       string            `csv:"lineItem/UsageEndDate"                                 json:"usageEndDate"`
+	ProductCode        string            `csv:"lineItem/ProductCode"                                  json:"productCode"`
+	UsageType          string            `csv:"lineItem/UsageType"                                    json:"usageType"`
+	Operation          string            `csv:"lineItem/Operation"                                    json:"operation"`
+	AvailabilityZone   string            `csv:"lineItem/AvailabilityZone"                             json:"availabilityZone"`
+	Region             string            `csv:"product/region"                                        json:"region"`
+	ResourceId         string            `csv:"lineItem/ResourceId"                                   json:"resourceId"`
+	UsageAmount        string            `csv:"lineItem/UsageAmount"                                  json:"usageAmount"`
+	ServiceCode        string            `csv:"product/servicecode"                                   json:"serviceCode"`
+	CurrencyCode       string            `csv:"lineItem/CurrencyCode"                                 json:"currencyCode"`
+	UnblendedCost      string            `csv:"lineItem/UnblendedCost"                                json:"unblendedCost"`
+	BlendedCost        string            `csv:"lineItem/BlendedCost"                                  json:"blendedCost,omitempty"`
+	NetUnblendedCost   string            `csv:"lineItem/NetUnblendedCost"                             json:"netUnblendedCost,omitempty"`
+	TaxType            string            `csv:"lineItem/TaxType"                                      json:"taxType"`
+	PricingTerm        string            `csv:"pricing/term"                                          json:"pricingTerm,omitempty"`
+	PricingUnit        string            `csv:"pricing/unit"                                          json:"pricingUnit,omitempty"`
+	PublicOnDemandCost string            `csv:"pricing/publicOnDemandCost"                            json:"publicOnDemandCost,omitempty"`
+	LeaseLength        string            `csv:"pricing/LeaseContractLength"                           json:"leaseContractLength,omitempty"`
+	OfferingClass      string            `csv:"pricing/OfferingClass"                                 json:"offeringClass,omitempty"`
+	PurchaseOption     string            `csv:"pricing/PurchaseOption"                                json:"purchaseOption,omitempty"`
+	ReservationArn     string            `csv:"reservation/ReservationARN"                            json:"reservationArn,omitempty"`
+	EffectiveCost      string            `csv:"reservation/EffectiveCost"                             json:"reservationEffectiveCost,omitempty"`
+	UnusedUpfrontFee   string            `csv:"reservation/UnusedAmortizedUpfrontFeeForBillingPeriod" json:"-"`
+	UnusedRecurringFee string            `csv:"reservation/UnusedRecurringFee"                        json:"-"`
+	SavingsPlanArn     string            `csv:"savingsPlan/SavingsPlanARN"                            json:"savingsPlanArn,omitempty"`
+	SavingsPlanCost    string            `csv:"savingsPlan/SavingsPlanEffectiveCost"                  json:"savingsPlanEffectiveCost,omitempty"`
+	SavingsPlanType    string            `csv:"savingsPlan/OfferingType"                              json:"savingsPlanOfferingType,omitempty"`
+	SavingsPlanTerm    string            `csv:"savingsPlan/PurchaseTerm"                              json:"savingsPlanPurchaseTerm,omitempty"`
+	SavingsPlanPayment string            `csv:"savingsPlan/PaymentOption"                             json:"savingsPlanPaymentOption,omitempty"`
+	TotalCommitment    string            `csv:"savingsPlan/TotalCommitmentToDate"                     json:"-"`
+	UsedCommitment     string            `csv:"savingsPlan/UsedCommitment"                            json:"-"`
+	AmortizedCost      float64           `csv:"-"                                                     json:"amortizedCost"`
+	Any                map[string]string `csv:",any"                                                  json:"-"`
+	Tags               []LineItemTags    `csv:"-"                                                     json:"tags,omitempty"`
 }
 
 type LineItemTags struct {
@@ -18,11 +18,6 @@ import (
 	"context"
 	"database/sql"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/organizations"
-
-	"github.com/trackit/trackit/config"
 	"github.com/trackit/trackit/models"
 )
 
@@ -46,47 +41,39 @@ func updateExistingAccount(ctx context.Context, aa AwsAccount, subs []AwsAccount
 	return nil
 }
 
-// getAwsSubAccounts gets the list of sub accounts in AWS for an aws account
-func getAwsSubAccounts(aa AwsAccount) ([]AwsAccount, error) {
-	creds, err := GetTemporaryCredentials(aa, "GetAwsSubAccounts")
-	if err != nil {
-		return nil, err
-	}
-	sess := session.Must(session.NewSession(&aws.Config{
-		Credentials: creds,
-		Region:      aws.String(config.AwsRegion),
-	}))
-	orga := organizations.New(sess)
-	res, err := orga.ListAccounts(nil)
-	if err != nil {
-		return nil, err
-	}
-	subAccounts := make([]AwsAccount, 0)
-	for _, account := range res.Accounts {
+// getAwsSubAccounts gets the list of sub accounts in the AWS organization
+// of an aws account
+func getAwsSubAccounts(aa AwsAccount, organization Organization) []AwsAccount {
+	subAccounts := make([]AwsAccount, 0, len(organization.Accounts))
+	for _, account := range organization.Accounts {
 		subAccounts = append(subAccounts, AwsAccount{
 			UserId:      aa.UserId,
-			Pretty:      aws.StringValue(account.Name),
+			Pretty:      account.Name,
 			RoleArn:     "",
 			External:    "",
 			Payer:       false,
-			AwsIdentity: aws.StringValue(account.Id),
+			AwsIdentity: account.Id,
 			ParentId:    sql.NullInt64{int64(aa.Id), true},
 		})
 	}
-	return subAccounts, err
+	return subAccounts
 }
 
-// PutSubAccounts gets AWS sub accounts of an aws accounts and puts it in DB if they don't already exists
+// PutSubAccounts discovers the AWS organization of an aws account, stores its
+// structure and puts its sub accounts in DB if they don't already exists
 func PutSubAccounts(ctx context.Context, account AwsAccount, tx *sql.Tx) error {
 	identity, err := account.GetAwsAccountIdentity()
 	if err != nil {
 		return err
 	}
 	account.AwsIdentity = identity
-	subAccounts, err := getAwsSubAccounts(account)
+	organization, err := getAwsOrganization(ctx, account)
 	if err != nil {
 		return err
+	} else if err := storeOrganization(tx, account, organization); err != nil {
+		return err
 	}
+	subAccounts := getAwsSubAccounts(account, organization)
 	alreadyAccounts, err := models.AwsAccountsByUserID(tx, account.UserId)
 	if err != nil {
 		return err
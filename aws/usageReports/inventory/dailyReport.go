@@ -0,0 +1,149 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package inventory
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/trackit/jsonlog"
+
+	taws "github.com/trackit/trackit/aws"
+	utils "github.com/trackit/trackit/aws/usageReports"
+	"github.com/trackit/trackit/config"
+)
+
+// globalRegion is the region in which the Resource Groups Tagging API lists
+// the global resources, such as CloudFront distributions.
+const globalRegion = "us-east-1"
+
+// resourceTypeFilters are the resources listed in each region, as filters
+// of the Resource Groups Tagging API.
+var resourceTypeFilters = []string{
+	"s3",
+	"dynamodb:table",
+	"ecs:service",
+	"eks:cluster",
+	"sqs",
+	"sns",
+	"elasticloadbalancing:loadbalancer",
+}
+
+// globalResourceTypeFilters are the global resources, which are listed in
+// globalRegion only.
+var globalResourceTypeFilters = []string{
+	"cloudfront:distribution",
+}
+
+// fetchDailyResourcesList sends in resourceChan the resources of a region
+// listed by the Resource Groups Tagging API. Only the resources which are or
+// were once tagged are listed.
+func fetchDailyResourcesList(ctx context.Context, creds *credentials.Credentials, region string, resourceChan chan Resource) error {
+	defer close(resourceChan)
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	sess := session.Must(session.NewSession(&aws.Config{
+		Credentials: creds,
+		Region:      aws.String(region),
+	}))
+	svc := resourcegroupstaggingapi.New(sess)
+	filters := resourceTypeFilters
+	if region == globalRegion {
+		filters = append(filters[:len(filters):len(filters)], globalResourceTypeFilters...)
+	}
+	err := svc.GetResourcesPages(&resourcegroupstaggingapi.GetResourcesInput{
+		ResourceTypeFilters: aws.StringSlice(filters),
+	}, func(page *resourcegroupstaggingapi.GetResourcesOutput, lastPage bool) bool {
+		for _, mapping := range page.ResourceTagMappingList {
+			arn := aws.StringValue(mapping.ResourceARN)
+			service, resourceType, id, ok := ParseArn(arn)
+			if !ok {
+				logger.Warning("Failed to parse resource ARN", arn)
+				continue
+			}
+			tags := make([]utils.Tag, 0, len(mapping.Tags))
+			for _, tag := range mapping.Tags {
+				tags = append(tags, utils.Tag{
+					Key:   aws.StringValue(tag.Key),
+					Value: aws.StringValue(tag.Value),
+				})
+			}
+			resourceChan <- Resource{
+				Arn:     arn,
+				Service: service,
+				Type:    resourceType,
+				Id:      id,
+				Region:  region,
+				Tags:    tags,
+			}
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Error when getting resources", map[string]interface{}{
+			"region": region,
+			"error":  err.Error(),
+		})
+	}
+	return err
+}
+
+// FetchDailyResources lists the resources of an AwsAccount and their tags
+// with the Resource Groups Tagging API to import them in ElasticSearch.
+func FetchDailyResources(ctx context.Context, awsAccount taws.AwsAccount) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Info("Fetching resource inventory", map[string]interface{}{"awsAccountId": awsAccount.Id})
+	creds, err := taws.GetTemporaryCredentials(awsAccount, MonitorInventoryStsSessionName)
+	if err != nil {
+		logger.Error("Error when getting temporary credentials", err.Error())
+		return err
+	}
+	defaultSession := session.Must(session.NewSession(&aws.Config{
+		Credentials: creds,
+		Region:      aws.String(config.AwsRegion),
+	}))
+	now := time.Now().UTC()
+	account, err := utils.GetAccountId(ctx, defaultSession)
+	if err != nil {
+		logger.Error("Error when getting account id", err.Error())
+		return err
+	}
+	regions, err := utils.FetchRegionsList(ctx, defaultSession)
+	if err != nil {
+		logger.Error("Error when fetching regions list", err.Error())
+		return err
+	}
+	resourceChans := make([]<-chan Resource, 0, len(regions))
+	for _, region := range regions {
+		resourceChan := make(chan Resource)
+		go fetchDailyResourcesList(ctx, creds, region, resourceChan)
+		resourceChans = append(resourceChans, resourceChan)
+	}
+	resources := make([]ResourceReport, 0)
+	for resource := range merge(resourceChans...) {
+		resources = append(resources, ResourceReport{
+			ReportBase: utils.ReportBase{
+				Account:    account,
+				ReportDate: now,
+				ReportType: "daily",
+			},
+			Resource: resource,
+		})
+	}
+	return importResourcesToEs(ctx, awsAccount, resources)
+}
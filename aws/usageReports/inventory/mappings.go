@@ -0,0 +1,97 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package inventory
+
+import (
+	"context"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/es"
+)
+
+const TypeInventoryReport = "inventory-report"
+const IndexPrefixInventoryReport = "inventory-reports"
+const TemplateNameInventoryReport = "inventory-reports"
+
+// put the ElasticSearch index for *-inventory-reports indices at startup.
+func init() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	res, err := es.Client.IndexPutTemplate(TemplateNameInventoryReport).BodyString(TemplateInventoryReport).Do(ctx)
+	if err != nil {
+		jsonlog.DefaultLogger.Error("Failed to put ES index InventoryReport.", err)
+	} else {
+		jsonlog.DefaultLogger.Info("Put ES index InventoryReport.", res)
+	}
+	ctxCancel()
+}
+
+const TemplateInventoryReport = `
+{
+	"template": "*-inventory-reports",
+	"version": 1,
+	"mappings": {
+		"inventory-report": {
+			"properties": {
+				"account": {
+					"type": "keyword"
+				},
+				"reportDate": {
+					"type": "date"
+				},
+				"reportType": {
+					"type": "keyword"
+				},
+				"resource": {
+					"properties": {
+						"arn": {
+							"type": "keyword"
+						},
+						"service": {
+							"type": "keyword"
+						},
+						"type": {
+							"type": "keyword"
+						},
+						"id": {
+							"type": "keyword"
+						},
+						"region": {
+							"type": "keyword"
+						},
+						"tags": {
+							"type": "nested",
+							"properties": {
+								"key": {
+									"type": "keyword"
+								},
+								"value": {
+									"type": "keyword"
+								}
+							}
+						}
+					}
+				}
+			},
+			"_all": {
+				"enabled": false
+			},
+			"numeric_detection": false,
+			"date_detection": false
+		}
+	}
+}
+`
@@ -0,0 +1,151 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package inventory
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	taws "github.com/trackit/trackit/aws"
+	utils "github.com/trackit/trackit/aws/usageReports"
+	"github.com/trackit/trackit/es"
+)
+
+const MonitorInventoryStsSessionName = "monitor-inventory"
+
+type (
+	// ResourceReport is saved in ES to have the tags of a resource listed by
+	// the Resource Groups Tagging API
+	ResourceReport struct {
+		utils.ReportBase
+		Resource Resource `json:"resource"`
+	}
+
+	// Resource contains the information of a resource parsed from its ARN,
+	// and its tags
+	Resource struct {
+		Arn string `json:"arn"`
+		// Service is the service of the resource, such as 'dynamodb'.
+		Service string `json:"service"`
+		// Type is the type of the resource within its service, such as
+		// 'table'. It is empty for the services with a single type of
+		// resource, such as S3 and SQS.
+		Type   string      `json:"type"`
+		Id     string      `json:"id"`
+		Region string      `json:"region"`
+		Tags   []utils.Tag `json:"tags"`
+	}
+)
+
+// ParseArn splits an ARN into the service, the type and the ID of its
+// resource. The resource part of an ARN is either its ID, or its type and ID
+// separated by a slash or a colon. It returns false if the ARN is malformed.
+func ParseArn(arn string) (service, resourceType, id string, ok bool) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] == "" || parts[5] == "" {
+		return "", "", "", false
+	}
+	service, resource := parts[2], parts[5]
+	if i := strings.IndexAny(resource, "/:"); i >= 0 {
+		return service, resource[:i], resource[i+1:], true
+	}
+	return service, "", resource, true
+}
+
+// importResourcesToEs imports the inventory of an AWS account in
+// ElasticSearch.
+func importResourcesToEs(ctx context.Context, aa taws.AwsAccount, resources []ResourceReport) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Info("Updating resource inventory for AWS account.", map[string]interface{}{
+		"awsAccount": aa,
+	})
+	index := es.IndexNameForUserId(aa.UserId, IndexPrefixInventoryReport)
+	bp, err := utils.GetBulkProcessor(ctx)
+	if err != nil {
+		logger.Error("Failed to get bulk processor.", err.Error())
+		return err
+	}
+	for _, resource := range resources {
+		id, err := generateId(resource)
+		if err != nil {
+			logger.Error("Error when marshaling resource var", err.Error())
+			return err
+		}
+		bp = utils.AddDocToBulkProcessor(bp, resource, TypeInventoryReport, index, id)
+	}
+	bp.Flush()
+	err = bp.Close()
+	if err != nil {
+		logger.Error("Fail to put resource inventory in ES", err.Error())
+		return err
+	}
+	logger.Info("Resource inventory put in ES", nil)
+	return nil
+}
+
+func generateId(resource ResourceReport) (string, error) {
+	ji, err := json.Marshal(struct {
+		Account    string    `json:"account"`
+		ReportDate time.Time `json:"reportDate"`
+		Id         string    `json:"id"`
+		Type       string    `json:"reportType"`
+	}{
+		resource.Account,
+		resource.ReportDate,
+		resource.Resource.Arn,
+		resource.ReportType,
+	})
+	if err != nil {
+		return "", err
+	}
+	hash := md5.Sum(ji)
+	hash64 := base64.URLEncoding.EncodeToString(hash[:])
+	return hash64, nil
+}
+
+// merge function from https://blog.golang.org/pipelines#TOC_4
+// It allows to merge many chans to one.
+func merge(cs ...<-chan Resource) <-chan Resource {
+	var wg sync.WaitGroup
+	out := make(chan Resource)
+
+	// Start an output goroutine for each input channel in cs. The output
+	// copies values from c to out until c is closed, then calls wg.Done.
+	output := func(c <-chan Resource) {
+		for n := range c {
+			out <- n
+		}
+		wg.Done()
+	}
+	wg.Add(len(cs))
+	for _, c := range cs {
+		go output(c)
+	}
+
+	// Start a goroutine to close out once all the output goroutines are
+	// done. This must start after the wg.Add call.
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
@@ -0,0 +1,40 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package inventory
+
+import "testing"
+
+func TestParseArn(t *testing.T) {
+	for _, test := range []struct {
+		arn, service, resourceType, id string
+		ok                             bool
+	}{
+		{"arn:aws:s3:::my-bucket", "s3", "", "my-bucket", true},
+		{"arn:aws:dynamodb:us-east-1:123456789012:table/Orders", "dynamodb", "table", "Orders", true},
+		{"arn:aws:ecs:us-east-1:123456789012:service/prod/api", "ecs", "service", "prod/api", true},
+		{"arn:aws:eks:eu-west-1:123456789012:cluster/main", "eks", "cluster", "main", true},
+		{"arn:aws:cloudfront::123456789012:distribution/E2QWRUHAPOMQZL", "cloudfront", "distribution", "E2QWRUHAPOMQZL", true},
+		{"arn:aws:sqs:us-east-1:123456789012:jobs", "sqs", "", "jobs", true},
+		{"arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/web/50dc6c495c0c9188", "elasticloadbalancing", "loadbalancer", "app/web/50dc6c495c0c9188", true},
+		{"arn:aws:lambda:us-east-1:123456789012:function:resize", "lambda", "function", "resize", true},
+		{"arn:aws:s3", "", "", "", false},
+		{"not:an:arn:at:all:really", "", "", "", false},
+	} {
+		service, resourceType, id, ok := ParseArn(test.arn)
+		if service != test.service || resourceType != test.resourceType || id != test.id || ok != test.ok {
+			t.Errorf("ARN %s: expected (%s, %s, %s, %v), got (%s, %s, %s, %v).", test.arn, test.service, test.resourceType, test.id, test.ok, service, resourceType, id, ok)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package billing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// azureColumns maps the fields of a line item to the normalized names of the
+// columns which may hold them in Azure cost exports, whose schema depends on
+// the type of the billing account.
+var azureColumns = map[string][]string{
+	"account":     {"subscriptionid", "subscriptionguid"},
+	"accountName": {"subscriptionname"},
+	"date":        {"date", "usagedatetime", "usagedate"},
+	"product":     {"metercategory", "servicename", "consumedservice"},
+	"usageType":   {"metername", "metersubcategory"},
+	"region":      {"resourcelocation", "location", "meterregion"},
+	"resource":    {"resourceid", "instanceid"},
+	"quantity":    {"quantity", "usagequantity", "consumedquantity"},
+	"unit":        {"unitofmeasure"},
+	"cost":        {"costinbillingcurrency", "pretaxcost", "cost"},
+	"currency":    {"billingcurrencycode", "billingcurrency", "currency"},
+	"chargeType":  {"chargetype"},
+	"invoice":     {"invoiceid"},
+	"tags":        {"tags"},
+}
+
+// parseAzureExport reads an Azure cost export file. Each row is the cost of
+// a resource for a day.
+func parseAzureExport(r io.Reader, format string, oli onLineItem) error {
+	return readCsv(r, func(row map[string]string) error {
+		li, err := azureLineItem(row)
+		if err != nil {
+			return err
+		}
+		return oli(li)
+	})
+}
+
+// azureColumn returns the value of a field of a line item in a row.
+func azureColumn(row map[string]string, field string) string {
+	value, _ := firstColumn(row, azureColumns[field]...)
+	return strings.TrimSpace(value)
+}
+
+// azureLineItem builds a LineItem from a row of an Azure cost export.
+func azureLineItem(row map[string]string) (li LineItem, err error) {
+	if _, ok := firstColumn(row, azureColumns["date"]...); !ok {
+		return li, fmt.Errorf("%s: date", ErrMissingColumn.Error())
+	}
+	date, err := parseTime(azureColumn(row, "date"))
+	if err != nil {
+		return
+	}
+	li = LineItem{
+		Provider:         ProviderAzure,
+		InvoiceId:        azureColumn(row, "invoice"),
+		UsageAccountId:   azureColumn(row, "account"),
+		UsageAccountName: azureColumn(row, "accountName"),
+		LineItemType:     azureColumn(row, "chargeType"),
+		UsageStartDate:   formatTime(date),
+		UsageEndDate:     formatTime(date.AddDate(0, 0, 1)),
+		ProductCode:      azureColumn(row, "product"),
+		UsageType:        azureColumn(row, "usageType"),
+		Region:           azureColumn(row, "region"),
+		ResourceId:       azureColumn(row, "resource"),
+		PricingUnit:      azureColumn(row, "unit"),
+		CurrencyCode:     azureColumn(row, "currency"),
+	}
+	if li.LineItemType == "" {
+		li.LineItemType = "Usage"
+	}
+	if li.UsageAmount, err = parseAmount(azureColumn(row, "quantity")); err != nil {
+		return
+	}
+	if li.UnblendedCost, err = parseAmount(azureColumn(row, "cost")); err != nil {
+		return
+	}
+	li.BlendedCost = li.UnblendedCost
+	li.NetUnblendedCost = li.UnblendedCost
+	li.AmortizedCost = li.UnblendedCost
+	li.Tags = parseAzureTags(azureColumn(row, "tags"))
+	return
+}
+
+// parseAzureTags parses the tags of a resource in an Azure cost export. They
+// are a JSON object, whose braces are omitted by some exports. Tags which
+// cannot be parsed are ignored rather than failing the whole export.
+func parseAzureTags(s string) []Tag {
+	if s == "" {
+		return nil
+	} else if !strings.HasPrefix(s, "{") {
+		s = "{" + s + "}"
+	}
+	var tagMap map[string]string
+	if err := json.Unmarshal([]byte(s), &tagMap); err != nil {
+		return nil
+	}
+	tags := make([]Tag, 0, len(tagMap))
+	for key, value := range tagMap {
+		tags = append(tags, Tag{key, value})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+	return tags
+}
@@ -0,0 +1,139 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package billing
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// azureBlobVersion is the version of the Azure Storage API used.
+const azureBlobVersion = "2019-12-12"
+
+// azureBlobHostSuffix is the suffix of the hosts of the Azure Storage blob
+// service. The locations are fetched by the server, so no other host is
+// allowed.
+const azureBlobHostSuffix = ".blob.core.windows.net"
+
+var ErrInvalidSasToken = errors.New("invalid Azure Storage SAS token")
+
+// azureBlobStorage reads the export files in an Azure Storage container.
+type azureBlobStorage struct {
+	host      string
+	container string
+	prefix    string
+	sas       string
+}
+
+// newAzureBlobStorage returns the storage of an
+// "https://account.blob.core.windows.net/container/prefix" location,
+// accessed with a SAS token.
+func newAzureBlobStorage(location string, credentials []byte) (storage, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme != "https" || u.User != nil || u.RawQuery != "" || !hasAzureBlobHost(u.Host) {
+		return nil, ErrInvalidLocation
+	}
+	path := strings.TrimPrefix(u.Path, "/")
+	container, prefix := path, ""
+	if i := strings.Index(path, "/"); i >= 0 {
+		container, prefix = path[:i], path[i+1:]
+	}
+	sas := strings.TrimPrefix(strings.TrimSpace(string(credentials)), "?")
+	if container == "" {
+		return nil, ErrInvalidLocation
+	} else if values, err := url.ParseQuery(sas); err != nil || values.Get("sig") == "" {
+		return nil, ErrInvalidSasToken
+	}
+	return azureBlobStorage{u.Host, container, prefix, sas}, nil
+}
+
+// hasAzureBlobHost returns true if host is that of an Azure Storage blob
+// service, without port.
+func hasAzureBlobHost(host string) bool {
+	name := strings.TrimSuffix(host, azureBlobHostSuffix)
+	return name != host && name != "" && !strings.ContainsAny(name, ".:@")
+}
+
+// get sends a GET request to the Azure Storage API.
+func (s azureBlobStorage) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	rawQuery := s.sas
+	if len(query) > 0 {
+		rawQuery = query.Encode() + "&" + s.sas
+	}
+	u := url.URL{
+		Scheme:   "https",
+		Host:     s.host,
+		Path:     path,
+		RawQuery: rawQuery,
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-version", azureBlobVersion)
+	return doRequest(req.WithContext(ctx))
+}
+
+func (s azureBlobStorage) list(ctx context.Context) (files []exportFile, err error) {
+	var page struct {
+		Blobs []struct {
+			Name         string `xml:"Name"`
+			LastModified string `xml:"Properties>Last-Modified"`
+		} `xml:"Blobs>Blob"`
+		NextMarker string `xml:"NextMarker"`
+	}
+	query := url.Values{
+		"restype": {"container"},
+		"comp":    {"list"},
+		"prefix":  {s.prefix},
+	}
+	for {
+		res, err := s.get(ctx, "/"+s.container, query)
+		if err != nil {
+			return nil, err
+		}
+		page.Blobs, page.NextMarker = nil, ""
+		err = xml.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, blob := range page.Blobs {
+			lastModified, err := time.Parse(time.RFC1123, blob.LastModified)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, exportFile{blob.Name, lastModified})
+		}
+		if page.NextMarker == "" {
+			return files, nil
+		}
+		query.Set("marker", page.NextMarker)
+	}
+}
+
+func (s azureBlobStorage) open(ctx context.Context, name string) (io.ReadCloser, error) {
+	res, err := s.get(ctx, "/"+s.container+"/"+name, nil)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
@@ -0,0 +1,232 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package billing ingests the billing exports of cloud providers other than
+// AWS. Their line items are converted to a provider-neutral model and stored
+// in the same ElasticSearch indices as the AWS Cost and Usage Reports, so
+// that the cost routes and the anomalies detection work across clouds.
+package billing
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// ProviderGcp is the provider of the line items read from Google Cloud
+	// billing exports.
+	ProviderGcp = "gcp"
+	// ProviderAzure is the provider of the line items read from Azure cost
+	// exports.
+	ProviderAzure = "azure"
+
+	// esDateFormat is the format of the dates of the line items in
+	// ElasticSearch.
+	esDateFormat = "2006-01-02T15:04:05Z"
+)
+
+var (
+	ErrUnknownProvider    = errors.New("unknown billing provider")
+	ErrUnsupportedFormat  = errors.New("unsupported export file format")
+	ErrMissingColumn      = errors.New("missing column in export file")
+	ErrInvalidLineItemRow = errors.New("invalid line item in export file")
+)
+
+// LineItem is a provider-neutral line item. Its fields are named after those
+// of the AWS line items so both can be queried alike.
+type LineItem struct {
+	Provider         string  `json:"provider"`
+	BillingSourceId  int     `json:"billingSourceId"`
+	SourceFile       string  `json:"sourceFile"`
+	LineItemId       string  `json:"lineItemId"`
+	InvoiceId        string  `json:"invoiceId"`
+	UsageAccountId   string  `json:"usageAccountId"`
+	UsageAccountName string  `json:"-"`
+	LineItemType     string  `json:"lineItemType"`
+	UsageStartDate   string  `json:"usageStartDate"`
+	UsageEndDate     string  `json:"usageEndDate"`
+	ProductCode      string  `json:"productCode"`
+	UsageType        string  `json:"usageType"`
+	Region           string  `json:"region"`
+	AvailabilityZone string  `json:"availabilityZone"`
+	ResourceId       string  `json:"resourceId"`
+	UsageAmount      float64 `json:"usageAmount"`
+	PricingUnit      string  `json:"pricingUnit,omitempty"`
+	CurrencyCode     string  `json:"currencyCode"`
+	UnblendedCost    float64 `json:"unblendedCost"`
+	BlendedCost      float64 `json:"blendedCost"`
+	NetUnblendedCost float64 `json:"netUnblendedCost"`
+	AmortizedCost    float64 `json:"amortizedCost"`
+	Tags             []Tag   `json:"tags,omitempty"`
+}
+
+// Tag is a label of a resource, stored like the tags of AWS line items.
+type Tag struct {
+	Key string `json:"key"`
+	Tag string `json:"tag"`
+}
+
+// onLineItem is called by parsers for each line item of an export file.
+type onLineItem func(LineItem) error
+
+// provider describes the export files of a cloud provider.
+type provider struct {
+	// formats are the extensions of the export files, which may also
+	// be gzipped.
+	formats []string
+	// cumulative is true if each export file of a directory holds the whole
+	// billing period so far, in which case only the latest one is read.
+	cumulative bool
+	// parse reads the line items of an export file of a given format.
+	parse func(r io.Reader, format string, oli onLineItem) error
+}
+
+var providers = map[string]provider{
+	ProviderGcp: {
+		formats: []string{".csv", ".json", ".jsonl"},
+		parse:   parseGcpExport,
+	},
+	ProviderAzure: {
+		formats:    []string{".csv"},
+		cumulative: true,
+		parse:      parseAzureExport,
+	},
+}
+
+// ValidProvider returns an error if name is not a provider whose billing
+// exports can be ingested.
+func ValidProvider(name string) error {
+	if _, ok := providers[name]; !ok {
+		return ErrUnknownProvider
+	}
+	return nil
+}
+
+// fileFormat returns the format of an export file from its name, and
+// whether it is gzipped. The format is empty if the provider does not
+// support it.
+func (p provider) fileFormat(name string) (format string, gzipped bool) {
+	name = strings.ToLower(name)
+	if strings.HasSuffix(name, ".gz") {
+		gzipped = true
+		name = strings.TrimSuffix(name, ".gz")
+	}
+	ext := path.Ext(name)
+	for _, f := range p.formats {
+		if f == ext {
+			return ext, gzipped
+		}
+	}
+	return "", false
+}
+
+// timeLayouts are the layouts accepted for dates in export files.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999 MST",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+	"01/02/2006",
+}
+
+// parseTime parses a date from an export file. Dates without a time zone are
+// in UTC.
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %q", s)
+}
+
+// parseAmount parses an amount from an export file. An empty amount is zero.
+func parseAmount(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// formatTime formats a date the way line items store it.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(esDateFormat)
+}
+
+// columnName normalizes the name of a column of a CSV export file, so that
+// "Usage Start Time", "usage.start_time" and "usageStartTime" all become
+// "usagestarttime".
+func columnName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// readCsv reads a CSV export file and calls onRow for each of its rows with
+// the values of the row mapped by normalized column name.
+func readCsv(r io.Reader, onRow func(row map[string]string) error) error {
+	reader := csv.NewReader(r)
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for i := range header {
+		header[i] = columnName(header[i])
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		row := make(map[string]string, len(header))
+		for i, value := range record {
+			if i < len(header) {
+				row[header[i]] = value
+			}
+		}
+		if err := onRow(row); err != nil {
+			return err
+		}
+	}
+}
+
+// firstColumn returns the value of the first of the columns present in a row.
+func firstColumn(row map[string]string, columns ...string) (string, bool) {
+	for _, column := range columns {
+		if value, ok := row[column]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
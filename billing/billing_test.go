@@ -0,0 +1,253 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package billing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trackit/trackit/config"
+)
+
+func parseAll(t *testing.T, p provider, format, content string) []LineItem {
+	var lineItems []LineItem
+	err := p.parse(strings.NewReader(content), format, func(li LineItem) error {
+		lineItems = append(lineItems, li)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse export: %s", err.Error())
+	}
+	return lineItems
+}
+
+const gcpJsonExport = `{"billing_account_id":"01A2B3-C4D5E6-F7G8H9","service":{"id":"6F81-5844-456A","description":"Compute Engine"},"sku":{"id":"2E27-4F75-95CD","description":"N1 Predefined Instance Core running in Americas"},"usage_start_time":"2020-03-01 10:00:00 UTC","usage_end_time":"2020-03-01 11:00:00 UTC","project":{"id":"my-project","name":"My Project"},"labels":[{"key":"env","value":"prod"}],"location":{"location":"us-central1","region":"us-central1","zone":"us-central1-a"},"cost":1.5,"currency":"USD","usage":{"amount":3600,"unit":"seconds"},"credits":[{"name":"Sustained use discount","amount":-0.25}],"cost_type":"regular","invoice":{"month":"202003"}}
+
+{"billing_account_id":"01A2B3-C4D5E6-F7G8H9","service":{"description":"Invoice"},"usage_start_time":"2020-03-01T00:00:00Z","cost":0.3,"currency":"USD","cost_type":"tax"}
+`
+
+func TestParseGcpJsonExport(t *testing.T) {
+	lineItems := parseAll(t, providers[ProviderGcp], ".jsonl", gcpJsonExport)
+	if len(lineItems) != 2 {
+		t.Fatalf("Expected 2 line items, got %d", len(lineItems))
+	}
+	expected := LineItem{
+		Provider:         ProviderGcp,
+		InvoiceId:        "202003",
+		UsageAccountId:   "my-project",
+		UsageAccountName: "My Project",
+		LineItemType:     "Usage",
+		UsageStartDate:   "2020-03-01T10:00:00Z",
+		UsageEndDate:     "2020-03-01T11:00:00Z",
+		ProductCode:      "Compute Engine",
+		UsageType:        "N1 Predefined Instance Core running in Americas",
+		Region:           "us-central1",
+		AvailabilityZone: "us-central1-a",
+		UsageAmount:      3600,
+		PricingUnit:      "seconds",
+		CurrencyCode:     "USD",
+		UnblendedCost:    1.5,
+		BlendedCost:      1.5,
+		NetUnblendedCost: 1.25,
+		AmortizedCost:    1.25,
+		Tags:             []Tag{{"env", "prod"}},
+	}
+	if !reflect.DeepEqual(lineItems[0], expected) {
+		t.Errorf("Expected %+v, got %+v", expected, lineItems[0])
+	}
+	if tax := lineItems[1]; tax.UsageAccountId != "01A2B3-C4D5E6-F7G8H9" || tax.LineItemType != "Tax" {
+		t.Errorf("Expected a tax of the billing account, got %+v", tax)
+	}
+}
+
+const gcpCsvExport = "Billing Account ID,Service.Description,SKU.Description,Usage Start Time,Usage End Time,Project.ID,Location.Region,Cost,Currency,Usage.Amount,Credits,Labels\n" +
+	"01A2B3-C4D5E6-F7G8H9,Cloud Storage,Standard Storage US Multi-region,2020-03-02T00:00:00Z,2020-03-03T00:00:00Z,my-project,us,2.00,USD,10,-0.5,\"[{\"\"key\"\":\"\"team\"\",\"\"value\"\":\"\"data\"\"}]\"\n"
+
+func TestParseGcpCsvExport(t *testing.T) {
+	lineItems := parseAll(t, providers[ProviderGcp], ".csv", gcpCsvExport)
+	if len(lineItems) != 1 {
+		t.Fatalf("Expected 1 line item, got %d", len(lineItems))
+	}
+	li := lineItems[0]
+	if li.ProductCode != "Cloud Storage" || li.UsageAccountId != "my-project" || li.Region != "us" {
+		t.Errorf("Unexpected line item %+v", li)
+	}
+	if li.UnblendedCost != 2 || li.NetUnblendedCost != 1.5 || li.UsageAmount != 10 {
+		t.Errorf("Unexpected costs in line item %+v", li)
+	}
+	if !reflect.DeepEqual(li.Tags, []Tag{{"team", "data"}}) {
+		t.Errorf("Unexpected tags %+v", li.Tags)
+	}
+}
+
+const azureCsvExport = "\ufeffInvoiceSectionName,AccountName,SubscriptionId,SubscriptionName,Date,MeterCategory,MeterSubCategory,MeterName,ResourceLocation,ResourceId,Quantity,UnitOfMeasure,CostInBillingCurrency,BillingCurrencyCode,ChargeType,Tags\n" +
+	"Default,Contoso,00000000-0000-0000-0000-000000000001,Production,03/04/2020,Virtual Machines,Dv3/DSv3 Series,D2 v3/D2s v3,EastUS,/subscriptions/00000000-0000-0000-0000-000000000001/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm1,24,1 Hour,2.304,USD,Usage,\"\"\"env\"\": \"\"prod\"\",\"\"app\"\": \"\"web\"\"\"\n" +
+	"Default,Contoso,00000000-0000-0000-0000-000000000001,Production,03/04/2020,Storage,,LRS Data Stored,EastUS,,1.5,1 GB/Month,0.03,USD,,\n"
+
+func TestParseAzureExport(t *testing.T) {
+	lineItems := parseAll(t, providers[ProviderAzure], ".csv", azureCsvExport)
+	if len(lineItems) != 2 {
+		t.Fatalf("Expected 2 line items, got %d", len(lineItems))
+	}
+	expected := LineItem{
+		Provider:         ProviderAzure,
+		UsageAccountId:   "00000000-0000-0000-0000-000000000001",
+		UsageAccountName: "Production",
+		LineItemType:     "Usage",
+		UsageStartDate:   "2020-03-04T00:00:00Z",
+		UsageEndDate:     "2020-03-05T00:00:00Z",
+		ProductCode:      "Virtual Machines",
+		UsageType:        "D2 v3/D2s v3",
+		Region:           "EastUS",
+		ResourceId:       "/subscriptions/00000000-0000-0000-0000-000000000001/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm1",
+		UsageAmount:      24,
+		PricingUnit:      "1 Hour",
+		CurrencyCode:     "USD",
+		UnblendedCost:    2.304,
+		BlendedCost:      2.304,
+		NetUnblendedCost: 2.304,
+		AmortizedCost:    2.304,
+		Tags:             []Tag{{"app", "web"}, {"env", "prod"}},
+	}
+	if !reflect.DeepEqual(lineItems[0], expected) {
+		t.Errorf("Expected %+v, got %+v", expected, lineItems[0])
+	}
+	if li := lineItems[1]; li.LineItemType != "Usage" || li.Tags != nil || li.UnblendedCost != 0.03 {
+		t.Errorf("Unexpected line item %+v", li)
+	}
+}
+
+func TestParseAzureExportMissingDate(t *testing.T) {
+	err := parseAzureExport(strings.NewReader("SubscriptionId,Cost\nabc,1\n"), ".csv", func(LineItem) error { return nil })
+	if err == nil {
+		t.Error("Expected an error for an export without dates")
+	}
+}
+
+func TestSelectFiles(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 3, d, 0, 0, 0, 0, time.UTC) }
+	files := []exportFile{
+		{"exports/daily/20200301-20200331/daily_1.csv", day(2)},
+		{"exports/daily/20200301-20200331/daily_2.csv.gz", day(4)},
+		{"exports/daily/20200201-20200229/daily_0.csv", day(1)},
+		{"exports/daily/20200301-20200331/manifest.json", day(5)},
+	}
+	selected := selectFiles(providers[ProviderAzure], files, day(1))
+	if len(selected) != 1 {
+		t.Fatalf("Expected 1 file, got %+v", selected)
+	}
+	if f := selected[0]; f.Name != "exports/daily/20200301-20200331/daily_2.csv.gz" || f.key != "exports/daily/20200301-20200331" {
+		t.Errorf("Unexpected file %+v", f)
+	}
+	selected = selectFiles(providers[ProviderGcp], files, time.Time{})
+	if len(selected) != 4 || selected[0].LastModified != day(1) || selected[3].key != "exports/daily/20200301-20200331/manifest.json" {
+		t.Errorf("Unexpected files %+v", selected)
+	}
+}
+
+func TestFileFormat(t *testing.T) {
+	cases := []struct {
+		name    string
+		format  string
+		gzipped bool
+	}{
+		{"export.csv", ".csv", false},
+		{"export.JSONL.gz", ".jsonl", true},
+		{"export.parquet", "", false},
+	}
+	for _, c := range cases {
+		if format, gzipped := providers[ProviderGcp].fileFormat(c.name); format != c.format || gzipped != c.gzipped {
+			t.Errorf("Expected %q to have format %q (gzipped: %v), got %q (%v)", c.name, c.format, c.gzipped, format, gzipped)
+		}
+	}
+}
+
+func TestValidRemoteLocation(t *testing.T) {
+	cases := []struct {
+		location    string
+		credentials string
+		expected    error
+	}{
+		{"https://account.blob.core.windows.net/exports/daily", "?sv=2019-12-12&sr=c&sp=rl&sig=c2lnbmF0dXJl", nil},
+		{"https://account.blob.core.windows.net/exports", "sv=2019-12-12&sr=c&sp=rl", ErrInvalidSasToken},
+		{"https://example.com/exports", "sv=2019-12-12&sig=c2lnbmF0dXJl", ErrInvalidLocation},
+		{"https://169.254.169.254/latest", "sv=2019-12-12&sig=c2lnbmF0dXJl", ErrInvalidLocation},
+		{"https://account.blob.core.chinacloudapi.cn/exports", "sv=2019-12-12&sig=c2lnbmF0dXJl", ErrInvalidLocation},
+		{"https://account.blob.core.windows.net:8080/exports", "sv=2019-12-12&sig=c2lnbmF0dXJl", ErrInvalidLocation},
+		{"https://user@account.blob.core.windows.net/exports", "sv=2019-12-12&sig=c2lnbmF0dXJl", ErrInvalidLocation},
+		{"https://evil.com?.blob.core.windows.net/exports", "sv=2019-12-12&sig=c2lnbmF0dXJl", ErrInvalidLocation},
+		{"http://account.blob.core.windows.net/exports", "sv=2019-12-12&sig=c2lnbmF0dXJl", ErrInvalidLocation},
+		{"https://account.blob.core.windows.net/", "sv=2019-12-12&sig=c2lnbmF0dXJl", ErrInvalidLocation},
+		{"gs://bucket/prefix", `{"client_email": "billing@project.iam.gserviceaccount.com"}`, ErrInvalidServiceAccountKey},
+		{"gs://", "{}", ErrInvalidLocation},
+	}
+	for _, c := range cases {
+		if err := ValidLocation(1, c.location, []byte(c.credentials)); err != c.expected {
+			t.Errorf("Expected %v for location %q, got %v", c.expected, c.location, err)
+		}
+	}
+}
+
+func TestLocalLocation(t *testing.T) {
+	defer func(dir string) { config.BillingSourcesDir = dir }(config.BillingSourcesDir)
+	config.BillingSourcesDir = "/var/lib/trackit/billing"
+	cases := []struct {
+		location string
+		root     string
+		expected error
+	}{
+		{"exports", "/var/lib/trackit/billing/42/exports", nil},
+		{"", "/var/lib/trackit/billing/42", nil},
+		{"../43/exports", "", ErrInvalidLocation},
+		{"..", "", ErrInvalidLocation},
+		{"exports/../../43", "", ErrInvalidLocation},
+		{"..exports", "/var/lib/trackit/billing/42/..exports", nil},
+	}
+	for _, c := range cases {
+		st, err := getStorage(42, c.location, nil)
+		if err != c.expected {
+			t.Errorf("Expected %v for location %q, got %v", c.expected, c.location, err)
+		} else if err == nil && st.(localStorage).root != c.root {
+			t.Errorf("Expected root %q for location %q, got %q", c.root, c.location, st.(localStorage).root)
+		}
+	}
+}
+
+func TestCredentialsEncryption(t *testing.T) {
+	defer func(key string) { config.BillingSourcesCredentialsKey = key }(config.BillingSourcesCredentialsKey)
+	config.BillingSourcesCredentialsKey = ""
+	if _, err := encryptCredentials([]byte("sv=2019-12-12&sig=c2lnbmF0dXJl")); err != ErrNoCredentialsKey {
+		t.Errorf("Expected %v without a key, got %v", ErrNoCredentialsKey, err)
+	}
+	config.BillingSourcesCredentialsKey = "secret"
+	encrypted, err := encryptCredentials([]byte("sv=2019-12-12&sig=c2lnbmF0dXJl"))
+	if err != nil {
+		t.Fatal(err)
+	} else if strings.Contains(string(encrypted), "c2lnbmF0dXJl") {
+		t.Errorf("Expected the credentials to be encrypted, got %q", encrypted)
+	}
+	if credentials, err := decryptCredentials(encrypted); err != nil || string(credentials) != "sv=2019-12-12&sig=c2lnbmF0dXJl" {
+		t.Errorf("Expected the credentials to be decrypted, got %q (%v)", credentials, err)
+	}
+	if credentials, err := decryptCredentials([]byte("sv=legacy")); err != nil || string(credentials) != "sv=legacy" {
+		t.Errorf("Expected credentials saved before encryption to be kept, got %q (%v)", credentials, err)
+	}
+	config.BillingSourcesCredentialsKey = "other"
+	if _, err := decryptCredentials(encrypted); err != ErrUndecryptableCredentials {
+		t.Errorf("Expected %v with another key, got %v", ErrUndecryptableCredentials, err)
+	}
+}
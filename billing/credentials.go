@@ -0,0 +1,104 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package billing
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"github.com/trackit/trackit/config"
+	"github.com/trackit/trackit/models"
+)
+
+// encryptedCredentialsPrefix prefixes the encrypted credentials stored in the
+// database. Credentials without it were saved before they were encrypted.
+const encryptedCredentialsPrefix = "aes-256-gcm:"
+
+var (
+	ErrNoCredentialsKey         = errors.New("no key is configured to encrypt the billing source credentials")
+	ErrUndecryptableCredentials = errors.New("billing source credentials cannot be decrypted with the configured key")
+)
+
+// credentialsCipher returns the cipher the credentials are encrypted with,
+// keyed with the SHA-256 hash of config.BillingSourcesCredentialsKey.
+func credentialsCipher() (cipher.AEAD, error) {
+	if config.BillingSourcesCredentialsKey == "" {
+		return nil, ErrNoCredentialsKey
+	}
+	key := sha256.Sum256([]byte(config.BillingSourcesCredentialsKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptCredentials encrypts credentials to be stored in the database.
+// Empty credentials stay empty.
+func encryptCredentials(credentials []byte) ([]byte, error) {
+	if len(credentials) == 0 {
+		return nil, nil
+	}
+	aead, err := credentialsCipher()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	res := append([]byte(encryptedCredentialsPrefix), nonce...)
+	return aead.Seal(res, nonce, credentials, nil), nil
+}
+
+// decryptCredentials decrypts credentials stored in the database. Credentials
+// saved before they were encrypted are returned as they are.
+func decryptCredentials(stored []byte) ([]byte, error) {
+	if !bytes.HasPrefix(stored, []byte(encryptedCredentialsPrefix)) {
+		return stored, nil
+	}
+	aead, err := credentialsCipher()
+	if err != nil {
+		return nil, err
+	}
+	sealed := stored[len(encryptedCredentialsPrefix):]
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrUndecryptableCredentials
+	}
+	credentials, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrUndecryptableCredentials
+	}
+	return credentials, nil
+}
+
+// SealCredentials encrypts the credentials of a billing source saved before
+// they were encrypted. The caller is responsible for saving the source.
+func SealCredentials(source *models.BillingSource) error {
+	if len(source.Credentials) == 0 || bytes.HasPrefix(source.Credentials, []byte(encryptedCredentialsPrefix)) {
+		return nil
+	}
+	credentials, err := encryptCredentials(source.Credentials)
+	if err != nil {
+		return err
+	}
+	source.Credentials = credentials
+	return nil
+}
@@ -0,0 +1,209 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package billing
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// gcpLineItemTypes maps the cost types of Google Cloud billing exports to
+// line item types.
+var gcpLineItemTypes = map[string]string{
+	"":               "Usage",
+	"regular":        "Usage",
+	"tax":            "Tax",
+	"adjustment":     "Adjustment",
+	"rounding_error": "Rounding",
+}
+
+// gcpRecord is a row of a Google Cloud billing export, as exported from
+// BigQuery.
+type gcpRecord struct {
+	BillingAccountId string `json:"billing_account_id"`
+	Service          struct {
+		Description string `json:"description"`
+	} `json:"service"`
+	Sku struct {
+		Description string `json:"description"`
+	} `json:"sku"`
+	UsageStartTime string `json:"usage_start_time"`
+	UsageEndTime   string `json:"usage_end_time"`
+	Project        struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"project"`
+	Labels   []gcpLabel `json:"labels"`
+	Location struct {
+		Location string `json:"location"`
+		Region   string `json:"region"`
+		Zone     string `json:"zone"`
+	} `json:"location"`
+	Resource struct {
+		Name string `json:"name"`
+	} `json:"resource"`
+	Cost     float64 `json:"cost"`
+	Currency string  `json:"currency"`
+	Usage    struct {
+		Amount float64 `json:"amount"`
+		Unit   string  `json:"unit"`
+	} `json:"usage"`
+	Credits  []gcpCredit `json:"credits"`
+	CostType string      `json:"cost_type"`
+	Invoice  struct {
+		Month string `json:"month"`
+	} `json:"invoice"`
+}
+
+type gcpLabel struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type gcpCredit struct {
+	Amount float64 `json:"amount"`
+}
+
+// parseGcpExport reads a Google Cloud billing export file. JSON files hold
+// one record per line, as exported from BigQuery. CSV files hold the same
+// fields, flattened: "service.description" or "service_description" for
+// instance. Their repeated fields, labels and credits, are JSON encoded, and
+// credits may also be a total amount.
+func parseGcpExport(r io.Reader, format string, oli onLineItem) error {
+	if format == ".csv" {
+		return readCsv(r, func(row map[string]string) error {
+			record, err := gcpRecordFromCsv(row)
+			if err != nil {
+				return err
+			}
+			return emitGcpRecord(record, oli)
+		})
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var record gcpRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return fmt.Errorf("%s: %s", ErrInvalidLineItemRow.Error(), err.Error())
+		}
+		if err := emitGcpRecord(record, oli); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// gcpRecordFromCsv builds a gcpRecord from a row of a CSV export file.
+func gcpRecordFromCsv(row map[string]string) (record gcpRecord, err error) {
+	var ok bool
+	if record.UsageStartTime, ok = row["usagestarttime"]; !ok {
+		return record, fmt.Errorf("%s: usage_start_time", ErrMissingColumn.Error())
+	}
+	if record.Cost, err = parseAmount(row["cost"]); err != nil {
+		return
+	}
+	if record.Usage.Amount, err = parseAmount(row["usageamount"]); err != nil {
+		return
+	}
+	record.BillingAccountId = row["billingaccountid"]
+	record.Service.Description = row["servicedescription"]
+	record.Sku.Description = row["skudescription"]
+	record.UsageEndTime = row["usageendtime"]
+	record.Project.Id = row["projectid"]
+	record.Project.Name = row["projectname"]
+	record.Location.Location = row["locationlocation"]
+	record.Location.Region = row["locationregion"]
+	record.Location.Zone = row["locationzone"]
+	record.Resource.Name = row["resourcename"]
+	record.Currency = row["currency"]
+	record.Usage.Unit = row["usageunit"]
+	record.CostType = row["costtype"]
+	record.Invoice.Month = row["invoicemonth"]
+	// Labels which cannot be parsed are ignored rather than failing the
+	// whole export.
+	if labels := strings.TrimSpace(row["labels"]); labels != "" {
+		json.Unmarshal([]byte(labels), &record.Labels)
+	}
+	if credits := strings.TrimSpace(row["credits"]); strings.HasPrefix(credits, "[") {
+		err = json.Unmarshal([]byte(credits), &record.Credits)
+	} else if credits != "" {
+		var amount float64
+		amount, err = parseAmount(credits)
+		record.Credits = []gcpCredit{{amount}}
+	}
+	return
+}
+
+// emitGcpRecord converts a gcpRecord to a LineItem and passes it to oli.
+func emitGcpRecord(record gcpRecord, oli onLineItem) error {
+	start, err := parseTime(record.UsageStartTime)
+	if err != nil {
+		return err
+	}
+	end := start
+	if record.UsageEndTime != "" {
+		if end, err = parseTime(record.UsageEndTime); err != nil {
+			return err
+		}
+	}
+	li := LineItem{
+		Provider:         ProviderGcp,
+		InvoiceId:        record.Invoice.Month,
+		UsageAccountId:   record.Project.Id,
+		UsageAccountName: record.Project.Name,
+		LineItemType:     gcpLineItemTypes[record.CostType],
+		UsageStartDate:   formatTime(start),
+		UsageEndDate:     formatTime(end),
+		ProductCode:      record.Service.Description,
+		UsageType:        record.Sku.Description,
+		Region:           record.Location.Region,
+		AvailabilityZone: record.Location.Zone,
+		ResourceId:       record.Resource.Name,
+		UsageAmount:      record.Usage.Amount,
+		PricingUnit:      record.Usage.Unit,
+		CurrencyCode:     record.Currency,
+		UnblendedCost:    record.Cost,
+		BlendedCost:      record.Cost,
+		NetUnblendedCost: record.Cost,
+	}
+	// Charges which are not bound to a project, such as taxes or support,
+	// are attributed to the billing account.
+	if li.UsageAccountId == "" {
+		li.UsageAccountId = record.BillingAccountId
+	}
+	if li.LineItemType == "" {
+		li.LineItemType = record.CostType
+	}
+	if li.Region == "" {
+		li.Region = record.Location.Location
+	}
+	// Committed use discounts and promotions are credits, so the net cost
+	// is also the amortized one.
+	for _, credit := range record.Credits {
+		li.NetUnblendedCost += credit.Amount
+	}
+	li.AmortizedCost = li.NetUnblendedCost
+	for _, label := range record.Labels {
+		li.Tags = append(li.Tags, Tag{label.Key, label.Value})
+	}
+	return oli(li)
+}
@@ -0,0 +1,175 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	gcsScheme   = "gs://"
+	gcsApiUrl   = "https://storage.googleapis.com/storage/v1/b/"
+	gcsScope    = "https://www.googleapis.com/auth/devstorage.read_only"
+	gcpTokenUrl = "https://oauth2.googleapis.com/token"
+)
+
+var ErrInvalidServiceAccountKey = errors.New("invalid Google Cloud service account key")
+
+// gcpServiceAccountKey is the JSON key of a Google Cloud service account.
+type gcpServiceAccountKey struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+}
+
+// gcsStorage reads the export files in a Google Cloud Storage bucket.
+type gcsStorage struct {
+	bucket      string
+	prefix      string
+	clientEmail string
+	privateKey  interface{}
+	token       string
+	expires     time.Time
+}
+
+// newGcsStorage returns the storage of a "gs://bucket/prefix" location,
+// accessed with a service account key.
+func newGcsStorage(location string, credentials []byte) (storage, error) {
+	var key gcpServiceAccountKey
+	path := strings.TrimPrefix(location, gcsScheme)
+	bucket, prefix := path, ""
+	if i := strings.Index(path, "/"); i >= 0 {
+		bucket, prefix = path[:i], path[i+1:]
+	}
+	if bucket == "" {
+		return nil, ErrInvalidLocation
+	} else if err := json.Unmarshal(credentials, &key); err != nil || key.ClientEmail == "" {
+		return nil, ErrInvalidServiceAccountKey
+	} else if privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(key.PrivateKey)); err != nil {
+		return nil, ErrInvalidServiceAccountKey
+	} else {
+		return &gcsStorage{
+			bucket:      bucket,
+			prefix:      prefix,
+			clientEmail: key.ClientEmail,
+			privateKey:  privateKey,
+		}, nil
+	}
+}
+
+// accessToken returns an OAuth2 access token for the service account,
+// exchanging a signed JWT for a new one when the previous one expired.
+func (s *gcsStorage) accessToken(ctx context.Context) (string, error) {
+	if s.token != "" && time.Now().Before(s.expires) {
+		return s.token, nil
+	}
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   s.clientEmail,
+		"scope": gcsScope,
+		"aud":   gcpTokenUrl,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(s.privateKey)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequest(http.MethodPost, gcpTokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := doRequest(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	s.token = token.AccessToken
+	s.expires = now.Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return s.token, nil
+}
+
+// get sends an authenticated GET request to the Google Cloud Storage API.
+func (s *gcsStorage) get(ctx context.Context, rawurl string) (*http.Response, error) {
+	token, err := s.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, rawurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return doRequest(req.WithContext(ctx))
+}
+
+func (s *gcsStorage) list(ctx context.Context) (files []exportFile, err error) {
+	var page struct {
+		Items []struct {
+			Name    string    `json:"name"`
+			Updated time.Time `json:"updated"`
+		} `json:"items"`
+		NextPageToken string `json:"nextPageToken"`
+	}
+	query := url.Values{"prefix": {s.prefix}}
+	for {
+		res, err := s.get(ctx, gcsApiUrl+url.PathEscape(s.bucket)+"/o?"+query.Encode())
+		if err != nil {
+			return nil, err
+		}
+		page.Items, page.NextPageToken = nil, ""
+		err = json.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			if !strings.HasSuffix(item.Name, "/") {
+				files = append(files, exportFile{item.Name, item.Updated})
+			}
+		}
+		if page.NextPageToken == "" {
+			return files, nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+func (s *gcsStorage) open(ctx context.Context, name string) (io.ReadCloser, error) {
+	res, err := s.get(ctx, gcsApiUrl+url.PathEscape(s.bucket)+"/o/"+url.PathEscape(name)+"?alt=media")
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
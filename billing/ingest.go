@@ -0,0 +1,137 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package billing
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/olivere/elastic"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/aws/s3"
+	"github.com/trackit/trackit/aws/usageReports"
+	"github.com/trackit/trackit/es"
+	"github.com/trackit/trackit/models"
+)
+
+// UpdateSource ingests the export files of a billing source which were
+// modified since its last import. It returns the modification date of the
+// latest file ingested and the accounts found in the files, mapped to their
+// names. Files ingested before an error are taken into account.
+func UpdateSource(ctx context.Context, source models.BillingSource) (lastImported time.Time, accounts map[string]string, err error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Info("Updating billing source.", map[string]interface{}{
+		"billingSourceId": source.ID,
+		"provider":        source.Provider,
+	})
+	lastImported = source.LastImported
+	accounts = make(map[string]string)
+	p, ok := providers[source.Provider]
+	if !ok {
+		return lastImported, accounts, ErrUnknownProvider
+	}
+	credentials, err := decryptCredentials(source.Credentials)
+	if err != nil {
+		return lastImported, accounts, err
+	}
+	st, err := getStorage(source.UserID, source.Location, credentials)
+	if err != nil {
+		return lastImported, accounts, err
+	}
+	files, err := st.list(ctx)
+	if err != nil {
+		return lastImported, accounts, err
+	}
+	bp, err := utils.GetBulkProcessor(ctx)
+	if err != nil {
+		logger.Error("Failed to get bulk processor.", err.Error())
+		return lastImported, accounts, err
+	}
+	defer func() {
+		bp.Flush()
+		bp.Close()
+	}()
+	index := es.IndexNameForUserId(source.UserID, s3.IndexPrefixLineItem)
+	for _, f := range selectFiles(p, files, source.LastImported) {
+		logger.Info("Ingesting billing export file.", map[string]interface{}{
+			"billingSourceId": source.ID,
+			"file":            f.Name,
+		})
+		if err = ingestFile(ctx, bp, st, p, source, f, index, accounts); err != nil {
+			return lastImported, accounts, fmt.Errorf("%s: %s", f.Name, err.Error())
+		}
+		lastImported = f.LastModified
+	}
+	logger.Info("Done ingesting data.", nil)
+	return lastImported, accounts, nil
+}
+
+// ingestFile replaces the line items stored under the key of an export file
+// by those read from the file.
+func ingestFile(ctx context.Context, bp *elastic.BulkProcessor, st storage, p provider, source models.BillingSource, f sourceFile, index string, accounts map[string]string) error {
+	if err := es.CleanByBillingSourceFile(ctx, source.UserID, source.ID, f.key); err != nil && !elastic.IsNotFound(err) {
+		return err
+	}
+	reader, err := st.open(ctx, f.Name)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	var r io.Reader = reader
+	format, gzipped := p.fileFormat(f.Name)
+	if gzipped {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		r = gzipReader
+	}
+	row := 0
+	return p.parse(r, format, func(li LineItem) error {
+		li.BillingSourceId = source.ID
+		li.SourceFile = f.key
+		li.LineItemId = lineItemId(source.ID, f.key, row)
+		row++
+		if li.LineItemType == "Tax" {
+			li.AvailabilityZone = "taxes"
+			li.Region = "taxes"
+		}
+		if li.UsageAccountId != "" && accounts[li.UsageAccountId] == "" {
+			accounts[li.UsageAccountId] = li.UsageAccountName
+		}
+		rq := elastic.NewBulkIndexRequest()
+		rq = rq.Index(index)
+		rq = rq.Type(s3.TypeLineItem)
+		rq = rq.Id(li.LineItemId)
+		rq = rq.Doc(li)
+		bp.Add(rq)
+		return ctx.Err()
+	})
+}
+
+// lineItemId generates the ID of the line item read from a row of an export
+// file. Since the line items of a file are removed before it is ingested
+// again, the position of the row is enough to identify it.
+func lineItemId(billingSourceId int, key string, row int) string {
+	hash := md5.Sum([]byte(fmt.Sprintf("%d/%s/%d", billingSourceId, key, row)))
+	return base64.URLEncoding.EncodeToString(hash[:])
+}
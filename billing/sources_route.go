@@ -0,0 +1,331 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package billing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/cache"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/es"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+type (
+	// SourceBody is the body required to create or edit a billing source.
+	// The credentials are the JSON key of a service account for Google
+	// Cloud Storage locations and a SAS token for Azure Storage locations.
+	// They are kept if left empty on edition, and are stored encrypted with
+	// config.BillingSourcesCredentialsKey.
+	SourceBody struct {
+		Provider    string `json:"provider" req:"nonzero"`
+		Name        string `json:"name" req:"nonzero"`
+		Location    string `json:"location" req:"nonzero"`
+		Credentials string `json:"credentials"`
+	}
+
+	// Source is a billing source as returned by the routes. Its credentials
+	// are never returned.
+	Source struct {
+		Id             int             `json:"id"`
+		Provider       string          `json:"provider"`
+		Name           string          `json:"name"`
+		Location       string          `json:"location"`
+		HasCredentials bool            `json:"hasCredentials"`
+		LastImported   time.Time       `json:"lastImported"`
+		NextUpdate     time.Time       `json:"nextUpdate"`
+		Error          string          `json:"error"`
+		Accounts       []SourceAccount `json:"accounts"`
+	}
+
+	// SourceAccount is an account found in the exports of a billing source:
+	// a Google Cloud project or an Azure subscription.
+	SourceAccount struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	}
+)
+
+func init() {
+	sourceExample := SourceBody{
+		Provider: ProviderGcp,
+		Name:     "GCP billing export",
+		Location: "gs://my-billing-bucket/exports",
+	}
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getSources).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			routes.Documentation{
+				Summary:     "get the billing sources",
+				Description: "Responds with the billing sources of the user and the accounts found in their exports",
+			},
+		),
+		http.MethodPost: routes.H(postSource).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{sourceExample},
+			routes.Documentation{
+				Summary:     "create a billing source",
+				Description: "Creates a billing source based on the body. Its exports are ingested on its first update",
+			},
+		),
+		http.MethodPut: routes.H(putSource).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{sourceExample},
+			routes.QueryArgs{routes.BillingSourceIdQueryArg},
+			routes.Documentation{
+				Summary:     "edit a billing source",
+				Description: "Edits a billing source based on the body. Its exports are ingested again if its provider or location changed",
+			},
+		),
+		http.MethodDelete: routes.H(deleteSource).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.QueryArgs{routes.BillingSourceIdQueryArg},
+			routes.Documentation{
+				Summary:     "delete a billing source",
+				Description: "Deletes a billing source and the line items read from its exports",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{Db: db.Db},
+		routes.Documentation{
+			Summary:     "interact with the billing sources",
+			Description: "A billing source is a location where a cloud provider other than AWS exports its billing data: Google Cloud billing exports or Azure cost exports. Its line items are ingested alongside those of the AWS accounts.",
+		},
+	).Register("/billing/sources")
+}
+
+// sourceFromDbSource builds a Source from its database representation.
+func sourceFromDbSource(dbSource *models.BillingSource, dbAccounts []*models.BillingSourceAccount) Source {
+	source := Source{
+		Id:             dbSource.ID,
+		Provider:       dbSource.Provider,
+		Name:           dbSource.Name,
+		Location:       dbSource.Location,
+		HasCredentials: len(dbSource.Credentials) > 0,
+		LastImported:   dbSource.LastImported,
+		NextUpdate:     dbSource.NextUpdate,
+		Error:          dbSource.Error,
+		Accounts:       []SourceAccount{},
+	}
+	for _, dbAccount := range dbAccounts {
+		if dbAccount.BillingSourceID == dbSource.ID {
+			source.Accounts = append(source.Accounts, SourceAccount{dbAccount.AccountID, dbAccount.Name})
+		}
+	}
+	return source
+}
+
+// getSources is a route handler which returns the billing sources of the
+// user.
+func getSources(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbSources, err := models.BillingSourcesByUserID(tx, user.Id)
+	if err != nil {
+		l.Error("Failed to get billing sources", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve billing sources.")
+	}
+	dbAccounts, err := models.BillingSourceAccountsByUserID(tx, user.Id)
+	if err != nil {
+		l.Error("Failed to get billing source accounts", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve billing sources.")
+	}
+	sources := make([]Source, len(dbSources))
+	for i, dbSource := range dbSources {
+		sources[i] = sourceFromDbSource(dbSource, dbAccounts)
+	}
+	return http.StatusOK, sources
+}
+
+// postSource is a route handler which creates a billing source.
+func postSource(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body SourceBody
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbSource := &models.BillingSource{
+		UserID:  user.Id,
+		Created: time.Now(),
+	}
+	return saveSource(r, tx, dbSource, body)
+}
+
+// putSource is a route handler which edits a billing source.
+func putSource(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body SourceBody
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbSource, code, err := getUserSource(r, tx, user, a[routes.BillingSourceIdQueryArg].(int))
+	if err != nil {
+		return code, err
+	}
+	return saveSource(r, tx, dbSource, body)
+}
+
+// deleteSource is a route handler which deletes a billing source and the line
+// items read from its exports.
+func deleteSource(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbSource, code, err := getUserSource(r, tx, user, a[routes.BillingSourceIdQueryArg].(int))
+	if err != nil {
+		return code, err
+	}
+	accounts, err := getSourceAccountIds(tx, dbSource)
+	if err != nil {
+		l.Error("Failed to get billing source accounts", map[string]interface{}{
+			"billingSourceId": dbSource.ID,
+			"error":           err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to delete billing source.")
+	}
+	if err := dbSource.Delete(tx); err != nil {
+		l.Error("Failed to delete billing source", map[string]interface{}{
+			"billingSourceId": dbSource.ID,
+			"error":           err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to delete billing source.")
+	}
+	cleanSource(l, dbSource, accounts)
+	return http.StatusOK, nil
+}
+
+// getUserSource retrieves a billing source owned by user.
+func getUserSource(r *http.Request, tx *sql.Tx, user users.User, sourceId int) (*models.BillingSource, int, error) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	dbSource, err := models.BillingSourceByID(tx, sourceId)
+	if err == sql.ErrNoRows || (err == nil && dbSource.UserID != user.Id) {
+		return nil, http.StatusNotFound, errors.New("Billing source not found.")
+	} else if err != nil {
+		l.Error("Failed to get billing source", map[string]interface{}{
+			"billingSourceId": sourceId,
+			"error":           err.Error(),
+		})
+		return nil, http.StatusInternalServerError, errors.New("Failed to retrieve billing source.")
+	}
+	return dbSource, http.StatusOK, nil
+}
+
+// getSourceAccountIds returns the IDs of the accounts found in the exports of
+// a billing source.
+func getSourceAccountIds(tx *sql.Tx, dbSource *models.BillingSource) ([]string, error) {
+	dbAccounts, err := models.BillingSourceAccountsByBillingSourceID(tx, dbSource.ID)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]string, len(dbAccounts))
+	for i, dbAccount := range dbAccounts {
+		accounts[i] = dbAccount.AccountID
+	}
+	return accounts, nil
+}
+
+// cleanSource removes the line items read from the exports of a billing
+// source, along with the cached responses of the routes using them.
+func cleanSource(l jsonlog.Logger, dbSource *models.BillingSource, accounts []string) {
+	if err := es.CleanByBillingSourceId(context.Background(), dbSource.UserID, dbSource.ID); err != nil {
+		l.Error("Failed to remove billing source line items", map[string]interface{}{
+			"billingSourceId": dbSource.ID,
+			"error":           err.Error(),
+		})
+	}
+	_ = cache.Invalidate(cache.EventBillingData, accounts, l)
+}
+
+// saveSource validates a billing source and saves it in the database. The
+// exports of the source are ingested again from scratch if its provider or
+// its location changed.
+func saveSource(r *http.Request, tx *sql.Tx, dbSource *models.BillingSource, body SourceBody) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	credentials, err := decryptCredentials(dbSource.Credentials)
+	if body.Credentials != "" {
+		credentials, err = []byte(body.Credentials), nil
+	}
+	if err != nil {
+		l.Error("Failed to decrypt billing source credentials", map[string]interface{}{
+			"billingSourceId": dbSource.ID,
+			"error":           err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to save billing source.")
+	} else if err := ValidProvider(body.Provider); err != nil {
+		return http.StatusBadRequest, err
+	} else if err := ValidLocation(dbSource.UserID, body.Location, credentials); err != nil {
+		return http.StatusBadRequest, err
+	}
+	encryptedCredentials, err := encryptCredentials(credentials)
+	if err == ErrNoCredentialsKey {
+		return http.StatusBadRequest, err
+	} else if err != nil {
+		l.Error("Failed to encrypt billing source credentials", map[string]interface{}{
+			"userId": dbSource.UserID,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to save billing source.")
+	}
+	var accounts []string
+	reset := dbSource.Exists() && (dbSource.Provider != body.Provider || dbSource.Location != body.Location)
+	if reset {
+		if accounts, err = getSourceAccountIds(tx, dbSource); err == nil {
+			err = models.DeleteBillingSourceAccounts(tx, dbSource.ID)
+		}
+		if err != nil {
+			l.Error("Failed to reset billing source accounts", map[string]interface{}{
+				"billingSourceId": dbSource.ID,
+				"error":           err.Error(),
+			})
+			return http.StatusInternalServerError, errors.New("Failed to save billing source.")
+		}
+		dbSource.LastImported = time.Unix(0, 0).UTC()
+		dbSource.NextUpdate = time.Unix(0, 0).UTC()
+		dbSource.Error = ""
+	} else if !dbSource.Exists() {
+		dbSource.LastImported = time.Unix(0, 0).UTC()
+		dbSource.NextUpdate = time.Unix(0, 0).UTC()
+	}
+	dbSource.Provider = body.Provider
+	dbSource.Name = body.Name
+	dbSource.Location = body.Location
+	dbSource.Credentials = encryptedCredentials
+	if err := dbSource.Save(tx); err != nil {
+		l.Error("Failed to save billing source", map[string]interface{}{
+			"userId": dbSource.UserID,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to save billing source.")
+	}
+	if reset {
+		cleanSource(l, dbSource, accounts)
+	}
+	return http.StatusOK, sourceFromDbSource(dbSource, nil)
+}
@@ -0,0 +1,168 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trackit/trackit/config"
+)
+
+var (
+	ErrLocalSourcesDisabled = errors.New("local billing sources are disabled")
+	ErrInvalidLocation      = errors.New("invalid billing source location")
+
+	httpClient = http.Client{}
+)
+
+// exportFile is an export file found in the location of a billing source.
+type exportFile struct {
+	Name         string
+	LastModified time.Time
+}
+
+// sourceFile is an export file to ingest, with the key its line items are
+// stored under: its name, or its directory for cumulative exports.
+type sourceFile struct {
+	exportFile
+	key string
+}
+
+// storage gives access to the export files in the location of a billing
+// source.
+type storage interface {
+	// list returns the files under the location.
+	list(ctx context.Context) ([]exportFile, error)
+	// open opens a file returned by list.
+	open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// getStorage returns the storage of a location of a user: "gs://bucket/prefix"
+// for a Google Cloud Storage bucket, the URL of an Azure Storage container for
+// an Azure Storage account, or a directory relative to the directory of the
+// user in config.BillingSourcesDir. The credentials are a service account key
+// for Google Cloud Storage and a SAS token for Azure Storage.
+func getStorage(userId int, location string, credentials []byte) (storage, error) {
+	switch {
+	case strings.HasPrefix(location, gcsScheme):
+		return newGcsStorage(location, credentials)
+	case strings.HasPrefix(location, "https://"):
+		return newAzureBlobStorage(location, credentials)
+	case strings.Contains(location, "://"):
+		return nil, ErrInvalidLocation
+	default:
+		return newLocalStorage(userId, location)
+	}
+}
+
+// ValidLocation returns an error if the location or the credentials of a
+// billing source of a user are invalid. It does not check they give access to
+// export files.
+func ValidLocation(userId int, location string, credentials []byte) error {
+	_, err := getStorage(userId, location, credentials)
+	return err
+}
+
+// localStorage reads the export files in a directory of the local disk.
+type localStorage struct {
+	root string
+}
+
+// newLocalStorage returns the storage of a directory, relative to the
+// directory of a user in config.BillingSourcesDir, named after their ID,
+// which it cannot leave. The users cannot read the exports of each other.
+func newLocalStorage(userId int, location string) (storage, error) {
+	if config.BillingSourcesDir == "" {
+		return nil, ErrLocalSourcesDisabled
+	}
+	userDir := filepath.Join(config.BillingSourcesDir, strconv.Itoa(userId))
+	root := filepath.Join(userDir, location)
+	if rel, err := filepath.Rel(userDir, root); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, ErrInvalidLocation
+	}
+	return localStorage{root}, nil
+}
+
+func (s localStorage) list(ctx context.Context) (files []exportFile, err error) {
+	err = filepath.Walk(s.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		} else if info.Mode().IsRegular() {
+			rel, _ := filepath.Rel(s.root, path)
+			files = append(files, exportFile{filepath.ToSlash(rel), info.ModTime()})
+		}
+		return ctx.Err()
+	})
+	return
+}
+
+func (s localStorage) open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.root, filepath.FromSlash(name)))
+}
+
+// doRequest sends a request and returns its response, or an error if its
+// status is not 200.
+func doRequest(req *http.Request) (*http.Response, error) {
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		res.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Host, res.Status, strings.TrimSpace(string(body)))
+	}
+	return res, nil
+}
+
+// selectFiles selects the export files of a provider modified after a given
+// date, oldest first. Only the latest file of each directory is selected for
+// cumulative exports.
+func selectFiles(p provider, files []exportFile, after time.Time) []sourceFile {
+	selected := make(map[string]exportFile)
+	for _, f := range files {
+		if format, _ := p.fileFormat(f.Name); format == "" {
+			continue
+		}
+		key := f.Name
+		if p.cumulative {
+			key = path.Dir(f.Name)
+		}
+		if latest, ok := selected[key]; !ok || f.LastModified.After(latest.LastModified) {
+			selected[key] = f
+		}
+	}
+	res := make([]sourceFile, 0, len(selected))
+	for key, f := range selected {
+		if f.LastModified.After(after) {
+			res = append(res, sourceFile{f, key})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].LastModified.Before(res[j].LastModified)
+	})
+	return res
+}
@@ -0,0 +1,203 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package budgets
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/olivere/elastic"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/aws/s3"
+	"github.com/trackit/trackit/es"
+	"github.com/trackit/trackit/mail"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/users"
+)
+
+// mysqlErrDuplicateEntry is the number of the MySQL error returned when an
+// insertion violates a unique key.
+const mysqlErrDuplicateEntry = 1062
+
+// CheckBudgets compares every budget with the actual and forecast spend of
+// its current period, and emails the owner of the budget when thresholds are
+// crossed. Each threshold triggers a single alert per period: the alerts are
+// claimed in the database before they are sent, so that concurrent checks do
+// not send them twice.
+func CheckBudgets(ctx context.Context, db *sql.DB) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	dbBudgets, err := models.Budgets(db)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, dbBudget := range dbBudgets {
+		if err := checkBudget(ctx, db, dbBudget, now); err != nil {
+			logger.Error("Failed to check budget.", map[string]interface{}{
+				"budgetId": dbBudget.ID,
+				"error":    err.Error(),
+			})
+		}
+	}
+	return nil
+}
+
+// checkBudget checks a single budget at date now.
+func checkBudget(ctx context.Context, db *sql.DB, dbBudget *models.Budget, now time.Time) error {
+	budget, err := budgetFromDbBudget(dbBudget)
+	if err != nil {
+		return err
+	}
+	begin, end := periodBounds(budget.Period, now)
+	user, alerts, spend, forecast, err := claimAlerts(ctx, db, dbBudget, budget, begin, end, now)
+	if err != nil || len(alerts) == 0 {
+		return err
+	}
+	if err := sendAlerts(ctx, user, budget, begin, spend, forecast, alerts); err != nil {
+		releaseAlerts(ctx, db, alerts)
+		return err
+	}
+	return nil
+}
+
+// claimAlerts inserts the alerts for the thresholds of a budget crossed
+// during the period starting at begin, and commits them. Alerts which another
+// check claimed first are left out.
+func claimAlerts(ctx context.Context, db *sql.DB, dbBudget *models.Budget, budget Budget, begin, end, now time.Time) (user users.User, claimed []models.BudgetAlert, spend, forecast float64, err error) {
+	var tx *sql.Tx
+	if tx, err = db.BeginTx(ctx, nil); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+	if user, err = users.GetUserWithId(tx, dbBudget.UserID); err != nil {
+		return
+	} else if spend, err = getBudgetSpend(ctx, tx, user, budget, begin, end); err != nil {
+		return
+	}
+	forecast = linearForecast(spend, begin, end, now)
+	alerts, err := getNewAlerts(tx, budget, begin, spend, forecast)
+	if err != nil {
+		return
+	}
+	for _, alert := range alerts {
+		if err = alert.Insert(tx); err == nil {
+			claimed = append(claimed, alert)
+		} else if isDuplicateEntry(err) {
+			err = nil
+		} else {
+			return
+		}
+	}
+	return
+}
+
+// releaseAlerts deletes alerts which could not be sent, so that the next
+// check sends them again.
+func releaseAlerts(ctx context.Context, db *sql.DB, alerts []models.BudgetAlert) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	for _, alert := range alerts {
+		if err := alert.Delete(db); err != nil {
+			logger.Error("Failed to release budget alert.", map[string]interface{}{
+				"budgetId":  alert.BudgetID,
+				"threshold": alert.Threshold,
+				"error":     err.Error(),
+			})
+		}
+	}
+}
+
+// isDuplicateEntry tells whether an insertion failed because of a unique
+// key.
+func isDuplicateEntry(err error) bool {
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	return ok && mysqlErr.Number == mysqlErrDuplicateEntry
+}
+
+// getBudgetSpend retrieves the spend within the scope of a budget between
+// begin and end.
+func getBudgetSpend(ctx context.Context, tx *sql.Tx, user users.User, budget Budget, begin, end time.Time) (float64, error) {
+	accountsAndIndexes, _, err := es.GetAccountsAndIndexes(budget.Scope.Accounts, user, tx, s3.IndexPrefixLineItem)
+	if err != nil {
+		return 0, err
+	}
+	index := strings.Join(accountsAndIndexes.Indexes, ",")
+	costField, err := s3.GetCostTypeField(budget.CostType)
+	if err != nil {
+		return 0, err
+	}
+	res, err := getSpendElasticSearchParams(accountsAndIndexes.Accounts, budget.Scope, begin, end, costField, es.Client, index).Do(ctx)
+	if elastic.IsNotFound(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if cost, found := res.Aggregations.Sum("cost"); found && cost.Value != nil {
+		return *cost.Value, nil
+	}
+	return 0, nil
+}
+
+// getNewAlerts returns the alerts for the thresholds of a budget crossed by
+// the actual or forecast spend which were not already sent during the period.
+// A threshold crossed by the actual spend does not trigger a forecast alert.
+func getNewAlerts(tx *sql.Tx, budget Budget, periodBegin time.Time, spend, forecast float64) ([]models.BudgetAlert, error) {
+	var alerts []models.BudgetAlert
+	for _, threshold := range budget.Thresholds {
+		limit := budget.Amount * float64(threshold) / 100
+		if spend < limit && forecast < limit {
+			continue
+		}
+		isForecast := spend < limit
+		_, err := models.BudgetAlertByBudgetIDPeriodBeginThresholdForecast(tx, budget.Id, periodBegin, threshold, isForecast)
+		if err == sql.ErrNoRows {
+			alerts = append(alerts, models.BudgetAlert{
+				BudgetID:    budget.Id,
+				PeriodBegin: periodBegin,
+				Threshold:   threshold,
+				Forecast:    isForecast,
+				Sent:        time.Now(),
+			})
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return alerts, nil
+}
+
+// sendAlerts emails the owner of a budget about the thresholds it crossed.
+func sendAlerts(ctx context.Context, user users.User, budget Budget, periodBegin time.Time, spend, forecast float64, alerts []models.BudgetAlert) error {
+	subject := fmt.Sprintf("Your TrackIt budget \"%s\" crossed a threshold", budget.Name)
+	body := fmt.Sprintf("Your %s budget \"%s\" of $%.2f for the period starting on %s:\n",
+		budget.Period, budget.Name, budget.Amount, periodBegin.Format("2006-01-02"))
+	for _, alert := range alerts {
+		if alert.Forecast {
+			body += fmt.Sprintf("- is forecast to reach %d%% with a spend of $%.2f by the end of the period.\n", alert.Threshold, forecast)
+		} else {
+			body += fmt.Sprintf("- has reached %d%% with a spend of $%.2f.\n", alert.Threshold, spend)
+		}
+	}
+	return mail.SendMail(user.Email, subject, body, ctx)
+}
@@ -0,0 +1,142 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package budgets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/trackit/trackit/aws/s3"
+	"github.com/trackit/trackit/models"
+)
+
+const (
+	// PeriodMonthly is the period of a budget reset every month.
+	PeriodMonthly = "monthly"
+	// PeriodQuarterly is the period of a budget reset every quarter.
+	PeriodQuarterly = "quarterly"
+)
+
+// DefaultThresholds are the percentages of a budget which trigger an alert
+// when none are specified.
+var DefaultThresholds = []int{50, 80, 100}
+
+type (
+	// Scope restricts the costs accounted in a budget. Empty fields do not
+	// restrict anything.
+	Scope struct {
+		Accounts  []string `json:"accounts"`
+		Products  []string `json:"products"`
+		TagKey    string   `json:"tagKey"`
+		TagValues []string `json:"tagValues"`
+	}
+
+	// Budget is a spending limit over a monthly or quarterly period.
+	// Thresholds are percentages of Amount which trigger an alert when the
+	// actual or forecast spend crosses them. CostType is the type of cost
+	// accounted, as in the costType argument of the cost routes, and
+	// defaults to s3.DefaultCostType.
+	Budget struct {
+		Id         int     `json:"id"`
+		Name       string  `json:"name" req:"nonzero"`
+		Period     string  `json:"period" req:"nonzero"`
+		Amount     float64 `json:"amount" req:"nonzero"`
+		CostType   string  `json:"costType"`
+		Scope      Scope   `json:"scope"`
+		Thresholds []int   `json:"thresholds"`
+	}
+)
+
+// Valid checks that a budget can be saved.
+func (b Budget) Valid() error {
+	if b.Period != PeriodMonthly && b.Period != PeriodQuarterly {
+		return fmt.Errorf("invalid period : %s", b.Period)
+	}
+	if b.Amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	if _, err := s3.GetCostTypeField(b.CostType); err != nil {
+		return err
+	}
+	if len(b.Scope.TagValues) > 0 && b.Scope.TagKey == "" {
+		return errors.New("tag values require a tag key")
+	}
+	for _, threshold := range b.Thresholds {
+		if threshold <= 0 {
+			return fmt.Errorf("invalid threshold : %d", threshold)
+		}
+	}
+	return nil
+}
+
+// periodBounds returns the beginning and the end of the period of a budget
+// which contains date.
+func periodBounds(period string, date time.Time) (time.Time, time.Time) {
+	date = date.UTC()
+	month := date.Month()
+	length := 1
+	if period == PeriodQuarterly {
+		month = (month-1)/3*3 + 1
+		length = 3
+	}
+	begin := time.Date(date.Year(), month, 1, 0, 0, 0, 0, time.UTC)
+	return begin, begin.AddDate(0, length, 0)
+}
+
+// linearForecast forecasts the spend at the end of the period from the spend
+// at date, assuming the spending rate stays the same.
+func linearForecast(spend float64, begin, end, date time.Time) float64 {
+	elapsed := date.Sub(begin)
+	if elapsed < 24*time.Hour {
+		elapsed = 24 * time.Hour
+	}
+	return spend * float64(end.Sub(begin)) / float64(elapsed)
+}
+
+// budgetFromDbBudget builds a Budget from its database representation.
+func budgetFromDbBudget(dbBudget *models.Budget) (Budget, error) {
+	budget := Budget{
+		Id:       dbBudget.ID,
+		Name:     dbBudget.Name,
+		Period:   dbBudget.Period,
+		Amount:   dbBudget.Amount,
+		CostType: dbBudget.CostType,
+	}
+	if err := json.Unmarshal(dbBudget.Scope, &budget.Scope); err != nil {
+		return budget, err
+	}
+	err := json.Unmarshal(dbBudget.Thresholds, &budget.Thresholds)
+	return budget, err
+}
+
+// updateDbBudget sets the fields of the database representation of a budget.
+func updateDbBudget(dbBudget *models.Budget, budget Budget) (err error) {
+	if budget.Thresholds == nil {
+		budget.Thresholds = DefaultThresholds
+	}
+	dbBudget.Name = budget.Name
+	dbBudget.Period = budget.Period
+	dbBudget.Amount = budget.Amount
+	if dbBudget.CostType = budget.CostType; dbBudget.CostType == "" {
+		dbBudget.CostType = s3.DefaultCostType
+	}
+	if dbBudget.Scope, err = json.Marshal(budget.Scope); err != nil {
+		return
+	}
+	dbBudget.Thresholds, err = json.Marshal(budget.Thresholds)
+	return
+}
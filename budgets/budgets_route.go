@@ -0,0 +1,209 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package budgets
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/aws/s3"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/es"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+var budgetExample = Budget{
+	Name:     "Production",
+	Period:   PeriodMonthly,
+	Amount:   1000,
+	CostType: s3.DefaultCostType,
+	Scope: Scope{
+		Accounts:  []string{"123456789012"},
+		Products:  []string{"AmazonEC2", "AmazonRDS"},
+		TagKey:    "environment",
+		TagValues: []string{"production"},
+	},
+	Thresholds: DefaultThresholds,
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getBudgets).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			routes.Documentation{
+				Summary:     "get the budgets",
+				Description: "Responds with the budgets of the user",
+			},
+		),
+		http.MethodPost: routes.H(postBudget).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{budgetExample},
+			routes.Documentation{
+				Summary:     "create a budget",
+				Description: "Creates a budget based on the body",
+			},
+		),
+		http.MethodPut: routes.H(putBudget).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{budgetExample},
+			routes.QueryArgs{routes.BudgetIdQueryArg},
+			routes.Documentation{
+				Summary:     "edit a budget",
+				Description: "Edits a budget based on the body",
+			},
+		),
+		http.MethodDelete: routes.H(deleteBudget).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.QueryArgs{routes.BudgetIdQueryArg},
+			routes.Documentation{
+				Summary:     "delete a budget",
+				Description: "Deletes a budget",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{Db: db.Db},
+		routes.Documentation{
+			Summary:     "interact with the budgets",
+			Description: "A budget is a monthly or quarterly spending limit scoped to AWS accounts, products or tag values. Its owner is emailed when the actual or forecast spend crosses its thresholds.",
+		},
+	).Register("/budgets")
+}
+
+// getBudgets is a route handler which returns the budgets of the user.
+func getBudgets(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbBudgets, err := models.BudgetsByUserID(tx, user.Id)
+	if err != nil {
+		l.Error("Failed to get budgets", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve budgets.")
+	}
+	budgets := make([]Budget, 0, len(dbBudgets))
+	for _, dbBudget := range dbBudgets {
+		budget, err := budgetFromDbBudget(dbBudget)
+		if err != nil {
+			l.Error("Failed to unmarshal budget", map[string]interface{}{
+				"budgetId": dbBudget.ID,
+				"error":    err.Error(),
+			})
+			return http.StatusInternalServerError, errors.New("Failed to retrieve budgets.")
+		}
+		budgets = append(budgets, budget)
+	}
+	return http.StatusOK, budgets
+}
+
+// postBudget is a route handler which creates a budget.
+func postBudget(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body Budget
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbBudget := &models.Budget{
+		UserID: user.Id,
+	}
+	return saveBudget(r, tx, user, dbBudget, body)
+}
+
+// putBudget is a route handler which edits a budget.
+func putBudget(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body Budget
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbBudget, code, err := getUserBudget(r, tx, user, a[routes.BudgetIdQueryArg].(int))
+	if err != nil {
+		return code, err
+	}
+	return saveBudget(r, tx, user, dbBudget, body)
+}
+
+// deleteBudget is a route handler which deletes a budget.
+func deleteBudget(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbBudget, code, err := getUserBudget(r, tx, user, a[routes.BudgetIdQueryArg].(int))
+	if err != nil {
+		return code, err
+	}
+	if err := dbBudget.Delete(tx); err != nil {
+		l.Error("Failed to delete budget", map[string]interface{}{
+			"budgetId": dbBudget.ID,
+			"error":    err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to delete budget.")
+	}
+	return http.StatusOK, nil
+}
+
+// getUserBudget retrieves a budget owned by user.
+func getUserBudget(r *http.Request, tx *sql.Tx, user users.User, budgetId int) (*models.Budget, int, error) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	dbBudget, err := models.BudgetByID(tx, budgetId)
+	if err == sql.ErrNoRows || (err == nil && dbBudget.UserID != user.Id) {
+		return nil, http.StatusNotFound, errors.New("Budget not found.")
+	} else if err != nil {
+		l.Error("Failed to get budget", map[string]interface{}{
+			"budgetId": budgetId,
+			"error":    err.Error(),
+		})
+		return nil, http.StatusInternalServerError, errors.New("Failed to retrieve budget.")
+	}
+	return dbBudget, http.StatusOK, nil
+}
+
+// saveBudget validates a budget and saves it in the database.
+func saveBudget(r *http.Request, tx *sql.Tx, user users.User, dbBudget *models.Budget, budget Budget) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	if err := budget.Valid(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if len(budget.Scope.Accounts) > 0 {
+		if _, code, err := es.GetAccountsAndIndexes(budget.Scope.Accounts, user, tx, s3.IndexPrefixLineItem); err != nil {
+			return code, err
+		}
+	}
+	if err := updateDbBudget(dbBudget, budget); err != nil {
+		l.Error("Failed to marshal budget", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to save budget.")
+	}
+	if err := dbBudget.Save(tx); err != nil {
+		l.Error("Failed to save budget", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to save budget.")
+	}
+	res, err := budgetFromDbBudget(dbBudget)
+	if err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to save budget.")
+	}
+	return http.StatusOK, res
+}
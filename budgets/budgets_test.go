@@ -0,0 +1,73 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package budgets
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodBounds(t *testing.T) {
+	date := time.Date(2020, time.May, 17, 13, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		period string
+		begin  time.Time
+		end    time.Time
+	}{
+		{PeriodMonthly, time.Date(2020, time.May, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{PeriodQuarterly, time.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, time.July, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		begin, end := periodBounds(tc.period, date)
+		if !begin.Equal(tc.begin) || !end.Equal(tc.end) {
+			t.Errorf("%s: expected [%s, %s), got [%s, %s)", tc.period, tc.begin, tc.end, begin, end)
+		}
+	}
+}
+
+func TestLinearForecast(t *testing.T) {
+	begin := time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2020, time.July, 1, 0, 0, 0, 0, time.UTC)
+	if forecast := linearForecast(100, begin, end, begin.AddDate(0, 0, 10)); forecast != 300 {
+		t.Errorf("Expected a forecast of 300, got %f", forecast)
+	}
+	if forecast := linearForecast(10, begin, end, begin.Add(time.Hour)); forecast != 300 {
+		t.Errorf("Expected the first day to count as a whole day, got %f", forecast)
+	}
+}
+
+func TestBudgetValid(t *testing.T) {
+	budget := Budget{Name: "Test", Period: PeriodMonthly, Amount: 100}
+	if err := budget.Valid(); err != nil {
+		t.Errorf("Expected budget to be valid, got %s", err)
+	}
+	budget.Period = "weekly"
+	if budget.Valid() == nil {
+		t.Error("Expected a weekly budget to be invalid")
+	}
+	budget.Period = PeriodQuarterly
+	budget.Scope.TagValues = []string{"production"}
+	if budget.Valid() == nil {
+		t.Error("Expected tag values without a tag key to be invalid")
+	}
+	budget.Scope.TagValues = nil
+	budget.CostType = "amortized"
+	if err := budget.Valid(); err != nil {
+		t.Errorf("Expected an amortized budget to be valid, got %s", err)
+	}
+	budget.CostType = "discounted"
+	if budget.Valid() == nil {
+		t.Error("Expected an unknown cost type to be invalid")
+	}
+}
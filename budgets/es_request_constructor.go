@@ -0,0 +1,69 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package budgets
+
+import (
+	"time"
+
+	"github.com/olivere/elastic"
+)
+
+// toInterfaces converts a []string into a []interface{} suitable for a
+// *elastic.TermsQuery.
+func toInterfaces(values []string) []interface{} {
+	res := make([]interface{}, len(values))
+	for i, v := range values {
+		res[i] = v
+	}
+	return res
+}
+
+// createQueryScopeFilter creates and returns a new *elastic.BoolQuery matching
+// the line items within the scope of a budget between durationBegin and
+// durationEnd.
+func createQueryScopeFilter(accountList []string, scope Scope, durationBegin, durationEnd time.Time) *elastic.BoolQuery {
+	query := elastic.NewBoolQuery()
+	query = query.Filter(elastic.NewTermsQuery("usageAccountId", toInterfaces(accountList)...))
+	query = query.Filter(elastic.NewRangeQuery("usageStartDate").From(durationBegin).To(durationEnd).IncludeUpper(false))
+	if len(scope.Products) > 0 {
+		query = query.Filter(elastic.NewTermsQuery("productCode", toInterfaces(scope.Products)...))
+	}
+	if scope.TagKey != "" {
+		tagQuery := elastic.NewBoolQuery().Filter(elastic.NewTermQuery("tags.key", scope.TagKey))
+		if len(scope.TagValues) > 0 {
+			tagQuery = tagQuery.Filter(elastic.NewTermsQuery("tags.tag", toInterfaces(scope.TagValues)...))
+		}
+		query = query.Filter(elastic.NewNestedQuery("tags", tagQuery))
+	}
+	return query
+}
+
+// getSpendElasticSearchParams is used to construct an ElasticSearch *elastic.SearchService
+// used to retrieve the spend of the line items within the scope of a budget.
+// It takes as parameters :
+//   - accountList []string : A slice of strings representing the AWS account numbers the budget applies to
+//   - scope Scope : The scope of the budget, restricting the products and tags
+//   - durationBegin time.Time : A time.Time struct representing the beginning of the time range in the query
+//   - durationEnd time.Time : A time.Time struct representing the end of the time range in the query, excluded
+//   - costField string : The line item field holding the cost to sum, as returned by s3.GetCostTypeField
+//   - client *elastic.Client : an instance of *elastic.Client that represent an Elastic Search client.
+//   - index string : The Elastic Search index on which to execute the query.
+func getSpendElasticSearchParams(accountList []string, scope Scope, durationBegin, durationEnd time.Time,
+	costField string, client *elastic.Client, index string) *elastic.SearchService {
+	query := createQueryScopeFilter(accountList, scope, durationBegin, durationEnd)
+	search := client.Search().Index(index).Size(0).Query(query)
+	search.Aggregation("cost", elastic.NewSumAggregation().Field(costField))
+	return search
+}
@@ -0,0 +1,129 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package cache
+
+import (
+	"strings"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/config"
+)
+
+// Backend stores the cached responses of the routes.
+type Backend interface {
+	// Get returns the value stored for key and whether it was found.
+	Get(key string) ([]byte, bool, error)
+	// Set stores value for key during ttl.
+	Set(key string, value []byte, ttl time.Duration) error
+	// Delete removes the value stored for key.
+	Delete(key string) error
+	// DeleteMatching removes the values whose key matches pattern, in
+	// which '*' matches any sequence of characters.
+	DeleteMatching(pattern string) error
+}
+
+// backends are the available backends, by name.
+var backends = map[string]func() (Backend, error){
+	"redis":  newRedisBackend,
+	"memory": newMemoryBackend,
+	"none":   newNoopBackend,
+}
+
+// backend is the backend used by UsersCache.
+var backend Backend
+
+// routeTtls are the cache durations overriding the default one, by route.
+var routeTtls map[string]time.Duration
+
+func init() {
+	routeTtls = parseRouteTtls(config.CacheRouteTtls)
+	newBackend, ok := backends[config.CacheBackend]
+	if !ok {
+		jsonlog.Error("Unknown cache backend, caching is disabled.", map[string]interface{}{
+			"backend": config.CacheBackend,
+		})
+		backend, _ = newNoopBackend()
+		return
+	}
+	var err error
+	if backend, err = newBackend(); err != nil {
+		jsonlog.Error("Unable to initialize the cache backend, caching is disabled.", map[string]interface{}{
+			"backend": config.CacheBackend,
+			"error":   err.Error(),
+		})
+		backend, _ = newNoopBackend()
+	} else if config.CacheBackend == "memory" && config.CacheMemorySingleProcess && config.Task != "server" {
+		jsonlog.Warning("The memory cache backend is set to a single process, the cache of the servers will not be invalidated by this task.", map[string]interface{}{
+			"task": config.Task,
+		})
+	}
+}
+
+// parseRouteTtls parses route cache durations formatted like
+// "/costs=1h,/ec2=6h". Invalid durations are logged and ignored.
+func parseRouteTtls(expression string) map[string]time.Duration {
+	res := make(map[string]time.Duration)
+	for _, pair := range strings.Split(expression, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			jsonlog.Error("Invalid route cache duration, ignoring it.", pair)
+			continue
+		}
+		if ttl, err := time.ParseDuration(strings.TrimSpace(parts[1])); err != nil || ttl <= 0 {
+			jsonlog.Error("Invalid route cache duration, ignoring it.", pair)
+		} else {
+			res[strings.TrimSpace(parts[0])] = ttl
+		}
+	}
+	return res
+}
+
+// getTtl returns the cache duration of a route: the one configured in
+// config.CacheRouteTtls, or else the one of the decorator, or else
+// config.CacheTtl.
+func getTtl(route string, decoratorTtl time.Duration) time.Duration {
+	if ttl, ok := routeTtls[route]; ok {
+		return ttl
+	} else if decoratorTtl > 0 {
+		return decoratorTtl
+	}
+	return config.CacheTtl
+}
+
+// matchPattern checks whether s matches pattern, in which '*' matches any
+// sequence of characters.
+func matchPattern(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	} else if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return len(s) >= len(last) && strings.HasSuffix(s, last)
+}
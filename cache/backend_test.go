@@ -0,0 +1,148 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package cache
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/trackit/trackit/routes"
+)
+
+func TestMatchPattern(test *testing.T) {
+	cases := []struct {
+		pattern  string
+		key      string
+		expected bool
+	}{
+		{"abc", "abc", true},
+		{"abc", "abcd", false},
+		{"a*-*-42-*", "a1-b2-42-", true},
+		{"a*-*-42-*", "a1-b2-43-42-", true},
+		{"a*-*-42-*", "b1-b2-42-", false},
+		{"a*-*-42-*", "a1-b2-43-", false},
+		{"*x", "x", true},
+		{"x*x", "x", false},
+	}
+	for _, c := range cases {
+		if result := matchPattern(c.pattern, c.key); result != c.expected {
+			test.Errorf("Expected matchPattern(%q, %q) to be %v", c.pattern, c.key, c.expected)
+		}
+	}
+}
+
+func TestMemoryBackendEvictsLeastRecentlyUsed(test *testing.T) {
+	mb := newMemoryBackendWithSize(2)
+	mb.Set("a", []byte("1"), time.Hour)
+	mb.Set("b", []byte("2"), time.Hour)
+	mb.Get("a")
+	mb.Set("c", []byte("3"), time.Hour)
+	if _, found, _ := mb.Get("b"); found {
+		test.Errorf("Expected 'b' to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, found, _ := mb.Get(key); !found {
+			test.Errorf("Expected '%s' to be kept", key)
+		}
+	}
+}
+
+func TestMemoryBackendExpiresAndDeletes(test *testing.T) {
+	mb := newMemoryBackendWithSize(10)
+	mb.Set("expired", []byte("1"), -time.Second)
+	if _, found, _ := mb.Get("expired"); found {
+		test.Errorf("Expected the expired entry to be missing")
+	}
+	mb.Set("route1-args-42-", []byte("1"), time.Hour)
+	mb.Set("route1-args-43-", []byte("2"), time.Hour)
+	mb.Set("route2-args-42-", []byte("3"), time.Hour)
+	mb.DeleteMatching("route1-*-42-*")
+	if _, found, _ := mb.Get("route1-args-42-"); found {
+		test.Errorf("Expected the matching entry to be deleted")
+	}
+	if value, found, _ := mb.Get("route1-args-43-"); !found || string(value) != "2" {
+		test.Errorf("Expected the other entries to be kept")
+	}
+	mb.Delete("route2-args-42-")
+	if _, found, _ := mb.Get("route2-args-42-"); found {
+		test.Errorf("Expected the deleted entry to be missing")
+	}
+}
+
+func TestPropagatedBackendAppliesOtherInvalidations(test *testing.T) {
+	var backends []*propagatedBackend
+	publish := func(i invalidation) error {
+		payload, _ := json.Marshal(i)
+		for _, pb := range backends {
+			if err := pb.apply(payload); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, origin := range []string{"a", "b"} {
+		pb := &propagatedBackend{newMemoryBackendWithSize(10), origin, publish}
+		pb.Set("route1-args-42-", []byte("1"), time.Hour)
+		pb.Set("route2-args-42-", []byte("2"), time.Hour)
+		backends = append(backends, pb)
+	}
+	if err := backends[0].DeleteMatching("route1-*"); err != nil {
+		test.Fatal(err)
+	}
+	if err := backends[1].Delete("route2-args-42-"); err != nil {
+		test.Fatal(err)
+	}
+	for _, pb := range backends {
+		for _, key := range []string{"route1-args-42-", "route2-args-42-"} {
+			if _, found, _ := pb.Get(key); found {
+				test.Errorf("Expected '%s' to be invalidated in backend '%s'", key, pb.origin)
+			}
+		}
+	}
+}
+
+func TestParseRouteTtls(test *testing.T) {
+	expected := map[string]time.Duration{
+		"/costs": time.Hour,
+		"/ec2":   6 * time.Hour,
+	}
+	if result := parseRouteTtls(" /costs=1h, /ec2 = 6h ,/rds=soon,/es"); !reflect.DeepEqual(result, expected) {
+		test.Errorf("Expected %v but got %v", expected, result)
+	}
+}
+
+func TestRoutesForEvent(test *testing.T) {
+	handler := func(events ...string) routes.Handler {
+		return routes.MethodMuxer{
+			http.MethodGet: routes.H(func(*http.Request, routes.Arguments) (int, interface{}) { return http.StatusOK, nil }).With(UsersCache{Events: events}),
+		}.H()
+	}
+	handlers := []routes.RegisteredHandler{
+		{Pattern: "/costs", Handler: handler(EventBillingData)},
+		{Pattern: "/costs/forecast", Handler: handler(EventBillingData, EventAnomalies)},
+		{Pattern: "/ec2", Handler: handler(EventUsageReports)},
+		{Pattern: "/user", Handler: handler()},
+	}
+	expected := []string{"/costs", "/costs/forecast"}
+	if result := routesForEvent(handlers, EventBillingData); !reflect.DeepEqual(result, expected) {
+		test.Errorf("Expected %v but got %v", expected, result)
+	}
+	if result := routesForEvent(handlers, EventPluginResults); len(result) != 0 {
+		test.Errorf("Expected no route but got %v", result)
+	}
+}
@@ -18,55 +18,35 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
-	"os"
 	"regexp"
 	"time"
 
-	"github.com/go-redis/redis"
 	"github.com/trackit/jsonlog"
 
-	"github.com/trackit/trackit/config"
 	"github.com/trackit/trackit/db"
 	"github.com/trackit/trackit/routes"
 	"github.com/trackit/trackit/users"
 )
 
 // UsersCache is a struct to format a decorator that retrieve data from
-// different route and cache it with redis. Cache expire automatically
-// after 24 hours.
+// different route and cache it in the configured backend. Cache expires
+// after Ttl, which defaults to config.CacheTtl, and is invalidated when one
+// of the data change Events is emitted for an account of the user.
 type UsersCache struct {
+	Events []string
+	Ttl    time.Duration
 }
 
 type redisCache struct {
 	route        string
 	args         string
 	awsAccount   []string
+	user         string
 	key          string
 	cacheContent []byte
 }
 
-const cacheExpireTime = 24 * time.Hour
-
-var mainClient *redis.Client
-
-func init() {
-	mainClient = redis.NewClient(&redis.Options{
-		Addr:        config.RedisAddress,
-		Password:    config.RedisPassword,
-		DB:          config.RedisDB,
-		IdleTimeout: -1,
-	})
-	_, err := mainClient.Ping().Result()
-	if err != nil {
-		jsonlog.Error("Unable to establish the connection to redis server", map[string]interface{}{
-			"error": err.Error(),
-		})
-		os.Exit(1)
-	}
-	jsonlog.Info("Successfully connected to redis client", map[string]interface{}{
-		"address": config.RedisAddress,
-	})
-}
+const TagCacheEvents = "cache:events"
 
 // getFunc allows us to intercept the current data flow from the route and
 // manipulate it to retrieve data or directly return data from the cache if
@@ -92,8 +72,10 @@ func (uc UsersCache) getFunc(hf routes.HandlerFunc) routes.HandlerFunc {
 			return hf(writer, request, args)
 		}
 		updateCacheByHeaderStatus(request, rdCache)
-		if userHasCacheForService(rdCache, logger) {
-			retrieveCache := getUserCache(rdCache, logger)
+		// The cache holds the JSON representation of the output, which
+		// exports cannot flatten as well as the output itself.
+		exporting := routes.IsExportRequest(request)
+		if retrieveCache, found := getUserCache(rdCache, logger); found && !exporting {
 			if retrieveCache == nil {
 				logger.Warning("Unable to retrieve cache, skipping it to avoid panic or error. The cache has been deleted.", map[string]interface{}{
 					"userKey": rdCache.key,
@@ -107,7 +89,7 @@ func (uc UsersCache) getFunc(hf routes.HandlerFunc) routes.HandlerFunc {
 		}
 		status, routeData := hf(writer, request, args)
 		if status == http.StatusOK && isValidResponse(routeData) {
-			createUserCache(rdCache, routeData, logger)
+			createUserCache(rdCache, routeData, getTtl(rdCache.route, uc.Ttl), logger)
 			writeHeaderCacheStatus(writer, cacheStatusCreated)
 		}
 		return status, routeData
@@ -124,5 +106,19 @@ func isValidResponse(data interface{}) bool {
 
 func (uc UsersCache) Decorate(handler routes.Handler) routes.Handler {
 	handler.Func = uc.getFunc(handler.Func)
+	handler.Documentation = uc.getDocumentation(handler.Documentation)
 	return handler
 }
+
+// getDocumentation tags the documentation with the events invalidating the
+// cache, which Invalidate looks for in the registered handlers.
+func (uc UsersCache) getDocumentation(hd routes.HandlerDocumentation) routes.HandlerDocumentation {
+	if len(uc.Events) == 0 {
+		return hd
+	}
+	if hd.Tags == nil {
+		hd.Tags = make(routes.Tags)
+	}
+	hd.Tags[TagCacheEvents] = append(hd.Tags[TagCacheEvents], uc.Events...)
+	return hd
+}
@@ -17,7 +17,10 @@ package cache
 import (
 	"crypto/md5"
 	"fmt"
+	"reflect"
 	"testing"
+
+	"github.com/trackit/trackit/users"
 )
 
 const (
@@ -35,10 +38,39 @@ func TestGetUserKey(test *testing.T) {
 		route:      testRoute,
 		args:       testArgs,
 		awsAccount: []string{testAwsAcc},
+		user:       cacheUser(users.User{Id: 42}),
 	}
 	formatKey(&result)
-	excepted := fmt.Sprintf("%x-%x-%v-", md5.Sum([]byte(testRoute)), md5.Sum([]byte(testArgs)), testAwsAcc)
+	excepted := fmt.Sprintf("%x-%x-%v-u42.0-", md5.Sum([]byte(testRoute)), md5.Sum([]byte(testArgs)), testAwsAcc)
 	if result.key != excepted {
 		test.Errorf("Execepted '%v' but got '%v'", excepted, result)
 	}
 }
+
+func TestCacheAccounts(test *testing.T) {
+	identities := []string{"420", testAwsAcc, "123"}
+	cases := []struct {
+		accounts []string
+		expected []string
+	}{
+		{nil, []string{"123", testAwsAcc, "420"}},
+		{[]string{"420", "123"}, []string{"123", "420"}},
+		{[]string{"999"}, []string{}},
+	}
+	for _, c := range cases {
+		user := users.User{Id: 1, Accounts: c.accounts}
+		if result := cacheAccounts(user, identities); !reflect.DeepEqual(result, c.expected) {
+			test.Errorf("Expected accounts %v to give %v, got %v", c.accounts, c.expected, result)
+		}
+	}
+}
+
+func TestCacheUser(test *testing.T) {
+	parent := cacheUser(users.User{Id: 42})
+	if viewer := cacheUser(users.User{Id: 42, ViewerId: 43}); viewer == parent {
+		test.Errorf("Expected a viewer acting as their parent not to share their cache, got %q", viewer)
+	}
+	if other := cacheUser(users.User{Id: 43}); other == parent {
+		test.Errorf("Expected users not to share their cache, got %q", other)
+	}
+}
@@ -0,0 +1,101 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package cache
+
+import (
+	"database/sql"
+	"sort"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+// Data change events invalidate the cache of the routes whose UsersCache
+// lists them.
+const (
+	// EventBillingData is emitted when the billing data of an account was
+	// ingested.
+	EventBillingData = "billing-data"
+	// EventUsageReports is emitted when the usage reports of an account were
+	// generated.
+	EventUsageReports = "usage-reports"
+	// EventAnomalies is emitted when the cost anomalies of an account were
+	// detected, snoozed or filtered.
+	EventAnomalies = "anomalies"
+	// EventPluginResults is emitted when the account plugins of an account
+	// were run.
+	EventPluginResults = "plugin-results"
+	// EventCostCategories is emitted when a cost category used by the costs
+	// of an account was changed.
+	EventCostCategories = "cost-categories"
+	// EventCostRedistributions is emitted when a redistribution rule used by
+	// the allocated costs of an account was changed.
+	EventCostRedistributions = "cost-redistributions"
+)
+
+// Invalidate removes the cache of the routes invalidated by event for the
+// given AWS identities.
+func Invalidate(event string, awsIdentities []string, logger jsonlog.Logger) error {
+	return RemoveMatchingCache(routesForEvent(routes.RegisteredHandlers, event), awsIdentities, logger)
+}
+
+// InvalidateUser removes the cache of the routes invalidated by event for the
+// AWS accounts of a user and the ones shared with them.
+func InvalidateUser(event string, tx *sql.Tx, user users.User, logger jsonlog.Logger) error {
+	awsAccounts, err := models.AwsAccountsByUserID(tx, user.Id)
+	if err != nil {
+		return err
+	}
+	awsIdentities := make([]string, 0, len(awsAccounts))
+	for _, awsAccount := range awsAccounts {
+		awsIdentities = append(awsIdentities, awsAccount.AwsIdentity)
+	}
+	if err := getAwsIdentityFromSharedAcc(user, &awsIdentities, tx, logger); err != nil {
+		return err
+	}
+	return Invalidate(event, awsIdentities, logger)
+}
+
+// routesForEvent returns the patterns of the registered handlers whose cache
+// is invalidated by event.
+func routesForEvent(handlers []routes.RegisteredHandler, event string) []string {
+	res := []string{}
+	for _, rh := range handlers {
+		if documentationHasEvent(rh.Handler.Documentation, event) {
+			res = append(res, rh.Pattern)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// documentationHasEvent checks whether a handler documentation or one of its
+// components is tagged with event.
+func documentationHasEvent(hd routes.HandlerDocumentation, event string) bool {
+	for _, e := range hd.Tags[TagCacheEvents] {
+		if e == event {
+			return true
+		}
+	}
+	for _, component := range hd.Components {
+		if documentationHasEvent(component, event) {
+			return true
+		}
+	}
+	return false
+}
@@ -30,12 +30,21 @@ import (
 )
 
 // formatKey is unique depending on user's AWS' identities (personal + shared accounts)
-// or identities passed in arguments and route's data (route's name and arguments)
+// or identities passed in arguments, route's data (route's name and arguments)
+// and the user, whose role on the accounts shapes the response.
 func formatKey(rdCache *redisCache) {
 	rdCache.key = fmt.Sprintf("%x-%x-", md5.Sum([]byte(rdCache.route)), md5.Sum([]byte(rdCache.args)))
 	for _, val := range rdCache.awsAccount {
 		rdCache.key = fmt.Sprintf("%v%v-", rdCache.key, val)
 	}
+	rdCache.key = fmt.Sprintf("%v%v-", rdCache.key, rdCache.user)
+}
+
+// cacheUser identifies a user in the cache key. Viewers acting as their
+// parent have the permissions of users.ViewerRole, so they do not share the
+// cache of their parent.
+func cacheUser(user users.User) string {
+	return fmt.Sprintf("u%d.%d", user.Id, user.ViewerId)
 }
 
 // parseRouteFromUrl store the route name (with the format: "/route") and,
@@ -80,17 +89,21 @@ func getAwsIdentityFromSharedAcc(user users.User, identities *[]string, context
 	return nil
 }
 
-// Initialize cache information by getting a list of all AWS identities and
+// Initialize cache information by getting a list of all AWS identities, the
+// ones the user was authorized on when the route requires a permission, and
 // retrieving different information from the URL. The user's key is also formatted
 // depending of the previous information.
 func initialiseCacheInfos(url string, args routes.Arguments, logger jsonlog.Logger) (rtn redisCache, err error) {
 	var allAcc []string
 	parseRouteFromUrl(url, &rtn)
+	user := args[users.AuthenticatedUser].(users.User)
 	if args[routes.AwsAccountsOptionalQueryArg] != nil {
 		allAcc = args[routes.AwsAccountsOptionalQueryArg].([]string)
+	} else if user.Accounts != nil {
+		// The accounts the user was authorized on for the route
+		allAcc = user.Accounts
 	} else {
 		tx := args[db.Transaction].(*sql.Tx)
-		user := args[users.AuthenticatedUser].(users.User)
 		awsAccs, awsAccsErr := models.AwsAccountsByUserID(tx, user.Id)
 		if awsAccsErr != nil {
 			logger.Error("Unable to retrieve AWS' accounts by user id.", map[string]interface{}{
@@ -107,10 +120,23 @@ func initialiseCacheInfos(url string, args routes.Arguments, logger jsonlog.Logg
 			return
 		}
 	}
-	for _, val := range allAcc {
-		rtn.awsAccount = append(rtn.awsAccount, val)
-	}
-	sort.Strings(rtn.awsAccount)
+	rtn.awsAccount = cacheAccounts(user, allAcc)
+	rtn.user = cacheUser(user)
 	formatKey(&rtn)
 	return
 }
+
+// cacheAccounts returns the sorted AWS identities of the cache of a user,
+// which are only the ones they can access when they are authenticated with an
+// API key restricted to some accounts. A restricted key does not share the
+// cache of its owner.
+func cacheAccounts(user users.User, identities []string) []string {
+	res := make([]string, 0, len(identities))
+	for _, val := range identities {
+		if user.CanAccessAccount(val) {
+			res = append(res, val)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,117 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/trackit/trackit/config"
+)
+
+// memoryBackend is a Backend storing the values in memory. When it is full,
+// the least recently used values are evicted first. Each process has its own
+// cache: newMemoryBackend propagates its invalidations to the other processes
+// through Redis, unless config.CacheMemorySingleProcess states there is no
+// other process.
+type memoryBackend struct {
+	mutex      sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	usage      *list.List
+}
+
+// memoryEntry is a value stored in a memoryBackend.
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func newMemoryBackend() (Backend, error) {
+	mb := newMemoryBackendWithSize(config.CacheMemoryMaxEntries)
+	if config.CacheMemorySingleProcess {
+		return mb, nil
+	}
+	return newPropagatedBackend(mb)
+}
+
+func newMemoryBackendWithSize(maxEntries int) *memoryBackend {
+	return &memoryBackend{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		usage:      list.New(),
+	}
+}
+
+func (mb *memoryBackend) Get(key string) ([]byte, bool, error) {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+	element, ok := mb.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*memoryEntry)
+	if time.Now().After(entry.expires) {
+		mb.remove(element)
+		return nil, false, nil
+	}
+	mb.usage.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+func (mb *memoryBackend) Set(key string, value []byte, ttl time.Duration) error {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+	expires := time.Now().Add(ttl)
+	if element, ok := mb.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.value, entry.expires = value, expires
+		mb.usage.MoveToFront(element)
+		return nil
+	}
+	mb.entries[key] = mb.usage.PushFront(&memoryEntry{key, value, expires})
+	for mb.maxEntries > 0 && mb.usage.Len() > mb.maxEntries {
+		mb.remove(mb.usage.Back())
+	}
+	return nil
+}
+
+func (mb *memoryBackend) Delete(key string) error {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+	if element, ok := mb.entries[key]; ok {
+		mb.remove(element)
+	}
+	return nil
+}
+
+func (mb *memoryBackend) DeleteMatching(pattern string) error {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+	for key, element := range mb.entries {
+		if matchPattern(pattern, key) {
+			mb.remove(element)
+		}
+	}
+	return nil
+}
+
+// remove removes an entry. The caller must hold mb.mutex.
+func (mb *memoryBackend) remove(element *list.Element) {
+	mb.usage.Remove(element)
+	delete(mb.entries, element.Value.(*memoryEntry).key)
+}
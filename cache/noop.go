@@ -0,0 +1,42 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package cache
+
+import (
+	"time"
+)
+
+// noopBackend is a Backend which caches nothing.
+type noopBackend struct{}
+
+func newNoopBackend() (Backend, error) {
+	return noopBackend{}, nil
+}
+
+func (noopBackend) Get(key string) ([]byte, bool, error) {
+	return nil, false, nil
+}
+
+func (noopBackend) Set(key string, value []byte, ttl time.Duration) error {
+	return nil
+}
+
+func (noopBackend) Delete(key string) error {
+	return nil
+}
+
+func (noopBackend) DeleteMatching(pattern string) error {
+	return nil
+}
@@ -0,0 +1,108 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package cache
+
+import (
+	"encoding/json"
+
+	"github.com/go-redis/redis"
+	uuid "github.com/satori/go.uuid"
+	"github.com/trackit/jsonlog"
+)
+
+// invalidationChannel is the Redis channel through which the memory backends
+// of the processes propagate their invalidations.
+const invalidationChannel = "trackit-cache-invalidations"
+
+// invalidation is an invalidation propagated between memory backends. Either
+// Key or Pattern is set, as in Backend's Delete and DeleteMatching. Origin
+// identifies the backend which published it.
+type invalidation struct {
+	Origin  string `json:"origin"`
+	Key     string `json:"key,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// propagatedBackend is a memoryBackend whose invalidations are published to
+// the other processes, and which applies theirs, so that the tasks run in any
+// process clear the cache of every server.
+type propagatedBackend struct {
+	*memoryBackend
+	origin  string
+	publish func(invalidation) error
+}
+
+// newPropagatedBackend propagates the invalidations of mb through Redis. It
+// fails if Redis cannot be reached, as the cache would otherwise be left
+// stale by the other processes.
+func newPropagatedBackend(mb *memoryBackend) (Backend, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		return nil, err
+	}
+	pubsub := client.Subscribe(invalidationChannel)
+	if _, err := pubsub.Receive(); err != nil {
+		return nil, err
+	}
+	pb := &propagatedBackend{
+		memoryBackend: mb,
+		origin:        uuid.NewV1().String(),
+		publish: func(i invalidation) error {
+			payload, err := json.Marshal(i)
+			if err != nil {
+				return err
+			}
+			return client.Publish(invalidationChannel, payload).Err()
+		},
+	}
+	go pb.listen(pubsub.Channel())
+	return pb, nil
+}
+
+func (pb *propagatedBackend) Delete(key string) error {
+	pb.memoryBackend.Delete(key)
+	return pb.publish(invalidation{Origin: pb.origin, Key: key})
+}
+
+func (pb *propagatedBackend) DeleteMatching(pattern string) error {
+	pb.memoryBackend.DeleteMatching(pattern)
+	return pb.publish(invalidation{Origin: pb.origin, Pattern: pattern})
+}
+
+// listen applies the invalidations published by the other processes.
+func (pb *propagatedBackend) listen(messages <-chan *redis.Message) {
+	for message := range messages {
+		if err := pb.apply([]byte(message.Payload)); err != nil {
+			jsonlog.Error("Failed to apply a cache invalidation.", map[string]interface{}{
+				"payload": message.Payload,
+				"error":   err.Error(),
+			})
+		}
+	}
+}
+
+// apply applies an invalidation published by another process, ignoring the
+// ones published by pb itself.
+func (pb *propagatedBackend) apply(payload []byte) error {
+	var i invalidation
+	if err := json.Unmarshal(payload, &i); err != nil {
+		return err
+	} else if i.Origin == pb.origin {
+		return nil
+	} else if i.Pattern != "" {
+		return pb.memoryBackend.DeleteMatching(i.Pattern)
+	}
+	return pb.memoryBackend.Delete(i.Key)
+}
@@ -0,0 +1,95 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package cache
+
+import (
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/config"
+)
+
+// redisScanCount is the number of keys Redis is asked to look at in each
+// iteration of a scan.
+const redisScanCount = 1000
+
+// redisBackend is a Backend storing the values in Redis, so that they are
+// shared by every server.
+type redisBackend struct {
+	client *redis.Client
+}
+
+func newRedisBackend() (Backend, error) {
+	client, err := newRedisClient()
+	if err != nil {
+		return nil, err
+	}
+	return redisBackend{client}, nil
+}
+
+// newRedisClient connects to the Redis database of the configuration.
+func newRedisClient() (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:        config.RedisAddress,
+		Password:    config.RedisPassword,
+		DB:          config.RedisDB,
+		IdleTimeout: -1,
+	})
+	if _, err := client.Ping().Result(); err != nil {
+		return nil, err
+	}
+	jsonlog.Info("Successfully connected to redis client", map[string]interface{}{
+		"address": config.RedisAddress,
+	})
+	return client, nil
+}
+
+func (rb redisBackend) Get(key string) ([]byte, bool, error) {
+	val, err := rb.client.Get(key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return val, true, nil
+}
+
+func (rb redisBackend) Set(key string, value []byte, ttl time.Duration) error {
+	return rb.client.Set(key, value, ttl).Err()
+}
+
+func (rb redisBackend) Delete(key string) error {
+	return rb.client.Del(key).Err()
+}
+
+func (rb redisBackend) DeleteMatching(pattern string) error {
+	var cursor uint64
+	for {
+		keys, next, err := rb.client.Scan(cursor, pattern, redisScanCount).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := rb.client.Del(keys...).Err(); err != nil {
+				return err
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return nil
+		}
+	}
+}
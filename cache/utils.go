@@ -16,50 +16,36 @@ package cache
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/trackit/jsonlog"
 )
 
-func userHasCacheForService(rdCache redisCache, logger jsonlog.Logger) bool {
-	rtn, err := mainClient.Exists(rdCache.key).Result()
+// getUserCache returns the cached data for a key and whether the key was
+// found. The data is nil if it could not be read.
+func getUserCache(rdCache redisCache, logger jsonlog.Logger) (interface{}, bool) {
+	var cacheData interface{} = nil
+	val, found, err := backend.Get(rdCache.key)
 	if err != nil {
-		logger.Error("Unable to check if the key already exists in redis.", map[string]interface{}{
+		logger.Error("Unable to get the cache for the key.", map[string]interface{}{
 			"error":   err.Error(),
 			"userKey": rdCache.key,
 		})
+		return nil, false
+	} else if !found {
+		return nil, false
 	}
-	return err == nil && rtn > 0
-}
-
-func getUserCache(rdCache redisCache, logger jsonlog.Logger) interface{} {
-	var cacheData interface{} = nil
-	val, err := mainClient.Get(rdCache.key).Result()
-	if len(val) == 0 {
-		logger.Error("No cache found on Redis for the key despite having it marked as valid", map[string]interface{}{
-			"error": err,
-		})
-		return nil
-	}
-	err = json.Unmarshal([]byte(val), &cacheData)
+	err = json.Unmarshal(val, &cacheData)
 	if err != nil {
 		logger.Error("Unable to unmarshal cache data for the key '%v'", map[string]interface{}{
 			"error": err,
 		})
-		return nil
+		return nil, true
 	}
-	return cacheData
+	return cacheData, true
 }
 
-func createUserCache(rdCache redisCache, data interface{}, logger jsonlog.Logger) {
-	if userHasCacheForService(rdCache, logger) {
-		logger.Warning("The user has already a cache attributed for the current route.", map[string]interface{}{
-			"userKey":   rdCache.key,
-			"route":     rdCache.route,
-			"routeArgs": rdCache.args,
-			"accounts":  rdCache.awsAccount,
-		})
-		return
-	}
+func createUserCache(rdCache redisCache, data interface{}, ttl time.Duration, logger jsonlog.Logger) {
 	var err error
 	rdCache.cacheContent, err = json.Marshal(data)
 	if err != nil {
@@ -69,22 +55,18 @@ func createUserCache(rdCache redisCache, data interface{}, logger jsonlog.Logger
 		})
 		return
 	}
-	cmdStat := mainClient.Append(rdCache.key, string(rdCache.cacheContent))
-	if cmdStat.Err() == nil {
-		mainClient.Expire(rdCache.key, cacheExpireTime)
-	} else {
-		logger.Error("Unable to append content.", map[string]interface{}{
-			"error":   cmdStat.Err().Error(),
+	if err = backend.Set(rdCache.key, rdCache.cacheContent, ttl); err != nil {
+		logger.Error("Unable to store content.", map[string]interface{}{
+			"error":   err.Error(),
 			"userKey": rdCache.key,
 		})
 	}
 }
 
 func deleteUserCache(rdCache redisCache, logger jsonlog.Logger) {
-	rtn := mainClient.Del(rdCache.key)
-	if rtn.Err() != nil {
+	if err := backend.Delete(rdCache.key); err != nil {
 		logger.Error("Unable to delete user's cache.", map[string]interface{}{
-			"error":   rtn.Err().Error(),
+			"error":   err.Error(),
 			"userKey": rdCache.key,
 		})
 	}
@@ -16,6 +16,7 @@ package config
 
 import (
 	"flag"
+	"time"
 )
 
 const (
@@ -33,6 +34,10 @@ var (
 	AuthIssuer string
 	// AuthSecret is the secret used to sign and verify JWT tokens.
 	AuthSecret string
+	// AuthTokenDuration is the duration a JWT token is valid for. Sessions are kept alive with refresh tokens.
+	AuthTokenDuration time.Duration
+	// AuthSessionDuration is the duration a session can be refreshed for before the user has to log in again.
+	AuthSessionDuration time.Duration
 	// AwsRegion is the AWS region the product operates in.
 	AwsRegion string
 	// BackendId is an identifier for the current instance of the server.
@@ -64,6 +69,17 @@ var (
 	RedisPassword string
 	// RedisDB is the DB used in Redis
 	RedisDB int
+	// CacheBackend is the backend of the route cache: "redis", "memory" or "none".
+	CacheBackend string
+	// CacheMemoryMaxEntries is the number of entries kept by the in-memory cache backend.
+	CacheMemoryMaxEntries int
+	// CacheMemorySingleProcess lets the in-memory cache backend run without
+	// propagating its invalidations to the other processes through Redis.
+	CacheMemorySingleProcess bool
+	// CacheTtl is the default duration a route response is cached for.
+	CacheTtl time.Duration
+	// CacheRouteTtls overrides the cache duration of some routes. Example: "/costs=1h,/ec2=6h".
+	CacheRouteTtls string
 	// SmtpAddress is the SMTP address where to send mails.
 	SmtpAddress string
 	// SmtpPort is the SMTP port where to send mails.
@@ -90,6 +106,12 @@ var (
 	AnomalyDetectionBollingerBandStandardDeviationCoefficient float64
 	// AnomalyDetectionBollingerBandUpperBandCoefficient is the coefficient applied to the upper band.
 	AnomalyDetectionBollingerBandUpperBandCoefficient float64
+	// AnomalyDetectionSeasonalityWeeks is the number of previous weeks whose same weekday is used by the weekly seasonality detector to generate the upper band.
+	AnomalyDetectionSeasonalityWeeks int
+	// AnomalyDetectionRobustZScorePeriod is the period in day used by the robust z-score detector to compute the median and the median absolute deviation.
+	AnomalyDetectionRobustZScorePeriod int
+	// AnomalyDetectionRobustZScoreThreshold is the modified z-score a cost has to exceed to be considered as an anomaly by the robust z-score detector.
+	AnomalyDetectionRobustZScoreThreshold float64
 	// AnomalyDetectionDisturbanceCleaningMinPercentOfDailyBill is the percentage of the daily bill an anomaly has to exceed. Otherwise, it's considered as a disturbance.
 	AnomalyDetectionDisturbanceCleaningMinPercentOfDailyBill float64
 	// AnomalyDetectionDisturbanceCleaningMinAbsoluteCost is the cost an anomaly has to exceed. Otherwise, it's considered as a disturbance.
@@ -106,6 +128,28 @@ var (
 	AnomalyDetectionPrettyLevels string
 	// AnomalyEmailingMinLevel is the minimum level required for the mail to be sent.
 	AnomalyEmailingMinLevel int
+	// NotificationMaxAttempts is the number of times the delivery of a notification is attempted on a channel.
+	NotificationMaxAttempts int
+	// NotificationRetryDelay is the delay before the first retry of a notification delivery. It doubles after each attempt.
+	NotificationRetryDelay time.Duration
+	// BillingSourcesDir is the directory under which billing sources may read export files from the local disk, in a subdirectory named after the ID of the user. Empty disables local billing sources.
+	BillingSourcesDir string
+	// BillingSourcesCredentialsKey is the secret the credentials of the billing sources are encrypted with. Billing sources cannot be given credentials without it.
+	BillingSourcesCredentialsKey string
+	// JobWorkers is the number of workers processing the job queue in the server. Zero disables them.
+	JobWorkers int
+	// JobLeaseDuration is the duration of the lease a worker takes on a job. It is renewed while the job runs.
+	JobLeaseDuration time.Duration
+	// JobPollInterval is the delay between two polls of the job queue by an idle worker.
+	JobPollInterval time.Duration
+	// JobMaxAttempts is the number of times a job is attempted before being marked as failed.
+	JobMaxAttempts int
+	// JobRetryDelay is the delay before the first retry of a failed job. It doubles after each attempt.
+	JobRetryDelay time.Duration
+	// JobAccountConcurrency is the maximum number of jobs running at once for an AWS account. Zero disables the limit.
+	JobAccountConcurrency int
+	// AdminEmails is the comma-separated list of the emails of the users allowed to use the administration routes.
+	AdminEmails string
 	// Stripe secret key for Tagbot
 	StripeKey string
 )
@@ -116,6 +160,8 @@ func init() {
 	flag.StringVar(&SqlAddress, "sql-address", "trackit:trackitpassword@tcp(127.0.0.1)/trackit?parseTime=true", "The address (username, password, transport, address and database) for the SQL database.")
 	flag.StringVar(&AuthIssuer, "auth-issuer", "trackit", "The 'iss' field for the JWT tokens.")
 	flag.StringVar(&AuthSecret, "auth-secret", "trackitdefaultsecret", "The secret used to sign and verify JWT tokens.")
+	flag.DurationVar(&AuthTokenDuration, "auth-token-duration", 15*time.Minute, "The duration a JWT token is valid for.")
+	flag.DurationVar(&AuthSessionDuration, "auth-session-duration", 30*24*time.Hour, "The duration a session can be refreshed for before the user has to log in again.")
 	flag.StringVar(&AwsRegion, "aws-region", "us-east-1", "The AWS region the server operates in.")
 	flag.StringVar(&BackendId, "backend-id", "", "The ID to be sent to clients through the 'X-Backend-ID' field. Generated if left empty.")
 	flag.StringVar(&ReportsBucket, "reports-bucket", "", "The bucket name where the reports are stored. The feature is disabled if left empty.")
@@ -130,6 +176,11 @@ func init() {
 	flag.StringVar(&RedisAddress, "redis-address", "127.0.0.1:6379", "The address of the Redis database.")
 	flag.StringVar(&RedisPassword, "redis-password", "changeme", "The password to use to connect to the Redis database.")
 	flag.IntVar(&RedisDB, "redis-db", 1, "The DB to use in Redis")
+	flag.StringVar(&CacheBackend, "cache-backend", "redis", "The backend of the route cache: redis, memory or none. The memory backend is local to each process and propagates its invalidations to the other processes through Redis.")
+	flag.IntVar(&CacheMemoryMaxEntries, "cache-memory-max-entries", 10000, "The number of entries kept by the in-memory cache backend.")
+	flag.BoolVar(&CacheMemorySingleProcess, "cache-memory-single-process", false, "Do not propagate the invalidations of the memory cache backend through Redis. Only valid when a single process serves the routes and runs every task.")
+	flag.DurationVar(&CacheTtl, "cache-ttl", 24*time.Hour, "The default duration a route response is cached for.")
+	flag.StringVar(&CacheRouteTtls, "cache-route-ttls", "", "Comma-separated route=duration pairs overriding the cache duration of some routes.")
 	flag.BoolVar(&PrettyJsonResponses, "pretty-json-responses", false, "JSON HTTP responses should be pretty.")
 	flag.StringVar(&UrlEc2Pricing, "url-ec2-pricing", "https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/AmazonEC2/current/index.json", "The URL used to download the EC2 pricing.")
 	flag.StringVar(&SmtpAddress, "smtp-address", "", "The address of the SMTP server.")
@@ -144,6 +195,9 @@ func init() {
 	flag.IntVar(&AnomalyDetectionBollingerBandPeriod, "anomaly-detection-bollinger-band-period", 3, "Period used by the Bollinger Band algorithm.")
 	flag.Float64Var(&AnomalyDetectionBollingerBandStandardDeviationCoefficient, "anomaly-detection-bollinger-band-standard-deviation-coefficient", 3.0, "Coefficient used by the Bollinger Band algorithm to generate the standard deviation.")
 	flag.Float64Var(&AnomalyDetectionBollingerBandUpperBandCoefficient, "anomaly-detection-bollinger-band-upper-band-coefficient", 1.05, "Coefficient used by the Bollinger Band algorithm to generate the upper band.")
+	flag.IntVar(&AnomalyDetectionSeasonalityWeeks, "anomaly-detection-seasonality-weeks", 4, "Number of previous weeks used by the weekly seasonality detector.")
+	flag.IntVar(&AnomalyDetectionRobustZScorePeriod, "anomaly-detection-robust-z-score-period", 14, "Period used by the robust z-score detector.")
+	flag.Float64Var(&AnomalyDetectionRobustZScoreThreshold, "anomaly-detection-robust-z-score-threshold", 3.5, "Modified z-score a cost has to exceed to be considered as an anomaly by the robust z-score detector.")
 	flag.Float64Var(&AnomalyDetectionDisturbanceCleaningMinPercentOfDailyBill, "anomaly-detection-disturbance-cleaning-min-percent-of-daily-bill", 5.0, "Percentage of the daily bill an anomaly has to exceed.")
 	flag.Float64Var(&AnomalyDetectionDisturbanceCleaningMinAbsoluteCost, "anomaly-detection-disturbance-cleaning-absolute-cost", 20.0, "Absolute cost an anomaly has to exceed.")
 	flag.IntVar(&AnomalyDetectionDisturbanceCleaningHighestSpendingMinRank, "anomaly-detection-disturbance-cleaning-highest-spending-min-rank", 5, "Minimum rank of the service where the anomaly has been detected.")
@@ -152,6 +206,17 @@ func init() {
 	flag.StringVar(&AnomalyDetectionLevels, "anomaly-detection-levels", "0,120,150,200", "Rules to generate the levels.")
 	flag.StringVar(&AnomalyDetectionPrettyLevels, "anomaly-detection-pretty-levels", "low,medium,high,critical", "Pretty names of the levels.")
 	flag.IntVar(&AnomalyEmailingMinLevel, "anomaly-emailing-min-level", 2, "Minimum level for the mail to be sent.")
+	flag.IntVar(&NotificationMaxAttempts, "notification-max-attempts", 4, "Number of times the delivery of a notification is attempted on a channel.")
+	flag.DurationVar(&NotificationRetryDelay, "notification-retry-delay", 2*time.Second, "Delay before the first retry of a notification delivery, doubled after each attempt.")
+	flag.StringVar(&BillingSourcesDir, "billing-sources-dir", "", "Directory under which billing sources may read export files from the local disk, in a subdirectory per user ID.")
+	flag.StringVar(&BillingSourcesCredentialsKey, "billing-sources-credentials-key", "", "Secret the credentials of the billing sources are encrypted with. Billing sources cannot be given credentials without it.")
+	flag.IntVar(&JobWorkers, "job-workers", 2, "Number of workers processing the job queue in the server.")
+	flag.DurationVar(&JobLeaseDuration, "job-lease-duration", time.Minute, "Duration of the lease a worker takes on a job.")
+	flag.DurationVar(&JobPollInterval, "job-poll-interval", 5*time.Second, "Delay between two polls of the job queue by an idle worker.")
+	flag.IntVar(&JobMaxAttempts, "job-max-attempts", 3, "Number of times a job is attempted before being marked as failed.")
+	flag.DurationVar(&JobRetryDelay, "job-retry-delay", time.Minute, "Delay before the first retry of a failed job, doubled after each attempt.")
+	flag.IntVar(&JobAccountConcurrency, "job-account-concurrency", 1, "Maximum number of jobs running at once for an AWS account.")
+	flag.StringVar(&AdminEmails, "admin-emails", "", "Comma-separated emails of the users allowed to use the administration routes.")
 	flag.StringVar(&StripeKey, "stripe-key", "stripekey", "Stripe key for Tagbot")
 	flag.Parse()
 	if len(EsAddress) == 0 {
@@ -0,0 +1,116 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package costs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/olivere/elastic"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/costs/redistribution"
+	"github.com/trackit/trackit/es"
+)
+
+// periodCriteria are the criteria splitting the costs by period. The pools
+// of the redistribution rules are spread per period.
+var periodCriteria = map[string]bool{
+	"year":  true,
+	"month": true,
+	"week":  true,
+	"day":   true,
+}
+
+// GetAllocatedCosts is like MakeElasticSearchRequestAndParseIt, but the pools
+// of the redistribution rules of parsedParams whose target is one of the
+// criteria are spread over their targets. The line items of the other pools
+// keep their raw costs.
+func GetAllocatedCosts(ctx context.Context, parsedParams EsQueryParams) (es.SimplifiedCostsDocument, int, error) {
+	rules := make([]redistribution.Rule, 0, len(parsedParams.Redistributions))
+	for _, rule := range parsedParams.Redistributions {
+		if hasCriterion(parsedParams, rule.Target) {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return MakeElasticSearchRequestAndParseIt(ctx, parsedParams)
+	}
+	index := strings.Join(parsedParams.IndexList, ",")
+	pools := make([]elastic.Query, len(rules))
+	for i, rule := range rules {
+		pools[i] = rule.Pool.Query()
+	}
+	directRows, err := searchCostsRows(ctx, parsedParams, index, elastic.NewBoolQuery().MustNot(pools...))
+	if err != nil {
+		returnCode, err := searchErrorStatus(ctx, index, err)
+		return es.SimplifiedCostsDocument{}, returnCode, err
+	}
+	periodIndexes := []int{}
+	kinds := make([]string, len(parsedParams.AggregationParams))
+	for i, criterion := range parsedParams.AggregationParams {
+		if periodCriteria[criterion] {
+			periodIndexes = append(periodIndexes, i)
+		}
+		kinds[i] = es.CriterionKind(criterion)
+	}
+	rows := directRows
+	for i, rule := range rules {
+		targetIndex := criterionIndex(parsedParams, rule.Target)
+		poolParams := parsedParams
+		poolParams.AggregationParams = make([]string, 0, len(parsedParams.AggregationParams)-1)
+		poolParams.AggregationParams = append(poolParams.AggregationParams, parsedParams.AggregationParams[:targetIndex]...)
+		poolParams.AggregationParams = append(poolParams.AggregationParams, parsedParams.AggregationParams[targetIndex+1:]...)
+		poolRows, err := searchCostsRows(ctx, poolParams, index, elastic.NewBoolQuery().Filter(pools[i]).MustNot(pools[:i]...))
+		if err != nil {
+			returnCode, err := searchErrorStatus(ctx, index, err)
+			return es.SimplifiedCostsDocument{}, returnCode, err
+		}
+		ignored := map[string]bool{}
+		if rule.Target != redistribution.TargetAccount {
+			ignored[untaggedBucketKey] = true
+		}
+		rows = append(rows, rule.Allocate(directRows, poolRows, targetIndex, periodIndexes, ignored)...)
+	}
+	return es.SimplifiedCostsDocumentFromRows(kinds, rows), http.StatusOK, nil
+}
+
+// searchCostsRows performs a costs request on ElasticSearch, restricted by a
+// filter, and returns the rows of its result.
+func searchCostsRows(ctx context.Context, parsedParams EsQueryParams, index string, filter elastic.Query) ([]es.CostsRow, error) {
+	res, err := searchCosts(ctx, parsedParams, index, filter)
+	if err != nil {
+		return nil, err
+	}
+	simplifiedCostDocument, err := es.SimplifyCostsDocument(ctx, res)
+	if err != nil {
+		jsonlog.LoggerFromContextOrDefault(ctx).Error("Error parsing cost response : "+err.Error(), nil)
+		return nil, fmt.Errorf("could not parse ElasticSearch response")
+	}
+	return simplifiedCostDocument.Rows(), nil
+}
+
+// criterionIndex returns the index of a criterion in the aggregation
+// criteria of a request, or -1.
+func criterionIndex(parsedParams EsQueryParams, criterion string) int {
+	for i, c := range parsedParams.AggregationParams {
+		if c == criterion {
+			return i
+		}
+	}
+	return -1
+}
@@ -20,7 +20,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -29,7 +28,6 @@ import (
 
 	"github.com/trackit/trackit/anomaliesDetection"
 	"github.com/trackit/trackit/cache"
-	"github.com/trackit/trackit/config"
 	"github.com/trackit/trackit/costs/anomalies/anomalyFilters"
 	"github.com/trackit/trackit/costs/anomalies/anomalyType"
 	"github.com/trackit/trackit/db"
@@ -69,7 +67,7 @@ func init() {
 			db.RequestTransaction{Db: db.Db},
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
 			routes.QueryArgs(anomalyQueryArgs),
-			cache.UsersCache{},
+			cache.UsersCache{Events: []string{cache.EventAnomalies}},
 			routes.Documentation{
 				Summary:     "get the cost anomalies",
 				Description: "Responds with the cost anomalies based on the query args passed to it",
@@ -134,16 +132,7 @@ func getAnomalyLevel(typedDocument esProductAnomalyTypedResult) (int, string) {
 	if !typedDocument.Abnormal {
 		return 0, ""
 	}
-	prettyLevels := strings.Split(config.AnomalyDetectionPrettyLevels, ",")
-	percent := (typedDocument.Cost.Value * 100) / typedDocument.Cost.MaxExpected
-	levels := strings.Split(config.AnomalyDetectionLevels, ",")
-	for i, level := range levels[1:] {
-		l, _ := strconv.ParseFloat(level, 64)
-		if percent < l {
-			return i, prettyLevels[i]
-		}
-	}
-	return len(levels) - 1, prettyLevels[len(levels)-1]
+	return anomalies.GetAnomalyLevel(typedDocument.Cost.Value, typedDocument.Cost.MaxExpected)
 }
 
 func formatAnomaliesData(raw *elastic.SearchResult, snoozedAnomalies map[string]bool, ctx context.Context) (anomalyType.AnomaliesDetectionResponse, error) {
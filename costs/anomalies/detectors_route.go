@@ -0,0 +1,189 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package anomalies
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/anomaliesDetection"
+	"github.com/trackit/trackit/aws"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+type (
+	// detectorBody is the body expected by putAnomaliesDetector.
+	// An empty product selects the detector of the whole AWS account.
+	detectorBody struct {
+		Product  string `json:"product"`
+		Detector string `json:"detector" req:"nonzero"`
+	}
+
+	// detectorsResponse is the response of getAnomaliesDetectors.
+	detectorsResponse struct {
+		Available []string          `json:"available"`
+		Account   string            `json:"account"`
+		Products  map[string]string `json:"products"`
+	}
+)
+
+// detectorProductQueryArg is the product whose detector is deleted.
+var detectorProductQueryArg = routes.QueryArg{
+	Name:        "product",
+	Type:        routes.QueryArgString{},
+	Description: "The product whose detector is deleted. The AWS account's detector is deleted if left empty.",
+	Optional:    true,
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getAnomaliesDetectors).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			aws.RequireAwsAccountId{},
+			routes.Documentation{
+				Summary:     "get the anomalies detectors",
+				Description: "Responds with the available anomalies detectors and the ones selected for the AWS account and its products",
+			},
+		),
+		http.MethodPut: routes.H(putAnomaliesDetector).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			aws.RequireAwsAccountId{},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{detectorBody{
+				Product:  "AmazonEC2",
+				Detector: "weekly-seasonality",
+			}},
+			routes.Documentation{
+				Summary:     "select an anomalies detector",
+				Description: "Selects the anomalies detector of the AWS account, or of one of its products",
+			},
+		),
+		http.MethodDelete: routes.H(deleteAnomaliesDetector).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			aws.RequireAwsAccountId{},
+			routes.QueryArgs{detectorProductQueryArg},
+			routes.Documentation{
+				Summary:     "unselect an anomalies detector",
+				Description: "Unselects the anomalies detector of the AWS account, or of one of its products, so the default one is used",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{Db: db.Db},
+		routes.QueryArgs{routes.AwsAccountIdQueryArg},
+		routes.Documentation{
+			Summary:     "interact with the anomalies detectors",
+			Description: "An anomalies detector is the algorithm used to detect the cost anomalies of an AWS account or of one of its products.",
+		},
+	).Register("/costs/anomalies/detectors")
+}
+
+// getAnomaliesDetectors is a route handler which returns the available
+// detectors and the ones selected for an AWS account.
+func getAnomaliesDetectors(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	dbDetectors, err := models.AnomalyDetectorsByAwsAccountID(tx, aa.Id)
+	if err != nil {
+		l.Error("Failed to get anomalies detectors", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve anomalies detectors.")
+	}
+	res := detectorsResponse{
+		Available: anomalies.DetectorNames(),
+		Account:   anomalies.DefaultDetector,
+		Products:  make(map[string]string),
+	}
+	for _, dbDetector := range dbDetectors {
+		if dbDetector.Product == "" {
+			res.Account = dbDetector.Detector
+		} else {
+			res.Products[dbDetector.Product] = dbDetector.Detector
+		}
+	}
+	return http.StatusOK, res
+}
+
+// putAnomaliesDetector is a route handler which selects the detector of an
+// AWS account or of one of its products.
+func putAnomaliesDetector(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	var body detectorBody
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	if err := anomalies.ValidDetector(body.Detector); err != nil {
+		return http.StatusBadRequest, err
+	}
+	dbDetector, err := models.AnomalyDetectorByAwsAccountIDProduct(tx, aa.Id, body.Product)
+	if err == sql.ErrNoRows {
+		dbDetector = &models.AnomalyDetector{
+			AwsAccountID: aa.Id,
+			Product:      body.Product,
+		}
+	} else if err != nil {
+		l.Error("Failed to get anomalies detector", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"product":      body.Product,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to update anomalies detector.")
+	}
+	dbDetector.Detector = body.Detector
+	if err := dbDetector.Save(tx); err != nil {
+		l.Error("Failed to save anomalies detector", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"product":      body.Product,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to update anomalies detector.")
+	}
+	return http.StatusOK, body
+}
+
+// deleteAnomaliesDetector is a route handler which unselects the detector of
+// an AWS account or of one of its products.
+func deleteAnomaliesDetector(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	aa := a[aws.AwsAccountSelection].(aws.AwsAccount)
+	var product string
+	if a[detectorProductQueryArg] != nil {
+		product = a[detectorProductQueryArg].(string)
+	}
+	dbDetector, err := models.AnomalyDetectorByAwsAccountIDProduct(tx, aa.Id, product)
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, errors.New("Anomalies detector not found.")
+	} else if err == nil {
+		err = dbDetector.Delete(tx)
+	}
+	if err != nil {
+		l.Error("Failed to delete anomalies detector", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"product":      product,
+			"error":        err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to delete anomalies detector.")
+	}
+	return http.StatusOK, nil
+}
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/trackit/jsonlog"
 
+	"github.com/trackit/trackit/audit"
 	"github.com/trackit/trackit/aws"
 	"github.com/trackit/trackit/cache"
 	"github.com/trackit/trackit/costs/anomalies/anomalyFilters"
@@ -107,30 +109,31 @@ func postAnomaliesFilters(r *http.Request, a routes.Arguments) (int, interface{}
 			"userId": user.Id,
 			"error": err.Error(),
 		})
-	} else if err := cache.RemoveMatchingCache([]string{"/costs/anomalies"}, []string{aa.AwsIdentity}, l); err != nil {
+	} else if err := cache.Invalidate(cache.EventAnomalies, []string{aa.AwsIdentity}, l); err != nil {
 		l.Error("Failed to remove cache", map[string]interface{}{
 			"userId": user.Id,
 			"error": err.Error(),
 		})
 	}
-	return postAnomaliesFiltersWithValidBody(r, tx, dbUser, body)
+	return postAnomaliesFiltersWithValidBody(r, tx, user, dbUser, body)
 }
 
 // postAnomaliesFiltersWithValidBody handles the logic assuming
 // the body is valid.
-func postAnomaliesFiltersWithValidBody(r *http.Request, tx *sql.Tx, dbUser *models.User, filters FiltersBody) (int, interface{}) {
+func postAnomaliesFiltersWithValidBody(r *http.Request, tx *sql.Tx, user users.User, dbUser *models.User, filters FiltersBody) (int, interface{}) {
 	for _, filter := range filters.Filters {
 		if err := anomalyFilters.Valid(filter.Rule, filter.Data); err != nil {
 			return http.StatusBadRequest, err
 		}
 	}
-	return postAnomaliesFiltersWithValidFilters(r, tx, dbUser, filters)
+	return postAnomaliesFiltersWithValidFilters(r, tx, user, dbUser, filters)
 }
 
 // postAnomaliesFiltersWithValidFilters handles the logic assuming
 // the body and the filters are valid. It wil update the DB.
-func postAnomaliesFiltersWithValidFilters(r *http.Request, tx *sql.Tx, dbUser *models.User, filters FiltersBody) (int, interface{}) {
+func postAnomaliesFiltersWithValidFilters(r *http.Request, tx *sql.Tx, user users.User, dbUser *models.User, filters FiltersBody) (int, interface{}) {
 	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	before := json.RawMessage(dbUser.AnomaliesFilters)
 	if res, err := json.Marshal(filters.Filters); err != nil {
 		l.Error("Failed to marshal anomalies filters", map[string]interface{}{
 			"userId": dbUser.ID,
@@ -151,6 +154,14 @@ func postAnomaliesFiltersWithValidFilters(r *http.Request, tx *sql.Tx, dbUser *m
 						"userId": dbUser.ID,
 						"error":  err.Error(),
 					})
+				} else if err := audit.Record(r, tx, user, audit.Entry{
+					Action:     audit.ActionAnomaliesFilterUpdate,
+					TargetType: audit.TargetAnomaliesFilters,
+					TargetId:   strconv.Itoa(dbUser.ID),
+					Before:     before,
+					After:      filters.Filters,
+				}); err != nil {
+					return http.StatusInternalServerError, err
 				} else {
 					return 200, filters
 				}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/trackit/jsonlog"
 
+	"github.com/trackit/trackit/audit"
 	"github.com/trackit/trackit/aws"
 	"github.com/trackit/trackit/cache"
 	"github.com/trackit/trackit/db"
@@ -75,12 +76,15 @@ func snoozeAnomalies(request *http.Request, a routes.Arguments) (int, interface{
 			res.Anomalies = append(res.Anomalies, anomalyId)
 		}
 	}
+	if err := recordSnoozing(request, tx, user, audit.ActionAnomalySnooze, res.Anomalies); err != nil {
+		return http.StatusInternalServerError, err
+	}
 	if aa, err := aws.GetAwsAccountWithId(user.Id, tx); err != nil {
 		l.Error("Failed to get Aws Account", map[string]interface{}{
 			"userId": user.Id,
 			"error": err.Error(),
 		})
-	} else if err := cache.RemoveMatchingCache([]string{"/costs/anomalies"}, []string{aa.AwsIdentity}, l); err != nil {
+	} else if err := cache.Invalidate(cache.EventAnomalies, []string{aa.AwsIdentity}, l); err != nil {
 		l.Error("Failed to remove cache", map[string]interface{}{
 			"userId": user.Id,
 			"error": err.Error(),
@@ -103,12 +107,15 @@ func unsnoozeAnomalies(request *http.Request, a routes.Arguments) (int, interfac
 			res.Anomalies = append(res.Anomalies, anomalyId)
 		}
 	}
+	if err := recordSnoozing(request, tx, user, audit.ActionAnomalyUnsnooze, res.Anomalies); err != nil {
+		return http.StatusInternalServerError, err
+	}
 	if aa, err := aws.GetAwsAccountWithId(user.Id, tx); err != nil {
 		l.Error("Failed to get Aws Account", map[string]interface{}{
 			"userId": user.Id,
 			"error": err.Error(),
 		})
-	} else if err := cache.RemoveMatchingCache([]string{"/costs/anomalies"}, []string{aa.AwsIdentity}, l); err != nil {
+	} else if err := cache.Invalidate(cache.EventAnomalies, []string{aa.AwsIdentity}, l); err != nil {
 		l.Error("Failed to remove cache", map[string]interface{}{
 			"userId": user.Id,
 			"error": err.Error(),
@@ -116,3 +123,18 @@ func unsnoozeAnomalies(request *http.Request, a routes.Arguments) (int, interfac
 	}
 	return http.StatusOK, res
 }
+
+// recordSnoozing records the snoozing or unsnoozing of anomalies in the audit
+// log, with one entry per anomaly.
+func recordSnoozing(request *http.Request, tx *sql.Tx, user users.User, action audit.Action, anomalies []string) error {
+	for _, anomalyId := range anomalies {
+		if err := audit.Record(request, tx, user, audit.Entry{
+			Action:     action,
+			TargetType: audit.TargetAnomaly,
+			TargetId:   anomalyId,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package categories maps the line items to user-defined business
+// dimensions. A cost category splits the costs into values by ordered rules
+// over the accounts, products, usage types, regions and tags of the line
+// items: a line item gets the value of the first rule it matches, or the
+// default value of the category if it matches none.
+package categories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/olivere/elastic"
+
+	"github.com/trackit/trackit/models"
+)
+
+// Criterion is the prefix of the 'category:<NAME>' criterion of the costs
+// requests.
+const Criterion = "category"
+
+// maxRules is the maximum number of rules of a cost category, which bounds
+// the size of the ElasticSearch queries it produces.
+const maxRules = 100
+
+var (
+	ErrCategoryNotFound = errors.New("Cost category not found.")
+	ErrInvalidName      = errors.New("The name of a cost category must not be empty nor contain ':' or ','.")
+	ErrTooManyRules     = fmt.Errorf("A cost category cannot have more than %d rules.", maxRules)
+)
+
+type (
+	// TagCondition matches the line items carrying a tag key with one of
+	// the values, or with any value if there are none.
+	TagCondition struct {
+		Key    string   `json:"key"`
+		Values []string `json:"values"`
+	}
+
+	// Rule gives its value to the line items matching every one of its
+	// non-empty conditions. A condition matches the line items with one of
+	// its values.
+	Rule struct {
+		Value      string         `json:"value"`
+		Accounts   []string       `json:"accounts"`
+		Products   []string       `json:"products"`
+		UsageTypes []string       `json:"usageTypes"`
+		Regions    []string       `json:"regions"`
+		Tags       []TagCondition `json:"tags"`
+	}
+
+	// Category is a cost category of a user. Its rules are evaluated in
+	// order.
+	Category struct {
+		Name         string `json:"name" req:"nonzero"`
+		DefaultValue string `json:"defaultValue" req:"nonzero"`
+		Rules        []Rule `json:"rules"`
+	}
+)
+
+// Validate checks a cost category can be stored and evaluated.
+func (c Category) Validate() error {
+	if c.Name == "" || strings.ContainsAny(c.Name, ":,") {
+		return ErrInvalidName
+	} else if len(c.Rules) > maxRules {
+		return ErrTooManyRules
+	}
+	for i, rule := range c.Rules {
+		if rule.Value == "" {
+			return fmt.Errorf("Rule %d of the cost category has no value.", i+1)
+		} else if len(rule.Accounts)+len(rule.Products)+len(rule.UsageTypes)+len(rule.Regions)+len(rule.Tags) == 0 {
+			return fmt.Errorf("Rule %d of the cost category has no condition.", i+1)
+		}
+		for _, tag := range rule.Tags {
+			if tag.Key == "" {
+				return fmt.Errorf("A tag condition of rule %d of the cost category has no key.", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// Values returns the values of a cost category, in the order of its rules
+// and then its default value.
+func (c Category) Values() []string {
+	values := []string{}
+	seen := make(map[string]bool)
+	for _, value := range append(c.ruleValues(), c.DefaultValue) {
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func (c Category) ruleValues() []string {
+	values := make([]string, len(c.Rules))
+	for i, rule := range c.Rules {
+		values[i] = rule.Value
+	}
+	return values
+}
+
+// Queries returns, for each value of a cost category, the query matching the
+// line items which get this value.
+func (c Category) Queries() map[string]elastic.Query {
+	matches := make(map[string][]elastic.Query)
+	previous := []elastic.Query{}
+	for _, rule := range c.Rules {
+		query := elastic.NewBoolQuery().Filter(rule.query()).MustNot(previous...)
+		matches[rule.Value] = append(matches[rule.Value], query)
+		previous = append(previous, rule.query())
+	}
+	matches[c.DefaultValue] = append(matches[c.DefaultValue], elastic.NewBoolQuery().MustNot(previous...))
+	queries := make(map[string]elastic.Query, len(matches))
+	for value, match := range matches {
+		queries[value] = elastic.NewBoolQuery().Should(match...).MinimumNumberShouldMatch(1)
+	}
+	return queries
+}
+
+// Aggregation returns a FiltersAggregation with one named filter per value of
+// a cost category.
+func (c Category) Aggregation() *elastic.FiltersAggregation {
+	aggregation := elastic.NewFiltersAggregation()
+	queries := c.Queries()
+	for _, value := range c.Values() {
+		aggregation = aggregation.FilterWithName(value, queries[value])
+	}
+	return aggregation
+}
+
+// query returns the query matching the line items matched by a rule.
+func (r Rule) query() elastic.Query {
+	query := elastic.NewBoolQuery()
+	for _, condition := range []struct {
+		field  string
+		values []string
+	}{
+		{"usageAccountId", r.Accounts},
+		{"productCode", r.Products},
+		{"usageType", r.UsageTypes},
+		{"region", r.Regions},
+	} {
+		if len(condition.values) > 0 {
+			query = query.Filter(elastic.NewTermsQuery(condition.field, stringsToInterfaces(condition.values)...))
+		}
+	}
+	for _, tag := range r.Tags {
+		tagQuery := elastic.NewBoolQuery().Filter(elastic.NewTermQuery("tags.key", tag.Key))
+		if len(tag.Values) > 0 {
+			tagQuery = tagQuery.Filter(elastic.NewTermsQuery("tags.tag", stringsToInterfaces(tag.Values)...))
+		}
+		query = query.Filter(elastic.NewNestedQuery("tags", tagQuery))
+	}
+	return query
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	res := make([]interface{}, len(values))
+	for i, value := range values {
+		res[i] = value
+	}
+	return res
+}
+
+// categoryFromDbCostCategory builds a Category from its database
+// representation.
+func categoryFromDbCostCategory(dbCategory models.CostCategory) (Category, error) {
+	category := Category{
+		Name:         dbCategory.Name,
+		DefaultValue: dbCategory.DefaultValue,
+		Rules:        []Rule{},
+	}
+	err := json.Unmarshal(dbCategory.Rules, &category.Rules)
+	return category, err
+}
+
+// GetCategories returns the cost categories of a user.
+func GetCategories(db models.XODB, userId int) ([]Category, error) {
+	dbCategories, err := models.CostCategoriesByUserID(db, userId)
+	if err != nil {
+		return nil, err
+	}
+	categories := make([]Category, len(dbCategories))
+	for i, dbCategory := range dbCategories {
+		if categories[i], err = categoryFromDbCostCategory(*dbCategory); err != nil {
+			return nil, err
+		}
+	}
+	return categories, nil
+}
+
+// GetCategory returns the cost category of a user with a name. It returns
+// ErrCategoryNotFound if there is none.
+func GetCategory(db models.XODB, userId int, name string) (Category, error) {
+	dbCategory, err := models.CostCategoryByUserIDName(db, userId, name)
+	if err == sql.ErrNoRows {
+		return Category{}, ErrCategoryNotFound
+	} else if err != nil {
+		return Category{}, err
+	}
+	return categoryFromDbCostCategory(*dbCategory)
+}
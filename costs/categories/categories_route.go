@@ -0,0 +1,200 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package categories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/audit"
+	"github.com/trackit/trackit/cache"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+// categoryNameQueryArg is the name of the cost category which is deleted.
+var categoryNameQueryArg = routes.QueryArg{
+	Name:        "name",
+	Type:        routes.QueryArgString{},
+	Description: "The name of the cost category.",
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getCategories).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			routes.Documentation{
+				Summary:     "get the cost categories",
+				Description: "Responds with the cost categories of the user",
+			},
+		),
+		http.MethodPut: routes.H(putCategory).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{Category{
+				Name:         "team",
+				DefaultValue: "shared",
+				Rules: []Rule{
+					{Value: "platform", Accounts: []string{"123456789012"}},
+					{Value: "platform", Products: []string{"AmazonEKS"}},
+					{Value: "platform", Tags: []TagCondition{{Key: "team", Values: []string{"infra"}}}},
+					{Value: "data", Products: []string{"AmazonRedshift", "AmazonEMR"}, Regions: []string{"eu-west-1"}},
+				},
+			}},
+			routes.Documentation{
+				Summary:     "create or replace a cost category",
+				Description: "Creates the cost category with the name of the body, or replaces it if it exists",
+			},
+		),
+		http.MethodDelete: routes.H(deleteCategory).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.QueryArgs{categoryNameQueryArg},
+			routes.Documentation{
+				Summary:     "delete a cost category",
+				Description: "Deletes the cost category with a name",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{Db: db.Db},
+		routes.Documentation{
+			Summary:     "interact with the cost categories",
+			Description: "A cost category splits the costs into business values by ordered rules over the accounts, products, usage types, regions and tags of the line items. It can be used as the 'category:<NAME>' criterion of /costs.",
+		},
+	).Register("/costs/categories")
+}
+
+// getCategories is a route handler which returns the cost categories of the
+// user.
+func getCategories(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	categories, err := GetCategories(tx, user.Id)
+	if err != nil {
+		l.Error("Failed to get cost categories", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve cost categories.")
+	}
+	return http.StatusOK, categories
+}
+
+// putCategory is a route handler which creates or replaces a cost category
+// of the user.
+func putCategory(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	var body Category
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	if body.Rules == nil {
+		body.Rules = []Rule{}
+	}
+	if err := body.Validate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	var before interface{}
+	dbCategory, err := models.CostCategoryByUserIDName(tx, user.Id, body.Name)
+	if err == sql.ErrNoRows {
+		dbCategory = &models.CostCategory{
+			UserID: user.Id,
+			Name:   body.Name,
+		}
+		err = nil
+	} else if err == nil {
+		before, err = categoryFromDbCostCategory(*dbCategory)
+	}
+	if err == nil {
+		dbCategory.DefaultValue = body.DefaultValue
+		if dbCategory.Rules, err = json.Marshal(body.Rules); err == nil {
+			err = dbCategory.Save(tx)
+		}
+	}
+	if err != nil {
+		l.Error("Failed to save cost category", map[string]interface{}{
+			"userId": user.Id,
+			"name":   body.Name,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to update cost category.")
+	}
+	if err := audit.Record(r, tx, user, audit.Entry{
+		Action:     audit.ActionCostCategoryUpdate,
+		TargetType: audit.TargetCostCategory,
+		TargetId:   body.Name,
+		Before:     before,
+		After:      body,
+	}); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	invalidateCache(r, tx, user)
+	return http.StatusOK, body
+}
+
+// deleteCategory is a route handler which deletes a cost category of the
+// user.
+func deleteCategory(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	name := a[categoryNameQueryArg].(string)
+	dbCategory, err := models.CostCategoryByUserIDName(tx, user.Id, name)
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, ErrCategoryNotFound
+	}
+	var before Category
+	if err == nil {
+		if before, err = categoryFromDbCostCategory(*dbCategory); err == nil {
+			err = dbCategory.Delete(tx)
+		}
+	}
+	if err != nil {
+		l.Error("Failed to delete cost category", map[string]interface{}{
+			"userId": user.Id,
+			"name":   name,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to delete cost category.")
+	}
+	if err := audit.Record(r, tx, user, audit.Entry{
+		Action:     audit.ActionCostCategoryDelete,
+		TargetType: audit.TargetCostCategory,
+		TargetId:   name,
+		Before:     before,
+	}); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	invalidateCache(r, tx, user)
+	return http.StatusOK, nil
+}
+
+// invalidateCache removes the cached costs of the accounts available to the
+// user, which may have been split by the cost category which was changed.
+func invalidateCache(r *http.Request, tx *sql.Tx, user users.User) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	if err := cache.InvalidateUser(cache.EventCostCategories, tx, user, l); err != nil {
+		l.Error("Failed to remove cache", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+	}
+}
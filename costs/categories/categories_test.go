@@ -0,0 +1,103 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package categories
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var testCategory = Category{
+	Name:         "team",
+	DefaultValue: "shared",
+	Rules: []Rule{
+		{Value: "platform", Accounts: []string{"123456789012"}},
+		{Value: "data", Products: []string{"AmazonRedshift"}, Regions: []string{"eu-west-1"}},
+		{Value: "platform", Tags: []TagCondition{{Key: "team", Values: []string{"infra"}}}},
+	},
+}
+
+func TestValidate(t *testing.T) {
+	if err := testCategory.Validate(); err != nil {
+		t.Errorf("Category should be valid, got %s.", err)
+	}
+	for _, c := range []Category{
+		{Name: "", DefaultValue: "shared"},
+		{Name: "team:a", DefaultValue: "shared"},
+		{Name: "team,a", DefaultValue: "shared"},
+		{Name: "team", DefaultValue: "shared", Rules: []Rule{{Value: "", Products: []string{"AmazonEC2"}}}},
+		{Name: "team", DefaultValue: "shared", Rules: []Rule{{Value: "compute"}}},
+		{Name: "team", DefaultValue: "shared", Rules: []Rule{{Value: "compute", Tags: []TagCondition{{Values: []string{"a"}}}}}},
+		{Name: "team", DefaultValue: "shared", Rules: make([]Rule, maxRules+1)},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Category %v should be invalid.", c)
+		}
+	}
+}
+
+func TestValues(t *testing.T) {
+	expected := []string{"platform", "data", "shared"}
+	if values := testCategory.Values(); !reflect.DeepEqual(values, expected) {
+		t.Errorf("Values should be %v, are %v.", expected, values)
+	}
+	category := Category{Name: "team", DefaultValue: "platform", Rules: testCategory.Rules}
+	expected = []string{"platform", "data"}
+	if values := category.Values(); !reflect.DeepEqual(values, expected) {
+		t.Errorf("Values should be %v, are %v.", expected, values)
+	}
+}
+
+func querySource(t *testing.T, query interface {
+	Source() (interface{}, error)
+}) string {
+	source, err := query.Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(raw)
+}
+
+func TestQueries(t *testing.T) {
+	category := Category{
+		Name:         "team",
+		DefaultValue: "shared",
+		Rules: []Rule{
+			{Value: "platform", Accounts: []string{"123456789012"}},
+			{Value: "data", Tags: []TagCondition{{Key: "team"}}},
+		},
+	}
+	platform := `{"terms":{"usageAccountId":["123456789012"]}}`
+	data := `{"nested":{"path":"tags","query":{"bool":{"filter":{"term":{"tags.key":"team"}}}}}}`
+	expected := map[string]string{
+		"platform": `{"bool":{"minimum_should_match":"1","should":{"bool":{"filter":{"bool":{"filter":` + platform + `}}}}}}`,
+		"data":     `{"bool":{"minimum_should_match":"1","should":{"bool":{"filter":{"bool":{"filter":` + data + `}},"must_not":{"bool":{"filter":` + platform + `}}}}}}`,
+		"shared":   `{"bool":{"minimum_should_match":"1","should":{"bool":{"must_not":[{"bool":{"filter":` + platform + `}},{"bool":{"filter":` + data + `}}]}}}}`,
+	}
+	queries := category.Queries()
+	if len(queries) != len(expected) {
+		t.Fatalf("There should be %d queries, there are %d.", len(expected), len(queries))
+	}
+	for value, query := range queries {
+		if source := querySource(t, query); source != expected[value] {
+			t.Errorf("Query of %s should be %s, is %s.", value, expected[value], source)
+		}
+	}
+}
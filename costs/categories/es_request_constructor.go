@@ -0,0 +1,50 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package categories
+
+import (
+	"context"
+	"time"
+
+	"github.com/olivere/elastic"
+)
+
+// GetCosts retrieves the cost of each value of a cost category over the line
+// items of the accounts in a time range. Like the costs requests, it excludes
+// the AWSDataTransfer product whose cost is included in the other products.
+// costField is the line item field holding the cost to sum, as returned by
+// s3.GetCostTypeField.
+func GetCosts(ctx context.Context, category Category, accountList []string, durationBegin time.Time,
+	durationEnd time.Time, costField string, client *elastic.Client, index string) (map[string]float64, error) {
+	query := elastic.NewBoolQuery().Filter(
+		elastic.NewTermsQuery("usageAccountId", stringsToInterfaces(accountList)...),
+		elastic.NewRangeQuery("usageStartDate").From(durationBegin).To(durationEnd),
+		elastic.NewBoolQuery().MustNot(elastic.NewTermQuery("productCode", "AWSDataTransfer")),
+	)
+	aggregation := category.Aggregation().SubAggregation("cost", elastic.NewSumAggregation().Field(costField))
+	res, err := client.Search().Index(index).Size(0).Query(query).Aggregation("values", aggregation).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	costs := make(map[string]float64)
+	if values, found := res.Aggregations.Filters("values"); found {
+		for value, bucket := range values.NamedBuckets {
+			if cost, found := bucket.Sum("cost"); found && cost.Value != nil {
+				costs[value] = *cost.Value
+			}
+		}
+	}
+	return costs, nil
+}
@@ -17,16 +17,21 @@ package costs
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/olivere/elastic"
 	"github.com/trackit/jsonlog"
 
+	"github.com/trackit/trackit/aws"
 	"github.com/trackit/trackit/aws/s3"
 	"github.com/trackit/trackit/cache"
+	"github.com/trackit/trackit/costs/categories"
+	"github.com/trackit/trackit/costs/redistribution"
 	"github.com/trackit/trackit/db"
 	"github.com/trackit/trackit/errors"
 	"github.com/trackit/trackit/es"
@@ -36,7 +41,8 @@ import (
 
 // simpleCriterionMap will map simple criterion to the boolean true.
 // This will be used in parseCriterionQueryParams to validate the queryParam.
-// It does not take into account the 'tag:*' criterion as it is not fixed.
+// It does not take into account the 'tag:*' and 'category:*' criteria as they
+// are not fixed.
 var simpleCriterionMap = map[string]bool{
 	"year":             true,
 	"month":            true,
@@ -46,6 +52,8 @@ var simpleCriterionMap = map[string]bool{
 	"product":          true,
 	"region":           true,
 	"availabilityzone": true,
+	"provider":         true,
+	"ou":               true,
 }
 
 // EsQueryParams will store the parsed query params
@@ -55,6 +63,16 @@ type EsQueryParams struct {
 	AccountList       []string
 	IndexList         []string
 	AggregationParams []string
+	CostType          string
+	// UnitAccounts are the accounts of each organizational unit of the
+	// 'ou' criterion.
+	UnitAccounts map[string][]string
+	// Categories are the cost categories of the 'category:*' criteria, by
+	// name.
+	Categories map[string]categories.Category
+	// Redistributions are the redistribution rules applied to get the
+	// allocated costs.
+	Redistributions []redistribution.Rule
 }
 
 // costQueryArgs allows to get required queryArgs params
@@ -64,10 +82,13 @@ var costsQueryArgs = []routes.QueryArg{
 	routes.DateEndQueryArg,
 	routes.QueryArg{
 		Name:        "by",
-		Description: "Criteria for the ES aggregation, comma separated. Possible values are year, month, week, day, account, product, region, tag(soon)",
+		Description: "Criteria for the ES aggregation, comma separated. Possible values are year, month, week, day, account, product, region, availabilityzone, provider, ou, tag:<TAG_KEY>, category:<NAME>. The ou criterion rolls the costs up by the organizational units directly in the ou query arg, or in the roots of the AWS organizations without it. The category criterion splits the costs by the values of one of the user's cost categories",
 		Type:        routes.QueryArgStringSlice{},
 		Optional:    false,
 	},
+	routes.CostTypeQueryArg,
+	routes.OrganizationUnitOptionalQueryArg,
+	routes.AllocatedOptionalQueryArg,
 }
 
 func init() {
@@ -76,10 +97,10 @@ func init() {
 			db.RequestTransaction{Db: db.Db},
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
 			routes.QueryArgs(costsQueryArgs),
-			cache.UsersCache{},
+			cache.UsersCache{Events: []string{cache.EventBillingData, cache.EventCostCategories, cache.EventCostRedistributions}},
 			routes.Documentation{
 				Summary:     "get the costs data",
-				Description: "Responds with cost data based on the query args passed to it",
+				Description: "Responds with cost data based on the query args passed to it. With the allocated query arg, the pools of the redistribution rules whose target is one of the criteria are spread over their targets. With the Accept header set to text/csv, application/x-ndjson or the XLSX content type, the costs are exported with one row per bucket of the last criterion.",
 			},
 		),
 	}.H().Register("/costs")
@@ -87,15 +108,15 @@ func init() {
 
 // validateCriteraParam will validate the different criterions.
 // It validate the criterion by checking its presence in the simpleCriterionMap
-// or, in the case of the special criterion tag, will check if it is in the
-// correct format : 'tag:*' (with no more than one ':')
-// Right now the tags are not enabled and will generate an error if they are
-// used because they are not yet implemented in the new ElasticSearch mapping
+// or, in the case of the special criteria tag and category, will check if it
+// is in the correct format : 'tag:*' or 'category:*' (with no more than one ':')
 func validateCriteriaParam(parsedParams EsQueryParams) error {
 	for _, criterion := range parsedParams.AggregationParams {
 		if !simpleCriterionMap[criterion] {
 			if len(criterion) >= 5 && criterion[:4] == "tag:" && strings.Count(criterion, ":") == 1 {
-				return fmt.Errorf("tags not yet implemented")
+				continue
+			} else if len(criterion) > len(categories.Criterion)+1 && strings.HasPrefix(criterion, categories.Criterion+":") && strings.Count(criterion, ":") == 1 {
+				continue
 			}
 			return fmt.Errorf("Error parsing criterion : %s", criterion)
 		}
@@ -103,6 +124,104 @@ func validateCriteriaParam(parsedParams EsQueryParams) error {
 	return nil
 }
 
+// hasCriterion checks whether a criterion is one of the aggregation
+// criteria of a request.
+func hasCriterion(parsedParams EsQueryParams, criterion string) bool {
+	for _, c := range parsedParams.AggregationParams {
+		if c == criterion {
+			return true
+		}
+	}
+	return false
+}
+
+// setOrganizationUnitParams restricts the accounts of a request to the ones
+// in an organizational unit and splits them by the units directly in it for
+// the 'ou' criterion. The accounts which were requested and are not in the
+// unit are left out.
+func setOrganizationUnitParams(tx *sql.Tx, user users.User, unitId string, parsedParams *EsQueryParams) error {
+	organization, err := aws.GetOrganizationWithUnit(tx, user, unitId)
+	if err != nil {
+		return err
+	}
+	accounts := organization.AccountsInUnit(unitId)
+	if len(parsedParams.AccountList) > 0 {
+		requested := make(map[string]bool, len(parsedParams.AccountList))
+		for _, account := range parsedParams.AccountList {
+			requested[account] = true
+		}
+		inUnit := []string{}
+		for _, account := range accounts {
+			if requested[account] {
+				inUnit = append(inUnit, account)
+			}
+		}
+		accounts = inUnit
+	}
+	parsedParams.AccountList = accounts
+	parsedParams.UnitAccounts = organization.UnitBuckets(unitId)
+	return nil
+}
+
+// setOrganizationsParams splits the accounts of a request by the units
+// directly in the roots of the AWS organizations available to the user, for
+// the 'ou' criterion.
+func setOrganizationsParams(tx *sql.Tx, user users.User, parsedParams *EsQueryParams) error {
+	organizations, err := aws.GetOrganizationsFromUser(user, tx)
+	if err != nil {
+		return err
+	}
+	parsedParams.UnitAccounts = make(map[string][]string)
+	for _, organization := range organizations {
+		for unitId, accounts := range organization.RootBuckets() {
+			parsedParams.UnitAccounts[unitId] = accounts
+		}
+	}
+	return nil
+}
+
+// setCategoriesParams retrieves the cost categories of the user used in a
+// 'category:*' criterion.
+func setCategoriesParams(tx *sql.Tx, user users.User, parsedParams *EsQueryParams) error {
+	parsedParams.Categories = make(map[string]categories.Category)
+	for _, criterion := range parsedParams.AggregationParams {
+		if !strings.HasPrefix(criterion, categories.Criterion+":") {
+			continue
+		}
+		name := strings.TrimPrefix(criterion, categories.Criterion+":")
+		if _, ok := parsedParams.Categories[name]; ok {
+			continue
+		}
+		category, err := categories.GetCategory(tx, user.Id, name)
+		if err != nil {
+			return err
+		}
+		parsedParams.Categories[name] = category
+	}
+	return nil
+}
+
+// getTagValuesForCriteria retrieves the values of every tag key used in a
+// 'tag:*' criterion, mapped by tag key.
+func getTagValuesForCriteria(ctx context.Context, parsedParams EsQueryParams, index string) (map[string][]string, error) {
+	tagValues := make(map[string][]string)
+	for _, criterion := range parsedParams.AggregationParams {
+		if !strings.HasPrefix(criterion, "tag:") {
+			continue
+		}
+		tagKey := strings.TrimPrefix(criterion, "tag:")
+		if _, ok := tagValues[tagKey]; ok {
+			continue
+		}
+		values, err := GetTagValues(ctx, parsedParams.AccountList, parsedParams.DateBegin, parsedParams.DateEnd, tagKey, es.Client, index)
+		if err != nil {
+			return nil, err
+		}
+		tagValues[tagKey] = values
+	}
+	return tagValues, nil
+}
+
 // MakeElasticSearchRequestAndParseIt will make the actual request to the ElasticSearch parse the results and return them
 // It will return the data, an http status code (as int) and an error.
 // Because an error can be generated, but is not critical and is not needed to be known by
@@ -112,38 +231,69 @@ func validateCriteriaParam(parsedParams EsQueryParams) error {
 func MakeElasticSearchRequestAndParseIt(ctx context.Context, parsedParams EsQueryParams) (es.SimplifiedCostsDocument, int, error) {
 	l := jsonlog.LoggerFromContextOrDefault(ctx)
 	index := strings.Join(parsedParams.IndexList, ",")
+	res, err := searchCosts(ctx, parsedParams, index, nil)
+	if err != nil {
+		returnCode, err := searchErrorStatus(ctx, index, err)
+		return es.SimplifiedCostsDocument{}, returnCode, err
+	}
+	simplifiedCostDocument, err := es.SimplifyCostsDocument(ctx, res)
+	if err != nil {
+		l.Error("Error parsing cost response : "+err.Error(), nil)
+		return simplifiedCostDocument, http.StatusInternalServerError, fmt.Errorf("could not parse ElasticSearch response")
+	}
+	return simplifiedCostDocument, http.StatusOK, nil
+}
+
+// searchErrorStatus logs an error of a costs request on ElasticSearch and
+// returns the status code and error to respond with. See
+// MakeElasticSearchRequestAndParseIt.
+func searchErrorStatus(ctx context.Context, index string, err error) (int, error) {
+	l := jsonlog.LoggerFromContextOrDefault(ctx)
+	if elastic.IsNotFound(err) {
+		l.Warning("Query execution failed, ES index does not exists", map[string]interface{}{
+			"index": index,
+			"error": err.Error(),
+		})
+		return http.StatusOK, errors.GetErrorMessage(ctx, err)
+	} else if cast, ok := err.(*elastic.Error); ok && cast.Details != nil && cast.Details.Type == "search_phase_execution_exception" {
+		l.Error("Error while getting data from ES", map[string]interface{}{
+			"type":  fmt.Sprintf("%T", err),
+			"error": err,
+		})
+	} else {
+		l.Error("Query execution failed", map[string]interface{}{"error": err.Error()})
+	}
+	return http.StatusInternalServerError, errors.GetErrorMessage(ctx, err)
+}
+
+// searchCosts retrieves the values of the tags used as criteria, if any, and
+// then performs the costs request on ElasticSearch. The line items can be
+// restricted further by a filter, which may be nil.
+func searchCosts(ctx context.Context, parsedParams EsQueryParams, index string, filter elastic.Query) (*elastic.SearchResult, error) {
+	costField, err := s3.GetCostTypeField(parsedParams.CostType)
+	if err != nil {
+		return nil, err
+	}
+	tagValues, err := getTagValuesForCriteria(ctx, parsedParams, index)
+	if err != nil {
+		return nil, err
+	}
 	searchService := GetElasticSearchParams(
 		parsedParams.AccountList,
 		parsedParams.DateBegin,
 		parsedParams.DateEnd,
 		parsedParams.AggregationParams,
+		costField,
+		tagValues,
+		parsedParams.UnitAccounts,
+		parsedParams.Categories,
 		es.Client,
 		index,
 	)
-	res, err := searchService.Do(ctx)
-	if err != nil {
-		if elastic.IsNotFound(err) {
-			l.Warning("Query execution failed, ES index does not exists", map[string]interface{}{
-				"index": index,
-				"error": err.Error(),
-			})
-			return es.SimplifiedCostsDocument{}, http.StatusOK, errors.GetErrorMessage(ctx, err)
-		} else if cast, ok := err.(*elastic.Error); ok && cast.Details != nil && cast.Details.Type == "search_phase_execution_exception" {
-			l.Error("Error while getting data from ES", map[string]interface{}{
-				"type":  fmt.Sprintf("%T", err),
-				"error": err,
-			})
-		} else {
-			l.Error("Query execution failed", map[string]interface{}{"error": err.Error()})
-		}
-		return es.SimplifiedCostsDocument{}, http.StatusInternalServerError, errors.GetErrorMessage(ctx, err)
-	}
-	simplifiedCostDocument, err := es.SimplifyCostsDocument(ctx, res)
-	if err != nil {
-		l.Error("Error parsing cost response : "+err.Error(), nil)
-		return simplifiedCostDocument, http.StatusInternalServerError, fmt.Errorf("could not parse ElasticSearch response")
+	if filter != nil {
+		searchService.Query(createQueryFilters(parsedParams.AccountList, parsedParams.DateBegin, parsedParams.DateEnd).Filter(filter))
 	}
-	return simplifiedCostDocument, http.StatusOK, nil
+	return searchService.Do(ctx)
 }
 
 // getCostsData returns the cost data based on the query params, in JSON format.
@@ -158,23 +308,84 @@ func getCostData(request *http.Request, a routes.Arguments) (int, interface{}) {
 	if a[costsQueryArgs[0]] != nil {
 		parsedParams.AccountList = a[costsQueryArgs[0]].([]string)
 	}
+	if a[costsQueryArgs[4]] != nil {
+		parsedParams.CostType = a[costsQueryArgs[4]].(string)
+	}
 	if err := validateCriteriaParam(parsedParams); err != nil {
 		return http.StatusBadRequest, err
 	}
+	if _, err := s3.GetCostTypeField(parsedParams.CostType); err != nil {
+		return http.StatusBadRequest, err
+	}
 	tx := a[db.Transaction].(*sql.Tx)
+	if unitId, ok := a[costsQueryArgs[5]].(string); ok {
+		if err := setOrganizationUnitParams(tx, user, unitId, &parsedParams); err == aws.ErrOrganizationUnitNotFound {
+			return http.StatusNotFound, err
+		} else if err != nil {
+			return http.StatusInternalServerError, errors.GetErrorMessage(request.Context(), err)
+		} else if len(parsedParams.AccountList) == 0 {
+			return http.StatusOK, costsResponse{Criteria: parsedParams.AggregationParams}
+		}
+	} else if hasCriterion(parsedParams, "ou") {
+		if err := setOrganizationsParams(tx, user, &parsedParams); err != nil {
+			return http.StatusInternalServerError, errors.GetErrorMessage(request.Context(), err)
+		}
+	}
+	if err := setCategoriesParams(tx, user, &parsedParams); err == categories.ErrCategoryNotFound {
+		return http.StatusNotFound, err
+	} else if err != nil {
+		return http.StatusInternalServerError, errors.GetErrorMessage(request.Context(), err)
+	}
 	accountsAndIndexes, returnCode, err := es.GetAccountsAndIndexes(parsedParams.AccountList, user, tx, s3.IndexPrefixLineItem)
 	if err != nil {
 		return returnCode, err
 	}
 	parsedParams.AccountList = accountsAndIndexes.Accounts
 	parsedParams.IndexList = accountsAndIndexes.Indexes
-	simplifiedCostDocument, returnCode, err := MakeElasticSearchRequestAndParseIt(request.Context(), parsedParams)
+	var simplifiedCostDocument es.SimplifiedCostsDocument
+	if allocated, ok := a[costsQueryArgs[6]].(bool); ok && allocated {
+		if parsedParams.Redistributions, err = redistribution.GetRules(tx, user.Id); err != nil {
+			return http.StatusInternalServerError, errors.GetErrorMessage(request.Context(), err)
+		}
+		simplifiedCostDocument, returnCode, err = GetAllocatedCosts(request.Context(), parsedParams)
+	} else {
+		simplifiedCostDocument, returnCode, err = MakeElasticSearchRequestAndParseIt(request.Context(), parsedParams)
+	}
 	if err != nil {
 		if returnCode == http.StatusOK {
-			return returnCode, es.SimplifiedCostsDocument{}.ToJsonable()
+			return returnCode, costsResponse{Criteria: parsedParams.AggregationParams}
 		} else {
 			return returnCode, err
 		}
 	}
-	return http.StatusOK, simplifiedCostDocument.ToJsonable()
+	return http.StatusOK, costsResponse{simplifiedCostDocument, parsedParams.AggregationParams}
+}
+
+// costsResponse is the response of the /costs route. It is marshaled as the
+// nested breakdown of the costs and exported with one row per bucket of the
+// last criterion, written as the breakdown is walked.
+type costsResponse struct {
+	Document es.SimplifiedCostsDocument
+	Criteria []string
+}
+
+func (cr costsResponse) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cr.Document.ToJsonable())
+}
+
+// ExportColumns returns the columns of the export of a costsResponse
+func (cr costsResponse) ExportColumns() []string {
+	return append(append([]string{}, cr.Criteria...), "cost")
+}
+
+// EachExportRow calls f with each row of the export of a costsResponse
+func (cr costsResponse) EachExportRow(f func(row []interface{}) error) error {
+	cells := make([]interface{}, len(cr.Criteria)+1)
+	return cr.Document.EachRow(func(row es.CostsRow) error {
+		for i, key := range row.Keys {
+			cells[i] = key
+		}
+		cells[len(cells)-1] = json.Number(strconv.FormatFloat(row.Value, 'f', -1, 64))
+		return f(cells)
+	})
 }
@@ -56,6 +56,7 @@ type esQueryParams struct {
 	accountList       []string
 	indexList         []string
 	aggregationPeriod string
+	costType          string
 }
 
 // diffQueryArgs allows to get required queryArgs params
@@ -69,6 +70,7 @@ var diffQueryArgs = []routes.QueryArg{
 		Type:        routes.QueryArgString{},
 		Optional:    false,
 	},
+	routes.CostTypeQueryArg,
 }
 
 func init() {
@@ -77,7 +79,7 @@ func init() {
 			db.RequestTransaction{Db: db.Db},
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
 			routes.QueryArgs(diffQueryArgs),
-			cache.UsersCache{},
+			cache.UsersCache{Events: []string{cache.EventBillingData}},
 			routes.Documentation{
 				Summary:     "get the cost diff",
 				Description: "Responds with the cost diff based on the query args passed to it",
@@ -95,11 +97,16 @@ func init() {
 func makeElasticSearchRequest(ctx context.Context, parsedParams esQueryParams) (*elastic.SearchResult, int, error) {
 	l := jsonlog.LoggerFromContextOrDefault(ctx)
 	index := strings.Join(parsedParams.indexList, ",")
+	costField, err := s3.GetCostTypeField(parsedParams.costType)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
 	searchService := GetElasticSearchParams(
 		parsedParams.accountList,
 		parsedParams.dateBegin,
 		parsedParams.dateEnd,
 		parsedParams.aggregationPeriod,
+		costField,
 		es.Client,
 		index,
 	)
@@ -187,9 +194,15 @@ func prepareGetDiffData(request *http.Request, a routes.Arguments) (int, interfa
 	if a[diffQueryArgs[0]] != nil {
 		parsedParams.accountList = a[diffQueryArgs[0]].([]string)
 	}
+	if a[diffQueryArgs[4]] != nil {
+		parsedParams.costType = a[diffQueryArgs[4]].(string)
+	}
 	if _, ok := validAggregationPeriodMap[parsedParams.aggregationPeriod]; ok == false {
 		return http.StatusBadRequest, fmt.Errorf("invalid aggregation period : %s", parsedParams.aggregationPeriod)
 	}
+	if _, err := s3.GetCostTypeField(parsedParams.costType); err != nil {
+		return http.StatusBadRequest, err
+	}
 	tx := a[db.Transaction].(*sql.Tx)
 	accountsAndIndexes, returnCode, err := es.GetAccountsAndIndexes(parsedParams.accountList, user, tx, s3.IndexPrefixLineItem)
 	if err != nil {
@@ -46,6 +46,8 @@ func createQueryTimeRange(durationBegin time.Time, durationEnd time.Time) *elast
 //	'awsdetailedlineitem.linked_account_id'
 //	- durationBeing time.Time : A time.Time struct representing the begining of the time range in the query
 //	- durationEnd time.Time : A time.Time struct representing the end of the time range in the query
+//	- aggregationPeriod string : The period of the date histogram, "month" or "week"
+//	- costField string : The line item field holding the cost to sum, as returned by s3.GetCostTypeField
 //	- client *elastic.Client : an instance of *elastic.Client that represent an Elastic Search client.
 //	- index string : The Elastic Search index on wich to execute the query. In this context the default value
 //	should be "awsdetailedlineitems"
@@ -54,7 +56,7 @@ func createQueryTimeRange(durationBegin time.Time, durationEnd time.Time) *elast
 //	- If the client is nil or malconfigured, it will crash
 //	- If the index is not an index present in the ES, it will crash
 func GetElasticSearchParams(accountList []string, durationBegin time.Time,
-	durationEnd time.Time, aggregationPeriod string, client *elastic.Client, index string) *elastic.SearchService {
+	durationEnd time.Time, aggregationPeriod string, costField string, client *elastic.Client, index string) *elastic.SearchService {
 	query := elastic.NewBoolQuery()
 	if len(accountList) > 0 {
 		query = query.Filter(createQueryAccountFilter(accountList))
@@ -64,6 +66,6 @@ func GetElasticSearchParams(accountList []string, durationBegin time.Time,
 
 	search.Aggregation("usageType", elastic.NewTermsAggregation().Field("usageType").Size(aggregationMaxSize).
 		SubAggregation("dateAgg", elastic.NewDateHistogramAggregation().Field("usageStartDate").MinDocCount(0).ExtendedBounds(durationBegin, durationEnd).Interval(aggregationPeriod).
-			SubAggregation("cost", elastic.NewSumAggregation().Field("unblendedCost"))))
+			SubAggregation("cost", elastic.NewSumAggregation().Field(costField))))
 	return search
 }
@@ -16,29 +16,45 @@
 package costs
 
 import (
-	"fmt"
+	"context"
 	"strings"
 	"time"
 
 	"github.com/olivere/elastic"
+
+	"github.com/trackit/trackit/aws/s3"
+	"github.com/trackit/trackit/costs/categories"
 )
 
 // aggregationBuilder is an alias for the function type that is used in the
 // aggregationBuilder functions.
 type aggregationBuilder func([]string) []paramAggrAndName
 
+// untaggedBucketKey is the key of the bucket holding the line items that do
+// not carry the tag key requested by a 'tag:<TAG_KEY>' param.
+const untaggedBucketKey = "untagged"
+
+// unassignedBucketKey is the key of the bucket holding the line items of the
+// accounts which are in none of the organizational units of an 'ou' param.
+const unassignedBucketKey = "unassigned"
+
 // paramNameToFuncPtr maps parameter names to functions building the aggregations.
 // map of string keys and functions pointer as values. For each possible param
 // after parsing (removing the ':<TAG_KEY>' in the case of the tag), there is a function associated to it
 // that create the Aggregations needed by this param.
 // If a new param, that is only creating aggregations, needs to be added,
 // a functions with an aggregationBuilder prototype need to be added to the list below.
+// The 'tag' param is not part of this map as it depends on the tag values
+// present in the index, see createAggregationPerTag. Neither are the 'ou'
+// param, which depends on the AWS organizations, see createAggregationPerUnit,
+// and the 'category' param, which depends on the cost categories of the user,
+// see createAggregationPerCategory.
 var paramNameToFuncPtr = map[string]aggregationBuilder{
 	"product":          createAggregationPerProduct,
 	"availabilityzone": createAggregationPerAvailabilityZone,
 	"region":           createAggregationPerRegion,
 	"account":          createAggregationPerAccount,
-	"tag":              createAggregationPerTag,
+	"provider":         createAggregationPerProvider,
 	"cost":             createCostSumAggregation,
 	"day":              createAggregationPerDay,
 	"week":             createAggregationPerWeek,
@@ -122,6 +138,19 @@ func createAggregationPerAccount(_ []string) []paramAggrAndName {
 	}
 }
 
+// createAggregationPerProvider creates and returns a new []paramAggrAndName of size 1 which creates a
+// bucket aggregation on the field 'provider'. Line items ingested before providers were introduced
+// have none and are AWS ones.
+func createAggregationPerProvider(_ []string) []paramAggrAndName {
+	return []paramAggrAndName{
+		paramAggrAndName{
+			name: "by-provider",
+			aggr: elastic.NewTermsAggregation().
+				Field("provider").Missing(s3.ProviderAws).Size(aggregationMaxSize),
+		},
+	}
+}
+
 // createAggregationPerDay creates and returns a new []paramAggrAndName of size 1 which creates a
 // date histogram aggregation on the field 'usage_start_date' with a time range of a day
 func createAggregationPerDay(_ []string) []paramAggrAndName {
@@ -170,33 +199,82 @@ func createAggregationPerYear(_ []string) []paramAggrAndName {
 	}
 }
 
-// createAggregationPerTag creates and returns a new []paramAggrAndName of size 2 which consits
-// of two aggregations that are required for the tag param.
-// The first aggregation is a FilterAggregation on the field 'tag.key', and with a value of
-// the tag key passed in the parameter 'paramSplit' in the form "user:<TAG_KEY_VALUE>".
-// The second aggregation is a TermsAggregation that creates bucket aggregation on the field
-// 'tag.value'.
-// No SubAggregation is created in this function, as it needs to be created in the nestAggregation function
-func createAggregationPerTag(paramSplit []string) []paramAggrAndName {
+// createQueryTagKeyFilter creates and returns a new *elastic.NestedQuery matching
+// the line items carrying the tag key 'tagKey'
+func createQueryTagKeyFilter(tagKey string) *elastic.NestedQuery {
+	return elastic.NewNestedQuery("tags", elastic.NewTermQuery("tags.key", tagKey))
+}
+
+// createQueryTagFilter creates and returns a new *elastic.NestedQuery matching
+// the line items carrying the tag key 'tagKey' with the value 'tagValue'
+func createQueryTagFilter(tagKey, tagValue string) *elastic.NestedQuery {
+	return elastic.NewNestedQuery("tags", elastic.NewBoolQuery().Filter(
+		elastic.NewTermQuery("tags.key", tagKey),
+		elastic.NewTermQuery("tags.tag", tagValue),
+	))
+}
+
+// createAggregationPerTag creates and returns a new []paramAggrAndName of size 1 which creates a
+// FiltersAggregation with one named filter per value in 'tagValues' for the tag key passed in the
+// parameter 'paramSplit' in the form "tag:<TAG_KEY>".
+// An additional filter named "untagged" matches every line item that does not carry the tag key.
+// A FiltersAggregation is used instead of a TermsAggregation because tags are stored as nested
+// documents, which would otherwise prevent both the untagged bucket and the nesting of the
+// aggregations that are based on the line item itself.
+func createAggregationPerTag(paramSplit []string, tagValues []string) []paramAggrAndName {
+	aggregation := elastic.NewFiltersAggregation()
+	for _, tagValue := range tagValues {
+		aggregation = aggregation.FilterWithName(tagValue, createQueryTagFilter(paramSplit[1], tagValue))
+	}
+	aggregation = aggregation.FilterWithName(untaggedBucketKey,
+		elastic.NewBoolQuery().MustNot(createQueryTagKeyFilter(paramSplit[1])))
 	return []paramAggrAndName{
 		paramAggrAndName{
-			name: "by-tag_key",
-			aggr: elastic.NewFilterAggregation().
-				Filter(elastic.NewTermQuery("tag.key", fmt.Sprintf("user:%v", paramSplit[1])))},
+			name: "by-tag",
+			aggr: aggregation,
+		},
+	}
+}
+
+// createAggregationPerUnit creates and returns a new []paramAggrAndName of size 1 which creates a
+// FiltersAggregation with one named filter per organizational unit in 'unitAccounts', matching the
+// line items of the accounts of the unit.
+// An additional filter named "unassigned" matches every line item of the other accounts.
+func createAggregationPerUnit(unitAccounts map[string][]string) []paramAggrAndName {
+	aggregation := elastic.NewFiltersAggregation()
+	allAccounts := []string{}
+	for unitId, accounts := range unitAccounts {
+		aggregation = aggregation.FilterWithName(unitId, createQueryAccountFilter(accounts))
+		allAccounts = append(allAccounts, accounts...)
+	}
+	aggregation = aggregation.FilterWithName(unassignedBucketKey,
+		elastic.NewBoolQuery().MustNot(createQueryAccountFilter(allAccounts)))
+	return []paramAggrAndName{
 		paramAggrAndName{
-			name: "tag_value",
-			aggr: elastic.NewTermsAggregation().
-				Field("tag.value").Size(aggregationMaxSize)},
+			name: "by-ou",
+			aggr: aggregation,
+		},
+	}
+}
+
+// createAggregationPerCategory creates and returns a new []paramAggrAndName of size 1 which creates a
+// FiltersAggregation with one named filter per value of a cost category, including its default value.
+func createAggregationPerCategory(category categories.Category) []paramAggrAndName {
+	return []paramAggrAndName{
+		paramAggrAndName{
+			name: "by-category",
+			aggr: category.Aggregation(),
+		},
 	}
 }
 
 // createCostSumAggregation : Creates and return a new []paramAggrAndName of size 1, which creates a
-// SumAggregation on the field 'cost'
-func createCostSumAggregation(_ []string) []paramAggrAndName {
+// SumAggregation on the cost field passed in the parameter 'paramSplit' in the form "cost:<FIELD>"
+func createCostSumAggregation(paramSplit []string) []paramAggrAndName {
 	return []paramAggrAndName{
 		paramAggrAndName{
 			name: "value",
-			aggr: elastic.NewSumAggregation().Field("unblendedCost"),
+			aggr: elastic.NewSumAggregation().Field(paramSplit[1]),
 		},
 	}
 }
@@ -213,8 +291,8 @@ func reverseAggregationArray(aggregationArray []paramAggrAndName) []paramAggrAnd
 // nestAggregation takes a slice of paramAggrAndName type, and will nest the different aggregations.
 // Aggregations are nested by creating a chain of SubAggregation
 // A type switch is required to simulate downcasting from the interface elastic.Aggregation.
-// Current types on the type switch are TermsAggregation, FilterAggregation, SumAggregation and
-// DateHistogramAggregation.
+// Current types on the type switch are TermsAggregation, FilterAggregation, FiltersAggregation,
+// SumAggregation and DateHistogramAggregation.
 // If a new function creating a type that is not listed here is added to the paramNameToFuncPtr map
 // it should be added to the type switch, or the function will create bugged SubAggregations
 func nestAggregation(allAggrSlice []paramAggrAndName) elastic.Aggregation {
@@ -228,6 +306,9 @@ func nestAggregation(allAggrSlice []paramAggrAndName) elastic.Aggregation {
 		case *elastic.FilterAggregation:
 			aggrBuff := assertedBaseAggr.SubAggregation(aggrToNest.name, aggrToNest.aggr)
 			aggrToNest = paramAggrAndName{name: baseAggr.name, aggr: aggrBuff}
+		case *elastic.FiltersAggregation:
+			aggrBuff := assertedBaseAggr.SubAggregation(aggrToNest.name, aggrToNest.aggr)
+			aggrToNest = paramAggrAndName{name: baseAggr.name, aggr: aggrBuff}
 		case *elastic.DateHistogramAggregation:
 			aggrBuff := assertedBaseAggr.SubAggregation(aggrToNest.name, aggrToNest.aggr)
 			aggrToNest = paramAggrAndName{name: baseAggr.name, aggr: aggrBuff}
@@ -249,11 +330,22 @@ func nestAggregation(allAggrSlice []paramAggrAndName) elastic.Aggregation {
 //		- "availabilityzone" : It will create a TermsAggregation on the field 'availability_zone'
 //		- "region" : It will create a TermsAggregation on the field 'region'
 //		- "account" : It will create a TermsAggregation on the field 'linked_account_id'
-//		- "tag:<TAG_KEY>" : It will create a FilterAggregation on the field 'tag.key',
-//		filtering on the value 'user:<TAG_KEY>'.
-//		It will then create a TermsAggregation on the field 'tag.value'
+//		- "provider" : It will create a TermsAggregation on the field 'provider'
+//		- "tag:<TAG_KEY>" : It will create a FiltersAggregation with a bucket for each value
+//		of <TAG_KEY> listed in tagValues, and an "untagged" bucket
+//		- "ou" : It will create a FiltersAggregation with a bucket for each organizational unit
+//		of unitAccounts, and an "unassigned" bucket
+//		- "category:<NAME>" : It will create a FiltersAggregation with a bucket for each value
+//		of the cost category <NAME> of costCategories
 //		- "[day|week|month|year]": It will create a DateHistogramAggregation on the specified duration on
 //		the field 'usage_start_date'
+//	- costField string : The line item field holding the cost to sum, as returned by s3.GetCostTypeField
+//	- tagValues map[string][]string : The values of each tag key used in a "tag:<TAG_KEY>" param,
+//	as returned by GetTagValues
+//	- unitAccounts map[string][]string : The accounts of each organizational unit of an "ou" param,
+//	as returned by aws.Organization.UnitBuckets
+//	- costCategories map[string]categories.Category : The cost categories used in a "category:<NAME>"
+//	param, by name
 //	- client *elastic.Client : an instance of *elastic.Client that represent an Elastic Search client.
 //	It needs to be fully configured and ready to execute a client.Search()
 //	- index string : The Elastic Search index on wich to execute the query. In this context the default value
@@ -261,26 +353,32 @@ func nestAggregation(allAggrSlice []paramAggrAndName) elastic.Aggregation {
 // This function excepts arguments passed to it to be sanitize. If they are not, the following cases will make
 // it crash :
 //	- For the 'tag:<TAG_KEY>' param, if the separator is not present, or if there is no key that is passed to it,
-//	the program will crash
+//	the program will crash. The same goes for the 'category:<NAME>' param
 //	- If a param in the slice is not present in the detailedLineItemsFieldsName, the program will crash.
 //	- If the client is nil or malconfigured, it will crash
 //	- If the index is not an index present in the ES, it will crash
 // We are excluding AWSDataTransfer products because it's value is always zero.
 // Data transfer costs are included in other products' costs.
 func GetElasticSearchParams(accountList []string, durationBegin time.Time,
-	durationEnd time.Time, params []string, client *elastic.Client, index string) *elastic.SearchService {
-	query := elastic.NewBoolQuery()
-	if len(accountList) > 0 {
-		query = query.Filter(createQueryAccountFilter(accountList))
-	}
-	query = query.Filter(createQueryTimeRange(durationBegin, durationEnd),
-		elastic.NewBoolQuery().MustNot(elastic.NewTermQuery("productCode", "AWSDataTransfer")))
+	durationEnd time.Time, params []string, costField string, tagValues map[string][]string,
+	unitAccounts map[string][]string, costCategories map[string]categories.Category,
+	client *elastic.Client, index string) *elastic.SearchService {
+	query := createQueryFilters(accountList, durationBegin, durationEnd)
 	search := client.Search().Index(index).Size(0).Query(query)
-	params = append(params, "cost")
+	params = append(params, "cost:"+costField)
 	var allAggregationSlice []paramAggrAndName
 	for _, paramName := range params {
+		var paramAggr []paramAggrAndName
 		paramNameSplit := strings.Split(paramName, ":")
-		paramAggr := paramNameToFuncPtr[paramNameSplit[0]](paramNameSplit)
+		if paramNameSplit[0] == "tag" {
+			paramAggr = createAggregationPerTag(paramNameSplit, tagValues[paramNameSplit[1]])
+		} else if paramNameSplit[0] == "ou" {
+			paramAggr = createAggregationPerUnit(unitAccounts)
+		} else if paramNameSplit[0] == categories.Criterion {
+			paramAggr = createAggregationPerCategory(costCategories[paramNameSplit[1]])
+		} else {
+			paramAggr = paramNameToFuncPtr[paramNameSplit[0]](paramNameSplit)
+		}
 		allAggregationSlice = append(allAggregationSlice, paramAggr...)
 	}
 	aggregationParamName := allAggregationSlice[0].name
@@ -288,3 +386,43 @@ func GetElasticSearchParams(accountList []string, durationBegin time.Time,
 	search.Aggregation(aggregationParamName, nestedAggregation)
 	return search
 }
+
+// createQueryFilters creates and returns the *elastic.BoolQuery filtering the
+// line items on the accounts and the time range of a request.
+// AWSDataTransfer products are excluded, see GetElasticSearchParams.
+func createQueryFilters(accountList []string, durationBegin time.Time, durationEnd time.Time) *elastic.BoolQuery {
+	query := elastic.NewBoolQuery()
+	if len(accountList) > 0 {
+		query = query.Filter(createQueryAccountFilter(accountList))
+	}
+	return query.Filter(createQueryTimeRange(durationBegin, durationEnd),
+		elastic.NewBoolQuery().MustNot(elastic.NewTermQuery("productCode", "AWSDataTransfer")))
+}
+
+// GetTagValues retrieves the values of the tag key 'tagKey' that are present
+// in the line items matching the accounts and time range. Those values are
+// used to build the buckets of a "tag:<TAG_KEY>" param in GetElasticSearchParams.
+func GetTagValues(ctx context.Context, accountList []string, durationBegin time.Time,
+	durationEnd time.Time, tagKey string, client *elastic.Client, index string) ([]string, error) {
+	query := createQueryFilters(accountList, durationBegin, durationEnd).Filter(createQueryTagKeyFilter(tagKey))
+	aggregation := elastic.NewNestedAggregation().Path("tags").SubAggregation("key",
+		elastic.NewFilterAggregation().Filter(elastic.NewTermQuery("tags.key", tagKey)).SubAggregation("values",
+			elastic.NewTermsAggregation().Field("tags.tag").Size(aggregationMaxSize)))
+	res, err := client.Search().Index(index).Size(0).Query(query).Aggregation("tags", aggregation).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tagValues := []string{}
+	if tags, found := res.Aggregations.Nested("tags"); found {
+		if key, found := tags.Filter("key"); found {
+			if values, found := key.Terms("values"); found {
+				for _, bucket := range values.Buckets {
+					if tagValue, ok := bucket.Key.(string); ok {
+						tagValues = append(tagValues, tagValue)
+					}
+				}
+			}
+		}
+	}
+	return tagValues, nil
+}
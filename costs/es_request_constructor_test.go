@@ -170,7 +170,7 @@ func TestAggregationPerMonth(t *testing.T) {
 }
 
 func TestCostSumAggregation(t *testing.T) {
-	res := createCostSumAggregation([]string{""})
+	res := createCostSumAggregation([]string{"cost", "cost"})
 	expectedResult := `{"sum":{"field":"cost"}}`
 	src, err := res[0].aggr.Source()
 	if err != nil {
@@ -218,30 +218,21 @@ func TestAggregationPerYear(t *testing.T) {
 }
 
 func TestAggregationPerTag(t *testing.T) {
-	res := createAggregationPerTag([]string{"tag", "test"})
-	expectedFirstResult := `{"filter":{"term":{"tag.key":"user:test"}}}`
-	expectedSecondResult := `{"terms":{"field":"tag.value","size":2147483647}}`
-	srcFirst, err := res[0].aggr.Source()
-	if err != nil {
-		t.Fatal(err)
-	}
-	srcSecond, err := res[1].aggr.Source()
-	if err != nil {
-		t.Fatal(err)
+	res := createAggregationPerTag([]string{"tag", "team"}, []string{"infra"})
+	expectedResult := `{"filters":{"filters":{"infra":{"nested":{"path":"tags","query":{"bool":{"filter":[{"term":{"tags.key":"team"}},{"term":{"tags.tag":"infra"}}]}}}},"untagged":{"bool":{"must_not":{"nested":{"path":"tags","query":{"term":{"tags.key":"team"}}}}}}}}}`
+	if len(res) != 1 || res[0].name != "by-tag" {
+		t.Fatalf("Expected a single by-tag aggregation but got %v", res)
 	}
-	jsonResFirst, err := json.Marshal(srcFirst)
+	src, err := res[0].aggr.Source()
 	if err != nil {
 		t.Fatal(err)
 	}
-	if string(jsonResFirst) != expectedFirstResult {
-		t.Fatalf("Expected %v but got %v", expectedFirstResult, string(jsonResFirst))
-	}
-	jsonResSecond, err := json.Marshal(srcSecond)
+	jsonRes, err := json.Marshal(src)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if string(jsonResSecond) != expectedSecondResult {
-		t.Fatalf("Expected %v but got %v", expectedSecondResult, string(jsonResSecond))
+	if string(jsonRes) != expectedResult {
+		t.Fatalf("Expected %v but got %v", expectedResult, string(jsonRes))
 	}
 }
 
@@ -324,7 +315,7 @@ func TestAggregationNestingWithCoupleElementsSlice(t *testing.T) {
 
 func TestAggregationNestingWithFewElementsSlice(t *testing.T) {
 	fewAggregationSlice := createAggregationPerTag([]string{"", "test"})
-	buffAggregation := createCostSumAggregation([]string{""})
+	buffAggregation := createCostSumAggregation([]string{"cost", "cost"})
 	fewAggregationSlice = append(fewAggregationSlice, buffAggregation...)
 	expectedResult := `{
 	"aggregations": {
@@ -428,7 +419,7 @@ func TestElasticSearchParamWithNoResults(t *testing.T) {
 		"buckets": []
 	}
 }`
-	searchService := GetElasticSearchParams(accountList, durationBegin, durationEnd, params, client, index)
+	searchService := GetElasticSearchParams(accountList, durationBegin, durationEnd, params, "cost", nil, nil, nil, client, index)
 	res, err := searchService.Do(context.Background())
 	if err != nil {
 		t.Fatal(err)
@@ -537,7 +528,7 @@ func TestElasticSearchParamWithFewResultsAndNoAggregationNesting(t *testing.T) {
 		]
 	}
 }`
-	searchService := GetElasticSearchParams(accountList, durationBegin, durationEnd, params, client, index)
+	searchService := GetElasticSearchParams(accountList, durationBegin, durationEnd, params, "cost", nil, nil, nil, client, index)
 	res, err := searchService.Do(context.Background())
 	if err != nil {
 		t.Fatal(err)
@@ -718,7 +709,7 @@ func TestElasticSearchParamWithFewResultsAndNesting(t *testing.T) {
 		]
 	}
 }`
-	searchService := GetElasticSearchParams(accountList, durationBegin, durationEnd, params, client, index)
+	searchService := GetElasticSearchParams(accountList, durationBegin, durationEnd, params, "cost", nil, nil, nil, client, index)
 	res, err := searchService.Do(context.Background())
 	if err != nil {
 		t.Fatal(err)
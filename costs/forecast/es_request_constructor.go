@@ -0,0 +1,46 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package forecast
+
+import (
+	"time"
+
+	"github.com/olivere/elastic"
+)
+
+// queryMaxSize is the maximum size of an Elastic Search Query
+const queryMaxSize = 10000
+
+// getAnomaliesElasticSearchParams is used to construct an ElasticSearch *elastic.SearchService
+// used to retrieve the cost anomalies detected during the training window of a forecast.
+// It takes as parameters :
+//   - accountList []string : A slice of string representing aws account number
+//   - durationBegin time.Time : A time.Time struct representing the beginning of the time range in the query
+//   - durationEnd time.Time : A time.Time struct representing the end of the time range in the query
+//   - client *elastic.Client : an instance of *elastic.Client that represent an Elastic Search client.
+//   - index string : The Elastic Search index on which to execute the query.
+//   - anomalyType string : The type of the anomalies documents.
+func getAnomaliesElasticSearchParams(accountList []string, durationBegin time.Time,
+	durationEnd time.Time, client *elastic.Client, index string, anomalyType string) *elastic.SearchService {
+	accountListFormatted := make([]interface{}, len(accountList))
+	for i, v := range accountList {
+		accountListFormatted[i] = v
+	}
+	query := elastic.NewBoolQuery()
+	query = query.Filter(elastic.NewTermsQuery("account", accountListFormatted...))
+	query = query.Filter(elastic.NewRangeQuery("date").From(durationBegin).To(durationEnd))
+	query = query.Filter(elastic.NewTermQuery("abnormal", true))
+	return client.Search().Index(index).Type(anomalyType).Size(queryMaxSize).Query(query)
+}
@@ -0,0 +1,184 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package forecast
+
+import (
+	"time"
+
+	"github.com/trackit/trackit/es"
+)
+
+const (
+	// dayFormat is the format of the dates of the forecast days.
+	dayFormat = "2006-01-02"
+	// monthFormat is the format of the dates of the forecast months.
+	monthFormat = "2006-01"
+)
+
+type (
+	// series is the daily cost of the line items sharing the same values for
+	// the criteria of a forecast. Days are formatted with dayFormat.
+	series struct {
+		key  map[string]string
+		days map[string]float64
+	}
+
+	// anomalousDay is a cost anomaly detected for a product of an account.
+	anomalousDay struct {
+		account string
+		product string
+	}
+
+	// anomalousDays maps days, formatted with dayFormat, to the anomalies
+	// detected on them.
+	anomalousDays map[string][]anomalousDay
+
+	// ForecastPoint is the forecast cost of a day.
+	ForecastPoint struct {
+		Date  string  `json:"date"`
+		Cost  float64 `json:"cost"`
+		Lower float64 `json:"lower"`
+		Upper float64 `json:"upper"`
+	}
+
+	// MonthForecast is the forecast cost of a month. Actual is the cost
+	// already spent during the month, which is included in Cost, Lower and
+	// Upper.
+	MonthForecast struct {
+		Month  string  `json:"month"`
+		Actual float64 `json:"actual"`
+		Cost   float64 `json:"cost"`
+		Lower  float64 `json:"lower"`
+		Upper  float64 `json:"upper"`
+	}
+
+	// SeriesForecast is the forecast of the costs matching Key, which maps
+	// the criteria of the forecast to their values.
+	SeriesForecast struct {
+		Key    map[string]string `json:"key"`
+		Days   []ForecastPoint   `json:"days"`
+		Months []MonthForecast   `json:"months"`
+	}
+
+	// ForecastResponse is the response of the forecast route.
+	ForecastResponse struct {
+		Confidence    float64          `json:"confidence"`
+		TrainingBegin string           `json:"trainingBegin"`
+		TrainingEnd   string           `json:"trainingEnd"`
+		Forecasts     []SeriesForecast `json:"forecasts"`
+	}
+)
+
+// flattenSeries extracts the series of a costs document aggregated by the
+// criteria and then by day.
+func flattenSeries(doc es.SimplifiedCostsDocument, criteria []string) []series {
+	return flattenSeriesRec(doc, criteria, map[string]string{})
+}
+
+func flattenSeriesRec(doc es.SimplifiedCostsDocument, criteria []string, key map[string]string) []series {
+	if len(criteria) == 0 {
+		s := series{key, map[string]float64{}}
+		for _, day := range doc.Children {
+			if len(day.Key) >= len(dayFormat) {
+				s.days[day.Key[:len(dayFormat)]] += day.Value
+			}
+		}
+		return []series{s}
+	}
+	var res []series
+	for _, child := range doc.Children {
+		childKey := map[string]string{criteria[0]: child.Key}
+		for k, v := range key {
+			childKey[k] = v
+		}
+		res = append(res, flattenSeriesRec(child, criteria[1:], childKey)...)
+	}
+	return res
+}
+
+// contains checks whether an anomaly matching the key of a series was
+// detected on date. A series which is not split by account or product
+// matches the anomalies of every account or product.
+func (a anomalousDays) contains(key map[string]string, date string) bool {
+	for _, anomaly := range a[date] {
+		if account, ok := key["account"]; ok && account != anomaly.account {
+			continue
+		}
+		if product, ok := key["product"]; ok && product != anomaly.product {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// daysSince returns the number of days between begin and date.
+func daysSince(begin, date time.Time) float64 {
+	return date.Sub(begin).Hours() / 24
+}
+
+// getTrainingPoints returns the days of a series between trainingBegin and
+// trainingEnd to train its model on. Days before the first cost of the
+// series are left out, since the series did not exist yet, and so are the
+// anomalous days.
+func getTrainingPoints(s series, anomalies anomalousDays, trainingBegin, trainingEnd time.Time) []trainingPoint {
+	var points []trainingPoint
+	started := false
+	for d := trainingBegin; d.Before(trainingEnd); d = d.AddDate(0, 0, 1) {
+		date := d.Format(dayFormat)
+		cost, ok := s.days[date]
+		started = started || ok
+		if started && !anomalies.contains(s.key, date) {
+			points = append(points, trainingPoint{daysSince(trainingBegin, d), cost})
+		}
+	}
+	return points
+}
+
+// forecastSeries forecasts the daily costs of a series from trainingEnd to
+// horizonEnd, and the monthly costs of the months in between. It returns
+// false if the series has no days to train on.
+func forecastSeries(s series, anomalies anomalousDays, trainingBegin, trainingEnd, horizonEnd time.Time) (SeriesForecast, bool) {
+	res := SeriesForecast{Key: s.key}
+	model, ok := fitLinearModel(getTrainingPoints(s, anomalies, trainingBegin, trainingEnd))
+	if !ok {
+		return res, false
+	}
+	for d := trainingEnd; d.Before(horizonEnd); d = d.AddDate(0, 0, 1) {
+		p := model.predict(daysSince(trainingBegin, d)).nonNegative()
+		res.Days = append(res.Days, ForecastPoint{d.Format(dayFormat), p.cost, p.lower, p.upper})
+	}
+	monthBegin := time.Date(trainingEnd.Year(), trainingEnd.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for ; monthBegin.Before(horizonEnd); monthBegin = monthBegin.AddDate(0, 1, 0) {
+		var actual float64
+		var ts []float64
+		for d := monthBegin; d.Before(monthBegin.AddDate(0, 1, 0)); d = d.AddDate(0, 0, 1) {
+			if d.Before(trainingEnd) {
+				actual += s.days[d.Format(dayFormat)]
+			} else {
+				ts = append(ts, daysSince(trainingBegin, d))
+			}
+		}
+		p := model.predictSum(ts).nonNegative()
+		res.Months = append(res.Months, MonthForecast{
+			Month:  monthBegin.Format(monthFormat),
+			Actual: actual,
+			Cost:   actual + p.cost,
+			Lower:  actual + p.lower,
+			Upper:  actual + p.upper,
+		})
+	}
+	return res, true
+}
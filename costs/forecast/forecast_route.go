@@ -0,0 +1,194 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package forecast
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/olivere/elastic"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/anomaliesDetection"
+	"github.com/trackit/trackit/aws/s3"
+	"github.com/trackit/trackit/cache"
+	"github.com/trackit/trackit/costs"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/es"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+const (
+	// trainingDays is the number of days before today the forecasts are
+	// trained on.
+	trainingDays = 90
+	// defaultMonths is the number of months forecast after the current one
+	// when none is requested.
+	defaultMonths = 3
+	// maxMonths is the maximum number of months forecast after the current
+	// one.
+	maxMonths = 12
+)
+
+// validCriteria are the criteria the forecasts can be split by.
+var validCriteria = map[string]bool{
+	"account":  true,
+	"product":  true,
+	"region":   true,
+	"provider": true,
+}
+
+// forecastQueryArgs allows to get required queryArgs params
+var forecastQueryArgs = []routes.QueryArg{
+	routes.AwsAccountsOptionalQueryArg,
+	routes.QueryArg{
+		Name:        "by",
+		Description: "Criteria to split the forecasts by, comma separated. Possible values are account, product, region, provider",
+		Type:        routes.QueryArgStringSlice{},
+		Optional:    true,
+	},
+	routes.QueryArg{
+		Name:        "months",
+		Description: fmt.Sprintf("Number of months to forecast after the current one. Defaults to %d, at most %d", defaultMonths, maxMonths),
+		Type:        routes.QueryArgInt{},
+		Optional:    true,
+	},
+	routes.CostTypeQueryArg,
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getForecast).With(
+			db.RequestTransaction{Db: db.Db},
+			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			routes.QueryArgs(forecastQueryArgs),
+			cache.UsersCache{Events: []string{cache.EventBillingData, cache.EventAnomalies}},
+			routes.Documentation{
+				Summary:     "get the costs forecast",
+				Description: "Responds with the daily costs forecast through the end of the month and the next months, with 95% confidence intervals. The forecasts are trained on the daily costs of the last 90 days, leaving out the days with cost anomalies.",
+			},
+		),
+	}.H().Register("/costs/forecast")
+}
+
+// getForecast returns the costs forecast based on the query params, in JSON format.
+func getForecast(request *http.Request, a routes.Arguments) (int, interface{}) {
+	ctx := request.Context()
+	l := jsonlog.LoggerFromContextOrDefault(ctx)
+	user := a[users.AuthenticatedUser].(users.User)
+	tx := a[db.Transaction].(*sql.Tx)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	parsedParams := costs.EsQueryParams{
+		AccountList: []string{},
+		DateBegin:   today.AddDate(0, 0, -trainingDays),
+		DateEnd:     today.Add(-time.Second),
+	}
+	criteria := []string{}
+	months := defaultMonths
+	if a[forecastQueryArgs[0]] != nil {
+		parsedParams.AccountList = a[forecastQueryArgs[0]].([]string)
+	}
+	if a[forecastQueryArgs[1]] != nil {
+		criteria = a[forecastQueryArgs[1]].([]string)
+	}
+	if a[forecastQueryArgs[2]] != nil {
+		months = a[forecastQueryArgs[2]].(int)
+	}
+	if a[forecastQueryArgs[3]] != nil {
+		parsedParams.CostType = a[forecastQueryArgs[3]].(string)
+	}
+	for _, criterion := range criteria {
+		if !validCriteria[criterion] {
+			return http.StatusBadRequest, fmt.Errorf("Error parsing criterion : %s", criterion)
+		}
+	}
+	if months < 0 || months > maxMonths {
+		return http.StatusBadRequest, fmt.Errorf("months must be between 0 and %d", maxMonths)
+	}
+	if _, err := s3.GetCostTypeField(parsedParams.CostType); err != nil {
+		return http.StatusBadRequest, err
+	}
+	accountsAndIndexes, returnCode, err := es.GetAccountsAndIndexes(parsedParams.AccountList, user, tx, s3.IndexPrefixLineItem)
+	if err != nil {
+		return returnCode, err
+	}
+	parsedParams.AccountList = accountsAndIndexes.Accounts
+	parsedParams.IndexList = accountsAndIndexes.Indexes
+	parsedParams.AggregationParams = append(criteria, "day")
+	doc, returnCode, err := costs.MakeElasticSearchRequestAndParseIt(ctx, parsedParams)
+	if err != nil && returnCode != http.StatusOK {
+		return returnCode, err
+	}
+	excludedDays, err := getAnomalousDays(ctx, tx, user, parsedParams)
+	if err != nil {
+		l.Warning("Failed to get anomalies, forecasting without leaving them out", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+	}
+	res := ForecastResponse{
+		Confidence:    0.95,
+		TrainingBegin: parsedParams.DateBegin.Format(dayFormat),
+		TrainingEnd:   parsedParams.DateEnd.Format(dayFormat),
+		Forecasts:     []SeriesForecast{},
+	}
+	horizonEnd := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, months+1, 0)
+	for _, s := range flattenSeries(doc, criteria) {
+		if forecast, ok := forecastSeries(s, excludedDays, parsedParams.DateBegin, today, horizonEnd); ok {
+			res.Forecasts = append(res.Forecasts, forecast)
+		}
+	}
+	return http.StatusOK, res
+}
+
+// getAnomalousDays retrieves the cost anomalies detected during the training
+// window of a forecast. Accounts without anomalies detection index do not
+// have any anomaly.
+func getAnomalousDays(ctx context.Context, tx *sql.Tx, user users.User, parsedParams costs.EsQueryParams) (anomalousDays, error) {
+	res := anomalousDays{}
+	accountsAndIndexes, _, err := es.GetAccountsAndIndexes(parsedParams.AccountList, user, tx, anomalies.IndexPrefixAnomaliesDetection)
+	if err != nil {
+		return res, err
+	}
+	index := strings.Join(accountsAndIndexes.Indexes, ",")
+	sr, err := getAnomaliesElasticSearchParams(accountsAndIndexes.Accounts, parsedParams.DateBegin,
+		parsedParams.DateEnd, es.Client, index, anomalies.TypeProductAnomaliesDetection).Do(ctx)
+	if elastic.IsNotFound(err) {
+		return res, nil
+	} else if err != nil {
+		return res, err
+	}
+	for _, hit := range sr.Hits.Hits {
+		var doc struct {
+			Account string `json:"account"`
+			Date    string `json:"date"`
+			Product string `json:"product"`
+		}
+		if err := json.Unmarshal(*hit.Source, &doc); err != nil {
+			return res, err
+		}
+		if len(doc.Date) >= len(dayFormat) {
+			date := doc.Date[:len(dayFormat)]
+			res[date] = append(res[date], anomalousDay{doc.Account, doc.Product})
+		}
+	}
+	return res, nil
+}
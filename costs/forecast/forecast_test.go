@@ -0,0 +1,102 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package forecast
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFitLinearModel(t *testing.T) {
+	points := []trainingPoint{{0, 10}, {1, 12}, {2, 14}, {3, 16}}
+	model, ok := fitLinearModel(points)
+	if !ok {
+		t.Fatal("Expected the model to be fitted")
+	}
+	if math.Abs(model.slope-2) > 1e-9 || math.Abs(model.intercept-10) > 1e-9 {
+		t.Errorf("Expected cost = 10 + 2t, got cost = %f + %ft", model.intercept, model.slope)
+	}
+	if p := model.predict(10); math.Abs(p.cost-30) > 1e-9 || p.lower != p.cost || p.upper != p.cost {
+		t.Errorf("Expected an exact prediction of 30, got %+v", p)
+	}
+	if _, ok := fitLinearModel(nil); ok {
+		t.Error("Expected no model without points")
+	}
+}
+
+func TestPredictSumInterval(t *testing.T) {
+	points := []trainingPoint{{0, 10}, {1, 14}, {2, 10}, {3, 14}, {4, 10}, {5, 14}}
+	model, _ := fitLinearModel(points)
+	day := model.predict(6)
+	month := model.predictSum([]float64{6, 7, 8, 9})
+	if day.lower >= day.cost || day.upper <= day.cost {
+		t.Errorf("Expected the interval to contain the prediction, got %+v", day)
+	}
+	if month.upper-month.cost <= day.upper-day.cost {
+		t.Errorf("Expected the interval of a sum to be wider than the one of a day")
+	}
+}
+
+func TestGetTrainingPointsLeavesOutAnomalies(t *testing.T) {
+	begin := time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)
+	s := series{
+		key: map[string]string{"product": "AmazonEC2"},
+		days: map[string]float64{
+			"2020-06-02": 10,
+			"2020-06-03": 100,
+			"2020-06-04": 10,
+		},
+	}
+	anomalies := anomalousDays{
+		"2020-06-03": {{"123456789012", "AmazonEC2"}},
+		"2020-06-04": {{"123456789012", "AmazonRDS"}},
+	}
+	points := getTrainingPoints(s, anomalies, begin, begin.AddDate(0, 0, 5))
+	expected := []trainingPoint{{1, 10}, {3, 10}, {4, 0}}
+	if len(points) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, points)
+	}
+	for i := range expected {
+		if points[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, points)
+		}
+	}
+}
+
+func TestForecastSeriesMonths(t *testing.T) {
+	begin := time.Date(2020, time.May, 1, 0, 0, 0, 0, time.UTC)
+	today := time.Date(2020, time.June, 11, 0, 0, 0, 0, time.UTC)
+	s := series{key: map[string]string{}, days: map[string]float64{}}
+	for d := begin; d.Before(today); d = d.AddDate(0, 0, 1) {
+		s.days[d.Format(dayFormat)] = 10
+	}
+	forecast, ok := forecastSeries(s, anomalousDays{}, begin, today, time.Date(2020, time.August, 1, 0, 0, 0, 0, time.UTC))
+	if !ok {
+		t.Fatal("Expected a forecast")
+	}
+	if len(forecast.Days) != 51 {
+		t.Errorf("Expected 51 forecast days, got %d", len(forecast.Days))
+	}
+	if len(forecast.Months) != 2 {
+		t.Fatalf("Expected 2 forecast months, got %d", len(forecast.Months))
+	}
+	if june := forecast.Months[0]; june.Month != "2020-06" || june.Actual != 100 || math.Abs(june.Cost-300) > 1e-6 {
+		t.Errorf("Expected June to land at 300 with 100 spent, got %+v", june)
+	}
+	if july := forecast.Months[1]; july.Actual != 0 || math.Abs(july.Cost-310) > 1e-6 {
+		t.Errorf("Expected July to land at 310, got %+v", july)
+	}
+}
@@ -0,0 +1,126 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package forecast
+
+import (
+	"math"
+)
+
+// zScore is the quantile of the normal distribution giving the 95% confidence
+// intervals of the forecasts.
+const zScore = 1.959964
+
+type (
+	// trainingPoint is the cost of a day used to train a linearModel. T is the
+	// number of days since the beginning of the training window.
+	trainingPoint struct {
+		t    float64
+		cost float64
+	}
+
+	// linearModel is an ordinary least squares regression of the daily cost
+	// over time.
+	linearModel struct {
+		n         float64
+		intercept float64
+		slope     float64
+		tMean     float64
+		sxx       float64
+		sigma     float64
+	}
+
+	// prediction is a forecast cost with its confidence interval.
+	prediction struct {
+		cost  float64
+		lower float64
+		upper float64
+	}
+)
+
+// fitLinearModel fits a linearModel on points. It returns false if there are
+// no points to fit on.
+func fitLinearModel(points []trainingPoint) (linearModel, bool) {
+	var m linearModel
+	if len(points) == 0 {
+		return m, false
+	}
+	m.n = float64(len(points))
+	var costMean float64
+	for _, p := range points {
+		m.tMean += p.t
+		costMean += p.cost
+	}
+	m.tMean /= m.n
+	costMean /= m.n
+	var sxy float64
+	for _, p := range points {
+		m.sxx += (p.t - m.tMean) * (p.t - m.tMean)
+		sxy += (p.t - m.tMean) * (p.cost - costMean)
+	}
+	if m.sxx > 0 {
+		m.slope = sxy / m.sxx
+	}
+	m.intercept = costMean - m.slope*m.tMean
+	if len(points) > 2 {
+		var sse float64
+		for _, p := range points {
+			residual := p.cost - m.estimate(p.t)
+			sse += residual * residual
+		}
+		m.sigma = math.Sqrt(sse / (m.n - 2))
+	}
+	return m, true
+}
+
+// estimate returns the expected cost at day t.
+func (m linearModel) estimate(t float64) float64 {
+	return m.intercept + m.slope*t
+}
+
+// predictSum forecasts the total cost of the days ts. The variance of the
+// total accounts for the noise of each day and for the uncertainty of the
+// model's parameters, which is shared by all days.
+func (m linearModel) predictSum(ts []float64) prediction {
+	var cost, leverage float64
+	for _, t := range ts {
+		cost += m.estimate(t)
+		leverage += t - m.tMean
+	}
+	count := float64(len(ts))
+	variance := count + count*count/m.n
+	if m.sxx > 0 {
+		variance += leverage * leverage / m.sxx
+	}
+	margin := zScore * m.sigma * math.Sqrt(variance)
+	return prediction{
+		cost:  cost,
+		lower: cost - margin,
+		upper: cost + margin,
+	}
+}
+
+// predict forecasts the cost of day t.
+func (m linearModel) predict(t float64) prediction {
+	return m.predictSum([]float64{t})
+}
+
+// nonNegative bounds a prediction to positive costs.
+func (p prediction) nonNegative() prediction {
+	return prediction{
+		cost:  math.Max(p.cost, 0),
+		lower: math.Max(p.lower, 0),
+		upper: math.Max(p.upper, 0),
+	}
+}
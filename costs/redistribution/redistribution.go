@@ -0,0 +1,306 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package redistribution spreads shared cost pools, such as AWS Support,
+// Enterprise Discount credits, shared networking or untagged spend, over the
+// accounts or tag values which benefit from them. The result is the allocated
+// cost, which is available next to the raw cost of the line items.
+package redistribution
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/olivere/elastic"
+
+	"github.com/trackit/trackit/costs/categories"
+	"github.com/trackit/trackit/es"
+	"github.com/trackit/trackit/models"
+)
+
+// Method is the way a cost pool is spread over the targets of a rule.
+type Method string
+
+const (
+	// MethodEven gives the same share to every target.
+	MethodEven = Method("even")
+	// MethodProportional gives each target a share proportional to its
+	// direct spend over the same period.
+	MethodProportional = Method("proportional")
+	// MethodFixed gives each target the percentage set in the rule.
+	MethodFixed = Method("fixed")
+)
+
+// TargetAccount is the target of the rules spreading their pool over the
+// accounts. The other rules spread it over the values of a tag key, with a
+// 'tag:<TAG_KEY>' target.
+const TargetAccount = "account"
+
+// UnallocatedKey is the target which keeps the costs of a pool that could
+// not be spread, because no target had direct spend over their period.
+const UnallocatedKey = "unallocated"
+
+// maxRules is the maximum number of redistribution rules of a user, each of
+// which costs an additional ElasticSearch request.
+const maxRules = 20
+
+var (
+	ErrRuleNotFound      = errors.New("Redistribution rule not found.")
+	ErrTooManyRules      = fmt.Errorf("A user cannot have more than %d redistribution rules.", maxRules)
+	ErrInvalidName       = errors.New("The name of a redistribution rule must not be empty.")
+	ErrEmptyPool         = errors.New("The pool of a redistribution rule must have at least one condition.")
+	ErrInvalidTarget     = errors.New("The target of a redistribution rule must be 'account' or 'tag:<TAG_KEY>'.")
+	ErrInvalidMethod     = errors.New("The method of a redistribution rule must be 'even', 'proportional' or 'fixed'.")
+	ErrInvalidPercentage = errors.New("The percentages of a fixed redistribution rule must be positive and add up to 100.")
+)
+
+type (
+	// Pool selects the line items whose costs are spread. A line item is in
+	// the pool if it matches every one of its non-empty conditions. A
+	// condition matches the line items with one of its values.
+	Pool struct {
+		Accounts      []string                  `json:"accounts"`
+		Products      []string                  `json:"products"`
+		UsageTypes    []string                  `json:"usageTypes"`
+		LineItemTypes []string                  `json:"lineItemTypes"`
+		Tags          []categories.TagCondition `json:"tags"`
+		// Untagged matches the line items which do not carry this tag
+		// key.
+		Untagged string `json:"untagged"`
+	}
+
+	// Rule spreads the costs of a pool over targets, which are accounts or
+	// the values of a tag key. Without targets, the pool is spread over the
+	// accounts or tag values with direct spend. The rules of a user are
+	// applied in the order they were created and a line item belongs to the
+	// pool of the first rule it matches.
+	Rule struct {
+		Name        string             `json:"name" req:"nonzero"`
+		Pool        Pool               `json:"pool"`
+		Target      string             `json:"target" req:"nonzero"`
+		Targets     []string           `json:"targets"`
+		Method      Method             `json:"method" req:"nonzero"`
+		Percentages map[string]float64 `json:"percentages"`
+	}
+)
+
+// Validate checks a redistribution rule can be stored and applied.
+func (r Rule) Validate() error {
+	if r.Name == "" {
+		return ErrInvalidName
+	} else if r.Pool.empty() {
+		return ErrEmptyPool
+	} else if r.Target != TargetAccount && (!strings.HasPrefix(r.Target, "tag:") || len(r.Target) < 5 || strings.Count(r.Target, ":") != 1) {
+		return ErrInvalidTarget
+	}
+	for _, tag := range r.Pool.Tags {
+		if tag.Key == "" {
+			return errors.New("A tag condition of the pool of the redistribution rule has no key.")
+		}
+	}
+	switch r.Method {
+	case MethodEven, MethodProportional:
+		return nil
+	case MethodFixed:
+		var total float64
+		for _, percentage := range r.Percentages {
+			if percentage < 0 {
+				return ErrInvalidPercentage
+			}
+			total += percentage
+		}
+		if math.Abs(total-100) > 0.01 {
+			return ErrInvalidPercentage
+		}
+		return nil
+	default:
+		return ErrInvalidMethod
+	}
+}
+
+func (p Pool) empty() bool {
+	return len(p.Accounts)+len(p.Products)+len(p.UsageTypes)+len(p.LineItemTypes)+len(p.Tags) == 0 && p.Untagged == ""
+}
+
+// Query returns the query matching the line items of a pool.
+func (p Pool) Query() elastic.Query {
+	query := elastic.NewBoolQuery()
+	for _, condition := range []struct {
+		field  string
+		values []string
+	}{
+		{"usageAccountId", p.Accounts},
+		{"productCode", p.Products},
+		{"usageType", p.UsageTypes},
+		{"lineItemType", p.LineItemTypes},
+	} {
+		if len(condition.values) > 0 {
+			query = query.Filter(elastic.NewTermsQuery(condition.field, stringsToInterfaces(condition.values)...))
+		}
+	}
+	for _, tag := range p.Tags {
+		tagQuery := elastic.NewBoolQuery().Filter(elastic.NewTermQuery("tags.key", tag.Key))
+		if len(tag.Values) > 0 {
+			tagQuery = tagQuery.Filter(elastic.NewTermsQuery("tags.tag", stringsToInterfaces(tag.Values)...))
+		}
+		query = query.Filter(elastic.NewNestedQuery("tags", tagQuery))
+	}
+	if p.Untagged != "" {
+		query = query.MustNot(elastic.NewNestedQuery("tags", elastic.NewTermQuery("tags.key", p.Untagged)))
+	}
+	return query
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	res := make([]interface{}, len(values))
+	for i, value := range values {
+		res[i] = value
+	}
+	return res
+}
+
+// Shares returns the share of a pool each target gets, given the direct
+// spend of the candidate targets over the period of the pool. The shares add
+// up to 1, unless there is no target to spread the pool over.
+func (r Rule) Shares(direct map[string]float64) map[string]float64 {
+	shares := make(map[string]float64)
+	if r.Method == MethodFixed {
+		for target, percentage := range r.Percentages {
+			if percentage > 0 {
+				shares[target] = percentage / 100
+			}
+		}
+		return shares
+	}
+	targets := r.Targets
+	if len(targets) == 0 {
+		for target, cost := range direct {
+			if cost != 0 {
+				targets = append(targets, target)
+			}
+		}
+	}
+	var total float64
+	if r.Method == MethodProportional {
+		for _, target := range targets {
+			if direct[target] > 0 {
+				total += direct[target]
+			}
+		}
+	}
+	for _, target := range targets {
+		if total > 0 {
+			if direct[target] > 0 {
+				shares[target] = direct[target] / total
+			}
+		} else {
+			shares[target] = 1 / float64(len(targets))
+		}
+	}
+	return shares
+}
+
+// Allocate spreads the rows of the costs of a pool over the targets of a
+// rule. The direct rows are split by the criteria of a costs request, the
+// target of the rule being at targetIndex, and the pool rows by the same
+// criteria without the target. The shares are computed over the direct
+// spend in the same period, which is given by the criteria at
+// periodIndexes. Direct rows whose target is in ignored are not candidate
+// targets. It returns the allocated rows, split like the direct ones.
+func (r Rule) Allocate(direct, pool []es.CostsRow, targetIndex int, periodIndexes []int, ignored map[string]bool) []es.CostsRow {
+	directByPeriod := make(map[string]map[string]float64)
+	for _, row := range direct {
+		target := row.Keys[targetIndex]
+		if ignored[target] {
+			continue
+		}
+		period := periodKey(row.Keys, periodIndexes)
+		if directByPeriod[period] == nil {
+			directByPeriod[period] = make(map[string]float64)
+		}
+		directByPeriod[period][target] += row.Value
+	}
+	poolPeriodIndexes := make([]int, len(periodIndexes))
+	for i, index := range periodIndexes {
+		if index > targetIndex {
+			index--
+		}
+		poolPeriodIndexes[i] = index
+	}
+	allocated := []es.CostsRow{}
+	for _, row := range pool {
+		shares := r.Shares(directByPeriod[periodKey(row.Keys, poolPeriodIndexes)])
+		if len(shares) == 0 {
+			shares = map[string]float64{UnallocatedKey: 1}
+		}
+		for target, share := range shares {
+			keys := make([]string, 0, len(row.Keys)+1)
+			keys = append(keys, row.Keys[:targetIndex]...)
+			keys = append(keys, target)
+			keys = append(keys, row.Keys[targetIndex:]...)
+			allocated = append(allocated, es.CostsRow{Keys: keys, Value: row.Value * share})
+		}
+	}
+	return allocated
+}
+
+func periodKey(keys []string, periodIndexes []int) string {
+	period := make([]string, len(periodIndexes))
+	for i, index := range periodIndexes {
+		period[i] = keys[index]
+	}
+	return strings.Join(period, "/")
+}
+
+// ruleFromDbCostRedistribution builds a Rule from its database
+// representation.
+func ruleFromDbCostRedistribution(dbRule models.CostRedistribution) (Rule, error) {
+	var rule Rule
+	err := json.Unmarshal(dbRule.Rule, &rule)
+	rule.Name = dbRule.Name
+	return rule, err
+}
+
+// GetRules returns the redistribution rules of a user, in the order they
+// are applied.
+func GetRules(db models.XODB, userId int) ([]Rule, error) {
+	dbRules, err := models.CostRedistributionsByUserID(db, userId)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]Rule, len(dbRules))
+	for i, dbRule := range dbRules {
+		if rules[i], err = ruleFromDbCostRedistribution(*dbRule); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// getRule returns the redistribution rule of a user with a name. It returns
+// ErrRuleNotFound if there is none.
+func getRule(tx *sql.Tx, userId int, name string) (*models.CostRedistribution, Rule, error) {
+	dbRule, err := models.CostRedistributionByUserIDName(tx, userId, name)
+	if err == sql.ErrNoRows {
+		return nil, Rule{}, ErrRuleNotFound
+	} else if err != nil {
+		return nil, Rule{}, err
+	}
+	rule, err := ruleFromDbCostRedistribution(*dbRule)
+	return dbRule, rule, err
+}
@@ -0,0 +1,197 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package redistribution
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/audit"
+	"github.com/trackit/trackit/cache"
+	"github.com/trackit/trackit/costs/categories"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+// ruleNameQueryArg is the name of the redistribution rule which is deleted.
+var ruleNameQueryArg = routes.QueryArg{
+	Name:        "name",
+	Type:        routes.QueryArgString{},
+	Description: "The name of the redistribution rule.",
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getRules).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			routes.Documentation{
+				Summary:     "get the redistribution rules",
+				Description: "Responds with the redistribution rules of the user, in the order they are applied",
+			},
+		),
+		http.MethodPut: routes.H(putRule).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{Rule{
+				Name: "support",
+				Pool: Pool{
+					Products: []string{"AWSSupportBusiness", "AWSSupportEnterprise"},
+					Tags:     []categories.TagCondition{},
+				},
+				Target:      "tag:team",
+				Targets:     []string{},
+				Method:      MethodProportional,
+				Percentages: map[string]float64{},
+			}},
+			routes.Documentation{
+				Summary:     "create or replace a redistribution rule",
+				Description: "Creates the redistribution rule with the name of the body, or replaces it if it exists. The pool selects the line items whose costs are spread, with the same conditions as the cost categories, and the line item types or a tag key the line items lack. The target is 'account' or 'tag:<TAG_KEY>'. The method is 'even', 'proportional' to the direct spend of the targets, or 'fixed' by the percentages of each target.",
+			},
+		),
+		http.MethodDelete: routes.H(deleteRule).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.QueryArgs{ruleNameQueryArg},
+			routes.Documentation{
+				Summary:     "delete a redistribution rule",
+				Description: "Deletes the redistribution rule with a name",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{Db: db.Db},
+		routes.Documentation{
+			Summary:     "interact with the redistribution rules",
+			Description: "A redistribution rule spreads a pool of shared costs over accounts or tag values. The allocated costs are available with the 'allocated' query arg of /costs.",
+		},
+	).Register("/costs/redistributions")
+}
+
+// getRules is a route handler which returns the redistribution rules of the
+// user.
+func getRules(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	rules, err := GetRules(tx, user.Id)
+	if err != nil {
+		l.Error("Failed to get redistribution rules", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve redistribution rules.")
+	}
+	return http.StatusOK, rules
+}
+
+// putRule is a route handler which creates or replaces a redistribution rule
+// of the user.
+func putRule(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	var body Rule
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	if err := body.Validate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	var before interface{}
+	dbRule, rule, err := getRule(tx, user.Id, body.Name)
+	if err == ErrRuleNotFound {
+		var dbRules []*models.CostRedistribution
+		if dbRules, err = models.CostRedistributionsByUserID(tx, user.Id); err == nil && len(dbRules) >= maxRules {
+			return http.StatusBadRequest, ErrTooManyRules
+		}
+		dbRule = &models.CostRedistribution{
+			UserID: user.Id,
+			Name:   body.Name,
+		}
+	} else if err == nil {
+		before = rule
+	}
+	if err == nil {
+		if dbRule.Rule, err = json.Marshal(body); err == nil {
+			err = dbRule.Save(tx)
+		}
+	}
+	if err != nil {
+		l.Error("Failed to save redistribution rule", map[string]interface{}{
+			"userId": user.Id,
+			"name":   body.Name,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to update redistribution rule.")
+	}
+	if err := audit.Record(r, tx, user, audit.Entry{
+		Action:     audit.ActionRedistributionUpdate,
+		TargetType: audit.TargetRedistribution,
+		TargetId:   body.Name,
+		Before:     before,
+		After:      body,
+	}); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	invalidateCache(r, tx, user)
+	return http.StatusOK, body
+}
+
+// deleteRule is a route handler which deletes a redistribution rule of the
+// user.
+func deleteRule(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	name := a[ruleNameQueryArg].(string)
+	dbRule, before, err := getRule(tx, user.Id, name)
+	if err == ErrRuleNotFound {
+		return http.StatusNotFound, err
+	} else if err == nil {
+		err = dbRule.Delete(tx)
+	}
+	if err != nil {
+		l.Error("Failed to delete redistribution rule", map[string]interface{}{
+			"userId": user.Id,
+			"name":   name,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to delete redistribution rule.")
+	}
+	if err := audit.Record(r, tx, user, audit.Entry{
+		Action:     audit.ActionRedistributionDelete,
+		TargetType: audit.TargetRedistribution,
+		TargetId:   name,
+		Before:     before,
+	}); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	invalidateCache(r, tx, user)
+	return http.StatusOK, nil
+}
+
+// invalidateCache removes the cached costs of the accounts available to the
+// user, whose allocated costs may have been changed by the rule.
+func invalidateCache(r *http.Request, tx *sql.Tx, user users.User) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	if err := cache.InvalidateUser(cache.EventCostRedistributions, tx, user, l); err != nil {
+		l.Error("Failed to remove cache", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+	}
+}
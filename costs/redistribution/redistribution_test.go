@@ -0,0 +1,104 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package redistribution
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/trackit/trackit/es"
+)
+
+func TestValidate(t *testing.T) {
+	pool := Pool{Products: []string{"AWSSupportBusiness"}}
+	for _, test := range []struct {
+		rule     Rule
+		expected error
+	}{
+		{Rule{Name: "support", Pool: pool, Target: TargetAccount, Method: MethodEven}, nil},
+		{Rule{Name: "support", Pool: pool, Target: "tag:team", Method: MethodProportional}, nil},
+		{Rule{Name: "support", Pool: pool, Target: TargetAccount, Method: MethodFixed, Percentages: map[string]float64{"a": 60, "b": 40}}, nil},
+		{Rule{Pool: pool, Target: TargetAccount, Method: MethodEven}, ErrInvalidName},
+		{Rule{Name: "support", Target: TargetAccount, Method: MethodEven}, ErrEmptyPool},
+		{Rule{Name: "support", Pool: pool, Target: "product", Method: MethodEven}, ErrInvalidTarget},
+		{Rule{Name: "support", Pool: pool, Target: "tag:", Method: MethodEven}, ErrInvalidTarget},
+		{Rule{Name: "support", Pool: pool, Target: TargetAccount, Method: "random"}, ErrInvalidMethod},
+		{Rule{Name: "support", Pool: pool, Target: TargetAccount, Method: MethodFixed, Percentages: map[string]float64{"a": 60, "b": 30}}, ErrInvalidPercentage},
+		{Rule{Name: "support", Pool: pool, Target: TargetAccount, Method: MethodFixed, Percentages: map[string]float64{"a": 120, "b": -20}}, ErrInvalidPercentage},
+	} {
+		if err := test.rule.Validate(); err != test.expected {
+			t.Errorf("Rule %+v: expected error %v, got %v.", test.rule, test.expected, err)
+		}
+	}
+}
+
+func TestShares(t *testing.T) {
+	direct := map[string]float64{"a": 30, "b": 10, "c": 0}
+	for _, test := range []struct {
+		rule     Rule
+		expected map[string]float64
+	}{
+		{Rule{Method: MethodEven}, map[string]float64{"a": 0.5, "b": 0.5}},
+		{Rule{Method: MethodEven, Targets: []string{"a", "b", "c", "d"}}, map[string]float64{"a": 0.25, "b": 0.25, "c": 0.25, "d": 0.25}},
+		{Rule{Method: MethodProportional}, map[string]float64{"a": 0.75, "b": 0.25}},
+		{Rule{Method: MethodProportional, Targets: []string{"c", "d"}}, map[string]float64{"c": 0.5, "d": 0.5}},
+		{Rule{Method: MethodFixed, Percentages: map[string]float64{"c": 80, "d": 20, "e": 0}}, map[string]float64{"c": 0.8, "d": 0.2}},
+	} {
+		checkCosts(t, string(test.rule.Method), test.rule.Shares(direct), test.expected)
+	}
+	if shares := (Rule{Method: MethodEven}).Shares(nil); len(shares) != 0 {
+		t.Errorf("Expected no share without direct spend, got %v.", shares)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	rule := Rule{Method: MethodProportional}
+	// Direct rows are split by month and account, pool rows by month.
+	direct := []es.CostsRow{
+		{Keys: []string{"2020-01", "a"}, Value: 30},
+		{Keys: []string{"2020-01", "b"}, Value: 10},
+		{Keys: []string{"2020-02", "b"}, Value: 20},
+		{Keys: []string{"2020-02", "ignored"}, Value: 20},
+	}
+	pool := []es.CostsRow{
+		{Keys: []string{"2020-01"}, Value: 100},
+		{Keys: []string{"2020-02"}, Value: 50},
+		{Keys: []string{"2020-03"}, Value: 10},
+	}
+	allocated := make(map[string]float64)
+	for _, row := range rule.Allocate(direct, pool, 1, []int{0}, map[string]bool{"ignored": true}) {
+		allocated[strings.Join(row.Keys, "/")] += row.Value
+	}
+	checkCosts(t, "allocated", allocated, map[string]float64{
+		"2020-01/a":           75,
+		"2020-01/b":           25,
+		"2020-02/b":           50,
+		"2020-03/unallocated": 10,
+	})
+}
+
+func checkCosts(t *testing.T, name string, got, expected map[string]float64) {
+	if len(got) != len(expected) {
+		t.Errorf("%s: expected %v, got %v.", name, expected, got)
+		return
+	}
+	for key, value := range expected {
+		if math.Abs(got[key]-value) > 1e-9 {
+			t.Errorf("%s: expected %v, got %v.", name, expected, got)
+			return
+		}
+	}
+}
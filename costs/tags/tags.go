@@ -34,7 +34,7 @@ func init() {
 			db.RequestTransaction{Db: db.Db},
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
 			routes.QueryArgs(tagsValuesQueryArgs),
-			cache.UsersCache{},
+			cache.UsersCache{Events: []string{cache.EventBillingData}},
 			routes.Documentation{
 				Summary:     "get the tag values and their cost with a filter",
 				Description: "get the tag values and their cost with filter for a specified time range, aws accounts and keys",
@@ -46,7 +46,7 @@ func init() {
 			db.RequestTransaction{Db: db.Db},
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
 			routes.QueryArgs(tagsKeysQueryArgs),
-			cache.UsersCache{},
+			cache.UsersCache{Events: []string{cache.EventBillingData}},
 			routes.Documentation{
 				Summary:     "get every tag keys",
 				Description: "get every tag keys for a specified time range and aws accounts",
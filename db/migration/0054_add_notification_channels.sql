@@ -0,0 +1,38 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE notification_channel (
+	id      INTEGER       NOT NULL AUTO_INCREMENT,
+	user_id INTEGER       NOT NULL,
+	name    VARCHAR(255)  NOT NULL,
+	type    VARCHAR(16)   NOT NULL,
+	target  VARCHAR(2048) NOT NULL,
+	secret  VARCHAR(255)  NOT NULL DEFAULT "",
+	events  BLOB          NULL DEFAULT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE TABLE notification_delivery (
+	id                      INTEGER       NOT NULL AUTO_INCREMENT,
+	notification_channel_id INTEGER       NOT NULL,
+	event                   VARCHAR(64)   NOT NULL,
+	subject                 VARCHAR(255)  NOT NULL,
+	status                  VARCHAR(16)   NOT NULL,
+	attempts                INTEGER       NOT NULL,
+	error                   VARCHAR(1024) NOT NULL DEFAULT "",
+	created                 TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_notification_channel FOREIGN KEY (notification_channel_id) REFERENCES notification_channel(id) ON DELETE CASCADE
+);
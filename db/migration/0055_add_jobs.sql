@@ -0,0 +1,35 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE job (
+	id               INTEGER       NOT NULL AUTO_INCREMENT,
+	type             VARCHAR(64)   NOT NULL,
+	args             BLOB          NOT NULL,
+	user_id          INTEGER       NULL DEFAULT NULL,
+	aws_account_id   INTEGER       NULL DEFAULT NULL,
+	status           VARCHAR(16)   NOT NULL DEFAULT "pending",
+	attempts         INTEGER       NOT NULL DEFAULT 0,
+	max_attempts     INTEGER       NOT NULL,
+	run_after        DATETIME      NOT NULL,
+	lease_owner      VARCHAR(255)  NOT NULL DEFAULT "",
+	lease_expires    DATETIME      NOT NULL,
+	cancel_requested BOOLEAN       NOT NULL DEFAULT 0,
+	error            VARCHAR(1024) NOT NULL DEFAULT "",
+	created          TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated          TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE,
+	INDEX job_status_run_after (status, run_after)
+);
@@ -0,0 +1,39 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE billing_source (
+	id            INTEGER       NOT NULL AUTO_INCREMENT,
+	user_id       INTEGER       NOT NULL,
+	provider      VARCHAR(16)   NOT NULL,
+	name          VARCHAR(255)  NOT NULL,
+	location      VARCHAR(2048) NOT NULL,
+	credentials   BLOB          NULL DEFAULT NULL,
+	last_imported DATETIME      NOT NULL DEFAULT "1970-01-01 00:00:00",
+	next_update   DATETIME      NOT NULL DEFAULT "1970-01-01 00:00:00",
+	error         VARCHAR(1024) NOT NULL DEFAULT "",
+	created       TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE TABLE billing_source_account (
+	id                    INTEGER      NOT NULL AUTO_INCREMENT,
+	billing_source_id     INTEGER      NOT NULL,
+	account_id            VARCHAR(255) NOT NULL,
+	name                  VARCHAR(255) NOT NULL DEFAULT "",
+	last_anomalies_update DATETIME     NOT NULL DEFAULT "1970-01-01 00:00:00",
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_billing_source FOREIGN KEY (billing_source_id) REFERENCES billing_source(id) ON DELETE CASCADE,
+	CONSTRAINT unique_billing_source_account UNIQUE KEY (billing_source_id, account_id)
+);
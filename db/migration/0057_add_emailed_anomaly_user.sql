@@ -0,0 +1,21 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+ALTER TABLE emailed_anomaly MODIFY aws_account_id INTEGER NULL DEFAULT NULL;
+ALTER TABLE emailed_anomaly ADD user_id INTEGER NULL DEFAULT NULL;
+ALTER TABLE emailed_anomaly ADD anomaly_id VARCHAR(255) NOT NULL DEFAULT "";
+ALTER TABLE emailed_anomaly ADD CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE;
+CREATE INDEX emailed_anomaly_user_anomaly ON emailed_anomaly (user_id, anomaly_id);
+
+UPDATE emailed_anomaly INNER JOIN aws_account ON aws_account.id = emailed_anomaly.aws_account_id SET emailed_anomaly.user_id = aws_account.user_id;
@@ -0,0 +1,28 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE user_session (
+	id            INTEGER      NOT NULL AUTO_INCREMENT,
+	user_id       INTEGER      NOT NULL,
+	refresh_token CHAR(64)     NOT NULL,
+	user_agent    VARCHAR(255) NOT NULL DEFAULT "",
+	ip_address    VARCHAR(64)  NOT NULL DEFAULT "",
+	created       DATETIME     NOT NULL,
+	last_used     DATETIME     NOT NULL,
+	expires       DATETIME     NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	CONSTRAINT unique_refresh_token UNIQUE KEY (refresh_token),
+	INDEX user_session_expires (expires)
+);
@@ -0,0 +1,65 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE sso_provider (
+	id            INTEGER       NOT NULL AUTO_INCREMENT,
+	user_id       INTEGER       NOT NULL,
+	name          VARCHAR(255)  NOT NULL,
+	issuer        VARCHAR(255)  NOT NULL,
+	client_id     VARCHAR(255)  NOT NULL,
+	client_secret VARCHAR(255)  NOT NULL,
+	redirect_uri  VARCHAR(2048) NOT NULL,
+	groups_claim  VARCHAR(255)  NOT NULL DEFAULT "groups",
+	email_domain  VARCHAR(255)  NOT NULL DEFAULT "",
+	created       TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE TABLE sso_group_mapping (
+	id              INTEGER      NOT NULL AUTO_INCREMENT,
+	sso_provider_id INTEGER      NOT NULL,
+	group_name      VARCHAR(255) NOT NULL,
+	aws_account_id  INTEGER      NOT NULL,
+	permission      INTEGER      NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_sso_provider FOREIGN KEY (sso_provider_id) REFERENCES sso_provider(id) ON DELETE CASCADE,
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE
+);
+
+CREATE TABLE sso_login (
+	id              INTEGER      NOT NULL AUTO_INCREMENT,
+	sso_provider_id INTEGER      NOT NULL,
+	state           CHAR(64)     NOT NULL,
+	nonce           VARCHAR(64)  NOT NULL,
+	code_verifier   VARCHAR(128) NOT NULL,
+	created         DATETIME     NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_sso_provider FOREIGN KEY (sso_provider_id) REFERENCES sso_provider(id) ON DELETE CASCADE,
+	CONSTRAINT unique_state UNIQUE KEY (state)
+);
+
+CREATE TABLE sso_identity (
+	id              INTEGER      NOT NULL AUTO_INCREMENT,
+	sso_provider_id INTEGER      NOT NULL,
+	subject         VARCHAR(255) NOT NULL,
+	user_id         INTEGER      NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_sso_provider FOREIGN KEY (sso_provider_id) REFERENCES sso_provider(id) ON DELETE CASCADE,
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	CONSTRAINT unique_subject UNIQUE KEY (sso_provider_id, subject)
+);
+
+ALTER TABLE shared_account ADD COLUMN sso_provider_id INTEGER NULL DEFAULT NULL;
+ALTER TABLE shared_account ADD CONSTRAINT foreign_sso_provider FOREIGN KEY (sso_provider_id) REFERENCES sso_provider(id) ON DELETE CASCADE;
@@ -0,0 +1,46 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE user_mfa (
+	id              INTEGER     NOT NULL AUTO_INCREMENT,
+	user_id         INTEGER     NOT NULL,
+	secret          VARCHAR(64) NOT NULL,
+	enabled         BOOLEAN     NOT NULL DEFAULT FALSE,
+	last_used_step  BIGINT      NOT NULL DEFAULT 0,
+	failed_attempts INTEGER     NOT NULL DEFAULT 0,
+	locked_until    DATETIME    NULL DEFAULT NULL,
+	created         DATETIME    NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	CONSTRAINT unique_user UNIQUE KEY (user_id)
+);
+
+CREATE TABLE user_mfa_recovery_code (
+	id      INTEGER  NOT NULL AUTO_INCREMENT,
+	user_id INTEGER  NOT NULL,
+	hash    CHAR(64) NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	CONSTRAINT unique_user_hash UNIQUE KEY (user_id, hash)
+);
+
+CREATE TABLE auth_policy (
+	id          INTEGER NOT NULL AUTO_INCREMENT,
+	require_mfa BOOLEAN NOT NULL DEFAULT FALSE,
+	CONSTRAINT PRIMARY KEY (id)
+);
+
+INSERT INTO auth_policy (require_mfa) VALUES (FALSE);
+
+ALTER TABLE user_session ADD COLUMN mfa BOOLEAN NOT NULL DEFAULT FALSE;
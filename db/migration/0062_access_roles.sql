@@ -0,0 +1,37 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE access_role (
+	id          INTEGER      NOT NULL AUTO_INCREMENT,
+	user_id     INTEGER      NULL DEFAULT NULL,
+	name        VARCHAR(255) NOT NULL,
+	permissions BLOB         NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+INSERT INTO access_role (id, user_id, name, permissions) VALUES
+	(1, NULL, "Administrator", '["costs:view","resources:view","billrepositories:manage","plugins:manage","sharing:manage","reports:download"]'),
+	(2, NULL, "Standard",      '["costs:view","resources:view","billrepositories:manage","plugins:manage","reports:download"]'),
+	(3, NULL, "Read-only",     '["costs:view","resources:view","reports:download"]');
+
+ALTER TABLE shared_account ADD COLUMN access_role_id INTEGER NOT NULL DEFAULT 3;
+UPDATE shared_account SET access_role_id = CASE user_permission WHEN 0 THEN 1 WHEN 1 THEN 2 ELSE 3 END;
+ALTER TABLE shared_account ADD CONSTRAINT foreign_access_role FOREIGN KEY (access_role_id) REFERENCES access_role(id);
+ALTER TABLE shared_account DROP COLUMN user_permission;
+
+ALTER TABLE sso_group_mapping ADD COLUMN access_role_id INTEGER NOT NULL DEFAULT 3;
+UPDATE sso_group_mapping SET access_role_id = CASE permission WHEN 0 THEN 1 WHEN 1 THEN 2 ELSE 3 END;
+ALTER TABLE sso_group_mapping ADD CONSTRAINT foreign_access_role FOREIGN KEY (access_role_id) REFERENCES access_role(id);
+ALTER TABLE sso_group_mapping DROP COLUMN permission;
@@ -0,0 +1,31 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE audit_log (
+	id           INTEGER      NOT NULL AUTO_INCREMENT,
+	owner_id     INTEGER      NOT NULL,
+	actor_id     INTEGER      NOT NULL,
+	actor_email  VARCHAR(254) NOT NULL,
+	action       VARCHAR(64)  NOT NULL,
+	target_type  VARCHAR(64)  NOT NULL,
+	target_id    VARCHAR(255) NOT NULL,
+	before_value BLOB         NULL DEFAULT NULL,
+	after_value  BLOB         NULL DEFAULT NULL,
+	request_id   VARCHAR(36)  NOT NULL,
+	ip_address   VARCHAR(45)  NOT NULL,
+	created      DATETIME     NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	INDEX owner_created (owner_id, created),
+	INDEX actor_created (actor_id, created)
+);
@@ -0,0 +1,38 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE aws_organization_unit (
+	id             INTEGER      NOT NULL AUTO_INCREMENT,
+	aws_account_id INTEGER      NOT NULL,
+	unit_id        VARCHAR(68)  NOT NULL,
+	parent_unit_id VARCHAR(68)  NOT NULL DEFAULT "",
+	name           VARCHAR(128) NOT NULL,
+	type           VARCHAR(32)  NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE,
+	CONSTRAINT unique_unit UNIQUE KEY (aws_account_id, unit_id),
+	INDEX unit_id (unit_id)
+);
+
+CREATE TABLE aws_organization_account (
+	id             INTEGER      NOT NULL AUTO_INCREMENT,
+	aws_account_id INTEGER      NOT NULL,
+	account_id     VARCHAR(12)  NOT NULL,
+	name           VARCHAR(255) NOT NULL,
+	unit_id        VARCHAR(68)  NOT NULL,
+	tags           BLOB         NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE,
+	CONSTRAINT unique_account UNIQUE KEY (aws_account_id, account_id)
+);
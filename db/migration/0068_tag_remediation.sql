@@ -0,0 +1,47 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE tag_remediation (
+	id          INTEGER     NOT NULL AUTO_INCREMENT,
+	user_id     INTEGER     NOT NULL,
+	created_by  INTEGER     NOT NULL,
+	reviewed_by INTEGER     NULL DEFAULT NULL,
+	status      VARCHAR(32) NOT NULL,
+	changes     BLOB        NOT NULL,
+	job_id      INTEGER     NULL DEFAULT NULL,
+	created     DATETIME    NOT NULL,
+	updated     DATETIME    NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE TABLE tag_remediation_resource (
+	id             INTEGER       NOT NULL AUTO_INCREMENT,
+	remediation_id INTEGER       NOT NULL,
+	account        VARCHAR(255)  NOT NULL,
+	region         VARCHAR(255)  NOT NULL,
+	resource_type  VARCHAR(255)  NOT NULL,
+	resource_id    VARCHAR(255)  NOT NULL,
+	arn            VARCHAR(2048) NOT NULL,
+	tags_before    BLOB          NOT NULL,
+	tags_after     BLOB          NOT NULL,
+	status         VARCHAR(32)   NOT NULL,
+	error          VARCHAR(1024) NOT NULL DEFAULT "",
+	updated        DATETIME      NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_remediation FOREIGN KEY (remediation_id) REFERENCES tag_remediation(id) ON DELETE CASCADE
+);
+
+UPDATE access_role SET permissions = '["costs:view","resources:view","billrepositories:manage","plugins:manage","sharing:manage","reports:download","tags:edit","tags:approve"]' WHERE id = 1;
+UPDATE access_role SET permissions = '["costs:view","resources:view","billrepositories:manage","plugins:manage","reports:download","tags:edit"]' WHERE id = 2;
@@ -0,0 +1,19 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+-- The line items ingested before the blended, net and amortized costs were
+-- read from Cost and Usage Reports lack them. Importing every manifest again
+-- overwrites them, as line items are indexed with stable IDs and the "index"
+-- operation type.
+UPDATE aws_bill_repository SET last_imported_manifest = "1970-01-01 00:00:00", next_update = NOW();
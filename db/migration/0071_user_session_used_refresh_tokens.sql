@@ -0,0 +1,22 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+-- The refresh tokens a session was rotated from. Presenting one of them again
+-- means it was stolen, and revokes the session.
+CREATE TABLE user_session_used_refresh_token (
+	refresh_token CHAR(64) NOT NULL,
+	session_id    INTEGER  NOT NULL,
+	CONSTRAINT PRIMARY KEY (refresh_token),
+	CONSTRAINT foreign_session FOREIGN KEY (session_id) REFERENCES user_session(id) ON DELETE CASCADE
+);
@@ -0,0 +1,19 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+-- The email domain of an SSO provider must be verified through a DNS TXT
+-- record holding its token before the provider can provision users, so that
+-- nobody can claim the email addresses of a domain they do not control.
+ALTER TABLE sso_provider ADD email_domain_token    VARCHAR(255) NOT NULL DEFAULT "";
+ALTER TABLE sso_provider ADD email_domain_verified BOOLEAN      NOT NULL DEFAULT 0;
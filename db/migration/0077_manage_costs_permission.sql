@@ -0,0 +1,16 @@
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+UPDATE access_role SET permissions = '["costs:view","costs:manage","resources:view","billrepositories:manage","plugins:manage","sharing:manage","reports:download","tags:edit","tags:approve"]' WHERE id = 1;
+UPDATE access_role SET permissions = '["costs:view","costs:manage","resources:view","billrepositories:manage","plugins:manage","reports:download","tags:edit"]' WHERE id = 2;
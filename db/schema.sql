@@ -1189,3 +1189,786 @@ CREATE TABLE tagbot_user (
 
 ALTER TABLE tagbot_user ADD stripe_subscription_identifier VARCHAR(255) NOT NULL DEFAULT "";
 ALTER TABLE tagbot_user ADD stripe_payment_method_identifier VARCHAR(255) NOT NULL DEFAULT "";
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE anomaly_detector (
+	id             INTEGER      NOT NULL AUTO_INCREMENT,
+	aws_account_id INTEGER      NOT NULL,
+	product        VARCHAR(255) NOT NULL DEFAULT "",
+	detector       VARCHAR(64)  NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_aws_account_product UNIQUE KEY (aws_account_id, product),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE
+);
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE budget (
+	id         INTEGER      NOT NULL AUTO_INCREMENT,
+	user_id    INTEGER      NOT NULL,
+	name       VARCHAR(255) NOT NULL,
+	period     VARCHAR(16)  NOT NULL,
+	amount     DOUBLE       NOT NULL,
+	scope      BLOB         NOT NULL,
+	thresholds BLOB         NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE TABLE budget_alert (
+	id           INTEGER   NOT NULL AUTO_INCREMENT,
+	budget_id    INTEGER   NOT NULL,
+	period_begin DATETIME  NOT NULL,
+	threshold    INTEGER   NOT NULL,
+	forecast     BOOLEAN   NOT NULL,
+	sent         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_budget_alert UNIQUE KEY (budget_id, period_begin, threshold, forecast),
+	CONSTRAINT foreign_budget FOREIGN KEY (budget_id) REFERENCES budget(id) ON DELETE CASCADE
+);
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE notification_channel (
+	id      INTEGER       NOT NULL AUTO_INCREMENT,
+	user_id INTEGER       NOT NULL,
+	name    VARCHAR(255)  NOT NULL,
+	type    VARCHAR(16)   NOT NULL,
+	target  VARCHAR(2048) NOT NULL,
+	secret  VARCHAR(255)  NOT NULL DEFAULT "",
+	events  BLOB          NULL DEFAULT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE TABLE notification_delivery (
+	id                      INTEGER       NOT NULL AUTO_INCREMENT,
+	notification_channel_id INTEGER       NOT NULL,
+	event                   VARCHAR(64)   NOT NULL,
+	subject                 VARCHAR(255)  NOT NULL,
+	status                  VARCHAR(16)   NOT NULL,
+	attempts                INTEGER       NOT NULL,
+	error                   VARCHAR(1024) NOT NULL DEFAULT "",
+	created                 TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_notification_channel FOREIGN KEY (notification_channel_id) REFERENCES notification_channel(id) ON DELETE CASCADE
+);
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE job (
+	id               INTEGER       NOT NULL AUTO_INCREMENT,
+	type             VARCHAR(64)   NOT NULL,
+	args             BLOB          NOT NULL,
+	user_id          INTEGER       NULL DEFAULT NULL,
+	aws_account_id   INTEGER       NULL DEFAULT NULL,
+	status           VARCHAR(16)   NOT NULL DEFAULT "pending",
+	attempts         INTEGER       NOT NULL DEFAULT 0,
+	max_attempts     INTEGER       NOT NULL,
+	run_after        DATETIME      NOT NULL,
+	lease_owner      VARCHAR(255)  NOT NULL DEFAULT "",
+	lease_expires    DATETIME      NOT NULL,
+	cancel_requested BOOLEAN       NOT NULL DEFAULT 0,
+	error            VARCHAR(1024) NOT NULL DEFAULT "",
+	created          TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated          TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE,
+	INDEX job_status_run_after (status, run_after)
+);
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE billing_source (
+	id            INTEGER       NOT NULL AUTO_INCREMENT,
+	user_id       INTEGER       NOT NULL,
+	provider      VARCHAR(16)   NOT NULL,
+	name          VARCHAR(255)  NOT NULL,
+	location      VARCHAR(2048) NOT NULL,
+	credentials   BLOB          NULL DEFAULT NULL,
+	last_imported DATETIME      NOT NULL DEFAULT "1970-01-01 00:00:00",
+	next_update   DATETIME      NOT NULL DEFAULT "1970-01-01 00:00:00",
+	error         VARCHAR(1024) NOT NULL DEFAULT "",
+	created       TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE TABLE billing_source_account (
+	id                    INTEGER      NOT NULL AUTO_INCREMENT,
+	billing_source_id     INTEGER      NOT NULL,
+	account_id            VARCHAR(255) NOT NULL,
+	name                  VARCHAR(255) NOT NULL DEFAULT "",
+	last_anomalies_update DATETIME     NOT NULL DEFAULT "1970-01-01 00:00:00",
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_billing_source FOREIGN KEY (billing_source_id) REFERENCES billing_source(id) ON DELETE CASCADE,
+	CONSTRAINT unique_billing_source_account UNIQUE KEY (billing_source_id, account_id)
+);
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+ALTER TABLE emailed_anomaly MODIFY aws_account_id INTEGER NULL DEFAULT NULL;
+ALTER TABLE emailed_anomaly ADD user_id INTEGER NULL DEFAULT NULL;
+ALTER TABLE emailed_anomaly ADD anomaly_id VARCHAR(255) NOT NULL DEFAULT "";
+ALTER TABLE emailed_anomaly ADD CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE;
+CREATE INDEX emailed_anomaly_user_anomaly ON emailed_anomaly (user_id, anomaly_id);
+
+UPDATE emailed_anomaly INNER JOIN aws_account ON aws_account.id = emailed_anomaly.aws_account_id SET emailed_anomaly.user_id = aws_account.user_id;
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE api_key (
+	id          INTEGER      NOT NULL AUTO_INCREMENT,
+	user_id     INTEGER      NOT NULL,
+	name        VARCHAR(255) NOT NULL,
+	prefix      VARCHAR(16)  NOT NULL,
+	hash        CHAR(64)     NOT NULL,
+	scopes      BLOB         NOT NULL,
+	accounts    BLOB         NULL DEFAULT NULL,
+	expires     DATETIME     NULL DEFAULT NULL,
+	last_used   DATETIME     NULL DEFAULT NULL,
+	created     TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	CONSTRAINT unique_hash UNIQUE KEY (hash)
+);
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE user_session (
+	id            INTEGER      NOT NULL AUTO_INCREMENT,
+	user_id       INTEGER      NOT NULL,
+	refresh_token CHAR(64)     NOT NULL,
+	user_agent    VARCHAR(255) NOT NULL DEFAULT "",
+	ip_address    VARCHAR(64)  NOT NULL DEFAULT "",
+	created       DATETIME     NOT NULL,
+	last_used     DATETIME     NOT NULL,
+	expires       DATETIME     NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	CONSTRAINT unique_refresh_token UNIQUE KEY (refresh_token),
+	INDEX user_session_expires (expires)
+);
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE sso_provider (
+	id            INTEGER       NOT NULL AUTO_INCREMENT,
+	user_id       INTEGER       NOT NULL,
+	name          VARCHAR(255)  NOT NULL,
+	issuer        VARCHAR(255)  NOT NULL,
+	client_id     VARCHAR(255)  NOT NULL,
+	client_secret VARCHAR(255)  NOT NULL,
+	redirect_uri  VARCHAR(2048) NOT NULL,
+	groups_claim  VARCHAR(255)  NOT NULL DEFAULT "groups",
+	email_domain  VARCHAR(255)  NOT NULL DEFAULT "",
+	created       TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE TABLE sso_group_mapping (
+	id              INTEGER      NOT NULL AUTO_INCREMENT,
+	sso_provider_id INTEGER      NOT NULL,
+	group_name      VARCHAR(255) NOT NULL,
+	aws_account_id  INTEGER      NOT NULL,
+	permission      INTEGER      NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_sso_provider FOREIGN KEY (sso_provider_id) REFERENCES sso_provider(id) ON DELETE CASCADE,
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE
+);
+
+CREATE TABLE sso_login (
+	id              INTEGER      NOT NULL AUTO_INCREMENT,
+	sso_provider_id INTEGER      NOT NULL,
+	state           CHAR(64)     NOT NULL,
+	nonce           VARCHAR(64)  NOT NULL,
+	code_verifier   VARCHAR(128) NOT NULL,
+	created         DATETIME     NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_sso_provider FOREIGN KEY (sso_provider_id) REFERENCES sso_provider(id) ON DELETE CASCADE,
+	CONSTRAINT unique_state UNIQUE KEY (state)
+);
+
+CREATE TABLE sso_identity (
+	id              INTEGER      NOT NULL AUTO_INCREMENT,
+	sso_provider_id INTEGER      NOT NULL,
+	subject         VARCHAR(255) NOT NULL,
+	user_id         INTEGER      NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_sso_provider FOREIGN KEY (sso_provider_id) REFERENCES sso_provider(id) ON DELETE CASCADE,
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	CONSTRAINT unique_subject UNIQUE KEY (sso_provider_id, subject)
+);
+
+ALTER TABLE shared_account ADD COLUMN sso_provider_id INTEGER NULL DEFAULT NULL;
+ALTER TABLE shared_account ADD CONSTRAINT foreign_sso_provider FOREIGN KEY (sso_provider_id) REFERENCES sso_provider(id) ON DELETE CASCADE;
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE user_mfa (
+	id              INTEGER     NOT NULL AUTO_INCREMENT,
+	user_id         INTEGER     NOT NULL,
+	secret          VARCHAR(64) NOT NULL,
+	enabled         BOOLEAN     NOT NULL DEFAULT FALSE,
+	last_used_step  BIGINT      NOT NULL DEFAULT 0,
+	failed_attempts INTEGER     NOT NULL DEFAULT 0,
+	locked_until    DATETIME    NULL DEFAULT NULL,
+	created         DATETIME    NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	CONSTRAINT unique_user UNIQUE KEY (user_id)
+);
+
+CREATE TABLE user_mfa_recovery_code (
+	id      INTEGER  NOT NULL AUTO_INCREMENT,
+	user_id INTEGER  NOT NULL,
+	hash    CHAR(64) NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+	CONSTRAINT unique_user_hash UNIQUE KEY (user_id, hash)
+);
+
+CREATE TABLE auth_policy (
+	id          INTEGER NOT NULL AUTO_INCREMENT,
+	require_mfa BOOLEAN NOT NULL DEFAULT FALSE,
+	CONSTRAINT PRIMARY KEY (id)
+);
+
+INSERT INTO auth_policy (require_mfa) VALUES (FALSE);
+
+ALTER TABLE user_session ADD COLUMN mfa BOOLEAN NOT NULL DEFAULT FALSE;
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE access_role (
+	id          INTEGER      NOT NULL AUTO_INCREMENT,
+	user_id     INTEGER      NULL DEFAULT NULL,
+	name        VARCHAR(255) NOT NULL,
+	permissions BLOB         NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+INSERT INTO access_role (id, user_id, name, permissions) VALUES
+	(1, NULL, "Administrator", '["costs:view","resources:view","billrepositories:manage","plugins:manage","sharing:manage","reports:download"]'),
+	(2, NULL, "Standard",      '["costs:view","resources:view","billrepositories:manage","plugins:manage","reports:download"]'),
+	(3, NULL, "Read-only",     '["costs:view","resources:view","reports:download"]');
+
+ALTER TABLE shared_account ADD COLUMN access_role_id INTEGER NOT NULL DEFAULT 3;
+UPDATE shared_account SET access_role_id = CASE user_permission WHEN 0 THEN 1 WHEN 1 THEN 2 ELSE 3 END;
+ALTER TABLE shared_account ADD CONSTRAINT foreign_access_role FOREIGN KEY (access_role_id) REFERENCES access_role(id);
+ALTER TABLE shared_account DROP COLUMN user_permission;
+
+ALTER TABLE sso_group_mapping ADD COLUMN access_role_id INTEGER NOT NULL DEFAULT 3;
+UPDATE sso_group_mapping SET access_role_id = CASE permission WHEN 0 THEN 1 WHEN 1 THEN 2 ELSE 3 END;
+ALTER TABLE sso_group_mapping ADD CONSTRAINT foreign_access_role FOREIGN KEY (access_role_id) REFERENCES access_role(id);
+ALTER TABLE sso_group_mapping DROP COLUMN permission;
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE audit_log (
+	id           INTEGER      NOT NULL AUTO_INCREMENT,
+	owner_id     INTEGER      NOT NULL,
+	actor_id     INTEGER      NOT NULL,
+	actor_email  VARCHAR(254) NOT NULL,
+	action       VARCHAR(64)  NOT NULL,
+	target_type  VARCHAR(64)  NOT NULL,
+	target_id    VARCHAR(255) NOT NULL,
+	before_value BLOB         NULL DEFAULT NULL,
+	after_value  BLOB         NULL DEFAULT NULL,
+	request_id   VARCHAR(36)  NOT NULL,
+	ip_address   VARCHAR(45)  NOT NULL,
+	created      DATETIME     NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	INDEX owner_created (owner_id, created),
+	INDEX actor_created (actor_id, created)
+);
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE aws_organization_unit (
+	id             INTEGER      NOT NULL AUTO_INCREMENT,
+	aws_account_id INTEGER      NOT NULL,
+	unit_id        VARCHAR(68)  NOT NULL,
+	parent_unit_id VARCHAR(68)  NOT NULL DEFAULT "",
+	name           VARCHAR(128) NOT NULL,
+	type           VARCHAR(32)  NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE,
+	CONSTRAINT unique_unit UNIQUE KEY (aws_account_id, unit_id),
+	INDEX unit_id (unit_id)
+);
+
+CREATE TABLE aws_organization_account (
+	id             INTEGER      NOT NULL AUTO_INCREMENT,
+	aws_account_id INTEGER      NOT NULL,
+	account_id     VARCHAR(12)  NOT NULL,
+	name           VARCHAR(255) NOT NULL,
+	unit_id        VARCHAR(68)  NOT NULL,
+	tags           BLOB         NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_aws_account FOREIGN KEY (aws_account_id) REFERENCES aws_account(id) ON DELETE CASCADE,
+	CONSTRAINT unique_account UNIQUE KEY (aws_account_id, account_id)
+);
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE cost_category (
+	id            INTEGER      NOT NULL AUTO_INCREMENT,
+	user_id       INTEGER      NOT NULL,
+	name          VARCHAR(255) NOT NULL,
+	default_value VARCHAR(255) NOT NULL,
+	rules         BLOB         NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_user_name UNIQUE KEY (user_id, name),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE cost_redistribution (
+	id      INTEGER      NOT NULL AUTO_INCREMENT,
+	user_id INTEGER      NOT NULL,
+	name    VARCHAR(255) NOT NULL,
+	rule    BLOB         NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_user_name UNIQUE KEY (user_id, name),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE tag_policy (
+	id      INTEGER      NOT NULL AUTO_INCREMENT,
+	user_id INTEGER      NOT NULL,
+	name    VARCHAR(255) NOT NULL,
+	policy  BLOB         NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT unique_user_name UNIQUE KEY (user_id, name),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+CREATE TABLE tag_remediation (
+	id          INTEGER     NOT NULL AUTO_INCREMENT,
+	user_id     INTEGER     NOT NULL,
+	created_by  INTEGER     NOT NULL,
+	reviewed_by INTEGER     NULL DEFAULT NULL,
+	status      VARCHAR(32) NOT NULL,
+	changes     BLOB        NOT NULL,
+	job_id      INTEGER     NULL DEFAULT NULL,
+	created     DATETIME    NOT NULL,
+	updated     DATETIME    NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_user FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE TABLE tag_remediation_resource (
+	id             INTEGER       NOT NULL AUTO_INCREMENT,
+	remediation_id INTEGER       NOT NULL,
+	account        VARCHAR(255)  NOT NULL,
+	region         VARCHAR(255)  NOT NULL,
+	resource_type  VARCHAR(255)  NOT NULL,
+	resource_id    VARCHAR(255)  NOT NULL,
+	arn            VARCHAR(2048) NOT NULL,
+	tags_before    BLOB          NOT NULL,
+	tags_after     BLOB          NOT NULL,
+	status         VARCHAR(32)   NOT NULL,
+	error          VARCHAR(1024) NOT NULL DEFAULT "",
+	updated        DATETIME      NOT NULL,
+	CONSTRAINT PRIMARY KEY (id),
+	CONSTRAINT foreign_remediation FOREIGN KEY (remediation_id) REFERENCES tag_remediation(id) ON DELETE CASCADE
+);
+
+UPDATE access_role SET permissions = '["costs:view","resources:view","billrepositories:manage","plugins:manage","sharing:manage","reports:download","tags:edit","tags:approve"]' WHERE id = 1;
+UPDATE access_role SET permissions = '["costs:view","resources:view","billrepositories:manage","plugins:manage","reports:download","tags:edit"]' WHERE id = 2;
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+ALTER TABLE aws_account_update_job ADD inventoryError VARCHAR(255) NOT NULL DEFAULT "";
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+-- The line items ingested before the blended, net and amortized costs were
+-- read from Cost and Usage Reports lack them. Importing every manifest again
+-- overwrites them, as line items are indexed with stable IDs and the "index"
+-- operation type.
+UPDATE aws_bill_repository SET last_imported_manifest = "1970-01-01 00:00:00", next_update = NOW();
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+-- The refresh tokens a session was rotated from. Presenting one of them again
+-- means it was stolen, and revokes the session.
+CREATE TABLE user_session_used_refresh_token (
+	refresh_token CHAR(64) NOT NULL,
+	session_id    INTEGER  NOT NULL,
+	CONSTRAINT PRIMARY KEY (refresh_token),
+	CONSTRAINT foreign_session FOREIGN KEY (session_id) REFERENCES user_session(id) ON DELETE CASCADE
+);
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+-- Whether an API key was created from a session verified with a second
+-- factor. The others are rejected when the MFA policy is enabled.
+ALTER TABLE api_key ADD COLUMN mfa BOOLEAN NOT NULL DEFAULT 0;
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+-- The type of cost a budget accounts, as in the costType argument of the
+-- cost routes.
+ALTER TABLE budget ADD COLUMN cost_type VARCHAR(16) NOT NULL DEFAULT 'unblended';
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+-- The runs of the periodic tasks, shared by the servers so that their
+-- history outlives the process which ran them.
+CREATE TABLE periodic_task_run (
+	id         INTEGER      NOT NULL AUTO_INCREMENT,
+	task       VARCHAR(255) NOT NULL,
+	backend_id VARCHAR(255) NOT NULL,
+	started    DATETIME     NOT NULL,
+	ended      DATETIME     NOT NULL,
+	error      TEXT         NOT NULL,
+	skipped    BOOLEAN      NOT NULL DEFAULT 0,
+	CONSTRAINT PRIMARY KEY (id),
+	INDEX periodic_task_run_task_started (task, started)
+);
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+-- The identity of the account of an emailed anomaly, so that the anomalies
+-- of every provider are tracked the same way, not only the ones of the AWS
+-- accounts.
+ALTER TABLE emailed_anomaly ADD account VARCHAR(255) NOT NULL DEFAULT "";
+CREATE INDEX emailed_anomaly_user_account ON emailed_anomaly (user_id, account, product, date);
+
+UPDATE emailed_anomaly INNER JOIN aws_account ON aws_account.id = emailed_anomaly.aws_account_id SET emailed_anomaly.account = aws_account.aws_identity;
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+-- The email domain of an SSO provider must be verified through a DNS TXT
+-- record holding its token before the provider can provision users, so that
+-- nobody can claim the email addresses of a domain they do not control.
+ALTER TABLE sso_provider ADD email_domain_token    VARCHAR(255) NOT NULL DEFAULT "";
+ALTER TABLE sso_provider ADD email_domain_verified BOOLEAN      NOT NULL DEFAULT 0;
+
+--   Copyright 2020 MSolution.IO
+--
+--   Licensed under the Apache License, Version 2.0 (the "License");
+--   you may not use this file except in compliance with the License.
+--   You may obtain a copy of the License at
+--
+--       http://www.apache.org/licenses/LICENSE-2.0
+--
+--   Unless required by applicable law or agreed to in writing, software
+--   distributed under the License is distributed on an "AS IS" BASIS,
+--   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+--   See the License for the specific language governing permissions and
+--   limitations under the License.
+
+UPDATE access_role SET permissions = '["costs:view","costs:manage","resources:view","billrepositories:manage","plugins:manage","sharing:manage","reports:download","tags:edit","tags:approve"]' WHERE id = 1;
+UPDATE access_role SET permissions = '["costs:view","costs:manage","resources:view","billrepositories:manage","plugins:manage","reports:download","tags:edit"]' WHERE id = 2;
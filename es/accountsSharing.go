@@ -78,13 +78,28 @@ func getAllAccountsAndIndexes(user users.User, tx *sql.Tx, indexPrefix string) (
 	}
 	// Add all the user accounts
 	for _, userAccount := range userAccounts {
+		if !user.CanAccessAccount(userAccount.AwsIdentity) {
+			continue
+		}
 		accountsAndIndexes.addAccount(userAccount.AwsIdentity)
 		accountsAndIndexes.addIndex(IndexNameForUserId(userAccount.UserID, indexPrefix))
 	}
+	// Add all the accounts found in the exports of the user's billing sources
+	billingSourceAccounts, err := getBillingSourceAccounts(user, tx)
+	if err != nil {
+		return accountsAndIndexes, http.StatusInternalServerError, err
+	}
+	for account := range billingSourceAccounts {
+		if !user.CanAccessAccount(account) {
+			continue
+		}
+		accountsAndIndexes.addAccount(account)
+		accountsAndIndexes.addIndex(IndexNameForUserId(user.Id, indexPrefix))
+	}
 	// Add all the non duplicate shared accounts
 	for _, sharedAccount := range sharedAccounts {
 		// Do not add the account if the user already own the same account
-		if accountsAndIndexes.isAccountDuplicate(sharedAccount.AwsIdentity) == false {
+		if accountsAndIndexes.isAccountDuplicate(sharedAccount.AwsIdentity) == false && user.CanAccessAccount(sharedAccount.AwsIdentity) {
 			accountsAndIndexes.addAccount(sharedAccount.AwsIdentity)
 			accountsAndIndexes.addIndex(IndexNameForUserId(sharedAccount.OwnerID, indexPrefix))
 		}
@@ -105,7 +120,18 @@ func GetAccountsAndIndexes(accountList []string, user users.User, tx *sql.Tx, in
 		return getAllAccountsAndIndexes(user, tx, indexPrefix)
 	}
 	accountsAndIndexes := AccountsAndIndexes{}
-	if err := aws.ValidateAwsAccounts(accountList); err != nil {
+	billingSourceAccounts, err := getBillingSourceAccounts(user, tx)
+	if err != nil {
+		return accountsAndIndexes, http.StatusInternalServerError, err
+	}
+	// Only AWS accounts have a fixed format
+	awsAccountList := make([]string, 0, len(accountList))
+	for _, account := range accountList {
+		if !billingSourceAccounts[account] {
+			awsAccountList = append(awsAccountList, account)
+		}
+	}
+	if err := aws.ValidateAwsAccounts(awsAccountList); err != nil {
 		return accountsAndIndexes, http.StatusBadRequest, err
 	}
 	// Retrieve the user's accounts and shared accounts
@@ -119,6 +145,10 @@ func GetAccountsAndIndexes(accountList []string, user users.User, tx *sql.Tx, in
 	}
 	// Match the accountList parameter with the user's accounts and shared accounts
 	for _, account := range accountList {
+		// The API key the user authenticated with may restrict their accounts
+		if !user.CanAccessAccount(account) {
+			return accountsAndIndexes, http.StatusBadRequest, fmt.Errorf("Unable to access account %s", account)
+		}
 		found_match := false
 		// Try to match in priority with the user's accounts
 		for _, userAccount := range userAccounts {
@@ -128,6 +158,12 @@ func GetAccountsAndIndexes(accountList []string, user users.User, tx *sql.Tx, in
 				accountsAndIndexes.addIndex(IndexNameForUserId(userAccount.UserID, indexPrefix))
 			}
 		}
+		// If no match is found in the user's accounts, try in the accounts of their billing sources
+		if found_match == false && billingSourceAccounts[account] {
+			found_match = true
+			accountsAndIndexes.addAccount(account)
+			accountsAndIndexes.addIndex(IndexNameForUserId(user.Id, indexPrefix))
+		}
 		// If no match is found in the user's accounts, try in the shared accounts
 		if found_match == false {
 			for _, sharedAccount := range sharedAccounts {
@@ -146,3 +182,18 @@ func GetAccountsAndIndexes(accountList []string, user users.User, tx *sql.Tx, in
 	}
 	return accountsAndIndexes, http.StatusOK, nil
 }
+
+// getBillingSourceAccounts returns the set of the accounts found in the
+// exports of the billing sources of a user. Those are the accounts of other
+// cloud providers than AWS.
+func getBillingSourceAccounts(user users.User, tx *sql.Tx) (map[string]bool, error) {
+	billingSourceAccounts, err := models.BillingSourceAccountsByUserID(tx, user.Id)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve the list of billing source accounts for current user: %s", err.Error())
+	}
+	accounts := make(map[string]bool, len(billingSourceAccounts))
+	for _, billingSourceAccount := range billingSourceAccounts {
+		accounts[billingSourceAccount.AccountID] = true
+	}
+	return accounts, nil
+}
@@ -37,3 +37,23 @@ func CleanCurrentMonthBillByBillRepositoryId(ctx context.Context, aaUId, brId in
 	_, err := elastic.NewDeleteByQueryService(Client).WaitForCompletion(false).Index(index).Query(query).Do(ctx)
 	return err
 }
+
+// CleanByBillingSourceId removes every line item of a specific billing source
+func CleanByBillingSourceId(ctx context.Context, userId, billingSourceId int) error {
+	index := IndexNameForUserId(userId, IndexPrefixLineItems)
+	query := elastic.NewBoolQuery()
+	query = query.Filter(elastic.NewTermQuery("billingSourceId", billingSourceId))
+	_, err := elastic.NewDeleteByQueryService(Client).WaitForCompletion(false).Index(index).Query(query).Do(ctx)
+	return err
+}
+
+// CleanByBillingSourceFile removes the line items read from an export file of
+// a specific billing source. It waits for their removal so that the file can
+// be ingested again right after.
+func CleanByBillingSourceFile(ctx context.Context, userId, billingSourceId int, sourceFile string) error {
+	index := IndexNameForUserId(userId, IndexPrefixLineItems)
+	query := elastic.NewBoolQuery()
+	query = query.Filter(elastic.NewTermQuery("billingSourceId", billingSourceId), elastic.NewTermQuery("sourceFile", sourceFile))
+	_, err := elastic.NewDeleteByQueryService(Client).WaitForCompletion(true).Refresh("true").Index(index).Query(query).Do(ctx)
+	return err
+}
@@ -0,0 +1,93 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package es
+
+import (
+	"sort"
+	"strings"
+)
+
+// CostsRow is a leaf of a SimplifiedCostsDocument: the keys of the buckets
+// leading to it, from the root down, and its value.
+type CostsRow struct {
+	Keys  []string
+	Value float64
+}
+
+// Rows flattens a simplified costs document into the rows of its leaves.
+func (scd SimplifiedCostsDocument) Rows() []CostsRow {
+	rows := []CostsRow{}
+	scd.EachRow(func(row CostsRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	return rows
+}
+
+// EachRow calls f with the rows of the leaves of a simplified costs document,
+// in the order of Rows, as the document is walked instead of once they are
+// all built. It stops at the first error returned by f.
+func (scd SimplifiedCostsDocument) EachRow(f func(CostsRow) error) error {
+	return scd.eachRow(nil, f)
+}
+
+func (scd SimplifiedCostsDocument) eachRow(keys []string, f func(CostsRow) error) error {
+	if scd.HasValue {
+		return f(CostsRow{Keys: append([]string{}, keys...), Value: scd.Value})
+	}
+	for _, child := range scd.Children {
+		if err := child.eachRow(append(keys, child.Key), f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SimplifiedCostsDocumentFromRows builds a simplified costs document from
+// rows, as returned by Rows, whose buckets at each depth are of the kind at
+// the same index in kinds. The values of the rows with the same keys are
+// summed and the children are sorted by key.
+func SimplifiedCostsDocumentFromRows(kinds []string, rows []CostsRow) SimplifiedCostsDocument {
+	var scd SimplifiedCostsDocument
+	if len(kinds) == 0 {
+		scd.HasValue = true
+		for _, row := range rows {
+			scd.Value += row.Value
+		}
+		return scd
+	}
+	scd.ChildrenKind = kinds[0]
+	childrenRows := make(map[string][]CostsRow)
+	for _, row := range rows {
+		childrenRows[row.Keys[0]] = append(childrenRows[row.Keys[0]], CostsRow{Keys: row.Keys[1:], Value: row.Value})
+	}
+	keys := make([]string, 0, len(childrenRows))
+	for key := range childrenRows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		child := SimplifiedCostsDocumentFromRows(kinds[1:], childrenRows[key])
+		child.Key = key
+		scd.Children = append(scd.Children, child)
+	}
+	return scd
+}
+
+// CriterionKind returns the kind of the buckets of a costs criterion, which
+// is the part before the ':' of the criteria with a parameter.
+func CriterionKind(criterion string) string {
+	return strings.SplitN(criterion, ":", 2)[0]
+}
@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/olivere/elastic"
@@ -167,6 +168,8 @@ func getChildren(ctx context.Context, doc bucket) (string, []SimplifiedCostsDocu
 		logger.Error(fmt.Sprintf("Failed to get buckets: value under '%s' does not have '%s' field.", childKey, AggBucketKey), nil)
 		logger.Debug("Document is.", doc)
 		return "", nil, ErrFailedJsonParsing
+	} else if keyedChildren, ok := childAggsBuckets.(map[string]interface{}); ok {
+		return getKeyedChildren(ctx, childKey, keyedChildren)
 	} else if children, ok := childAggsBuckets.([]interface{}); !ok {
 		logger.Error(fmt.Sprintf("Failed to get buckets: value under '%s.%s' is not a slice.", childKey, AggBucketKey), nil)
 		logger.Debug("Document is.", doc)
@@ -188,6 +191,32 @@ func getChildren(ctx context.Context, doc bucket) (string, []SimplifiedCostsDocu
 	}
 }
 
+// getKeyedChildren builds the children of a document from keyed buckets, as
+// returned by a filters aggregation. Since those buckets do not have a 'key'
+// field, it is set from the name of the bucket. Children are sorted by key.
+func getKeyedChildren(ctx context.Context, childKey string, keyedChildren map[string]interface{}) (string, []SimplifiedCostsDocument, error) {
+	var logger = jsonlog.LoggerFromContextOrDefault(ctx)
+	keys := make([]string, 0, len(keyedChildren))
+	for k := range keyedChildren {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	cs := make([]SimplifiedCostsDocument, len(keys))
+	for i, k := range keys {
+		tchild, ok := keyedChildren[k].(bucket)
+		if !ok {
+			logger.Error(fmt.Sprintf("Child '%s' under '%s' is not a bucket.", k, childKey), nil)
+			return "", nil, ErrFailedJsonParsing
+		}
+		tchild[BucketKeyKey] = k
+		var err error
+		if cs[i], err = simplifyCostsDocumentRec(ctx, tchild, false); err != nil {
+			return "", nil, err
+		}
+	}
+	return childKey, cs, nil
+}
+
 func getChildKey(ctx context.Context, doc map[string]interface{}) (string, error) {
 	var childKey string
 	for k := range doc {
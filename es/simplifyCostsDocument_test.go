@@ -15,6 +15,7 @@
 package es
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 )
@@ -129,3 +130,24 @@ func TestToJsonableSimpleTwoLevelsResults(t *testing.T) {
 		t.Fatalf("Expected %s but got %s", expectedResult, string(marshalled))
 	}
 }
+
+func TestSimplifyCostsDocumentWithKeyedBuckets(t *testing.T) {
+	rawDocument := json.RawMessage(`{
+		"buckets": {
+			"untagged": {"doc_count": 2, "value": {"value": 3}},
+			"infra": {"doc_count": 1, "value": {"value": 42}}
+		}
+	}`)
+	scd, err := simplifyCostsDocumentWithSingleAggregation(context.Background(), "by-tag", &rawDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedResult := `{"tag":{"infra":42,"untagged":3}}`
+	marshalled, _ := json.Marshal(scd.ToJsonable())
+	if string(marshalled) != expectedResult {
+		t.Fatalf("Expected %s but got %s", expectedResult, string(marshalled))
+	}
+	if scd.Children[0].Key != "infra" || scd.Children[1].Key != "untagged" {
+		t.Fatalf("Expected children to be sorted by key but got %v", scd.Children)
+	}
+}
@@ -0,0 +1,198 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package jobs implements a durable job queue stored in the database. Jobs
+// are run by pools of workers which lease them, renew their lease while they
+// run and retry them with an exponential backoff when they fail.
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/trackit/trackit/config"
+	"github.com/trackit/trackit/models"
+)
+
+// Statuses of the jobs of the queue.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// maxRetryDelay caps the delay before the retry of a failed job.
+const maxRetryDelay = 6 * time.Hour
+
+// maxErrorLength is the size of the error column of the job table.
+const maxErrorLength = 1024
+
+type (
+	// Handler runs a job with its arguments. The context is cancelled when
+	// the cancellation of the job is requested or when its lease is lost.
+	Handler func(ctx context.Context, args []string) error
+
+	// Job is a job of the queue as returned by the API.
+	Job struct {
+		Id              int       `json:"id"`
+		Type            string    `json:"type"`
+		Args            []string  `json:"args"`
+		AwsAccountId    int       `json:"awsAccountId,omitempty"`
+		Status          string    `json:"status"`
+		Attempts        int       `json:"attempts"`
+		MaxAttempts     int       `json:"maxAttempts"`
+		RunAfter        time.Time `json:"runAfter"`
+		CancelRequested bool      `json:"cancelRequested"`
+		Error           string    `json:"error"`
+		Created         time.Time `json:"created"`
+		Updated         time.Time `json:"updated"`
+	}
+
+	// Options are the optional attributes of an enqueued job. UserId and
+	// AwsAccountId are zero when the job does not belong to a user or an
+	// AWS account. A zero MaxAttempts defaults to config.JobMaxAttempts.
+	Options struct {
+		UserId       int
+		AwsAccountId int
+		MaxAttempts  int
+		RunAfter     time.Time
+	}
+)
+
+// handlers are the registered job handlers, by job type.
+var handlers = make(map[string]Handler)
+
+// RegisterHandler registers the handler running the jobs of type typ.
+func RegisterHandler(typ string, h Handler) {
+	handlers[typ] = h
+}
+
+// Types returns the registered job types.
+func Types() []string {
+	res := make([]string, 0, len(handlers))
+	for typ := range handlers {
+		res = append(res, typ)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Enqueue adds a job of type typ to the queue.
+func Enqueue(db models.XODB, typ string, args []string, opts Options) (Job, error) {
+	if _, ok := handlers[typ]; !ok {
+		return Job{}, fmt.Errorf("unknown job type : %s", typ)
+	}
+	if args == nil {
+		args = []string{}
+	}
+	serializedArgs, err := json.Marshal(args)
+	if err != nil {
+		return Job{}, err
+	}
+	now := time.Now().UTC()
+	dbJob := models.Job{
+		Type:         typ,
+		Args:         serializedArgs,
+		UserID:       nullInt(opts.UserId),
+		AwsAccountID: nullInt(opts.AwsAccountId),
+		Status:       StatusPending,
+		MaxAttempts:  opts.MaxAttempts,
+		RunAfter:     opts.RunAfter.UTC(),
+		LeaseExpires: now,
+		Created:      now,
+		Updated:      now,
+	}
+	if dbJob.MaxAttempts <= 0 {
+		dbJob.MaxAttempts = config.JobMaxAttempts
+	}
+	if opts.RunAfter.IsZero() {
+		dbJob.RunAfter = now
+	}
+	if err := dbJob.Insert(db); err != nil {
+		return Job{}, err
+	}
+	return jobFromDbJob(&dbJob), nil
+}
+
+// EnqueueUnique adds a job of type typ to the queue unless a pending or
+// running job of the same type has the same arguments. It returns false if
+// no job was added.
+func EnqueueUnique(db models.XODB, typ string, args []string, opts Options) (Job, bool, error) {
+	if args == nil {
+		args = []string{}
+	}
+	serializedArgs, err := json.Marshal(args)
+	if err != nil {
+		return Job{}, false, err
+	}
+	if exists, err := models.JobActiveExists(db, typ, serializedArgs); err != nil || exists {
+		return Job{}, false, err
+	}
+	job, err := Enqueue(db, typ, args, opts)
+	return job, err == nil, err
+}
+
+// retryDelay returns the delay before the next attempt of a job which failed
+// attempts times.
+func retryDelay(base time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
+
+// jobFromDbJob builds a Job from its database representation.
+func jobFromDbJob(dbJob *models.Job) Job {
+	job := Job{
+		Id:              dbJob.ID,
+		Type:            dbJob.Type,
+		Args:            []string{},
+		Status:          dbJob.Status,
+		Attempts:        dbJob.Attempts,
+		MaxAttempts:     dbJob.MaxAttempts,
+		RunAfter:        dbJob.RunAfter,
+		CancelRequested: dbJob.CancelRequested,
+		Error:           dbJob.Error,
+		Created:         dbJob.Created,
+		Updated:         dbJob.Updated,
+	}
+	json.Unmarshal(dbJob.Args, &job.Args)
+	if dbJob.AwsAccountID.Valid {
+		job.AwsAccountId = int(dbJob.AwsAccountID.Int64)
+	}
+	return job
+}
+
+// nullInt returns a NULL integer for zero, a valid one otherwise.
+func nullInt(i int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(i), Valid: i != 0}
+}
+
+// truncateError shortens an error message to fit in the error column.
+func truncateError(message string) string {
+	if len(message) > maxErrorLength {
+		return message[:maxErrorLength]
+	}
+	return message
+}
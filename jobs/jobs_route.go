@@ -0,0 +1,201 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package jobs
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+// jobsLimit is the number of jobs returned by getJobs.
+const jobsLimit = 100
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getJobs).With(
+			db.RequestTransaction{Db: db.Db},
+			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			routes.Documentation{
+				Summary:     "get the jobs",
+				Description: "Responds with the latest background jobs of the user",
+			},
+		),
+	}.H().Register("/jobs")
+
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getSystemJobs).With(
+			db.RequestTransaction{Db: db.Db},
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			users.RequireAdminUser{},
+			routes.Documentation{
+				Summary:     "get the system jobs",
+				Description: "Responds with the latest background jobs which belong to no user, such as the ingestion of billing sources. Administrators can retry and cancel them through /jobs/retry and /jobs/cancel",
+			},
+		),
+	}.H().Register("/admin/jobs")
+
+	routes.MethodMuxer{
+		http.MethodPost: routes.H(retryJob).With(
+			db.RequestTransaction{Db: db.Db},
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.QueryArgs{routes.JobIdQueryArg},
+			routes.Documentation{
+				Summary:     "retry a job",
+				Description: "Puts a failed or cancelled job back in the queue",
+			},
+		),
+	}.H().Register("/jobs/retry")
+
+	routes.MethodMuxer{
+		http.MethodPost: routes.H(cancelJob).With(
+			db.RequestTransaction{Db: db.Db},
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.QueryArgs{routes.JobIdQueryArg},
+			routes.Documentation{
+				Summary:     "cancel a job",
+				Description: "Cancels a pending job or requests the cancellation of a running job",
+			},
+		),
+	}.H().Register("/jobs/cancel")
+}
+
+// getJobs is a route handler which returns the latest jobs of the user.
+func getJobs(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbJobs, err := models.JobsLatestByUserID(tx, user.Id, jobsLimit)
+	if err != nil {
+		l.Error("Failed to get jobs", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve jobs.")
+	}
+	jobs := make([]Job, len(dbJobs))
+	for i, dbJob := range dbJobs {
+		jobs[i] = jobFromDbJob(dbJob)
+	}
+	return http.StatusOK, jobs
+}
+
+// getSystemJobs is a route handler which returns the latest jobs which belong
+// to no user.
+func getSystemJobs(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	dbJobs, err := models.JobsLatestWithoutUser(tx, jobsLimit)
+	if err != nil {
+		l.Error("Failed to get system jobs", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to retrieve jobs.")
+	}
+	jobs := make([]Job, len(dbJobs))
+	for i, dbJob := range dbJobs {
+		jobs[i] = jobFromDbJob(dbJob)
+	}
+	return http.StatusOK, jobs
+}
+
+// retryJob is a route handler which puts a failed or cancelled job back in
+// the queue.
+func retryJob(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbJob, code, err := getUserJob(r, tx, user, a[routes.JobIdQueryArg].(int))
+	if err != nil {
+		return code, err
+	}
+	if retried, err := models.RetryJob(tx, dbJob.ID, time.Now().UTC()); err != nil {
+		l.Error("Failed to retry job", map[string]interface{}{
+			"jobId": dbJob.ID,
+			"error": err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retry job.")
+	} else if !retried {
+		return http.StatusBadRequest, errors.New("Only failed or cancelled jobs can be retried.")
+	}
+	return getUpdatedJob(r, tx, dbJob.ID)
+}
+
+// cancelJob is a route handler which cancels a pending job or requests the
+// cancellation of a running job.
+func cancelJob(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbJob, code, err := getUserJob(r, tx, user, a[routes.JobIdQueryArg].(int))
+	if err != nil {
+		return code, err
+	}
+	if cancelled, err := models.CancelJob(tx, dbJob.ID); err != nil {
+		l.Error("Failed to cancel job", map[string]interface{}{
+			"jobId": dbJob.ID,
+			"error": err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to cancel job.")
+	} else if !cancelled {
+		return http.StatusBadRequest, errors.New("Only pending or running jobs can be cancelled.")
+	}
+	return getUpdatedJob(r, tx, dbJob.ID)
+}
+
+// getUserJob retrieves a job owned by user. Administrators can also retrieve
+// the jobs which belong to no user.
+func getUserJob(r *http.Request, tx *sql.Tx, user users.User, jobId int) (*models.Job, int, error) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	dbJob, err := models.JobByID(tx, jobId)
+	if err == sql.ErrNoRows || (err == nil && !canManageJob(user, dbJob)) {
+		return nil, http.StatusNotFound, errors.New("Job not found.")
+	} else if err != nil {
+		l.Error("Failed to get job", map[string]interface{}{
+			"jobId": jobId,
+			"error": err.Error(),
+		})
+		return nil, http.StatusInternalServerError, errors.New("Failed to retrieve job.")
+	}
+	return dbJob, http.StatusOK, nil
+}
+
+// getUpdatedJob responds with a job after it was updated.
+func getUpdatedJob(r *http.Request, tx *sql.Tx, jobId int) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	dbJob, err := models.JobByID(tx, jobId)
+	if err != nil {
+		l.Error("Failed to get job", map[string]interface{}{
+			"jobId": jobId,
+			"error": err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve job.")
+	}
+	return http.StatusOK, jobFromDbJob(dbJob)
+}
+
+// canManageJob tells whether user can retry and cancel a job.
+func canManageJob(user users.User, dbJob *models.Job) bool {
+	if !dbJob.UserID.Valid {
+		return users.IsAdmin(user)
+	}
+	return int(dbJob.UserID.Int64) == user.Id
+}
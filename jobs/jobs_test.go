@@ -0,0 +1,104 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trackit/trackit/config"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/users"
+)
+
+func TestRetryDelay(t *testing.T) {
+	cases := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{20, maxRetryDelay},
+	}
+	for _, c := range cases {
+		if delay := retryDelay(time.Minute, c.attempts); delay != c.expected {
+			t.Errorf("Expected a delay of %v after %d attempts, got %v", c.expected, c.attempts, delay)
+		}
+	}
+}
+
+func TestJobFromDbJob(t *testing.T) {
+	dbJob := models.Job{
+		ID:           42,
+		Type:         "process-account",
+		Args:         []byte(`["12","3","2020"]`),
+		AwsAccountID: sql.NullInt64{Int64: 12, Valid: true},
+		Status:       StatusPending,
+		MaxAttempts:  3,
+	}
+	job := jobFromDbJob(&dbJob)
+	if job.Id != 42 || job.AwsAccountId != 12 || strings.Join(job.Args, " ") != "12 3 2020" {
+		t.Errorf("Unexpected job %+v", job)
+	}
+	dbJob.Args = []byte("not json")
+	dbJob.AwsAccountID = sql.NullInt64{}
+	if job := jobFromDbJob(&dbJob); job.Args == nil || len(job.Args) != 0 || job.AwsAccountId != 0 {
+		t.Errorf("Expected no arguments and no AWS account, got %+v", job)
+	}
+}
+
+func TestRunHandlerRecoversPanics(t *testing.T) {
+	err := runHandler(context.Background(), func(context.Context, []string) error {
+		panic("boom")
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected the panic as an error, got %v", err)
+	}
+	expected := errors.New("failed")
+	if err := runHandler(context.Background(), func(context.Context, []string) error {
+		return expected
+	}, nil); err != expected {
+		t.Errorf("Expected %v, got %v", expected, err)
+	}
+}
+
+func TestTruncateError(t *testing.T) {
+	if message := truncateError(strings.Repeat("a", 2000)); len(message) != maxErrorLength {
+		t.Errorf("Expected a message of %d characters, got %d", maxErrorLength, len(message))
+	}
+	if message := truncateError("short"); message != "short" {
+		t.Errorf("Expected the message to be kept, got %q", message)
+	}
+}
+
+func TestCanManageJob(t *testing.T) {
+	defer func(adminEmails string) { config.AdminEmails = adminEmails }(config.AdminEmails)
+	config.AdminEmails = "admin@example.com"
+	admin := users.User{Id: 1, Email: "admin@example.com"}
+	user := users.User{Id: 2, Email: "user@example.com"}
+	systemJob := &models.Job{}
+	userJob := &models.Job{UserID: sql.NullInt64{Int64: 2, Valid: true}}
+	if !canManageJob(admin, systemJob) || canManageJob(user, systemJob) {
+		t.Error("Expected only administrators to manage the jobs of no user")
+	}
+	if !canManageJob(user, userJob) || canManageJob(admin, userJob) {
+		t.Error("Expected only the owner of a job to manage it")
+	}
+}
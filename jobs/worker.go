@@ -0,0 +1,241 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/config"
+	"github.com/trackit/trackit/models"
+)
+
+// claimBatchSize is the number of claimable jobs a worker fetches when
+// looking for a job to run.
+const claimBatchSize = 10
+
+// errLeaseExpired is the error of a job whose lease expired during its last
+// attempt.
+var errLeaseExpired = errors.New("lease expired during the last attempt")
+
+// Pool is a pool of workers running the jobs of the queue whose type has a
+// registered handler. Owner identifies the pool in the leases it takes and
+// must be unique among the pools sharing the queue.
+type Pool struct {
+	Db      *sql.DB
+	Owner   string
+	Workers int
+}
+
+// heartbeatResult tells why a job stopped being heartbeat.
+type heartbeatResult struct {
+	cancelled bool
+	lost      bool
+}
+
+// Start starts the workers of the pool. They stop when ctx is done.
+func (p Pool) Start(ctx context.Context) {
+	for i := 0; i < p.Workers; i++ {
+		go p.work(ctx, fmt.Sprintf("%s/%d", p.Owner, i))
+	}
+}
+
+// work runs jobs as owner until ctx is done, polling the queue every
+// config.JobPollInterval when there is no job to run.
+func (p Pool) work(ctx context.Context, owner string) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	for ctx.Err() == nil {
+		ran, err := p.claimAndRun(ctx, owner)
+		if err != nil {
+			logger.Error("Failed to claim a job.", map[string]interface{}{
+				"owner": owner,
+				"error": err.Error(),
+			})
+		}
+		if !ran {
+			select {
+			case <-ctx.Done():
+			case <-time.After(config.JobPollInterval):
+			}
+		}
+	}
+}
+
+// claimAndRun claims a job of the queue and runs it. It returns false if
+// there was no job it could claim.
+func (p Pool) claimAndRun(ctx context.Context, owner string) (bool, error) {
+	now := time.Now().UTC()
+	if err := models.CancelExpiredJobs(p.Db, now); err != nil {
+		return false, err
+	}
+	candidates, err := models.JobsClaimable(p.Db, now, claimBatchSize)
+	if err != nil {
+		return false, err
+	}
+	for _, dbJob := range candidates {
+		if _, ok := handlers[dbJob.Type]; !ok {
+			continue
+		}
+		claimed, err := p.claim(ctx, dbJob, owner, now)
+		if err != nil {
+			return false, err
+		} else if claimed {
+			dbJob.Attempts++
+			p.run(ctx, owner, dbJob)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// claim leases a job to owner. The AWS account of the job is locked while the
+// jobs running for it are counted, so that concurrent workers cannot exceed
+// config.JobAccountConcurrency.
+func (p Pool) claim(ctx context.Context, dbJob *models.Job, owner string, now time.Time) (claimed bool, err error) {
+	leaseExpires := now.Add(config.JobLeaseDuration)
+	if !dbJob.AwsAccountID.Valid || config.JobAccountConcurrency <= 0 {
+		return models.ClaimJob(p.Db, dbJob.ID, dbJob.AwsAccountID, owner, now, leaseExpires, config.JobAccountConcurrency)
+	}
+	tx, err := p.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+	if err = models.LockAwsAccountForJobs(tx, dbJob.AwsAccountID.Int64); err == sql.ErrNoRows {
+		// The account was deleted, and its jobs with it.
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return models.ClaimJob(tx, dbJob.ID, dbJob.AwsAccountID, owner, now, leaseExpires, config.JobAccountConcurrency)
+}
+
+// run runs a job leased by owner and records its outcome.
+func (p Pool) run(ctx context.Context, owner string, dbJob *models.Job) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	job := jobFromDbJob(dbJob)
+	logger.Info("Running job.", map[string]interface{}{
+		"owner": owner,
+		"job":   job,
+	})
+	var err error
+	var hb heartbeatResult
+	if job.Attempts > job.MaxAttempts {
+		err = errLeaseExpired
+	} else {
+		jobCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		result := make(chan heartbeatResult)
+		go p.heartbeat(jobCtx, cancel, owner, job.Id, done, result)
+		err = runHandler(jobCtx, handlers[job.Type], job.Args)
+		close(done)
+		hb = <-result
+		cancel()
+	}
+	if hb.lost {
+		logger.Warning("Lost the lease of a job.", map[string]interface{}{
+			"owner": owner,
+			"jobId": job.Id,
+		})
+		return
+	}
+	now := time.Now().UTC()
+	status, runAfter, message := StatusSucceeded, now, ""
+	if err != nil {
+		message = truncateError(err.Error())
+		if hb.cancelled {
+			status = StatusCancelled
+		} else if ctx.Err() != nil {
+			status = StatusPending
+		} else if err != errLeaseExpired && job.Attempts < job.MaxAttempts {
+			status, runAfter = StatusPending, now.Add(retryDelay(config.JobRetryDelay, job.Attempts))
+		} else {
+			status = StatusFailed
+		}
+		logger.Error("Job failed.", map[string]interface{}{
+			"owner":  owner,
+			"jobId":  job.Id,
+			"status": status,
+			"error":  message,
+		})
+	} else if hb.cancelled {
+		status = StatusCancelled
+	}
+	// The job is released even if ctx is done, so that it does not wait for
+	// its lease to expire.
+	if err := models.FinishJob(p.Db, job.Id, owner, status, runAfter, message); err != nil {
+		logger.Error("Failed to record the outcome of a job.", map[string]interface{}{
+			"owner": owner,
+			"jobId": job.Id,
+			"error": err.Error(),
+		})
+	}
+}
+
+// heartbeat renews the lease of a job every third of config.JobLeaseDuration
+// until done is closed, then sends on result why it stopped. It cancels the
+// job when its cancellation is requested or when its lease is lost.
+func (p Pool) heartbeat(ctx context.Context, cancel context.CancelFunc, owner string, jobId int, done <-chan struct{}, result chan<- heartbeatResult) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	ticker := time.NewTicker(config.JobLeaseDuration / 3)
+	defer ticker.Stop()
+	var res heartbeatResult
+	for {
+		select {
+		case <-done:
+			result <- res
+			return
+		case <-ticker.C:
+			if res.lost {
+				continue
+			}
+			renewed, cancelRequested, err := models.RenewJobLease(p.Db, jobId, owner, time.Now().UTC().Add(config.JobLeaseDuration))
+			if err != nil {
+				logger.Error("Failed to renew the lease of a job.", map[string]interface{}{
+					"owner": owner,
+					"jobId": jobId,
+					"error": err.Error(),
+				})
+			} else if !renewed {
+				res.lost = true
+				cancel()
+			} else if cancelRequested && !res.cancelled {
+				res.cancelled = true
+				cancel()
+			}
+		}
+	}
+}
+
+// runHandler runs a job handler, turning its panics into errors.
+func runHandler(ctx context.Context, h Handler, args []string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return h(ctx, args)
+}
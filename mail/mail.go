@@ -15,11 +15,15 @@
 package mail
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"fmt"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net"
 	"net/smtp"
+	"net/textproto"
 
 	"github.com/trackit/jsonlog"
 
@@ -54,6 +58,22 @@ func SendMail(recipient string, subject, body string, ctx context.Context) error
 	return mail.Send(ctx)
 }
 
+// SendHtmlMail is the easiest way to send a mail with both a plain text and
+// an HTML body. It gets the SMTP information from the config file.
+func SendHtmlMail(recipient string, subject, body, htmlBody string, ctx context.Context) error {
+	mail := Mail{
+		config.SmtpAddress,
+		config.SmtpPort,
+		config.SmtpUser,
+		config.SmtpPassword,
+		config.SmtpSender,
+		recipient,
+		subject,
+		body,
+	}
+	return mail.SendHtml(htmlBody, ctx)
+}
+
 func (m Mail) buildMessage() []byte {
 	message := ""
 	message += fmt.Sprintf("From: %s\r\n", m.Sender)
@@ -63,6 +83,46 @@ func (m Mail) buildMessage() []byte {
 	return []byte(message)
 }
 
+// buildHtmlMessage builds a multipart/alternative message holding the Body
+// of the Mail as plain text and htmlBody as HTML.
+func (m Mail) buildHtmlMessage(htmlBody string) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", m.Body},
+		{"text/html; charset=UTF-8", htmlBody},
+	}
+	for _, part := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qpw := quotedprintable.NewWriter(pw)
+		if _, err := qpw.Write([]byte(part.content)); err != nil {
+			return nil, err
+		} else if err := qpw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	message := ""
+	message += fmt.Sprintf("From: %s\r\n", m.Sender)
+	message += fmt.Sprintf("To: %s\r\n", m.Recipient)
+	message += fmt.Sprintf("Subject: %s\r\n", m.Subject)
+	message += "MIME-Version: 1.0\r\n"
+	message += fmt.Sprintf("Content-Type: multipart/alternative; boundary=%s\r\n", mw.Boundary())
+	message += "\r\n" + body.String()
+	return []byte(message), nil
+}
+
 func (m Mail) setTlsConfig(client *smtp.Client) error {
 	tlsconfig := &tls.Config{
 		InsecureSkipVerify: true,
@@ -118,12 +178,12 @@ func (m Mail) setAddresses(client *smtp.Client) error {
 	return nil
 }
 
-func (m Mail) setMessage(client *smtp.Client) error {
+func (m Mail) setMessage(client *smtp.Client, message []byte) error {
 	w, err := client.Data()
 	if err != nil {
 		return err
 	}
-	if _, err := w.Write(m.buildMessage()); err != nil {
+	if _, err := w.Write(message); err != nil {
 		return err
 	}
 	if err := w.Close(); err != nil {
@@ -135,6 +195,22 @@ func (m Mail) setMessage(client *smtp.Client) error {
 // Send provides a way to send a mail with SMTP information
 // from the Mail structure.
 func (m Mail) Send(ctx context.Context) error {
+	return m.sendMessage(ctx, m.buildMessage())
+}
+
+// SendHtml sends a mail whose body has both a plain text version, the Body
+// of the Mail, and an HTML version. Mail clients display the one they
+// support best.
+func (m Mail) SendHtml(htmlBody string, ctx context.Context) error {
+	message, err := m.buildHtmlMessage(htmlBody)
+	if err != nil {
+		return err
+	}
+	return m.sendMessage(ctx, message)
+}
+
+// sendMessage sends a message built from the Mail structure.
+func (m Mail) sendMessage(ctx context.Context, message []byte) error {
 	dataLogged := map[string]interface{}{"subject": m.Subject, "recipient": m.Recipient}
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	logger.Info("Sending mail.", dataLogged)
@@ -146,7 +222,7 @@ func (m Mail) Send(ctx context.Context) error {
 	if err := m.setAddresses(client); err != nil {
 		return err
 	}
-	if err := m.setMessage(client); err != nil {
+	if err := m.setMessage(client, message); err != nil {
 		return err
 	}
 	if err := client.Quit(); err != nil {
@@ -16,6 +16,10 @@ package mail
 
 import (
 	"bytes"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	netmail "net/mail"
 	"testing"
 )
 
@@ -42,3 +46,55 @@ func TestSendMail(t *testing.T) {
 		t.Fatalf("Unexcepted message: (%s) instead of (%s)", msg, template)
 	}
 }
+
+func TestBuildHtmlMessage(t *testing.T) {
+	m := Mail{
+		"",
+		"",
+		"",
+		"",
+		"team@msolution.io",
+		"thibaut@trackit.io",
+		"test subject!",
+		"test body!",
+	}
+	msg, err := m.buildHtmlMessage("<p>test body!</p>")
+	if err != nil {
+		t.Fatalf("Failed to build message: %s", err)
+	}
+	parsed, err := netmail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %s", err)
+	}
+	if parsed.Header.Get("Subject") != m.Subject {
+		t.Errorf("Unexpected subject: %s", parsed.Header.Get("Subject"))
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Unexpected content type: %s", parsed.Header.Get("Content-Type"))
+	}
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	expected := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", "test body!"},
+		{"text/html; charset=UTF-8", "<p>test body!</p>"},
+	}
+	for _, e := range expected {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("Failed to read part: %s", err)
+		}
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			t.Fatalf("Failed to read part: %s", err)
+		}
+		if part.Header.Get("Content-Type") != e.contentType {
+			t.Errorf("Unexpected part content type: %s", part.Header.Get("Content-Type"))
+		}
+		if string(content) != e.content {
+			t.Errorf("Unexpected part content: (%s) instead of (%s)", content, e.content)
+		}
+	}
+}
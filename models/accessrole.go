@@ -0,0 +1,55 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+// AccessRolesForUserID returns the built-in AccessRole, which have no user,
+// and the AccessRole created by a user.
+func AccessRolesForUserID(db XODB, userID int) ([]*AccessRole, error) {
+	var err error
+
+	// sql query
+	const sqlstr = `SELECT ` +
+		`id, user_id, name, permissions ` +
+		`FROM trackit.access_role ` +
+		`WHERE user_id IS NULL OR user_id = ? ` +
+		`ORDER BY id`
+
+	// run query
+	XOLog(sqlstr, userID)
+	q, err := db.Query(sqlstr, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	// load results
+	res := []*AccessRole{}
+	for q.Next() {
+		ar := AccessRole{
+			_exists: true,
+		}
+
+		// scan
+		err = q.Scan(&ar.ID, &ar.UserID, &ar.Name, &ar.Permissions)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, &ar)
+	}
+
+	return res, nil
+}
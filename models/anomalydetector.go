@@ -0,0 +1,44 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+// AnomalyDetectorsByAwsAccountID returns the anomaly detectors selected for
+// an AWS account and its products.
+func AnomalyDetectorsByAwsAccountID(db XODB, awsAccountID int) ([]*AnomalyDetector, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`id, aws_account_id, product, detector ` +
+		`FROM trackit.anomaly_detector ` +
+		`WHERE aws_account_id = ?`
+	XOLog(sqlstr, awsAccountID)
+	q, err := db.Query(sqlstr, awsAccountID)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*AnomalyDetector{}
+	for q.Next() {
+		ad := AnomalyDetector{
+			_exists: true,
+		}
+		err = q.Scan(&ad.ID, &ad.AwsAccountID, &ad.Product, &ad.Detector)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &ad)
+	}
+	return res, nil
+}
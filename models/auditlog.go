@@ -0,0 +1,110 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// AuditLogFilter restricts the AuditLog returned by AuditLogsByFilter. Zero
+// fields do not restrict the results.
+type AuditLogFilter struct {
+	// UserID restricts the results to the entries acted on or by a user.
+	UserID     int
+	ActorID    int
+	Action     string
+	TargetType string
+	Begin      time.Time
+	End        time.Time
+	// BeforeID restricts the results to the entries older than an entry, to
+	// page through the log.
+	BeforeID int
+	// Limit is the maximum number of results.
+	Limit int
+}
+
+// AuditLogsByFilter retrieves the AuditLog matching a filter, most recent
+// first. The entries are never updated, so their id orders them.
+func AuditLogsByFilter(db XODB, filter AuditLogFilter) ([]*AuditLog, error) {
+	var err error
+
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	if filter.UserID != 0 {
+		conditions = append(conditions, `(owner_id = ? OR actor_id = ?)`)
+		args = append(args, filter.UserID, filter.UserID)
+	}
+	if filter.ActorID != 0 {
+		conditions = append(conditions, `actor_id = ?`)
+		args = append(args, filter.ActorID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, `action = ?`)
+		args = append(args, filter.Action)
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, `target_type = ?`)
+		args = append(args, filter.TargetType)
+	}
+	if !filter.Begin.IsZero() {
+		conditions = append(conditions, `created >= ?`)
+		args = append(args, filter.Begin)
+	}
+	if !filter.End.IsZero() {
+		conditions = append(conditions, `created < ?`)
+		args = append(args, filter.End)
+	}
+	if filter.BeforeID != 0 {
+		conditions = append(conditions, `id < ?`)
+		args = append(args, filter.BeforeID)
+	}
+	args = append(args, filter.Limit)
+
+	// sql query
+	sqlstr := `SELECT ` +
+		`id, owner_id, actor_id, actor_email, action, target_type, target_id, before_value, after_value, request_id, ip_address, created ` +
+		`FROM trackit.audit_log ` +
+		`WHERE ` + strings.Join(conditions, ` AND `) + ` ` +
+		`ORDER BY id DESC ` +
+		`LIMIT ?`
+
+	// run query
+	XOLog(sqlstr, args...)
+	q, err := db.Query(sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	// load results
+	res := []*AuditLog{}
+	for q.Next() {
+		al := AuditLog{
+			_exists: true,
+		}
+
+		// scan
+		err = q.Scan(&al.ID, &al.OwnerID, &al.ActorID, &al.ActorEmail, &al.Action, &al.TargetType, &al.TargetID, &al.BeforeValue, &al.AfterValue, &al.RequestID, &al.IPAddress, &al.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, &al)
+	}
+
+	return res, nil
+}
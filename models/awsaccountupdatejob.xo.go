@@ -27,6 +27,7 @@ type AwsAccountUpdateJob struct {
 	Rirdserror              string         `json:"riRdsError"`                // riRdsError
 	Odtoriec2error          string         `json:"odToRiEc2Error"`            // odToRiEc2Error
 	Ebserror                string         `json:"ebsError"`                  // ebsError
+	Inventoryerror          string         `json:"inventoryError"`            // inventoryError
 
 	// xo fields
 	_exists, _deleted bool
@@ -53,14 +54,14 @@ func (aauj *AwsAccountUpdateJob) Insert(db XODB) error {
 
 	// sql insert query, primary key provided by autoincrement
 	const sqlstr = `INSERT INTO trackit.aws_account_update_job (` +
-		`aws_account_id, completed, worker_id, jobError, rdsError, ec2Error, historyError, esError, monthly_reports_generated, elastiCacheError, lambdaError, riEc2Error, riRdsError, odToRiEc2Error, ebsError` +
+		`aws_account_id, completed, worker_id, jobError, rdsError, ec2Error, historyError, esError, monthly_reports_generated, elastiCacheError, lambdaError, riEc2Error, riRdsError, odToRiEc2Error, ebsError, inventoryError` +
 		`) VALUES (` +
-		`?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?` +
+		`?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?` +
 		`)`
 
 	// run query
-	XOLog(sqlstr, aauj.AwsAccountID, aauj.Completed, aauj.WorkerID, aauj.Joberror, aauj.Rdserror, aauj.Ec2error, aauj.Historyerror, aauj.Eserror, aauj.MonthlyReportsGenerated, aauj.Elasticacheerror, aauj.Lambdaerror, aauj.Riec2error, aauj.Rirdserror, aauj.Odtoriec2error, aauj.Ebserror)
-	res, err := db.Exec(sqlstr, aauj.AwsAccountID, aauj.Completed, aauj.WorkerID, aauj.Joberror, aauj.Rdserror, aauj.Ec2error, aauj.Historyerror, aauj.Eserror, aauj.MonthlyReportsGenerated, aauj.Elasticacheerror, aauj.Lambdaerror, aauj.Riec2error, aauj.Rirdserror, aauj.Odtoriec2error, aauj.Ebserror)
+	XOLog(sqlstr, aauj.AwsAccountID, aauj.Completed, aauj.WorkerID, aauj.Joberror, aauj.Rdserror, aauj.Ec2error, aauj.Historyerror, aauj.Eserror, aauj.MonthlyReportsGenerated, aauj.Elasticacheerror, aauj.Lambdaerror, aauj.Riec2error, aauj.Rirdserror, aauj.Odtoriec2error, aauj.Ebserror, aauj.Inventoryerror)
+	res, err := db.Exec(sqlstr, aauj.AwsAccountID, aauj.Completed, aauj.WorkerID, aauj.Joberror, aauj.Rdserror, aauj.Ec2error, aauj.Historyerror, aauj.Eserror, aauj.MonthlyReportsGenerated, aauj.Elasticacheerror, aauj.Lambdaerror, aauj.Riec2error, aauj.Rirdserror, aauj.Odtoriec2error, aauj.Ebserror, aauj.Inventoryerror)
 	if err != nil {
 		return err
 	}
@@ -94,12 +95,12 @@ func (aauj *AwsAccountUpdateJob) Update(db XODB) error {
 
 	// sql query
 	const sqlstr = `UPDATE trackit.aws_account_update_job SET ` +
-		`aws_account_id = ?, completed = ?, worker_id = ?, jobError = ?, rdsError = ?, ec2Error = ?, historyError = ?, esError = ?, monthly_reports_generated = ?, elastiCacheError = ?, lambdaError = ?, riEc2Error = ?, riRdsError = ?, odToRiEc2Error = ?, ebsError = ?` +
+		`aws_account_id = ?, completed = ?, worker_id = ?, jobError = ?, rdsError = ?, ec2Error = ?, historyError = ?, esError = ?, monthly_reports_generated = ?, elastiCacheError = ?, lambdaError = ?, riEc2Error = ?, riRdsError = ?, odToRiEc2Error = ?, ebsError = ?, inventoryError = ?` +
 		` WHERE id = ?`
 
 	// run query
-	XOLog(sqlstr, aauj.AwsAccountID, aauj.Completed, aauj.WorkerID, aauj.Joberror, aauj.Rdserror, aauj.Ec2error, aauj.Historyerror, aauj.Eserror, aauj.MonthlyReportsGenerated, aauj.Elasticacheerror, aauj.Lambdaerror, aauj.Riec2error, aauj.Rirdserror, aauj.Odtoriec2error, aauj.Ebserror, aauj.ID)
-	_, err = db.Exec(sqlstr, aauj.AwsAccountID, aauj.Completed, aauj.WorkerID, aauj.Joberror, aauj.Rdserror, aauj.Ec2error, aauj.Historyerror, aauj.Eserror, aauj.MonthlyReportsGenerated, aauj.Elasticacheerror, aauj.Lambdaerror, aauj.Riec2error, aauj.Rirdserror, aauj.Odtoriec2error, aauj.Ebserror, aauj.ID)
+	XOLog(sqlstr, aauj.AwsAccountID, aauj.Completed, aauj.WorkerID, aauj.Joberror, aauj.Rdserror, aauj.Ec2error, aauj.Historyerror, aauj.Eserror, aauj.MonthlyReportsGenerated, aauj.Elasticacheerror, aauj.Lambdaerror, aauj.Riec2error, aauj.Rirdserror, aauj.Odtoriec2error, aauj.Ebserror, aauj.Inventoryerror, aauj.ID)
+	_, err = db.Exec(sqlstr, aauj.AwsAccountID, aauj.Completed, aauj.WorkerID, aauj.Joberror, aauj.Rdserror, aauj.Ec2error, aauj.Historyerror, aauj.Eserror, aauj.MonthlyReportsGenerated, aauj.Elasticacheerror, aauj.Lambdaerror, aauj.Riec2error, aauj.Rirdserror, aauj.Odtoriec2error, aauj.Ebserror, aauj.Inventoryerror, aauj.ID)
 	return err
 }
 
@@ -157,7 +158,7 @@ func AwsAccountUpdateJobByID(db XODB, id int) (*AwsAccountUpdateJob, error) {
 
 	// sql query
 	const sqlstr = `SELECT ` +
-		`id, aws_account_id, completed, worker_id, jobError, rdsError, ec2Error, historyError, esError, monthly_reports_generated, elastiCacheError, lambdaError, riEc2Error, riRdsError, odToRiEc2Error, ebsError ` +
+		`id, aws_account_id, completed, worker_id, jobError, rdsError, ec2Error, historyError, esError, monthly_reports_generated, elastiCacheError, lambdaError, riEc2Error, riRdsError, odToRiEc2Error, ebsError, inventoryError ` +
 		`FROM trackit.aws_account_update_job ` +
 		`WHERE id = ?`
 
@@ -167,7 +168,7 @@ func AwsAccountUpdateJobByID(db XODB, id int) (*AwsAccountUpdateJob, error) {
 		_exists: true,
 	}
 
-	err = db.QueryRow(sqlstr, id).Scan(&aauj.ID, &aauj.AwsAccountID, &aauj.Completed, &aauj.WorkerID, &aauj.Joberror, &aauj.Rdserror, &aauj.Ec2error, &aauj.Historyerror, &aauj.Eserror, &aauj.MonthlyReportsGenerated, &aauj.Elasticacheerror, &aauj.Lambdaerror, &aauj.Riec2error, &aauj.Rirdserror, &aauj.Odtoriec2error, &aauj.Ebserror)
+	err = db.QueryRow(sqlstr, id).Scan(&aauj.ID, &aauj.AwsAccountID, &aauj.Completed, &aauj.WorkerID, &aauj.Joberror, &aauj.Rdserror, &aauj.Ec2error, &aauj.Historyerror, &aauj.Eserror, &aauj.MonthlyReportsGenerated, &aauj.Elasticacheerror, &aauj.Lambdaerror, &aauj.Riec2error, &aauj.Rirdserror, &aauj.Odtoriec2error, &aauj.Ebserror, &aauj.Inventoryerror)
 	if err != nil {
 		return nil, err
 	}
@@ -183,7 +184,7 @@ func AwsAccountUpdateJobsByAwsAccountID(db XODB, awsAccountID int) ([]*AwsAccoun
 
 	// sql query
 	const sqlstr = `SELECT ` +
-		`id, aws_account_id, completed, worker_id, jobError, rdsError, ec2Error, historyError, esError, monthly_reports_generated, elastiCacheError, lambdaError, riEc2Error, riRdsError, odToRiEc2Error, ebsError ` +
+		`id, aws_account_id, completed, worker_id, jobError, rdsError, ec2Error, historyError, esError, monthly_reports_generated, elastiCacheError, lambdaError, riEc2Error, riRdsError, odToRiEc2Error, ebsError, inventoryError ` +
 		`FROM trackit.aws_account_update_job ` +
 		`WHERE aws_account_id = ?`
 
@@ -203,7 +204,7 @@ func AwsAccountUpdateJobsByAwsAccountID(db XODB, awsAccountID int) ([]*AwsAccoun
 		}
 
 		// scan
-		err = q.Scan(&aauj.ID, &aauj.AwsAccountID, &aauj.Completed, &aauj.WorkerID, &aauj.Joberror, &aauj.Rdserror, &aauj.Ec2error, &aauj.Historyerror, &aauj.Eserror, &aauj.MonthlyReportsGenerated, &aauj.Elasticacheerror, &aauj.Lambdaerror, &aauj.Riec2error, &aauj.Rirdserror, &aauj.Odtoriec2error, &aauj.Ebserror)
+		err = q.Scan(&aauj.ID, &aauj.AwsAccountID, &aauj.Completed, &aauj.WorkerID, &aauj.Joberror, &aauj.Rdserror, &aauj.Ec2error, &aauj.Historyerror, &aauj.Eserror, &aauj.MonthlyReportsGenerated, &aauj.Elasticacheerror, &aauj.Lambdaerror, &aauj.Riec2error, &aauj.Rirdserror, &aauj.Odtoriec2error, &aauj.Ebserror, &aauj.Inventoryerror)
 		if err != nil {
 			return nil, err
 		}
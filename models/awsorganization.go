@@ -0,0 +1,34 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+// DeleteAwsOrganizationUnitsByAwsAccountID deletes all the
+// AwsOrganizationUnit discovered from a payer AwsAccount.
+func DeleteAwsOrganizationUnitsByAwsAccountID(db XODB, awsAccountID int) error {
+	const sqlstr = `DELETE FROM trackit.aws_organization_unit WHERE aws_account_id = ?`
+	XOLog(sqlstr, awsAccountID)
+	_, err := db.Exec(sqlstr, awsAccountID)
+	return err
+}
+
+// DeleteAwsOrganizationAccountsByAwsAccountID deletes all the
+// AwsOrganizationAccount discovered from a payer AwsAccount.
+func DeleteAwsOrganizationAccountsByAwsAccountID(db XODB, awsAccountID int) error {
+	const sqlstr = `DELETE FROM trackit.aws_organization_account WHERE aws_account_id = ?`
+	XOLog(sqlstr, awsAccountID)
+	_, err := db.Exec(sqlstr, awsAccountID)
+	return err
+}
@@ -0,0 +1,114 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+const billingSourceColumns = `id, user_id, provider, name, location, credentials, last_imported, next_update, error, created `
+
+// queryBillingSources runs a query selecting billingSourceColumns and returns
+// the resulting billing sources.
+func queryBillingSources(db XODB, sqlstr string, args ...interface{}) ([]*BillingSource, error) {
+	XOLog(sqlstr, args...)
+	q, err := db.Query(sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*BillingSource{}
+	for q.Next() {
+		bs := BillingSource{
+			_exists: true,
+		}
+		err = q.Scan(&bs.ID, &bs.UserID, &bs.Provider, &bs.Name, &bs.Location, &bs.Credentials, &bs.LastImported, &bs.NextUpdate, &bs.Error, &bs.Created)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &bs)
+	}
+	return res, nil
+}
+
+// BillingSourcesWithDueUpdate returns the set of billing sources with a due
+// update.
+func BillingSourcesWithDueUpdate(db XODB) ([]*BillingSource, error) {
+	const sqlstr = `SELECT ` + billingSourceColumns +
+		`FROM trackit.billing_source ` +
+		`WHERE next_update <= NOW()`
+	return queryBillingSources(db, sqlstr)
+}
+
+const billingSourceAccountColumns = `bsa.id, bsa.billing_source_id, bsa.account_id, bsa.name, bsa.last_anomalies_update `
+
+// queryBillingSourceAccounts runs a query selecting
+// billingSourceAccountColumns and returns the resulting accounts.
+func queryBillingSourceAccounts(db XODB, sqlstr string, args ...interface{}) ([]*BillingSourceAccount, error) {
+	XOLog(sqlstr, args...)
+	q, err := db.Query(sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*BillingSourceAccount{}
+	for q.Next() {
+		bsa := BillingSourceAccount{
+			_exists: true,
+		}
+		err = q.Scan(&bsa.ID, &bsa.BillingSourceID, &bsa.AccountID, &bsa.Name, &bsa.LastAnomaliesUpdate)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &bsa)
+	}
+	return res, nil
+}
+
+// BillingSourceAccountsByBillingSourceID returns the accounts found in the
+// exports of a billing source.
+func BillingSourceAccountsByBillingSourceID(db XODB, billingSourceID int) ([]*BillingSourceAccount, error) {
+	const sqlstr = `SELECT ` + billingSourceAccountColumns +
+		`FROM trackit.billing_source_account AS bsa ` +
+		`WHERE bsa.billing_source_id = ?`
+	return queryBillingSourceAccounts(db, sqlstr, billingSourceID)
+}
+
+// BillingSourceAccountsByUserID returns the accounts found in the exports of
+// the billing sources of a user.
+func BillingSourceAccountsByUserID(db XODB, userID int) ([]*BillingSourceAccount, error) {
+	const sqlstr = `SELECT ` + billingSourceAccountColumns +
+		`FROM trackit.billing_source_account AS bsa ` +
+		`INNER JOIN trackit.billing_source AS bs ON bs.id = bsa.billing_source_id ` +
+		`WHERE bs.user_id = ?`
+	return queryBillingSourceAccounts(db, sqlstr, userID)
+}
+
+// AddBillingSourceAccount records an account found in the exports of a
+// billing source, updating its name if it is already known.
+func AddBillingSourceAccount(db XODB, billingSourceID int, accountID, name string) error {
+	const sqlstr = `INSERT INTO trackit.billing_source_account (billing_source_id, account_id, name) ` +
+		`VALUES (?, ?, ?) ` +
+		`ON DUPLICATE KEY UPDATE name = IF(VALUES(name) = '', name, VALUES(name))`
+	XOLog(sqlstr, billingSourceID, accountID, name)
+	_, err := db.Exec(sqlstr, billingSourceID, accountID, name)
+	return err
+}
+
+// DeleteBillingSourceAccounts forgets the accounts found in the exports of a
+// billing source.
+func DeleteBillingSourceAccounts(db XODB, billingSourceID int) error {
+	const sqlstr = `DELETE FROM trackit.billing_source_account WHERE billing_source_id = ?`
+	XOLog(sqlstr, billingSourceID)
+	_, err := db.Exec(sqlstr, billingSourceID)
+	return err
+}
@@ -0,0 +1,45 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+// CostCategoriesByUserID returns the cost categories of a user, ordered by
+// name.
+func CostCategoriesByUserID(db XODB, userID int) ([]*CostCategory, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`id, user_id, name, default_value, rules ` +
+		`FROM trackit.cost_category ` +
+		`WHERE user_id = ? ` +
+		`ORDER BY name`
+	XOLog(sqlstr, userID)
+	q, err := db.Query(sqlstr, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*CostCategory{}
+	for q.Next() {
+		cc := CostCategory{
+			_exists: true,
+		}
+		err = q.Scan(&cc.ID, &cc.UserID, &cc.Name, &cc.DefaultValue, &cc.Rules)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &cc)
+	}
+	return res, nil
+}
@@ -17,14 +17,35 @@ package models
 
 import "time"
 
-// IsAnomalyAlreadyEmailed checks if an anomaly has already been sent.
-func IsAnomalyAlreadyEmailed(db XODB, awsAccountId int, product string, date time.Time) (bool, error) {
+// IsAnomalyAlreadyEmailed checks if an anomaly of an account, identified by
+// its AWS identity or billing source account ID, has already been sent to a
+// user.
+func IsAnomalyAlreadyEmailed(db XODB, userId int, account string, product string, date time.Time) (bool, error) {
 	const sqlstr = `SELECT ` +
-		`id, aws_account_id, product, recipient, date ` +
+		`id ` +
 		`FROM trackit.emailed_anomaly ` +
-		`WHERE aws_account_id = ? AND product = ? AND date = ?`
-	XOLog(sqlstr, awsAccountId, product, date)
-	q, err := db.Query(sqlstr, awsAccountId, product, date)
+		`WHERE user_id = ? AND account = ? AND product = ? AND date = ?`
+	XOLog(sqlstr, userId, account, product, date)
+	q, err := db.Query(sqlstr, userId, account, product, date)
+	if err != nil {
+		return false, err
+	}
+	defer q.Close()
+	if q.Next() {
+		return true, nil
+	}
+	return false, nil
+}
+
+// IsUserAnomalyAlreadyEmailed checks if an anomaly has already been sent to a
+// user.
+func IsUserAnomalyAlreadyEmailed(db XODB, userId int, anomalyId string) (bool, error) {
+	const sqlstr = `SELECT ` +
+		`id ` +
+		`FROM trackit.emailed_anomaly ` +
+		`WHERE user_id = ? AND anomaly_id = ?`
+	XOLog(sqlstr, userId, anomalyId)
+	q, err := db.Query(sqlstr, userId, anomalyId)
 	if err != nil {
 		return false, err
 	}
@@ -4,17 +4,21 @@ package models
 // Code generated by xo. DO NOT EDIT.
 
 import (
+	"database/sql"
 	"errors"
 	"time"
 )
 
 // EmailedAnomaly represents a row from 'trackit.emailed_anomaly'.
 type EmailedAnomaly struct {
-	ID           int       `json:"id"`             // id
-	AwsAccountID int       `json:"aws_account_id"` // aws_account_id
-	Product      string    `json:"product"`        // product
-	Recipient    string    `json:"recipient"`      // recipient
-	Date         time.Time `json:"date"`           // date
+	ID           int           `json:"id"`             // id
+	AwsAccountID sql.NullInt64 `json:"aws_account_id"` // aws_account_id
+	Product      string        `json:"product"`        // product
+	Recipient    string        `json:"recipient"`      // recipient
+	Date         time.Time     `json:"date"`           // date
+	UserID       sql.NullInt64 `json:"user_id"`        // user_id
+	AnomalyID    string        `json:"anomaly_id"`     // anomaly_id
+	Account      string        `json:"account"`        // account
 
 	// xo fields
 	_exists, _deleted bool
@@ -41,14 +45,14 @@ func (ea *EmailedAnomaly) Insert(db XODB) error {
 
 	// sql insert query, primary key provided by autoincrement
 	const sqlstr = `INSERT INTO trackit.emailed_anomaly (` +
-		`aws_account_id, product, recipient, date` +
+		`aws_account_id, product, recipient, date, user_id, anomaly_id, account` +
 		`) VALUES (` +
-		`?, ?, ?, ?` +
+		`?, ?, ?, ?, ?, ?, ?` +
 		`)`
 
 	// run query
-	XOLog(sqlstr, ea.AwsAccountID, ea.Product, ea.Recipient, ea.Date)
-	res, err := db.Exec(sqlstr, ea.AwsAccountID, ea.Product, ea.Recipient, ea.Date)
+	XOLog(sqlstr, ea.AwsAccountID, ea.Product, ea.Recipient, ea.Date, ea.UserID, ea.AnomalyID, ea.Account)
+	res, err := db.Exec(sqlstr, ea.AwsAccountID, ea.Product, ea.Recipient, ea.Date, ea.UserID, ea.AnomalyID, ea.Account)
 	if err != nil {
 		return err
 	}
@@ -82,12 +86,12 @@ func (ea *EmailedAnomaly) Update(db XODB) error {
 
 	// sql query
 	const sqlstr = `UPDATE trackit.emailed_anomaly SET ` +
-		`aws_account_id = ?, product = ?, recipient = ?, date = ?` +
+		`aws_account_id = ?, product = ?, recipient = ?, date = ?, user_id = ?, anomaly_id = ?, account = ?` +
 		` WHERE id = ?`
 
 	// run query
-	XOLog(sqlstr, ea.AwsAccountID, ea.Product, ea.Recipient, ea.Date, ea.ID)
-	_, err = db.Exec(sqlstr, ea.AwsAccountID, ea.Product, ea.Recipient, ea.Date, ea.ID)
+	XOLog(sqlstr, ea.AwsAccountID, ea.Product, ea.Recipient, ea.Date, ea.UserID, ea.AnomalyID, ea.Account, ea.ID)
+	_, err = db.Exec(sqlstr, ea.AwsAccountID, ea.Product, ea.Recipient, ea.Date, ea.UserID, ea.AnomalyID, ea.Account, ea.ID)
 	return err
 }
 
@@ -130,54 +134,60 @@ func (ea *EmailedAnomaly) Delete(db XODB) error {
 	return nil
 }
 
-// AwsAccount returns the AwsAccount associated with the EmailedAnomaly's AwsAccountID (aws_account_id).
-//
-// Generated from foreign key 'emailed_anomaly_ibfk_1'.
-func (ea *EmailedAnomaly) AwsAccount(db XODB) (*AwsAccount, error) {
-	return AwsAccountByID(db, ea.AwsAccountID)
-}
-
-// EmailedAnomalyByID retrieves a row from 'trackit.emailed_anomaly' as a EmailedAnomaly.
+// EmailedAnomaliesByAwsAccountID retrieves a row from 'trackit.emailed_anomaly' as a EmailedAnomaly.
 //
-// Generated from index 'emailed_anomaly_id_pkey'.
-func EmailedAnomalyByID(db XODB, id int) (*EmailedAnomaly, error) {
+// Generated from index 'foreign_aws_account'.
+func EmailedAnomaliesByAwsAccountID(db XODB, awsAccountID sql.NullInt64) ([]*EmailedAnomaly, error) {
 	var err error
 
 	// sql query
 	const sqlstr = `SELECT ` +
-		`id, aws_account_id, product, recipient, date ` +
+		`id, aws_account_id, product, recipient, date, user_id, anomaly_id, account ` +
 		`FROM trackit.emailed_anomaly ` +
-		`WHERE id = ?`
+		`WHERE aws_account_id = ?`
 
 	// run query
-	XOLog(sqlstr, id)
-	ea := EmailedAnomaly{
-		_exists: true,
-	}
-
-	err = db.QueryRow(sqlstr, id).Scan(&ea.ID, &ea.AwsAccountID, &ea.Product, &ea.Recipient, &ea.Date)
+	XOLog(sqlstr, awsAccountID)
+	q, err := db.Query(sqlstr, awsAccountID)
 	if err != nil {
 		return nil, err
 	}
+	defer q.Close()
 
-	return &ea, nil
+	// load results
+	res := []*EmailedAnomaly{}
+	for q.Next() {
+		ea := EmailedAnomaly{
+			_exists: true,
+		}
+
+		// scan
+		err = q.Scan(&ea.ID, &ea.AwsAccountID, &ea.Product, &ea.Recipient, &ea.Date, &ea.UserID, &ea.AnomalyID, &ea.Account)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, &ea)
+	}
+
+	return res, nil
 }
 
-// EmailedAnomaliesByAwsAccountID retrieves a row from 'trackit.emailed_anomaly' as a EmailedAnomaly.
+// EmailedAnomaliesByUserIDAnomalyID retrieves a row from 'trackit.emailed_anomaly' as a EmailedAnomaly.
 //
-// Generated from index 'foreign_aws_account'.
-func EmailedAnomaliesByAwsAccountID(db XODB, awsAccountID int) ([]*EmailedAnomaly, error) {
+// Generated from index 'emailed_anomaly_user_anomaly'.
+func EmailedAnomaliesByUserIDAnomalyID(db XODB, userID sql.NullInt64, anomalyID string) ([]*EmailedAnomaly, error) {
 	var err error
 
 	// sql query
 	const sqlstr = `SELECT ` +
-		`id, aws_account_id, product, recipient, date ` +
+		`id, aws_account_id, product, recipient, date, user_id, anomaly_id, account ` +
 		`FROM trackit.emailed_anomaly ` +
-		`WHERE aws_account_id = ?`
+		`WHERE user_id = ? AND anomaly_id = ?`
 
 	// run query
-	XOLog(sqlstr, awsAccountID)
-	q, err := db.Query(sqlstr, awsAccountID)
+	XOLog(sqlstr, userID, anomalyID)
+	q, err := db.Query(sqlstr, userID, anomalyID)
 	if err != nil {
 		return nil, err
 	}
@@ -191,7 +201,7 @@ func EmailedAnomaliesByAwsAccountID(db XODB, awsAccountID int) ([]*EmailedAnomal
 		}
 
 		// scan
-		err = q.Scan(&ea.ID, &ea.AwsAccountID, &ea.Product, &ea.Recipient, &ea.Date)
+		err = q.Scan(&ea.ID, &ea.AwsAccountID, &ea.Product, &ea.Recipient, &ea.Date, &ea.UserID, &ea.AnomalyID, &ea.Account)
 		if err != nil {
 			return nil, err
 		}
@@ -201,3 +211,29 @@ func EmailedAnomaliesByAwsAccountID(db XODB, awsAccountID int) ([]*EmailedAnomal
 
 	return res, nil
 }
+
+// EmailedAnomalyByID retrieves a row from 'trackit.emailed_anomaly' as a EmailedAnomaly.
+//
+// Generated from index 'emailed_anomaly_id_pkey'.
+func EmailedAnomalyByID(db XODB, id int) (*EmailedAnomaly, error) {
+	var err error
+
+	// sql query
+	const sqlstr = `SELECT ` +
+		`id, aws_account_id, product, recipient, date, user_id, anomaly_id, account ` +
+		`FROM trackit.emailed_anomaly ` +
+		`WHERE id = ?`
+
+	// run query
+	XOLog(sqlstr, id)
+	ea := EmailedAnomaly{
+		_exists: true,
+	}
+
+	err = db.QueryRow(sqlstr, id).Scan(&ea.ID, &ea.AwsAccountID, &ea.Product, &ea.Recipient, &ea.Date, &ea.UserID, &ea.AnomalyID, &ea.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ea, nil
+}
@@ -0,0 +1,195 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const jobColumns = `id, type, args, user_id, aws_account_id, status, attempts, max_attempts, run_after, lease_owner, lease_expires, cancel_requested, error, created, updated `
+
+// queryJobs runs a query selecting jobColumns and returns the resulting jobs.
+func queryJobs(db XODB, sqlstr string, args ...interface{}) ([]*Job, error) {
+	XOLog(sqlstr, args...)
+	q, err := db.Query(sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*Job{}
+	for q.Next() {
+		j := Job{
+			_exists: true,
+		}
+		err = q.Scan(&j.ID, &j.Type, &j.Args, &j.UserID, &j.AwsAccountID, &j.Status, &j.Attempts, &j.MaxAttempts, &j.RunAfter, &j.LeaseOwner, &j.LeaseExpires, &j.CancelRequested, &j.Error, &j.Created, &j.Updated)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &j)
+	}
+	return res, nil
+}
+
+// JobsClaimable returns the jobs which can be claimed by a worker at a given
+// time: pending jobs whose run_after is past and running jobs whose lease
+// expired, oldest first.
+func JobsClaimable(db XODB, now time.Time, limit int) ([]*Job, error) {
+	const sqlstr = `SELECT ` + jobColumns +
+		`FROM trackit.job ` +
+		`WHERE cancel_requested = 0 AND ((status = 'pending' AND run_after <= ?) OR (status = 'running' AND lease_expires < ?)) ` +
+		`ORDER BY run_after, id ` +
+		`LIMIT ?`
+	return queryJobs(db, sqlstr, now, now, limit)
+}
+
+// JobsLatestByUserID returns the latest jobs of a user, most recent first.
+func JobsLatestByUserID(db XODB, userID int, limit int) ([]*Job, error) {
+	const sqlstr = `SELECT ` + jobColumns +
+		`FROM trackit.job ` +
+		`WHERE user_id = ? ` +
+		`ORDER BY created DESC, id DESC ` +
+		`LIMIT ?`
+	return queryJobs(db, sqlstr, userID, limit)
+}
+
+// JobsLatestWithoutUser returns the latest jobs which belong to no user, most
+// recent first.
+func JobsLatestWithoutUser(db XODB, limit int) ([]*Job, error) {
+	const sqlstr = `SELECT ` + jobColumns +
+		`FROM trackit.job ` +
+		`WHERE user_id IS NULL ` +
+		`ORDER BY created DESC, id DESC ` +
+		`LIMIT ?`
+	return queryJobs(db, sqlstr, limit)
+}
+
+// JobActiveExists tells whether a pending or running job of type typ with
+// args exists.
+func JobActiveExists(db XODB, typ string, args []byte) (bool, error) {
+	const sqlstr = `SELECT COUNT(*) FROM trackit.job ` +
+		`WHERE type = ? AND args = ? AND status IN ('pending', 'running')`
+	var count int
+	XOLog(sqlstr, typ, args)
+	err := db.QueryRow(sqlstr, typ, args).Scan(&count)
+	return count > 0, err
+}
+
+// LockAwsAccountForJobs locks the row of an AWS account until the end of the
+// transaction, so that the jobs of the account are claimed one at a time.
+func LockAwsAccountForJobs(db XODB, awsAccountID int64) error {
+	const sqlstr = `SELECT id FROM trackit.aws_account WHERE id = ? FOR UPDATE`
+	var id int64
+	XOLog(sqlstr, awsAccountID)
+	return db.QueryRow(sqlstr, awsAccountID).Scan(&id)
+}
+
+// ClaimJob leases a claimable job to owner until leaseExpires and increments
+// its attempts. If the job has an AWS account and accountLimit is positive,
+// the job is only claimed if fewer than accountLimit jobs are running for
+// this account, which is only reliable if the account is locked with
+// LockAwsAccountForJobs in the same transaction. It returns false if the job
+// could not be claimed, for example because another worker claimed it first.
+func ClaimJob(db XODB, id int, awsAccountID sql.NullInt64, owner string, now time.Time, leaseExpires time.Time, accountLimit int) (bool, error) {
+	const sqlstr = `UPDATE trackit.job SET ` +
+		`status = 'running', lease_owner = ?, lease_expires = ?, attempts = attempts + 1 ` +
+		`WHERE id = ? AND cancel_requested = 0 AND ((status = 'pending' AND run_after <= ?) OR (status = 'running' AND lease_expires < ?)) ` +
+		`AND (aws_account_id IS NULL OR ? <= 0 OR (` +
+		`SELECT c.running FROM (` +
+		`SELECT COUNT(*) AS running FROM trackit.job WHERE aws_account_id = ? AND status = 'running' AND lease_expires >= ?` +
+		`) AS c) < ?)`
+	XOLog(sqlstr, owner, leaseExpires, id, now, now, accountLimit, awsAccountID, now, accountLimit)
+	res, err := db.Exec(sqlstr, owner, leaseExpires, id, now, now, accountLimit, awsAccountID, now, accountLimit)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
+
+// RenewJobLease extends the lease of a job held by owner. It returns whether
+// the lease is still held and whether the cancellation of the job was
+// requested.
+func RenewJobLease(db XODB, id int, owner string, leaseExpires time.Time) (bool, bool, error) {
+	const sqlstr = `UPDATE trackit.job SET ` +
+		`lease_expires = ? ` +
+		`WHERE id = ? AND lease_owner = ? AND status = 'running'`
+	XOLog(sqlstr, leaseExpires, id, owner)
+	res, err := db.Exec(sqlstr, leaseExpires, id, owner)
+	if err != nil {
+		return false, false, err
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected != 1 {
+		return false, false, err
+	}
+	const cancelSqlstr = `SELECT cancel_requested FROM trackit.job WHERE id = ?`
+	var cancelRequested bool
+	XOLog(cancelSqlstr, id)
+	err = db.QueryRow(cancelSqlstr, id).Scan(&cancelRequested)
+	return true, cancelRequested, err
+}
+
+// FinishJob releases the lease of a job held by owner and sets its status,
+// the time after which it can run again and its error.
+func FinishJob(db XODB, id int, owner string, status string, runAfter time.Time, errorMessage string) error {
+	const sqlstr = `UPDATE trackit.job SET ` +
+		`status = ?, run_after = ?, error = ?, lease_owner = '' ` +
+		`WHERE id = ? AND lease_owner = ? AND status = 'running'`
+	XOLog(sqlstr, status, runAfter, errorMessage, id, owner)
+	_, err := db.Exec(sqlstr, status, runAfter, errorMessage, id, owner)
+	return err
+}
+
+// CancelExpiredJobs marks as cancelled the running jobs whose cancellation
+// was requested and whose lease expired before their worker could stop them.
+func CancelExpiredJobs(db XODB, now time.Time) error {
+	const sqlstr = `UPDATE trackit.job SET ` +
+		`status = 'cancelled', lease_owner = '' ` +
+		`WHERE status = 'running' AND cancel_requested = 1 AND lease_expires < ?`
+	XOLog(sqlstr, now)
+	_, err := db.Exec(sqlstr, now)
+	return err
+}
+
+// CancelJob cancels a pending job or requests the cancellation of a running
+// job. It returns false if the job is neither pending nor running.
+func CancelJob(db XODB, id int) (bool, error) {
+	const sqlstr = `UPDATE trackit.job SET ` +
+		`status = IF(status = 'pending', 'cancelled', status), cancel_requested = 1 ` +
+		`WHERE id = ? AND status IN ('pending', 'running') AND cancel_requested = 0`
+	XOLog(sqlstr, id)
+	res, err := db.Exec(sqlstr, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
+
+// RetryJob puts back a failed or cancelled job in the queue with its attempts
+// reset. It returns false if the job is neither failed nor cancelled.
+func RetryJob(db XODB, id int, now time.Time) (bool, error) {
+	const sqlstr = `UPDATE trackit.job SET ` +
+		`status = 'pending', attempts = 0, run_after = ?, cancel_requested = 0, error = '', lease_owner = '' ` +
+		`WHERE id = ? AND status IN ('failed', 'cancelled')`
+	XOLog(sqlstr, now, id)
+	res, err := db.Exec(sqlstr, now, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
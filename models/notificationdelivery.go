@@ -0,0 +1,47 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+// NotificationDeliveriesByUserID returns the latest deliveries on the
+// notification channels of a user, most recent first.
+func NotificationDeliveriesByUserID(db XODB, userID int, limit int) ([]*NotificationDelivery, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`nd.id, nd.notification_channel_id, nd.event, nd.subject, nd.status, nd.attempts, nd.error, nd.created ` +
+		`FROM trackit.notification_delivery AS nd ` +
+		`INNER JOIN trackit.notification_channel AS nc ON nd.notification_channel_id = nc.id ` +
+		`WHERE nc.user_id = ? ` +
+		`ORDER BY nd.created DESC, nd.id DESC ` +
+		`LIMIT ?`
+	XOLog(sqlstr, userID, limit)
+	q, err := db.Query(sqlstr, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*NotificationDelivery{}
+	for q.Next() {
+		nd := NotificationDelivery{
+			_exists: true,
+		}
+		err = q.Scan(&nd.ID, &nd.NotificationChannelID, &nd.Event, &nd.Subject, &nd.Status, &nd.Attempts, &nd.Error, &nd.Created)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &nd)
+	}
+	return res, nil
+}
@@ -0,0 +1,56 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"time"
+)
+
+// LatestPeriodicTaskRunsByTask retrieves the latest runs of a periodic task,
+// most recent first.
+func LatestPeriodicTaskRunsByTask(db XODB, task string, limit int) ([]*PeriodicTaskRun, error) {
+	const sqlstr = `SELECT ` +
+		`id, task, backend_id, started, ended, error, skipped ` +
+		`FROM trackit.periodic_task_run ` +
+		`WHERE task = ? ORDER BY started DESC, id DESC LIMIT ?`
+	XOLog(sqlstr, task, limit)
+	q, err := db.Query(sqlstr, task, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*PeriodicTaskRun{}
+	for q.Next() {
+		ptr := PeriodicTaskRun{
+			_exists: true,
+		}
+		err = q.Scan(&ptr.ID, &ptr.Task, &ptr.BackendID, &ptr.Started, &ptr.Ended, &ptr.Error, &ptr.Skipped)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &ptr)
+	}
+	return res, q.Err()
+}
+
+// DeletePeriodicTaskRunsEndedBefore deletes the runs of the periodic tasks
+// which ended before the date parameter.
+func DeletePeriodicTaskRunsEndedBefore(db XODB, date time.Time) error {
+	const sqlstr = `DELETE FROM trackit.periodic_task_run WHERE ended < ?`
+	XOLog(sqlstr, date)
+	_, err := db.Exec(sqlstr, date)
+	return err
+}
@@ -4,16 +4,18 @@ package models
 // Code generated by xo. DO NOT EDIT.
 
 import (
+	"database/sql"
 	"errors"
 )
 
 // SharedAccount represents a row from 'trackit.shared_account'.
 type SharedAccount struct {
-	ID              int  `json:"id"`               // id
-	AccountID       int  `json:"account_id"`       // account_id
-	UserID          int  `json:"user_id"`          // user_id
-	UserPermission  int  `json:"user_permission"`  // user_permission
-	SharingAccepted bool `json:"sharing_accepted"` // sharing_accepted
+	ID              int           `json:"id"`               // id
+	AccountID       int           `json:"account_id"`       // account_id
+	UserID          int           `json:"user_id"`          // user_id
+	SharingAccepted bool          `json:"sharing_accepted"` // sharing_accepted
+	SSOProviderID   sql.NullInt64 `json:"sso_provider_id"`  // sso_provider_id
+	AccessRoleID    int           `json:"access_role_id"`   // access_role_id
 
 	// xo fields
 	_exists, _deleted bool
@@ -40,14 +42,14 @@ func (sa *SharedAccount) Insert(db XODB) error {
 
 	// sql insert query, primary key provided by autoincrement
 	const sqlstr = `INSERT INTO trackit.shared_account (` +
-		`account_id, user_id, user_permission, sharing_accepted` +
+		`account_id, user_id, sharing_accepted, sso_provider_id, access_role_id` +
 		`) VALUES (` +
-		`?, ?, ?, ?` +
+		`?, ?, ?, ?, ?` +
 		`)`
 
 	// run query
-	XOLog(sqlstr, sa.AccountID, sa.UserID, sa.UserPermission, sa.SharingAccepted)
-	res, err := db.Exec(sqlstr, sa.AccountID, sa.UserID, sa.UserPermission, sa.SharingAccepted)
+	XOLog(sqlstr, sa.AccountID, sa.UserID, sa.SharingAccepted, sa.SSOProviderID, sa.AccessRoleID)
+	res, err := db.Exec(sqlstr, sa.AccountID, sa.UserID, sa.SharingAccepted, sa.SSOProviderID, sa.AccessRoleID)
 	if err != nil {
 		return err
 	}
@@ -81,12 +83,12 @@ func (sa *SharedAccount) Update(db XODB) error {
 
 	// sql query
 	const sqlstr = `UPDATE trackit.shared_account SET ` +
-		`account_id = ?, user_id = ?, user_permission = ?, sharing_accepted = ?` +
+		`account_id = ?, user_id = ?, sharing_accepted = ?, sso_provider_id = ?, access_role_id = ?` +
 		` WHERE id = ?`
 
 	// run query
-	XOLog(sqlstr, sa.AccountID, sa.UserID, sa.UserPermission, sa.SharingAccepted, sa.ID)
-	_, err = db.Exec(sqlstr, sa.AccountID, sa.UserID, sa.UserPermission, sa.SharingAccepted, sa.ID)
+	XOLog(sqlstr, sa.AccountID, sa.UserID, sa.SharingAccepted, sa.SSOProviderID, sa.AccessRoleID, sa.ID)
+	_, err = db.Exec(sqlstr, sa.AccountID, sa.UserID, sa.SharingAccepted, sa.SSOProviderID, sa.AccessRoleID, sa.ID)
 	return err
 }
 
@@ -143,6 +145,13 @@ func (sa *SharedAccount) User(db XODB) (*User, error) {
 	return UserByID(db, sa.UserID)
 }
 
+// AccessRole returns the AccessRole associated with the SharedAccount's AccessRoleID (access_role_id).
+//
+// Generated from foreign key 'foreign_access_role'.
+func (sa *SharedAccount) AccessRole(db XODB) (*AccessRole, error) {
+	return AccessRoleByID(db, sa.AccessRoleID)
+}
+
 // SharedAccountsByAccountID retrieves a row from 'trackit.shared_account' as a SharedAccount.
 //
 // Generated from index 'foreign_aws_account'.
@@ -151,7 +160,7 @@ func SharedAccountsByAccountID(db XODB, accountID int) ([]*SharedAccount, error)
 
 	// sql query
 	const sqlstr = `SELECT ` +
-		`id, account_id, user_id, user_permission, sharing_accepted ` +
+		`id, account_id, user_id, sharing_accepted, sso_provider_id, access_role_id ` +
 		`FROM trackit.shared_account ` +
 		`WHERE account_id = ?`
 
@@ -171,7 +180,7 @@ func SharedAccountsByAccountID(db XODB, accountID int) ([]*SharedAccount, error)
 		}
 
 		// scan
-		err = q.Scan(&sa.ID, &sa.AccountID, &sa.UserID, &sa.UserPermission, &sa.SharingAccepted)
+		err = q.Scan(&sa.ID, &sa.AccountID, &sa.UserID, &sa.SharingAccepted, &sa.SSOProviderID, &sa.AccessRoleID)
 		if err != nil {
 			return nil, err
 		}
@@ -190,7 +199,7 @@ func SharedAccountsByUserID(db XODB, userID int) ([]*SharedAccount, error) {
 
 	// sql query
 	const sqlstr = `SELECT ` +
-		`id, account_id, user_id, user_permission, sharing_accepted ` +
+		`id, account_id, user_id, sharing_accepted, sso_provider_id, access_role_id ` +
 		`FROM trackit.shared_account ` +
 		`WHERE user_id = ?`
 
@@ -210,7 +219,85 @@ func SharedAccountsByUserID(db XODB, userID int) ([]*SharedAccount, error) {
 		}
 
 		// scan
-		err = q.Scan(&sa.ID, &sa.AccountID, &sa.UserID, &sa.UserPermission, &sa.SharingAccepted)
+		err = q.Scan(&sa.ID, &sa.AccountID, &sa.UserID, &sa.SharingAccepted, &sa.SSOProviderID, &sa.AccessRoleID)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, &sa)
+	}
+
+	return res, nil
+}
+
+// SharedAccountsBySSOProviderID retrieves a row from 'trackit.shared_account' as a SharedAccount.
+//
+// Generated from index 'foreign_sso_provider'.
+func SharedAccountsBySSOProviderID(db XODB, ssoProviderID sql.NullInt64) ([]*SharedAccount, error) {
+	var err error
+
+	// sql query
+	const sqlstr = `SELECT ` +
+		`id, account_id, user_id, sharing_accepted, sso_provider_id, access_role_id ` +
+		`FROM trackit.shared_account ` +
+		`WHERE sso_provider_id = ?`
+
+	// run query
+	XOLog(sqlstr, ssoProviderID)
+	q, err := db.Query(sqlstr, ssoProviderID)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	// load results
+	res := []*SharedAccount{}
+	for q.Next() {
+		sa := SharedAccount{
+			_exists: true,
+		}
+
+		// scan
+		err = q.Scan(&sa.ID, &sa.AccountID, &sa.UserID, &sa.SharingAccepted, &sa.SSOProviderID, &sa.AccessRoleID)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, &sa)
+	}
+
+	return res, nil
+}
+
+// SharedAccountsByAccessRoleID retrieves a row from 'trackit.shared_account' as a SharedAccount.
+//
+// Generated from index 'foreign_access_role'.
+func SharedAccountsByAccessRoleID(db XODB, accessRoleID int) ([]*SharedAccount, error) {
+	var err error
+
+	// sql query
+	const sqlstr = `SELECT ` +
+		`id, account_id, user_id, sharing_accepted, sso_provider_id, access_role_id ` +
+		`FROM trackit.shared_account ` +
+		`WHERE access_role_id = ?`
+
+	// run query
+	XOLog(sqlstr, accessRoleID)
+	q, err := db.Query(sqlstr, accessRoleID)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	// load results
+	res := []*SharedAccount{}
+	for q.Next() {
+		sa := SharedAccount{
+			_exists: true,
+		}
+
+		// scan
+		err = q.Scan(&sa.ID, &sa.AccountID, &sa.UserID, &sa.SharingAccepted, &sa.SSOProviderID, &sa.AccessRoleID)
 		if err != nil {
 			return nil, err
 		}
@@ -229,7 +316,7 @@ func SharedAccountByID(db XODB, id int) (*SharedAccount, error) {
 
 	// sql query
 	const sqlstr = `SELECT ` +
-		`id, account_id, user_id, user_permission, sharing_accepted ` +
+		`id, account_id, user_id, sharing_accepted, sso_provider_id, access_role_id ` +
 		`FROM trackit.shared_account ` +
 		`WHERE id = ?`
 
@@ -239,7 +326,7 @@ func SharedAccountByID(db XODB, id int) (*SharedAccount, error) {
 		_exists: true,
 	}
 
-	err = db.QueryRow(sqlstr, id).Scan(&sa.ID, &sa.AccountID, &sa.UserID, &sa.UserPermission, &sa.SharingAccepted)
+	err = db.QueryRow(sqlstr, id).Scan(&sa.ID, &sa.AccountID, &sa.UserID, &sa.SharingAccepted, &sa.SSOProviderID, &sa.AccessRoleID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,52 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"time"
+)
+
+// DeleteExpiredSSOLogins deletes the SSOLogin that were started before the
+// date parameter.
+func DeleteExpiredSSOLogins(db XODB, date time.Time) error {
+	const sqlstr = `DELETE FROM trackit.sso_login WHERE created < ?`
+	XOLog(sqlstr, date)
+	_, err := db.Exec(sqlstr, date)
+	return err
+}
+
+// DeleteSSOGroupMappingsBySSOProviderID deletes the SSOGroupMapping of an
+// SSO provider.
+func DeleteSSOGroupMappingsBySSOProviderID(db XODB, ssoProviderID int) error {
+	const sqlstr = `DELETE FROM trackit.sso_group_mapping WHERE sso_provider_id = ?`
+	XOLog(sqlstr, ssoProviderID)
+	_, err := db.Exec(sqlstr, ssoProviderID)
+	return err
+}
+
+// DeleteSSOIdentitiesByUserID deletes the SSOIdentity linked to a user, and
+// the SharedAccount their SSO providers granted them.
+func DeleteSSOIdentitiesByUserID(db XODB, userID int) error {
+	const sqlstr = `DELETE FROM trackit.shared_account WHERE user_id = ? AND sso_provider_id IS NOT NULL`
+	XOLog(sqlstr, userID)
+	if _, err := db.Exec(sqlstr, userID); err != nil {
+		return err
+	}
+	const sqlstr2 = `DELETE FROM trackit.sso_identity WHERE user_id = ?`
+	XOLog(sqlstr2, userID)
+	_, err := db.Exec(sqlstr2, userID)
+	return err
+}
@@ -0,0 +1,44 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+// TagPoliciesByUserID returns the tag policies of a user, ordered by name.
+func TagPoliciesByUserID(db XODB, userID int) ([]*TagPolicy, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`id, user_id, name, policy ` +
+		`FROM trackit.tag_policy ` +
+		`WHERE user_id = ? ` +
+		`ORDER BY name`
+	XOLog(sqlstr, userID)
+	q, err := db.Query(sqlstr, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*TagPolicy{}
+	for q.Next() {
+		tp := TagPolicy{
+			_exists: true,
+		}
+		err = q.Scan(&tp.ID, &tp.UserID, &tp.Name, &tp.Policy)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &tp)
+	}
+	return res, nil
+}
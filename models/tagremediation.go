@@ -0,0 +1,46 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+// TagRemediationsLatestByUserID returns the latest tag remediations a user
+// owns or proposed, most recent first.
+func TagRemediationsLatestByUserID(db XODB, userID int, limit int) ([]*TagRemediation, error) {
+	var err error
+	const sqlstr = `SELECT ` +
+		`id, user_id, created_by, reviewed_by, status, changes, job_id, created, updated ` +
+		`FROM trackit.tag_remediation ` +
+		`WHERE user_id = ? OR created_by = ? ` +
+		`ORDER BY created DESC, id DESC ` +
+		`LIMIT ?`
+	XOLog(sqlstr, userID, userID, limit)
+	q, err := db.Query(sqlstr, userID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []*TagRemediation{}
+	for q.Next() {
+		tr := TagRemediation{
+			_exists: true,
+		}
+		err = q.Scan(&tr.ID, &tr.UserID, &tr.CreatedBy, &tr.ReviewedBy, &tr.Status, &tr.Changes, &tr.JobID, &tr.Created, &tr.Updated)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &tr)
+	}
+	return res, nil
+}
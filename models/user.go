@@ -40,3 +40,25 @@ func GetUnusedAccounts(db XODB, unseenFor time.Duration) ([]*User, error) {
 
 	return res, nil
 }
+
+// UserIDsWithAccounts returns the IDs of the users owning AWS accounts or
+// billing sources.
+func UserIDsWithAccounts(db XODB) ([]int, error) {
+	const sqlstr = `SELECT user_id FROM trackit.aws_account ` +
+		`UNION SELECT user_id FROM trackit.billing_source`
+	XOLog(sqlstr)
+	q, err := db.Query(sqlstr)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+	res := []int{}
+	for q.Next() {
+		var userID int
+		if err := q.Scan(&userID); err != nil {
+			return nil, err
+		}
+		res = append(res, userID)
+	}
+	return res, nil
+}
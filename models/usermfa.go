@@ -0,0 +1,42 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"time"
+)
+
+// DeleteUserMFARecoveryCodesByUserID deletes all the UserMFARecoveryCode of
+// a user.
+func DeleteUserMFARecoveryCodesByUserID(db XODB, userID int) error {
+	const sqlstr = `DELETE FROM trackit.user_mfa_recovery_code WHERE user_id = ?`
+	XOLog(sqlstr, userID)
+	_, err := db.Exec(sqlstr, userID)
+	return err
+}
+
+// IncrementUserMFAFailedAttempts counts a failed verification for the
+// UserMFA of a user. Once the count reaches maxAttempts, the UserMFA is
+// locked until the lockedUntil parameter.
+func IncrementUserMFAFailedAttempts(db XODB, userID int, maxAttempts int, lockedUntil time.Time) error {
+	const sqlstr = `UPDATE trackit.user_mfa SET ` +
+		`failed_attempts = failed_attempts + 1, ` +
+		`locked_until = IF(failed_attempts >= ?, ?, locked_until) ` +
+		`WHERE user_id = ?`
+	XOLog(sqlstr, maxAttempts, lockedUntil, userID)
+	_, err := db.Exec(sqlstr, maxAttempts, lockedUntil, userID)
+	return err
+}
@@ -0,0 +1,66 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package models contains the types for schema 'trackit'.
+package models
+
+import (
+	"time"
+)
+
+// DeleteExpiredUserSessions deletes the UserSession that expired before the
+// date parameter.
+func DeleteExpiredUserSessions(db XODB, date time.Time) error {
+	const sqlstr = `DELETE FROM trackit.user_session WHERE expires < ?`
+	XOLog(sqlstr, date)
+	_, err := db.Exec(sqlstr, date)
+	return err
+}
+
+// DeleteUserSessionsByUserID deletes the UserSession of a user, except the
+// one with the exceptID id. An exceptID of 0 deletes all of them.
+func DeleteUserSessionsByUserID(db XODB, userID int, exceptID int) error {
+	const sqlstr = `DELETE FROM trackit.user_session WHERE user_id = ? AND id != ?`
+	XOLog(sqlstr, userID, exceptID)
+	_, err := db.Exec(sqlstr, userID, exceptID)
+	return err
+}
+
+// InsertUsedRefreshToken records that a UserSession was rotated from a
+// refresh token, so that it can be recognized if it is presented again.
+func InsertUsedRefreshToken(db XODB, sessionID int, refreshToken string) error {
+	const sqlstr = `INSERT INTO trackit.user_session_used_refresh_token (refresh_token, session_id) VALUES (?, ?)`
+	XOLog(sqlstr, refreshToken, sessionID)
+	_, err := db.Exec(sqlstr, refreshToken, sessionID)
+	return err
+}
+
+// UserSessionByUsedRefreshToken retrieves the UserSession which was rotated
+// from a refresh token.
+func UserSessionByUsedRefreshToken(db XODB, refreshToken string) (*UserSession, error) {
+	const sqlstr = `SELECT ` +
+		`s.id, s.user_id, s.refresh_token, s.user_agent, s.ip_address, s.created, s.last_used, s.expires, s.mfa ` +
+		`FROM trackit.user_session AS s ` +
+		`JOIN trackit.user_session_used_refresh_token AS u ON u.session_id = s.id ` +
+		`WHERE u.refresh_token = ?`
+	XOLog(sqlstr, refreshToken)
+	us := UserSession{
+		_exists: true,
+	}
+	err := db.QueryRow(sqlstr, refreshToken).Scan(&us.ID, &us.UserID, &us.RefreshToken, &us.UserAgent, &us.IPAddress, &us.Created, &us.LastUsed, &us.Expires, &us.MFA)
+	if err != nil {
+		return nil, err
+	}
+	return &us, nil
+}
@@ -0,0 +1,80 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package notifications
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/trackit/trackit/models"
+)
+
+type (
+	// slackChannel posts notifications to a Slack incoming webhook.
+	slackChannel struct {
+		url string
+	}
+
+	// teamsChannel posts notifications to a Microsoft Teams incoming
+	// webhook.
+	teamsChannel struct {
+		url string
+	}
+
+	// slackMessage is the payload of a Slack incoming webhook.
+	slackMessage struct {
+		Text string `json:"text"`
+	}
+
+	// teamsMessageCard is the payload of a Microsoft Teams incoming webhook.
+	teamsMessageCard struct {
+		Type    string `json:"@type"`
+		Context string `json:"@context"`
+		Summary string `json:"summary"`
+		Title   string `json:"title"`
+		Text    string `json:"text"`
+	}
+)
+
+func init() {
+	registerChannelType("slack", channelType{
+		validate: validateWebhookUrl,
+		build: func(nc models.NotificationChannel) channel {
+			return slackChannel{nc.Target}
+		},
+	})
+	registerChannelType("teams", channelType{
+		validate: validateWebhookUrl,
+		build: func(nc models.NotificationChannel) channel {
+			return teamsChannel{nc.Target}
+		},
+	})
+}
+
+func (c slackChannel) deliver(ctx context.Context, n Notification) error {
+	return postJson(ctx, c.url, slackMessage{
+		Text: fmt.Sprintf("*%s*\n%s", n.Subject, n.Body),
+	}, nil)
+}
+
+func (c teamsChannel) deliver(ctx context.Context, n Notification) error {
+	return postJson(ctx, c.url, teamsMessageCard{
+		Type:    "MessageCard",
+		Context: "https://schema.org/extensions",
+		Summary: n.Subject,
+		Title:   n.Subject,
+		Text:    n.Body,
+	}, nil)
+}
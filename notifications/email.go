@@ -0,0 +1,50 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package notifications
+
+import (
+	"context"
+	"errors"
+	netmail "net/mail"
+
+	"github.com/trackit/trackit/mail"
+	"github.com/trackit/trackit/models"
+)
+
+// emailChannel emails notifications to a recipient.
+type emailChannel struct {
+	recipient string
+}
+
+func init() {
+	registerChannelType("email", channelType{
+		validate: func(target string) error {
+			if _, err := netmail.ParseAddress(target); err != nil {
+				return errors.New("invalid email address")
+			}
+			return nil
+		},
+		build: func(nc models.NotificationChannel) channel {
+			return emailChannel{nc.Target}
+		},
+	})
+}
+
+func (c emailChannel) deliver(ctx context.Context, n Notification) error {
+	if n.HtmlBody != "" {
+		return mail.SendHtmlMail(c.recipient, n.Subject, n.Body, n.HtmlBody, ctx)
+	}
+	return mail.SendMail(c.recipient, n.Subject, n.Body, ctx)
+}
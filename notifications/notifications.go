@@ -0,0 +1,205 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package notifications delivers the alerts of TrackIt to the channels
+// configured by the users: email, webhooks and chat applications.
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/models"
+)
+
+const (
+	// EventAnomaly is the event of the cost anomalies detected on an AWS
+	// account.
+	EventAnomaly = "anomaly"
+	// EventPlugin is the event of the results of the account plugins.
+	EventPlugin = "plugin"
+)
+
+const (
+	statusDelivered = "delivered"
+	statusFailed    = "failed"
+)
+
+// Events are the events a channel can subscribe to.
+var Events = []string{EventAnomaly, EventPlugin}
+
+// emailedEvents are the events emailed to the users who did not configure
+// any channel.
+var emailedEvents = map[string]bool{
+	EventAnomaly: true,
+}
+
+type (
+	// Notification is an alert sent to the channels of a user. Data holds
+	// the details of the event, which are sent along with the message by the
+	// webhook channels. HtmlBody is an optional HTML version of Body used by
+	// the email channels.
+	Notification struct {
+		Event    string      `json:"event"`
+		Date     time.Time   `json:"date"`
+		Subject  string      `json:"subject"`
+		Body     string      `json:"body"`
+		HtmlBody string      `json:"-"`
+		Data     interface{} `json:"data,omitempty"`
+	}
+
+	// channel delivers notifications to a destination.
+	channel interface {
+		deliver(context.Context, Notification) error
+	}
+
+	// channelType validates the target of the channels of a type and builds
+	// them from their database representation.
+	channelType struct {
+		validate func(target string) error
+		build    func(models.NotificationChannel) channel
+	}
+)
+
+// channelTypes are the registered channel types, by name.
+var channelTypes = make(map[string]channelType)
+
+// registerChannelType registers a channel type under name.
+func registerChannelType(name string, ct channelType) {
+	channelTypes[name] = ct
+}
+
+// ValidChannel checks that a channel of type typ can deliver notifications
+// to target.
+func ValidChannel(typ, target string) error {
+	if ct, ok := channelTypes[typ]; !ok {
+		return fmt.Errorf("invalid channel type : %s", typ)
+	} else {
+		return ct.validate(target)
+	}
+}
+
+// ChannelTypes returns the names of the registered channel types.
+func ChannelTypes() []string {
+	res := make([]string, 0, len(channelTypes))
+	for name := range channelTypes {
+		res = append(res, name)
+	}
+	return res
+}
+
+// ValidEvent checks that channels can subscribe to event.
+func ValidEvent(event string) error {
+	for _, e := range Events {
+		if e == event {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid event : %s", event)
+}
+
+// subscribed checks whether a channel subscribed to event. Channels without
+// any event subscribed to all of them.
+func subscribed(nc *models.NotificationChannel, event string) bool {
+	var events []string
+	if nc.Events == nil || json.Unmarshal(nc.Events, &events) != nil || len(events) == 0 {
+		return true
+	}
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify delivers a notification on the channels of a user subscribed to its
+// event, and logs every delivery. Users who did not configure any channel are
+// emailed the events in emailedEvents.
+func Notify(ctx context.Context, db models.XODB, userId int, n Notification) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	if n.Date.IsZero() {
+		n.Date = time.Now().UTC()
+	}
+	dbChannels, err := models.NotificationChannelsByUserID(db, userId)
+	if err != nil {
+		return err
+	} else if len(dbChannels) == 0 {
+		return notifyByEmail(ctx, db, userId, n)
+	}
+	var lastErr error
+	for _, dbChannel := range dbChannels {
+		ct, ok := channelTypes[dbChannel.Type]
+		if !ok || !subscribed(dbChannel, n.Event) {
+			continue
+		}
+		attempts, err := deliverWithRetry(ctx, ct.build(*dbChannel), n)
+		if err != nil {
+			logger.Error("Failed to deliver notification.", map[string]interface{}{
+				"notificationChannelId": dbChannel.ID,
+				"event":                 n.Event,
+				"error":                 err.Error(),
+			})
+			lastErr = err
+		}
+		if err := logDelivery(db, dbChannel, n, attempts, err); err != nil {
+			return err
+		}
+	}
+	return lastErr
+}
+
+// notifyByEmail emails a notification to a user if its event is in
+// emailedEvents.
+func notifyByEmail(ctx context.Context, db models.XODB, userId int, n Notification) error {
+	if !emailedEvents[n.Event] {
+		return nil
+	}
+	dbUser, err := models.UserByID(db, userId)
+	if err != nil {
+		return err
+	}
+	_, err = deliverWithRetry(ctx, emailChannel{dbUser.Email}, n)
+	return err
+}
+
+// logDelivery saves the outcome of the delivery of a notification on a
+// channel.
+func logDelivery(db models.XODB, dbChannel *models.NotificationChannel, n Notification, attempts int, deliveryErr error) error {
+	delivery := models.NotificationDelivery{
+		NotificationChannelID: dbChannel.ID,
+		Event:                 n.Event,
+		Subject:               truncate(n.Subject, 255),
+		Status:                statusDelivered,
+		Attempts:              attempts,
+		Created:               time.Now(),
+	}
+	if deliveryErr != nil {
+		delivery.Status = statusFailed
+		delivery.Error = truncate(deliveryErr.Error(), 1024)
+	}
+	return delivery.Insert(db)
+}
+
+// truncate truncates s to at most n bytes so it fits in a database column.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,141 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/trackit/trackit/config"
+	"github.com/trackit/trackit/models"
+)
+
+// failingChannel fails its first failures deliveries.
+type failingChannel struct {
+	failures   int
+	deliveries *int
+}
+
+func (c failingChannel) deliver(ctx context.Context, n Notification) error {
+	*c.deliveries++
+	if *c.deliveries <= c.failures {
+		return errors.New("delivery failed")
+	}
+	return nil
+}
+
+func TestDeliverWithRetry(t *testing.T) {
+	config.NotificationMaxAttempts = 3
+	config.NotificationRetryDelay = time.Millisecond
+	var deliveries int
+	if attempts, err := deliverWithRetry(context.Background(), failingChannel{2, &deliveries}, Notification{}); err != nil || attempts != 3 {
+		t.Errorf("Expected a delivery after 3 attempts, got %d attempts and error %v", attempts, err)
+	}
+	deliveries = 0
+	if attempts, err := deliverWithRetry(context.Background(), failingChannel{5, &deliveries}, Notification{}); err == nil || attempts != 3 {
+		t.Errorf("Expected a failure after 3 attempts, got %d attempts and error %v", attempts, err)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	var received Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get(SignatureHeader) != sign("secret", body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get(EventHeader) != EventAnomaly {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.Unmarshal(body, &received)
+	}))
+	defer server.Close()
+	// The test server listens on a loopback address, which the webhooks
+	// cannot be delivered to.
+	defer func(client *http.Client) { httpClient = client }(httpClient)
+	httpClient = server.Client()
+	n := Notification{Event: EventAnomaly, Subject: "Subject", Body: "Body"}
+	if err := (webhookChannel{server.URL, "secret"}).deliver(context.Background(), n); err != nil {
+		t.Fatalf("Expected the webhook to be delivered, got %s", err)
+	}
+	if received.Subject != n.Subject {
+		t.Errorf("Expected subject %q, got %q", n.Subject, received.Subject)
+	}
+	if err := (webhookChannel{server.URL, "wrong"}).deliver(context.Background(), n); err == nil {
+		t.Error("Expected a wrongly signed webhook to be rejected")
+	}
+}
+
+func TestSubscribed(t *testing.T) {
+	all := &models.NotificationChannel{}
+	anomalies := &models.NotificationChannel{Events: []byte(`["anomaly"]`)}
+	if !subscribed(all, EventPlugin) {
+		t.Error("Expected a channel without events to subscribe to every event")
+	}
+	if !subscribed(anomalies, EventAnomaly) || subscribed(anomalies, EventPlugin) {
+		t.Error("Expected a channel to only subscribe to its events")
+	}
+}
+
+func TestWebhookPrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	if err := (webhookChannel{server.URL, ""}).deliver(context.Background(), Notification{}); err == nil {
+		t.Error("Expected a webhook to a loopback address not to be delivered")
+	}
+}
+
+func TestValidChannel(t *testing.T) {
+	defer func(lookup func(context.Context, string) ([]net.IPAddr, error)) { lookupIPAddr = lookup }(lookupIPAddr)
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if ip := net.ParseIP(host); ip != nil {
+			return []net.IPAddr{{IP: ip}}, nil
+		} else if host == "internal.example.com" {
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("10.0.0.1")}}, nil
+		}
+		return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+	}
+	for _, tc := range []struct {
+		typ    string
+		target string
+		valid  bool
+	}{
+		{"email", "user@example.com", true},
+		{"email", "not an email", false},
+		{"webhook", "https://example.com/hook", true},
+		{"webhook", "http://example.com/hook", false},
+		{"webhook", "https://127.0.0.1/hook", false},
+		{"webhook", "https://169.254.169.254/latest/meta-data/", false},
+		{"webhook", "https://[::1]/hook", false},
+		{"webhook", "https://192.168.1.10/hook", false},
+		{"webhook", "https://internal.example.com/hook", false},
+		{"slack", "ftp://example.com/hook", false},
+		{"teams", "https://example.webhook.office.com/webhookb2/x", true},
+		{"pager", "https://example.com", false},
+	} {
+		if err := ValidChannel(tc.typ, tc.target); (err == nil) != tc.valid {
+			t.Errorf("%s %s: expected validity %t, got %v", tc.typ, tc.target, tc.valid, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package notifications
+
+import (
+	"context"
+	"time"
+
+	"github.com/trackit/trackit/config"
+)
+
+// deliverWithRetry delivers a notification on a channel, attempting it up to
+// config.NotificationMaxAttempts times. The delay between two attempts
+// starts at config.NotificationRetryDelay and doubles after each attempt.
+// It returns the number of attempts and the error of the last one.
+func deliverWithRetry(ctx context.Context, ch channel, n Notification) (attempts int, err error) {
+	delay := config.NotificationRetryDelay
+	for attempts = 1; ; attempts++ {
+		if err = ch.deliver(ctx, n); err == nil || attempts >= config.NotificationMaxAttempts {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return attempts, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
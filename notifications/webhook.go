@@ -0,0 +1,191 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/trackit/trackit/models"
+)
+
+const (
+	// SignatureHeader is the header holding the HMAC-SHA256 signature of the
+	// body of a webhook request, keyed with the secret of the channel.
+	SignatureHeader = "X-Trackit-Signature"
+	// EventHeader is the header holding the event of a webhook request.
+	EventHeader = "X-Trackit-Event"
+)
+
+// errNotPublicAddress is returned when a webhook resolves to an address
+// which is not reachable from the internet.
+var errNotPublicAddress = errors.New("webhook host is not a public address")
+
+// httpClient is the client used to call the webhooks. It refuses to connect
+// to addresses which are not public, which is checked again when dialing so
+// that the host cannot be resolved to another address after validation.
+var httpClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				} else if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+					return errNotPublicAddress
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+}
+
+// lookupIPAddr resolves the hosts of the webhooks.
+var lookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+// nonPublicNetworks are the networks webhooks cannot be delivered to, besides
+// loopback, link-local, multicast and unspecified addresses.
+var nonPublicNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"fc00::/7",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+// publicIP tells whether ip is reachable from the internet, which excludes
+// the instance metadata endpoint and the internal services of the network
+// TrackIt runs in.
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// webhookChannel posts notifications as JSON to a URL. The body is signed
+// when the channel has a secret.
+type webhookChannel struct {
+	url    string
+	secret string
+}
+
+func init() {
+	registerChannelType("webhook", channelType{
+		validate: validateWebhookUrl,
+		build: func(nc models.NotificationChannel) channel {
+			return webhookChannel{nc.Target, nc.Secret}
+		},
+	})
+}
+
+// validateWebhookUrl checks that target is an absolute HTTPS URL whose host
+// only resolves to public addresses.
+func validateWebhookUrl(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return errors.New("invalid webhook URL, an absolute HTTPS URL is required")
+	}
+	addrs, err := lookupIPAddr(context.Background(), u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("invalid webhook URL, failed to resolve %s", u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return errNotPublicAddress
+		}
+	}
+	return nil
+}
+
+// sign returns the signature of body keyed with secret, as sent in the
+// SignatureHeader header.
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// postJson posts payload marshaled as JSON to target. Responses with a non
+// 2xx status are errors.
+func postJson(ctx context.Context, target string, payload interface{}, headers map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status : %s", res.Status)
+	}
+	return nil
+}
+
+func (c webhookChannel) deliver(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{EventHeader: n.Event}
+	if c.secret != "" {
+		headers[SignatureHeader] = sign(c.secret, body)
+	}
+	return postJson(ctx, c.url, json.RawMessage(body), headers)
+}
@@ -0,0 +1,232 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package periodic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule tells when a periodic task runs.
+type Schedule interface {
+	// Next returns the first time the task runs strictly after t.
+	Next(t time.Time) time.Time
+	String() string
+}
+
+// everySchedule runs a task at a fixed period.
+type everySchedule time.Duration
+
+// cronSchedule runs a task at the times matching a cron expression. Each
+// field is a bit set of the values it matches.
+type cronSchedule struct {
+	expression string
+	minutes    uint64
+	hours      uint64
+	days       uint64
+	months     uint64
+	weekdays   uint64
+	anyDay     bool
+	anyWeekday bool
+}
+
+// cronField describes the bounds and the names of the values of a field of a
+// cron expression.
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+// maxCronSearch is how far in the future Next looks for a time matching a
+// cron expression, which may never match, e.g. on February 30th.
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+var (
+	minuteField  = cronField{"minute", 0, 59, nil}
+	hourField    = cronField{"hour", 0, 23, nil}
+	dayField     = cronField{"day of month", 1, 31, nil}
+	monthField   = cronField{"month", 1, 12, map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}}
+	weekdayField = cronField{"day of week", 0, 7, map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}}
+)
+
+// cronMacros are the shorthands accepted in place of a cron expression.
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Every returns a Schedule running a task every period.
+func Every(period time.Duration) Schedule {
+	return everySchedule(period)
+}
+
+// Next implements Schedule.
+func (e everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// String implements Schedule.
+func (e everySchedule) String() string {
+	return "@every " + time.Duration(e).String()
+}
+
+// ParseSchedule parses a schedule. It is either "@every" followed by a
+// duration, a macro such as "@daily" or a standard cron expression with five
+// fields: minute, hour, day of month, month and day of week. Fields accept
+// "*", values, ranges, lists and steps, such as "*/15" or "1-5". Months and
+// days of week also accept their three letter English names. Cron
+// expressions are evaluated in UTC.
+func ParseSchedule(expression string) (Schedule, error) {
+	expression = strings.TrimSpace(expression)
+	if strings.HasPrefix(expression, "@every ") {
+		period, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expression, "@every ")))
+		if err != nil {
+			return nil, err
+		} else if period <= 0 {
+			return nil, fmt.Errorf("invalid schedule %q: the period must be positive", expression)
+		}
+		return Every(period), nil
+	}
+	fieldsExpression := expression
+	if macro, ok := cronMacros[strings.ToLower(expression)]; ok {
+		fieldsExpression = macro
+	}
+	fields := strings.Fields(fieldsExpression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: a cron expression has 5 fields", expression)
+	}
+	s := cronSchedule{
+		expression: expression,
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+	var err error
+	for i, f := range []struct {
+		bits  *uint64
+		field cronField
+	}{
+		{&s.minutes, minuteField},
+		{&s.hours, hourField},
+		{&s.days, dayField},
+		{&s.months, monthField},
+		{&s.weekdays, weekdayField},
+	} {
+		if *f.bits, err = f.field.parse(fields[i]); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %s", expression, err.Error())
+		}
+	}
+	// Sunday is both 0 and 7.
+	if s.weekdays&(1<<7) != 0 {
+		s.weekdays |= 1
+	}
+	return s, nil
+}
+
+// parse parses a field of a cron expression to the bit set of the values it
+// matches.
+func (f cronField) parse(expression string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expression, ",") {
+		rangeExpression, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %s field: %q", f.name, part)
+			}
+			rangeExpression = part[:i]
+		}
+		low, high := f.min, f.max
+		if rangeExpression != "*" {
+			bounds := strings.SplitN(rangeExpression, "-", 2)
+			var err error
+			if low, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if len(bounds) == 2 {
+				if high, err = f.value(bounds[1]); err != nil {
+					return 0, err
+				}
+			} else if step == 1 {
+				high = low
+			}
+		}
+		if low > high {
+			return 0, fmt.Errorf("invalid range in %s field: %q", f.name, part)
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value parses a value of a field of a cron expression, checking its bounds.
+func (f cronField) value(expression string) (int, error) {
+	if v, ok := f.names[strings.ToLower(expression)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(expression)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value in %s field: %q", f.name, expression)
+	}
+	return v, nil
+}
+
+// Next implements Schedule. It returns the zero time if the expression never
+// matches.
+func (s cronSchedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxCronSearch)
+	for t.Before(limit) {
+		if s.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		} else if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		} else if s.hours&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		} else if s.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+		} else {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay checks whether the day of t matches the expression. As in cron,
+// when both the day of month and the day of week are restricted, a day
+// matching either of them matches.
+func (s cronSchedule) matchesDay(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	if s.anyDay || s.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+// String implements Schedule.
+func (s cronSchedule) String() string {
+	return s.expression
+}
@@ -0,0 +1,73 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package periodic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleNext(t *testing.T) {
+	from := time.Date(2020, time.January, 31, 10, 17, 30, 0, time.UTC)
+	cases := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"*/15 * * * *", time.Date(2020, time.January, 31, 10, 30, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2020, time.January, 31, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 2 * * mon-fri", time.Date(2020, time.February, 3, 2, 30, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2020, time.February, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2020, time.February, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2020, time.February, 2, 0, 0, 0, 0, time.UTC)},
+		{"@every 10m", from.Add(10 * time.Minute)},
+	}
+	for _, c := range cases {
+		if s, err := ParseSchedule(c.expression); err != nil {
+			t.Errorf("Failed to parse %q: %s", c.expression, err.Error())
+		} else if next := s.Next(from); !next.Equal(c.expected) {
+			t.Errorf("Expected %q to run at %v, got %v", c.expression, c.expected, next)
+		}
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@every -1m",
+		"@every often",
+	} {
+		if _, err := ParseSchedule(expression); err == nil {
+			t.Errorf("Expected %q to be invalid", expression)
+		}
+	}
+}
+
+func TestParseScheduleNeverMatches(t *testing.T) {
+	s, err := ParseSchedule("0 0 30 feb *")
+	if err != nil {
+		t.Fatalf("Failed to parse schedule: %s", err.Error())
+	}
+	if next := s.Next(time.Now()); !next.IsZero() {
+		t.Errorf("Expected the schedule to never match, got %v", next)
+	}
+}
@@ -18,6 +18,7 @@ package periodic
 
 import (
 	"context"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -48,21 +49,75 @@ const (
 // Task is a task that can be scheduled.
 type Task func(context.Context) error
 
+// historySize is the number of runs reported in the history of a task.
+// Without a RunStore, the history is kept in memory, so it is lost when the
+// process stops and each process only knows about its own runs.
+const historySize = 50
+
+// RunStore persists the runs of the tasks, so that their history outlives
+// the process and is shared by every process running them.
+type RunStore interface {
+	// SaveRun saves a run of the task named task.
+	SaveRun(task string, r Run) error
+	// LatestRuns returns the n latest runs of the task named task, most
+	// recent first.
+	LatestRuns(task string, n int) ([]Run, error)
+}
+
+// Options are the options of a task registration. Jitter is the maximum
+// random delay added to each run, to spread the load of the tasks scheduled
+// at the same time. SkipIfRunning skips a run if the previous one is still
+// running.
+type Options struct {
+	Jitter        time.Duration
+	SkipIfRunning bool
+}
+
+// Run is a run of a periodic task. Skipped runs were not started because
+// the previous run was still running. BackendId identifies the process which
+// ran the task, when the run comes from a RunStore.
+type Run struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Error     string    `json:"error,omitempty"`
+	Skipped   bool      `json:"skipped,omitempty"`
+	BackendId string    `json:"backendId,omitempty"`
+}
+
+// TaskStatus is the status of a registered task, with its latest runs, most
+// recent first. LastSuccess is zero if the task never succeeded.
+type TaskStatus struct {
+	Name        string    `json:"name"`
+	Schedule    string    `json:"schedule"`
+	Running     bool      `json:"running"`
+	Next        time.Time `json:"next"`
+	LastSuccess time.Time `json:"lastSuccess"`
+	Runs        []Run     `json:"runs"`
+}
+
 // taskRegistration is a task registration that may or may not be ticking.
 type taskRegistration struct {
-	Name    string `json:"name"`
-	task    Task
-	Period  time.Duration
-	ticker  *time.Ticker
-	control chan taskSignal
+	Name        string `json:"name"`
+	task        Task
+	schedule    Schedule
+	options     Options
+	store       RunStore
+	control     chan taskSignal
+	mutex       sync.Mutex
+	running     int
+	next        time.Time
+	lastSuccess time.Time
+	history     []Run
 }
 
 // Scheduler runs registered periodic tasks. Its zero value is a valid
 // Scheduler that doesn't tick and has no registered task. It may be used in
-// parallel.
+// parallel. The runs of the tasks registered while Store is set are saved to
+// it, and their history is read from it.
 type Scheduler struct {
+	Store         RunStore
 	running       bool
-	registrations []taskRegistration
+	registrations []*taskRegistration
 	mutex         sync.RWMutex
 }
 
@@ -76,12 +131,21 @@ func (s *Scheduler) Ticking() bool {
 // Register registers a Task to the Scheduler to be run at period p. If the
 // Scheduler is ticking, the task starts ticking immediately.
 func (s *Scheduler) Register(t Task, p time.Duration, n string) {
-	r := taskRegistration{
-		task:   t,
-		Period: p,
-		Name:   n,
+	s.RegisterSchedule(t, Every(p), n, Options{})
+}
+
+// RegisterSchedule registers a Task to the Scheduler to be run on a
+// Schedule with options o. If the Scheduler is ticking, the task starts
+// ticking immediately.
+func (s *Scheduler) RegisterSchedule(t Task, sc Schedule, n string, o Options) {
+	r := &taskRegistration{
+		task:     t,
+		schedule: sc,
+		options:  o,
+		Name:     n,
 	}
 	s.mutex.Lock()
+	r.store = s.Store
 	defer s.mutex.Unlock()
 	if s.running {
 		r.start()
@@ -97,8 +161,8 @@ func (s *Scheduler) Start() {
 	if s.running {
 		jsonlog.Error("Attempt to start already started scheduler. Ignoring.", nil)
 	} else {
-		for i := range s.registrations {
-			s.registrations[i].start()
+		for _, r := range s.registrations {
+			r.start()
 		}
 		s.running = true
 	}
@@ -110,18 +174,32 @@ func (s *Scheduler) Stop() {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	if s.running {
-		for i := range s.registrations {
-			s.registrations[i].stop()
+		for _, r := range s.registrations {
+			r.stop()
 		}
 		s.running = false
 	}
 }
 
+// Status returns the status of the registered tasks. It fails if the
+// history of a task cannot be read from its RunStore.
+func (s *Scheduler) Status() ([]TaskStatus, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	res := make([]TaskStatus, len(s.registrations))
+	for i, r := range s.registrations {
+		var err error
+		if res[i], err = r.status(); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
 // start starts a taskRegistration, having it tick and run its task
 // periodically.
 func (t *taskRegistration) start() {
 	if t.control == nil {
-		t.ticker = time.NewTicker(t.Period)
 		t.control = make(chan taskSignal)
 		go t.tick()
 	} else {
@@ -129,39 +207,152 @@ func (t *taskRegistration) start() {
 	}
 }
 
-// run runs the taskRegistration's task in the current goroutine.
+// run runs the taskRegistration's task in the current goroutine, recording
+// the run in its history.
 func (t *taskRegistration) run(d time.Time) error {
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, TaskTime, d)
-	return t.task(ctx)
+	run := Run{Start: time.Now()}
+	err := t.task(ctx)
+	run.End = time.Now()
+	if err != nil {
+		run.Error = err.Error()
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.running--
+	if err == nil {
+		t.lastSuccess = run.End
+	}
+	t.record(run)
+	return err
 }
 
-// getTickerChan gets the taskRegistration's ticker channel, creating it if it
-// doesn’t exist yet. This is the channel where a time.Ticker outputs to run
-// the periodic tasks.
-func (t *taskRegistration) getTickerChan() <-chan time.Time {
-	c := t.ticker
-	if c == nil {
-		return nil
-	} else {
-		return c.C
+// launch starts a run of the taskRegistration's task in its own goroutine,
+// unless it must be skipped because the previous one is still running.
+func (t *taskRegistration) launch(d time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.options.SkipIfRunning && t.running > 0 {
+		now := time.Now()
+		t.record(Run{Start: now, End: now, Skipped: true})
+		jsonlog.Warning("Skipped periodic task as its previous run is still running.", t.Name)
+		return
 	}
+	t.running++
+	go t.run(d)
 }
 
-// tick starts periodic tasks in response to ticks from t.ticker. The tasks are
-// started in their own goroutine using t.run.
+// record adds a run to the history of the taskRegistration, saving it to
+// its RunStore if it has one. The caller must hold t.mutex.
+func (t *taskRegistration) record(r Run) {
+	if t.store != nil {
+		go t.save(r)
+		return
+	}
+	t.history = append(t.history, r)
+	if len(t.history) > historySize {
+		t.history = t.history[len(t.history)-historySize:]
+	}
+}
+
+// save saves a run to the RunStore of the taskRegistration, logging any
+// error since the run itself is over.
+func (t *taskRegistration) save(r Run) {
+	if err := t.store.SaveRun(t.Name, r); err != nil {
+		jsonlog.Error("Failed to save periodic task run.", map[string]interface{}{
+			"task":  t.Name,
+			"error": err.Error(),
+		})
+	}
+}
+
+// runs returns the latest runs of the taskRegistration, most recent first.
+func (t *taskRegistration) runs() ([]Run, error) {
+	if t.store != nil {
+		return t.store.LatestRuns(t.Name, historySize)
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	runs := make([]Run, len(t.history))
+	for i, r := range t.history {
+		runs[len(runs)-1-i] = r
+	}
+	return runs, nil
+}
+
+// status returns the status of the taskRegistration. With a RunStore, the
+// last success also accounts for the runs of the other processes.
+func (t *taskRegistration) status() (TaskStatus, error) {
+	runs, err := t.runs()
+	if err != nil {
+		return TaskStatus{}, err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	status := TaskStatus{
+		Name:        t.Name,
+		Schedule:    t.schedule.String(),
+		Running:     t.running > 0,
+		Next:        t.next,
+		LastSuccess: t.lastSuccess,
+		Runs:        runs,
+	}
+	for _, r := range runs {
+		if !r.Skipped && r.Error == "" && r.End.After(status.LastSuccess) {
+			status.LastSuccess = r.End
+		}
+	}
+	return status, nil
+}
+
+// nextRun returns the time of the run following the one planned at
+// previous, skipping the runs which should already have happened.
+func (t *taskRegistration) nextRun(previous time.Time) time.Time {
+	next := t.schedule.Next(previous)
+	if now := time.Now(); !next.IsZero() && next.Before(now) {
+		next = t.schedule.Next(now)
+	}
+	t.mutex.Lock()
+	t.next = next
+	t.mutex.Unlock()
+	return next
+}
+
+// jitter returns a random delay up to the taskRegistration's jitter.
+func (t *taskRegistration) jitter() time.Duration {
+	if t.options.Jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(t.options.Jitter)))
+}
+
+// tick starts periodic tasks when their schedule is due. The tasks are
+// started in their own goroutine using t.launch. A schedule which never
+// comes due leaves the registration idle until it is stopped.
 func (t *taskRegistration) tick() {
+	var timer <-chan time.Time
+	next := t.nextRun(time.Now())
+	if !next.IsZero() {
+		timer = time.After(time.Until(next) + t.jitter())
+	}
 	for {
 		select {
-		case d := <-t.getTickerChan():
-			go t.run(d)
+		case <-timer:
+			t.launch(next)
+			if next = t.nextRun(next); next.IsZero() {
+				timer = nil
+			} else {
+				timer = time.After(time.Until(next) + t.jitter())
+			}
 		case s := <-t.control:
 			switch s {
 			case taskStop:
 				close(t.control)
 				t.control = nil
-				t.ticker.Stop()
-				t.ticker = nil
+				t.mutex.Lock()
+				t.next = time.Time{}
+				t.mutex.Unlock()
 				return
 			}
 		}
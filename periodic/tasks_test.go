@@ -17,6 +17,7 @@ package periodic
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -110,3 +111,102 @@ out:
 		t.Errorf("Expected task count to be %#v, is %#v.", be, b)
 	}
 }
+
+func TestSkipIfRunning(t *testing.T) {
+	var s Scheduler
+	var runs int
+	c := make(chan int)
+	release := make(chan struct{})
+	s.RegisterSchedule(
+		func(_ context.Context) error {
+			c <- 0
+			<-release
+			return nil
+		},
+		Every(50*time.Millisecond),
+		"Slow task",
+		Options{SkipIfRunning: true},
+	)
+	s.Start()
+	e := time.After(280 * time.Millisecond)
+out:
+	for {
+		select {
+		case <-c:
+			runs++
+		case <-e:
+			break out
+		}
+	}
+	status, err := s.Status()
+	s.Stop()
+	close(release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs != 1 {
+		t.Errorf("Task should run %d times, ran %d times.", 1, runs)
+	}
+	if len(status) != 1 || !status[0].Running || len(status[0].Runs) < 3 || !status[0].Runs[0].Skipped {
+		t.Errorf("Expected the task to be running with skipped runs, got %#v.", status)
+	}
+}
+
+func TestRunHistory(t *testing.T) {
+	var s Scheduler
+	c := make(chan int)
+	s.Register(messageTask(c, 0), 50*time.Millisecond, "Fast task")
+	s.Start()
+	<-c
+	time.Sleep(10 * time.Millisecond)
+	status, err := s.Status()
+	s.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 1 || len(status[0].Runs) != 1 || status[0].LastSuccess.IsZero() || status[0].Runs[0].Error != "" {
+		t.Errorf("Expected a successful run in the history, got %#v.", status)
+	}
+}
+
+// memoryRunStore is a RunStore shared by several schedulers in the tests.
+type memoryRunStore struct {
+	mutex sync.Mutex
+	runs  map[string][]Run
+}
+
+func (m *memoryRunStore) SaveRun(task string, r Run) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.runs[task] = append([]Run{r}, m.runs[task]...)
+	return nil
+}
+
+func (m *memoryRunStore) LatestRuns(task string, n int) ([]Run, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	runs := m.runs[task]
+	if len(runs) > n {
+		runs = runs[:n]
+	}
+	return append([]Run(nil), runs...), nil
+}
+
+func TestRunStore(t *testing.T) {
+	store := &memoryRunStore{runs: map[string][]Run{}}
+	store.SaveRun("Fast task", Run{Start: time.Now(), End: time.Now(), BackendId: "other"})
+	s := Scheduler{Store: store}
+	c := make(chan int)
+	s.Register(messageTask(c, 0), 50*time.Millisecond, "Fast task")
+	s.Start()
+	<-c
+	time.Sleep(10 * time.Millisecond)
+	status, err := s.Status()
+	s.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 1 || len(status[0].Runs) != 2 || status[0].Runs[1].BackendId != "other" {
+		t.Errorf("Expected the runs of both processes in the history, got %#v.", status)
+	}
+}
@@ -48,7 +48,7 @@ func init() {
 			db.RequestTransaction{Db: db.Db},
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
 			routes.QueryArgs(pluginsQueryArgs),
-			cache.UsersCache{},
+			cache.UsersCache{Events: []string{cache.EventPluginResults}},
 			routes.Documentation{
 				Summary:     "get the latests plugins results",
 				Description: "Responds with the latests plugins results for the account(s) specified in the request",
@@ -43,4 +43,8 @@ var modules = []module{
 	ebsUsageReportModule,
 	instanceCountUsageReportModule,
 	riEc2ReportModule,
+	savingsPlansReportModule,
+	organizationUnitsReportModule,
+	costCategoriesReportModule,
+	allocatedCostsReportModule,
 }
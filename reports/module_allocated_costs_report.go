@@ -0,0 +1,193 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/aws"
+	"github.com/trackit/trackit/aws/s3"
+	"github.com/trackit/trackit/aws/usageReports/history"
+	"github.com/trackit/trackit/costs"
+	"github.com/trackit/trackit/costs/redistribution"
+	"github.com/trackit/trackit/es"
+)
+
+const allocatedCostsReportSheetName = "Allocated Costs"
+
+var allocatedCostsReportModule = module{
+	Name:          "Allocated Costs",
+	SheetName:     allocatedCostsReportSheetName,
+	ErrorName:     "allocatedCostsReportError",
+	GenerateSheet: generateAllocatedCostsReportSheet,
+}
+
+// allocatedCostsReport holds the raw and allocated costs of last month split by
+// the target of redistribution rules.
+type allocatedCostsReport struct {
+	Target    string
+	Raw       map[string]float64
+	Allocated map[string]float64
+}
+
+// generateAllocatedCostsReportSheet will generate a sheet with the raw and allocated costs of last
+// month, split by each target of the redistribution rules of the owner of the accounts. No sheet is
+// generated if they have none.
+func generateAllocatedCostsReportSheet(ctx context.Context, aas []aws.AwsAccount, date time.Time, tx *sql.Tx, file *excelize.File) (err error) {
+	if date.IsZero() {
+		date, _ = history.GetHistoryDate()
+	}
+	if len(aas) == 0 {
+		return
+	}
+	rules, err := redistribution.GetRules(tx, aas[0].UserId)
+	if err != nil || len(rules) == 0 {
+		return
+	}
+	data, err := allocatedCostsReportGetData(ctx, aas, date, rules)
+	if err == nil {
+		allocatedCostsReportInsertDataInSheet(file, data)
+	}
+	return
+}
+
+func allocatedCostsReportGetData(ctx context.Context, aas []aws.AwsAccount, dateBegin time.Time,
+	rules []redistribution.Rule) (reports []allocatedCostsReport, err error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	indexes := make(map[string]bool)
+	for _, aa := range aas {
+		indexes[es.IndexNameForUserId(aa.UserId, s3.IndexPrefixLineItem)] = true
+	}
+	parsedParams := costs.EsQueryParams{
+		DateBegin:       dateBegin,
+		DateEnd:         time.Date(dateBegin.Year(), dateBegin.Month()+1, 0, 23, 59, 59, 999999999, dateBegin.Location()).UTC(),
+		AccountList:     getAwsIdentities(aas),
+		Redistributions: rules,
+	}
+	for index := range indexes {
+		parsedParams.IndexList = append(parsedParams.IndexList, index)
+	}
+	targets := make(map[string]bool)
+	for _, rule := range rules {
+		if targets[rule.Target] {
+			continue
+		}
+		targets[rule.Target] = true
+		parsedParams.AggregationParams = []string{rule.Target}
+		raw, _, err := costs.MakeElasticSearchRequestAndParseIt(ctx, parsedParams)
+		if err != nil {
+			return reports, allocatedCostsReportError(logger, err, aas, rule.Target, dateBegin)
+		}
+		allocated, _, err := costs.GetAllocatedCosts(ctx, parsedParams)
+		if err != nil {
+			return reports, allocatedCostsReportError(logger, err, aas, rule.Target, dateBegin)
+		}
+		reports = append(reports, allocatedCostsReport{
+			Target:    rule.Target,
+			Raw:       costsByKey(raw),
+			Allocated: costsByKey(allocated),
+		})
+	}
+	return
+}
+
+func allocatedCostsReportError(logger jsonlog.Logger, err error, aas []aws.AwsAccount, target string, date time.Time) error {
+	logger.Error("An error occurred while generating an Allocated Costs Report", map[string]interface{}{
+		"error":    err,
+		"accounts": aas,
+		"target":   target,
+		"date":     date,
+	})
+	return err
+}
+
+// costsByKey returns the costs of the buckets of a costs document split by a
+// single criterion.
+func costsByKey(document es.SimplifiedCostsDocument) map[string]float64 {
+	res := make(map[string]float64)
+	for _, row := range document.Rows() {
+		res[strings.Join(row.Keys, "/")] += row.Value
+	}
+	return res
+}
+
+func allocatedCostsReportInsertDataInSheet(file *excelize.File, data []allocatedCostsReport) {
+	file.NewSheet(allocatedCostsReportSheetName)
+	allocatedCostsReportGenerateHeader(file)
+	line := 2
+	for _, report := range data {
+		first := line
+		for _, key := range sortedCostsKeys(report.Raw, report.Allocated) {
+			cells := cells{
+				newCell(report.Target, "A"+strconv.Itoa(line)),
+				newCell(key, "B"+strconv.Itoa(line)),
+				newCell(report.Raw[key], "C"+strconv.Itoa(line)).addStyles("price"),
+				newCell(report.Allocated[key], "D"+strconv.Itoa(line)).addStyles("price"),
+			}
+			cells.addStyles("borders", "centerText").setValues(file, allocatedCostsReportSheetName)
+			line++
+		}
+		total := cells{
+			newCell(report.Target, "A"+strconv.Itoa(line)),
+			newCell("Total", "B"+strconv.Itoa(line)),
+			newFormula(fmt.Sprintf("SUM(C%d:C%d)", first, line-1), "C"+strconv.Itoa(line)).addStyles("price"),
+			newFormula(fmt.Sprintf("SUM(D%d:D%d)", first, line-1), "D"+strconv.Itoa(line)).addStyles("price"),
+		}
+		total.addStyles("borders", "bold", "centerText").setValues(file, allocatedCostsReportSheetName)
+		line++
+	}
+}
+
+// sortedCostsKeys returns the sorted keys of costs maps.
+func sortedCostsKeys(costs ...map[string]float64) []string {
+	set := make(map[string]bool)
+	for _, c := range costs {
+		for key := range c {
+			set[key] = true
+		}
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func allocatedCostsReportGenerateHeader(file *excelize.File) {
+	header := cells{
+		newCell("Allocated to", "A1"),
+		newCell("Target", "B1"),
+		newCell("Raw cost", "C1"),
+		newCell("Allocated cost", "D1"),
+	}
+	header.addStyles("borders", "bold", "centerText").setValues(file, allocatedCostsReportSheetName)
+	columns := columnsWidth{
+		newColumnWidth("A", 20),
+		newColumnWidth("B", 30),
+		newColumnWidth("C", 20),
+		newColumnWidth("D", 20),
+	}
+	columns.setValues(file, allocatedCostsReportSheetName)
+}
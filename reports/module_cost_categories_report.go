@@ -0,0 +1,138 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/aws"
+	"github.com/trackit/trackit/aws/s3"
+	"github.com/trackit/trackit/aws/usageReports/history"
+	"github.com/trackit/trackit/costs/categories"
+	"github.com/trackit/trackit/es"
+)
+
+const costCategoriesReportSheetName = "Cost Categories"
+
+var costCategoriesReportModule = module{
+	Name:          "Cost Categories",
+	SheetName:     costCategoriesReportSheetName,
+	ErrorName:     "costCategoriesReportError",
+	GenerateSheet: generateCostCategoriesReportSheet,
+}
+
+// costCategoryReport is the cost of each value of a cost category.
+type costCategoryReport struct {
+	Category categories.Category
+	Costs    map[string]float64
+}
+
+// generateCostCategoriesReportSheet will generate a sheet with the costs of last month split by the
+// values of the cost categories of the owner of the accounts. No sheet is generated if they have none.
+func generateCostCategoriesReportSheet(ctx context.Context, aas []aws.AwsAccount, date time.Time, tx *sql.Tx, file *excelize.File) (err error) {
+	if date.IsZero() {
+		date, _ = history.GetHistoryDate()
+	}
+	if len(aas) == 0 {
+		return
+	}
+	userCategories, err := categories.GetCategories(tx, aas[0].UserId)
+	if err != nil || len(userCategories) == 0 {
+		return
+	}
+	data, err := costCategoriesReportGetData(ctx, aas, date, userCategories)
+	if err == nil {
+		costCategoriesReportInsertDataInSheet(file, data)
+	}
+	return
+}
+
+func costCategoriesReportGetData(ctx context.Context, aas []aws.AwsAccount, dateBegin time.Time,
+	userCategories []categories.Category) (reports []costCategoryReport, err error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	dateEnd := time.Date(dateBegin.Year(), dateBegin.Month()+1, 0, 23, 59, 59, 999999999, dateBegin.Location()).UTC()
+	indexes := make(map[string]bool)
+	for _, aa := range aas {
+		indexes[es.IndexNameForUserId(aa.UserId, s3.IndexPrefixLineItem)] = true
+	}
+	costField, _ := s3.GetCostTypeField(s3.DefaultCostType)
+	indexList := make([]string, 0, len(indexes))
+	for index := range indexes {
+		indexList = append(indexList, index)
+	}
+	for _, category := range userCategories {
+		report := costCategoryReport{Category: category}
+		report.Costs, err = categories.GetCosts(ctx, category, getAwsIdentities(aas), dateBegin, dateEnd, costField, es.Client, strings.Join(indexList, ","))
+		if err != nil {
+			logger.Error("An error occurred while generating a Cost Categories Report", map[string]interface{}{
+				"error":    err,
+				"accounts": aas,
+				"category": category.Name,
+				"date":     dateBegin,
+			})
+			return
+		}
+		reports = append(reports, report)
+	}
+	return
+}
+
+func costCategoriesReportInsertDataInSheet(file *excelize.File, data []costCategoryReport) {
+	file.NewSheet(costCategoriesReportSheetName)
+	costCategoriesReportGenerateHeader(file)
+	line := 2
+	for _, report := range data {
+		first := line
+		for _, value := range report.Category.Values() {
+			cells := cells{
+				newCell(report.Category.Name, "A"+strconv.Itoa(line)),
+				newCell(value, "B"+strconv.Itoa(line)),
+				newCell(report.Costs[value], "C"+strconv.Itoa(line)).addStyles("price"),
+			}
+			cells.addStyles("borders", "centerText").setValues(file, costCategoriesReportSheetName)
+			line++
+		}
+		total := cells{
+			newCell(report.Category.Name, "A"+strconv.Itoa(line)),
+			newCell("Total", "B"+strconv.Itoa(line)),
+			newFormula(fmt.Sprintf("SUM(C%d:C%d)", first, line-1), "C"+strconv.Itoa(line)).addStyles("price"),
+		}
+		total.addStyles("borders", "bold", "centerText").setValues(file, costCategoriesReportSheetName)
+		line++
+	}
+}
+
+func costCategoriesReportGenerateHeader(file *excelize.File) {
+	header := cells{
+		newCell("Cost Category", "A1"),
+		newCell("Value", "B1"),
+		newCell("Cost", "C1"),
+	}
+	header.addStyles("borders", "bold", "centerText").setValues(file, costCategoriesReportSheetName)
+	columns := columnsWidth{
+		newColumnWidth("A", 30),
+		newColumnWidth("B", 30),
+		newColumnWidth("C", 20),
+	}
+	columns.setValues(file, costCategoriesReportSheetName)
+}
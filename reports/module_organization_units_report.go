@@ -0,0 +1,161 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/aws"
+	"github.com/trackit/trackit/aws/usageReports/history"
+	"github.com/trackit/trackit/costs/diff"
+)
+
+const organizationUnitsReportSheetName = "Organizational Units"
+
+var organizationUnitsReportModule = module{
+	Name:          "Organizational Units",
+	SheetName:     organizationUnitsReportSheetName,
+	ErrorName:     "organizationUnitsReportError",
+	GenerateSheet: generateOrganizationUnitsReportSheet,
+}
+
+// organizationUnitCost is the cost of the accounts of the report which are
+// in an organizational unit.
+type organizationUnitCost struct {
+	Unit     aws.OrganizationUnit
+	Accounts int
+	Cost     float64
+}
+
+// generateOrganizationUnitsReportSheet will generate a sheet with the costs of last month rolled up
+// by organizational unit. The units are the ones directly in the deepest unit holding all of the
+// accounts of the report, so that a report restricted to a unit is rolled up by its own units.
+// No sheet is generated for a single account or if the accounts are not in an AWS organization.
+func generateOrganizationUnitsReportSheet(ctx context.Context, aas []aws.AwsAccount, date time.Time, tx *sql.Tx, file *excelize.File) (err error) {
+	var dateRange diff.DateRange
+	if date.IsZero() {
+		dateRange.Begin, dateRange.End = history.GetHistoryDate()
+	} else {
+		dateRange = diff.DateRange{
+			Begin: date,
+			End:   time.Date(date.Year(), date.Month()+1, 0, 23, 59, 59, 999999999, date.Location()).UTC(),
+		}
+	}
+	if len(aas) < 2 {
+		return
+	}
+	payer := aas[0]
+	if payer.ParentId.Valid {
+		payer = aws.AwsAccount{Id: int(payer.ParentId.Int64)}
+	}
+	organization, err := aws.GetOrganization(tx, payer)
+	if err != nil {
+		return
+	}
+	unit, ok := organization.LowestCommonUnit(getAwsIdentities(aas))
+	if !ok {
+		return
+	}
+	data, err := organizationUnitsReportGetData(ctx, aas, organization, unit, dateRange)
+	if err == nil {
+		organizationUnitsReportInsertDataInSheet(file, unit, data)
+	}
+	return
+}
+
+func organizationUnitsReportGetData(ctx context.Context, aas []aws.AwsAccount, organization aws.Organization,
+	unit aws.OrganizationUnit, dateRange diff.DateRange) (data []organizationUnitCost, err error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	accountCosts := make(map[string]float64, len(aas))
+	for _, account := range aas {
+		report, err := diff.TaskDiffData(ctx, account, dateRange, "month")
+		if err != nil {
+			logger.Error("An error occurred while generating an Organizational Units Report", map[string]interface{}{
+				"error":     err,
+				"account":   account,
+				"dateStart": dateRange.Begin,
+				"dateEnd":   dateRange.End,
+			})
+			return data, err
+		}
+		for _, values := range report {
+			for _, value := range values {
+				accountCosts[account.AwsIdentity] += value.Cost
+			}
+		}
+	}
+	for unitId, accounts := range organization.UnitBuckets(unit.Id) {
+		unitCost := organizationUnitCost{}
+		unitCost.Unit, _ = organization.Unit(unitId)
+		for _, account := range accounts {
+			if cost, ok := accountCosts[account]; ok {
+				unitCost.Accounts++
+				unitCost.Cost += cost
+			}
+		}
+		if unitCost.Accounts > 0 {
+			data = append(data, unitCost)
+		}
+	}
+	sort.Slice(data, func(i, j int) bool {
+		return data[i].Unit.Name < data[j].Unit.Name
+	})
+	return
+}
+
+func organizationUnitsReportInsertDataInSheet(file *excelize.File, unit aws.OrganizationUnit, data []organizationUnitCost) {
+	file.NewSheet(organizationUnitsReportSheetName)
+	organizationUnitsReportGenerateHeader(file, unit)
+	line := 3
+	for _, unitCost := range data {
+		cells := cells{
+			newCell(fmt.Sprintf("%s (%s)", unitCost.Unit.Name, unitCost.Unit.Id), "A"+strconv.Itoa(line)),
+			newCell(unitCost.Accounts, "B"+strconv.Itoa(line)),
+			newCell(unitCost.Cost, "C"+strconv.Itoa(line)).addStyles("price"),
+		}
+		cells.addStyles("borders", "centerText").setValues(file, organizationUnitsReportSheetName)
+		line++
+	}
+	total := cells{
+		newCell("Total", "A"+strconv.Itoa(line)),
+		newFormula(fmt.Sprintf("SUM(B3:B%d)", line-1), "B"+strconv.Itoa(line)),
+		newFormula(fmt.Sprintf("SUM(C3:C%d)", line-1), "C"+strconv.Itoa(line)).addStyles("price"),
+	}
+	total.addStyles("borders", "bold", "centerText").setValues(file, organizationUnitsReportSheetName)
+}
+
+func organizationUnitsReportGenerateHeader(file *excelize.File, unit aws.OrganizationUnit) {
+	header := cells{
+		newCell(fmt.Sprintf("Costs of %s (%s)", unit.Name, unit.Id), "A1").mergeTo("C1"),
+		newCell("Organizational unit", "A2"),
+		newCell("Accounts", "B2"),
+		newCell("Cost", "C2"),
+	}
+	header.addStyles("borders", "bold", "centerText").setValues(file, organizationUnitsReportSheetName)
+	columns := columnsWidth{
+		newColumnWidth("A", 50),
+		newColumnWidth("B", 12.5),
+		newColumnWidth("C", 20),
+	}
+	columns.setValues(file, organizationUnitsReportSheetName)
+}
@@ -0,0 +1,151 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/aws"
+	"github.com/trackit/trackit/aws/usageReports/history"
+	"github.com/trackit/trackit/savingsPlans"
+	"github.com/trackit/trackit/users"
+)
+
+const savingsPlansReportSheetName = "Savings Plans Recommendations"
+
+var savingsPlansReportModule = module{
+	Name:          "Savings Plans Recommendations",
+	SheetName:     savingsPlansReportSheetName,
+	ErrorName:     "savingsPlansReportError",
+	GenerateSheet: generateSavingsPlansReportSheet,
+}
+
+// generateSavingsPlansReportSheet will generate a sheet with the Savings Plans
+// recommendations for the usage of the month of the given date
+func generateSavingsPlansReportSheet(ctx context.Context, aas []aws.AwsAccount, date time.Time, tx *sql.Tx, file *excelize.File) (err error) {
+	if date.IsZero() {
+		date, _ = history.GetHistoryDate()
+	}
+	return savingsPlansReportGenerateSheet(ctx, aas, date, tx, file)
+}
+
+func savingsPlansReportGenerateSheet(ctx context.Context, aas []aws.AwsAccount, date time.Time, tx *sql.Tx, file *excelize.File) (err error) {
+	data, err := savingsPlansReportGetData(ctx, aas, date, tx)
+	if err == nil {
+		return savingsPlansReportInsertDataInSheet(file, data)
+	}
+	return
+}
+
+func savingsPlansReportGetData(ctx context.Context, aas []aws.AwsAccount, date time.Time, tx *sql.Tx) (res savingsPlans.Response, err error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	identities := getAwsIdentities(aas)
+	user, err := users.GetUserWithId(tx, aas[0].UserId)
+	if err != nil {
+		return
+	}
+	dateBegin := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	parameters := savingsPlans.QueryParams{
+		AccountList: identities,
+		DateBegin:   dateBegin,
+		DateEnd:     dateBegin.AddDate(0, 1, 0),
+	}
+	logger.Debug("Getting Savings Plans Recommendations for accounts", map[string]interface{}{
+		"accounts": aas,
+		"date":     date,
+	})
+	_, res, err = savingsPlans.GetRecommendations(ctx, parameters, user, tx)
+	if err != nil {
+		logger.Error("An error occurred while generating Savings Plans Recommendations", map[string]interface{}{
+			"error":    err,
+			"accounts": aas,
+			"date":     date,
+		})
+	}
+	return
+}
+
+func savingsPlansReportInsertDataInSheet(file *excelize.File, data savingsPlans.Response) (err error) {
+	file.NewSheet(savingsPlansReportSheetName)
+	savingsPlansReportGenerateHeader(file, data.EstimatedRates)
+	line := 3
+	for _, rec := range data.Options {
+		cells := cells{
+			newCell(rec.SavingsPlanType, "A"+strconv.Itoa(line)),
+			newCell(rec.Term, "B"+strconv.Itoa(line)),
+			newCell(rec.PaymentOption, "C"+strconv.Itoa(line)),
+			newCell(rec.DiscountRate, "D"+strconv.Itoa(line)).addStyles("percentage"),
+			newCell(rec.HourlyCommitment, "E"+strconv.Itoa(line)).addStyles("price"),
+			newCell(rec.UpfrontCost, "F"+strconv.Itoa(line)).addStyles("price"),
+			newCell(rec.RecurringHourlyCost, "G"+strconv.Itoa(line)).addStyles("price"),
+			newCell(rec.MonthlyOnDemandCost, "H"+strconv.Itoa(line)).addStyles("price"),
+			newCell(rec.MonthlyCost, "I"+strconv.Itoa(line)).addStyles("price"),
+			newCell(rec.MonthlySavings, "J"+strconv.Itoa(line)).addStyles("price"),
+			newCell(formatMetricPercentage(rec.SavingsPercentage), "K"+strconv.Itoa(line)).addStyles("percentage"),
+			newCell(formatMetricPercentage(rec.Utilization), "L"+strconv.Itoa(line)).addStyles("percentage"),
+			newCell(formatMetricPercentage(rec.Coverage), "M"+strconv.Itoa(line)).addStyles("percentage"),
+			newCell(rec.BreakEvenMonths, "N"+strconv.Itoa(line)),
+		}
+		cells.addStyles("borders", "centerText").setValues(file, savingsPlansReportSheetName)
+		line++
+	}
+	return
+}
+
+func savingsPlansReportGenerateHeader(file *excelize.File, estimatedRates bool) {
+	discount := "Discount"
+	if estimatedRates {
+		discount = "Discount (estimated)"
+	}
+	header := cells{
+		newCell("Savings Plan", "A1").mergeTo("D1"),
+		newCell("Type", "A2"),
+		newCell("Term", "B2"),
+		newCell("Payment Option", "C2"),
+		newCell(discount, "D2"),
+		newCell("Commitment", "E1").mergeTo("G1"),
+		newCell("Hourly", "E2"),
+		newCell("Upfront Cost", "F2"),
+		newCell("Recurring Hourly Cost", "G2"),
+		newCell("Monthly Projection", "H1").mergeTo("K1"),
+		newCell("On Demand Cost", "H2"),
+		newCell("Cost", "I2"),
+		newCell("Savings", "J2"),
+		newCell("Savings (%)", "K2"),
+		newCell("Utilization", "L1").mergeTo("L2"),
+		newCell("Coverage", "M1").mergeTo("M2"),
+		newCell("Break Even (months)", "N1").mergeTo("N2"),
+	}
+	header.addStyles("borders", "bold", "centerText").setValues(file, savingsPlansReportSheetName)
+	columns := columnsWidth{
+		newColumnWidth("A", 25),
+		newColumnWidth("B", 7.5),
+		newColumnWidth("C", 15),
+		newColumnWidth("D", 10),
+		newColumnWidth("E", 10).toColumn("F"),
+		newColumnWidth("G", 20),
+		newColumnWidth("H", 15).toColumn("J"),
+		newColumnWidth("K", 12.5).toColumn("M"),
+		newColumnWidth("N", 20),
+	}
+	columns.setValues(file, savingsPlansReportSheetName)
+	return
+}
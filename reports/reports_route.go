@@ -26,6 +26,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 
+	"github.com/trackit/trackit/audit"
 	"github.com/trackit/trackit/awsSession"
 	"github.com/trackit/trackit/config"
 	"github.com/trackit/trackit/db"
@@ -62,24 +63,10 @@ func init() {
 	}.H().Register("/report")
 }
 
+// isUserAccount checks whether the user can download the reports of an AWS
+// account.
 func isUserAccount(tx *sql.Tx, user users.User, aa int) (bool, error) {
-	aaDB, err := models.AwsAccountByID(tx, aa)
-	if err != nil {
-		return false, err
-	}
-	if aaDB.UserID == user.Id {
-		return true, nil
-	}
-	saDB, err := models.SharedAccountsByAccountID(tx, aa)
-	if err != nil {
-		return false, err
-	}
-	for _, key := range saDB {
-		if key.UserID == user.Id {
-			return true, nil
-		}
-	}
-	return false, nil
+	return users.HasAccountPermission(tx, user, aa, users.PermissionDownloadReports)
 }
 
 // getAwsReports returns the list of reports based on the query params, in JSON format.
@@ -152,5 +139,15 @@ func getAwsReportsDownload(request *http.Request, a routes.Arguments) (int, inte
 	if err != nil {
 		return http.StatusNotFound, fmt.Errorf("The specified key does not exist")
 	}
+	if dbAwsAccount, err := models.AwsAccountByID(tx, aa); err != nil {
+		return http.StatusInternalServerError, err
+	} else if err := audit.Record(request, tx, user, audit.Entry{
+		Action:     audit.ActionReportDownload,
+		TargetType: audit.TargetReport,
+		TargetId:   reportPath,
+		OwnerId:    dbAwsAccount.UserID,
+	}); err != nil {
+		return http.StatusInternalServerError, err
+	}
 	return http.StatusOK, Report{buff.Bytes(), reportName}
 }
@@ -96,6 +96,18 @@ var (
 		Optional:    false,
 	}
 
+	// CostTypeQueryArg allows to get the type of cost to aggregate in the URL
+	// Parameters with routes.QueryArgs. This type will be a string stored in
+	// the routes.Arguments map with itself for key.
+	// CostTypeQueryArg is optional and will not panic if no query argument is
+	// found.
+	CostTypeQueryArg = QueryArg{
+		Name:        "costType",
+		Type:        QueryArgString{},
+		Description: "Type of cost to aggregate. Possible values are unblended, blended, amortized, net. Defaults to unblended",
+		Optional:    true,
+	}
+
 	// DetailedQueryArg allows to get detailed
 	// Parameters with routes.QueryArgs. This type will be a
 	// bool stored in the routes.Arguments map with itself for key.
@@ -134,4 +146,191 @@ var (
 		Type:        QueryArgInt{},
 		Description: "The DB ID of the sharing",
 	}
+
+	// BudgetIdQueryArg allows to get the DB id for a budget in the URL Parameters
+	// with routes.QueryArgs. This budget ID will be an int stored
+	// in the routes.Arguments map with itself for key.
+	BudgetIdQueryArg = QueryArg{
+		Name:        "budget-id",
+		Type:        QueryArgInt{},
+		Description: "The DB ID of the budget",
+	}
+
+	// NotificationChannelIdQueryArg allows to get the DB id for a notification channel
+	// in the URL Parameters with routes.QueryArgs. This notification channel ID will be
+	// an int stored in the routes.Arguments map with itself for key.
+	NotificationChannelIdQueryArg = QueryArg{
+		Name:        "channel-id",
+		Type:        QueryArgInt{},
+		Description: "The DB ID of the notification channel",
+	}
+
+	// JobIdQueryArg allows to get the DB id for a job in the URL Parameters
+	// with routes.QueryArgs. This job ID will be an int stored
+	// in the routes.Arguments map with itself for key.
+	JobIdQueryArg = QueryArg{
+		Name:        "job-id",
+		Type:        QueryArgInt{},
+		Description: "The DB ID of the job",
+	}
+
+	// BillingSourceIdQueryArg allows to get the DB id for a billing source
+	// in the URL Parameters with routes.QueryArgs. This billing source ID will
+	// be an int stored in the routes.Arguments map with itself for key.
+	BillingSourceIdQueryArg = QueryArg{
+		Name:        "billing-source-id",
+		Type:        QueryArgInt{},
+		Description: "The DB ID of the billing source",
+	}
+
+	// ApiKeyIdQueryArg allows to get the DB id for an API key in the URL
+	// Parameters with routes.QueryArgs. This API key ID will be an int stored
+	// in the routes.Arguments map with itself for key.
+	ApiKeyIdQueryArg = QueryArg{
+		Name:        "api-key-id",
+		Type:        QueryArgInt{},
+		Description: "The DB ID of the API key",
+	}
+
+	// SessionIdOptionalQueryArg allows to get the DB id for a user session in
+	// the URL Parameters with routes.QueryArgs. This session ID will be an int
+	// stored in the routes.Arguments map with itself for key.
+	// SessionIdOptionalQueryArg is optional and will not panic if no query
+	// argument is found.
+	SessionIdOptionalQueryArg = QueryArg{
+		Name:        "session-id",
+		Type:        QueryArgInt{},
+		Description: "The DB ID of the session",
+		Optional:    true,
+	}
+
+	// SsoProviderIdQueryArg allows to get the DB id for an SSO provider in the
+	// URL Parameters with routes.QueryArgs. This SSO provider ID will be an int
+	// stored in the routes.Arguments map with itself for key.
+	SsoProviderIdQueryArg = QueryArg{
+		Name:        "provider-id",
+		Type:        QueryArgInt{},
+		Description: "The DB ID of the SSO provider",
+	}
+
+	// RoleIdQueryArg allows to get the DB id for a role in the URL Parameters
+	// with routes.QueryArgs. This role ID will be an int stored in the
+	// routes.Arguments map with itself for key.
+	RoleIdQueryArg = QueryArg{
+		Name:        "role-id",
+		Type:        QueryArgInt{},
+		Description: "The DB ID of the role",
+	}
+
+	// DateBeginOptionalQueryArg allows to get the iso8601 begin date in the
+	// URL Parameters with routes.QueryArgs. This date will be a time.Time
+	// stored in the routes.Arguments map with itself for key.
+	// DateBeginOptionalQueryArg is optional and will not panic if no query
+	// argument is found.
+	DateBeginOptionalQueryArg = QueryArg{
+		Name:        "begin",
+		Type:        QueryArgDate{},
+		Description: "Begining of date interval. Format is ISO8601",
+		Optional:    true,
+	}
+
+	// DateEndOptionalQueryArg allows to get the iso8601 end date in the URL
+	// Parameters with routes.QueryArgs. This date will be a time.Time stored
+	// in the routes.Arguments map with itself for key.
+	// DateEndOptionalQueryArg is optional and will not panic if no query
+	// argument is found.
+	DateEndOptionalQueryArg = QueryArg{
+		Name:        "end",
+		Type:        QueryArgDate{},
+		Description: "End of date interval. Format is ISO8601",
+		Optional:    true,
+	}
+
+	// AuditActionOptionalQueryArg allows to get an audit log action in the
+	// URL Parameters with routes.QueryArgs. This action will be a string
+	// stored in the routes.Arguments map with itself for key.
+	// AuditActionOptionalQueryArg is optional and will not panic if no query
+	// argument is found.
+	AuditActionOptionalQueryArg = QueryArg{
+		Name:        "action",
+		Type:        QueryArgString{},
+		Description: "The action of the audit log entries, such as aws_account.create",
+		Optional:    true,
+	}
+
+	// AuditTargetTypeOptionalQueryArg allows to get an audit log target type
+	// in the URL Parameters with routes.QueryArgs. This target type will be
+	// a string stored in the routes.Arguments map with itself for key.
+	// AuditTargetTypeOptionalQueryArg is optional and will not panic if no
+	// query argument is found.
+	AuditTargetTypeOptionalQueryArg = QueryArg{
+		Name:        "target-type",
+		Type:        QueryArgString{},
+		Description: "The type of target of the audit log entries, such as aws_account",
+		Optional:    true,
+	}
+
+	// AuditActorIdOptionalQueryArg allows to get the DB id for the user who
+	// performed an action in the URL Parameters with routes.QueryArgs. This
+	// user ID will be an int stored in the routes.Arguments map with itself
+	// for key.
+	// AuditActorIdOptionalQueryArg is optional and will not panic if no query
+	// argument is found.
+	AuditActorIdOptionalQueryArg = QueryArg{
+		Name:        "actor-id",
+		Type:        QueryArgInt{},
+		Description: "The DB ID of the user who performed the actions",
+		Optional:    true,
+	}
+
+	// AuditBeforeIdOptionalQueryArg allows to get the DB id of an audit log
+	// entry in the URL Parameters with routes.QueryArgs, to get the next page
+	// of the audit log. This entry ID will be an int stored in the
+	// routes.Arguments map with itself for key.
+	// AuditBeforeIdOptionalQueryArg is optional and will not panic if no
+	// query argument is found.
+	AuditBeforeIdOptionalQueryArg = QueryArg{
+		Name:        "before-id",
+		Type:        QueryArgInt{},
+		Description: "The DB ID of the last entry of the previous page, only older entries are returned",
+		Optional:    true,
+	}
+
+	// AuditLimitOptionalQueryArg allows to get the maximum number of audit
+	// log entries in the URL Parameters with routes.QueryArgs. This number
+	// will be an int stored in the routes.Arguments map with itself for key.
+	// AuditLimitOptionalQueryArg is optional and will not panic if no query
+	// argument is found.
+	AuditLimitOptionalQueryArg = QueryArg{
+		Name:        "limit",
+		Type:        QueryArgInt{},
+		Description: "The maximum number of entries, from 1 to 1000, 100 by default",
+		Optional:    true,
+	}
+
+	// OrganizationUnitOptionalQueryArg allows to get the ID of a root or an
+	// organizational unit of an AWS organization in the URL Parameters with
+	// routes.QueryArgs. This ID will be a string stored in the
+	// routes.Arguments map with itself for key.
+	// OrganizationUnitOptionalQueryArg is optional and will not panic if no
+	// query argument is found.
+	OrganizationUnitOptionalQueryArg = QueryArg{
+		Name:        "ou",
+		Type:        QueryArgString{},
+		Description: "The ID of a root or an organizational unit of an AWS organization, such as ou-ab12-34cd56ef.",
+		Optional:    true,
+	}
+
+	// AllocatedOptionalQueryArg allows to get whether the allocated costs,
+	// after the redistribution of the shared costs, are requested instead of
+	// the raw costs in the URL Parameters with routes.QueryArgs. This will be
+	// a bool stored in the routes.Arguments map with itself for key.
+	// AllocatedOptionalQueryArg is optional and will not panic if no query
+	// argument is found.
+	AllocatedOptionalQueryArg = QueryArg{
+		Name:        "allocated",
+		Type:        QueryArgBool{},
+		Description: "Whether to respond with the allocated costs, after the redistribution of the shared costs, instead of the raw costs. Defaults to false",
+		Optional:    true,
+	}
 )
@@ -0,0 +1,254 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/trackit/jsonlog"
+)
+
+const (
+	contentTypeJson   = "application/json"
+	contentTypeCsv    = "text/csv"
+	contentTypeNdjson = "application/x-ndjson"
+	contentTypeXlsx   = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	contentTypeXls    = "application/vnd.ms-excel"
+)
+
+// contentTypes are the content types the routes can respond with, by order
+// of preference.
+var contentTypes = []string{
+	contentTypeJson,
+	contentTypeCsv,
+	contentTypeNdjson,
+	contentTypeXlsx,
+	contentTypeXls,
+}
+
+// producedContentTypes returns the content types a route can respond with
+// given its output, by order of preference. The XLS content is generated by
+// the output itself, so other outputs cannot be exported as XLS.
+func producedContentTypes(output interface{}) []string {
+	if isXlsGenerator(output) {
+		return contentTypes
+	}
+	produced := make([]string, 0, len(contentTypes))
+	for _, contentType := range contentTypes {
+		if contentType != contentTypeXls {
+			produced = append(produced, contentType)
+		}
+	}
+	return produced
+}
+
+// flushEvery is the number of rows after which CSV and NDJSON exports are
+// flushed to the client.
+const flushEvery = 1000
+
+type acceptedType struct {
+	mediaRange string
+	quality    float64
+	position   int
+}
+
+// negotiateContentType returns the content type to respond with given the
+// Accept header of a request and the content types which can be produced,
+// following RFC 7231 section 5.3.2. It returns an empty string if none of
+// the produced content types is acceptable.
+func negotiateContentType(accept string, produced []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return contentTypeJson
+	}
+	var accepted []acceptedType
+	for i, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaRange := strings.ToLower(strings.TrimSpace(params[0]))
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if mediaRange != "" && quality > 0 {
+			accepted = append(accepted, acceptedType{mediaRange, quality, i})
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].quality > accepted[j].quality
+	})
+	for _, a := range accepted {
+		for _, contentType := range produced {
+			if mediaRangeMatches(a.mediaRange, contentType) {
+				return contentType
+			}
+		}
+	}
+	return ""
+}
+
+func mediaRangeMatches(mediaRange, contentType string) bool {
+	if mediaRange == "*/*" || mediaRange == contentType {
+		return true
+	}
+	return strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(contentType, mediaRange[:len(mediaRange)-1])
+}
+
+// IsExportRequest tells whether a request asks for a tabular export of the
+// output of a route rather than for its JSON representation.
+func IsExportRequest(r *http.Request) bool {
+	contentType := negotiateContentType(r.Header.Get("Accept"), contentTypes)
+	return contentType != contentTypeJson && contentType != ""
+}
+
+// writeJson writes the JSON representation of the output of a route.
+func writeJson(w http.ResponseWriter, status int, output interface{}, pretty bool) {
+	w.Header().Set("Content-Type", contentTypeJson+"; charset=utf-8")
+	w.WriteHeader(status)
+	e := json.NewEncoder(w)
+	if pretty {
+		e.SetIndent("", "\t")
+	}
+	e.Encode(output)
+}
+
+// writeExport writes the output of a route as a table in one of the export
+// content types. Outputs implementing rowsGenerator are written as their rows
+// are produced, other outputs are first built into a table in memory, see
+// tableFromOutput. CSV and NDJSON are flushed every flushEvery rows. The
+// status is already sent when writing fails, so the error is only logged.
+func writeExport(w http.ResponseWriter, r *http.Request, status int, output interface{}, contentType string) {
+	logger := jsonlog.LoggerFromContextOrDefault(r.Context())
+	rows, ok := output.(rowsGenerator)
+	if !ok {
+		t, err := tableFromOutput(output)
+		if err != nil {
+			logger.Error("Failed to build export table.", err.Error())
+			writeJson(w, http.StatusInternalServerError, errorBody{"Failed to export the response."}, false)
+			return
+		}
+		rows = t
+	}
+	var err error
+	switch contentType {
+	case contentTypeCsv:
+		w.Header().Set("Content-Type", contentTypeCsv+"; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename=trackit.csv")
+		w.WriteHeader(status)
+		err = writeCsv(w, rows)
+	case contentTypeNdjson:
+		w.Header().Set("Content-Type", contentTypeNdjson+"; charset=utf-8")
+		w.WriteHeader(status)
+		err = writeNdjson(w, rows)
+	case contentTypeXlsx:
+		w.Header().Set("Content-Type", contentTypeXlsx)
+		w.Header().Set("Content-Disposition", "attachment; filename=trackit.xlsx")
+		w.WriteHeader(status)
+		err = writeXlsx(w, rows)
+	}
+	if err != nil {
+		logger.Error("Failed to write export.", map[string]interface{}{
+			"contentType": contentType,
+			"error":       err.Error(),
+		})
+	}
+}
+
+func flush(w io.Writer) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func writeCsv(w io.Writer, rows rowsGenerator) error {
+	csvWriter := csv.NewWriter(w)
+	columns := rows.ExportColumns()
+	if err := csvWriter.Write(columns); err != nil {
+		return err
+	}
+	line := make([]string, len(columns))
+	written := 0
+	err := rows.EachExportRow(func(cells []interface{}) error {
+		for j, cell := range cells {
+			line[j] = cellString(cell)
+		}
+		if err := csvWriter.Write(line); err != nil {
+			return err
+		}
+		if written++; written%flushEvery == 0 {
+			csvWriter.Flush()
+			flush(w)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func writeNdjson(w io.Writer, rows rowsGenerator) error {
+	columns := rows.ExportColumns()
+	written := 0
+	return rows.EachExportRow(func(cells []interface{}) error {
+		if err := writeNdjsonRow(w, columns, cells); err != nil {
+			return err
+		}
+		if written++; written%flushEvery == 0 {
+			flush(w)
+		}
+		return nil
+	})
+}
+
+// writeXlsx writes the rows of an export as a single sheet workbook. Numbers
+// are written as numeric cells. The workbook can only be written once all
+// its rows are added to it.
+func writeXlsx(w io.Writer, rows rowsGenerator) error {
+	const sheet = "Sheet1"
+	file := excelize.NewFile()
+	columns := rows.ExportColumns()
+	file.SetSheetRow(sheet, "A1", &columns)
+	line := 2
+	err := rows.EachExportRow(func(cells []interface{}) error {
+		for j, cell := range cells {
+			if number, ok := cell.(json.Number); ok {
+				if f, err := number.Float64(); err == nil {
+					cell = f
+				}
+			}
+			if cell != nil {
+				file.SetCellValue(sheet, excelize.ToAlphaString(j)+strconv.Itoa(line), cell)
+			}
+		}
+		line++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return file.Write(w)
+}
@@ -0,0 +1,114 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateContentType(t *testing.T) {
+	for _, test := range []struct {
+		accept   string
+		expected string
+	}{
+		{"", contentTypeJson},
+		{"*/*", contentTypeJson},
+		{"text/csv", contentTypeCsv},
+		{"text/*", contentTypeCsv},
+		{"text/csv;q=0.5, application/x-ndjson", contentTypeNdjson},
+		{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", contentTypeXlsx},
+		{"text/html, */*;q=0.1", contentTypeJson},
+		{"text/csv;q=0", ""},
+		{"text/html", ""},
+	} {
+		if got := negotiateContentType(test.accept, contentTypes); got != test.expected {
+			t.Errorf("Accept '%s': expected '%s', got '%s'.", test.accept, test.expected, got)
+		}
+	}
+}
+
+func TestProducedContentTypes(t *testing.T) {
+	if got := negotiateContentType(contentTypeXls, producedContentTypes(getFooResponse)); got != "" {
+		t.Errorf("XLS should only be produced by xls generators, got '%s'.", got)
+	}
+	if got := negotiateContentType(contentTypeXls, producedContentTypes(xlsTest{})); got != contentTypeXls {
+		t.Errorf("XLS should be produced by xls generators, got '%s'.", got)
+	}
+}
+
+func TestHandlerServeHttpCsv(t *testing.T) {
+	h := H(getFoo)
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Accept", "text/csv")
+	response := httptest.NewRecorder()
+	h.ServeHTTP(response, request)
+	if response.Code != http.StatusOK {
+		t.Errorf("Response status should be %d, is %d instead.", http.StatusOK, response.Code)
+	}
+	if expected := "value\n" + getFooResponse + "\n"; response.Body.String() != expected {
+		t.Errorf("Body should be '%s', is '%s' instead.", expected, response.Body.String())
+	}
+}
+
+func TestHandlerServeHttpNotAcceptable(t *testing.T) {
+	h := H(getFoo)
+	for _, accept := range []string{"image/png", contentTypeXls} {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Accept", accept)
+		response := httptest.NewRecorder()
+		h.ServeHTTP(response, request)
+		if response.Code != http.StatusNotAcceptable {
+			t.Errorf("Accept '%s': response status should be %d, is %d instead.", accept, http.StatusNotAcceptable, response.Code)
+		}
+	}
+}
+
+type xlsTest struct{}
+
+func (xlsTest) GetFileContent() []byte { return nil }
+func (xlsTest) GetFileName() string    { return "test.xls" }
+
+type rowsTest int
+
+func (rowsTest) ExportColumns() []string {
+	return []string{"n", "square"}
+}
+
+func (rt rowsTest) EachExportRow(f func(row []interface{}) error) error {
+	for i := 1; i <= int(rt); i++ {
+		if err := f([]interface{}{i, i * i}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestWriteRowsGenerator(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := writeCsv(&buffer, rowsTest(3)); err != nil {
+		t.Errorf("Unexpected error %v.", err)
+	} else if expected := "n,square\n1,1\n2,4\n3,9\n"; buffer.String() != expected {
+		t.Errorf("CSV should be '%s', is '%s' instead.", expected, buffer.String())
+	}
+	buffer.Reset()
+	if err := writeNdjson(&buffer, rowsTest(2)); err != nil {
+		t.Errorf("Unexpected error %v.", err)
+	} else if expected := "{\"n\":1,\"square\":1}\n{\"n\":2,\"square\":4}\n"; buffer.String() != expected {
+		t.Errorf("NDJSON should be '%s', is '%s' instead.", expected, buffer.String())
+	}
+}
@@ -15,11 +15,8 @@
 package routes
 
 import (
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/trackit/trackit/config"
 )
@@ -59,6 +56,15 @@ type csvGenerator interface {
 	ToCSVable() [][]string
 }
 
+// rowsGenerator is an interface for any type that can generate the rows of
+// its export one at a time, so that large outputs are exported as their rows
+// are produced rather than from a table built in memory. Each row has a cell
+// for each column
+type rowsGenerator interface {
+	ExportColumns() []string
+	EachExportRow(func(row []interface{}) error) error
+}
+
 // xlsGenerator is an interface for any type that can generate an xls file content
 type xlsGenerator interface {
 	GetFileContent() []byte
@@ -69,54 +75,42 @@ func resetRegisteredHandlers() {
 	RegisteredHandlers = RegisteredHandlers[:0]
 }
 
-// BUG(zanchi-r): We do not support content type negociation
-// Only a single type without quality factor should be specified in the Accept header
-// Example : "Accept: text/csv"
-// If an unsupported type is specified an empty body will be returned
+// ServeHTTP runs the handler and writes its output in the content type
+// negotiated from the Accept header of the request. JSON is the default and
+// is used for errors. Other outputs can be exported as CSV, NDJSON or XLSX,
+// see tableFromOutput, and only outputs implementing xlsGenerator can be
+// exported as XLS.
 func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	arguments := make(Arguments)
 	status, output := h.Func(w, r, arguments)
-	acceptType := "*/*"
-	if len(r.Header["Accept"]) > 0 {
-		acceptType = r.Header["Accept"][0]
-	}
-	// If no specific accept type is specified we use application/json by default
-	if strings.HasPrefix(acceptType, "*/*") {
-		acceptType = "application/json"
-	}
-	w.Header()["Content-Type"] = []string{fmt.Sprintf("%s; charset=utf-8", acceptType)}
-	switch acceptType {
-	case "application/json":
+	contentType := negotiateContentType(r.Header.Get("Accept"), producedContentTypes(output))
+	switch {
+	case contentType == "":
+		writeJson(w, http.StatusNotAcceptable, errorBody{"None of the accepted content types can be produced."}, config.PrettyJsonResponses)
+	case contentType == contentTypeJson || status >= http.StatusBadRequest:
+		writeJson(w, status, output, config.PrettyJsonResponses)
+	case contentType == contentTypeXls:
+		outputGen := output.(xlsGenerator)
+		w.Header().Set("Content-Type", contentTypeXls+"; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", outputGen.GetFileName()))
 		w.WriteHeader(status)
-		e := json.NewEncoder(w)
-		if config.PrettyJsonResponses {
-			e.SetIndent("", "\t")
-		}
-		e.Encode(output)
-	case "text/csv":
-		w.Header().Set("Content-Disposition", "attachment; filename=trackit.csv")
+		w.Write(outputGen.GetFileContent())
+	case contentType == contentTypeXlsx && isXlsGenerator(output):
+		outputGen := output.(xlsGenerator)
+		w.Header().Set("Content-Type", contentTypeXlsx)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", outputGen.GetFileName()))
 		w.WriteHeader(status)
-		if outputGen, ok := output.(csvGenerator); ok {
-			csvWriter := csv.NewWriter(w)
-			csvWriter.WriteAll(outputGen.ToCSVable())
-		} else {
-			// TODO: if the data do not implement the csvGenerator interface, try to generate it by reflection
-		}
-	case "application/vnd.ms-excel":
-		if outputGen, ok := output.(xlsGenerator); ok {
-			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", outputGen.GetFileName()))
-			w.WriteHeader(status)
-			w.Write(outputGen.GetFileContent())
-		} else {
-			if status == http.StatusOK {
-				w.WriteHeader(http.StatusNotImplemented)
-			} else {
-				w.WriteHeader(status)
-			}
-		}
+		w.Write(outputGen.GetFileContent())
+	default:
+		writeExport(w, r, status, output, contentType)
 	}
 }
 
+func isXlsGenerator(output interface{}) bool {
+	_, ok := output.(xlsGenerator)
+	return ok
+}
+
 func (h Handler) With(ds ...Decorator) Handler {
 	l := len(ds) - 1
 	for i := range ds {
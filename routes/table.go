@@ -0,0 +1,290 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// table is the tabular form of the output of a route, used to export it as
+// CSV, NDJSON or XLSX.
+type table struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// ExportColumns returns the columns of a table. It implements rowsGenerator.
+func (t table) ExportColumns() []string {
+	return t.Columns
+}
+
+// EachExportRow calls f with each row of a table. It implements
+// rowsGenerator.
+func (t table) EachExportRow(f func(row []interface{}) error) error {
+	for _, row := range t.Rows {
+		if err := f(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableFromCsvGenerator builds the table of an output which generates its
+// own CSV content, whose first line is the header.
+func tableFromCsvGenerator(gen csvGenerator) table {
+	var t table
+	for i, line := range gen.ToCSVable() {
+		if i == 0 {
+			t.Columns = line
+			continue
+		}
+		row := make([]interface{}, len(line))
+		for j, cell := range line {
+			row[j] = cell
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+// tableFromOutput flattens the output of a route into a table. Outputs
+// implementing csvGenerator provide their own rows. Other outputs are
+// flattened from their JSON representation:
+//   - the fields of objects become columns, named after their path with dots,
+//     e.g. 'instance.tags.Name', in the order of the fields;
+//   - arrays of objects or arrays become one row per element, repeating the
+//     fields of the objects containing them;
+//   - arrays of scalars are joined with semicolons in a single cell;
+//   - null values and empty arrays are left out.
+//
+// The name of a column only depends on the path of its field, never on the
+// position of the elements containing it.
+func tableFromOutput(output interface{}) (table, error) {
+	if gen, ok := output.(csvGenerator); ok {
+		return tableFromCsvGenerator(gen), nil
+	}
+	marshaled, err := json.Marshal(output)
+	if err != nil {
+		return table{}, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(marshaled))
+	decoder.UseNumber()
+	value, err := decodeOrdered(decoder)
+	if err != nil {
+		return table{}, err
+	}
+	var f flattener
+	rows := f.flatten(value, "")
+	t := table{Columns: f.columns, Rows: make([][]interface{}, len(rows))}
+	for i, r := range rows {
+		t.Rows[i] = make([]interface{}, len(f.columns))
+		for column, cell := range r {
+			t.Rows[i][f.index[column]] = cell
+		}
+	}
+	return t, nil
+}
+
+// orderedObject is a decoded JSON object which keeps the order of its keys.
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// decodeOrdered decodes the next JSON value of a decoder, keeping the order
+// of the keys of objects.
+func decodeOrdered(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := orderedObject{values: make(map[string]interface{})}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := object.values[key.(string)]; !exists {
+				object.keys = append(object.keys, key.(string))
+			}
+			object.values[key.(string)] = value
+		}
+		_, err = decoder.Token()
+		return object, err
+	case json.Delim('['):
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token()
+		return array, err
+	default:
+		return token, nil
+	}
+}
+
+type row map[string]interface{}
+
+// flattener flattens decoded JSON values into rows, registering the columns
+// in the order they are first met.
+type flattener struct {
+	columns []string
+	index   map[string]int
+}
+
+func (f *flattener) column(path string) string {
+	if path == "" {
+		path = "value"
+	}
+	if f.index == nil {
+		f.index = make(map[string]int)
+	}
+	if _, ok := f.index[path]; !ok {
+		f.index[path] = len(f.columns)
+		f.columns = append(f.columns, path)
+	}
+	return path
+}
+
+func (f *flattener) flatten(value interface{}, path string) []row {
+	switch value := value.(type) {
+	case orderedObject:
+		return f.flattenObject(value, path)
+	case []interface{}:
+		return f.flattenArray(value, path)
+	case nil:
+		return nil
+	default:
+		return []row{{f.column(path): value}}
+	}
+}
+
+// flattenObject merges the single rows of the fields of an object. The
+// fields with several rows each add their rows to the result, with the
+// single row fields repeated on each of them.
+func (f *flattener) flattenObject(object orderedObject, path string) []row {
+	base := make(row)
+	var expansions [][]row
+	for _, key := range object.keys {
+		rows := f.flatten(object.values[key], joinPath(path, key))
+		if len(rows) == 1 {
+			for column, cell := range rows[0] {
+				base[column] = cell
+			}
+		} else if len(rows) > 1 {
+			expansions = append(expansions, rows)
+		}
+	}
+	if len(expansions) == 0 {
+		return []row{base}
+	}
+	var rows []row
+	for _, expansion := range expansions {
+		for _, r := range expansion {
+			merged := make(row, len(base)+len(r))
+			for column, cell := range base {
+				merged[column] = cell
+			}
+			for column, cell := range r {
+				merged[column] = cell
+			}
+			rows = append(rows, merged)
+		}
+	}
+	return rows
+}
+
+func (f *flattener) flattenArray(array []interface{}, path string) []row {
+	if len(array) == 0 {
+		return nil
+	}
+	scalars := make([]string, 0, len(array))
+	for _, value := range array {
+		switch value.(type) {
+		case orderedObject, []interface{}:
+			var rows []row
+			for _, value := range array {
+				rows = append(rows, f.flatten(value, path)...)
+			}
+			return rows
+		default:
+			scalars = append(scalars, cellString(value))
+		}
+	}
+	return []row{{f.column(path): strings.Join(scalars, ";")}}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// cellString formats a cell of a table for text exports.
+func cellString(cell interface{}) string {
+	switch cell := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return cell
+	case json.Number:
+		return cell.String()
+	default:
+		return fmt.Sprint(cell)
+	}
+}
+
+// writeNdjsonRow writes a row of a table as a JSON object on its own line,
+// with the columns in the order of the table. Empty cells are omitted.
+func writeNdjsonRow(w io.Writer, columns []string, cells []interface{}) error {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	first := true
+	for i, cell := range cells {
+		if cell == nil {
+			continue
+		}
+		if !first {
+			buffer.WriteByte(',')
+		}
+		first = false
+		key, _ := json.Marshal(columns[i])
+		value, err := json.Marshal(cell)
+		if err != nil {
+			return err
+		}
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteString("}\n")
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
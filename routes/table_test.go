@@ -0,0 +1,88 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package routes
+
+import (
+	"reflect"
+	"testing"
+)
+
+type tableTestInstance struct {
+	Id   string            `json:"id"`
+	Tags map[string]string `json:"tags"`
+	Cost float64           `json:"cost"`
+}
+
+type tableTestReport struct {
+	Account   string              `json:"account"`
+	Regions   []string            `json:"regions"`
+	Instances []tableTestInstance `json:"instances"`
+}
+
+type tableTestCsv struct{}
+
+func (tableTestCsv) ToCSVable() [][]string {
+	return [][]string{{"a", "b"}, {"1", "2"}}
+}
+
+func tableStrings(t table) [][]string {
+	res := [][]string{t.Columns}
+	for _, cells := range t.Rows {
+		line := make([]string, len(cells))
+		for i, cell := range cells {
+			line[i] = cellString(cell)
+		}
+		res = append(res, line)
+	}
+	return res
+}
+
+func TestTableFromOutput(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		output   interface{}
+		expected [][]string
+	}{
+		{"scalar", "foo", [][]string{{"value"}, {"foo"}}},
+		{"csvGenerator", tableTestCsv{}, [][]string{{"a", "b"}, {"1", "2"}}},
+		{
+			"nested",
+			[]tableTestReport{
+				{
+					Account: "123",
+					Regions: []string{"us-east-1", "eu-west-1"},
+					Instances: []tableTestInstance{
+						{Id: "i-1", Tags: map[string]string{"Name": "web"}, Cost: 1.5},
+						{Id: "i-2", Cost: 2},
+					},
+				},
+				{Account: "456", Instances: []tableTestInstance{{Id: "i-3", Cost: 3}}},
+			},
+			[][]string{
+				{"account", "regions", "instances.id", "instances.tags.Name", "instances.cost"},
+				{"123", "us-east-1;eu-west-1", "i-1", "web", "1.5"},
+				{"123", "us-east-1;eu-west-1", "i-2", "", "2"},
+				{"456", "", "i-3", "", "3"},
+			},
+		},
+	} {
+		res, err := tableFromOutput(test.output)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v.", test.name, err)
+		} else if got := tableStrings(res); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected %v, got %v.", test.name, test.expected, got)
+		}
+	}
+}
@@ -49,6 +49,7 @@ func createQueryTimeRange(durationBegin time.Time, durationEnd time.Time) *elast
 //	- durationEnd time.Time : A time.Time struct representing the end of the time range in the query
 //	- client *elastic.Client : an instance of *elastic.Client that represent an Elastic Search client.
 //  - filters []esFilter : A slice of esFilter containing the filters (key/value) to apply to the request
+//  - costField string : The line item field holding the cost to sum, as returned by s3.GetCostTypeField
 //	It needs to be fully configured and ready to execute a client.Search()
 //	- index string : The Elastic Search index on wich to execute the query. In this context the default value
 //	should be "awsdetailedlineitems"
@@ -57,7 +58,7 @@ func createQueryTimeRange(durationBegin time.Time, durationEnd time.Time) *elast
 //	- If the client is nil or malconfigured, it will crash
 //	- If the index is not an index present in the ES, it will crash
 func GetS3UsageAndCostElasticSearchParams(accountList []string, durationBegin time.Time,
-	durationEnd time.Time, filters []esFilter, client *elastic.Client, index string) *elastic.SearchService {
+	durationEnd time.Time, filters []esFilter, costField string, client *elastic.Client, index string) *elastic.SearchService {
 	query := elastic.NewBoolQuery()
 	if len(accountList) > 0 {
 		query = query.Filter(createQueryAccountFilter(accountList))
@@ -71,6 +72,6 @@ func GetS3UsageAndCostElasticSearchParams(accountList []string, durationBegin ti
 
 	search.Aggregation("buckets", elastic.NewTermsAggregation().Field("resourceId").Size(aggregationMaxSize).
 		SubAggregation("usage", elastic.NewSumAggregation().Field("usageAmount")).
-		SubAggregation("cost", elastic.NewSumAggregation().Field("unblendedCost")))
+		SubAggregation("cost", elastic.NewSumAggregation().Field(costField)))
 	return search
 }
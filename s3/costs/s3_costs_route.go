@@ -39,6 +39,7 @@ type S3QueryParams struct {
 	DateBegin   time.Time
 	DateEnd     time.Time
 	AccountList []string
+	CostType    string
 	indexList   []string
 }
 
@@ -77,7 +78,8 @@ func init() {
 			routes.QueryArgs{routes.AwsAccountsOptionalQueryArg},
 			routes.QueryArgs{routes.DateBeginQueryArg},
 			routes.QueryArgs{routes.DateEndQueryArg},
-			cache.UsersCache{},
+			routes.QueryArgs{routes.CostTypeQueryArg},
+			cache.UsersCache{Events: []string{cache.EventBillingData}},
 			routes.Documentation{
 				Summary:     "get the s3 costs data",
 				Description: "Responds with cost data based on the queryparams passed to it",
@@ -104,12 +106,17 @@ func makeElasticSearchRequest(ctx context.Context, parsedParams S3QueryParams,
 		l.Error("Failed to retrieve s3 costs", err)
 		return nil, http.StatusInternalServerError, err
 	}
+	costField, err := s3.GetCostTypeField(parsedParams.CostType)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
 
 	searchService := GetS3UsageAndCostElasticSearchParams(
 		parsedParams.AccountList,
 		parsedParams.DateBegin,
 		parsedParams.DateEnd,
 		esFilters,
+		costField,
 		es.Client,
 		index,
 	)
@@ -145,6 +152,12 @@ func getS3CostDataHandler(request *http.Request, a routes.Arguments) (int, inter
 	if a[routes.AwsAccountsOptionalQueryArg] != nil {
 		parsedParams.AccountList = a[routes.AwsAccountsOptionalQueryArg].([]string)
 	}
+	if a[routes.CostTypeQueryArg] != nil {
+		parsedParams.CostType = a[routes.CostTypeQueryArg].(string)
+	}
+	if _, err := s3.GetCostTypeField(parsedParams.CostType); err != nil {
+		return http.StatusBadRequest, err
+	}
 	var err error
 	var returnCode int
 	tx := a[db.Transaction].(*sql.Tx)
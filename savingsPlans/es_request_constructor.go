@@ -0,0 +1,291 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package savingsPlans
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/olivere/elastic"
+	"github.com/trackit/jsonlog"
+
+	terrors "github.com/trackit/trackit/errors"
+	"github.com/trackit/trackit/es"
+)
+
+const maxAggregationSize = 0x7FFFFFFF
+
+// savingsPlanLineItemTypes are the types of the line items of the usage
+// Savings Plans cover and of their fees, which tell their kind.
+var savingsPlanLineItemTypes = []interface{}{
+	"SavingsPlanCoveredUsage",
+	"SavingsPlanRecurringFee",
+	"SavingsPlanUpfrontFee",
+}
+
+// coveredUsageTypes are patterns of the usage types of the line items
+// Savings Plans can cover: EC2 instances, Fargate and Lambda.
+var coveredUsageTypes = []string{
+	"*BoxUsage*",
+	"*Fargate-vCPU-Hours*",
+	"*Fargate-GB-Hours*",
+	"*Lambda-GB-Second*",
+}
+
+type (
+	// QueryParams will store the parsed query params
+	QueryParams struct {
+		AccountList     []string
+		IndexList       []string
+		DateBegin       time.Time
+		DateEnd         time.Time
+		SavingsPlanType string
+		Term            string
+		PaymentOption   string
+	}
+
+	// ResponseUsage allows to parse the ES response of the hourly on-demand
+	// spend of the covered usage
+	ResponseUsage struct {
+		Regions struct {
+			Buckets []struct {
+				Key        string `json:"key"`
+				UsageTypes struct {
+					Buckets []struct {
+						Key   string `json:"key"`
+						Hours struct {
+							Buckets []struct {
+								Key  float64 `json:"key"`
+								Cost struct {
+									Value float64 `json:"value"`
+								} `json:"cost"`
+							} `json:"buckets"`
+						} `json:"hours"`
+					} `json:"buckets"`
+				} `json:"usageTypes"`
+			} `json:"buckets"`
+		} `json:"regions"`
+	}
+
+	// ResponseDiscountRates allows to parse the ES response of the costs of
+	// the usage covered by each Savings Plan
+	ResponseDiscountRates struct {
+		SavingsPlans struct {
+			Buckets []struct {
+				Key            string           `json:"key"`
+				OfferingTypes  responseKeywords `json:"offeringTypes"`
+				PurchaseTerms  responseKeywords `json:"purchaseTerms"`
+				PaymentOptions responseKeywords `json:"paymentOptions"`
+				Covered        struct {
+					OnDemandCost struct {
+						Value float64 `json:"value"`
+					} `json:"onDemandCost"`
+					SavingsPlansCost struct {
+						Value float64 `json:"value"`
+					} `json:"savingsPlansCost"`
+				} `json:"covered"`
+			} `json:"buckets"`
+		} `json:"savingsPlans"`
+	}
+
+	// responseKeywords allows to parse the ES response of a terms
+	// aggregation on a keyword field
+	responseKeywords struct {
+		Buckets []struct {
+			Key string `json:"key"`
+		} `json:"buckets"`
+	}
+)
+
+// makeElasticSearchRequest prepares and run an ES request
+// based on the QueryParams and search params
+// It will return the data, an http status code (as int) and an error.
+// Because an error can be generated, but is not critical and is not needed to be known by
+// the user (e.g if the index does not exists because it was not yet indexed ) the error will
+// be returned, but instead of having a 500 status code, it will return the provided status code
+// with empty data
+func makeElasticSearchRequest(ctx context.Context, parsedParams QueryParams,
+	esSearchParams func(QueryParams, *elastic.Client, string) *elastic.SearchService) (*elastic.SearchResult, int, error) {
+	l := jsonlog.LoggerFromContextOrDefault(ctx)
+	index := strings.Join(parsedParams.IndexList, ",")
+	searchService := esSearchParams(
+		parsedParams,
+		es.Client,
+		index,
+	)
+	res, err := searchService.Do(ctx)
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			l.Warning("Query execution failed, ES index does not exists", map[string]interface{}{
+				"index": index,
+				"error": err.Error(),
+			})
+			return nil, http.StatusOK, terrors.GetErrorMessage(ctx, err)
+		} else if cast, ok := err.(*elastic.Error); ok && cast.Details != nil && cast.Details.Type == "search_phase_execution_exception" {
+			l.Error("Error while getting data from ES", map[string]interface{}{
+				"type":  fmt.Sprintf("%T", err),
+				"error": err,
+			})
+		} else {
+			l.Error("Query execution failed", map[string]interface{}{"error": err.Error()})
+		}
+		return nil, http.StatusInternalServerError, terrors.GetErrorMessage(ctx, err)
+	}
+	return res, http.StatusOK, nil
+}
+
+// createQueryAccountFilter creates and returns a new *elastic.TermsQuery on the accountList array
+func createQueryAccountFilter(accountList []string) *elastic.TermsQuery {
+	accountListFormatted := make([]interface{}, len(accountList))
+	for i, v := range accountList {
+		accountListFormatted[i] = v
+	}
+	return elastic.NewTermsQuery("usageAccountId", accountListFormatted...)
+}
+
+// createQueryTimeRange creates and returns a new *elastic.RangeQuery based on the duration
+// defined by durationBegin and durationEnd
+func createQueryTimeRange(durationBegin time.Time, durationEnd time.Time) *elastic.RangeQuery {
+	return elastic.NewRangeQuery("usageStartDate").
+		From(durationBegin).To(durationEnd)
+}
+
+// createQueryCoveredUsage creates and returns a new *elastic.BoolQuery matching
+// the usage billed on demand which Savings Plans can cover
+func createQueryCoveredUsage() *elastic.BoolQuery {
+	usageTypes := elastic.NewBoolQuery().MinimumNumberShouldMatch(1)
+	for _, pattern := range coveredUsageTypes {
+		usageTypes = usageTypes.Should(elastic.NewWildcardQuery("usageType", pattern))
+	}
+	return elastic.NewBoolQuery().
+		Filter(elastic.NewTermQuery("lineItemType", "Usage")).
+		Filter(usageTypes)
+}
+
+// getElasticSearchParams is used to construct an ElasticSearch *elastic.SearchService used to perform a request on ES
+// It takes as parameters :
+//   - params QueryParams : contains the list of accounts and the lookback period
+//   - client *elastic.Client : an instance of *elastic.Client that represent an Elastic Search client.
+//     It needs to be fully configured and ready to execute a client.Search()
+//   - index string : The Elastic Search index on which to execute the query. In this context the default value
+//     should be "lineitems"
+func getElasticSearchParams(params QueryParams, client *elastic.Client, index string) *elastic.SearchService {
+	query := elastic.NewBoolQuery()
+	if len(params.AccountList) > 0 {
+		query = query.Filter(createQueryAccountFilter(params.AccountList))
+	}
+	query = query.Filter(createQueryTimeRange(params.DateBegin, params.DateEnd))
+	query = query.Filter(createQueryCoveredUsage())
+	search := client.Search().Index(index).Size(0).Query(query)
+	search.Aggregation("regions", elastic.NewTermsAggregation().Field("region").Size(maxAggregationSize).
+		SubAggregation("usageTypes", elastic.NewTermsAggregation().Field("usageType").Size(maxAggregationSize).
+			SubAggregation("hours", elastic.NewDateHistogramAggregation().Field("usageStartDate").Interval("hour").
+				SubAggregation("cost", elastic.NewSumAggregation().Field("unblendedCost")))))
+	return search
+}
+
+// getDiscountRatesElasticSearchParams is used to construct an ElasticSearch *elastic.SearchService used to
+// perform a request on ES. It sums the on-demand and Savings Plans costs of the usage each Savings Plan covered,
+// along with the kind of the Savings Plan, which its fees give.
+// It takes as parameters :
+//   - params QueryParams : contains the list of accounts and the lookback period
+//   - client *elastic.Client : an instance of *elastic.Client that represent an Elastic Search client.
+//     It needs to be fully configured and ready to execute a client.Search()
+//   - index string : The Elastic Search index on which to execute the query. In this context the default value
+//     should be "lineitems"
+func getDiscountRatesElasticSearchParams(params QueryParams, client *elastic.Client, index string) *elastic.SearchService {
+	query := elastic.NewBoolQuery()
+	if len(params.AccountList) > 0 {
+		query = query.Filter(createQueryAccountFilter(params.AccountList))
+	}
+	query = query.Filter(createQueryTimeRange(params.DateBegin, params.DateEnd))
+	query = query.Filter(elastic.NewTermsQuery("lineItemType", savingsPlanLineItemTypes...))
+	search := client.Search().Index(index).Size(0).Query(query)
+	search.Aggregation("savingsPlans", elastic.NewTermsAggregation().Field("savingsPlanArn").Size(maxAggregationSize).
+		SubAggregation("offeringTypes", elastic.NewTermsAggregation().Field("savingsPlanOfferingType").Size(1)).
+		SubAggregation("purchaseTerms", elastic.NewTermsAggregation().Field("savingsPlanPurchaseTerm").Size(1)).
+		SubAggregation("paymentOptions", elastic.NewTermsAggregation().Field("savingsPlanPaymentOption").Size(1)).
+		SubAggregation("covered", elastic.NewFilterAggregation().Filter(elastic.NewTermQuery("lineItemType", "SavingsPlanCoveredUsage")).
+			SubAggregation("onDemandCost", elastic.NewSumAggregation().Field("unblendedCost")).
+			SubAggregation("savingsPlansCost", elastic.NewSumAggregation().Field("savingsPlanEffectiveCost"))))
+	return search
+}
+
+// prepareResponseCurves parses the results from elasticsearch and returns the
+// curves of the usage each type of Savings Plan covers over the lookback period
+func prepareResponseCurves(ctx context.Context, res *elastic.SearchResult, params QueryParams) (Curves, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	curves := NewCurves(int(params.DateEnd.Sub(params.DateBegin) / time.Hour))
+	if res == nil {
+		return curves, nil
+	}
+	var response ResponseUsage
+	err := json.Unmarshal(*res.Aggregations["regions"], &response.Regions)
+	if err != nil {
+		logger.Error("Error while unmarshaling ES Savings Plans usage response", err)
+		return curves, terrors.GetErrorMessage(ctx, err)
+	}
+	for _, region := range response.Regions.Buckets {
+		for _, usageType := range region.UsageTypes.Buckets {
+			for _, hour := range usageType.Hours.Buckets {
+				date := time.Unix(0, int64(hour.Key)*int64(time.Millisecond)).UTC()
+				curves.AddUsage(region.Key, usageType.Key, int(date.Sub(params.DateBegin)/time.Hour), hour.Cost.Value)
+			}
+		}
+	}
+	return curves, nil
+}
+
+// first returns the key of the first bucket of a terms aggregation, or an
+// empty string if it has none
+func (rk responseKeywords) first() string {
+	if len(rk.Buckets) == 0 {
+		return ""
+	}
+	return rk.Buckets[0].Key
+}
+
+// prepareResponseDiscountRates parses the results from elasticsearch and
+// returns the discount rates of the kinds of Savings Plans the accounts have.
+// The Savings Plans whose kind the line items do not give are left out.
+func prepareResponseDiscountRates(ctx context.Context, res *elastic.SearchResult) (DiscountRates, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	if res == nil {
+		return DiscountRates{}, nil
+	}
+	var response ResponseDiscountRates
+	err := json.Unmarshal(*res.Aggregations["savingsPlans"], &response.SavingsPlans)
+	if err != nil {
+		logger.Error("Error while unmarshaling ES Savings Plans discount rates response", err)
+		return DiscountRates{}, terrors.GetErrorMessage(ctx, err)
+	}
+	costs := make(map[Option]coveredCosts)
+	for _, savingsPlan := range response.SavingsPlans.Buckets {
+		option := Option{
+			SavingsPlanType: savingsPlan.OfferingTypes.first(),
+			Term:            savingsPlan.PurchaseTerms.first(),
+			PaymentOption:   savingsPlan.PaymentOptions.first(),
+		}
+		c := costs[option]
+		c.onDemand += savingsPlan.Covered.OnDemandCost.Value
+		c.savingsPlans += savingsPlan.Covered.SavingsPlansCost.Value
+		costs[option] = c
+	}
+	return discountRatesFromCosts(costs), nil
+}
@@ -0,0 +1,393 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package savingsPlans recommends Savings Plans purchases from the hourly
+// on-demand spend of the usage they cover.
+package savingsPlans
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Types of Savings Plans.
+const (
+	// PlanCompute applies to EC2, Fargate and Lambda usage in any region
+	// and instance family.
+	PlanCompute = "ComputeSavingsPlans"
+	// PlanEc2Instance applies to the EC2 usage of an instance family in a
+	// region.
+	PlanEc2Instance = "EC2InstanceSavingsPlans"
+)
+
+// Terms of Savings Plans.
+const (
+	TermOneYear    = "1yr"
+	TermThreeYears = "3yr"
+)
+
+// Payment options of Savings Plans.
+const (
+	PaymentNoUpfront      = "No Upfront"
+	PaymentPartialUpfront = "Partial Upfront"
+	PaymentAllUpfront     = "All Upfront"
+)
+
+// hoursPerMonth is the average number of hours in a month, which AWS uses to
+// turn hourly rates into monthly costs.
+const hoursPerMonth = 730
+
+var (
+	// termHours is the duration of each term, in hours.
+	termHours = map[string]float64{
+		TermOneYear:    365 * 24,
+		TermThreeYears: 3 * 365 * 24,
+	}
+	// upfrontShares is the share of the commitment of the whole term paid
+	// upfront with each payment option.
+	upfrontShares = map[string]float64{
+		PaymentNoUpfront:      0,
+		PaymentPartialUpfront: 0.5,
+		PaymentAllUpfront:     1,
+	}
+)
+
+// Option is a kind of Savings Plan which can be bought.
+type Option struct {
+	SavingsPlanType string `json:"savingsPlanType"`
+	Term            string `json:"term"`
+	PaymentOption   string `json:"paymentOption"`
+}
+
+// averageDiscountRates are the discounts of the Savings Plans rates from the
+// on-demand rates of the usage they cover, averaged across the public rates
+// of instance types and regions. They estimate the discount rates of the
+// options the line items give no Savings Plans rates for.
+var averageDiscountRates = map[Option]float64{
+	{PlanCompute, TermOneYear, PaymentNoUpfront}:             0.27,
+	{PlanCompute, TermOneYear, PaymentPartialUpfront}:        0.30,
+	{PlanCompute, TermOneYear, PaymentAllUpfront}:            0.32,
+	{PlanCompute, TermThreeYears, PaymentNoUpfront}:          0.46,
+	{PlanCompute, TermThreeYears, PaymentPartialUpfront}:     0.50,
+	{PlanCompute, TermThreeYears, PaymentAllUpfront}:         0.52,
+	{PlanEc2Instance, TermOneYear, PaymentNoUpfront}:         0.36,
+	{PlanEc2Instance, TermOneYear, PaymentPartialUpfront}:    0.39,
+	{PlanEc2Instance, TermOneYear, PaymentAllUpfront}:        0.41,
+	{PlanEc2Instance, TermThreeYears, PaymentNoUpfront}:      0.56,
+	{PlanEc2Instance, TermThreeYears, PaymentPartialUpfront}: 0.60,
+	{PlanEc2Instance, TermThreeYears, PaymentAllUpfront}:     0.62,
+}
+
+type (
+	// DiscountRates are the discounts of the Savings Plans rates from the
+	// on-demand rates of the usage they cover, by option, as the Savings
+	// Plans the accounts already have give them.
+	DiscountRates map[Option]float64
+
+	// coveredCosts are the costs of the usage covered by the Savings Plans
+	// of an option.
+	coveredCosts struct {
+		// onDemand is the cost of the usage at the on-demand rates.
+		onDemand float64
+		// savingsPlans is the cost of the usage at the Savings Plans rates.
+		savingsPlans float64
+	}
+)
+
+// discountRatesFromCosts returns the discount rates of the options from the
+// costs of the usage their Savings Plans covered. Unknown options and
+// options without covered usage are left out.
+func discountRatesFromCosts(costs map[Option]coveredCosts) DiscountRates {
+	rates := make(DiscountRates, len(costs))
+	for option, c := range costs {
+		if _, ok := averageDiscountRates[option]; !ok || c.onDemand <= 0 {
+			continue
+		}
+		if rate := 1 - c.savingsPlans/c.onDemand; rate > 0 && rate < 1 {
+			rates[option] = rate
+		}
+	}
+	return rates
+}
+
+// discountRate returns the discount rate of an option, and whether it is
+// estimated from averageDiscountRates because there is none for it.
+func (dr DiscountRates) discountRate(option Option) (float64, bool) {
+	if rate, ok := dr[option]; ok {
+		return rate, false
+	}
+	return averageDiscountRates[option], true
+}
+
+// Options returns the options matching a Savings Plan type, a term and a
+// payment option, any of them when they are empty, in a stable order.
+func Options(savingsPlanType, term, paymentOption string) ([]Option, error) {
+	options := make([]Option, 0, len(averageDiscountRates))
+	for option := range averageDiscountRates {
+		if (savingsPlanType == "" || option.SavingsPlanType == savingsPlanType) &&
+			(term == "" || option.Term == term) &&
+			(paymentOption == "" || option.PaymentOption == paymentOption) {
+			options = append(options, option)
+		}
+	}
+	if len(options) == 0 {
+		return nil, fmt.Errorf("No Savings Plan matches type '%s', term '%s' and payment option '%s'.", savingsPlanType, term, paymentOption)
+	}
+	sort.Slice(options, func(i, j int) bool {
+		if options[i].SavingsPlanType != options[j].SavingsPlanType {
+			return options[i].SavingsPlanType < options[j].SavingsPlanType
+		} else if options[i].Term != options[j].Term {
+			return options[i].Term < options[j].Term
+		}
+		return upfrontShares[options[i].PaymentOption] < upfrontShares[options[j].PaymentOption]
+	})
+	return options, nil
+}
+
+type (
+	// Curve is the hourly on-demand spend of the usage a Savings Plan can
+	// cover, over consecutive hours.
+	Curve []float64
+
+	// InstanceGroup is the scope of an EC2 Instance Savings Plan.
+	InstanceGroup struct {
+		Region         string `json:"region"`
+		InstanceFamily string `json:"instanceFamily"`
+	}
+
+	// Curves are the curves of the usage each type of Savings Plan covers.
+	Curves struct {
+		// Compute is the usage Compute Savings Plans cover.
+		Compute Curve
+		// Instances is the EC2 usage of each instance family and region,
+		// which EC2 Instance Savings Plans cover.
+		Instances map[InstanceGroup]Curve
+	}
+
+	// Commitment is the hourly commitment recommended for the scope of an EC2
+	// Instance Savings Plan.
+	Commitment struct {
+		InstanceGroup
+		HourlyCommitment float64 `json:"hourlyCommitment"`
+	}
+
+	// Recommendation is the purchase recommended for an option, and its
+	// outcome projected over a month from the usage of the lookback period.
+	Recommendation struct {
+		Option
+		DiscountRate float64 `json:"discountRate"`
+		// EstimatedRate is set when DiscountRate is an average rather
+		// than the rate of the Savings Plans of the accounts, which makes
+		// the costs and savings estimates.
+		EstimatedRate bool `json:"estimatedRate"`
+		// HourlyCommitment is the spend per hour committed to, in
+		// Savings Plans rates.
+		HourlyCommitment float64 `json:"hourlyCommitment"`
+		// UpfrontCost is paid at purchase.
+		UpfrontCost float64 `json:"upfrontCost"`
+		// RecurringHourlyCost is paid every hour of the term.
+		RecurringHourlyCost float64 `json:"recurringHourlyCost"`
+		// MonthlyOnDemandCost is the cost of the covered usage without the
+		// Savings Plan.
+		MonthlyOnDemandCost float64 `json:"monthlyOnDemandCost"`
+		// MonthlyCost is the cost with the Savings Plan: the commitment and
+		// the usage it does not cover.
+		MonthlyCost       float64 `json:"monthlyCost"`
+		MonthlySavings    float64 `json:"monthlySavings"`
+		SavingsPercentage float64 `json:"savingsPercentage"`
+		// Utilization is the percentage of the commitment which is used.
+		Utilization float64 `json:"utilization"`
+		// Coverage is the percentage of the on-demand spend covered.
+		Coverage float64 `json:"coverage"`
+		// BreakEvenMonths is the number of months after which the savings
+		// make up for the upfront payment.
+		BreakEvenMonths float64 `json:"breakEvenMonths"`
+		// Commitments split the commitment of EC2 Instance Savings Plans by
+		// scope.
+		Commitments []Commitment `json:"commitments,omitempty"`
+	}
+
+	// outcome is the outcome of a commitment on a curve.
+	outcome struct {
+		onDemandCost float64
+		cost         float64
+		covered      float64
+		used         float64
+		commitment   float64
+	}
+)
+
+// NewCurves returns empty curves spanning a number of hours.
+func NewCurves(hours int) Curves {
+	return Curves{
+		Compute:   make(Curve, hours),
+		Instances: make(map[InstanceGroup]Curve),
+	}
+}
+
+// AddUsage adds the on-demand cost of usage during an hour to the curves of
+// the Savings Plans covering it. Usage types are those of the line items,
+// such as 'USE1-BoxUsage:m5.large'.
+func (c Curves) AddUsage(region, usageType string, hour int, cost float64) {
+	if hour < 0 || hour >= len(c.Compute) {
+		return
+	}
+	c.Compute[hour] += cost
+	if family, ok := instanceFamily(usageType); ok {
+		group := InstanceGroup{region, family}
+		curve, ok := c.Instances[group]
+		if !ok {
+			curve = make(Curve, len(c.Compute))
+			c.Instances[group] = curve
+		}
+		curve[hour] += cost
+	}
+}
+
+// instanceFamily returns the instance family of the usage type of an EC2
+// instance running on demand. It returns false for the other usage types.
+func instanceFamily(usageType string) (string, bool) {
+	i := strings.Index(usageType, "BoxUsage")
+	if i < 0 {
+		return "", false
+	}
+	rest := usageType[i+len("BoxUsage"):]
+	if rest == "" {
+		// The usage type of m1.small instances has no instance type.
+		return "m1", true
+	} else if rest[0] != ':' {
+		return "", false
+	}
+	instanceType := rest[1:]
+	if j := strings.Index(instanceType, "."); j > 0 {
+		return instanceType[:j], true
+	}
+	return "", false
+}
+
+// evaluate returns the outcome of a commitment on a curve, given the
+// discount of the Savings Plan.
+func (c Curve) evaluate(commitment, discount float64) outcome {
+	o := outcome{commitment: commitment * float64(len(c))}
+	capacity := commitment / (1 - discount)
+	for _, spend := range c {
+		covered := math.Min(spend, capacity)
+		o.onDemandCost += spend
+		o.covered += covered
+		o.used += covered * (1 - discount)
+		o.cost += commitment + spend - covered
+	}
+	return o
+}
+
+// optimalCommitment returns the hourly commitment which minimizes the cost of
+// a curve, given the discount of the Savings Plan. The cost is piecewise
+// linear in the commitment, so its minimum is at the on-demand equivalent
+// of the spend of one of the hours, or at zero. The smallest commitment is
+// returned on ties.
+func (c Curve) optimalCommitment(discount float64) float64 {
+	n := len(c)
+	if n == 0 {
+		return 0
+	}
+	sorted := append(Curve(nil), c...)
+	sort.Float64s(sorted)
+	above := 0.0
+	for _, spend := range sorted {
+		above += spend
+	}
+	best, bestCost := 0.0, above
+	for k, spend := range sorted {
+		above -= spend
+		if spend <= 0 || (k+1 < n && sorted[k+1] == spend) {
+			continue
+		}
+		// All the hours up to k spend at most spend, the others more.
+		commitment := spend * (1 - discount)
+		cost := float64(n)*commitment + above - float64(n-k-1)*spend
+		if cost < bestCost-1e-9 {
+			best, bestCost = commitment, cost
+		}
+	}
+	return best
+}
+
+// Recommend returns the recommendation for an option from the curves of the
+// usage of a period and the discount rates of the accounts. It returns false
+// if the option would save nothing.
+func Recommend(curves Curves, option Option, rates DiscountRates) (Recommendation, bool) {
+	discount, estimated := rates.discountRate(option)
+	rec := Recommendation{Option: option, DiscountRate: discount, EstimatedRate: estimated}
+	var total outcome
+	if option.SavingsPlanType == PlanCompute {
+		commitment := curves.Compute.optimalCommitment(discount)
+		rec.HourlyCommitment = commitment
+		total = curves.Compute.evaluate(commitment, discount)
+	} else {
+		rec.Commitments = make([]Commitment, 0)
+		for group, curve := range curves.Instances {
+			commitment := curve.optimalCommitment(discount)
+			o := curve.evaluate(commitment, discount)
+			total.onDemandCost += o.onDemandCost
+			total.cost += o.cost
+			total.covered += o.covered
+			total.used += o.used
+			total.commitment += o.commitment
+			if commitment > 0 {
+				rec.HourlyCommitment += commitment
+				rec.Commitments = append(rec.Commitments, Commitment{group, commitment})
+			}
+		}
+		sort.Slice(rec.Commitments, func(i, j int) bool {
+			return rec.Commitments[i].HourlyCommitment > rec.Commitments[j].HourlyCommitment
+		})
+	}
+	hours := float64(len(curves.Compute))
+	if rec.HourlyCommitment <= 0 || hours == 0 {
+		return rec, false
+	}
+	upfrontShare := upfrontShares[option.PaymentOption]
+	rec.UpfrontCost = rec.HourlyCommitment * termHours[option.Term] * upfrontShare
+	rec.RecurringHourlyCost = rec.HourlyCommitment * (1 - upfrontShare)
+	rec.MonthlyOnDemandCost = total.onDemandCost * hoursPerMonth / hours
+	rec.MonthlyCost = total.cost * hoursPerMonth / hours
+	rec.MonthlySavings = rec.MonthlyOnDemandCost - rec.MonthlyCost
+	if rec.MonthlySavings <= 0 {
+		return rec, false
+	}
+	rec.SavingsPercentage = 100 * rec.MonthlySavings / rec.MonthlyOnDemandCost
+	rec.Utilization = 100 * total.used / total.commitment
+	rec.Coverage = 100 * total.covered / total.onDemandCost
+	// The upfront payment is made up for by the spend avoided each month,
+	// net of the recurring payments.
+	rec.BreakEvenMonths = rec.UpfrontCost / (rec.MonthlySavings + rec.UpfrontCost/(termHours[option.Term]/hoursPerMonth))
+	return rec, true
+}
+
+// RecommendAll returns the recommendations for options which save
+// something, the one saving the most first.
+func RecommendAll(curves Curves, options []Option, rates DiscountRates) []Recommendation {
+	recs := make([]Recommendation, 0, len(options))
+	for _, option := range options {
+		if rec, ok := Recommend(curves, option, rates); ok {
+			recs = append(recs, rec)
+		}
+	}
+	sort.SliceStable(recs, func(i, j int) bool {
+		return recs[i].MonthlySavings > recs[j].MonthlySavings
+	})
+	return recs
+}
@@ -0,0 +1,182 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package savingsPlans
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/trackit/trackit/aws/s3"
+	"github.com/trackit/trackit/cache"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/es"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+const (
+	// defaultLookbackDays is the number of days before today the
+	// recommendations are based on when none is requested.
+	defaultLookbackDays = 30
+	// maxLookbackDays is the maximum number of days before today the
+	// recommendations can be based on.
+	maxLookbackDays = 90
+)
+
+type (
+	// Response is the recommended Savings Plans purchase, and the
+	// recommendations for each option which would save something.
+	// EstimatedRates is set when the discount rate of any of the
+	// recommendations is estimated, see Recommendation.EstimatedRate.
+	Response struct {
+		LookbackBegin  time.Time        `json:"lookbackBegin"`
+		LookbackEnd    time.Time        `json:"lookbackEnd"`
+		Hours          int              `json:"hours"`
+		EstimatedRates bool             `json:"estimatedRates"`
+		Recommendation *Recommendation  `json:"recommendation"`
+		Options        []Recommendation `json:"options"`
+	}
+)
+
+// recommendationsQueryArgs allows to get required queryArgs params
+var recommendationsQueryArgs = []routes.QueryArg{
+	routes.AwsAccountsOptionalQueryArg,
+	routes.QueryArg{
+		Name:        "lookbackDays",
+		Description: fmt.Sprintf("Number of days before today the recommendations are based on. Defaults to %d, at most %d", defaultLookbackDays, maxLookbackDays),
+		Type:        routes.QueryArgInt{},
+		Optional:    true,
+	},
+	routes.QueryArg{
+		Name:        "savingsPlanType",
+		Description: fmt.Sprintf("Type of Savings Plan to recommend: %s or %s. Defaults to both", PlanCompute, PlanEc2Instance),
+		Type:        routes.QueryArgString{},
+		Optional:    true,
+	},
+	routes.QueryArg{
+		Name:        "term",
+		Description: fmt.Sprintf("Term of the Savings Plan to recommend: %s or %s. Defaults to both", TermOneYear, TermThreeYears),
+		Type:        routes.QueryArgString{},
+		Optional:    true,
+	},
+	routes.QueryArg{
+		Name:        "paymentOption",
+		Description: fmt.Sprintf("Payment option of the Savings Plan to recommend: %s, %s or %s. Defaults to all", PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront),
+		Type:        routes.QueryArgString{},
+		Optional:    true,
+	},
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getRecommendations).With(
+			db.RequestTransaction{Db: db.Db},
+			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			routes.QueryArgs(recommendationsQueryArgs),
+			cache.UsersCache{Events: []string{cache.EventBillingData}},
+			routes.Documentation{
+				Summary:     "get Savings Plans purchase recommendations",
+				Description: "Responds with the Savings Plans purchase saving the most, and the recommendations for each type, term and payment option which would save something. The hourly commitments are derived from the hourly on-demand spend of the EC2, Fargate and Lambda usage of the line items over the lookback period, and the savings are projected over a month. The discount rates of the Savings Plans are those of the Savings Plans of the same kind the accounts already have, from the costs of the usage they covered over the lookback period. Without any, they are averages of the public rates across instance types and regions, so the costs and savings are estimates: estimatedRate is set on these recommendations, and estimatedRates in the response.",
+			},
+		),
+	}.H().Register("/savingsplans/recommendations")
+}
+
+// getRecommendations returns the Savings Plans recommendations based on the query params, in JSON format.
+func getRecommendations(request *http.Request, a routes.Arguments) (int, interface{}) {
+	user := a[users.AuthenticatedUser].(users.User)
+	tx := a[db.Transaction].(*sql.Tx)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	lookbackDays := defaultLookbackDays
+	parsedParams := QueryParams{
+		AccountList: []string{},
+	}
+	if a[recommendationsQueryArgs[0]] != nil {
+		parsedParams.AccountList = a[recommendationsQueryArgs[0]].([]string)
+	}
+	if a[recommendationsQueryArgs[1]] != nil {
+		lookbackDays = a[recommendationsQueryArgs[1]].(int)
+	}
+	if a[recommendationsQueryArgs[2]] != nil {
+		parsedParams.SavingsPlanType = a[recommendationsQueryArgs[2]].(string)
+	}
+	if a[recommendationsQueryArgs[3]] != nil {
+		parsedParams.Term = a[recommendationsQueryArgs[3]].(string)
+	}
+	if a[recommendationsQueryArgs[4]] != nil {
+		parsedParams.PaymentOption = a[recommendationsQueryArgs[4]].(string)
+	}
+	if lookbackDays < 1 || lookbackDays > maxLookbackDays {
+		return http.StatusBadRequest, fmt.Errorf("lookbackDays must be between 1 and %d", maxLookbackDays)
+	}
+	parsedParams.DateBegin = today.AddDate(0, 0, -lookbackDays)
+	parsedParams.DateEnd = today
+	returnCode, res, err := GetRecommendations(request.Context(), parsedParams, user, tx)
+	if err != nil {
+		return returnCode, err
+	}
+	return returnCode, res
+}
+
+// GetRecommendations returns the Savings Plans recommendations for the usage
+// of the accounts between DateBegin and DateEnd, which is excluded.
+func GetRecommendations(ctx context.Context, params QueryParams, user users.User, tx *sql.Tx) (int, Response, error) {
+	res := Response{
+		LookbackBegin: params.DateBegin,
+		LookbackEnd:   params.DateEnd,
+		Hours:         int(params.DateEnd.Sub(params.DateBegin) / time.Hour),
+		Options:       []Recommendation{},
+	}
+	options, err := Options(params.SavingsPlanType, params.Term, params.PaymentOption)
+	if err != nil {
+		return http.StatusBadRequest, res, err
+	}
+	accountsAndIndexes, returnCode, err := es.GetAccountsAndIndexes(params.AccountList, user, tx, s3.IndexPrefixLineItem)
+	if err != nil {
+		return returnCode, res, err
+	}
+	params.AccountList = accountsAndIndexes.Accounts
+	params.IndexList = accountsAndIndexes.Indexes
+	searchResult, returnCode, err := makeElasticSearchRequest(ctx, params, getElasticSearchParams)
+	if err != nil {
+		if returnCode == http.StatusOK {
+			return returnCode, res, nil
+		}
+		return returnCode, res, err
+	}
+	curves, err := prepareResponseCurves(ctx, searchResult, params)
+	if err != nil {
+		return http.StatusInternalServerError, res, err
+	}
+	searchResult, returnCode, err = makeElasticSearchRequest(ctx, params, getDiscountRatesElasticSearchParams)
+	if err != nil {
+		return returnCode, res, err
+	}
+	rates, err := prepareResponseDiscountRates(ctx, searchResult)
+	if err != nil {
+		return http.StatusInternalServerError, res, err
+	}
+	res.Options = RecommendAll(curves, options, rates)
+	if len(res.Options) > 0 {
+		res.Recommendation = &res.Options[0]
+	}
+	for _, rec := range res.Options {
+		res.EstimatedRates = res.EstimatedRates || rec.EstimatedRate
+	}
+	return http.StatusOK, res, nil
+}
@@ -0,0 +1,148 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package savingsPlans
+
+import (
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestInstanceFamily(t *testing.T) {
+	for usageType, expected := range map[string]string{
+		"BoxUsage:m5.large":              "m5",
+		"USE1-BoxUsage:c5.xlarge":        "c5",
+		"EU-DedicatedUsage:m5.large":     "",
+		"USW2-BoxUsage":                  "m1",
+		"USE1-Fargate-vCPU-Hours:perCPU": "",
+	} {
+		family, ok := instanceFamily(usageType)
+		if family != expected || ok != (expected != "") {
+			t.Errorf("Family of %s is '%s' (%v), expected '%s'", usageType, family, ok, expected)
+		}
+	}
+}
+
+func TestOptimalCommitment(t *testing.T) {
+	// At a 50% discount, covering an hour of usage pays for itself if the
+	// usage runs at least half of the hours.
+	curve := Curve{4, 4, 4, 4, 2, 2, 0, 0}
+	if c := curve.optimalCommitment(0.5); !almostEqual(c, 1) {
+		t.Errorf("Commitment is %f, expected 1", c)
+	}
+	if c := curve.optimalCommitment(0.8); !almostEqual(c, 0.8) {
+		t.Errorf("Commitment is %f, expected 0.8", c)
+	}
+	if c := curve.optimalCommitment(0.3); !almostEqual(c, 1.4) {
+		t.Errorf("Commitment is %f, expected 1.4", c)
+	}
+	if c := (Curve{0, 0, 0}).optimalCommitment(0.5); c != 0 {
+		t.Errorf("Commitment is %f, expected 0", c)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	o := Curve{4, 4, 2, 0}.evaluate(1, 0.5)
+	if !almostEqual(o.onDemandCost, 10) || !almostEqual(o.cost, 8) ||
+		!almostEqual(o.covered, 6) || !almostEqual(o.used, 3) || !almostEqual(o.commitment, 4) {
+		t.Errorf("Unexpected outcome %+v", o)
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	curves := NewCurves(hoursPerMonth)
+	for hour := 0; hour < hoursPerMonth; hour++ {
+		curves.AddUsage("us-east-1", "USE1-BoxUsage:m5.large", hour, 1)
+	}
+	rec, ok := Recommend(curves, Option{PlanEc2Instance, TermOneYear, PaymentAllUpfront}, nil)
+	if !ok {
+		t.Fatalf("Expected a recommendation")
+	}
+	if !almostEqual(rec.HourlyCommitment, 0.59) || !almostEqual(rec.MonthlySavings, 0.41*hoursPerMonth) ||
+		!almostEqual(rec.Utilization, 100) || !almostEqual(rec.Coverage, 100) || rec.RecurringHourlyCost != 0 {
+		t.Errorf("Unexpected recommendation %+v", rec)
+	}
+	if !rec.EstimatedRate {
+		t.Errorf("Discount rate should be estimated without the rates of the accounts")
+	}
+	if len(rec.Commitments) != 1 || rec.Commitments[0].InstanceFamily != "m5" {
+		t.Errorf("Unexpected commitments %+v", rec.Commitments)
+	}
+	// The whole term is paid upfront, and the usage costs 730 a month on
+	// demand, so it breaks even once 0.59 * 8760 is spent on demand.
+	if !almostEqual(rec.BreakEvenMonths, 0.59*8760/hoursPerMonth) {
+		t.Errorf("Break even after %f months, expected %f", rec.BreakEvenMonths, 0.59*8760/hoursPerMonth)
+	}
+	rec, _ = Recommend(curves, Option{PlanCompute, TermOneYear, PaymentNoUpfront}, nil)
+	if rec.BreakEvenMonths != 0 || rec.UpfrontCost != 0 {
+		t.Errorf("Unexpected upfront cost %f breaking even after %f months", rec.UpfrontCost, rec.BreakEvenMonths)
+	}
+	rates := DiscountRates{{PlanCompute, TermOneYear, PaymentNoUpfront}: 0.5}
+	rec, _ = Recommend(curves, Option{PlanCompute, TermOneYear, PaymentNoUpfront}, rates)
+	if rec.EstimatedRate || !almostEqual(rec.DiscountRate, 0.5) || !almostEqual(rec.HourlyCommitment, 0.5) {
+		t.Errorf("Unexpected recommendation with the rates of the accounts %+v", rec)
+	}
+}
+
+func TestRecommendAll(t *testing.T) {
+	curves := NewCurves(4)
+	curves.AddUsage("us-east-1", "USE1-Lambda-GB-Second", 0, 1)
+	options, err := Options(PlanEc2Instance, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recs := RecommendAll(curves, options, nil); len(recs) != 0 {
+		t.Errorf("Expected no recommendation for Lambda usage, got %d", len(recs))
+	}
+	for hour := 0; hour < 4; hour++ {
+		curves.AddUsage("us-east-1", "USE1-BoxUsage:t3.micro", hour, 1)
+	}
+	options, _ = Options("", "", "")
+	recs := RecommendAll(curves, options, nil)
+	if len(recs) != len(options) {
+		t.Fatalf("Expected %d recommendations, got %d", len(options), len(recs))
+	}
+	for i := 1; i < len(recs); i++ {
+		if recs[i].MonthlySavings > recs[0].MonthlySavings {
+			t.Errorf("%+v saves more than the recommendation %+v", recs[i].Option, recs[0].Option)
+		}
+	}
+	if _, err := Options("Unknown", "", ""); err == nil {
+		t.Errorf("Expected an error for an unknown Savings Plan type")
+	}
+}
+
+func TestDiscountRatesFromCosts(t *testing.T) {
+	compute := Option{PlanCompute, TermThreeYears, PaymentAllUpfront}
+	instance := Option{PlanEc2Instance, TermOneYear, PaymentNoUpfront}
+	rates := discountRatesFromCosts(map[Option]coveredCosts{
+		compute:                  {onDemand: 100, savingsPlans: 40},
+		instance:                 {},
+		{"", TermOneYear, ""}:    {onDemand: 100, savingsPlans: 70},
+		{PlanCompute, "5yr", ""}: {onDemand: 100, savingsPlans: 70},
+	})
+	if len(rates) != 1 || !almostEqual(rates[compute], 0.6) {
+		t.Errorf("Unexpected discount rates %v", rates)
+	}
+	if rate, estimated := rates.discountRate(compute); estimated || !almostEqual(rate, 0.6) {
+		t.Errorf("Expected the discount rate of the accounts, got %f (estimated %v)", rate, estimated)
+	}
+	if rate, estimated := rates.discountRate(instance); !estimated || rate != averageDiscountRates[instance] {
+		t.Errorf("Expected the average discount rate, got %f (estimated %v)", rate, estimated)
+	}
+}
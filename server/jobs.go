@@ -0,0 +1,166 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strconv"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/config"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/jobs"
+	"github.com/trackit/trackit/models"
+)
+
+// taskArgumentsKey is the key of the arguments of a task run as a job in its
+// context.
+const taskArgumentsKey = contextKey(iota)
+
+// contextKey is an unexported type to avoid collisions in context.Context
+// values.
+type contextKey uint
+
+// notJobTasks are the tasks which cannot be run as jobs.
+var notJobTasks = map[string]bool{
+	"server":      true,
+	"job-worker":  true,
+	"enqueue-job": true,
+}
+
+// awsAccountTasks are the tasks whose first argument is the ID of an AWS
+// account. Their jobs are subject to config.JobAccountConcurrency.
+var awsAccountTasks = map[string]bool{
+	"ingest":                      true,
+	"ingest-limit":                true,
+	"process-account":             true,
+	"process-account-plugins":     true,
+	"anomalies-detection":         true,
+	"generate-spreadsheet":        true,
+	"generate-tags-spreadsheet":   true,
+	"generate-master-spreadsheet": true,
+	"check-cost":                  true,
+}
+
+// userTasks are the tasks whose first argument is the ID of a user.
+var userTasks = map[string]bool{
+	"check-user-entitlement": true,
+	"update-tags":            true,
+	"onboard-tagbot":         true,
+	"apply-tag-remediation":  true,
+}
+
+func init() {
+	for name, task := range tasks {
+		if !notJobTasks[name] {
+			jobs.RegisterHandler(name, taskJobHandler(task))
+		}
+	}
+}
+
+// taskJobHandler turns a task into a job handler, passing the arguments of
+// the job to the task in its context.
+func taskJobHandler(task func(context.Context) error) jobs.Handler {
+	return func(ctx context.Context, args []string) error {
+		return task(context.WithValue(ctx, taskArgumentsKey, args))
+	}
+}
+
+// taskArguments returns the arguments of a task: those of its job if it runs
+// as one, the command line arguments otherwise.
+func taskArguments(ctx context.Context) []string {
+	if args, ok := ctx.Value(taskArgumentsKey).([]string); ok {
+		return args
+	}
+	return flag.Args()
+}
+
+// startJobWorkers starts config.JobWorkers workers running the jobs of the
+// queue.
+func startJobWorkers(ctx context.Context) {
+	jobs.Pool{
+		Db:      db.Db,
+		Owner:   backendId,
+		Workers: config.JobWorkers,
+	}.Start(ctx)
+}
+
+// taskJobWorker runs config.JobWorkers workers processing the job queue
+// without serving the API.
+func taskJobWorker(ctx context.Context) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	if config.JobWorkers <= 0 {
+		return errors.New("taskJobWorker requires at least one job worker")
+	}
+	startJobWorkers(ctx)
+	logger.Info("Started job workers.", map[string]interface{}{
+		"workers": config.JobWorkers,
+	})
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// taskEnqueueJob adds a job to the queue. Its first argument is the type of
+// the job, which is the name of a task, and the following ones are the
+// arguments of the task.
+func taskEnqueueJob(ctx context.Context) error {
+	args := flag.Args()
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Debug("Running task 'enqueue-job'.", map[string]interface{}{
+		"args": args,
+	})
+	if len(args) < 1 {
+		return errors.New("taskEnqueueJob requires the type of the job")
+	}
+	opts, err := getJobOptions(args[0], args[1:])
+	if err != nil {
+		logger.Error("Failed to enqueue job.", err.Error())
+		return err
+	}
+	job, err := jobs.Enqueue(db.Db, args[0], args[1:], opts)
+	if err != nil {
+		logger.Error("Failed to enqueue job.", err.Error())
+		return err
+	}
+	logger.Info("Enqueued job.", job)
+	return nil
+}
+
+// getJobOptions returns the options of a job of type typ: the AWS account
+// and the user it belongs to, found from its first argument.
+func getJobOptions(typ string, args []string) (opts jobs.Options, err error) {
+	if !awsAccountTasks[typ] && !userTasks[typ] {
+		return
+	} else if len(args) < 1 {
+		return opts, errors.New("this job requires at least an integer argument")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return
+	} else if userTasks[typ] {
+		opts.UserId = id
+		return
+	}
+	aa, err := models.AwsAccountByID(db.Db, id)
+	if err != nil {
+		return
+	}
+	opts.AwsAccountId = aa.ID
+	opts.UserId = aa.UserID
+	return
+}
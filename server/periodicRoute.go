@@ -0,0 +1,112 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/periodic"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getPeriodicTasks).With(
+			db.RequestTransaction{Db: db.Db},
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			users.RequireAdminUser{},
+			routes.Documentation{
+				Summary:     "get the periodic tasks",
+				Description: "Responds with the schedule and the state of the periodic tasks of the server answering the request, identified by the X-Backend-ID header, along with their latest runs. The runs are saved in the database by every server, each run being identified by the backend ID of the server which ran it. Since the due updates are run as jobs, their outcome is detailed in /admin/jobs and /jobs.",
+			},
+		),
+	}.H().Register("/admin/periodic")
+}
+
+// periodicRunRetention is how long the runs of the periodic tasks are kept in
+// the database.
+const periodicRunRetention = 30 * 24 * time.Hour
+
+// dbRunStore is a periodic.RunStore saving the runs of the periodic tasks in
+// the database, along with the backend ID of the server running them.
+type dbRunStore struct {
+	db        *sql.DB
+	backendId string
+}
+
+// SaveRun saves a run in the database and deletes the runs past
+// periodicRunRetention.
+func (s dbRunStore) SaveRun(task string, r periodic.Run) error {
+	dbRun := models.PeriodicTaskRun{
+		Task:      task,
+		BackendID: s.backendId,
+		Started:   r.Start.UTC(),
+		Ended:     r.End.UTC(),
+		Error:     r.Error,
+		Skipped:   r.Skipped,
+	}
+	if err := dbRun.Insert(s.db); err != nil {
+		return err
+	}
+	return models.DeletePeriodicTaskRunsEndedBefore(s.db, time.Now().UTC().Add(-periodicRunRetention))
+}
+
+// LatestRuns returns the n latest runs of a task saved by any server.
+func (s dbRunStore) LatestRuns(task string, n int) ([]periodic.Run, error) {
+	dbRuns, err := models.LatestPeriodicTaskRunsByTask(s.db, task, n)
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]periodic.Run, len(dbRuns))
+	for i, dbRun := range dbRuns {
+		runs[i] = periodic.Run{
+			Start:     dbRun.Started,
+			End:       dbRun.Ended,
+			Error:     dbRun.Error,
+			Skipped:   dbRun.Skipped,
+			BackendId: dbRun.BackendID,
+		}
+	}
+	return runs, nil
+}
+
+// mustParseSchedule parses a periodic task schedule, panicking if it is
+// invalid.
+func mustParseSchedule(expression string) periodic.Schedule {
+	if s, err := periodic.ParseSchedule(expression); err != nil {
+		panic(err)
+	} else {
+		return s
+	}
+}
+
+// getPeriodicTasks is a route handler which returns the status of the
+// periodic tasks, with their run history.
+func getPeriodicTasks(r *http.Request, a routes.Arguments) (int, interface{}) {
+	status, err := sched.Status()
+	if err != nil {
+		jsonlog.LoggerFromContextOrDefault(r.Context()).Error("Failed to get periodic task runs", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to retrieve periodic tasks.")
+	}
+	return http.StatusOK, status
+}
@@ -25,19 +25,26 @@ import (
 	uuid "github.com/satori/go.uuid"
 	"github.com/trackit/jsonlog"
 
+	_ "github.com/trackit/trackit/audit"
 	_ "github.com/trackit/trackit/aws"
 	_ "github.com/trackit/trackit/aws/routes"
 	_ "github.com/trackit/trackit/aws/s3"
 	"github.com/trackit/trackit/config"
 	_ "github.com/trackit/trackit/costs"
 	_ "github.com/trackit/trackit/costs/anomalies"
+	_ "github.com/trackit/trackit/costs/categories"
 	_ "github.com/trackit/trackit/costs/diff"
+	_ "github.com/trackit/trackit/costs/forecast"
+	_ "github.com/trackit/trackit/costs/redistribution"
 	_ "github.com/trackit/trackit/costs/tags"
+	"github.com/trackit/trackit/db"
 	"github.com/trackit/trackit/periodic"
 	_ "github.com/trackit/trackit/plugins"
 	_ "github.com/trackit/trackit/reports"
 	"github.com/trackit/trackit/routes"
 	_ "github.com/trackit/trackit/s3/costs"
+	_ "github.com/trackit/trackit/savingsPlans"
+	_ "github.com/trackit/trackit/tagging/policies"
 	_ "github.com/trackit/trackit/tagging/routes"
 	_ "github.com/trackit/trackit/usageReports/ec2"
 	_ "github.com/trackit/trackit/usageReports/ec2Coverage"
@@ -49,6 +56,7 @@ import (
 	_ "github.com/trackit/trackit/usageReports/riRds"
 	_ "github.com/trackit/trackit/users"
 	_ "github.com/trackit/trackit/users/shared_account"
+	_ "github.com/trackit/trackit/users/sso"
 )
 
 var buildNumber string = "unknown-build"
@@ -76,6 +84,14 @@ var tasks = map[string]func(context.Context) error{
 	"update-tags":                 taskUpdateTags,
 	"onboard-tagbot":              taskOnboardTagbot,
 	"check-unused-accounts":       taskCheckUnusedAccounts,
+	"check-budgets":               taskCheckBudgets,
+	"notify-anomalies":            taskNotifyAnomalies,
+	"ingest-billing-source":       taskIngestBillingSource,
+	"ingest-due-billing-sources":  taskIngestDueBillingSources,
+	"job-worker":                  taskJobWorker,
+	"enqueue-job":                 taskEnqueueJob,
+	"apply-tag-remediation":       taskApplyTagRemediation,
+	"schedule-due-jobs":           taskScheduleDueJobs,
 }
 
 // dockerHostnameRe matches the value of the HOSTNAME environment variable when
@@ -105,7 +121,19 @@ func main() {
 var sched periodic.Scheduler
 
 func schedulePeriodicTasks() {
-	sched.Register(taskIngestDue, 10*time.Minute, "ingest-due-updates")
+	sched.Store = dbRunStore{db.Db, backendId}
+	sched.RegisterSchedule(taskScheduleDueJobs, periodic.Every(10*time.Minute), "schedule-due-jobs", periodic.Options{
+		Jitter:        30 * time.Second,
+		SkipIfRunning: true,
+	})
+	sched.RegisterSchedule(taskCheckBudgets, mustParseSchedule("0 * * * *"), "check-budgets", periodic.Options{
+		Jitter:        5 * time.Minute,
+		SkipIfRunning: true,
+	})
+	sched.RegisterSchedule(taskNotifyAnomalies, mustParseSchedule("0 8 * * *"), "notify-anomalies", periodic.Options{
+		Jitter:        5 * time.Minute,
+		SkipIfRunning: true,
+	})
 	sched.Start()
 }
 
@@ -116,6 +144,12 @@ func taskServer(ctx context.Context) error {
 		schedulePeriodicTasks()
 		logger.Info("Scheduled periodic tasks.", nil)
 	}
+	if config.JobWorkers > 0 {
+		startJobWorkers(ctx)
+		logger.Info("Started job workers.", map[string]interface{}{
+			"workers": config.JobWorkers,
+		})
+	}
 	logger.Info(fmt.Sprintf("Listening on %s.", config.HttpAddress), nil)
 	err := http.ListenAndServe(config.HttpAddress, nil)
 	logger.Error("Server stopped.", err.Error())
@@ -18,7 +18,6 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"flag"
 	"strconv"
 	"time"
 
@@ -35,7 +34,7 @@ import (
 // taskAnomaliesDetection processes an AwsAccount to email
 // the user if anomalies are detected.
 func taskAnomaliesDetection(ctx context.Context) error {
-	args := flag.Args()
+	args := taskArguments(ctx)
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	logger.Debug("Running task 'anomalies-detection'.", map[string]interface{}{
 		"args": args,
@@ -76,13 +75,7 @@ func processAnomaliesForAccount(ctx context.Context, aaId int) (err error) {
 			"error":        err.Error(),
 		})
 	}
-	var affectedRoutes = []string{
-		"/costs/anomalies",
-		"/costs/anomalies/filters",
-		"/costs/anomalies/snooze",
-		"/costs/anomalies/unsnooze",
-	}
-	_ = cache.RemoveMatchingCache(affectedRoutes, []string{aa.AwsIdentity}, logger)
+	_ = cache.Invalidate(cache.EventAnomalies, []string{aa.AwsIdentity}, logger)
 	return
 }
 
@@ -0,0 +1,58 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/tagging/remediation"
+)
+
+// taskApplyTagRemediation changes the tags of the resources of an approved
+// tag remediation in AWS, or rolls them back. Its arguments are the ID of
+// the owner of the remediation and the ID of the remediation.
+func taskApplyTagRemediation(ctx context.Context) error {
+	args := taskArguments(ctx)
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Info("Running task 'apply-tag-remediation'.", map[string]interface{}{
+		"args": args,
+	})
+	userId, remediationId, err := checkApplyTagRemediationArguments(args)
+	if err == nil {
+		err = remediation.Apply(ctx, db.Db, userId, remediationId)
+	}
+	if err != nil {
+		logger.Error("Failed to execute task 'apply-tag-remediation'.", map[string]interface{}{
+			"args":  args,
+			"error": err.Error(),
+		})
+	}
+	return err
+}
+
+func checkApplyTagRemediationArguments(args []string) (userId, remediationId int, err error) {
+	if len(args) < 2 {
+		err = errors.New("Task 'apply-tag-remediation' requires a user ID and a remediation ID")
+	} else if userId, err = strconv.Atoi(args[0]); err != nil {
+	} else {
+		remediationId, err = strconv.Atoi(args[1])
+	}
+	return
+}
@@ -18,7 +18,6 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"flag"
 	"math/rand"
 	"strconv"
 	"time"
@@ -34,7 +33,7 @@ import (
 
 // taskIngest ingests billing data for a given BillRepository and AwsAccount.
 func taskIngest(ctx context.Context) error {
-	args := flag.Args()
+	args := taskArguments(ctx)
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	logger.Debug("Running task 'ingest'.", map[string]interface{}{
 		"args": args,
@@ -90,14 +89,7 @@ func ingestBillingDataForBillRepository(ctx context.Context, aaId, brId int) (er
 	}
 	updateCompletion(ctx, aaId, brId, db.Db, updateId, err)
 	updateSubAccounts(ctx, aa)
-	var affectedRoutes = []string{
-		"/costs",
-		"/costs/diff",
-		"/costs/tags/keys",
-		"/costs/tags/values",
-		"/s3/costs",
-	}
-	_ = cache.RemoveMatchingCache(affectedRoutes, []string{aa.AwsIdentity}, logger)
+	_ = cache.Invalidate(cache.EventBillingData, []string{aa.AwsIdentity}, logger)
 	return
 }
 
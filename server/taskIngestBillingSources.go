@@ -0,0 +1,175 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/olivere/elastic"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/anomaliesDetection"
+	"github.com/trackit/trackit/aws"
+	"github.com/trackit/trackit/billing"
+	"github.com/trackit/trackit/cache"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+)
+
+// billingSourceErrorMaxLength is the size of the error column of the
+// billing_source table.
+const billingSourceErrorMaxLength = 1024
+
+// taskIngestBillingSource ingests the new export files of a billing source.
+func taskIngestBillingSource(ctx context.Context) error {
+	args := taskArguments(ctx)
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Debug("Running task 'ingest-billing-source'.", map[string]interface{}{
+		"args": args,
+	})
+	if len(args) != 1 {
+		return errors.New("taskIngestBillingSource requires an integer argument")
+	} else if sourceId, err := strconv.Atoi(args[0]); err != nil {
+		return err
+	} else if dbSource, err := models.BillingSourceByID(db.Db, sourceId); err != nil {
+		logger.Error("Failed to get billing source.", map[string]interface{}{
+			"billingSourceId": sourceId,
+			"error":           err.Error(),
+		})
+		return err
+	} else {
+		return ingestBillingSource(ctx, dbSource)
+	}
+}
+
+// taskIngestDueBillingSources lists all billing sources with due updates and
+// updates them.
+func taskIngestDueBillingSources(ctx context.Context) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	dbSources, err := models.BillingSourcesWithDueUpdate(db.Db)
+	if err != nil {
+		logger.Error("Failed to get billing sources with due update.", err.Error())
+		return err
+	}
+	for _, dbSource := range dbSources {
+		if sErr := ingestBillingSource(ctx, dbSource); sErr != nil {
+			err = sErr
+		}
+	}
+	return err
+}
+
+// ingestBillingSource ingests the new export files of a billing source,
+// records the accounts found in them and schedules its next update. The
+// anomalies of its accounts are detected again if new files were ingested.
+func ingestBillingSource(ctx context.Context, dbSource *models.BillingSource) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	lastImported, accounts, err := billing.UpdateSource(ctx, *dbSource)
+	if err != nil {
+		logger.Error("Failed to ingest billing source.", map[string]interface{}{
+			"billingSourceId": dbSource.ID,
+			"error":           err.Error(),
+		})
+		dbSource.Error = err.Error()
+		if len(dbSource.Error) > billingSourceErrorMaxLength {
+			dbSource.Error = dbSource.Error[:billingSourceErrorMaxLength]
+		}
+	} else {
+		dbSource.Error = ""
+	}
+	accountIds := make([]string, 0, len(accounts))
+	for accountId, name := range accounts {
+		if aErr := models.AddBillingSourceAccount(db.Db, dbSource.ID, accountId, name); aErr != nil {
+			logger.Error("Failed to record billing source account.", map[string]interface{}{
+				"billingSourceId": dbSource.ID,
+				"accountId":       accountId,
+				"error":           aErr.Error(),
+			})
+		}
+		accountIds = append(accountIds, accountId)
+	}
+	updated := lastImported.After(dbSource.LastImported)
+	if updated {
+		dbSource.LastImported = lastImported
+	}
+	if sErr := billing.SealCredentials(dbSource); sErr != nil {
+		logger.Error("Failed to encrypt billing source credentials.", map[string]interface{}{
+			"billingSourceId": dbSource.ID,
+			"error":           sErr.Error(),
+		})
+	}
+	updateDeltaMinutes := time.Duration(UpdateIntervalMinutes-UpdateIntervalWindow/2+rand.Int63n(UpdateIntervalWindow)) * time.Minute
+	dbSource.NextUpdate = time.Now().Add(updateDeltaMinutes)
+	if uErr := dbSource.Update(db.Db); uErr != nil {
+		logger.Error("Failed to update billing source.", map[string]interface{}{
+			"billingSourceId": dbSource.ID,
+			"error":           uErr.Error(),
+		})
+		return uErr
+	}
+	if updated {
+		_ = cache.Invalidate(cache.EventBillingData, accountIds, logger)
+		processAnomaliesForBillingSource(ctx, dbSource)
+	}
+	return err
+}
+
+// processAnomaliesForBillingSource detects the anomalies of the accounts
+// found in the exports of a billing source.
+func processAnomaliesForBillingSource(ctx context.Context, dbSource *models.BillingSource) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	dbAccounts, err := models.BillingSourceAccountsByBillingSourceID(db.Db, dbSource.ID)
+	if err != nil {
+		logger.Error("Failed to get billing source accounts.", map[string]interface{}{
+			"billingSourceId": dbSource.ID,
+			"error":           err.Error(),
+		})
+		return
+	}
+	accountIds := make([]string, len(dbAccounts))
+	for i, dbAccount := range dbAccounts {
+		accountIds[i] = dbAccount.AccountID
+		// The anomalies detection only relies on the owner and the
+		// identity of the account, which is that of the line items.
+		aa := aws.AwsAccount{
+			UserId:      dbSource.UserID,
+			Pretty:      dbAccount.Name,
+			AwsIdentity: dbAccount.AccountID,
+		}
+		if lastUpdate, err := anomalies.RunAnomaliesDetection(aa, dbAccount.LastAnomaliesUpdate, ctx); err != nil {
+			if !elastic.IsNotFound(err) {
+				logger.Error("Failed to detect anomalies.", map[string]interface{}{
+					"billingSourceId": dbSource.ID,
+					"accountId":       dbAccount.AccountID,
+					"error":           err.Error(),
+				})
+			}
+		} else {
+			dbAccount.LastAnomaliesUpdate = lastUpdate
+			if err := dbAccount.Update(db.Db); err != nil {
+				logger.Error("Failed to register anomalies update.", map[string]interface{}{
+					"billingSourceId": dbSource.ID,
+					"accountId":       dbAccount.AccountID,
+					"error":           err.Error(),
+				})
+			}
+		}
+	}
+	_ = cache.Invalidate(cache.EventAnomalies, accountIds, logger)
+}
@@ -18,7 +18,6 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"flag"
 	"strconv"
 	"time"
 
@@ -35,7 +34,7 @@ const iso8601DateFormat = "2006-01-02"
 
 // taskIngest ingests billing data for a given BillRepository and AwsAccount.
 func taskIngestLimit(ctx context.Context) error {
-	args := flag.Args()
+	args := taskArguments(ctx)
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	logger.Debug("Running task 'ingest-limit'.", map[string]interface{}{
 		"args": args,
@@ -102,13 +101,6 @@ func ingestBillingDataForBillRepositoryLimit(ctx context.Context, aaId, brId int
 	}
 	updateCompletion(ctx, aaId, brId, db.Db, updateId, err)
 	updateSubAccounts(ctx, aa)
-	var affectedRoutes = []string{
-		"/costs",
-		"/costs/diff",
-		"/costs/tags/keys",
-		"/costs/tags/values",
-		"/s3/costs",
-	}
-	_ = cache.RemoveMatchingCache(affectedRoutes, []string{aa.AwsIdentity}, logger)
+	_ = cache.Invalidate(cache.EventBillingData, []string{aa.AwsIdentity}, logger)
 	return
 }
@@ -17,8 +17,9 @@ package main
 import (
 	"context"
 	"database/sql"
-	"flag"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/trackit/jsonlog"
 
@@ -30,27 +31,35 @@ import (
 )
 
 // taskMasterSpreadsheet generates Spreadsheet with reports for a master AwsAccount including subaccounts.
+// An organizational unit ID can be given as last argument to restrict the report to the accounts of the unit.
 func taskMasterSpreadsheet(ctx context.Context) error {
-	args := flag.Args()
+	args := taskArguments(ctx)
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	logger.Debug("Running task 'Master Spreadsheet'.", map[string]interface{}{
 		"args": args,
 	})
 
+	var unitId string
+	if len(args) == 2 || len(args) == 4 {
+		unitId = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
 	aaId, date, err := checkArguments(args)
 	if err != nil {
 		return err
 	} else {
-		return generateMasterReport(ctx, aaId, date)
+		return generateMasterReport(ctx, aaId, date, unitId)
 	}
 }
 
-func generateMasterReport(ctx context.Context, aaId int, date time.Time) (err error) {
+func generateMasterReport(ctx context.Context, aaId int, date time.Time, unitId string) (err error) {
 	var tx *sql.Tx
 	var aa aws.AwsAccount
 	var updateId int64
 	var generation bool
-	forceGeneration := !date.IsZero()
+	var dbAccounts []*models.AwsAccount
+	// Reports restricted to a unit do not replace the report of the whole organization.
+	forceGeneration := !date.IsZero() || unitId != ""
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	defer func() {
 		if tx != nil {
@@ -65,15 +74,20 @@ func generateMasterReport(ctx context.Context, aaId int, date time.Time) (err er
 	} else if aa, err = aws.GetAwsAccountWithId(aaId, tx); err != nil {
 	} else if generation, err = checkMasterReportGeneration(ctx, db.Db, aa, forceGeneration); err != nil || !generation {
 	} else if updateId, err = registerMasterAccountReportGeneration(db.Db, aa); err != nil {
-	} else if dbAccounts, err := getAccounts(ctx, db.Db, aa); err != nil {
+	} else if dbAccounts, err = getAccounts(ctx, db.Db, aa); err != nil {
 	} else {
 		accounts := make([]aws.AwsAccount, 0)
 		for _, dbAccount := range dbAccounts {
 			account := aws.AwsAccountFromDbAwsAccount(*dbAccount)
 			accounts = append(accounts, account)
 		}
-		errs := reports.GenerateReport(ctx, aa, accounts, date)
-		updateMasterAccountReportGenerationCompletion(ctx, aaId, db.Db, updateId, nil, errs, forceGeneration)
+		if unitId != "" {
+			aa, accounts, err = restrictAccountsToUnit(tx, aa, accounts, unitId)
+		}
+		if err == nil {
+			errs := reports.GenerateReport(ctx, aa, accounts, date)
+			updateMasterAccountReportGenerationCompletion(ctx, aaId, db.Db, updateId, nil, errs, forceGeneration)
+		}
 	}
 	if err != nil {
 		logger.Error("Error while generating spreadsheet report.", map[string]interface{}{
@@ -85,6 +99,36 @@ func generateMasterReport(ctx context.Context, aaId int, date time.Time) (err er
 	return
 }
 
+// restrictAccountsToUnit keeps the accounts which are in an organizational unit,
+// and names the report after the unit.
+func restrictAccountsToUnit(tx *sql.Tx, aa aws.AwsAccount, accounts []aws.AwsAccount, unitId string) (aws.AwsAccount, []aws.AwsAccount, error) {
+	organization, err := aws.GetOrganization(tx, aa)
+	if err != nil {
+		return aa, nil, err
+	}
+	unit, ok := organization.Unit(unitId)
+	if !ok {
+		return aa, nil, aws.ErrOrganizationUnitNotFound
+	}
+	inUnit := make(map[string]bool)
+	for _, account := range organization.AccountsInUnit(unitId) {
+		inUnit[account] = true
+	}
+	unitAccounts := make([]aws.AwsAccount, 0, len(accounts))
+	for _, account := range accounts {
+		if inUnit[account.AwsIdentity] {
+			unitAccounts = append(unitAccounts, account)
+		}
+	}
+	aa.Pretty = aa.Pretty + "_" + strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' {
+			return r
+		}
+		return '-'
+	}, unit.Name)
+	return aa, unitAccounts, nil
+}
+
 func getAccounts(ctx context.Context, db *sql.DB, aa aws.AwsAccount) ([]*models.AwsAccount, error) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	dbAllAccounts := make([]*models.AwsAccount, 0)
@@ -0,0 +1,37 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/anomaliesDetection"
+	"github.com/trackit/trackit/db"
+)
+
+// taskNotifyAnomalies sends each user the digest of the new anomalies
+// detected on their accounts.
+func taskNotifyAnomalies(ctx context.Context) (err error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Info("Running task 'notify-anomalies'.", nil)
+	if err = anomalies.NotifyAnomalies(ctx, db.Db); err != nil {
+		logger.Error("Failed to execute task 'notify-anomalies'.", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	return
+}
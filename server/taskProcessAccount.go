@@ -18,7 +18,6 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"flag"
 	"strconv"
 	"time"
 
@@ -30,6 +29,7 @@ import (
 	"github.com/trackit/trackit/aws/usageReports/elasticache"
 	"github.com/trackit/trackit/aws/usageReports/es"
 	"github.com/trackit/trackit/aws/usageReports/history"
+	"github.com/trackit/trackit/aws/usageReports/inventory"
 	"github.com/trackit/trackit/aws/usageReports/lambda"
 	"github.com/trackit/trackit/aws/usageReports/rds"
 	"github.com/trackit/trackit/aws/usageReports/riEc2"
@@ -68,7 +68,7 @@ func checkArgumentsForProcessAccount(args []string) (int, time.Time, error) {
 
 // taskProcessAccount processes an AwsAccount to retrieve data from the AWS api.
 func taskProcessAccount(ctx context.Context) error {
-	args := flag.Args()
+	args := taskArguments(ctx)
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	logger.Debug("Running task 'process-account'.", map[string]interface{}{
 		"args": args,
@@ -100,7 +100,7 @@ func ingestDataForAccount(ctx context.Context, aaId int, date time.Time) (err er
 	} else if aa, err = aws.GetAwsAccountWithId(aaId, tx); err != nil {
 	} else if updateId, err = registerAccountProcessing(db.Db, aa); err != nil {
 	} else {
-		var ec2Err, rdsErr, esErr, elastiCacheErr, lambdaErr, riEc2Err, riRdsErr, odToRiEc2Err, ebsErr error
+		var ec2Err, rdsErr, esErr, elastiCacheErr, lambdaErr, riEc2Err, riRdsErr, odToRiEc2Err, ebsErr, inventoryErr error
 		if date.IsZero() {
 			ec2Err = processAccountEC2(ctx, aa)
 			rdsErr = processAccountRDS(ctx, aa)
@@ -111,32 +111,19 @@ func ingestDataForAccount(ctx context.Context, aaId int, date time.Time) (err er
 			riRdsErr = riRdS.FetchDailyInstancesStats(ctx, aa)
 			odToRiEc2Err = onDemandToRiEc2.RunOnDemandToRiEc2(ctx, aa)
 			ebsErr = processAccountEbsSnapshot(ctx, aa)
+			inventoryErr = processAccountInventory(ctx, aa)
 		}
 		historyCreated, historyErr := processAccountHistory(ctx, aa, date)
-		updateAccountProcessingCompletion(ctx, aaId, db.Db, updateId, nil, rdsErr, ec2Err, esErr, elastiCacheErr, lambdaErr, riEc2Err, riRdsErr, odToRiEc2Err, historyErr, ebsErr, historyCreated)
+		updateAccountProcessingCompletion(ctx, aaId, db.Db, updateId, nil, rdsErr, ec2Err, esErr, elastiCacheErr, lambdaErr, riEc2Err, riRdsErr, odToRiEc2Err, historyErr, ebsErr, inventoryErr, historyCreated)
 	}
 	if err != nil {
-		updateAccountProcessingCompletion(ctx, aaId, db.Db, updateId, err, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, false)
+		updateAccountProcessingCompletion(ctx, aaId, db.Db, updateId, err, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, false)
 		logger.Error("Failed to process account data.", map[string]interface{}{
 			"awsAccountId": aaId,
 			"error":        err.Error(),
 		})
 	}
-	var affectedRoutes = []string{
-		"/ec2",
-		"/ec2/coverage",
-		"/ec2/unused",
-		"/elasticache",
-		"/elasticache/unused",
-		"/es",
-		"/es/unused",
-		"/lambda",
-		"/rds",
-		"/rds/unused",
-		"/ri/ec2",
-		"/ri/rds",
-	}
-	_ = cache.RemoveMatchingCache(affectedRoutes, []string{aa.AwsIdentity}, logger)
+	_ = cache.Invalidate(cache.EventUsageReports, []string{aa.AwsIdentity}, logger)
 	return
 }
 
@@ -152,9 +139,9 @@ func registerAccountProcessing(db *sql.DB, aa aws.AwsAccount) (int64, error) {
 	return res.LastInsertId()
 }
 
-func updateAccountProcessingCompletion(ctx context.Context, aaId int, db *sql.DB, updateId int64, jobErr, rdsErr, ec2Err, esErr, elastiCacheErr, lambdaErr, riEc2Err, riRdsErr, odToRiEc2Err, historyErr, ebsErr error, historyCreated bool) {
+func updateAccountProcessingCompletion(ctx context.Context, aaId int, db *sql.DB, updateId int64, jobErr, rdsErr, ec2Err, esErr, elastiCacheErr, lambdaErr, riEc2Err, riRdsErr, odToRiEc2Err, historyErr, ebsErr, inventoryErr error, historyCreated bool) {
 	updateNextUpdateAccount(db, aaId)
-	rErr := registerAccountProcessingCompletion(db, updateId, jobErr, rdsErr, ec2Err, esErr, elastiCacheErr, lambdaErr, riEc2Err, riRdsErr, odToRiEc2Err, historyErr, ebsErr, historyCreated)
+	rErr := registerAccountProcessingCompletion(db, updateId, jobErr, rdsErr, ec2Err, esErr, elastiCacheErr, lambdaErr, riEc2Err, riRdsErr, odToRiEc2Err, historyErr, ebsErr, inventoryErr, historyCreated)
 	if rErr != nil {
 		logger := jsonlog.LoggerFromContextOrDefault(ctx)
 		logger.Error("Failed to register account processing completion.", map[string]interface{}{
@@ -180,7 +167,7 @@ func updateNextUpdateAccount(db *sql.DB, aaId int) error {
 	return err
 }
 
-func registerAccountProcessingCompletion(db *sql.DB, updateId int64, jobErr, rdsErr, ec2Err, esErr, elastiCacheErr, lambdaErr, riEc2Err, riRdsErr, odToRiEc2Err, historyErr, ebsErr error, historyCreated bool) error {
+func registerAccountProcessingCompletion(db *sql.DB, updateId int64, jobErr, rdsErr, ec2Err, esErr, elastiCacheErr, lambdaErr, riEc2Err, riRdsErr, odToRiEc2Err, historyErr, ebsErr, inventoryErr error, historyCreated bool) error {
 	const sqlstr = `UPDATE aws_account_update_job SET
 		completed=?,
 		jobError=?,
@@ -190,13 +177,14 @@ func registerAccountProcessingCompletion(db *sql.DB, updateId int64, jobErr, rds
 		elastiCacheError=?,
 		lambdaError=?,
 		ebsError=?,
+		inventoryError=?,
 		riEc2Error=?,
 		riRdsError=?,
 		odToRiEc2Error=?,
 		historyError=?,
 		monthly_reports_generated=?
 	WHERE id=?`
-	_, err := db.Exec(sqlstr, time.Now(), errToStr(jobErr), errToStr(rdsErr), errToStr(ec2Err), errToStr(esErr), errToStr(elastiCacheErr), errToStr(lambdaErr), errToStr(ebsErr), errToStr(riEc2Err), errToStr(riRdsErr), errToStr(odToRiEc2Err), errToStr(historyErr), historyCreated, updateId)
+	_, err := db.Exec(sqlstr, time.Now(), errToStr(jobErr), errToStr(rdsErr), errToStr(ec2Err), errToStr(esErr), errToStr(elastiCacheErr), errToStr(lambdaErr), errToStr(ebsErr), errToStr(inventoryErr), errToStr(riEc2Err), errToStr(riRdsErr), errToStr(odToRiEc2Err), errToStr(historyErr), historyCreated, updateId)
 	return err
 }
 
@@ -265,6 +253,20 @@ func processAccountEbsSnapshot(ctx context.Context, aa aws.AwsAccount) error {
 	return err
 }
 
+// processAccountInventory lists the resources of an AwsAccount with the
+// Resource Groups Tagging API
+func processAccountInventory(ctx context.Context, aa aws.AwsAccount) error {
+	err := inventory.FetchDailyResources(ctx, aa)
+	if err != nil {
+		logger := jsonlog.LoggerFromContextOrDefault(ctx)
+		logger.Error("Failed to ingest resource inventory", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"error":        err.Error(),
+		})
+	}
+	return err
+}
+
 // processAccountLambda processes all the Lambda data for an AwsAccount
 func processAccountLambda(ctx context.Context, aa aws.AwsAccount) error {
 	err := lambda.FetchDailyFunctionsStats(ctx, aa)
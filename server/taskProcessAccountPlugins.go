@@ -18,7 +18,6 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"flag"
 	"fmt"
 	"strconv"
 	"strings"
@@ -30,13 +29,14 @@ import (
 	"github.com/trackit/trackit/cache"
 	"github.com/trackit/trackit/db"
 	"github.com/trackit/trackit/es"
+	"github.com/trackit/trackit/notifications"
 	core "github.com/trackit/trackit/plugins/account/core"
 	"github.com/trackit/trackit/users"
 )
 
 // taskProcessAccountPlugins is the entry point for account plugins processing
 func taskProcessAccountPlugins(ctx context.Context) error {
-	args := flag.Args()
+	args := taskArguments(ctx)
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	logger.Debug("Running task 'process-account-plugin'.", map[string]interface{}{
 		"args": args,
@@ -82,16 +82,14 @@ func preparePluginsProcessingForAccount(ctx context.Context, aaId int) (err erro
 			"error":        err.Error(),
 		})
 	}
-	var affectedRoutes = []string{
-		"/plugins/results",
-	}
-	_ = cache.RemoveMatchingCache(affectedRoutes, []string{aa.AwsIdentity}, logger)
+	_ = cache.Invalidate(cache.EventPluginResults, []string{aa.AwsIdentity}, logger)
 	return
 }
 
 // runPluginsForAccount runs all the registered plugins for an account
 func runPluginsForAccount(ctx context.Context, user users.User, aa aws.AwsAccount) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	var results []core.PluginResultES
 	for _, plugin := range core.RegisteredAccountPlugins {
 		if plugin.BillingDataOnly == false && strings.TrimSpace(aa.RoleArn) == "" {
 			continue
@@ -130,9 +128,42 @@ func runPluginsForAccount(ctx context.Context, user users.User, aa aws.AwsAccoun
 			pluginResultES.Passed = res.Passed
 		}
 		core.IngestPluginResult(ctx, aa, pluginResultES)
+		results = append(results, pluginResultES)
+	}
+	if err := notifyPluginResults(ctx, aa, results); err != nil {
+		logger.Error("Failed to notify plugin results.", map[string]interface{}{
+			"awsAccountId": aa.Id,
+			"error":        err.Error(),
+		})
 	}
 }
 
+// notifyPluginResults notifies the owner of an account of the plugins which
+// failed or did not pass.
+func notifyPluginResults(ctx context.Context, aa aws.AwsAccount, results []core.PluginResultES) error {
+	var failed []core.PluginResultES
+	body := fmt.Sprintf("Some checks did not pass on the AWS account %s (%s):\n", aa.Pretty, aa.AwsIdentity)
+	for _, result := range results {
+		if result.Error != "" {
+			body += fmt.Sprintf("- %s: %s\n", result.Label, result.Error)
+		} else if result.Status != "green" {
+			body += fmt.Sprintf("- %s: %s\n", result.Label, result.Result)
+		} else {
+			continue
+		}
+		failed = append(failed, result)
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return notifications.Notify(ctx, db.Db, aa.UserId, notifications.Notification{
+		Event:   notifications.EventPlugin,
+		Subject: fmt.Sprintf("%d checks did not pass on %s", len(failed), aa.Pretty),
+		Body:    body,
+		Data:    failed,
+	})
+}
+
 func registerAccountPluginsProcessing(db *sql.DB, aa aws.AwsAccount) (int64, error) {
 	const sqlstr = `INSERT INTO aws_account_plugins_job(
 	aws_account_id,
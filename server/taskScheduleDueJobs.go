@@ -0,0 +1,217 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/jobs"
+)
+
+// scheduleDueJobsLock is the name of the database lock held while the due
+// jobs are scheduled, so that a single server schedules them at a time.
+const scheduleDueJobsLock = "trackit.schedule-due-jobs"
+
+// dueJobSource is a table whose rows are due for a job when their next
+// update is past.
+type dueJobSource struct {
+	// typ is the type of the jobs, which is the name of a task.
+	typ string
+	// query selects the ID of the due rows and the arguments of their
+	// jobs, separated by spaces.
+	query string
+	// update sets the next update of a row from its ID.
+	update string
+	// next returns the next update of a row whose job was enqueued at now.
+	// The tasks updating the next update themselves overwrite it once done.
+	next func(now time.Time) time.Time
+}
+
+// dueJobSources are the tables whose due rows are turned into jobs by
+// taskScheduleDueJobs.
+var dueJobSources = []dueJobSource{
+	{
+		typ:    "ingest",
+		query:  `SELECT id, CONCAT(aws_account_id, ' ', id) FROM aws_bill_repository_due_update`,
+		update: `UPDATE aws_bill_repository SET next_update = ? WHERE id = ?`,
+		next:   nextIngestion,
+	},
+	{
+		typ:    "ingest-billing-source",
+		query:  `SELECT id, id FROM billing_source WHERE next_update <= NOW()`,
+		update: `UPDATE billing_source SET next_update = ? WHERE id = ?`,
+		next:   nextIngestion,
+	},
+	{
+		typ:    "process-account",
+		query:  `SELECT id, id FROM aws_account_due_update`,
+		update: `UPDATE aws_account SET next_update = ? WHERE id = ?`,
+		next:   nextDay,
+	},
+	{
+		typ:    "process-account-plugins",
+		query:  `SELECT id, id FROM aws_account_plugins_due_update`,
+		update: `UPDATE aws_account SET next_update_plugins = ? WHERE id = ?`,
+		next:   nextDay,
+	},
+	{
+		typ:    "anomalies-detection",
+		query:  `SELECT id, id FROM anomalies_detection_due_update`,
+		update: `UPDATE aws_account SET next_update_anomalies_detection = ? WHERE id = ?`,
+		next:   nextDay,
+	},
+	{
+		typ:    "generate-spreadsheet",
+		query:  `SELECT id, id FROM aws_account_spreadsheets_reports_due_update`,
+		update: `UPDATE aws_account SET next_spreadsheet_report_generation = ? WHERE id = ?`,
+		next:   nextMonth,
+	},
+	{
+		typ:    "generate-tags-spreadsheet",
+		query:  `SELECT id, id FROM aws_account_tags_spreadsheets_reports_due_update`,
+		update: `UPDATE aws_account SET next_tags_spreadsheet_report_generation = ? WHERE id = ?`,
+		next:   nextMonth,
+	},
+	{
+		typ:    "generate-master-spreadsheet",
+		query:  `SELECT id, id FROM aws_account_master_spreadsheets_reports_due_update`,
+		update: `UPDATE aws_account SET next_master_spreadsheet_report_generation = ? WHERE id = ?`,
+		next:   nextMonth,
+	},
+	{
+		typ:    "check-user-entitlement",
+		query:  `SELECT id, id FROM user_entitlement_due_update`,
+		update: `UPDATE user SET next_update_entitlement = ? WHERE id = ?`,
+		next:   nextDay,
+	},
+	{
+		typ:    "update-tags",
+		query:  `SELECT id, id FROM user_update_tags_due_update`,
+		update: `UPDATE user SET next_update_tags = ? WHERE id = ?`,
+		next:   nextDay,
+	},
+}
+
+// nextIngestion returns the next update of a bill repository or a billing
+// source, spread over UpdateIntervalWindow.
+func nextIngestion(now time.Time) time.Time {
+	return now.Add(time.Duration(UpdateIntervalMinutes-UpdateIntervalWindow/2+rand.Int63n(UpdateIntervalWindow)) * time.Minute)
+}
+
+// nextDay returns the same time on the next day.
+func nextDay(now time.Time) time.Time {
+	return now.AddDate(0, 0, 1)
+}
+
+// nextMonth returns the beginning of the next month.
+func nextMonth(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// taskScheduleDueJobs enqueues the jobs of the bill repositories, billing
+// sources, AWS accounts and users whose next update is past, and schedules
+// their next update. The jobs are run by the job workers of the servers and
+// of the job-worker task. Servers running it concurrently schedule the jobs
+// one at a time.
+func taskScheduleDueJobs(ctx context.Context) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	conn, err := db.Db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, 0)`, scheduleDueJobsLock).Scan(&locked); err != nil {
+		return err
+	} else if locked.Int64 != 1 {
+		logger.Info("Due jobs are being scheduled by another server.", nil)
+		return nil
+	}
+	defer conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, scheduleDueJobsLock)
+	for _, source := range dueJobSources {
+		if sErr := scheduleDueJobs(ctx, conn, source); sErr != nil {
+			logger.Error("Failed to schedule due jobs.", map[string]interface{}{
+				"type":  source.typ,
+				"error": sErr.Error(),
+			})
+			err = sErr
+		}
+	}
+	return err
+}
+
+// scheduleDueJobs enqueues the jobs of the due rows of a source and schedules
+// their next update.
+func scheduleDueJobs(ctx context.Context, conn *sql.Conn, source dueJobSource) (err error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+	due, err := getDueRows(tx, source.query)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for id, args := range due {
+		opts, err := getJobOptions(source.typ, args)
+		if err != nil {
+			return err
+		}
+		if job, enqueued, err := jobs.EnqueueUnique(tx, source.typ, args, opts); err != nil {
+			return err
+		} else if enqueued {
+			logger.Info("Enqueued due job.", job)
+		}
+		if _, err := tx.Exec(source.update, source.next(now), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getDueRows runs the query of a source and returns the arguments of the jobs
+// of the due rows, by ID.
+func getDueRows(tx *sql.Tx, query string) (map[int][]string, error) {
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	due := make(map[int][]string)
+	for rows.Next() {
+		var id int
+		var args string
+		if err := rows.Scan(&id, &args); err != nil {
+			return nil, err
+		}
+		due[id] = strings.Fields(args)
+	}
+	return due, rows.Err()
+}
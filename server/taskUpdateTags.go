@@ -18,7 +18,6 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"flag"
 	"strconv"
 	"time"
 
@@ -27,12 +26,13 @@ import (
 	"github.com/trackit/trackit/db"
 	"github.com/trackit/trackit/models"
 	"github.com/trackit/trackit/tagging"
+	"github.com/trackit/trackit/tagging/policies"
 )
 
 const invalidUserID = -1
 
 func taskUpdateTags(ctx context.Context) error {
-	args := flag.Args()
+	args := taskArguments(ctx)
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 
 	logger.Info("Running task 'update-tags'.", map[string]interface{}{
@@ -77,7 +77,8 @@ func updateTagsForUser(ctx context.Context, userId int) (err error) {
 	if job, err = registerUpdateTagsTask(db.Db, userId); err != nil {
 	} else if err = tagging.UpdateTagsForUser(ctx, userId); err != nil {
 	} else if err = tagging.UpdateMostUsedTagsForUser(ctx, userId); err != nil {
-	} else if err = tagging.UpdateTaggingComplianceForUser(ctx, userId); err != nil {
+	} else {
+		err = updateComplianceForUser(ctx, userId)
 	}
 	updateUpdateTagsTask(db.Db, job, err)
 	if err != nil {
@@ -89,6 +90,31 @@ func updateTagsForUser(ctx context.Context, userId int) (err error) {
 	return
 }
 
+// updateComplianceForUser updates both the policies compliance and the
+// tagging compliance of a user, so that one failing does not leave the other
+// outdated. Each error is logged and the first one is returned.
+func updateComplianceForUser(ctx context.Context, userId int) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	policiesErr := policies.UpdateComplianceForUser(ctx, userId)
+	if policiesErr != nil {
+		logger.Error("Failed to update the policies compliance.", map[string]interface{}{
+			"userId": userId,
+			"error":  policiesErr.Error(),
+		})
+	}
+	taggingErr := tagging.UpdateTaggingComplianceForUser(ctx, userId)
+	if taggingErr != nil {
+		logger.Error("Failed to update the tagging compliance.", map[string]interface{}{
+			"userId": userId,
+			"error":  taggingErr.Error(),
+		})
+	}
+	if policiesErr != nil {
+		return policiesErr
+	}
+	return taggingErr
+}
+
 func registerUpdateTagsTask(db *sql.DB, userId int) (models.UserUpdateTagsJob, error) {
 	job := models.UserUpdateTagsJob{
 		UserID:   userId,
@@ -0,0 +1,146 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package tagginginventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/olivere/elastic"
+	"github.com/trackit/jsonlog"
+
+	indexSource "github.com/trackit/trackit/aws/usageReports/inventory"
+	"github.com/trackit/trackit/tagging/utils"
+)
+
+// inventoryType is the service and type of the resources of the inventory
+// which make a type of resources of the tagging reports, and the format of
+// the URL of their page in the AWS console.
+type inventoryType struct {
+	service      string
+	resourceType string
+	url          func(region, id, arn string) string
+}
+
+// inventoryTypes are the inventory types, by resource type of the tagging
+// reports.
+var inventoryTypes = map[string]inventoryType{
+	"s3": {"s3", "", func(region, id, arn string) string {
+		return fmt.Sprintf("https://s3.console.aws.amazon.com/s3/buckets/%s", id)
+	}},
+	"dynamodb": {"dynamodb", "table", func(region, id, arn string) string {
+		return fmt.Sprintf("https://console.aws.amazon.com/dynamodb/home?region=%s#tables:selected=%s", region, id)
+	}},
+	"ecs": {"ecs", "service", ecsServiceUrl},
+	"eks": {"eks", "cluster", func(region, id, arn string) string {
+		return fmt.Sprintf("https://console.aws.amazon.com/eks/home?region=%s#/clusters/%s", region, id)
+	}},
+	"cloudfront": {"cloudfront", "distribution", func(region, id, arn string) string {
+		return fmt.Sprintf("https://console.aws.amazon.com/cloudfront/home#distribution-settings:%s", id)
+	}},
+	"sqs": {"sqs", "", func(region, id, arn string) string {
+		return fmt.Sprintf("https://console.aws.amazon.com/sqs/home?region=%s#queue-browser:prefix=%s", region, id)
+	}},
+	"sns": {"sns", "", func(region, id, arn string) string {
+		return fmt.Sprintf("https://console.aws.amazon.com/sns/v3/home?region=%s#/topic/%s", region, arn)
+	}},
+	"elb": {"elasticloadbalancing", "loadbalancer", elbUrl},
+}
+
+// ecsServiceUrl returns the URL of an ECS service, whose ID is
+// '<CLUSTER>/<NAME>', or '<NAME>' in the default cluster for the services
+// created before the long ARN format.
+func ecsServiceUrl(region, id, arn string) string {
+	cluster, name := "default", id
+	if i := strings.Index(id, "/"); i >= 0 {
+		cluster, name = id[:i], id[i+1:]
+	}
+	return fmt.Sprintf("https://console.aws.amazon.com/ecs/home?region=%s#/clusters/%s/services/%s/details", region, cluster, name)
+}
+
+// elbUrl returns the URL of a load balancer, whose ID is
+// '<app|net>/<NAME>/<ID>', or '<NAME>' for the classic load balancers.
+func elbUrl(region, id, arn string) string {
+	name := id
+	if parts := strings.Split(id, "/"); len(parts) == 3 {
+		name = parts[1]
+	}
+	return fmt.Sprintf("https://console.aws.amazon.com/ec2/home?region=%s#LoadBalancers:search=%s", region, url.QueryEscape(name))
+}
+
+// Process generates tagging reports from the inventory reports of the
+// resources of a type
+func Process(ctx context.Context, userId int, resourceTypeString string) ([]utils.TaggingReportDocument, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	logger.Info("Processing reports.", map[string]interface{}{
+		"type": resourceTypeString,
+	})
+
+	source, ok := inventoryTypes[resourceTypeString]
+	if !ok {
+		return nil, fmt.Errorf("unknown inventory resource type '%s'", resourceTypeString)
+	}
+
+	hits, err := fetchReports(ctx, userId, source)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []utils.TaggingReportDocument
+	for _, hit := range hits {
+		document, success := processHit(ctx, hit, resourceTypeString, source)
+		if success {
+			documents = append(documents, document)
+		}
+	}
+
+	logger.Info("Reports processed.", map[string]interface{}{
+		"type":  resourceTypeString,
+		"count": len(documents),
+	})
+	return documents, nil
+}
+
+// processHit converts an elasticSearch hit into a TaggingReportDocument
+// Second argument is true if operation is a success
+func processHit(ctx context.Context, hit *elastic.SearchHit, resourceTypeString string, source inventoryType) (utils.TaggingReportDocument, bool) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	var report indexSource.ResourceReport
+	err := json.Unmarshal(*hit.Source, &report)
+	if err != nil {
+		logger.Error("Could not process report.", map[string]interface{}{
+			"type": resourceTypeString,
+		})
+		return utils.TaggingReportDocument{}, false
+	}
+	return documentFromResource(report.Account, report.Resource, resourceTypeString, source), true
+}
+
+// documentFromResource converts a resource of the inventory into a
+// TaggingReportDocument
+func documentFromResource(account string, resource indexSource.Resource, resourceTypeString string, source inventoryType) utils.TaggingReportDocument {
+	return utils.TaggingReportDocument{
+		Account:      account,
+		ResourceID:   resource.Id,
+		ResourceType: resourceTypeString,
+		Region:       resource.Region,
+		URL:          source.url(utils.GetRegionForURL(resource.Region), resource.Id, resource.Arn),
+		Arn:          resource.Arn,
+		Tags:         resource.Tags,
+	}
+}
@@ -0,0 +1,44 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package tagginginventory
+
+import (
+	"testing"
+
+	indexSource "github.com/trackit/trackit/aws/usageReports/inventory"
+)
+
+func TestDocumentFromResource(t *testing.T) {
+	for _, test := range []struct {
+		resourceType string
+		resource     indexSource.Resource
+		url          string
+	}{
+		{"ecs", indexSource.Resource{Id: "prod/api", Region: "us-east-1"}, "https://console.aws.amazon.com/ecs/home?region=us-east-1#/clusters/prod/services/api/details"},
+		{"ecs", indexSource.Resource{Id: "api", Region: "us-east-1"}, "https://console.aws.amazon.com/ecs/home?region=us-east-1#/clusters/default/services/api/details"},
+		{"elb", indexSource.Resource{Id: "app/web/50dc6c495c0c9188", Region: "eu-west-1"}, "https://console.aws.amazon.com/ec2/home?region=eu-west-1#LoadBalancers:search=web"},
+		{"elb", indexSource.Resource{Id: "classic", Region: "eu-west-1"}, "https://console.aws.amazon.com/ec2/home?region=eu-west-1#LoadBalancers:search=classic"},
+		{"s3", indexSource.Resource{Id: "my-bucket", Region: "us-west-2"}, "https://s3.console.aws.amazon.com/s3/buckets/my-bucket"},
+	} {
+		test.resource.Arn = "arn"
+		doc := documentFromResource("123456789012", test.resource, test.resourceType, inventoryTypes[test.resourceType])
+		if doc.URL != test.url {
+			t.Errorf("Resource %+v: expected URL %s, got %s.", test.resource, test.url, doc.URL)
+		}
+		if doc.ResourceType != test.resourceType || doc.ResourceID != test.resource.Id || doc.Arn != "arn" || doc.Account != "123456789012" {
+			t.Errorf("Resource %+v: unexpected document %+v.", test.resource, doc)
+		}
+	}
+}
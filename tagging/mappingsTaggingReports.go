@@ -43,13 +43,16 @@ func init() {
 const templateTaggingReport = `
 {
     "template":"*-tagging-reports",
-    "version":1,
+    "version":2,
     "mappings":{
         "tagging-reports":{
             "properties":{
                 "account":{
                     "type":"keyword"
                 },
+                "arn":{
+                    "type":"keyword"
+                },
                 "region":{
                     "type":"keyword"
                 },
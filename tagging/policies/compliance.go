@@ -0,0 +1,244 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package policies
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/olivere/elastic"
+	"github.com/trackit/jsonlog"
+
+	bulk "github.com/trackit/trackit/aws/usageReports"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/es"
+	"github.com/trackit/trackit/tagging"
+	"github.com/trackit/trackit/tagging/utils"
+)
+
+// scrollSize is the number of tagging reports fetched by each request of
+// the scroll over the latest reports.
+const scrollSize = 1000
+
+type (
+	// RuleViolations is the number of violations of a rule of a tag
+	// policy, by kind.
+	RuleViolations struct {
+		Key   string        `json:"key"`
+		Kind  ViolationKind `json:"kind"`
+		Count int64         `json:"count"`
+	}
+
+	// PolicyCompliance is the compliance of the resources of a user with
+	// one of their tag policies at a report date. Total is the number of
+	// resources the policy covers and Score the percentage of them which
+	// break none of its rules.
+	PolicyCompliance struct {
+		ReportDate time.Time        `json:"reportDate"`
+		Policy     string           `json:"policy"`
+		Total      int64            `json:"total"`
+		Compliant  int64            `json:"compliant"`
+		Violations int64            `json:"violations"`
+		Score      float64          `json:"score"`
+		Rules      []RuleViolations `json:"rules"`
+	}
+
+	// ViolationDocument is a violation of a tag policy by a resource of a
+	// tagging report.
+	ViolationDocument struct {
+		Violation
+		Account      string    `json:"account"`
+		ReportDate   time.Time `json:"reportDate"`
+		ResourceID   string    `json:"resourceId"`
+		ResourceType string    `json:"resourceType"`
+		Region       string    `json:"region"`
+		URL          string    `json:"url"`
+	}
+)
+
+// UpdateComplianceForUser checks the resources of the latest tagging reports
+// of a user against their tag policies. It stores the violations and the
+// compliance with each policy, under the date of the reports.
+func UpdateComplianceForUser(ctx context.Context, userId int) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	policies, err := GetPolicies(db.Db, userId)
+	if err != nil || len(policies) == 0 {
+		return err
+	}
+	compiled := make([]compiledPolicy, len(policies))
+	for i, policy := range policies {
+		if compiled[i], err = compile(policy); err != nil {
+			return err
+		}
+	}
+	documents, err := getLatestTaggingReports(ctx, userId)
+	if err != nil {
+		return err
+	} else if len(documents) == 0 {
+		logger.Info("No tagging reports to check against tag policies.", map[string]interface{}{
+			"userId": userId,
+		})
+		return nil
+	}
+	reportDate := documents[0].ReportDate
+	compliances, violations := checkDocuments(compiled, documents)
+	if err = pushViolationsToEs(ctx, userId, violations); err != nil {
+		return err
+	}
+	for _, compliance := range compliances {
+		compliance.ReportDate = reportDate
+		if err = pushPolicyComplianceToEs(ctx, userId, compliance); err != nil {
+			return err
+		}
+	}
+	logger.Info("Tag policies compliance pushed to ES.", map[string]interface{}{
+		"userId":     userId,
+		"violations": len(violations),
+	})
+	return nil
+}
+
+// checkDocuments checks tagging reports against tag policies. It returns the
+// compliance with each policy, in the same order, and the violations.
+func checkDocuments(policies []compiledPolicy, documents []utils.TaggingReportDocument) ([]PolicyCompliance, []ViolationDocument) {
+	compliances := make([]PolicyCompliance, len(policies))
+	violations := []ViolationDocument{}
+	for i, policy := range policies {
+		compliance := PolicyCompliance{Policy: policy.Name, Rules: []RuleViolations{}}
+		counts := make(map[RuleViolations]int64)
+		for _, doc := range documents {
+			covered, docViolations := policy.check(doc)
+			if !covered {
+				continue
+			}
+			compliance.Total++
+			if len(docViolations) == 0 {
+				compliance.Compliant++
+			}
+			for _, violation := range docViolations {
+				compliance.Violations++
+				counts[RuleViolations{Key: violation.Key, Kind: violation.Kind}]++
+				violations = append(violations, ViolationDocument{
+					Violation:    violation,
+					Account:      doc.Account,
+					ReportDate:   doc.ReportDate,
+					ResourceID:   doc.ResourceID,
+					ResourceType: doc.ResourceType,
+					Region:       doc.Region,
+					URL:          doc.URL,
+				})
+			}
+		}
+		for _, rule := range policy.Rules {
+			for _, kind := range []ViolationKind{ViolationMissing, ViolationKeyCase, ViolationValueNotAllowed, ViolationValuePattern, ViolationValueCase} {
+				key := RuleViolations{Key: rule.Key, Kind: kind}
+				if count := counts[key]; count > 0 {
+					delete(counts, key)
+					key.Count = count
+					compliance.Rules = append(compliance.Rules, key)
+				}
+			}
+		}
+		compliance.Score = 100
+		if compliance.Total > 0 {
+			compliance.Score = float64(compliance.Compliant) * 100 / float64(compliance.Total)
+		}
+		compliances[i] = compliance
+	}
+	return compliances, violations
+}
+
+// getLatestTaggingReports returns the tagging reports of a user with the
+// latest report date.
+func getLatestTaggingReports(ctx context.Context, userId int) ([]utils.TaggingReportDocument, error) {
+	client := es.Client
+	indexName := es.IndexNameForUserId(userId, tagging.IndexPrefixTaggingReport)
+	res, err := client.Search().Index(indexName).Size(0).Query(elastic.NewMatchAllQuery()).
+		Aggregation("reportDate", elastic.NewTermsAggregation().Field("reportDate").Order("_term", false).Size(1)).Do(ctx)
+	if elastic.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	reportDateRes, found := res.Aggregations.Terms("reportDate")
+	if !found || len(reportDateRes.Buckets) == 0 {
+		return nil, nil
+	}
+	documents := []utils.TaggingReportDocument{}
+	scroll := client.Scroll(indexName).Query(elastic.NewTermQuery("reportDate", reportDateRes.Buckets[0].Key)).Size(scrollSize)
+	defer scroll.Clear(context.Background())
+	for {
+		res, err := scroll.Do(ctx)
+		if err == io.EOF {
+			return documents, nil
+		} else if err != nil {
+			return nil, err
+		}
+		for _, hit := range res.Hits.Hits {
+			var doc utils.TaggingReportDocument
+			if err := json.Unmarshal(*hit.Source, &doc); err != nil {
+				return nil, err
+			}
+			documents = append(documents, doc)
+		}
+	}
+}
+
+// documentId returns a unique ID for a document built from its identifying
+// fields, so that checking the same reports twice does not duplicate it.
+func documentId(fields ...interface{}) (string, error) {
+	ji, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	hash := md5.Sum(ji)
+	return base64.URLEncoding.EncodeToString(hash[:]), nil
+}
+
+func pushViolationsToEs(ctx context.Context, userId int, violations []ViolationDocument) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	indexName := es.IndexNameForUserId(userId, IndexPrefixTaggingViolations)
+	bulkProcessor, err := bulk.GetBulkProcessor(ctx)
+	if err != nil {
+		return err
+	}
+	for _, violation := range violations {
+		id, err := documentId(violation.Account, violation.ReportDate, violation.ResourceID, violation.Policy, violation.Key)
+		if err != nil {
+			logger.Error("Could not add a tag policy violation to bulk processor.", err.Error())
+			continue
+		}
+		bulkProcessor = bulk.AddDocToBulkProcessor(bulkProcessor, violation, typeTaggingViolations, indexName, id)
+	}
+	bulkProcessor.Flush()
+	if err = bulkProcessor.Close(); err != nil {
+		logger.Error("Failed to put tag policy violations in ES", err.Error())
+	}
+	return err
+}
+
+func pushPolicyComplianceToEs(ctx context.Context, userId int, compliance PolicyCompliance) error {
+	id, err := documentId(compliance.ReportDate, compliance.Policy)
+	if err != nil {
+		return err
+	}
+	indexName := es.IndexNameForUserId(userId, IndexPrefixTaggingPolicyCompliance)
+	_, err = es.Client.Index().Index(indexName).Type(typeTaggingPolicyCompliance).Id(id).BodyJson(compliance).Do(ctx)
+	return err
+}
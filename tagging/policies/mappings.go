@@ -0,0 +1,147 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package policies
+
+import (
+	"context"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/es"
+)
+
+const typeTaggingViolations = "tagging-violations"
+const IndexPrefixTaggingViolations = "tagging-violations"
+const templateNameTaggingViolations = "tagging-violations"
+
+const typeTaggingPolicyCompliance = "tagging-policy-compliance"
+const IndexPrefixTaggingPolicyCompliance = "tagging-policy-compliance"
+const templateNameTaggingPolicyCompliance = "tagging-policy-compliance"
+
+// put the ElasticSearch index for *-tagging-violations and
+// *-tagging-policy-compliance indices at startup.
+func init() {
+	for name, template := range map[string]string{
+		templateNameTaggingViolations:       templateTaggingViolations,
+		templateNameTaggingPolicyCompliance: templateTaggingPolicyCompliance,
+	} {
+		ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		res, err := es.Client.IndexPutTemplate(name).BodyString(template).Do(ctx)
+		if err != nil {
+			jsonlog.DefaultLogger.Error("Failed to put ES index "+name+".", err)
+		} else {
+			jsonlog.DefaultLogger.Info("Put ES index "+name+".", res)
+		}
+		ctxCancel()
+	}
+}
+
+const templateTaggingViolations = `
+{
+    "template":"*-tagging-violations",
+    "version":1,
+    "mappings":{
+        "tagging-violations":{
+            "properties":{
+                "account":{
+                    "type":"keyword"
+                },
+                "reportDate":{
+                    "type":"date"
+                },
+                "resourceId":{
+                    "type":"keyword"
+                },
+                "resourceType":{
+                    "type":"keyword"
+                },
+                "region":{
+                    "type":"keyword"
+                },
+                "url":{
+                    "type":"keyword"
+                },
+                "policy":{
+                    "type":"keyword"
+                },
+                "key":{
+                    "type":"keyword"
+                },
+                "kind":{
+                    "type":"keyword"
+                },
+                "value":{
+                    "type":"keyword"
+                }
+            },
+            "_all": {
+                "enabled": false
+            },
+            "date_detection": false,
+            "numeric_detection": false
+        }
+    }
+}
+`
+
+const templateTaggingPolicyCompliance = `
+{
+    "template":"*-tagging-policy-compliance",
+    "version":1,
+    "mappings":{
+        "tagging-policy-compliance":{
+            "properties":{
+                "reportDate":{
+                    "type":"date"
+                },
+                "policy":{
+                    "type":"keyword"
+                },
+                "total":{
+                    "type":"long"
+                },
+                "compliant":{
+                    "type":"long"
+                },
+                "violations":{
+                    "type":"long"
+                },
+                "score":{
+                    "type":"double"
+                },
+                "rules":{
+                    "properties":{
+                        "key":{
+                            "type":"keyword"
+                        },
+                        "kind":{
+                            "type":"keyword"
+                        },
+                        "count":{
+                            "type":"long"
+                        }
+                    }
+                }
+            },
+            "_all": {
+                "enabled": false
+            },
+            "date_detection": false,
+            "numeric_detection": false
+        }
+    }
+}
+`
@@ -0,0 +1,269 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package policies checks the tags of the resources in the tagging reports
+// against the tag policies declared by the users. A tag policy lists the tag
+// keys expected on each resource type, with the values, pattern and case
+// allowed for them, and the accounts and resources exempted from it.
+package policies
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/tagging/utils"
+)
+
+// Case is the case the values of a tag must be in.
+type Case string
+
+const (
+	// CaseAny allows values in any case.
+	CaseAny = Case("")
+	// CaseLower allows lower case values only.
+	CaseLower = Case("lower")
+	// CaseUpper allows upper case values only.
+	CaseUpper = Case("upper")
+)
+
+// ViolationKind is the way a resource breaks a rule of a tag policy.
+type ViolationKind string
+
+const (
+	// ViolationMissing is the violation of a required key the resource
+	// does not carry.
+	ViolationMissing = ViolationKind("missing")
+	// ViolationKeyCase is the violation of a key the resource carries with
+	// another case, e.g. 'environment' for 'Environment'.
+	ViolationKeyCase = ViolationKind("key-case")
+	// ViolationValueNotAllowed is the violation of a value which is not one
+	// of the allowed values of the rule.
+	ViolationValueNotAllowed = ViolationKind("value-not-allowed")
+	// ViolationValuePattern is the violation of a value which does not
+	// match the pattern of the rule.
+	ViolationValuePattern = ViolationKind("value-pattern")
+	// ViolationValueCase is the violation of a value which is not in the
+	// case of the rule.
+	ViolationValueCase = ViolationKind("value-case")
+)
+
+// maxRules is the maximum number of rules of a tag policy.
+const maxRules = 100
+
+var (
+	ErrPolicyNotFound = errors.New("Tag policy not found.")
+	ErrInvalidName    = errors.New("The name of a tag policy must not be empty.")
+	ErrTooManyRules   = fmt.Errorf("A tag policy cannot have more than %d rules.", maxRules)
+	ErrNoRules        = errors.New("A tag policy must have at least one rule.")
+	ErrInvalidCase    = errors.New("The case of a rule must be empty, 'lower' or 'upper'.")
+)
+
+type (
+	// Rule is a tag key expected on the resources of some types, all of
+	// them if there are none. When the key is present, its value must be
+	// one of the allowed values, match the pattern and be in the case of the
+	// rule, when they are set.
+	Rule struct {
+		Key           string   `json:"key" req:"nonzero"`
+		ResourceTypes []string `json:"resourceTypes"`
+		Required      bool     `json:"required"`
+		AllowedValues []string `json:"allowedValues"`
+		Pattern       string   `json:"pattern"`
+		Case          Case     `json:"case"`
+	}
+
+	// Exemptions are the accounts and resources a tag policy does not
+	// apply to.
+	Exemptions struct {
+		Accounts  []string `json:"accounts"`
+		Resources []string `json:"resources"`
+	}
+
+	// Policy is a tag policy of a user.
+	Policy struct {
+		Name       string     `json:"name" req:"nonzero"`
+		Rules      []Rule     `json:"rules"`
+		Exemptions Exemptions `json:"exemptions"`
+	}
+
+	// Violation is a rule of a tag policy a resource breaks. Value is the
+	// value of the tag, or the key found with another case for
+	// ViolationKeyCase.
+	Violation struct {
+		Policy string        `json:"policy"`
+		Key    string        `json:"key"`
+		Kind   ViolationKind `json:"kind"`
+		Value  string        `json:"value"`
+	}
+)
+
+// Validate checks a tag policy can be stored and checked.
+func (p Policy) Validate() error {
+	if p.Name == "" {
+		return ErrInvalidName
+	} else if len(p.Rules) == 0 {
+		return ErrNoRules
+	} else if len(p.Rules) > maxRules {
+		return ErrTooManyRules
+	}
+	for _, rule := range p.Rules {
+		if rule.Key == "" {
+			return errors.New("A rule of the tag policy has no key.")
+		} else if rule.Case != CaseAny && rule.Case != CaseLower && rule.Case != CaseUpper {
+			return ErrInvalidCase
+		} else if _, err := compilePattern(rule.Pattern); err != nil {
+			return fmt.Errorf("The pattern of the rule for '%s' is invalid: %s", rule.Key, err.Error())
+		}
+	}
+	return nil
+}
+
+// compilePattern compiles the pattern of a rule, which must match the whole
+// value. It returns nil for an empty pattern.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// compiledRule is a rule whose pattern is compiled.
+type compiledRule struct {
+	Rule
+	pattern *regexp.Regexp
+}
+
+// compiledPolicy is a policy whose patterns are compiled, ready to check
+// many resources.
+type compiledPolicy struct {
+	Policy
+	rules []compiledRule
+}
+
+func compile(policy Policy) (compiledPolicy, error) {
+	compiled := compiledPolicy{Policy: policy, rules: make([]compiledRule, len(policy.Rules))}
+	for i, rule := range policy.Rules {
+		pattern, err := compilePattern(rule.Pattern)
+		if err != nil {
+			return compiled, err
+		}
+		compiled.rules[i] = compiledRule{rule, pattern}
+	}
+	return compiled, nil
+}
+
+// exempts tells whether a resource is exempted from the policy.
+func (p compiledPolicy) exempts(doc utils.TaggingReportDocument) bool {
+	return contains(p.Exemptions.Accounts, doc.Account) || contains(p.Exemptions.Resources, doc.ResourceID)
+}
+
+// check returns whether a resource is covered by the policy, that is it is
+// not exempted and at least one of the rules applies to its type, and the
+// violations of the rules by the resource.
+func (p compiledPolicy) check(doc utils.TaggingReportDocument) (bool, []Violation) {
+	if p.exempts(doc) {
+		return false, nil
+	}
+	covered := false
+	violations := []Violation{}
+	for _, rule := range p.rules {
+		if len(rule.ResourceTypes) > 0 && !contains(rule.ResourceTypes, doc.ResourceType) {
+			continue
+		}
+		covered = true
+		if kind, value, violated := rule.check(doc); violated {
+			violations = append(violations, Violation{p.Name, rule.Key, kind, value})
+		}
+	}
+	return covered, violations
+}
+
+// check returns the violation of the rule by a resource, if any.
+func (r compiledRule) check(doc utils.TaggingReportDocument) (kind ViolationKind, value string, violated bool) {
+	found := false
+	for _, tag := range doc.Tags {
+		if tag.Key == r.Key {
+			found = true
+			value = tag.Value
+			break
+		} else if strings.EqualFold(tag.Key, r.Key) && value == "" {
+			value = tag.Key
+		}
+	}
+	switch {
+	case !found && value != "":
+		return ViolationKeyCase, value, true
+	case !found:
+		return ViolationMissing, "", r.Required
+	case len(r.AllowedValues) > 0 && !contains(r.AllowedValues, value):
+		return ViolationValueNotAllowed, value, true
+	case r.pattern != nil && !r.pattern.MatchString(value):
+		return ViolationValuePattern, value, true
+	case r.Case == CaseLower && value != strings.ToLower(value),
+		r.Case == CaseUpper && value != strings.ToUpper(value):
+		return ViolationValueCase, value, true
+	default:
+		return "", value, false
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// policyFromDbTagPolicy builds a Policy from its database representation.
+func policyFromDbTagPolicy(dbPolicy models.TagPolicy) (Policy, error) {
+	var policy Policy
+	err := json.Unmarshal(dbPolicy.Policy, &policy)
+	policy.Name = dbPolicy.Name
+	return policy, err
+}
+
+// GetPolicies returns the tag policies of a user.
+func GetPolicies(db models.XODB, userId int) ([]Policy, error) {
+	dbPolicies, err := models.TagPoliciesByUserID(db, userId)
+	if err != nil {
+		return nil, err
+	}
+	policies := make([]Policy, len(dbPolicies))
+	for i, dbPolicy := range dbPolicies {
+		if policies[i], err = policyFromDbTagPolicy(*dbPolicy); err != nil {
+			return nil, err
+		}
+	}
+	return policies, nil
+}
+
+// getPolicy returns the tag policy of a user with a name. It returns
+// ErrPolicyNotFound if there is none.
+func getPolicy(tx *sql.Tx, userId int, name string) (*models.TagPolicy, Policy, error) {
+	dbPolicy, err := models.TagPolicyByUserIDName(tx, userId, name)
+	if err == sql.ErrNoRows {
+		return nil, Policy{}, ErrPolicyNotFound
+	} else if err != nil {
+		return nil, Policy{}, err
+	}
+	policy, err := policyFromDbTagPolicy(*dbPolicy)
+	return dbPolicy, policy, err
+}
@@ -0,0 +1,172 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package policies
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/audit"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+// policyNameQueryArg is the name of the tag policy which is deleted.
+var policyNameQueryArg = routes.QueryArg{
+	Name:        "name",
+	Type:        routes.QueryArgString{},
+	Description: "The name of the tag policy.",
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getPolicies).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			routes.Documentation{
+				Summary:     "get the tag policies",
+				Description: "Responds with the tag policies of the user, ordered by name",
+			},
+		),
+		http.MethodPut: routes.H(putPolicy).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{Policy{
+				Name: "production",
+				Rules: []Rule{
+					{Key: "Environment", Required: true, AllowedValues: []string{"prod", "staging", "dev"}, ResourceTypes: []string{}, Case: CaseLower},
+					{Key: "CostCenter", Required: true, Pattern: "CC-[0-9]{4}", ResourceTypes: []string{"ec2", "rds"}, AllowedValues: []string{}},
+				},
+				Exemptions: Exemptions{Accounts: []string{}, Resources: []string{"i-0123456789abcdef0"}},
+			}},
+			routes.Documentation{
+				Summary:     "create or replace a tag policy",
+				Description: "Creates the tag policy with the name of the body, or replaces it if it exists. Each rule is a tag key expected on the resources of its types, all of them if there are none. When present, the value of the key must be one of the allowed values, fully match the pattern and be in the case of the rule ('lower' or 'upper'), when they are set. Resources carrying the key with another case break the rule. The exempted accounts and resources are not checked.",
+			},
+		),
+		http.MethodDelete: routes.H(deletePolicy).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.QueryArgs{policyNameQueryArg},
+			routes.Documentation{
+				Summary:     "delete a tag policy",
+				Description: "Deletes the tag policy with a name",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{Db: db.Db},
+		routes.Documentation{
+			Summary:     "interact with the tag policies",
+			Description: "The resources of the tagging reports are checked against the tag policies of the user each time their tags are updated.",
+		},
+	).Register("/tagging/policies")
+}
+
+// getPolicies is a route handler which returns the tag policies of the user.
+func getPolicies(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	policies, err := GetPolicies(tx, user.Id)
+	if err != nil {
+		l.Error("Failed to get tag policies", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve tag policies.")
+	}
+	return http.StatusOK, policies
+}
+
+// putPolicy is a route handler which creates or replaces a tag policy of the
+// user.
+func putPolicy(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	var body Policy
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	if err := body.Validate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	var before interface{}
+	dbPolicy, policy, err := getPolicy(tx, user.Id, body.Name)
+	if err == ErrPolicyNotFound {
+		dbPolicy, err = &models.TagPolicy{
+			UserID: user.Id,
+			Name:   body.Name,
+		}, nil
+	} else if err == nil {
+		before = policy
+	}
+	if err == nil {
+		if dbPolicy.Policy, err = json.Marshal(body); err == nil {
+			err = dbPolicy.Save(tx)
+		}
+	}
+	if err != nil {
+		l.Error("Failed to save tag policy", map[string]interface{}{
+			"userId": user.Id,
+			"name":   body.Name,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to update tag policy.")
+	}
+	if err := audit.Record(r, tx, user, audit.Entry{
+		Action:     audit.ActionTagPolicyUpdate,
+		TargetType: audit.TargetTagPolicy,
+		TargetId:   body.Name,
+		Before:     before,
+		After:      body,
+	}); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, body
+}
+
+// deletePolicy is a route handler which deletes a tag policy of the user.
+func deletePolicy(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	name := a[policyNameQueryArg].(string)
+	dbPolicy, before, err := getPolicy(tx, user.Id, name)
+	if err == ErrPolicyNotFound {
+		return http.StatusNotFound, err
+	} else if err == nil {
+		err = dbPolicy.Delete(tx)
+	}
+	if err != nil {
+		l.Error("Failed to delete tag policy", map[string]interface{}{
+			"userId": user.Id,
+			"name":   name,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to delete tag policy.")
+	}
+	if err := audit.Record(r, tx, user, audit.Entry{
+		Action:     audit.ActionTagPolicyDelete,
+		TargetType: audit.TargetTagPolicy,
+		TargetId:   name,
+		Before:     before,
+	}); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,120 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package policies
+
+import (
+	"reflect"
+	"testing"
+
+	usageReports "github.com/trackit/trackit/aws/usageReports"
+	"github.com/trackit/trackit/tagging/utils"
+)
+
+var testPolicy = Policy{
+	Name: "production",
+	Rules: []Rule{
+		{Key: "Environment", Required: true, AllowedValues: []string{"prod", "dev"}},
+		{Key: "CostCenter", Required: true, Pattern: "CC-[0-9]{4}", ResourceTypes: []string{"ec2"}},
+		{Key: "Owner", Case: CaseLower},
+	},
+	Exemptions: Exemptions{Accounts: []string{"exempted"}},
+}
+
+func testDocument(account, resourceType string, tags ...string) utils.TaggingReportDocument {
+	doc := utils.TaggingReportDocument{Account: account, ResourceID: "r", ResourceType: resourceType}
+	for i := 0; i+1 < len(tags); i += 2 {
+		doc.Tags = append(doc.Tags, usageReports.Tag{Key: tags[i], Value: tags[i+1]})
+	}
+	return doc
+}
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		policy   Policy
+		expected bool
+	}{
+		{testPolicy, true},
+		{Policy{Rules: testPolicy.Rules}, false},
+		{Policy{Name: "empty"}, false},
+		{Policy{Name: "case", Rules: []Rule{{Key: "Owner", Case: "title"}}}, false},
+		{Policy{Name: "pattern", Rules: []Rule{{Key: "Owner", Pattern: "("}}}, false},
+		{Policy{Name: "key", Rules: []Rule{{Required: true}}}, false},
+	} {
+		if err := test.policy.Validate(); (err == nil) != test.expected {
+			t.Errorf("Policy %+v: expected valid %v, got error %v.", test.policy, test.expected, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	policy, err := compile(testPolicy)
+	if err != nil {
+		t.Fatalf("Unexpected error %v.", err)
+	}
+	for _, test := range []struct {
+		name       string
+		doc        utils.TaggingReportDocument
+		covered    bool
+		violations []Violation
+	}{
+		{"compliant", testDocument("a", "ec2", "Environment", "prod", "CostCenter", "CC-1234", "Owner", "alice"), true, []Violation{}},
+		{"not ec2", testDocument("a", "rds", "Environment", "dev"), true, []Violation{}},
+		{"exempted", testDocument("exempted", "ec2"), false, nil},
+		{"missing", testDocument("a", "ec2"), true, []Violation{
+			{"production", "Environment", ViolationMissing, ""},
+			{"production", "CostCenter", ViolationMissing, ""},
+		}},
+		{"values", testDocument("a", "ec2", "Environment", "test", "CostCenter", "CC-12", "Owner", "Alice"), true, []Violation{
+			{"production", "Environment", ViolationValueNotAllowed, "test"},
+			{"production", "CostCenter", ViolationValuePattern, "CC-12"},
+			{"production", "Owner", ViolationValueCase, "Alice"},
+		}},
+		{"key case", testDocument("a", "rds", "environment", "prod"), true, []Violation{
+			{"production", "Environment", ViolationKeyCase, "environment"},
+		}},
+	} {
+		covered, violations := policy.check(test.doc)
+		if covered != test.covered || !reflect.DeepEqual(violations, test.violations) {
+			t.Errorf("%s: expected %v %v, got %v %v.", test.name, test.covered, test.violations, covered, violations)
+		}
+	}
+}
+
+func TestCheckDocuments(t *testing.T) {
+	policy, _ := compile(testPolicy)
+	compliances, violations := checkDocuments([]compiledPolicy{policy}, []utils.TaggingReportDocument{
+		testDocument("a", "ec2", "Environment", "prod", "CostCenter", "CC-1234"),
+		testDocument("a", "ec2", "Environment", "prod"),
+		testDocument("a", "rds"),
+		testDocument("exempted", "rds"),
+	})
+	expected := PolicyCompliance{
+		Policy:     "production",
+		Total:      3,
+		Compliant:  1,
+		Violations: 2,
+		Score:      100.0 / 3,
+		Rules: []RuleViolations{
+			{"Environment", ViolationMissing, 1},
+			{"CostCenter", ViolationMissing, 1},
+		},
+	}
+	if len(compliances) != 1 || !reflect.DeepEqual(compliances[0], expected) {
+		t.Errorf("Expected compliance %+v, got %+v.", expected, compliances)
+	}
+	if len(violations) != 2 {
+		t.Errorf("Expected 2 violations, got %+v.", violations)
+	}
+}
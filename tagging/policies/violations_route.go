@@ -0,0 +1,146 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package policies
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/olivere/elastic"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/errors"
+	"github.com/trackit/trackit/es"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+// maxDocuments is the maximum number of documents returned by the searches
+// of the violations route, which is the default result window of
+// ElasticSearch.
+const maxDocuments = 10000
+
+// policyOptionalQueryArg restricts the violations to a tag policy.
+var policyOptionalQueryArg = routes.QueryArg{
+	Name:        "policy",
+	Type:        routes.QueryArgString{},
+	Description: "The name of the tag policy to get the violations of, all of them if omitted.",
+	Optional:    true,
+}
+
+var violationsQueryArgs = []routes.QueryArg{
+	routes.DateBeginQueryArg,
+	routes.DateEndQueryArg,
+	policyOptionalQueryArg,
+}
+
+// violationsResponse is the response of the /tagging/violations route.
+type violationsResponse struct {
+	// Trend is the compliance with the tag policies at each check in the
+	// date range, by report date.
+	Trend []PolicyCompliance `json:"trend"`
+	// Violations are the violations found by the latest check in the date
+	// range.
+	Violations []ViolationDocument `json:"violations"`
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getViolations).With(
+			db.RequestTransaction{Db: db.Db},
+			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			routes.QueryArgs(violationsQueryArgs),
+			routes.Documentation{
+				Summary:     "get the tag policy violations",
+				Description: "Responds with the compliance of the resources with the tag policies at each check in the date range, and the violations found by the latest of them. The score of a policy is the percentage of the resources it covers which break none of its rules.",
+			},
+		),
+	}.H().Register("/tagging/violations")
+}
+
+// getViolations is a route handler which returns the trend of the compliance
+// with the tag policies of the user and their latest violations.
+func getViolations(r *http.Request, a routes.Arguments) (int, interface{}) {
+	user := a[users.AuthenticatedUser].(users.User)
+	dateBegin := a[violationsQueryArgs[0]].(time.Time)
+	dateEnd := a[violationsQueryArgs[1]].(time.Time).Add(time.Hour*time.Duration(23) + time.Minute*time.Duration(59) + time.Second*time.Duration(59))
+	policy, _ := a[violationsQueryArgs[2]].(string)
+	response := violationsResponse{Trend: []PolicyCompliance{}, Violations: []ViolationDocument{}}
+	var err error
+	if response.Trend, err = getPolicyComplianceTrend(r.Context(), user, dateBegin, dateEnd, policy); err != nil {
+		return searchErrorStatus(r.Context(), err)
+	} else if len(response.Trend) == 0 {
+		return http.StatusOK, response
+	}
+	latest := response.Trend[len(response.Trend)-1].ReportDate
+	if response.Violations, err = getViolationsAtDate(r.Context(), user, latest, policy); err != nil {
+		return searchErrorStatus(r.Context(), err)
+	}
+	return http.StatusOK, response
+}
+
+// searchErrorStatus returns the status code and the output of the route for
+// an error of a search, which is an empty response if the index does not
+// exist yet.
+func searchErrorStatus(ctx context.Context, err error) (int, interface{}) {
+	if elastic.IsNotFound(err) {
+		return http.StatusOK, violationsResponse{Trend: []PolicyCompliance{}, Violations: []ViolationDocument{}}
+	}
+	jsonlog.LoggerFromContextOrDefault(ctx).Error("Could not get tag policy violations.", map[string]interface{}{
+		"error": err.Error(),
+	})
+	return http.StatusInternalServerError, errors.GetErrorMessage(ctx, err)
+}
+
+func getPolicyComplianceTrend(ctx context.Context, user users.User, begin, end time.Time, policy string) ([]PolicyCompliance, error) {
+	query := elastic.NewBoolQuery().Filter(elastic.NewRangeQuery("reportDate").From(begin).To(end))
+	if policy != "" {
+		query = query.Filter(elastic.NewTermQuery("policy", policy))
+	}
+	res, err := es.Client.Search().Index(es.IndexNameForUser(user, IndexPrefixTaggingPolicyCompliance)).
+		Query(query).Sort("reportDate", true).Sort("policy", true).Size(maxDocuments).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	trend := make([]PolicyCompliance, len(res.Hits.Hits))
+	for i, hit := range res.Hits.Hits {
+		if err := json.Unmarshal(*hit.Source, &trend[i]); err != nil {
+			return nil, err
+		}
+	}
+	return trend, nil
+}
+
+func getViolationsAtDate(ctx context.Context, user users.User, date time.Time, policy string) ([]ViolationDocument, error) {
+	query := elastic.NewBoolQuery().Filter(elastic.NewTermQuery("reportDate", date))
+	if policy != "" {
+		query = query.Filter(elastic.NewTermQuery("policy", policy))
+	}
+	res, err := es.Client.Search().Index(es.IndexNameForUser(user, IndexPrefixTaggingViolations)).
+		Query(query).Sort("policy", true).Sort("account", true).Sort("resourceId", true).Size(maxDocuments).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	violations := make([]ViolationDocument, len(res.Hits.Hits))
+	for i, hit := range res.Hits.Hits {
+		if err := json.Unmarshal(*hit.Source, &violations[i]); err != nil {
+			return nil, err
+		}
+	}
+	return violations, nil
+}
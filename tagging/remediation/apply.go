@@ -0,0 +1,251 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package remediation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/trackit/jsonlog"
+
+	taws "github.com/trackit/trackit/aws"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/tagging/utils"
+)
+
+// sessionName is the name of the sessions of the roles assumed to change the
+// tags of resources.
+const sessionName = "trackit-tag-remediation"
+
+// maxErrorLength is the size of the error column of the resources of
+// remediations.
+const maxErrorLength = 1024
+
+// taggingClients creates and caches the clients of the Resource Groups
+// Tagging API of the AWS accounts of a user, by account and region.
+type taggingClients struct {
+	accounts map[string]taws.AwsAccount
+	clients  map[string]*resourcegroupstaggingapi.ResourceGroupsTaggingAPI
+}
+
+// get returns the client for a region of an AWS account, assuming its role
+// the first time.
+func (tc taggingClients) get(account, region string) (*resourcegroupstaggingapi.ResourceGroupsTaggingAPI, error) {
+	region = utils.GetRegionForURL(region)
+	key := account + "/" + region
+	if client, ok := tc.clients[key]; ok {
+		return client, nil
+	}
+	aa, ok := tc.accounts[account]
+	if !ok {
+		return nil, fmt.Errorf("AWS account %s is not configured.", account)
+	}
+	creds, err := taws.GetTemporaryCredentials(aa, sessionName)
+	if err != nil {
+		return nil, err
+	}
+	client := resourcegroupstaggingapi.New(session.Must(session.NewSession(&aws.Config{
+		Credentials: creds,
+		Region:      aws.String(region),
+	})))
+	tc.clients[key] = client
+	return client, nil
+}
+
+// Apply changes the tags of the resources of a remediation of a user in AWS,
+// if it is approved, or restores their previous tags if its rollback is
+// requested. The outcome of each resource is recorded, and the remediation
+// fails if any of them failed. Resources already processed are skipped, so
+// that Apply can be retried.
+func Apply(ctx context.Context, db *sql.DB, userId, id int) error {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	dbRemediation, err := models.TagRemediationByID(db, id)
+	if err == sql.ErrNoRows || (err == nil && dbRemediation.UserID != userId) {
+		return ErrRemediationNotFound
+	} else if err != nil {
+		return err
+	}
+	var rollback bool
+	switch dbRemediation.Status {
+	case StatusApproved:
+	case StatusRollbackRequested:
+		rollback = true
+	default:
+		return fmt.Errorf("Tag remediation %d cannot be applied in status '%s'.", id, dbRemediation.Status)
+	}
+	dbResources, err := models.TagRemediationResourcesByRemediationID(db, id)
+	if err != nil {
+		return err
+	}
+	clients, err := getTaggingClients(db, userId)
+	if err != nil {
+		return err
+	}
+	failures := 0
+	for _, dbResource := range dbResources {
+		var ok bool
+		if rollback {
+			ok, err = rollbackResource(ctx, db, clients, dbResource)
+		} else {
+			ok, err = applyResource(ctx, db, clients, dbResource)
+		}
+		if err != nil {
+			return err
+		} else if !ok {
+			failures++
+		}
+	}
+	if !rollback && failures > 0 {
+		dbRemediation.Status = StatusFailed
+	} else if !rollback {
+		dbRemediation.Status = StatusApplied
+	} else if failures > 0 {
+		dbRemediation.Status = StatusRollbackFailed
+	} else {
+		dbRemediation.Status = StatusRolledBack
+	}
+	dbRemediation.Updated = time.Now().UTC()
+	logger.Info("Applied tag remediation.", map[string]interface{}{
+		"remediationId": id,
+		"rollback":      rollback,
+		"resources":     len(dbResources),
+		"failures":      failures,
+	})
+	return dbRemediation.Update(db)
+}
+
+// getTaggingClients returns the tagging clients of the AWS accounts of a
+// user.
+func getTaggingClients(db models.XODB, userId int) (taggingClients, error) {
+	clients := taggingClients{
+		accounts: make(map[string]taws.AwsAccount),
+		clients:  make(map[string]*resourcegroupstaggingapi.ResourceGroupsTaggingAPI),
+	}
+	dbAwsAccounts, err := models.AwsAccountsByUserID(db, userId)
+	if err != nil {
+		return clients, err
+	}
+	for _, dbAwsAccount := range dbAwsAccounts {
+		clients.accounts[dbAwsAccount.AwsIdentity] = taws.AwsAccountFromDbAwsAccount(*dbAwsAccount)
+	}
+	return clients, nil
+}
+
+// applyResource changes the tags of a resource which was not changed yet. It
+// returns false if it failed.
+func applyResource(ctx context.Context, db models.XODB, clients taggingClients, dbResource *models.TagRemediationResource) (bool, error) {
+	if dbResource.Status != StatusPending && dbResource.Status != StatusFailed {
+		return true, nil
+	}
+	return processResource(ctx, db, clients, dbResource, false, StatusApplied, StatusFailed)
+}
+
+// rollbackResource restores the tags of a resource which was changed. It
+// returns false if it failed.
+func rollbackResource(ctx context.Context, db models.XODB, clients taggingClients, dbResource *models.TagRemediationResource) (bool, error) {
+	if dbResource.Status != StatusApplied && dbResource.Status != StatusRollbackFailed {
+		return true, nil
+	}
+	return processResource(ctx, db, clients, dbResource, true, StatusRolledBack, StatusRollbackFailed)
+}
+
+// processResource changes the tags of a resource to its tags after the
+// remediation, or before it if reverse is set, and records the outcome.
+func processResource(ctx context.Context, db models.XODB, clients taggingClients, dbResource *models.TagRemediationResource, reverse bool, success, failure string) (bool, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	var before, after Tags
+	err := json.Unmarshal(dbResource.TagsBefore, &before)
+	if err == nil {
+		err = json.Unmarshal(dbResource.TagsAfter, &after)
+	}
+	if err != nil {
+		return false, err
+	}
+	diff := GetDiff(before, after)
+	if reverse {
+		diff = GetDiff(after, before)
+	}
+	client, err := clients.get(dbResource.Account, dbResource.Region)
+	if err == nil {
+		err = tagResource(client, dbResource.Arn, diff)
+	}
+	if err != nil {
+		logger.Warning("Failed to change the tags of a resource.", map[string]interface{}{
+			"remediationId": dbResource.RemediationID,
+			"arn":           dbResource.Arn,
+			"error":         err.Error(),
+		})
+		dbResource.Status = failure
+		dbResource.Error = truncateError(err.Error())
+	} else {
+		dbResource.Status = success
+		dbResource.Error = ""
+	}
+	dbResource.Updated = time.Now().UTC()
+	return err == nil, dbResource.Update(db)
+}
+
+// tagResource makes the changes of a diff to the tags of a resource through
+// the Resource Groups Tagging API.
+func tagResource(client *resourcegroupstaggingapi.ResourceGroupsTaggingAPI, arn string, diff Diff) error {
+	if len(diff.Set) > 0 {
+		output, err := client.TagResources(&resourcegroupstaggingapi.TagResourcesInput{
+			ResourceARNList: aws.StringSlice([]string{arn}),
+			Tags:            aws.StringMap(diff.Set),
+		})
+		if err != nil {
+			return err
+		} else if err = failureError(output.FailedResourcesMap, arn); err != nil {
+			return err
+		}
+	}
+	if len(diff.Remove) > 0 {
+		output, err := client.UntagResources(&resourcegroupstaggingapi.UntagResourcesInput{
+			ResourceARNList: aws.StringSlice([]string{arn}),
+			TagKeys:         aws.StringSlice(diff.Remove),
+		})
+		if err != nil {
+			return err
+		} else if err = failureError(output.FailedResourcesMap, arn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// failureError returns the failure the Resource Groups Tagging API reported
+// for a resource, if any.
+func failureError(failures map[string]*resourcegroupstaggingapi.FailureInfo, arn string) error {
+	failure, ok := failures[arn]
+	if !ok || failure == nil {
+		return nil
+	}
+	return errors.New(aws.StringValue(failure.ErrorCode) + ": " + aws.StringValue(failure.ErrorMessage))
+}
+
+// truncateError truncates an error message to the size of the error column.
+func truncateError(message string) string {
+	if len(message) > maxErrorLength {
+		return message[:maxErrorLength]
+	}
+	return message
+}
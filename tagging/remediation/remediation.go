@@ -0,0 +1,473 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package remediation
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/tagging/utils"
+	"github.com/trackit/trackit/users"
+)
+
+// Action is the kind of a change made to the tags of resources.
+type Action string
+
+const (
+	// ActionSet sets the value of a tag, adding it if it is missing.
+	ActionSet = Action("set")
+	// ActionRemove removes a tag.
+	ActionRemove = Action("remove")
+	// ActionRename moves the value of a tag to another key.
+	ActionRename = Action("rename")
+)
+
+// Statuses of a remediation.
+const (
+	StatusPending           = "pending"
+	StatusApproved          = "approved"
+	StatusRejected          = "rejected"
+	StatusApplied           = "applied"
+	StatusFailed            = "failed"
+	StatusRollbackRequested = "rollback-requested"
+	StatusRolledBack        = "rolled-back"
+	StatusRollbackFailed    = "rollback-failed"
+)
+
+const (
+	// maxResources is the maximum number of resources a remediation can
+	// change.
+	maxResources = 1000
+	// maxKeyLength and maxValueLength are the limits AWS puts on the length
+	// of tags.
+	maxKeyLength   = 128
+	maxValueLength = 256
+	// reservedPrefix is the prefix of the tag keys reserved to AWS, which
+	// cannot be changed.
+	reservedPrefix = "aws:"
+)
+
+var (
+	ErrRemediationNotFound = errors.New("Tag remediation not found.")
+	ErrNoChanges           = errors.New("A tag remediation needs at least one change.")
+	ErrNoResources         = errors.New("None of the selected resources would have their tags changed.")
+	ErrTooManyResources    = fmt.Errorf("A tag remediation cannot change more than %d resources.", maxResources)
+	ErrSeveralOwners       = errors.New("A tag remediation can only change the resources of the AWS accounts of a single owner.")
+	ErrSelfApproval        = errors.New("A tag remediation must be approved by its owner, an administrator or another user than the one who proposed it.")
+)
+
+type (
+	// Change is a change made to the tags of the resources of a
+	// remediation.
+	Change struct {
+		Action Action `json:"action"`
+		Key    string `json:"key"`
+		// Value is the value of the tag, for ActionSet.
+		Value string `json:"value,omitempty"`
+		// NewKey is the key the tag is moved to, for ActionRename.
+		NewKey string `json:"newKey,omitempty"`
+	}
+
+	// Tags are the tags of a resource, by key.
+	Tags map[string]string
+
+	// Resource is a resource whose tags a remediation changes.
+	Resource struct {
+		Id           int       `json:"id,omitempty"`
+		Account      string    `json:"account"`
+		Region       string    `json:"region"`
+		ResourceType string    `json:"resourceType"`
+		ResourceId   string    `json:"resourceId"`
+		Arn          string    `json:"arn"`
+		TagsBefore   Tags      `json:"tagsBefore"`
+		TagsAfter    Tags      `json:"tagsAfter"`
+		Diff         Diff      `json:"diff"`
+		Status       string    `json:"status,omitempty"`
+		Error        string    `json:"error,omitempty"`
+		Updated      time.Time `json:"updated,omitempty"`
+	}
+
+	// Diff is the difference between two sets of tags.
+	Diff struct {
+		// Set are the tags which are added or whose value changes.
+		Set Tags `json:"set"`
+		// Remove are the keys of the tags which are removed.
+		Remove []string `json:"remove"`
+	}
+
+	// Remediation is a set of tag changes proposed on resources, which are
+	// applied to AWS once another user than the one who proposed it, its
+	// owner or an administrator approves it. It belongs to the owner of the AWS accounts of its
+	// resources, whose roles are used to apply it.
+	Remediation struct {
+		Id         int        `json:"id"`
+		UserId     int        `json:"userId"`
+		CreatedBy  int        `json:"createdBy"`
+		ReviewedBy *int       `json:"reviewedBy"`
+		Status     string     `json:"status"`
+		Changes    []Change   `json:"changes"`
+		JobId      *int       `json:"jobId"`
+		Created    time.Time  `json:"created"`
+		Updated    time.Time  `json:"updated"`
+		Resources  []Resource `json:"resources,omitempty"`
+	}
+)
+
+// validKey checks that a tag key can be changed.
+func validKey(key string) error {
+	if key == "" {
+		return errors.New("Tag keys cannot be empty.")
+	} else if len(key) > maxKeyLength {
+		return fmt.Errorf("Tag key '%s' is longer than %d characters.", key, maxKeyLength)
+	} else if strings.HasPrefix(strings.ToLower(key), reservedPrefix) {
+		return fmt.Errorf("Tag key '%s' is reserved to AWS.", key)
+	}
+	return nil
+}
+
+// ValidateChanges checks that changes can be made to the tags of resources.
+func ValidateChanges(changes []Change) error {
+	if len(changes) == 0 {
+		return ErrNoChanges
+	}
+	for _, change := range changes {
+		if err := validKey(change.Key); err != nil {
+			return err
+		}
+		switch change.Action {
+		case ActionSet:
+			if len(change.Value) > maxValueLength {
+				return fmt.Errorf("Value of tag '%s' is longer than %d characters.", change.Key, maxValueLength)
+			}
+		case ActionRemove:
+		case ActionRename:
+			if err := validKey(change.NewKey); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("Unknown action '%s', expected 'set', 'remove' or 'rename'.", change.Action)
+		}
+	}
+	return nil
+}
+
+// ApplyChanges returns the tags resulting from changes made in order to
+// tags, which are left untouched. Renaming a missing tag does nothing.
+func ApplyChanges(tags Tags, changes []Change) Tags {
+	res := make(Tags, len(tags))
+	for key, value := range tags {
+		res[key] = value
+	}
+	for _, change := range changes {
+		switch change.Action {
+		case ActionSet:
+			res[change.Key] = change.Value
+		case ActionRemove:
+			delete(res, change.Key)
+		case ActionRename:
+			if value, ok := res[change.Key]; ok && change.NewKey != change.Key {
+				delete(res, change.Key)
+				res[change.NewKey] = value
+			}
+		}
+	}
+	return res
+}
+
+// GetDiff returns the changes turning the tags from into the tags to.
+func GetDiff(from, to Tags) Diff {
+	diff := Diff{Set: Tags{}, Remove: []string{}}
+	for key, value := range to {
+		if old, ok := from[key]; !ok || old != value {
+			diff.Set[key] = value
+		}
+	}
+	for key := range from {
+		if _, ok := to[key]; !ok {
+			diff.Remove = append(diff.Remove, key)
+		}
+	}
+	sort.Strings(diff.Remove)
+	return diff
+}
+
+// Empty checks whether a diff changes nothing.
+func (d Diff) Empty() bool {
+	return len(d.Set) == 0 && len(d.Remove) == 0
+}
+
+// tagsFromDocument returns the tags of a tagging report.
+func tagsFromDocument(doc utils.TaggingReportDocument) Tags {
+	tags := make(Tags, len(doc.Tags))
+	for _, tag := range doc.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	return tags
+}
+
+// GetResources returns the resources of tagging reports the user can access
+// whose tags the changes modify, with their tags before and after them.
+func GetResources(user users.User, docs []utils.TaggingReportDocument, changes []Change) ([]Resource, error) {
+	resources := make([]Resource, 0)
+	for _, doc := range docs {
+		if !user.CanAccessAccount(doc.Account) {
+			continue
+		}
+		before := tagsFromDocument(doc)
+		after := ApplyChanges(before, changes)
+		diff := GetDiff(before, after)
+		if diff.Empty() {
+			continue
+		}
+		arn, err := utils.ResourceArn(doc)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, Resource{
+			Account:      doc.Account,
+			Region:       doc.Region,
+			ResourceType: doc.ResourceType,
+			ResourceId:   doc.ResourceID,
+			Arn:          arn,
+			TagsBefore:   before,
+			TagsAfter:    after,
+			Diff:         diff,
+		})
+	}
+	if len(resources) == 0 {
+		return nil, ErrNoResources
+	} else if len(resources) > maxResources {
+		return nil, ErrTooManyResources
+	}
+	return resources, nil
+}
+
+// GetOwner returns the owner of the AWS accounts of the resources of a
+// remediation proposed by a user: either the user themselves or the user
+// who shared the accounts with them. It fails with ErrSeveralOwners if the
+// accounts belong to different users.
+func GetOwner(tx *sql.Tx, user users.User, resources []Resource) (int, error) {
+	owners := make(map[string]int)
+	dbSharedAccounts, err := models.SharedAccountsWithRoleByUserID(tx, user.Id)
+	if err != nil {
+		return 0, err
+	}
+	for _, dbSharedAccount := range dbSharedAccounts {
+		owners[dbSharedAccount.AwsIdentity] = dbSharedAccount.OwnerID
+	}
+	dbAwsAccounts, err := models.AwsAccountsByUserID(tx, user.Id)
+	if err != nil {
+		return 0, err
+	}
+	for _, dbAwsAccount := range dbAwsAccounts {
+		owners[dbAwsAccount.AwsIdentity] = user.Id
+	}
+	return remediationOwner(user.Id, owners, resources)
+}
+
+// remediationOwner returns the owner of the accounts of resources, given
+// the owners of the accounts by AWS identity. The accounts without owner
+// belong to the user.
+func remediationOwner(userId int, owners map[string]int, resources []Resource) (int, error) {
+	owner := 0
+	for _, resource := range resources {
+		accountOwner, ok := owners[resource.Account]
+		if !ok {
+			accountOwner = userId
+		}
+		if owner != 0 && owner != accountOwner {
+			return 0, ErrSeveralOwners
+		}
+		owner = accountOwner
+	}
+	return owner, nil
+}
+
+// CreateRemediation stores a pending remediation of resources proposed by a
+// user for the tags of a user.
+func CreateRemediation(tx *sql.Tx, userId, createdBy int, changes []Change, resources []Resource) (Remediation, error) {
+	now := time.Now().UTC()
+	dbRemediation := models.TagRemediation{
+		UserID:    userId,
+		CreatedBy: createdBy,
+		Status:    StatusPending,
+		Created:   now,
+		Updated:   now,
+	}
+	var err error
+	if dbRemediation.Changes, err = json.Marshal(changes); err != nil {
+		return Remediation{}, err
+	} else if err = dbRemediation.Insert(tx); err != nil {
+		return Remediation{}, err
+	}
+	for i := range resources {
+		dbResource := models.TagRemediationResource{
+			RemediationID: dbRemediation.ID,
+			Account:       resources[i].Account,
+			Region:        resources[i].Region,
+			ResourceType:  resources[i].ResourceType,
+			ResourceID:    resources[i].ResourceId,
+			Arn:           resources[i].Arn,
+			Status:        StatusPending,
+			Updated:       now,
+		}
+		if dbResource.TagsBefore, err = json.Marshal(resources[i].TagsBefore); err != nil {
+			return Remediation{}, err
+		} else if dbResource.TagsAfter, err = json.Marshal(resources[i].TagsAfter); err != nil {
+			return Remediation{}, err
+		} else if err = dbResource.Insert(tx); err != nil {
+			return Remediation{}, err
+		}
+		resources[i].Id = dbResource.ID
+		resources[i].Status = dbResource.Status
+		resources[i].Updated = now
+	}
+	remediation, err := remediationFromDb(&dbRemediation)
+	remediation.Resources = resources
+	return remediation, err
+}
+
+// GetRemediations returns the latest remediations a user owns or proposed,
+// without their resources.
+func GetRemediations(tx *sql.Tx, userId int, limit int) ([]Remediation, error) {
+	dbRemediations, err := models.TagRemediationsLatestByUserID(tx, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+	remediations := make([]Remediation, len(dbRemediations))
+	for i, dbRemediation := range dbRemediations {
+		if remediations[i], err = remediationFromDb(dbRemediation); err != nil {
+			return nil, err
+		}
+	}
+	return remediations, nil
+}
+
+// GetRemediation returns a remediation with its resources. It fails with
+// ErrRemediationNotFound if the user neither owns nor proposed it, and
+// cannot access all the accounts of its resources.
+func GetRemediation(db models.XODB, user users.User, id int) (*models.TagRemediation, Remediation, error) {
+	dbRemediation, err := models.TagRemediationByID(db, id)
+	if err == sql.ErrNoRows {
+		return nil, Remediation{}, ErrRemediationNotFound
+	} else if err != nil {
+		return nil, Remediation{}, err
+	}
+	remediation, err := remediationFromDb(dbRemediation)
+	if err != nil {
+		return nil, remediation, err
+	}
+	dbResources, err := models.TagRemediationResourcesByRemediationID(db, id)
+	if err != nil {
+		return nil, remediation, err
+	}
+	remediation.Resources = make([]Resource, len(dbResources))
+	for i, dbResource := range dbResources {
+		if remediation.Resources[i], err = resourceFromDb(dbResource); err != nil {
+			return nil, remediation, err
+		}
+	}
+	if !remediation.visibleTo(user) {
+		return nil, Remediation{}, ErrRemediationNotFound
+	}
+	return dbRemediation, remediation, nil
+}
+
+// visibleTo checks whether a user owns or proposed a remediation, or can
+// access all the accounts of its resources.
+func (r Remediation) visibleTo(user users.User) bool {
+	if r.UserId == user.Id || r.CreatedBy == user.Id {
+		return true
+	} else if user.Accounts == nil {
+		return false
+	}
+	for _, account := range r.Accounts() {
+		if !user.CanAccessAccount(account) {
+			return false
+		}
+	}
+	return true
+}
+
+// approvableBy checks whether a user can approve a remediation. Users cannot
+// approve the remediations they proposed, unless they own them or are
+// administrators, who may have nobody else to approve them.
+func (r Remediation) approvableBy(user users.User, isAdmin bool) bool {
+	return r.CreatedBy != user.Id || r.UserId == user.Id || isAdmin
+}
+
+// Accounts returns the accounts of the resources of a remediation.
+func (r Remediation) Accounts() []string {
+	seen := make(map[string]bool)
+	accounts := make([]string, 0)
+	for _, resource := range r.Resources {
+		if !seen[resource.Account] {
+			seen[resource.Account] = true
+			accounts = append(accounts, resource.Account)
+		}
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// remediationFromDb converts a remediation from the database.
+func remediationFromDb(dbRemediation *models.TagRemediation) (Remediation, error) {
+	remediation := Remediation{
+		Id:        dbRemediation.ID,
+		UserId:    dbRemediation.UserID,
+		CreatedBy: dbRemediation.CreatedBy,
+		Status:    dbRemediation.Status,
+		Created:   dbRemediation.Created,
+		Updated:   dbRemediation.Updated,
+	}
+	if dbRemediation.ReviewedBy.Valid {
+		reviewedBy := int(dbRemediation.ReviewedBy.Int64)
+		remediation.ReviewedBy = &reviewedBy
+	}
+	if dbRemediation.JobID.Valid {
+		jobId := int(dbRemediation.JobID.Int64)
+		remediation.JobId = &jobId
+	}
+	err := json.Unmarshal(dbRemediation.Changes, &remediation.Changes)
+	return remediation, err
+}
+
+// resourceFromDb converts a resource of a remediation from the database.
+func resourceFromDb(dbResource *models.TagRemediationResource) (Resource, error) {
+	resource := Resource{
+		Id:           dbResource.ID,
+		Account:      dbResource.Account,
+		Region:       dbResource.Region,
+		ResourceType: dbResource.ResourceType,
+		ResourceId:   dbResource.ResourceID,
+		Arn:          dbResource.Arn,
+		Status:       dbResource.Status,
+		Error:        dbResource.Error,
+		Updated:      dbResource.Updated,
+	}
+	if err := json.Unmarshal(dbResource.TagsBefore, &resource.TagsBefore); err != nil {
+		return resource, err
+	} else if err := json.Unmarshal(dbResource.TagsAfter, &resource.TagsAfter); err != nil {
+		return resource, err
+	}
+	resource.Diff = GetDiff(resource.TagsBefore, resource.TagsAfter)
+	return resource, nil
+}
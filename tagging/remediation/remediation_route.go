@@ -0,0 +1,306 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package remediation
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/audit"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/jobs"
+	"github.com/trackit/trackit/routes"
+	taggingRoutes "github.com/trackit/trackit/tagging/routes"
+	"github.com/trackit/trackit/users"
+)
+
+// applyJobType is the type of the job which applies remediations to AWS.
+const applyJobType = "apply-tag-remediation"
+
+// maxRemediations is the number of remediations the list route returns.
+const maxRemediations = 100
+
+// proposalBody is the body of the route proposing a remediation.
+type proposalBody struct {
+	Resources taggingRoutes.ResourcesRequestBody `json:"resources" req:"nonzero"`
+	Changes   []Change                           `json:"changes"   req:"nonzero"`
+}
+
+var (
+	// remediationIdQueryArg is the remediation which is reviewed.
+	remediationIdQueryArg = routes.QueryArg{
+		Name:        "id",
+		Type:        routes.QueryArgInt{},
+		Description: "The ID of the tag remediation.",
+	}
+	// remediationIdOptionalQueryArg is the remediation which is returned.
+	remediationIdOptionalQueryArg = routes.QueryArg{
+		Name:        "id",
+		Type:        routes.QueryArgInt{},
+		Description: "The ID of the tag remediation to get with its resources, the latest ones without them if omitted.",
+		Optional:    true,
+	}
+	// dryRunOptionalQueryArg returns the remediation without storing it.
+	dryRunOptionalQueryArg = routes.QueryArg{
+		Name:        "dryRun",
+		Type:        routes.QueryArgBool{},
+		Description: "Responds with the changes the tag remediation would make without proposing it.",
+		Optional:    true,
+	}
+)
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getRemediations).With(
+			users.RequireAuthenticatedUser{users.ViewerAsParent},
+			routes.QueryArgs{remediationIdOptionalQueryArg},
+			routes.Documentation{
+				Summary:     "get the tag remediations",
+				Description: "Responds with the latest tag remediations the user owns or proposed, or with one of them and its resources. The remediations of the accounts the user can access can be retrieved by ID too.",
+			},
+		),
+		http.MethodPost: routes.H(postRemediation).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.RequestContentType{"application/json"},
+			routes.QueryArgs{dryRunOptionalQueryArg},
+			routes.RequestBody{proposalBody{
+				Resources: taggingRoutes.ResourcesRequestBody{
+					Accounts:      []string{"394125495069"},
+					Regions:       []string{"us-west-2"},
+					ResourceTypes: []string{"ec2"},
+					Tags:          []taggingRoutes.Tag{},
+					MissingTags:   []taggingRoutes.Tag{{Key: "CostCenter", Value: "CC-0042"}},
+				},
+				Changes: []Change{
+					{Action: ActionRename, Key: "env", NewKey: "Environment"},
+					{Action: ActionSet, Key: "CostCenter", Value: "CC-0042"},
+				},
+			}},
+			routes.Documentation{
+				Summary:     "propose a tag remediation",
+				Description: "Proposes changes to the tags of the resources the filter selects, as the tagging resources route does. Changes are made in order: 'set' sets the value of a key, 'remove' removes a key and 'rename' moves the value of a key to the new key. Keys reserved to AWS cannot be changed. Responds with the resources whose tags change and their tags before and after the changes. The remediation is stored pending approval unless it is a dry run. It belongs to the owner of the AWS accounts of the resources, which must all have the same owner.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{Db: db.Db},
+		routes.Documentation{
+			Summary:     "interact with the tag remediations",
+			Description: "Tag remediations change the tags of resources in AWS once another user than the one who proposed them, their owner or an administrator approves them.",
+		},
+	).Register("/tagging/remediations")
+
+	reviewRoute(approveRemediation, "approve a tag remediation", "Approves a pending tag remediation and enqueues the job changing the tags of its resources in AWS. The user needs the permission to approve tag changes on all the accounts of the resources, and cannot approve the tag remediations they proposed unless they own them or are an administrator.").Register("/tagging/remediations/approve")
+	reviewRoute(rejectRemediation, "reject a tag remediation", "Rejects a pending tag remediation.").Register("/tagging/remediations/reject")
+	reviewRoute(rollbackRemediation, "roll back a tag remediation", "Enqueues the job restoring the tags the resources of an applied tag remediation had before it. The user needs the permission to approve tag changes on all the accounts of the resources.").Register("/tagging/remediations/rollback")
+}
+
+// reviewRoute returns the route of an action reviewing a remediation.
+func reviewRoute(h routes.SimpleHandler, summary, description string) routes.Handler {
+	return routes.MethodMuxer{
+		http.MethodPost: routes.H(h).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.QueryArgs{remediationIdQueryArg},
+			routes.Documentation{
+				Summary:     summary,
+				Description: description,
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{Db: db.Db},
+	)
+}
+
+// getRemediations is a route handler which returns the latest remediations
+// of the user, or one of them with its resources.
+func getRemediations(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	if id, ok := a[remediationIdOptionalQueryArg].(int); ok {
+		_, remediation, err := GetRemediation(tx, user, id)
+		if err == ErrRemediationNotFound {
+			return http.StatusNotFound, err
+		} else if err != nil {
+			l.Error("Failed to get tag remediation", map[string]interface{}{
+				"userId":        user.Id,
+				"remediationId": id,
+				"error":         err.Error(),
+			})
+			return http.StatusInternalServerError, errors.New("Failed to retrieve tag remediation.")
+		}
+		return http.StatusOK, remediation
+	}
+	remediations, err := GetRemediations(tx, user.Id, maxRemediations)
+	if err != nil {
+		l.Error("Failed to get tag remediations", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve tag remediations.")
+	}
+	return http.StatusOK, remediations
+}
+
+// postRemediation is a route handler which proposes a remediation of the
+// resources selected by the body, or returns its diff if it is a dry run.
+func postRemediation(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	var body proposalBody
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	if err := ValidateChanges(body.Changes); err != nil {
+		return http.StatusBadRequest, err
+	}
+	code, docs, err := taggingRoutes.GetResources(r.Context(), body.Resources, user)
+	if err != nil {
+		return code, err
+	}
+	resources, err := GetResources(user, docs, body.Changes)
+	if err == ErrNoResources || err == ErrTooManyResources {
+		return http.StatusBadRequest, err
+	} else if err != nil {
+		l.Error("Failed to get the resources of a tag remediation", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to retrieve resources.")
+	}
+	owner, err := GetOwner(tx, user, resources)
+	if err == ErrSeveralOwners {
+		return http.StatusBadRequest, err
+	} else if err != nil {
+		l.Error("Failed to get the owner of a tag remediation", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to retrieve resources.")
+	}
+	if dryRun, _ := a[dryRunOptionalQueryArg].(bool); dryRun {
+		now := time.Now().UTC()
+		return http.StatusOK, Remediation{
+			UserId:    owner,
+			CreatedBy: user.Id,
+			Status:    StatusPending,
+			Changes:   body.Changes,
+			Created:   now,
+			Updated:   now,
+			Resources: resources,
+		}
+	}
+	remediation, err := CreateRemediation(tx, owner, user.Id, body.Changes, resources)
+	if err != nil {
+		l.Error("Failed to create tag remediation", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to create tag remediation.")
+	}
+	if err := audit.Record(r, tx, user, audit.Entry{
+		Action:     audit.ActionTagRemediationCreate,
+		TargetType: audit.TargetTagRemediation,
+		TargetId:   strconv.Itoa(remediation.Id),
+		After:      remediation.Changes,
+	}); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, remediation
+}
+
+// approveRemediation is a route handler which approves a pending
+// remediation and enqueues the job applying it.
+func approveRemediation(r *http.Request, a routes.Arguments) (int, interface{}) {
+	return reviewRemediation(r, a, StatusPending, StatusApproved, audit.ActionTagRemediationApprove, true)
+}
+
+// rejectRemediation is a route handler which rejects a pending remediation.
+func rejectRemediation(r *http.Request, a routes.Arguments) (int, interface{}) {
+	return reviewRemediation(r, a, StatusPending, StatusRejected, audit.ActionTagRemediationReject, false)
+}
+
+// rollbackRemediation is a route handler which enqueues the job rolling back
+// an applied remediation.
+func rollbackRemediation(r *http.Request, a routes.Arguments) (int, interface{}) {
+	return reviewRemediation(r, a, StatusApplied, StatusRollbackRequested, audit.ActionTagRemediationRollback, true)
+}
+
+// reviewRemediation moves a remediation from a status to another, and
+// enqueues the job applying it with the accounts of its owner if apply is
+// set. The user needs the permission to approve tag changes on all the
+// accounts of its resources, and cannot approve the ones they proposed
+// unless they own them or are an administrator.
+// Remediations which partly failed can be rolled back too.
+func reviewRemediation(r *http.Request, a routes.Arguments, from, to string, action audit.Action, apply bool) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	id := a[remediationIdQueryArg].(int)
+	dbRemediation, remediation, err := GetRemediation(tx, user, id)
+	if err == ErrRemediationNotFound {
+		return http.StatusNotFound, err
+	} else if err != nil {
+		l.Error("Failed to get tag remediation", map[string]interface{}{
+			"userId":        user.Id,
+			"remediationId": id,
+			"error":         err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve tag remediation.")
+	}
+	if remediation.Status != from && !(from == StatusApplied && (remediation.Status == StatusFailed || remediation.Status == StatusRollbackFailed)) {
+		return http.StatusBadRequest, errors.New("The tag remediation is " + remediation.Status + ".")
+	}
+	for _, account := range remediation.Accounts() {
+		if !user.CanAccessAccount(account) {
+			return http.StatusForbidden, users.ErrPermissionDenied
+		}
+	}
+	if to == StatusApproved && !remediation.approvableBy(user, users.IsAdmin(user)) {
+		return http.StatusForbidden, ErrSelfApproval
+	}
+	before := remediation.Status
+	dbRemediation.Status = to
+	dbRemediation.ReviewedBy = sql.NullInt64{Int64: int64(user.Id), Valid: true}
+	dbRemediation.Updated = time.Now().UTC()
+	if apply {
+		var job jobs.Job
+		job, err = jobs.Enqueue(tx, applyJobType, []string{strconv.Itoa(dbRemediation.UserID), strconv.Itoa(id)}, jobs.Options{UserId: dbRemediation.UserID})
+		dbRemediation.JobID = sql.NullInt64{Int64: int64(job.Id), Valid: err == nil}
+	}
+	if err == nil {
+		err = dbRemediation.Update(tx)
+	}
+	if err != nil {
+		l.Error("Failed to review tag remediation", map[string]interface{}{
+			"userId":        user.Id,
+			"remediationId": id,
+			"status":        to,
+			"error":         err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to update tag remediation.")
+	}
+	if err := audit.Record(r, tx, user, audit.Entry{
+		Action:     action,
+		TargetType: audit.TargetTagRemediation,
+		TargetId:   strconv.Itoa(id),
+		Before:     before,
+		After:      to,
+	}); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	_, remediation, err = GetRemediation(tx, user, id)
+	if err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to retrieve tag remediation.")
+	}
+	return http.StatusOK, remediation
+}
@@ -0,0 +1,156 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package remediation
+
+import (
+	"reflect"
+	"testing"
+
+	usageReports "github.com/trackit/trackit/aws/usageReports"
+	"github.com/trackit/trackit/tagging/utils"
+	"github.com/trackit/trackit/users"
+)
+
+func TestValidateChanges(t *testing.T) {
+	for _, test := range []struct {
+		changes  []Change
+		expected bool
+	}{
+		{[]Change{{Action: ActionSet, Key: "Environment", Value: "prod"}, {Action: ActionRename, Key: "env", NewKey: "Environment"}}, true},
+		{[]Change{{Action: ActionRemove, Key: "Owner"}}, true},
+		{[]Change{}, false},
+		{[]Change{{Action: ActionSet, Key: ""}}, false},
+		{[]Change{{Action: ActionSet, Key: "aws:cloudformation:stack-name"}}, false},
+		{[]Change{{Action: ActionRename, Key: "env", NewKey: "AWS:env"}}, false},
+		{[]Change{{Action: "copy", Key: "env"}}, false},
+	} {
+		if err := ValidateChanges(test.changes); (err == nil) != test.expected {
+			t.Errorf("Changes %+v: expected valid %v, got error %v.", test.changes, test.expected, err)
+		}
+	}
+}
+
+func TestApplyChanges(t *testing.T) {
+	before := Tags{"env": "prod", "Owner": "alice", "Team": "data"}
+	after := ApplyChanges(before, []Change{
+		{Action: ActionRename, Key: "env", NewKey: "Environment"},
+		{Action: ActionSet, Key: "Owner", Value: "bob"},
+		{Action: ActionRemove, Key: "Team"},
+		{Action: ActionRename, Key: "missing", NewKey: "Other"},
+	})
+	expected := Tags{"Environment": "prod", "Owner": "bob"}
+	if !reflect.DeepEqual(after, expected) {
+		t.Errorf("Expected %v, got %v.", expected, after)
+	}
+	if len(before) != 3 || before["env"] != "prod" {
+		t.Errorf("Expected the tags before to be left untouched, got %v.", before)
+	}
+}
+
+func TestGetDiff(t *testing.T) {
+	diff := GetDiff(Tags{"env": "prod", "Owner": "alice", "Team": "data"}, Tags{"Environment": "prod", "Owner": "bob", "Team": "data"})
+	expected := Diff{Set: Tags{"Environment": "prod", "Owner": "bob"}, Remove: []string{"env"}}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("Expected %v, got %v.", expected, diff)
+	}
+	if !GetDiff(Tags{"Owner": "bob"}, Tags{"Owner": "bob"}).Empty() {
+		t.Errorf("Expected an empty diff between equal tags.")
+	}
+}
+
+func TestGetResources(t *testing.T) {
+	docs := []utils.TaggingReportDocument{
+		{Account: "1", Region: "us-east-1", ResourceType: "ec2", ResourceID: "i-1", Tags: []usageReports.Tag{{Key: "env", Value: "prod"}}},
+		{Account: "1", Region: "us-east-1", ResourceType: "ec2", ResourceID: "i-2", Tags: []usageReports.Tag{{Key: "Environment", Value: "dev"}}},
+		{Account: "2", Region: "eu-west-1", ResourceType: "lambda", ResourceID: "f", Tags: []usageReports.Tag{{Key: "env", Value: "dev"}}},
+	}
+	changes := []Change{{Action: ActionRename, Key: "env", NewKey: "Environment"}}
+	resources, err := GetResources(users.User{Accounts: []string{"1"}}, docs, changes)
+	if err != nil {
+		t.Fatalf("Unexpected error %v.", err)
+	} else if len(resources) != 1 {
+		t.Fatalf("Expected only the changed resource of the accessible account, got %+v.", resources)
+	}
+	if resources[0].Arn != "arn:aws:ec2:us-east-1:1:instance/i-1" {
+		t.Errorf("Unexpected ARN %s.", resources[0].Arn)
+	}
+	if !reflect.DeepEqual(resources[0].Diff, Diff{Set: Tags{"Environment": "prod"}, Remove: []string{"env"}}) {
+		t.Errorf("Unexpected diff %+v.", resources[0].Diff)
+	}
+	if _, err := GetResources(users.User{}, docs[1:2], changes); err != ErrNoResources {
+		t.Errorf("Expected ErrNoResources, got %v.", err)
+	}
+}
+
+func TestRemediationOwner(t *testing.T) {
+	owners := map[string]int{"1": 42, "2": 42, "3": 43}
+	for _, test := range []struct {
+		accounts []string
+		owner    int
+		err      error
+	}{
+		{[]string{"1", "2"}, 42, nil},
+		{[]string{"4"}, 7, nil},
+		{[]string{"1", "3"}, 0, ErrSeveralOwners},
+		{[]string{"4", "3"}, 0, ErrSeveralOwners},
+	} {
+		resources := make([]Resource, len(test.accounts))
+		for i, account := range test.accounts {
+			resources[i].Account = account
+		}
+		if owner, err := remediationOwner(7, owners, resources); owner != test.owner || err != test.err {
+			t.Errorf("Accounts %v: expected owner %d (%v), got %d (%v).", test.accounts, test.owner, test.err, owner, err)
+		}
+	}
+}
+
+func TestRemediationVisibleTo(t *testing.T) {
+	remediation := Remediation{
+		UserId:    42,
+		CreatedBy: 43,
+		Resources: []Resource{{Account: "1"}, {Account: "2"}},
+	}
+	for _, test := range []struct {
+		user     users.User
+		expected bool
+	}{
+		{users.User{Id: 42, Accounts: []string{}}, true},
+		{users.User{Id: 43, Accounts: []string{}}, true},
+		{users.User{Id: 44, Accounts: []string{"1", "2"}}, true},
+		{users.User{Id: 44, Accounts: []string{"1"}}, false},
+		{users.User{Id: 44}, false},
+	} {
+		if visible := remediation.visibleTo(test.user); visible != test.expected {
+			t.Errorf("User %+v: expected visible %v, got %v.", test.user, test.expected, visible)
+		}
+	}
+}
+
+func TestRemediationApprovableBy(t *testing.T) {
+	for _, test := range []struct {
+		remediation Remediation
+		isAdmin     bool
+		expected    bool
+	}{
+		{Remediation{UserId: 42, CreatedBy: 43}, false, true},
+		{Remediation{UserId: 42, CreatedBy: 44}, false, false},
+		{Remediation{UserId: 42, CreatedBy: 44}, true, true},
+		{Remediation{UserId: 44, CreatedBy: 44}, false, true},
+	} {
+		if approvable := test.remediation.approvableBy(users.User{Id: 44}, test.isAdmin); approvable != test.expected {
+			t.Errorf("Remediation %+v (admin %v): expected approvable %v, got %v.", test.remediation, test.isAdmin, test.expected, approvable)
+		}
+	}
+}
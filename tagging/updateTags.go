@@ -28,6 +28,7 @@ import (
 	ec2Ri "github.com/trackit/trackit/tagging/ec2Ri"
 	elasticache "github.com/trackit/trackit/tagging/elasticache"
 	esProc "github.com/trackit/trackit/tagging/es"
+	inventory "github.com/trackit/trackit/tagging/inventory"
 	lambda "github.com/trackit/trackit/tagging/lambda"
 	rds "github.com/trackit/trackit/tagging/rds"
 	rdsRi "github.com/trackit/trackit/tagging/rdsRi"
@@ -77,6 +78,38 @@ var processors = []processor{
 		Name: "rds-ri",
 		Run:  rdsRi.Process,
 	},
+	processor{
+		Name: "s3",
+		Run:  inventory.Process,
+	},
+	processor{
+		Name: "dynamodb",
+		Run:  inventory.Process,
+	},
+	processor{
+		Name: "ecs",
+		Run:  inventory.Process,
+	},
+	processor{
+		Name: "eks",
+		Run:  inventory.Process,
+	},
+	processor{
+		Name: "cloudfront",
+		Run:  inventory.Process,
+	},
+	processor{
+		Name: "sqs",
+		Run:  inventory.Process,
+	},
+	processor{
+		Name: "sns",
+		Run:  inventory.Process,
+	},
+	processor{
+		Name: "elb",
+		Run:  inventory.Process,
+	},
 }
 
 // UpdateTagsForUser updates tags in ES for the specified user
@@ -0,0 +1,53 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResourceArn returns the ARN of the resource of a tagging report, which the
+// Resource Groups Tagging API needs to change its tags. It is built from the
+// type and ID of the resource if the report does not carry it, and fails for
+// the resource types it does not know.
+func ResourceArn(doc TaggingReportDocument) (string, error) {
+	if doc.Arn != "" {
+		return doc.Arn, nil
+	}
+	region := GetRegionForURL(doc.Region)
+	switch doc.ResourceType {
+	case "ec2":
+		return fmt.Sprintf("arn:aws:ec2:%s:%s:instance/%s", region, doc.Account, doc.ResourceID), nil
+	case "ebs":
+		return fmt.Sprintf("arn:aws:ec2:%s::snapshot/%s", region, doc.ResourceID), nil
+	case "ec2-ri":
+		return fmt.Sprintf("arn:aws:ec2:%s:%s:reserved-instances/%s", region, doc.Account, doc.ResourceID), nil
+	case "elasticache":
+		return fmt.Sprintf("arn:aws:elasticache:%s:%s:cluster:%s", region, doc.Account, doc.ResourceID), nil
+	case "es":
+		// The ID of a domain is '<ACCOUNT>/<DOMAIN_NAME>'.
+		domain := doc.ResourceID[strings.LastIndex(doc.ResourceID, "/")+1:]
+		return fmt.Sprintf("arn:aws:es:%s:%s:domain/%s", region, doc.Account, domain), nil
+	case "lambda":
+		return fmt.Sprintf("arn:aws:lambda:%s:%s:function:%s", region, doc.Account, doc.ResourceID), nil
+	case "rds":
+		return fmt.Sprintf("arn:aws:rds:%s:%s:db:%s", region, doc.Account, doc.ResourceID), nil
+	case "rds-ri":
+		return fmt.Sprintf("arn:aws:rds:%s:%s:ri:%s", region, doc.Account, doc.ResourceID), nil
+	default:
+		return "", fmt.Errorf("unknown resource type '%s'", doc.ResourceType)
+	}
+}
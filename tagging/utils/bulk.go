@@ -27,10 +27,14 @@ func GenerateBulkID(doc TaggingReportDocument) (string, error) {
 		Account    string    `json:"account"`
 		ReportDate time.Time `json:"reportDate"`
 		ID         string    `json:"id"`
+		Type       string    `json:"resourceType"`
+		Region     string    `json:"region"`
 	}{
 		doc.Account,
 		doc.ReportDate,
 		doc.ResourceID,
+		doc.ResourceType,
+		doc.Region,
 	})
 	if err != nil {
 		return "", err
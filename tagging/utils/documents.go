@@ -20,7 +20,8 @@ import (
 	utils "github.com/trackit/trackit/aws/usageReports"
 )
 
-// TaggingReportDocument is an entry in ES' tagging index
+// TaggingReportDocument is an entry in ES' tagging index. Arn is only set for
+// the resources whose ARN is known when they are collected.
 type TaggingReportDocument struct {
 	Account      string      `json:"account"`
 	ReportDate   time.Time   `json:"reportDate"`
@@ -28,5 +29,6 @@ type TaggingReportDocument struct {
 	ResourceType string      `json:"resourceType"`
 	Region       string      `json:"region"`
 	URL          string      `json:"url"`
+	Arn          string      `json:"arn,omitempty"`
 	Tags         []utils.Tag `json:"tags"`
 }
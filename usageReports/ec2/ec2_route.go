@@ -68,7 +68,7 @@ func init() {
 			db.RequestTransaction{Db: db.Db},
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
 			routes.QueryArgs(ec2QueryArgs),
-			cache.UsersCache{},
+			cache.UsersCache{Events: []string{cache.EventUsageReports}},
 			routes.Documentation{
 				Summary:     "get the list of EC2 instances",
 				Description: "Responds with the list of EC2 instances based on the queryparams passed to it",
@@ -80,7 +80,7 @@ func init() {
 			db.RequestTransaction{Db: db.Db},
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
 			routes.QueryArgs(ec2UnusedQueryArgs),
-			cache.UsersCache{},
+			cache.UsersCache{Events: []string{cache.EventUsageReports}},
 			routes.Documentation{
 				Summary:     "get the list of the most unused EC2 instances of a month",
 				Description: "Responds with the list of the most unused EC2 instances of a month based on the queryparams passed to it",
@@ -48,7 +48,7 @@ func init() {
 			db.RequestTransaction{Db: db.Db},
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
 			routes.QueryArgs(ec2CoverageQueryArgs),
-			cache.UsersCache{},
+			cache.UsersCache{Events: []string{cache.EventUsageReports}},
 			routes.Documentation{
 				Summary:     "get the list of EC2 Coverage reports",
 				Description: "Responds with the list of EC2 Coverage reports based on the queryparams passed to it",
@@ -68,7 +68,7 @@ func init() {
 			db.RequestTransaction{Db: db.Db},
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
 			routes.QueryArgs(elasticacheQueryArgs),
-			cache.UsersCache{},
+			cache.UsersCache{Events: []string{cache.EventUsageReports}},
 			routes.Documentation{
 				Summary:     "get the list of ElastiCache instances",
 				Description: "Responds with the list of ElastiCache instances based on the queryparams passed to it",
@@ -80,7 +80,7 @@ func init() {
 			db.RequestTransaction{Db: db.Db},
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
 			routes.QueryArgs(elasticacheUnusedQueryArgs),
-			cache.UsersCache{},
+			cache.UsersCache{Events: []string{cache.EventUsageReports}},
 			routes.Documentation{
 				Summary:     "get the list of the most unused ElastiCache instances of a month",
 				Description: "Responds with the list of the most unused ElastiCache instances of a month based on the queryparams passed to it",
@@ -68,7 +68,7 @@ func init() {
 			db.RequestTransaction{Db: db.Db},
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
 			routes.QueryArgs(esQueryArgs),
-			cache.UsersCache{},
+			cache.UsersCache{Events: []string{cache.EventUsageReports}},
 			routes.Documentation{
 				Summary:     "get the latest ES report",
 				Description: "Responds with the latest ES report for the account specified in the request",
@@ -80,7 +80,7 @@ func init() {
 			db.RequestTransaction{Db: db.Db},
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
 			routes.QueryArgs(esUnusedQueryArgs),
-			cache.UsersCache{},
+			cache.UsersCache{Events: []string{cache.EventUsageReports}},
 			routes.Documentation{
 				Summary:     "get the list of the most unused ES domains of a month",
 				Description: "Responds with the list of the most unused ES domains of a month based on the queryparams passed to it",
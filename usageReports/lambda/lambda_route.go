@@ -48,7 +48,7 @@ func init() {
 			db.RequestTransaction{Db: db.Db},
 			users.RequireAuthenticatedUser{users.ViewerAsParent},
 			routes.QueryArgs(lambdaQueryArgs),
-			cache.UsersCache{},
+			cache.UsersCache{Events: []string{cache.EventUsageReports}},
 			routes.Documentation{
 				Summary:     "get the list of Lambda functions",
 				Description: "Responds with the list of Lambda functions based on the queryparams passed to it",
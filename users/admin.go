@@ -0,0 +1,66 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/trackit/trackit/config"
+	"github.com/trackit/trackit/routes"
+)
+
+const TagRequireAdminUser = "require:admin"
+
+// RequireAdminUser is a decorator restricting a route to the users whose
+// email is in config.AdminEmails. It must be used after
+// RequireAuthenticatedUser.
+type RequireAdminUser struct{}
+
+func (d RequireAdminUser) Decorate(h routes.Handler) routes.Handler {
+	h.Func = d.getFunc(h.Func)
+	h.Documentation = d.getDocumentation(h.Documentation)
+	return h
+}
+
+func (d RequireAdminUser) getFunc(hf routes.HandlerFunc) routes.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, a routes.Arguments) (int, interface{}) {
+		user := a[AuthenticatedUser].(User)
+		if !IsAdmin(user) {
+			return http.StatusForbidden, errors.New("This action is restricted to administrators.")
+		}
+		return hf(w, r, a)
+	}
+}
+
+func (_ RequireAdminUser) getDocumentation(hd routes.HandlerDocumentation) routes.HandlerDocumentation {
+	if hd.Tags == nil {
+		hd.Tags = make(routes.Tags)
+	}
+	hd.Tags[TagRequireAdminUser] = []string{"admin"}
+	return hd
+}
+
+// IsAdmin checks whether a user is an administrator, i.e. whether its email
+// is in config.AdminEmails.
+func IsAdmin(user User) bool {
+	for _, email := range strings.Split(config.AdminEmails, ",") {
+		if email = strings.TrimSpace(email); email != "" && strings.EqualFold(email, user.Email) {
+			return true
+		}
+	}
+	return false
+}
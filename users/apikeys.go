@@ -0,0 +1,167 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/trackit/trackit/models"
+)
+
+const (
+	// ScopeReadCosts allows an API key to read the costs and usage reports.
+	ScopeReadCosts = "costs:read"
+	// ScopeReadReports allows an API key to read the spreadsheet reports.
+	ScopeReadReports = "reports:read"
+	// ScopeManageAccounts allows an API key to manage the AWS accounts and
+	// billing sources.
+	ScopeManageAccounts = "accounts:manage"
+)
+
+const (
+	// apiKeyPrefix starts every API key, which tells them apart from JWTs.
+	apiKeyPrefix = "tk_"
+	// apiKeyDisplayLength is the length of the start of the API keys kept
+	// to let users recognize them.
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+)
+
+var (
+	ErrInvalidApiKey      = errors.New("invalid or expired API key")
+	ErrApiKeyScope        = errors.New("the API key is not allowed to perform this action")
+	ErrFailedToLoadApiKey = errors.New("failed to load API key")
+)
+
+// Scopes are the scopes an API key can be given.
+var Scopes = []string{ScopeReadCosts, ScopeReadReports, ScopeManageAccounts}
+
+// scopedRoute is a route prefix an API key can access if it was given scope.
+// If readOnly is set, only GET requests are allowed.
+type scopedRoute struct {
+	prefix   string
+	scope    string
+	readOnly bool
+}
+
+// scopedRoutes are the routes API keys can access. Routes match a request if
+// its path is their prefix or a subpath of it. API keys cannot access any
+// other route.
+var scopedRoutes = []scopedRoute{
+	{"/aws", ScopeManageAccounts, false},
+	{"/billing/sources", ScopeManageAccounts, false},
+	{"/report", ScopeReadReports, true},
+	{"/reports", ScopeReadReports, true},
+	{"/budgets", ScopeReadCosts, true},
+	{"/costs", ScopeReadCosts, true},
+	{"/ebs", ScopeReadCosts, true},
+	{"/ec2", ScopeReadCosts, true},
+	{"/elasticache", ScopeReadCosts, true},
+	{"/es", ScopeReadCosts, true},
+	{"/instanceCount", ScopeReadCosts, true},
+	{"/lambda", ScopeReadCosts, true},
+	{"/plugins/results", ScopeReadCosts, true},
+	{"/rds", ScopeReadCosts, true},
+	{"/ri", ScopeReadCosts, true},
+	{"/s3/costs", ScopeReadCosts, true},
+	{"/tagging/compliance", ScopeReadCosts, true},
+	{"/tagging/mostusedtags", ScopeReadCosts, true},
+	{"/tagging/resources", ScopeReadCosts, true},
+}
+
+// isApiKey checks whether an Authorization header holds an API key rather
+// than a JWT.
+func isApiKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+// generateApiKey generates a random API key and returns it with its hash.
+func generateApiKey() (string, string, error) {
+	secret, err := generateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	key := apiKeyPrefix + secret
+	return key, hashSecret(key), nil
+}
+
+// requiredScope returns the scope an API key needs to perform a request. It
+// returns false if API keys cannot perform it.
+func requiredScope(r *http.Request) (string, bool) {
+	for _, sr := range scopedRoutes {
+		if r.URL.Path != sr.prefix && !strings.HasPrefix(r.URL.Path, sr.prefix+"/") {
+			continue
+		} else if sr.readOnly && r.Method != http.MethodGet {
+			return "", false
+		}
+		return sr.scope, true
+	}
+	return "", false
+}
+
+// hasScope checks whether scopes contains scope.
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// testApiKey checks whether an API key is valid and allowed to perform a
+// request, and retrieves the owning User if it is. The User is restricted to
+// the accounts of the key. The last use of the key is recorded.
+func testApiKey(tx *sql.Tx, key string, r *http.Request) (User, error) {
+	dbApiKey, err := models.APIKeyByHash(tx, hashSecret(key))
+	if err == sql.ErrNoRows {
+		return User{}, ErrInvalidApiKey
+	} else if err != nil {
+		return User{}, err
+	}
+	now := time.Now().UTC()
+	if dbApiKey.Expires.Valid && !now.Before(dbApiKey.Expires.Time) {
+		return User{}, ErrInvalidApiKey
+	}
+	var scopes []string
+	if err := json.Unmarshal(dbApiKey.Scopes, &scopes); err != nil {
+		return User{}, ErrFailedToLoadApiKey
+	}
+	if scope, ok := requiredScope(r); !ok || !hasScope(scopes, scope) {
+		return User{}, ErrApiKeyScope
+	} else if required, err := isMfaRequired(tx); err != nil {
+		return User{}, err
+	} else if required && !dbApiKey.MFA {
+		return User{}, ErrMfaRequired
+	}
+	user, err := GetUserWithId(tx, dbApiKey.UserID)
+	if err != nil {
+		return user, err
+	}
+	if dbApiKey.Accounts != nil {
+		user.Accounts = []string{}
+		if err := json.Unmarshal(dbApiKey.Accounts, &user.Accounts); err != nil {
+			return User{}, ErrFailedToLoadApiKey
+		}
+	}
+	dbApiKey.LastUsed = mysql.NullTime{Time: now, Valid: true}
+	return user, dbApiKey.Update(tx)
+}
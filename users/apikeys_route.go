@@ -0,0 +1,254 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+)
+
+type (
+	// ApiKeyBody is the body required to create an API key. An empty
+	// Accounts gives the key access to all the accounts of the user.
+	ApiKeyBody struct {
+		Name     string     `json:"name" req:"nonzero"`
+		Scopes   []string   `json:"scopes" req:"nonzero"`
+		Accounts []string   `json:"accounts"`
+		Expires  *time.Time `json:"expires"`
+	}
+
+	// ApiKey is an API key as returned by the routes. The key itself is
+	// only returned once, when it is created.
+	ApiKey struct {
+		Id       int        `json:"id"`
+		Name     string     `json:"name"`
+		Key      string     `json:"key,omitempty"`
+		Prefix   string     `json:"prefix"`
+		Scopes   []string   `json:"scopes"`
+		Accounts []string   `json:"accounts"`
+		Expires  *time.Time `json:"expires"`
+		LastUsed *time.Time `json:"lastUsed"`
+		Created  time.Time  `json:"created"`
+		// Mfa is set when the key was created from a session verified
+		// with a second factor. The other keys are rejected while the
+		// policy requires MFA.
+		Mfa bool `json:"mfa"`
+	}
+)
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getApiKeys).With(
+			RequireAuthenticatedUser{ViewerCannot},
+			routes.Documentation{
+				Summary:     "get the API keys",
+				Description: "Responds with the API keys of the user, without the keys themselves",
+			},
+		),
+		http.MethodPost: routes.H(postApiKey).With(
+			RequireAuthenticatedUser{ViewerCannot},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{ApiKeyBody{
+				Name:   "Continuous integration",
+				Scopes: []string{ScopeReadCosts},
+			}},
+			routes.Documentation{
+				Summary:     "create an API key",
+				Description: "Creates an API key based on the body and responds with it. The key is only returned once and can be used in the Authorization header instead of a token. Keys cannot prove a second factor: while the policy requires MFA, only the ones created from a session verified with one are accepted",
+			},
+		),
+		http.MethodDelete: routes.H(deleteApiKey).With(
+			RequireAuthenticatedUser{ViewerCannot},
+			routes.QueryArgs{routes.ApiKeyIdQueryArg},
+			routes.Documentation{
+				Summary:     "revoke an API key",
+				Description: "Revokes an API key",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{Db: db.Db},
+		routes.Documentation{
+			Summary:     "interact with the API keys",
+			Description: "API keys give machines a long-lived access to the data of the user, restricted to some scopes and accounts.",
+		},
+	).Register("/user/apikeys")
+}
+
+// apiKeyFromDbApiKey builds an ApiKey from its database representation.
+func apiKeyFromDbApiKey(dbApiKey *models.APIKey) ApiKey {
+	apiKey := ApiKey{
+		Id:      dbApiKey.ID,
+		Name:    dbApiKey.Name,
+		Prefix:  dbApiKey.Prefix,
+		Scopes:  []string{},
+		Created: dbApiKey.Created,
+		Mfa:     dbApiKey.MFA,
+	}
+	json.Unmarshal(dbApiKey.Scopes, &apiKey.Scopes)
+	if dbApiKey.Accounts != nil {
+		json.Unmarshal(dbApiKey.Accounts, &apiKey.Accounts)
+	}
+	if dbApiKey.Expires.Valid {
+		apiKey.Expires = &dbApiKey.Expires.Time
+	}
+	if dbApiKey.LastUsed.Valid {
+		apiKey.LastUsed = &dbApiKey.LastUsed.Time
+	}
+	return apiKey
+}
+
+// getApiKeys is a route handler which returns the API keys of the user.
+func getApiKeys(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[AuthenticatedUser].(User)
+	dbApiKeys, err := models.APIKeysByUserID(tx, user.Id)
+	if err != nil {
+		l.Error("Failed to get API keys", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve API keys.")
+	}
+	apiKeys := make([]ApiKey, len(dbApiKeys))
+	for i, dbApiKey := range dbApiKeys {
+		apiKeys[i] = apiKeyFromDbApiKey(dbApiKey)
+	}
+	return http.StatusOK, apiKeys
+}
+
+// postApiKey is a route handler which creates an API key.
+func postApiKey(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	var body ApiKeyBody
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[AuthenticatedUser].(User)
+	for _, scope := range body.Scopes {
+		if !hasScope(Scopes, scope) {
+			return http.StatusBadRequest, fmt.Errorf("invalid scope : %s", scope)
+		}
+	}
+	if body.Expires != nil && !body.Expires.After(time.Now()) {
+		return http.StatusBadRequest, errors.New("The expiry date must be in the future.")
+	}
+	if code, err := validApiKeyAccounts(tx, user, body.Accounts); err != nil {
+		return code, err
+	}
+	key, hash, err := generateApiKey()
+	if err != nil {
+		l.Error("Failed to generate API key", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to create API key.")
+	}
+	dbApiKey := models.APIKey{
+		UserID:  user.Id,
+		Name:    body.Name,
+		Prefix:  key[:apiKeyDisplayLength],
+		Hash:    hash,
+		Created: time.Now().UTC(),
+		MFA:     user.MfaVerified,
+	}
+	dbApiKey.Scopes, _ = json.Marshal(body.Scopes)
+	if len(body.Accounts) > 0 {
+		dbApiKey.Accounts, _ = json.Marshal(body.Accounts)
+	}
+	if body.Expires != nil {
+		dbApiKey.Expires = mysql.NullTime{Time: body.Expires.UTC(), Valid: true}
+	}
+	if err := dbApiKey.Insert(tx); err != nil {
+		l.Error("Failed to insert API key", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to create API key.")
+	}
+	apiKey := apiKeyFromDbApiKey(&dbApiKey)
+	apiKey.Key = key
+	return http.StatusOK, apiKey
+}
+
+// deleteApiKey is a route handler which revokes an API key.
+func deleteApiKey(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[AuthenticatedUser].(User)
+	apiKeyId := a[routes.ApiKeyIdQueryArg].(int)
+	dbApiKey, err := models.APIKeyByID(tx, apiKeyId)
+	if err == sql.ErrNoRows || (err == nil && dbApiKey.UserID != user.Id) {
+		return http.StatusNotFound, errors.New("API key not found.")
+	} else if err != nil {
+		l.Error("Failed to get API key", map[string]interface{}{
+			"apiKeyId": apiKeyId,
+			"error":    err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve API key.")
+	}
+	if err := dbApiKey.Delete(tx); err != nil {
+		l.Error("Failed to delete API key", map[string]interface{}{
+			"apiKeyId": apiKeyId,
+			"error":    err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to revoke API key.")
+	}
+	return http.StatusOK, nil
+}
+
+// validApiKeyAccounts checks that a user can access the accounts an API key
+// is restricted to: their AWS accounts, the AWS accounts shared with them and
+// the accounts of their billing sources.
+func validApiKeyAccounts(tx *sql.Tx, user User, accounts []string) (int, error) {
+	if len(accounts) == 0 {
+		return http.StatusOK, nil
+	}
+	available := make(map[string]bool)
+	if awsAccounts, err := models.AwsAccountsByUserID(tx, user.Id); err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to retrieve AWS accounts.")
+	} else {
+		for _, awsAccount := range awsAccounts {
+			available[awsAccount.AwsIdentity] = true
+		}
+	}
+	if sharedAccounts, err := models.SharedAccountsWithRoleByUserID(tx, user.Id); err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to retrieve shared accounts.")
+	} else {
+		for _, sharedAccount := range sharedAccounts {
+			available[sharedAccount.AwsIdentity] = true
+		}
+	}
+	if billingSourceAccounts, err := models.BillingSourceAccountsByUserID(tx, user.Id); err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to retrieve billing source accounts.")
+	} else {
+		for _, billingSourceAccount := range billingSourceAccounts {
+			available[billingSourceAccount.AccountID] = true
+		}
+	}
+	for _, account := range accounts {
+		if !available[account] {
+			return http.StatusBadRequest, fmt.Errorf("Unable to access account %s", account)
+		}
+	}
+	return http.StatusOK, nil
+}
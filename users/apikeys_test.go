@@ -0,0 +1,79 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequiredScope(t *testing.T) {
+	cases := []struct {
+		method string
+		path   string
+		scope  string
+		ok     bool
+	}{
+		{"GET", "/costs", ScopeReadCosts, true},
+		{"GET", "/costs/anomalies", ScopeReadCosts, true},
+		{"POST", "/costs/anomalies/snooze", "", false},
+		{"GET", "/ec2/unused", ScopeReadCosts, true},
+		{"GET", "/reports", ScopeReadReports, true},
+		{"GET", "/report", ScopeReadReports, true},
+		{"POST", "/aws", ScopeManageAccounts, true},
+		{"DELETE", "/billing/sources", ScopeManageAccounts, true},
+		{"GET", "/user", "", false},
+		{"POST", "/user/apikeys", "", false},
+		{"GET", "/costsx", "", false},
+	}
+	for _, c := range cases {
+		scope, ok := requiredScope(httptest.NewRequest(c.method, c.path, nil))
+		if scope != c.scope || ok != c.ok {
+			t.Errorf("%s %s: expected (%s, %t), got (%s, %t)", c.method, c.path, c.scope, c.ok, scope, ok)
+		}
+	}
+}
+
+func TestGenerateApiKey(t *testing.T) {
+	key, hash, err := generateApiKey()
+	if err != nil {
+		t.Fatalf("Failed to generate API key: %s", err)
+	}
+	if !isApiKey(key) {
+		t.Errorf("API key %s should start with %s", key, apiKeyPrefix)
+	}
+	if hash != hashSecret(key) || len(hash) != 64 {
+		t.Errorf("Unexpected hash %s", hash)
+	}
+	if other, _, _ := generateApiKey(); other == key {
+		t.Error("Two API keys should not be equal")
+	}
+}
+
+func TestCanAccessAccount(t *testing.T) {
+	if !(User{}).CanAccessAccount("123456789012") {
+		t.Error("Unrestricted users should access every account")
+	}
+	restricted := User{Accounts: []string{"123456789012"}}
+	if !restricted.CanAccessAccount("123456789012") {
+		t.Error("Restricted users should access their accounts")
+	}
+	if restricted.CanAccessAccount("210987654321") {
+		t.Error("Restricted users should not access other accounts")
+	}
+	if (User{Accounts: []string{}}).CanAccessAccount("123456789012") {
+		t.Error("Users restricted to no account should not access any account")
+	}
+}
@@ -15,13 +15,18 @@
 package users
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/trackit/trackit/config"
+	"github.com/trackit/trackit/models"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -37,6 +42,7 @@ var (
 	ErrMissingToken            = errors.New("missing or duplicate token")
 	ErrFailedToValidateToken   = errors.New("failed to validate token")
 	ErrMarketplaceInvalidToken = errors.New("failed to validate marketplace token")
+	ErrRevokedSession          = errors.New("session was revoked or expired")
 )
 
 // getPasswordHash generates a hash string for a given password.
@@ -50,23 +56,43 @@ func passwordMatchesHash(password, hash string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
+// generateSecret generates a random secret, such as a refresh token or an
+// API key.
+func generateSecret() (string, error) {
+	var random [32]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(random[:]), nil
+}
+
+// hashSecret hashes a secret generated by generateSecret to store it or look
+// it up. Such secrets are long enough random strings for a plain SHA-256 to
+// be safe.
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
 // jwtClaims represents the JWT claims used by this software, as a structure.
 type jwtClaims struct {
 	Issuer    string `json:"iss"`
 	NotBefore int64  `json:"nbf"`
 	Expires   int64  `json:"exp"`
 	Subject   int    `json:"sub"`
+	Session   int    `json:"sid"`
 	User      User   `json:"usr"`
 	jwt.StandardClaims
 }
 
-// generateToken generates a valid JWT token for a given user.
-func generateToken(user User) (string, error) {
+// generateToken generates a valid JWT token for a given user and session.
+func generateToken(user User, sessionId int) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaims{
 		Issuer:    jwtIssuer,
 		NotBefore: time.Now().Add(-1 * time.Hour).Unix(),
-		Expires:   time.Now().Add(60 * 24 * time.Hour).Unix(),
+		Expires:   time.Now().Add(config.AuthTokenDuration).Unix(),
 		Subject:   user.Id,
+		Session:   sessionId,
 		User:      user,
 	})
 	return token.SignedString([]byte(jwtSecret))
@@ -89,6 +115,21 @@ func areClaimsValid(claims jwtClaims) bool {
 	return claims.Issuer == jwtIssuer && claims.NotBefore <= now && now < claims.Expires
 }
 
+// getActiveSession returns the session of a JWT token if it still exists, so
+// that revoking a session invalidates its tokens at once. It returns nil if
+// the session is not active.
+func getActiveSession(tx *sql.Tx, claims jwtClaims) (*models.UserSession, error) {
+	dbSession, err := models.UserSessionByID(tx, claims.Session)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	} else if dbSession.UserID != claims.Subject || !time.Now().Before(dbSession.Expires) {
+		return nil, nil
+	}
+	return dbSession, nil
+}
+
 // testToken checks whether a JWT token is valid and retrieves the owning User
 // if it is.
 func testToken(tx *sql.Tx, tokenString string) (User, error) {
@@ -96,11 +137,16 @@ func testToken(tx *sql.Tx, tokenString string) (User, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &jwtClaims{}, getTokenSigningKey)
 	if err == nil {
 		if claims, ok := token.Claims.(*jwtClaims); ok && token.Valid {
-			if areClaimsValid(*claims) {
-				userId := claims.Subject
-				user, err = GetUserWithId(tx, userId)
-			} else {
+			if !areClaimsValid(*claims) {
 				err = ErrInvalidClaims
+			} else if dbSession, sErr := getActiveSession(tx, *claims); sErr != nil {
+				err = sErr
+			} else if dbSession == nil {
+				err = ErrRevokedSession
+			} else {
+				user, err = GetUserWithId(tx, claims.Subject)
+				user.SessionId = dbSession.ID
+				user.MfaVerified = dbSession.MFA
 			}
 		} else {
 			err = ErrCannotReadToken
@@ -0,0 +1,223 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/trackit/trackit/models"
+)
+
+var ErrPermissionDenied = errors.New("You do not have permission to perform this action on any of your accounts.")
+
+// routePermission is the permission needed on an account for the data of the
+// account to be available to a route prefix. Read is the permission for GET
+// requests and Write the one for the others. An empty permission needs no
+// check.
+type routePermission struct {
+	prefix string
+	read   Permission
+	write  Permission
+}
+
+// routePermissions are the permissions the routes need. Routes match a
+// request if its path is their prefix or a subpath of it. Other routes need
+// no permission.
+var routePermissions = []routePermission{
+	{"/aws/billrepository", "", PermissionManageBillRepositories},
+	{"/billing/sources", "", PermissionManageBillRepositories},
+	{"/budgets", PermissionViewCosts, PermissionManageCosts},
+	{"/costs/anomalies/detectors", PermissionViewCosts, PermissionManageCosts},
+	{"/costs/anomalies/filters", PermissionViewCosts, PermissionManageCosts},
+	{"/costs/anomalies/snooze", PermissionViewCosts, PermissionManageCosts},
+	{"/costs/anomalies/unsnooze", PermissionViewCosts, PermissionManageCosts},
+	{"/costs/categories", PermissionViewCosts, PermissionManageCosts},
+	{"/costs/redistributions", PermissionViewCosts, PermissionManageCosts},
+	{"/costs", PermissionViewCosts, PermissionViewCosts},
+	{"/ri", PermissionViewCosts, PermissionViewCosts},
+	{"/s3/costs", PermissionViewCosts, PermissionViewCosts},
+	{"/savingsplans", PermissionViewCosts, PermissionViewCosts},
+	{"/ebs", PermissionViewResources, PermissionViewResources},
+	{"/ec2", PermissionViewResources, PermissionViewResources},
+	{"/elasticache", PermissionViewResources, PermissionViewResources},
+	{"/es", PermissionViewResources, PermissionViewResources},
+	{"/instanceCount", PermissionViewResources, PermissionViewResources},
+	{"/lambda", PermissionViewResources, PermissionViewResources},
+	{"/rds", PermissionViewResources, PermissionViewResources},
+	{"/tagging/compliance", PermissionViewResources, PermissionViewResources},
+	{"/tagging/mostusedtags", PermissionViewResources, PermissionViewResources},
+	{"/tagging/policies", PermissionViewResources, PermissionViewResources},
+	{"/tagging/remediations/approve", "", PermissionApproveTags},
+	{"/tagging/remediations/reject", "", PermissionApproveTags},
+	{"/tagging/remediations/rollback", "", PermissionApproveTags},
+	{"/tagging/remediations", PermissionViewResources, PermissionEditTags},
+	{"/tagging/resources", PermissionViewResources, PermissionViewResources},
+	{"/tagging/suggestions", PermissionViewResources, PermissionViewResources},
+	{"/tagging/violations", PermissionViewResources, PermissionViewResources},
+	{"/plugins", PermissionViewResources, PermissionManagePlugins},
+	{"/report", PermissionDownloadReports, PermissionDownloadReports},
+	{"/reports", PermissionDownloadReports, PermissionDownloadReports},
+	{"/user/share", PermissionManageSharing, PermissionManageSharing},
+}
+
+// requiredPermission returns the permission a request needs on an account
+// for the data of the account to be available. It returns false if it needs
+// none.
+func requiredPermission(r *http.Request) (Permission, bool) {
+	for _, rp := range routePermissions {
+		if r.URL.Path != rp.prefix && !strings.HasPrefix(r.URL.Path, rp.prefix+"/") {
+			continue
+		} else if r.Method == http.MethodGet {
+			return rp.read, rp.read != ""
+		} else {
+			return rp.write, rp.write != ""
+		}
+	}
+	return "", false
+}
+
+// viewerRole returns the role a user has on the accounts of their parent if
+// they are a viewer acting as their parent.
+func viewerRole(tx *sql.Tx, user User) (Role, bool, error) {
+	if user.ViewerId == 0 {
+		return Role{}, false, nil
+	}
+	role, err := GetRoleWithId(tx, ViewerRole)
+	return role, true, err
+}
+
+// roleOnAccount returns the role a user has on an AWS account they own or
+// which is shared with them, by its database ID. It returns false if they
+// have no access to it.
+func roleOnAccount(tx *sql.Tx, user User, dbAwsAccount *models.AwsAccount) (Role, bool, error) {
+	role, access := Role{}, false
+	if dbAwsAccount.UserID == user.Id {
+		role, access = ownerRole, true
+	} else if dbSharedAccounts, err := models.SharedAccountsByAccountID(tx, dbAwsAccount.ID); err != nil {
+		return role, false, err
+	} else {
+		for _, dbSharedAccount := range dbSharedAccounts {
+			if dbSharedAccount.UserID != user.Id {
+				continue
+			} else if role, err = GetRoleWithId(tx, dbSharedAccount.AccessRoleID); err != nil {
+				return role, false, err
+			}
+			access = true
+		}
+	}
+	if !access {
+		return role, false, nil
+	} else if vRole, isViewer, err := viewerRole(tx, user); err != nil {
+		return role, false, err
+	} else if isViewer {
+		role = role.intersection(vRole)
+	}
+	return role, true, nil
+}
+
+// GetRoleOnAccount returns the role a user has on an AWS account, by its
+// database ID. Owners have every permission on their accounts and viewers
+// have at most the permissions of ViewerRole on the accounts of their
+// parent. It returns false if the user has no access to the account.
+func GetRoleOnAccount(tx *sql.Tx, user User, awsAccountId int) (Role, bool, error) {
+	dbAwsAccount, err := models.AwsAccountByID(tx, awsAccountId)
+	if err == sql.ErrNoRows {
+		return Role{}, false, nil
+	} else if err != nil {
+		return Role{}, false, err
+	} else if !user.CanAccessAccount(dbAwsAccount.AwsIdentity) {
+		return Role{}, false, nil
+	}
+	return roleOnAccount(tx, user, dbAwsAccount)
+}
+
+// HasAccountPermission checks whether a user has a permission on an AWS
+// account, by its database ID.
+func HasAccountPermission(tx *sql.Tx, user User, awsAccountId int, permission Permission) (bool, error) {
+	role, access, err := GetRoleOnAccount(tx, user, awsAccountId)
+	return access && role.HasPermission(permission), err
+}
+
+// accountRoles returns the role a user has on each of the accounts they can
+// access, by account ID. Those are AWS accounts and the accounts of the
+// billing sources of the user.
+func accountRoles(tx *sql.Tx, user User) (map[string]Role, error) {
+	roles := make(map[string]Role)
+	if dbAwsAccounts, err := models.AwsAccountsByUserID(tx, user.Id); err != nil {
+		return nil, err
+	} else {
+		for _, dbAwsAccount := range dbAwsAccounts {
+			roles[dbAwsAccount.AwsIdentity] = ownerRole
+		}
+	}
+	if dbBillingSourceAccounts, err := models.BillingSourceAccountsByUserID(tx, user.Id); err != nil {
+		return nil, err
+	} else {
+		for _, dbBillingSourceAccount := range dbBillingSourceAccounts {
+			roles[dbBillingSourceAccount.AccountID] = ownerRole
+		}
+	}
+	if dbSharedAccounts, err := models.SharedAccountsWithRoleByUserID(tx, user.Id); err != nil {
+		return nil, err
+	} else {
+		for _, dbSharedAccount := range dbSharedAccounts {
+			role, err := GetRoleWithId(tx, dbSharedAccount.AccessRoleID)
+			if err != nil {
+				return nil, err
+			} else if previous, ok := roles[dbSharedAccount.AwsIdentity]; ok {
+				role = previous.union(role)
+			}
+			roles[dbSharedAccount.AwsIdentity] = role
+		}
+	}
+	if vRole, isViewer, err := viewerRole(tx, user); err != nil {
+		return nil, err
+	} else if isViewer {
+		for account, role := range roles {
+			roles[account] = role.intersection(vRole)
+		}
+	}
+	return roles, nil
+}
+
+// authorize is the authorization check of every route. It restricts the
+// accounts of a user to the ones they have the permission the request needs
+// on. It fails if they have accounts but none with this permission.
+func authorize(tx *sql.Tx, user *User, r *http.Request) error {
+	permission, ok := requiredPermission(r)
+	if !ok {
+		return nil
+	}
+	roles, err := accountRoles(tx, *user)
+	if err != nil {
+		return err
+	}
+	accounts := []string{}
+	for account, role := range roles {
+		if role.HasPermission(permission) && user.CanAccessAccount(account) {
+			accounts = append(accounts, account)
+		}
+	}
+	if len(accounts) == 0 && len(roles) > 0 {
+		return ErrPermissionDenied
+	}
+	sort.Strings(accounts)
+	user.Accounts = accounts
+	return nil
+}
@@ -56,9 +56,14 @@ func (d RequireAuthenticatedUser) getFunc(hf routes.HandlerFunc) routes.HandlerF
 		tx := a[db.Transaction].(*sql.Tx)
 		if auth != nil && len(auth) == 1 {
 			tokenString := auth[0]
-			if user, err := testToken(tx, tokenString); err == nil {
+			if user, err := testAuthorization(tx, tokenString, r); err == nil {
 				return d.handleWithAuthenticatedUser(user, tx, hf, w, r, a)
-			} else if err != ErrCannotReadToken && err != ErrInvalidClaims && err != ErrMarketplaceInvalidToken{
+			} else if err == ErrApiKeyScope || err == ErrMfaRequired {
+				return http.StatusForbidden, err
+			} else if err != ErrCannotReadToken && err != ErrInvalidClaims && err != ErrMarketplaceInvalidToken && err != ErrInvalidApiKey && err != ErrRevokedSession {
+				if isApiKey(tokenString) && len(tokenString) > apiKeyDisplayLength {
+					tokenString = tokenString[:apiKeyDisplayLength]
+				}
 				logger.Error("Abnormal authentication failure.", map[string]interface{}{
 					"error": err.Error(),
 					"user":  user.Email,
@@ -74,16 +79,33 @@ func (d RequireAuthenticatedUser) getFunc(hf routes.HandlerFunc) routes.HandlerF
 	}
 }
 
+// testAuthorization authenticates a request with either a JWT or an API key.
+// Users authenticated with a JWT are subject to the MFA policy. API keys
+// cannot prove a second factor, so testApiKey only accepts the ones created
+// from a session verified with one while the policy requires MFA.
+func testAuthorization(tx *sql.Tx, tokenString string, r *http.Request) (User, error) {
+	if isApiKey(tokenString) {
+		return testApiKey(tx, tokenString, r)
+	}
+	user, err := testToken(tx, tokenString)
+	if err == nil {
+		err = checkMfaPolicy(tx, user, r)
+	}
+	return user, err
+}
+
 func (d RequireAuthenticatedUser) handleWithAuthenticatedUser(user User, tx *sql.Tx, hf routes.HandlerFunc, w http.ResponseWriter, r *http.Request, a routes.Arguments) (int, interface{}) {
 	switch d.ViewerHandling {
 	case ViewerAsParent:
 		if user.ParentId != nil {
-			var err error
-			user, err = GetUserParent(r.Context(), tx, user)
+			parent, err := GetUserParent(r.Context(), tx, user)
 			if err != nil {
 				jsonlog.LoggerFromContextOrDefault(r.Context()).Error("Failed to get viewer user parent.", err.Error())
 				return http.StatusInternalServerError, errors.New("Failed to get viewer user parent.")
 			}
+			parent.Accounts = user.Accounts
+			parent.ViewerId = user.Id
+			user = parent
 		}
 	case ViewerCannot:
 		if user.ParentId != nil {
@@ -91,6 +113,12 @@ func (d RequireAuthenticatedUser) handleWithAuthenticatedUser(user User, tx *sql
 		}
 	default:
 	}
+	if err := authorize(tx, &user, r); err == ErrPermissionDenied {
+		return http.StatusForbidden, err
+	} else if err != nil {
+		jsonlog.LoggerFromContextOrDefault(r.Context()).Error("Failed to authorize user.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to authorize user.")
+	}
 	a[AuthenticatedUser] = user
 	return hf(w, r, a)
 }
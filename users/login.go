@@ -32,10 +32,31 @@ type loginRequestBody struct {
 	Password string `json:"password" req:"nonzero"`
 }
 
-// loginResponseBody is the response body in case LogIn succeeds.
+// loginResponseBody is the response body in case LogIn succeeds. The token
+// is short-lived and the refresh token is used to get a new one.
+// MfaEnrollmentRequired is set when the policy requires MFA and the user
+// must enroll before they can use the other routes.
 type loginResponseBody struct {
-	User  User   `json:"user"`
-	Token string `json:"token"`
+	User                  User   `json:"user"`
+	Token                 string `json:"token"`
+	RefreshToken          string `json:"refreshToken"`
+	MfaEnrollmentRequired bool   `json:"mfaEnrollmentRequired,omitempty"`
+}
+
+// mfaChallengeResponseBody is the response body in case the password is
+// correct but the user enabled MFA. The MFA token must be sent with a code
+// to the LogInWithMfa route handler to get a session.
+type mfaChallengeResponseBody struct {
+	MfaRequired bool   `json:"mfaRequired"`
+	MfaToken    string `json:"mfaToken"`
+}
+
+// loginMfaRequestBody is the expected request body for the LogInWithMfa
+// route handler. Either a TOTP code or a recovery code must be sent.
+type loginMfaRequestBody struct {
+	MfaToken     string `json:"mfaToken"     req:"nonzero"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
 }
 
 func init() {
@@ -46,10 +67,22 @@ func init() {
 			db.RequestTransaction{db.Db},
 			routes.Documentation{
 				Summary:     "log in as a user",
-				Description: "Logs a user in based on an e-mail/password couple and returns a JWT token and the user's data.",
+				Description: "Logs a user in based on an e-mail/password couple and returns a JWT token, a refresh token and the user's data. If the user enabled MFA, it returns an MFA token to send with a code to /user/login/mfa instead.",
 			},
 		),
 	}.H().Register("/user/login")
+
+	routes.MethodMuxer{
+		http.MethodPost: routes.H(logInWithMfa).With(
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{loginMfaRequestBody{"mfatoken", "123456", ""}},
+			db.RequestTransaction{db.Db},
+			routes.Documentation{
+				Summary:     "log in with a second factor",
+				Description: "Completes the login of a user who enabled MFA with the MFA token returned by /user/login and either a TOTP code or a recovery code, and returns a JWT token, a refresh token and the user's data. Recovery codes can only be used once.",
+			},
+		),
+	}.H().Register("/user/login/mfa")
 }
 
 // LogIn handles users attempting to log in. It shall return a valid token the
@@ -67,7 +100,7 @@ func logInWithValidBody(request *http.Request, body loginRequestBody, tx *sql.Tx
 	logger := jsonlog.LoggerFromContextOrDefault(request.Context())
 	user, err := GetUserWithEmailAndPassword(request.Context(), tx, body.Email, body.Password)
 	if err == nil {
-		return logAuthenticatedUserIn(request, user)
+		return logInOrChallengeMfa(request, tx, user)
 	} else {
 		logger.Warning("Authentication failure.", struct {
 			Email string `json:"user"`
@@ -76,11 +109,76 @@ func logInWithValidBody(request *http.Request, body loginRequestBody, tx *sql.Tx
 	}
 }
 
-// logAuthenticatedUserIn generates a token for a user that's already been
-// authenticated.
-func logAuthenticatedUserIn(request *http.Request, user User) (int, interface{}) {
+// logInOrChallengeMfa logs a user who entered their password in, or asks
+// them for a code if they enabled MFA.
+func logInOrChallengeMfa(request *http.Request, tx *sql.Tx, user User) (int, interface{}) {
+	logger := jsonlog.LoggerFromContextOrDefault(request.Context())
+	dbMfa, err := getEnabledMfa(tx, user)
+	if err != nil {
+		logger.Error("Failed to get MFA settings.", err.Error())
+		return 500, errors.New("Failed to log in.")
+	} else if dbMfa == nil {
+		return LogAuthenticatedUserIn(request, tx, user, false)
+	}
+	mfaToken, err := generateMfaToken(user)
+	if err != nil {
+		logger.Error("Failed to generate MFA token.", err.Error())
+		return 500, errors.New("Failed to generate token.")
+	}
+	return 200, mfaChallengeResponseBody{
+		MfaRequired: true,
+		MfaToken:    mfaToken,
+	}
+}
+
+// logInWithMfa handles users who enabled MFA sending their code after their
+// password was verified. The session is only opened once the code is.
+func logInWithMfa(request *http.Request, a routes.Arguments) (int, interface{}) {
+	var body loginMfaRequestBody
+	routes.MustRequestBody(a, &body)
+	logger := jsonlog.LoggerFromContextOrDefault(request.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	userId, err := testMfaToken(body.MfaToken)
+	if err != nil {
+		return 401, err
+	}
+	user, err := GetUserWithId(tx, userId)
+	if err != nil {
+		return 401, ErrInvalidMfaToken
+	}
+	dbMfa, err := getEnabledMfa(tx, user)
+	if err != nil {
+		logger.Error("Failed to get MFA settings.", err.Error())
+		return 500, errors.New("Failed to log in.")
+	} else if dbMfa == nil {
+		return 401, ErrInvalidMfaToken
+	}
+	if err := verifyMfaCode(tx, dbMfa, body.Code, body.RecoveryCode); err == ErrInvalidMfaCode || err == ErrMfaLocked {
+		logger.Warning("MFA failure.", struct {
+			Email string `json:"user"`
+		}{user.Email})
+		return 403, err
+	} else if err != nil {
+		logger.Error("Failed to verify MFA code.", err.Error())
+		return 500, errors.New("Failed to log in.")
+	}
+	return LogAuthenticatedUserIn(request, tx, user, true)
+}
+
+// LogAuthenticatedUserIn opens a session and generates a token for a user
+// that's already been authenticated. mfaVerified tells whether they proved
+// a second factor, which the MFA policy may require.
+func LogAuthenticatedUserIn(request *http.Request, tx *sql.Tx, user User, mfaVerified bool) (int, interface{}) {
 	logger := jsonlog.LoggerFromContextOrDefault(request.Context())
-	token, err := generateToken(user)
+	mfaEnrollmentRequired := false
+	if !mfaVerified {
+		var err error
+		if mfaEnrollmentRequired, err = isMfaRequired(tx); err != nil {
+			logger.Error("Failed to get authentication policy.", err.Error())
+			return 500, errors.New("Failed to log in.")
+		}
+	}
+	token, refreshToken, err := createSession(request, tx, user, mfaVerified)
 	if err == nil {
 		if err := updateLastSeen(user); err != nil {
 			logger.Error("Could not update last seen for user.", map[string]interface{}{
@@ -90,8 +188,10 @@ func logAuthenticatedUserIn(request *http.Request, user User) (int, interface{})
 		}
 		logger.Info("User logged in.", user)
 		return 200, loginResponseBody{
-			User:  user,
-			Token: token,
+			User:                  user,
+			Token:                 token,
+			RefreshToken:          refreshToken,
+			MfaEnrollmentRequired: mfaEnrollmentRequired,
 		}
 	} else {
 		logger.Error("Failed to generate token.", err.Error())
@@ -0,0 +1,319 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/trackit/trackit/config"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+)
+
+const (
+	// totpPeriod is the duration of a TOTP time step, in seconds.
+	totpPeriod = 30
+	// totpDigits is the number of digits of a TOTP code, and totpModulo is
+	// 10 to the power of it.
+	totpDigits = 6
+	totpModulo = 1000000
+	// totpSkew is the number of time steps accepted before and after the
+	// current one, to allow for clock drift.
+	totpSkew = 1
+	// totpSecretLength is the length of a TOTP secret, in bytes.
+	totpSecretLength = 20
+	// recoveryCodeCount is the number of recovery codes a user is given.
+	recoveryCodeCount = 10
+	// recoveryCodeLength is the length of a recovery code, in bytes.
+	recoveryCodeLength = 10
+	// mfaMaxFailedAttempts is the number of wrong codes after which the
+	// verification of codes is locked.
+	mfaMaxFailedAttempts = 5
+	// mfaLockDuration is the duration the verification of codes is locked
+	// for after too many wrong codes.
+	mfaLockDuration = 15 * time.Minute
+	// mfaTokenDuration is the duration a user has to enter their code after
+	// they entered their password.
+	mfaTokenDuration = 5 * time.Minute
+	// mfaTokenPurpose tells MFA tokens apart from the other JWTs.
+	mfaTokenPurpose = "mfa"
+)
+
+var (
+	ErrMfaRequired       = errors.New("multi-factor authentication is required")
+	ErrInvalidMfaCode    = errors.New("The code is incorrect. Try again.")
+	ErrMfaLocked         = errors.New("Too many incorrect codes. Try again later.")
+	ErrInvalidMfaToken   = errors.New("The login attempt expired. Log in again.")
+	ErrMfaNotEnabled     = errors.New("Multi-factor authentication is not enabled.")
+	ErrMfaAlreadyEnabled = errors.New("Multi-factor authentication is already enabled.")
+)
+
+// mfaEnrollmentPaths are the routes a user without a second factor can
+// access when the policy requires one, so that they can enroll.
+var mfaEnrollmentPaths = []string{
+	"/user",
+	"/user/mfa",
+	"/user/mfa/enable",
+}
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateTotpSecret generates a random TOTP secret, encoded in base32 as
+// authenticator applications expect it.
+func generateTotpSecret() (string, error) {
+	var random [totpSecretLength]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(random[:]), nil
+}
+
+// totpStep returns the TOTP time step of a date.
+func totpStep(date time.Time) int64 {
+	return date.Unix() / totpPeriod
+}
+
+// totpCode computes the TOTP code of a secret for a time step, as described
+// in RFC 6238.
+func totpCode(secret []byte, step int64) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], uint64(step))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%totpModulo)
+}
+
+// matchTotpCode checks a TOTP code against a secret. It returns the time
+// step the code matched, which must come after lastUsedStep so that a code
+// cannot be used twice.
+func matchTotpCode(secret string, code string, date time.Time, lastUsedStep int64) (int64, bool) {
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		return 0, false
+	}
+	code = strings.Replace(code, " ", "", -1)
+	current := totpStep(date)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastUsedStep {
+			continue
+		} else if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpProvisioningUri returns the otpauth URI authenticator applications
+// are provisioned with, usually by scanning it as a QR code.
+func totpProvisioningUri(secret string, email string) string {
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {config.AuthIssuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(totpPeriod)},
+	}
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + config.AuthIssuer + ":" + email,
+		RawQuery: query.Encode(),
+	}
+	return uri.String()
+}
+
+// generateRecoveryCodes generates random recovery codes and returns them
+// with their hashes.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		var random [recoveryCodeLength]byte
+		if _, err := rand.Read(random[:]); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(random[:]))
+		codes[i] = code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes a recovery code to store it or look it up. The
+// case and separators of the code are ignored.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return hashSecret(code)
+}
+
+// resetRecoveryCodes replaces the recovery codes of a user and returns the
+// new ones.
+func resetRecoveryCodes(tx *sql.Tx, user User) ([]string, error) {
+	if err := models.DeleteUserMFARecoveryCodesByUserID(tx, user.Id); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range hashes {
+		dbCode := models.UserMFARecoveryCode{
+			UserID: user.Id,
+			Hash:   hash,
+		}
+		if err := dbCode.Insert(tx); err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// verifyMfaCode checks a TOTP code, or a recovery code if code is empty,
+// for a user. A recovery code can only be used once. Failed attempts are
+// recorded outside of the transaction, so that they are kept when it is
+// rolled back, and lock the verification once there are too many of them.
+func verifyMfaCode(tx *sql.Tx, dbMfa *models.UserMFA, code string, recoveryCode string) error {
+	now := time.Now().UTC()
+	if dbMfa.LockedUntil.Valid && now.Before(dbMfa.LockedUntil.Time) {
+		return ErrMfaLocked
+	}
+	verified := false
+	if code != "" {
+		var step int64
+		if step, verified = matchTotpCode(dbMfa.Secret, code, now, dbMfa.LastUsedStep); verified {
+			dbMfa.LastUsedStep = step
+		}
+	} else if recoveryCode != "" && dbMfa.Enabled {
+		dbCode, err := models.UserMFARecoveryCodeByUserIDHash(tx, dbMfa.UserID, hashRecoveryCode(recoveryCode))
+		if err == nil {
+			if err := dbCode.Delete(tx); err != nil {
+				return err
+			}
+			verified = true
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+	}
+	if !verified {
+		if err := models.IncrementUserMFAFailedAttempts(db.Db, dbMfa.UserID, mfaMaxFailedAttempts, now.Add(mfaLockDuration)); err != nil {
+			return err
+		}
+		return ErrInvalidMfaCode
+	}
+	dbMfa.FailedAttempts = 0
+	dbMfa.LockedUntil.Valid = false
+	return dbMfa.Update(tx)
+}
+
+// getEnabledMfa returns the MFA settings of a user if they enabled it, or
+// nil if they did not.
+func getEnabledMfa(tx *sql.Tx, user User) (*models.UserMFA, error) {
+	dbMfa, err := models.UserMFAByUserID(tx, user.Id)
+	if err == sql.ErrNoRows || (err == nil && !dbMfa.Enabled) {
+		return nil, nil
+	}
+	return dbMfa, err
+}
+
+// isMfaRequired checks whether the policy requires users to log in with a
+// second factor.
+func isMfaRequired(tx *sql.Tx) (bool, error) {
+	dbPolicy, err := models.AuthPolicyByID(tx, authPolicyId)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return dbPolicy.RequireMFA, nil
+}
+
+// checkMfaPolicy rejects the requests of users whose session was opened
+// without a second factor when the policy requires one, except the ones
+// they need to enroll.
+func checkMfaPolicy(tx *sql.Tx, user User, r *http.Request) error {
+	if user.MfaVerified {
+		return nil
+	}
+	for _, path := range mfaEnrollmentPaths {
+		if r.URL.Path == path {
+			return nil
+		}
+	}
+	if required, err := isMfaRequired(tx); err != nil {
+		return err
+	} else if required {
+		return ErrMfaRequired
+	}
+	return nil
+}
+
+// mfaClaims are the claims of the token a user gets after entering their
+// password, which they exchange with their code for a session. It has no
+// session, so it is never accepted as an authentication token.
+type mfaClaims struct {
+	Issuer    string `json:"iss"`
+	NotBefore int64  `json:"nbf"`
+	Expires   int64  `json:"exp"`
+	Subject   int    `json:"sub"`
+	Purpose   string `json:"pur"`
+	jwt.StandardClaims
+}
+
+// generateMfaToken generates the token a user exchanges with their code for
+// a session.
+func generateMfaToken(user User) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, mfaClaims{
+		Issuer:    jwtIssuer,
+		NotBefore: now.Unix(),
+		Expires:   now.Add(mfaTokenDuration).Unix(),
+		Subject:   user.Id,
+		Purpose:   mfaTokenPurpose,
+	})
+	return token.SignedString(jwtSecret)
+}
+
+// testMfaToken checks whether an MFA token is valid and returns the id of
+// the user it was issued to.
+func testMfaToken(tokenString string) (int, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &mfaClaims{}, getTokenSigningKey)
+	if err != nil {
+		return 0, ErrInvalidMfaToken
+	}
+	claims, ok := token.Claims.(*mfaClaims)
+	now := time.Now().Unix()
+	if !ok || !token.Valid || claims.Purpose != mfaTokenPurpose || claims.Issuer != jwtIssuer || claims.NotBefore > now || now >= claims.Expires {
+		return 0, ErrInvalidMfaToken
+	}
+	return claims.Subject, nil
+}
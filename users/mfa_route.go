@@ -0,0 +1,334 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+)
+
+type (
+	// MfaStatus is the MFA status of a user as returned by the routes.
+	// Required is set when the policy requires MFA.
+	MfaStatus struct {
+		Enabled           bool `json:"enabled"`
+		Required          bool `json:"required"`
+		RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+	}
+
+	// MfaEnrollment is the secret a user adds to their authenticator
+	// application. Uri is the otpauth URI to display as a QR code.
+	MfaEnrollment struct {
+		Secret string `json:"secret"`
+		Uri    string `json:"uri"`
+	}
+
+	// RecoveryCodes are the one-time codes a user can log in with when they
+	// lost their authenticator. They are only returned once.
+	RecoveryCodes struct {
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}
+
+	// mfaCodeRequestBody is the expected request body for the route
+	// handlers which need a TOTP code.
+	mfaCodeRequestBody struct {
+		Code string `json:"code" req:"nonzero"`
+	}
+
+	// mfaVerificationRequestBody is the expected request body for the route
+	// handlers which need either a TOTP code or a recovery code.
+	mfaVerificationRequestBody struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recoveryCode"`
+	}
+)
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getMfa).With(
+			RequireAuthenticatedUser{ViewerAsSelf},
+			routes.Documentation{
+				Summary:     "get the MFA status",
+				Description: "Responds with whether the user enabled MFA, whether the policy requires it and how many recovery codes they have left.",
+			},
+		),
+		http.MethodPost: routes.H(postMfa).With(
+			RequireAuthenticatedUser{ViewerAsSelf},
+			routes.Documentation{
+				Summary:     "start the MFA enrollment",
+				Description: "Generates a TOTP secret and responds with it and its otpauth URI, to display as a QR code. MFA is enabled once a code is sent to /user/mfa/enable.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+		routes.Documentation{
+			Summary: "interact with the MFA settings of the user",
+		},
+	).Register("/user/mfa")
+
+	routes.MethodMuxer{
+		http.MethodPost: routes.H(enableMfa).With(
+			RequireAuthenticatedUser{ViewerAsSelf},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{mfaCodeRequestBody{"123456"}},
+			routes.Documentation{
+				Summary:     "enable MFA",
+				Description: "Enables MFA once a code of the secret generated by /user/mfa is verified, and responds with the recovery codes. They are only returned once. The other sessions of the user are revoked.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+	).Register("/user/mfa/enable")
+
+	routes.MethodMuxer{
+		http.MethodPost: routes.H(disableMfa).With(
+			RequireAuthenticatedUser{ViewerAsSelf},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{mfaVerificationRequestBody{"123456", ""}},
+			routes.Documentation{
+				Summary:     "disable MFA",
+				Description: "Disables MFA once either a TOTP code or a recovery code is verified. MFA cannot be disabled when the policy requires it.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+	).Register("/user/mfa/disable")
+
+	routes.MethodMuxer{
+		http.MethodPost: routes.H(postRecoveryCodes).With(
+			RequireAuthenticatedUser{ViewerAsSelf},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{mfaCodeRequestBody{"123456"}},
+			routes.Documentation{
+				Summary:     "regenerate the recovery codes",
+				Description: "Replaces the recovery codes once a TOTP code is verified, and responds with the new ones. They are only returned once.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+	).Register("/user/mfa/recovery-codes")
+}
+
+// mfaVerificationStatus returns the HTTP status for an error returned by
+// verifyMfaCode.
+func mfaVerificationStatus(err error) int {
+	if err == ErrInvalidMfaCode || err == ErrMfaLocked {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// setSessionMfa records whether the session of a user was verified with a
+// second factor.
+func setSessionMfa(tx *sql.Tx, user User, mfa bool) error {
+	if user.SessionId == 0 {
+		return nil
+	}
+	dbSession, err := models.UserSessionByID(tx, user.SessionId)
+	if err != nil {
+		return err
+	}
+	dbSession.MFA = mfa
+	return dbSession.Update(tx)
+}
+
+// getMfa is a route handler which returns the MFA status of the current
+// user.
+func getMfa(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[AuthenticatedUser].(User)
+	var status MfaStatus
+	var err error
+	if status.Required, err = isMfaRequired(tx); err != nil {
+		l.Error("Failed to get authentication policy.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to retrieve MFA status.")
+	}
+	dbMfa, err := getEnabledMfa(tx, user)
+	if err != nil {
+		l.Error("Failed to get MFA settings.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to retrieve MFA status.")
+	} else if dbMfa == nil {
+		return http.StatusOK, status
+	}
+	status.Enabled = true
+	dbCodes, err := models.UserMFARecoveryCodesByUserID(tx, user.Id)
+	if err != nil {
+		l.Error("Failed to get recovery codes.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to retrieve MFA status.")
+	}
+	status.RecoveryCodesLeft = len(dbCodes)
+	return http.StatusOK, status
+}
+
+// postMfa is a route handler which generates a new TOTP secret for the
+// current user. It does not enable MFA until a code is verified.
+func postMfa(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[AuthenticatedUser].(User)
+	dbMfa, err := models.UserMFAByUserID(tx, user.Id)
+	if err == sql.ErrNoRows {
+		dbMfa = &models.UserMFA{UserID: user.Id}
+	} else if err != nil {
+		l.Error("Failed to get MFA settings.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to start MFA enrollment.")
+	} else if dbMfa.Enabled {
+		return http.StatusConflict, ErrMfaAlreadyEnabled
+	}
+	secret, err := generateTotpSecret()
+	if err != nil {
+		l.Error("Failed to generate TOTP secret.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to start MFA enrollment.")
+	}
+	dbMfa.Secret = secret
+	dbMfa.LastUsedStep = 0
+	dbMfa.Created = time.Now().UTC()
+	if err := dbMfa.Save(tx); err != nil {
+		l.Error("Failed to save MFA settings.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to start MFA enrollment.")
+	}
+	return http.StatusOK, MfaEnrollment{
+		Secret: secret,
+		Uri:    totpProvisioningUri(secret, user.Email),
+	}
+}
+
+// enableMfa is a route handler which enables MFA for the current user once
+// a code of their new secret is verified. The current session counts as
+// verified with a second factor from then on, and the other sessions are
+// revoked since they were not.
+func enableMfa(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body mfaCodeRequestBody
+	routes.MustRequestBody(a, &body)
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[AuthenticatedUser].(User)
+	dbMfa, err := models.UserMFAByUserID(tx, user.Id)
+	if err == sql.ErrNoRows {
+		return http.StatusBadRequest, errors.New("MFA enrollment was not started.")
+	} else if err != nil {
+		l.Error("Failed to get MFA settings.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to enable MFA.")
+	} else if dbMfa.Enabled {
+		return http.StatusConflict, ErrMfaAlreadyEnabled
+	}
+	if err := verifyMfaCode(tx, dbMfa, body.Code, ""); err != nil {
+		if status := mfaVerificationStatus(err); status != http.StatusForbidden {
+			l.Error("Failed to verify MFA code.", err.Error())
+			return status, errors.New("Failed to enable MFA.")
+		} else {
+			return status, err
+		}
+	}
+	dbMfa.Enabled = true
+	if err := dbMfa.Update(tx); err != nil {
+		l.Error("Failed to update MFA settings.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to enable MFA.")
+	}
+	codes, err := resetRecoveryCodes(tx, user)
+	if err != nil {
+		l.Error("Failed to generate recovery codes.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to enable MFA.")
+	}
+	if err := setSessionMfa(tx, user, true); err != nil {
+		l.Error("Failed to update session.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to enable MFA.")
+	} else if err := models.DeleteUserSessionsByUserID(tx, user.Id, user.SessionId); err != nil {
+		l.Error("Failed to revoke other sessions.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to enable MFA.")
+	}
+	l.Info("MFA enabled.", user)
+	return http.StatusOK, RecoveryCodes{codes}
+}
+
+// disableMfa is a route handler which disables MFA for the current user once
+// a TOTP code or a recovery code is verified, unless the policy requires it.
+func disableMfa(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body mfaVerificationRequestBody
+	routes.MustRequestBody(a, &body)
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[AuthenticatedUser].(User)
+	if required, err := isMfaRequired(tx); err != nil {
+		l.Error("Failed to get authentication policy.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to disable MFA.")
+	} else if required {
+		return http.StatusForbidden, errors.New("MFA is required by the policy and cannot be disabled.")
+	}
+	dbMfa, err := getEnabledMfa(tx, user)
+	if err != nil {
+		l.Error("Failed to get MFA settings.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to disable MFA.")
+	} else if dbMfa == nil {
+		return http.StatusBadRequest, ErrMfaNotEnabled
+	}
+	if err := verifyMfaCode(tx, dbMfa, body.Code, body.RecoveryCode); err != nil {
+		if status := mfaVerificationStatus(err); status != http.StatusForbidden {
+			l.Error("Failed to verify MFA code.", err.Error())
+			return status, errors.New("Failed to disable MFA.")
+		} else {
+			return status, err
+		}
+	}
+	if err := models.DeleteUserMFARecoveryCodesByUserID(tx, user.Id); err != nil {
+		l.Error("Failed to delete recovery codes.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to disable MFA.")
+	} else if err := dbMfa.Delete(tx); err != nil {
+		l.Error("Failed to delete MFA settings.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to disable MFA.")
+	}
+	l.Info("MFA disabled.", user)
+	return http.StatusOK, nil
+}
+
+// postRecoveryCodes is a route handler which replaces the recovery codes of
+// the current user once a TOTP code is verified.
+func postRecoveryCodes(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body mfaCodeRequestBody
+	routes.MustRequestBody(a, &body)
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[AuthenticatedUser].(User)
+	dbMfa, err := getEnabledMfa(tx, user)
+	if err != nil {
+		l.Error("Failed to get MFA settings.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to generate recovery codes.")
+	} else if dbMfa == nil {
+		return http.StatusBadRequest, ErrMfaNotEnabled
+	}
+	if err := verifyMfaCode(tx, dbMfa, body.Code, ""); err != nil {
+		if status := mfaVerificationStatus(err); status != http.StatusForbidden {
+			l.Error("Failed to verify MFA code.", err.Error())
+			return status, errors.New("Failed to generate recovery codes.")
+		} else {
+			return status, err
+		}
+	}
+	codes, err := resetRecoveryCodes(tx, user)
+	if err != nil {
+		l.Error("Failed to generate recovery codes.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to generate recovery codes.")
+	}
+	return http.StatusOK, RecoveryCodes{codes}
+}
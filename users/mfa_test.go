@@ -0,0 +1,92 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTotpCode(t *testing.T) {
+	// Test vectors from RFC 6238, truncated to 6 digits.
+	secret := []byte("12345678901234567890")
+	cases := []struct {
+		time int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, c := range cases {
+		if code := totpCode(secret, totpStep(time.Unix(c.time, 0))); code != c.code {
+			t.Errorf("Code at %d should be %s, is %s", c.time, c.code, code)
+		}
+	}
+}
+
+func TestMatchTotpCode(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	date := time.Unix(1111111111, 0)
+	step, ok := matchTotpCode(secret, "050 471", date, 0)
+	if !ok || step != totpStep(date) {
+		t.Errorf("Code should match the current step, got %d, %v", step, ok)
+	}
+	if _, ok := matchTotpCode(secret, "081804", date, 0); !ok {
+		t.Errorf("Code of the previous step should match")
+	}
+	if _, ok := matchTotpCode(secret, "050471", date, step); ok {
+		t.Errorf("Code should not be used twice")
+	}
+	if _, ok := matchTotpCode(secret, "123456", date, 0); ok {
+		t.Errorf("Wrong code should not match")
+	}
+}
+
+func TestTotpProvisioningUri(t *testing.T) {
+	uri, err := url.Parse(totpProvisioningUri("JBSWY3DPEHPK3PXP", "example@example.com"))
+	if err != nil {
+		t.Fatalf("Provisioning URI should parse: %s", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || !strings.HasSuffix(uri.Path, ":example@example.com") {
+		t.Errorf("Unexpected provisioning URI %s", uri)
+	}
+	if secret := uri.Query().Get("secret"); secret != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Unexpected secret %s", secret)
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("Failed to generate recovery codes: %s", err)
+	} else if len(codes) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("Expected %d recovery codes, got %d", recoveryCodeCount, len(codes))
+	}
+	for i, code := range codes {
+		if len(code) != 19 {
+			t.Errorf("Unexpected recovery code %s", code)
+		}
+		compact := strings.ToUpper(strings.Replace(code, "-", "", -1))
+		if hashRecoveryCode(compact) != hashes[i] {
+			t.Errorf("Recovery code %s should match regardless of case and separators", code)
+		}
+	}
+}
@@ -66,7 +66,7 @@ func init() {
 			db.RequestTransaction{db.Db},
 			routes.Documentation{
 				Summary:     "reset a forgotten password",
-				Description: "Allows a user to reset a forgotten password using a temporary token",
+				Description: "Allows a user to reset a forgotten password using a temporary token. Every session and API key of the user is revoked and their SSO identities are unlinked",
 			},
 		),
 	}.H().Register("/user/password/reset")
@@ -184,6 +184,25 @@ func updatePasswordFromForgottenToken(request *http.Request, body resetPasswordR
 		logger.Warning("Unable to update user password", err.Error())
 		return 500, errors.New("Unable to update user")
 	}
+	err = models.DeleteUserSessionsByUserID(tx, user.Id, 0)
+	if err != nil {
+		logger.Error("Unable to revoke user sessions", err.Error())
+		return 500, errors.New("Unable to revoke sessions")
+	}
+	// Whoever reset the password may not be the one who created the API
+	// keys, so they are revoked along with the sessions.
+	err = models.DeleteAPIKeysByUserID(tx, user.Id)
+	if err != nil {
+		logger.Error("Unable to revoke user API keys", err.Error())
+		return 500, errors.New("Unable to revoke API keys")
+	}
+	// The identity providers linked to the user are unlinked, as their
+	// administrators may not be the owner of the email address.
+	err = models.DeleteSSOIdentitiesByUserID(tx, user.Id)
+	if err != nil {
+		logger.Error("Unable to unlink user SSO identities", err.Error())
+		return 500, errors.New("Unable to unlink SSO identities")
+	}
 	err = forgottenPassword.Delete(tx)
 	if err != nil {
 		logger.Warning("Unable to delete forgotten password token", err.Error())
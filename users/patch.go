@@ -41,7 +41,12 @@ func patchUserWithValidBody(request *http.Request, user User, body createUserReq
 		l.Error("Failed to find bill repository to update.", err.Error())
 		return http.StatusInternalServerError, errors.New("failed to find user in database")
 	}
+	// Changing the password revokes the other sessions of the user
+	sessionId := user.SessionId
 	user, err = UpdateUserWithPassword(ctx, tx, dbUser, body.Email, body.Password)
+	if err == nil {
+		err = models.DeleteUserSessionsByUserID(tx, user.Id, sessionId)
+	}
 	if err == nil {
 		l.Info("User updated.", user)
 		return http.StatusOK, user
@@ -0,0 +1,96 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+)
+
+// authPolicyId is the id of the only row of the auth_policy table.
+const authPolicyId = 1
+
+// AuthPolicy is the authentication policy of the platform. RequireMfa
+// forces users to log in with a second factor, or through single sign-on.
+type AuthPolicy struct {
+	RequireMfa bool `json:"requireMfa"`
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getAuthPolicy).With(
+			routes.Documentation{
+				Summary:     "get the authentication policy",
+				Description: "Responds with the authentication policy of the platform.",
+			},
+		),
+		http.MethodPut: routes.H(putAuthPolicy).With(
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{AuthPolicy{true}},
+			routes.Documentation{
+				Summary:     "update the authentication policy",
+				Description: "Updates the authentication policy of the platform. When MFA is required, the users who did not log in with a second factor can only use the routes to enroll, and the API keys which were not created from a session verified with a second factor are rejected.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{Db: db.Db},
+		RequireAuthenticatedUser{ViewerCannot},
+		RequireAdminUser{},
+	).Register("/admin/auth-policy")
+}
+
+// getAuthPolicy is a route handler which returns the authentication policy.
+func getAuthPolicy(r *http.Request, a routes.Arguments) (int, interface{}) {
+	tx := a[db.Transaction].(*sql.Tx)
+	required, err := isMfaRequired(tx)
+	if err != nil {
+		jsonlog.LoggerFromContextOrDefault(r.Context()).Error("Failed to get authentication policy.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to retrieve authentication policy.")
+	}
+	return http.StatusOK, AuthPolicy{required}
+}
+
+// putAuthPolicy is a route handler which updates the authentication policy.
+func putAuthPolicy(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body AuthPolicy
+	routes.MustRequestBody(a, &body)
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[AuthenticatedUser].(User)
+	dbPolicy, err := models.AuthPolicyByID(tx, authPolicyId)
+	if err == sql.ErrNoRows {
+		dbPolicy = &models.AuthPolicy{}
+	} else if err != nil {
+		l.Error("Failed to get authentication policy.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to update authentication policy.")
+	}
+	dbPolicy.RequireMFA = body.RequireMfa
+	if err := dbPolicy.Save(tx); err != nil {
+		l.Error("Failed to save authentication policy.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to update authentication policy.")
+	}
+	l.Info("Authentication policy updated.", map[string]interface{}{
+		"user":   user.Email,
+		"policy": body,
+	})
+	return http.StatusOK, body
+}
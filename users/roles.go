@@ -0,0 +1,206 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/trackit/trackit/models"
+)
+
+// Permission is something a role allows on an AWS account.
+type Permission string
+
+const (
+	// PermissionViewCosts allows to view the costs, budgets and anomalies.
+	PermissionViewCosts = Permission("costs:view")
+	// PermissionManageCosts allows to manage the budgets, cost categories,
+	// cost redistributions and anomalies settings.
+	PermissionManageCosts = Permission("costs:manage")
+	// PermissionViewResources allows to view the resources and their usage.
+	PermissionViewResources = Permission("resources:view")
+	// PermissionManageBillRepositories allows to manage the bill
+	// repositories and billing sources.
+	PermissionManageBillRepositories = Permission("billrepositories:manage")
+	// PermissionManagePlugins allows to manage the plugins.
+	PermissionManagePlugins = Permission("plugins:manage")
+	// PermissionManageSharing allows to share the account with other users.
+	PermissionManageSharing = Permission("sharing:manage")
+	// PermissionDownloadReports allows to list and download the reports.
+	PermissionDownloadReports = Permission("reports:download")
+	// PermissionEditTags allows to propose changes to the tags of the
+	// resources.
+	PermissionEditTags = Permission("tags:edit")
+	// PermissionApproveTags allows to approve, reject and roll back the
+	// proposed changes to the tags of the resources.
+	PermissionApproveTags = Permission("tags:approve")
+)
+
+// Permissions are the permissions a role can be given.
+var Permissions = []Permission{
+	PermissionViewCosts,
+	PermissionManageCosts,
+	PermissionViewResources,
+	PermissionManageBillRepositories,
+	PermissionManagePlugins,
+	PermissionManageSharing,
+	PermissionDownloadReports,
+	PermissionEditTags,
+	PermissionApproveTags,
+}
+
+// Ids of the built-in roles, which the migrations create.
+const (
+	RoleAdministrator = 1
+	RoleStandard      = 2
+	RoleReadOnly      = 3
+)
+
+// ViewerRole is the role viewer users have on the accounts of their parent.
+const ViewerRole = RoleReadOnly
+
+var (
+	ErrRoleNotFound      = errors.New("Role not found.")
+	ErrFailedToLoadRole  = errors.New("Failed to load role.")
+	ErrInvalidPermission = errors.New("Invalid permission.")
+)
+
+// Role is a named set of permissions a user is given on an AWS account.
+// Built-in roles are available to everyone, other roles are only available
+// on the accounts of the user who created them.
+type Role struct {
+	Id          int          `json:"id"`
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions"`
+	BuiltIn     bool         `json:"builtIn"`
+	userId      int
+}
+
+// ownerRole is the role of users on their own accounts.
+var ownerRole = Role{
+	Name:        "Owner",
+	Permissions: Permissions,
+}
+
+// roleFromDbRole builds a Role from its database representation.
+func roleFromDbRole(dbRole *models.AccessRole) (Role, error) {
+	role := Role{
+		Id:          dbRole.ID,
+		Name:        dbRole.Name,
+		Permissions: []Permission{},
+		BuiltIn:     !dbRole.UserID.Valid,
+		userId:      int(dbRole.UserID.Int64),
+	}
+	if err := json.Unmarshal(dbRole.Permissions, &role.Permissions); err != nil {
+		return role, ErrFailedToLoadRole
+	}
+	return role, nil
+}
+
+// GetRoleWithId retrieves a role from its id.
+func GetRoleWithId(tx *sql.Tx, id int) (Role, error) {
+	dbRole, err := models.AccessRoleByID(tx, id)
+	if err == sql.ErrNoRows {
+		return Role{}, ErrRoleNotFound
+	} else if err != nil {
+		return Role{}, err
+	}
+	return roleFromDbRole(dbRole)
+}
+
+// GetRolesForUser retrieves the built-in roles and the roles created by a
+// user.
+func GetRolesForUser(tx *sql.Tx, user User) ([]Role, error) {
+	dbRoles, err := models.AccessRolesForUserID(tx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]Role, len(dbRoles))
+	for i, dbRole := range dbRoles {
+		if roles[i], err = roleFromDbRole(dbRole); err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+// AvailableOn checks whether a role can be given on the accounts of a user.
+func (r Role) AvailableOn(ownerId int) bool {
+	return r.BuiltIn || r.userId == ownerId
+}
+
+// HasPermission checks whether a role allows a permission.
+func (r Role) HasPermission(permission Permission) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Includes checks whether a role allows all the permissions of another one.
+// Users can only give roles their own role includes.
+func (r Role) Includes(other Role) bool {
+	for _, p := range other.Permissions {
+		if !r.HasPermission(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// union returns a role with the permissions of both roles.
+func (r Role) union(other Role) Role {
+	res := Role{Name: r.Name, Permissions: append([]Permission{}, r.Permissions...)}
+	for _, p := range other.Permissions {
+		if !res.HasPermission(p) {
+			res.Permissions = append(res.Permissions, p)
+		}
+	}
+	return res
+}
+
+// intersection returns a role with the permissions both roles allow.
+func (r Role) intersection(other Role) Role {
+	res := Role{Name: r.Name, Permissions: []Permission{}}
+	for _, p := range r.Permissions {
+		if other.HasPermission(p) {
+			res.Permissions = append(res.Permissions, p)
+		}
+	}
+	return res
+}
+
+// validPermissions checks that permissions only holds known permissions and
+// returns them sorted without duplicates.
+func validPermissions(permissions []Permission) ([]Permission, error) {
+	known := Role{Permissions: Permissions}
+	set := make(map[Permission]bool, len(permissions))
+	res := make([]Permission, 0, len(permissions))
+	for _, p := range permissions {
+		if !known.HasPermission(p) {
+			return nil, ErrInvalidPermission
+		} else if !set[p] {
+			set[p] = true
+			res = append(res, p)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res, nil
+}
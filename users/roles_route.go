@@ -0,0 +1,179 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+)
+
+// RoleBody is the body required to create or update a role.
+type RoleBody struct {
+	Name        string       `json:"name"        req:"nonzero"`
+	Permissions []Permission `json:"permissions"`
+}
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getRoles).With(
+			RequireAuthenticatedUser{ViewerCannot},
+			routes.Documentation{
+				Summary:     "get the roles",
+				Description: "Responds with the built-in roles and the roles created by the user.",
+			},
+		),
+		http.MethodPost: routes.H(postRole).With(
+			RequireAuthenticatedUser{ViewerCannot},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{RoleBody{"Finance", []Permission{PermissionViewCosts, PermissionDownloadReports}}},
+			routes.Documentation{
+				Summary:     "create a role",
+				Description: "Creates a role based on the body and responds with it. The role can then be given to the users the accounts of the user are shared with.",
+			},
+		),
+		http.MethodPatch: routes.H(patchRole).With(
+			RequireAuthenticatedUser{ViewerCannot},
+			routes.QueryArgs{routes.RoleIdQueryArg},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{RoleBody{"Finance", []Permission{PermissionViewCosts, PermissionDownloadReports}}},
+			routes.Documentation{
+				Summary:     "update a role",
+				Description: "Updates a role created by the user. The users who were given it get the new permissions at once.",
+			},
+		),
+		http.MethodDelete: routes.H(deleteRole).With(
+			RequireAuthenticatedUser{ViewerCannot},
+			routes.QueryArgs{routes.RoleIdQueryArg},
+			routes.Documentation{
+				Summary:     "delete a role",
+				Description: "Deletes a role created by the user. Roles which are still given to users cannot be deleted.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{Db: db.Db},
+		routes.Documentation{
+			Summary:     "interact with the roles",
+			Description: "Roles are named sets of permissions given to the users an AWS account is shared with.",
+		},
+	).Register("/user/roles")
+}
+
+// getRoles is a route handler which returns the roles available to the
+// user.
+func getRoles(r *http.Request, a routes.Arguments) (int, interface{}) {
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[AuthenticatedUser].(User)
+	roles, err := GetRolesForUser(tx, user)
+	if err != nil {
+		jsonlog.LoggerFromContextOrDefault(r.Context()).Error("Failed to get roles.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to retrieve roles.")
+	}
+	return http.StatusOK, roles
+}
+
+// postRole is a route handler which creates a role.
+func postRole(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body RoleBody
+	routes.MustRequestBody(a, &body)
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[AuthenticatedUser].(User)
+	permissions, err := validPermissions(body.Permissions)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	dbRole := models.AccessRole{
+		UserID: sql.NullInt64{Int64: int64(user.Id), Valid: true},
+		Name:   body.Name,
+	}
+	dbRole.Permissions, _ = json.Marshal(permissions)
+	if err := dbRole.Insert(tx); err != nil {
+		l.Error("Failed to insert role.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to create role.")
+	}
+	role, _ := roleFromDbRole(&dbRole)
+	return http.StatusOK, role
+}
+
+// getOwnRole retrieves a role the user created, from the role ID in the
+// query args.
+func getOwnRole(r *http.Request, a routes.Arguments) (*models.AccessRole, int, error) {
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[AuthenticatedUser].(User)
+	dbRole, err := models.AccessRoleByID(tx, a[routes.RoleIdQueryArg].(int))
+	if err == sql.ErrNoRows || (err == nil && int(dbRole.UserID.Int64) != user.Id) {
+		return nil, http.StatusNotFound, ErrRoleNotFound
+	} else if err != nil {
+		jsonlog.LoggerFromContextOrDefault(r.Context()).Error("Failed to get role.", err.Error())
+		return nil, http.StatusInternalServerError, errors.New("Failed to retrieve role.")
+	}
+	return dbRole, http.StatusOK, nil
+}
+
+// patchRole is a route handler which updates a role the user created.
+func patchRole(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body RoleBody
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	dbRole, code, err := getOwnRole(r, a)
+	if err != nil {
+		return code, err
+	}
+	permissions, err := validPermissions(body.Permissions)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	dbRole.Name = body.Name
+	dbRole.Permissions, _ = json.Marshal(permissions)
+	if err := dbRole.Update(tx); err != nil {
+		jsonlog.LoggerFromContextOrDefault(r.Context()).Error("Failed to update role.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to update role.")
+	}
+	role, _ := roleFromDbRole(dbRole)
+	return http.StatusOK, role
+}
+
+// deleteRole is a route handler which deletes a role the user created, if
+// it is not given to anyone.
+func deleteRole(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	dbRole, code, err := getOwnRole(r, a)
+	if err != nil {
+		return code, err
+	}
+	if dbSharedAccounts, err := models.SharedAccountsByAccessRoleID(tx, dbRole.ID); err != nil {
+		l.Error("Failed to get shared accounts of role.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to delete role.")
+	} else if dbMappings, err := models.SSOGroupMappingsByAccessRoleID(tx, dbRole.ID); err != nil {
+		l.Error("Failed to get SSO group mappings of role.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to delete role.")
+	} else if len(dbSharedAccounts) > 0 || len(dbMappings) > 0 {
+		return http.StatusConflict, errors.New("This role is still given to users.")
+	}
+	if err := dbRole.Delete(tx); err != nil {
+		l.Error("Failed to delete role.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to delete role.")
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,89 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoleIncludes(t *testing.T) {
+	standard := Role{Permissions: []Permission{PermissionViewCosts, PermissionViewResources, PermissionManageBillRepositories}}
+	readOnly := Role{Permissions: []Permission{PermissionViewCosts, PermissionViewResources}}
+	if !ownerRole.Includes(standard) || !standard.Includes(readOnly) {
+		t.Error("Roles should include the roles with less permissions")
+	}
+	if readOnly.Includes(standard) {
+		t.Error("Roles should not include the roles with more permissions")
+	}
+	if !readOnly.Includes(Role{}) {
+		t.Error("Roles should include the roles without permissions")
+	}
+}
+
+func TestRoleUnionAndIntersection(t *testing.T) {
+	costs := Role{Permissions: []Permission{PermissionViewCosts, PermissionDownloadReports}}
+	resources := Role{Permissions: []Permission{PermissionViewResources, PermissionDownloadReports}}
+	union := costs.union(resources)
+	if len(union.Permissions) != 3 || !union.Includes(costs) || !union.Includes(resources) {
+		t.Errorf("Unexpected union %v", union.Permissions)
+	}
+	intersection := costs.intersection(resources)
+	if len(intersection.Permissions) != 1 || !intersection.HasPermission(PermissionDownloadReports) {
+		t.Errorf("Unexpected intersection %v", intersection.Permissions)
+	}
+}
+
+func TestValidPermissions(t *testing.T) {
+	permissions, err := validPermissions([]Permission{PermissionViewResources, PermissionViewCosts, PermissionViewResources})
+	if err != nil {
+		t.Fatalf("Permissions should be valid: %s", err)
+	} else if len(permissions) != 2 || permissions[0] != PermissionViewCosts || permissions[1] != PermissionViewResources {
+		t.Errorf("Permissions should be sorted without duplicates, got %v", permissions)
+	}
+	if _, err := validPermissions([]Permission{"costs:delete"}); err != ErrInvalidPermission {
+		t.Errorf("Unknown permissions should be invalid")
+	}
+}
+
+func TestRequiredPermission(t *testing.T) {
+	cases := []struct {
+		method     string
+		path       string
+		permission Permission
+		ok         bool
+	}{
+		{"GET", "/costs", PermissionViewCosts, true},
+		{"POST", "/costs", PermissionViewCosts, true},
+		{"POST", "/costs/anomalies/snooze", PermissionManageCosts, true},
+		{"GET", "/costs/categories", PermissionViewCosts, true},
+		{"PUT", "/costs/categories", PermissionManageCosts, true},
+		{"DELETE", "/budgets", PermissionManageCosts, true},
+		{"GET", "/costsomething", "", false},
+		{"GET", "/plugins/results", PermissionViewResources, true},
+		{"POST", "/plugins/results", PermissionManagePlugins, true},
+		{"GET", "/aws/billrepository", "", false},
+		{"DELETE", "/aws/billrepository", PermissionManageBillRepositories, true},
+		{"GET", "/report", PermissionDownloadReports, true},
+		{"DELETE", "/user/share", PermissionManageSharing, true},
+		{"GET", "/aws", "", false},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, c.path, nil)
+		if permission, ok := requiredPermission(r); permission != c.permission || ok != c.ok {
+			t.Errorf("%s %s: expected %q %v, got %q %v", c.method, c.path, c.permission, c.ok, permission, ok)
+		}
+	}
+}
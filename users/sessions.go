@@ -0,0 +1,260 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/config"
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+)
+
+type (
+	// refreshRequestBody is the expected request body for the refresh route
+	// handler.
+	refreshRequestBody struct {
+		RefreshToken string `json:"refreshToken" req:"nonzero"`
+	}
+
+	// Session is a session of a user as returned by the routes. Current is
+	// set for the session the request was made with.
+	Session struct {
+		Id        int       `json:"id"`
+		UserAgent string    `json:"userAgent"`
+		IpAddress string    `json:"ipAddress"`
+		Created   time.Time `json:"created"`
+		LastUsed  time.Time `json:"lastUsed"`
+		Expires   time.Time `json:"expires"`
+		Current   bool      `json:"current"`
+	}
+)
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodPost: routes.H(refreshSession).With(
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{refreshRequestBody{"refreshtoken"}},
+			db.RequestTransaction{db.Db},
+			routes.Documentation{
+				Summary:     "refresh a session",
+				Description: "Responds with a new JWT token and a new refresh token for the session of a refresh token. The refresh token can only be used once: using it again revokes the session.",
+			},
+		),
+	}.H().Register("/user/refresh")
+
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getSessions).With(
+			RequireAuthenticatedUser{ViewerAsSelf},
+			routes.Documentation{
+				Summary:     "get the sessions",
+				Description: "Responds with the active sessions of the current user.",
+			},
+		),
+		http.MethodDelete: routes.H(deleteSessions).With(
+			RequireAuthenticatedUser{ViewerAsSelf},
+			routes.QueryArgs{routes.SessionIdOptionalQueryArg},
+			routes.Documentation{
+				Summary:     "revoke sessions",
+				Description: "Revokes the session passed in the query args, or every other session than the current one if none is passed. The tokens of revoked sessions are rejected at once.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+		routes.Documentation{
+			Summary: "interact with the sessions of the user",
+		},
+	).Register("/user/sessions")
+}
+
+// RequestIpAddress returns the IP address a request was made from.
+func RequestIpAddress(request *http.Request) string {
+	if host, _, err := net.SplitHostPort(request.RemoteAddr); err == nil {
+		return host
+	}
+	return request.RemoteAddr
+}
+
+// requestUserAgent returns the user agent of a request, truncated to fit in
+// the database.
+func requestUserAgent(request *http.Request) string {
+	if userAgent := request.UserAgent(); len(userAgent) > 255 {
+		return userAgent[:255]
+	} else {
+		return userAgent
+	}
+}
+
+// createSession opens a session for a user and returns a JWT token and a
+// refresh token for it. mfa tells whether the user proved a second factor.
+// Expired sessions are cleaned up on the way.
+func createSession(request *http.Request, tx *sql.Tx, user User, mfa bool) (string, string, error) {
+	now := time.Now().UTC()
+	if err := models.DeleteExpiredUserSessions(tx, now); err != nil {
+		return "", "", err
+	}
+	refreshToken, err := generateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	dbSession := models.UserSession{
+		UserID:       user.Id,
+		RefreshToken: hashSecret(refreshToken),
+		UserAgent:    requestUserAgent(request),
+		IPAddress:    RequestIpAddress(request),
+		Created:      now,
+		LastUsed:     now,
+		Expires:      now.Add(config.AuthSessionDuration),
+		MFA:          mfa,
+	}
+	if err := dbSession.Insert(tx); err != nil {
+		return "", "", err
+	}
+	token, err := generateToken(user, dbSession.ID)
+	return token, refreshToken, err
+}
+
+// refreshSession handles users refreshing their session. The refresh token is
+// rotated so that a stolen one can only be used once.
+func refreshSession(request *http.Request, a routes.Arguments) (int, interface{}) {
+	var body refreshRequestBody
+	routes.MustRequestBody(a, &body)
+	logger := jsonlog.LoggerFromContextOrDefault(request.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	dbSession, err := models.UserSessionByRefreshToken(tx, hashSecret(body.RefreshToken))
+	if err == sql.ErrNoRows {
+		revokeReusedSession(request, body.RefreshToken)
+		return http.StatusUnauthorized, ErrRevokedSession
+	} else if err == nil && !time.Now().Before(dbSession.Expires) {
+		return http.StatusUnauthorized, ErrRevokedSession
+	} else if err != nil {
+		logger.Error("Failed to get session.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to refresh session.")
+	}
+	user, err := GetUserWithId(tx, dbSession.UserID)
+	if err != nil {
+		logger.Error("Failed to get user of session.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to refresh session.")
+	}
+	refreshToken, err := generateSecret()
+	if err != nil {
+		logger.Error("Failed to generate refresh token.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to refresh session.")
+	}
+	if err := models.InsertUsedRefreshToken(tx, dbSession.ID, dbSession.RefreshToken); err != nil {
+		logger.Error("Failed to record used refresh token.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to refresh session.")
+	}
+	dbSession.RefreshToken = hashSecret(refreshToken)
+	dbSession.UserAgent = requestUserAgent(request)
+	dbSession.IPAddress = RequestIpAddress(request)
+	dbSession.LastUsed = time.Now().UTC()
+	if err := dbSession.Update(tx); err != nil {
+		logger.Error("Failed to update session.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to refresh session.")
+	}
+	token, err := generateToken(user, dbSession.ID)
+	if err != nil {
+		logger.Error("Failed to generate token.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to generate token.")
+	}
+	return http.StatusOK, loginResponseBody{
+		User:         user,
+		Token:        token,
+		RefreshToken: refreshToken,
+	}
+}
+
+// revokeReusedSession revokes the session a refresh token was rotated from,
+// if any: either the token was stolen or the thief already used it, so
+// neither of them can be trusted with the session. It does not use the
+// transaction of the request, which is rolled back.
+func revokeReusedSession(request *http.Request, refreshToken string) {
+	logger := jsonlog.LoggerFromContextOrDefault(request.Context())
+	dbSession, err := models.UserSessionByUsedRefreshToken(db.Db, hashSecret(refreshToken))
+	if err == sql.ErrNoRows {
+		return
+	} else if err != nil {
+		logger.Error("Failed to get session of used refresh token.", err.Error())
+	} else if err := dbSession.Delete(db.Db); err != nil {
+		logger.Error("Failed to revoke session of reused refresh token.", err.Error())
+	} else {
+		logger.Warning("Revoked session of reused refresh token.", map[string]interface{}{
+			"sessionId": dbSession.ID,
+			"userId":    dbSession.UserID,
+		})
+	}
+}
+
+// getSessions is a route handler which returns the active sessions of the
+// current user.
+func getSessions(request *http.Request, a routes.Arguments) (int, interface{}) {
+	logger := jsonlog.LoggerFromContextOrDefault(request.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[AuthenticatedUser].(User)
+	dbSessions, err := models.UserSessionsByUserID(tx, user.Id)
+	if err != nil {
+		logger.Error("Failed to get sessions.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to retrieve sessions.")
+	}
+	now := time.Now()
+	sessions := make([]Session, 0, len(dbSessions))
+	for _, dbSession := range dbSessions {
+		if !now.Before(dbSession.Expires) {
+			continue
+		}
+		sessions = append(sessions, Session{
+			Id:        dbSession.ID,
+			UserAgent: dbSession.UserAgent,
+			IpAddress: dbSession.IPAddress,
+			Created:   dbSession.Created,
+			LastUsed:  dbSession.LastUsed,
+			Expires:   dbSession.Expires,
+			Current:   dbSession.ID == user.SessionId,
+		})
+	}
+	return http.StatusOK, sessions
+}
+
+// deleteSessions is a route handler which revokes a session of the current
+// user, or all of them but the current one.
+func deleteSessions(request *http.Request, a routes.Arguments) (int, interface{}) {
+	logger := jsonlog.LoggerFromContextOrDefault(request.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[AuthenticatedUser].(User)
+	if sessionId, ok := a[routes.SessionIdOptionalQueryArg].(int); ok {
+		dbSession, err := models.UserSessionByID(tx, sessionId)
+		if err == sql.ErrNoRows || (err == nil && dbSession.UserID != user.Id) {
+			return http.StatusNotFound, errors.New("Session not found.")
+		} else if err != nil {
+			logger.Error("Failed to get session.", err.Error())
+			return http.StatusInternalServerError, errors.New("Failed to retrieve session.")
+		} else if err := dbSession.Delete(tx); err != nil {
+			logger.Error("Failed to delete session.", err.Error())
+			return http.StatusInternalServerError, errors.New("Failed to revoke session.")
+		}
+	} else if err := models.DeleteUserSessionsByUserID(tx, user.Id, user.SessionId); err != nil {
+		logger.Error("Failed to delete sessions.", err.Error())
+		return http.StatusInternalServerError, errors.New("Failed to revoke sessions.")
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,41 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package users
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestIpAddress(t *testing.T) {
+	r := httptest.NewRequest("POST", "/user/login", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	if ip := RequestIpAddress(r); ip != "192.0.2.1" {
+		t.Errorf("Unexpected IP address %s", ip)
+	}
+	r.RemoteAddr = "192.0.2.1"
+	if ip := RequestIpAddress(r); ip != "192.0.2.1" {
+		t.Errorf("Unexpected IP address %s", ip)
+	}
+}
+
+func TestRequestUserAgent(t *testing.T) {
+	r := httptest.NewRequest("POST", "/user/login", nil)
+	r.Header.Set("User-Agent", strings.Repeat("a", 300))
+	if userAgent := requestUserAgent(r); len(userAgent) != 255 {
+		t.Errorf("User agent should be truncated to 255 bytes, is %d", len(userAgent))
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/satori/go.uuid"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/trackit/trackit/audit"
 	"github.com/trackit/trackit/mail"
 	"github.com/trackit/trackit/users"
 	"github.com/trackit/trackit/models"
@@ -96,11 +97,11 @@ func checkSharedAccount(ctx context.Context, db models.XODB, accountId int, gues
 
 // addAccountToGuest adds an entry in shared_account table allowing a user
 // to share an access to all or part of his account
-func addAccountToGuest(ctx context.Context, db *sql.Tx, accountId int, permissionLevel int, guestId int) (models.SharedAccount, error) {
+func addAccountToGuest(ctx context.Context, db *sql.Tx, accountId int, roleId int, guestId int) (models.SharedAccount, error) {
 	dbSharedAccount := models.SharedAccount{
-		AccountID:  accountId,
-		UserID:   guestId,
-		UserPermission: permissionLevel,
+		AccountID:    accountId,
+		UserID:       guestId,
+		AccessRoleID: roleId,
 	}
 	err := dbSharedAccount.Insert(db)
 	return dbSharedAccount, err
@@ -113,7 +114,7 @@ func createAccountForGuest(ctx context.Context, db *sql.Tx, body InviteUserReque
 	tempPassword := uuid.NewV1().String()
 	usr, err := users.CreateUserWithPassword(ctx, db, body.Email, tempPassword, "")
 	if err == nil {
-		sharedAccount, err = addAccountToGuest(ctx, db, accountId, body.PermissionLevel, usr.Id)
+		sharedAccount, err = addAccountToGuest(ctx, db, accountId, body.RoleId, usr.Id)
 		if err != nil {
 			logger.Error("Error occured while adding account to an newly created user.", err.Error())
 			return 0, models.SharedAccount{}, err
@@ -185,7 +186,7 @@ func inviteUserAlreadyExist(ctx context.Context, tx *sql.Tx, body InviteUserRequ
 	} else if isAlreadyShared {
 		return http.StatusBadRequest, ErrorAlreadyShared
 	}
-	sharedAccount, err := addAccountToGuest(ctx, tx, accountId, body.PermissionLevel, guestId)
+	sharedAccount, err := addAccountToGuest(ctx, tx, accountId, body.RoleId, guestId)
 	if err == nil {
 		err = sendMailNotification(ctx, tx, body.Email,true, 0)
 		if err != nil {
@@ -219,25 +220,30 @@ func inviteNewUser(ctx context.Context, tx *sql.Tx, body InviteUserRequest, acco
 // inviteUserWithValidBody tries to share an account with a specific user
 func InviteUserWithValidBody(request *http.Request, body InviteUserRequest, accountId int, tx *sql.Tx, user users.User) (int, interface{}) {
 	logger := jsonlog.LoggerFromContextOrDefault(request.Context())
-	security, err := safetyCheckByAccountIdAndPermissionLevel(request.Context(), tx, accountId, body, user)
+	security, err := safetyCheckByAccountIdAndRole(request.Context(), tx, accountId, body.RoleId, user)
 	if err != nil {
 		return http.StatusBadRequest, err
 	} else if !security {
 		return http.StatusForbidden, errors.New("You do not have permission to edit this sharing")
 	}
-	if !checkPermissionLevel(body.PermissionLevel) {
-		logger.Info("Non existing user permission", nil)
-		return http.StatusBadRequest, ErrorInviteUser
-	}
 	result, guestId, err := checkUserWithEmail(request.Context(), tx, body.Email, user)
 	if err == nil {
+		var code int
+		var res interface{}
 		if result {
-			code, res := inviteUserAlreadyExist(request.Context(), tx, body, accountId, guestId)
-			return code, res
+			code, res = inviteUserAlreadyExist(request.Context(), tx, body, accountId, guestId)
 		} else {
-			code, res := inviteNewUser(request.Context(), tx, body, accountId)
-			return code, res
+			code, res = inviteNewUser(request.Context(), tx, body, accountId)
+		}
+		if sharedAccount, ok := res.(models.SharedAccount); ok && code == http.StatusOK {
+			dbAwsAccount, err := getAwsAccount(request.Context(), tx, accountId)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			} else if err := recordSharingChange(request, tx, user, audit.ActionSharingInvite, dbAwsAccount.UserID, sharedAccount.ID, nil, sharedAccount); err != nil {
+				return http.StatusInternalServerError, err
+			}
 		}
+		return code, res
 	} else {
 		logger.Error("Error occured while checking body elements.", err.Error())
 		return 403, ErrorInviteNewUser
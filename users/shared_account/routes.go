@@ -10,12 +10,12 @@ import (
 
 // inviteUserRequest is the expected request body for the invite user route handler.
 type InviteUserRequest struct {
-	Email           string `json:"email" req:"nonzero"`
-	PermissionLevel int    `json:"permissionLevel"`
+	Email  string `json:"email" req:"nonzero"`
+	RoleId int    `json:"roleId"`
 }
 
 type updateUsersSharedAccountRequest struct {
-	PermissionLevel int `json:"permissionLevel"`
+	RoleId int `json:"roleId"`
 }
 
 func init() {
@@ -37,7 +37,7 @@ func init() {
 			routes.RequestContentType{"application/json"},
 			routes.Documentation{
 				Summary:     "Creates an invite",
-				Description: "Creates an invite for account team sharing. Permission level can be 0 for admin, 1 for standard and 2 for read-only.",
+				Description: "Creates an invite for account team sharing with a role. The role can be a built-in role or a role created by the owner of the account, and cannot have more permissions than the role of the user.",
 			},
 			routes.QueryArgs{
 				routes.AwsAccountIdQueryArg,
@@ -52,7 +52,7 @@ func init() {
 			routes.RequestContentType{"application/json"},
 			routes.Documentation{
 				Summary:     "Update shared users",
-				Description: "Update shared users associated with a specific AWS account. Permission level can be 0 for admin, 1 for standard and 2 for read-only.",
+				Description: "Update the role of shared users associated with a specific AWS account. The role cannot have more permissions than the role of the user.",
 			},
 		),
 		http.MethodDelete: routes.H(deleteSharedUsers).With(
@@ -12,150 +12,122 @@ import (
 )
 
 const (
-	AdminLevel = 0
-	StandardLevel = 1
-	ReadLevel = 2
 	DatabaseError = "Error while getting data from database"
 )
 
-// safetyCheckByAccountId checks by AccountId if the user have a high enough
-// permission level to perform an action on a shared account
-func safetyCheckByAccountId(ctx context.Context, tx *sql.Tx, AccountId int, user users.User) (bool, error) {
+// sharingRole returns the role of the user on an AWS account, and whether it
+// allows them to manage the sharing of the account.
+func sharingRole(ctx context.Context, tx *sql.Tx, dbAwsAccount *models.AwsAccount, user users.User) (users.Role, bool, error) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
-	dbAwsAccount, err := models.AwsAccountByID(tx, AccountId)
-	if err == sql.ErrNoRows {
-		return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseItemNotFound, "This AWS Account does not exist"})
-	} else if err != nil {
-		logger.Error("Error while retrieving AWS account from DB", err)
-		return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseGenericError, err.Error()})
-	}
-	if dbAwsAccount.UserID == user.Id {
-		return true, nil
-	}
-	dbSharedAccount, err := models.SharedAccountsByAccountID(tx, AccountId)
-	if err == nil {
-		for _, key := range dbSharedAccount {
-			if key.UserID == user.Id && (key.UserPermission == AdminLevel || key.UserPermission == StandardLevel) {
-				return true, nil
-			}
-		}
-	}
-	if err == sql.ErrNoRows {
-		return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseItemNotFound, "This Shared Account does not exist"})
+	role, access, err := users.GetRoleOnAccount(tx, user, dbAwsAccount.ID)
+	if err != nil {
+		logger.Error("Error while retrieving role on AWS account from DB", err)
+		return role, false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseGenericError, "Unable to ensure user have enough rights to do this action"})
 	}
-	logger.Error("Error while retrieving shared account by account ID from DB", err)
-	return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseGenericError, "Unable to ensure user have enough rights to do this action"})
+	return role, access && role.HasPermission(users.PermissionManageSharing), nil
 }
 
-// checkLevel checks if the current user permission level is high enough to perform an action
-func checkLevel(PermissionLevelToCheck int, currentUserPermissionLevel int) (bool) {
-	if currentUserPermissionLevel == AdminLevel {
-		return true
-	} else if currentUserPermissionLevel == StandardLevel {
-		if currentUserPermissionLevel <= PermissionLevelToCheck {
-			return true
-		}
+// canGiveRole checks whether a user with a role on an AWS account can give
+// another role on it: the role must be available on the account and the
+// user cannot give more permissions than they have.
+func canGiveRole(ctx context.Context, tx *sql.Tx, dbAwsAccount *models.AwsAccount, userRole users.Role, roleId int) (bool, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	role, err := users.GetRoleWithId(tx, roleId)
+	if err == users.ErrRoleNotFound {
+		return false, errors.GetErrorMessage(ctx, &errors.SharedAccountError{errors.SharedAccountBadPermission, "This role does not exist"})
+	} else if err != nil {
+		logger.Error("Error while retrieving role from DB", err)
+		return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseGenericError, err.Error()})
+	} else if !role.AvailableOn(dbAwsAccount.UserID) {
+		return false, errors.GetErrorMessage(ctx, &errors.SharedAccountError{errors.SharedAccountBadPermission, "This role does not exist"})
 	}
-	return false
+	return userRole.Includes(role), nil
 }
 
-// safetyCheckByAccountIdAndPermissionLevel checks by AccountId if the user have a high enough
-// permission level to perform an action on a shared account. It also compares current user Permission Level
-// to the permissionLevel of the viewer account.
-func safetyCheckByAccountIdAndPermissionLevel(ctx context.Context, tx *sql.Tx, AccountId int, body InviteUserRequest, user users.User) (bool, error) {
+// getAwsAccount retrieves an AWS account for the safety checks.
+func getAwsAccount(ctx context.Context, tx *sql.Tx, accountId int) (*models.AwsAccount, error) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
-	dbAwsAccount, err := models.AwsAccountByID(tx, AccountId)
+	dbAwsAccount, err := models.AwsAccountByID(tx, accountId)
 	if err == sql.ErrNoRows {
-		return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseItemNotFound, "This AWS Account does not exist"})
+		return nil, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseItemNotFound, "This AWS Account does not exist"})
 	} else if err != nil {
 		logger.Error("Error while retrieving AWS account from DB", err)
-		return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseGenericError, err.Error()})
-	}
-	if dbAwsAccount.UserID == user.Id {
-		return true, nil
-	}
-	dbSharedAccount, err := models.SharedAccountsByAccountID(tx, AccountId)
-	if err == nil {
-		for _, key := range dbSharedAccount {
-			if key.UserID == user.Id  && checkLevel(body.PermissionLevel, key.UserPermission){
-				return true, nil
-			}
-		}
-	}
-	if err == sql.ErrNoRows {
-		return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseItemNotFound, "This Shared Account does not exist"})
+		return nil, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseGenericError, err.Error()})
 	}
-	logger.Error("Error while retrieving shared account by account ID from DB", err)
-	return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseGenericError, "Unable to ensure user have enough rights to do this action"})
+	return dbAwsAccount, nil
 }
 
-// safetyCheckByShareId checks by ShareId if the user have a high enough
-// permission level to perform an action on a shared account
-func safetyCheckByShareId(ctx context.Context, tx *sql.Tx, shareId int, user users.User) (bool, error) {
+// getShare retrieves a share and its AWS account for the safety checks. It
+// returns a nil share if it does not exist.
+func getShare(ctx context.Context, tx *sql.Tx, shareId int) (*models.SharedAccount, *models.AwsAccount, error) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	dbShareAccount, err := models.SharedAccountByID(tx, shareId)
 	if err == sql.ErrNoRows {
-		return false, nil
+		return nil, nil, nil
 	} else if err != nil {
-		logger.Error("Error while retrieving Shared Accounts" , err)
-		return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseGenericError, err.Error()})
-	}
-	dbAwsAccount, err := models.AwsAccountByID(tx, dbShareAccount.AccountID)
-	if dbAwsAccount.UserID == user.Id {
-		return true, nil
-	}
-	dbShareAccountByAccountId, err := models.SharedAccountsByAccountID(tx, dbShareAccount.AccountID)
-	if err == nil {
-		for _, key := range dbShareAccountByAccountId {
-			if key.UserID == user.Id && checkLevel(dbShareAccount.UserPermission, key.UserPermission) {
-				return true, nil
-			}
-		}
+		logger.Error("Error while retrieving Shared Accounts from DB", err)
+		return nil, nil, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseGenericError, err.Error()})
 	}
-	logger.Error("Error while retrieving shared account by account ID from DB", err)
-	return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseGenericError, err.Error()})
+	dbAwsAccount, err := getAwsAccount(ctx, tx, dbShareAccount.AccountID)
+	return dbShareAccount, dbAwsAccount, err
 }
 
-// safetyCheckByShareIdAndPermissionLevel checks by ShareId if the user have a high enough
-// permission level to perform an action on a shared account. It also checks if permission Level  of the current user
-// is higher than the one that the users wants to set.
-func safetyCheckByShareIdAndPermissionLevel(ctx context.Context, tx *sql.Tx, shareId int, newPermissionLevel int, user users.User) (bool, error) {
-	logger := jsonlog.LoggerFromContextOrDefault(ctx)
-	dbShareAccount, err := models.SharedAccountByID(tx, shareId)
-	if err == sql.ErrNoRows {
-		return false, nil
-	} else if err != nil {
-		logger.Error("Error while retrieving Shared Accounts from DB" , err)
-		return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseGenericError, err.Error()})
+// safetyCheckByAccountId checks by AccountId if the user has the permission
+// to manage the sharing of an AWS account
+func safetyCheckByAccountId(ctx context.Context, tx *sql.Tx, AccountId int, user users.User) (bool, error) {
+	dbAwsAccount, err := getAwsAccount(ctx, tx, AccountId)
+	if err != nil {
+		return false, err
 	}
-	dbAwsAccount, err := models.AwsAccountByID(tx, dbShareAccount.AccountID)
-	if dbAwsAccount.UserID == user.Id {
-		return true, nil
+	_, allowed, err := sharingRole(ctx, tx, dbAwsAccount, user)
+	return allowed, err
+}
+
+// safetyCheckByAccountIdAndRole checks by AccountId if the user has the
+// permission to manage the sharing of an AWS account. It also checks that
+// the role they give does not have more permissions than their own.
+func safetyCheckByAccountIdAndRole(ctx context.Context, tx *sql.Tx, AccountId int, roleId int, user users.User) (bool, error) {
+	dbAwsAccount, err := getAwsAccount(ctx, tx, AccountId)
+	if err != nil {
+		return false, err
+	}
+	userRole, allowed, err := sharingRole(ctx, tx, dbAwsAccount, user)
+	if err != nil || !allowed {
+		return false, err
+	}
+	return canGiveRole(ctx, tx, dbAwsAccount, userRole, roleId)
+}
+
+// safetyCheckByShareId checks by ShareId if the user has the permission to
+// manage the sharing of an AWS account. Users cannot revoke the access of
+// users who have more permissions than them.
+func safetyCheckByShareId(ctx context.Context, tx *sql.Tx, shareId int, user users.User) (bool, error) {
+	dbShareAccount, dbAwsAccount, err := getShare(ctx, tx, shareId)
+	if err != nil || dbShareAccount == nil {
+		return false, err
 	}
-	dbShareAccountByAccountId, err := models.SharedAccountsByAccountID(tx, dbShareAccount.AccountID)
-	if err == nil {
-		for _, key := range dbShareAccountByAccountId {
-			if key.UserID == user.Id && checkLevel(newPermissionLevel, key.UserPermission) {
-				if key.UserPermission == StandardLevel && dbShareAccount.UserPermission == AdminLevel {
-					return false, nil
-				}
-				return true, nil
-			}
-		}
+	userRole, allowed, err := sharingRole(ctx, tx, dbAwsAccount, user)
+	if err != nil || !allowed {
+		return false, err
 	}
-	logger.Error("Error while retrieving shared account by account ID from DB", err)
-	return false, errors.GetErrorMessage(ctx, &errors.DatabaseError{errors.DatabaseGenericError, err.Error()})
+	return canGiveRole(ctx, tx, dbAwsAccount, userRole, dbShareAccount.AccessRoleID)
 }
 
-// checkPermissionLevel checks user permission level
-func checkPermissionLevel(permissionLevel int) (bool) {
-	if permissionLevel == AdminLevel {
-		return true
-	} else if permissionLevel == StandardLevel {
-		return true
-	} else if permissionLevel == ReadLevel {
-		return true
-	} else {
-		return false
-	}
+// safetyCheckByShareIdAndRole checks by ShareId if the user has the
+// permission to manage the sharing of an AWS account. Users cannot change
+// the role of users who have more permissions than them, nor give more
+// permissions than they have.
+func safetyCheckByShareIdAndRole(ctx context.Context, tx *sql.Tx, shareId int, newRoleId int, user users.User) (bool, error) {
+	dbShareAccount, dbAwsAccount, err := getShare(ctx, tx, shareId)
+	if err != nil || dbShareAccount == nil {
+		return false, err
+	}
+	userRole, allowed, err := sharingRole(ctx, tx, dbAwsAccount, user)
+	if err != nil || !allowed {
+		return false, err
+	}
+	if allowed, err := canGiveRole(ctx, tx, dbAwsAccount, userRole, dbShareAccount.AccessRoleID); err != nil || !allowed {
+		return false, err
+	}
+	return canGiveRole(ctx, tx, dbAwsAccount, userRole, newRoleId)
 }
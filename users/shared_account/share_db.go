@@ -27,7 +27,8 @@ import (
 type SharedResults struct {
 	ShareId       int    `json:"sharedId" req:"nonzero"`
 	Mail          string `json:"email" req:"nonzero"`
-	Level         int    `json:"level"`
+	RoleId        int    `json:"roleId"`
+	RoleName      string `json:"roleName"`
 	UserId        int    `json:"userId" req:"nonzero"`
 	SharingStatus bool   `json:"sharingStatus"`
 }
@@ -49,24 +50,29 @@ func GetSharingList(ctx context.Context, db models.XODB, accountId int) ([]Share
 				logger.Error("Error getting users from database.", err.Error())
 				return nil, errors.New("Error while getting data from database")
 			}
-			response = append(response, SharedResults{key.ID, dbUser.Email,key.UserPermission,key.UserID,key.SharingAccepted})
+			dbRole, err := models.AccessRoleByID(db, key.AccessRoleID)
+			if err != nil {
+				logger.Error("Error getting roles from database.", err.Error())
+				return nil, errors.New("Error while getting data from database")
+			}
+			response = append(response, SharedResults{key.ID, dbUser.Email, key.AccessRoleID, dbRole.Name, key.UserID, key.SharingAccepted})
 		}
 		return response, nil
 	}
 }
 
-// UpdateSharedUser updates user permission level
-func UpdateSharedUser(ctx context.Context, db models.XODB, shareId int, permissionLevel int) (interface{}, error) {
+// UpdateSharedUser updates user role
+func UpdateSharedUser(ctx context.Context, db models.XODB, shareId int, roleId int) (interface{}, error) {
 	logger := jsonlog.LoggerFromContextOrDefault(ctx)
 	dbSharedAccount, err := models.SharedAccountByID(db, shareId)
 	if err != nil {
 		logger.Error("Error while getting shared user information", err)
 		return nil, err
 	}
-	dbSharedAccount.UserPermission = permissionLevel
+	dbSharedAccount.AccessRoleID = roleId
 	err = dbSharedAccount.Update(db)
 	if err != nil {
-		logger.Error("Error while updating user permission", err)
+		logger.Error("Error while updating user role", err)
 		return nil, err
 	}
 	return dbSharedAccount, nil
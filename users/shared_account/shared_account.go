@@ -17,7 +17,9 @@ package shared_account
 import (
 	"database/sql"
 	"net/http"
+	"strconv"
 
+	"github.com/trackit/trackit/audit"
 	"github.com/trackit/trackit/db"
 	"github.com/trackit/trackit/errors"
 	"github.com/trackit/trackit/routes"
@@ -45,7 +47,7 @@ func listSharedUsers(request *http.Request, a routes.Arguments) (int, interface{
 	return listSharedUserAccessWithValidBody(request, accountId, tx, user)
 }
 
-// updateSharedUsers handles updates of user permission level for team sharing.
+// updateSharedUsers handles updates of user role for team sharing.
 func updateSharedUsers(request *http.Request, a routes.Arguments) (int, interface{}) {
 	var body updateUsersSharedAccountRequest
 	shareId := a[routes.ShareIdQueryArg].(int)
@@ -83,22 +85,26 @@ func listSharedUserAccessWithValidBody(request *http.Request, accountId int, tx
 	}
 }
 
-// updateSharedUserAccessWithValidBody tries to update users permission level for team sharing
+// updateSharedUserAccessWithValidBody tries to update users role for team sharing
 func updateSharedUserAccessWithValidBody(request *http.Request, body updateUsersSharedAccountRequest, shareId int, tx *sql.Tx, user users.User) (int, interface{}) {
 	ctx := request.Context()
-	security, err := safetyCheckByShareIdAndPermissionLevel(ctx, tx, shareId, body.PermissionLevel, user)
+	security, err := safetyCheckByShareIdAndRole(ctx, tx, shareId, body.RoleId, user)
 	if err != nil {
 		return http.StatusBadRequest, err
 	} else if !security {
 		return http.StatusForbidden, errors.GetErrorMessage(ctx, &errors.SharedAccountError{errors.SharedAccountNoPermission, "You do not have permission to edit this sharing"})
 	}
-	if !checkPermissionLevel(body.PermissionLevel) {
-		return http.StatusBadRequest, errors.GetErrorMessage(ctx, &errors.SharedAccountError{errors.SharedAccountBadPermission, "Bad permission level"})
+	before, dbAwsAccount, err := getShare(ctx, tx, shareId)
+	if err != nil {
+		return http.StatusBadRequest, err
 	}
-	res, err := UpdateSharedUser(request.Context(), db.Db, shareId, body.PermissionLevel)
+	res, err := UpdateSharedUser(request.Context(), tx, shareId, body.RoleId)
 	if err != nil {
 		return http.StatusForbidden, errors.GetErrorMessage(ctx, &errors.SharedAccountError{errors.SharedAccountRequestError, "Error retrieving shared user list"})
 	}
+	if err := recordSharingChange(request, tx, user, audit.ActionSharingUpdate, dbAwsAccount.UserID, shareId, before, res); err != nil {
+		return http.StatusInternalServerError, err
+	}
 	return http.StatusOK, res
 }
 
@@ -111,9 +117,29 @@ func deleteSharedUserAccessWithValidBody(request *http.Request, shareId int, tx
 	} else if !security {
 		return http.StatusForbidden, errors.GetErrorMessage(ctx, &errors.SharedAccountError{errors.SharedAccountNoPermission, "You do not have permission to delete this sharing"})
 	}
-	err = DeleteSharedUser(request.Context(), db.Db, shareId)
+	before, dbAwsAccount, err := getShare(ctx, tx, shareId)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	err = DeleteSharedUser(request.Context(), tx, shareId)
 	if err != nil {
 		return http.StatusBadRequest, errors.GetErrorMessage(ctx, &errors.SharedAccountError{errors.SharedAccountRequestError, "Error deleting shared user"})
 	}
+	if err := recordSharingChange(request, tx, user, audit.ActionSharingDelete, dbAwsAccount.UserID, shareId, before, nil); err != nil {
+		return http.StatusInternalServerError, err
+	}
 	return http.StatusOK, nil
 }
+
+// recordSharingChange records a change of the sharing of an AWS account in
+// the audit log, on behalf of the owner of the account.
+func recordSharingChange(request *http.Request, tx *sql.Tx, user users.User, action audit.Action, ownerId int, shareId int, before, after interface{}) error {
+	return audit.Record(request, tx, user, audit.Entry{
+		Action:     action,
+		TargetType: audit.TargetSharedAccount,
+		TargetId:   strconv.Itoa(shareId),
+		OwnerId:    ownerId,
+		Before:     before,
+		After:      after,
+	})
+}
@@ -0,0 +1,126 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package sso
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+const (
+	// domainRecordPrefix prefixes the email domain of a provider to name
+	// the DNS TXT record verifying it.
+	domainRecordPrefix = "_trackit-sso."
+	// domainRecordValuePrefix prefixes the token of a provider in the DNS
+	// TXT record verifying its email domain.
+	domainRecordValuePrefix = "trackit-sso-verification="
+)
+
+// domainRe matches the domain names of email addresses.
+var domainRe = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?\.)+[a-zA-Z]{2,}$`)
+
+// lookupTXT looks the TXT records of a name up. It is a variable so that
+// tests can replace it.
+var lookupTXT = net.DefaultResolver.LookupTXT
+
+// validDomain checks whether a string is a domain name usable in email
+// addresses.
+func validDomain(domain string) bool {
+	return len(domain) <= 253 && domainRe.MatchString(domain)
+}
+
+// domainRecordName returns the name of the DNS TXT record verifying an email
+// domain.
+func domainRecordName(domain string) string {
+	return domainRecordPrefix + domain
+}
+
+// domainRecordValue returns the value the DNS TXT record verifying the email
+// domain of a provider must hold.
+func domainRecordValue(token string) string {
+	return domainRecordValuePrefix + token
+}
+
+// domainVerified checks whether the DNS TXT record of the email domain of a
+// provider holds its token.
+func domainVerified(ctx context.Context, provider *models.SSOProvider) (bool, error) {
+	if provider.EmailDomain == "" || provider.EmailDomainToken == "" {
+		return false, nil
+	}
+	records, err := lookupTXT(ctx, domainRecordName(provider.EmailDomain))
+	if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	for _, record := range records {
+		if record == domainRecordValue(provider.EmailDomainToken) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// verifyProviderDomain is a route handler which verifies the email domain of
+// an SSO provider through its DNS TXT record.
+func verifyProviderDomain(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbProvider, code, err := getUserProvider(r, tx, user, a[routes.SsoProviderIdQueryArg].(int))
+	if err != nil {
+		return code, err
+	}
+	if !dbProvider.EmailDomainVerified {
+		verified, err := domainVerified(r.Context(), dbProvider)
+		if err != nil {
+			l.Error("Failed to look the SSO email domain record up", map[string]interface{}{
+				"ssoProviderId": dbProvider.ID,
+				"error":         err.Error(),
+			})
+			return http.StatusBadGateway, errors.New("Failed to look the DNS record of the email domain up.")
+		} else if !verified {
+			return http.StatusBadRequest, errors.New("The DNS record of the email domain does not hold the verification value.")
+		}
+		dbProvider.EmailDomainVerified = true
+		if err := dbProvider.Update(tx); err != nil {
+			l.Error("Failed to update SSO provider", map[string]interface{}{
+				"ssoProviderId": dbProvider.ID,
+				"error":         err.Error(),
+			})
+			return http.StatusInternalServerError, errors.New("Failed to verify SSO provider.")
+		}
+	}
+	dbMappings, err := models.SSOGroupMappingsBySSOProviderID(tx, dbProvider.ID)
+	if err != nil {
+		l.Error("Failed to get SSO group mappings", map[string]interface{}{
+			"ssoProviderId": dbProvider.ID,
+			"error":         err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve SSO provider.")
+	}
+	return http.StatusOK, providerFromDbProvider(dbProvider, dbMappings)
+}
@@ -0,0 +1,270 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package sso
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/trackit/trackit/models"
+)
+
+type (
+	// providerMetadata is the part of the OpenID Connect discovery document
+	// of an identity provider used by the login flow.
+	providerMetadata struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JwksUri               string `json:"jwks_uri"`
+	}
+
+	// tokenResponse is the response of the token endpoint of an identity
+	// provider.
+	tokenResponse struct {
+		IdToken string `json:"id_token"`
+	}
+
+	// jsonWebKey is a public key of an identity provider. Only RSA keys are
+	// supported.
+	jsonWebKey struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	}
+
+	// identity is the identity of a user asserted by an identity provider.
+	identity struct {
+		Subject string
+		Email   string
+		Groups  []string
+	}
+)
+
+var httpClient = http.Client{
+	Timeout: 10 * time.Second,
+}
+
+// doRequest runs an HTTP request against an identity provider and decodes
+// its JSON response in res.
+func doRequest(req *http.Request, res interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	r, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(r.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Host, r.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(r.Body).Decode(res)
+}
+
+// discover retrieves the OpenID Connect discovery document of an issuer.
+func discover(ctx context.Context, issuer string) (providerMetadata, error) {
+	var metadata providerMetadata
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return metadata, err
+	} else if err := doRequest(req.WithContext(ctx), &metadata); err != nil {
+		return metadata, err
+	} else if strings.TrimSuffix(metadata.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return metadata, fmt.Errorf("issuer mismatch : %s", metadata.Issuer)
+	} else if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JwksUri == "" {
+		return metadata, errors.New("incomplete discovery document")
+	}
+	return metadata, nil
+}
+
+// pkceChallenge returns the S256 PKCE challenge of a code verifier.
+func pkceChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// authorizationUrl builds the URL the user is sent to in order to log in
+// with their identity provider.
+func authorizationUrl(metadata providerMetadata, provider *models.SSOProvider, state, nonce, verifier string) (string, error) {
+	u, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", provider.ClientID)
+	q.Set("redirect_uri", provider.RedirectURI)
+	q.Set("scope", "openid email profile")
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", pkceChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// exchangeCode exchanges an authorization code for an ID token.
+func exchangeCode(ctx context.Context, metadata providerMetadata, provider *models.SSOProvider, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.RedirectURI},
+		"client_id":     {provider.ClientID},
+		"client_secret": {provider.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var res tokenResponse
+	if err := doRequest(req.WithContext(ctx), &res); err != nil {
+		return "", err
+	} else if res.IdToken == "" {
+		return "", errors.New("no ID token in token response")
+	}
+	return res.IdToken, nil
+}
+
+// getSigningKeys retrieves the RSA public keys of an identity provider, by
+// key ID.
+func getSigningKeys(ctx context.Context, metadata providerMetadata) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequest(http.MethodGet, metadata.JwksUri, nil)
+	if err != nil {
+		return nil, err
+	}
+	var res struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := doRequest(req.WithContext(ctx), &res); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range res.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+// verifyIdToken verifies the signature and the claims of an ID token and
+// returns the identity it asserts.
+func verifyIdToken(idToken string, keys map[string]*rsa.PublicKey, metadata providerMetadata, provider *models.SSOProvider, nonce string) (identity, error) {
+	token, err := jwt.Parse(idToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method : %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := keys[kid]; ok {
+			return key, nil
+		} else if len(keys) == 1 && kid == "" {
+			for _, key := range keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key : %s", kid)
+	})
+	if err != nil {
+		return identity{}, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return identity{}, errors.New("invalid ID token")
+	}
+	return identityFromClaims(claims, metadata.Issuer, provider, nonce)
+}
+
+// identityFromClaims checks the claims of a verified ID token and extracts
+// the identity they assert.
+func identityFromClaims(claims jwt.MapClaims, issuer string, provider *models.SSOProvider, nonce string) (identity, error) {
+	var id identity
+	if !claims.VerifyIssuer(issuer, true) {
+		return id, errors.New("invalid issuer")
+	} else if !hasAudience(claims["aud"], provider.ClientID) {
+		return id, errors.New("invalid audience")
+	} else if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return id, errors.New("expired ID token")
+	} else if claimNonce, _ := claims["nonce"].(string); claimNonce != nonce {
+		return id, errors.New("invalid nonce")
+	}
+	id.Subject, _ = claims["sub"].(string)
+	id.Email, _ = claims["email"].(string)
+	if id.Subject == "" || id.Email == "" {
+		return id, errors.New("missing subject or email")
+	} else if verified, _ := claims["email_verified"].(bool); !verified {
+		return id, errors.New("email is not verified")
+	}
+	id.Groups = stringsFromClaim(claims[provider.GroupsClaim])
+	return id, nil
+}
+
+// hasAudience checks whether the aud claim of a token, a string or an array
+// of strings, contains clientId.
+func hasAudience(aud interface{}, clientId string) bool {
+	for _, a := range stringsFromClaim(aud) {
+		if a == clientId {
+			return true
+		}
+	}
+	return false
+}
+
+// stringsFromClaim reads a claim holding either a string or an array of
+// strings.
+func stringsFromClaim(claim interface{}) []string {
+	switch typed := claim.(type) {
+	case string:
+		return []string{typed}
+	case []interface{}:
+		res := make([]string, 0, len(typed))
+		for _, v := range typed {
+			if s, ok := v.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,346 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package sso
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+// defaultGroupsClaim is the claim of the ID tokens holding the groups of the
+// users when a provider does not set any.
+const defaultGroupsClaim = "groups"
+
+type (
+	// MappingBody maps the members of a group of the identity provider to a
+	// role on an AWS account.
+	MappingBody struct {
+		Group        string `json:"group" req:"nonzero"`
+		AwsAccountId int    `json:"awsAccountId" req:"nonzero"`
+		RoleId       int    `json:"roleId" req:"nonzero"`
+	}
+
+	// ProviderBody is the body required to create or edit an SSO provider.
+	// The client secret is kept if left empty when editing a provider. Only
+	// users with an email address of EmailDomain are provisioned.
+	ProviderBody struct {
+		Name         string        `json:"name" req:"nonzero"`
+		Issuer       string        `json:"issuer" req:"nonzero"`
+		ClientId     string        `json:"clientId" req:"nonzero"`
+		ClientSecret string        `json:"clientSecret"`
+		RedirectUri  string        `json:"redirectUri" req:"nonzero"`
+		GroupsClaim  string        `json:"groupsClaim"`
+		EmailDomain  string        `json:"emailDomain" req:"nonzero"`
+		Mappings     []MappingBody `json:"mappings"`
+	}
+
+	// Provider is an SSO provider as returned by the routes. Its client
+	// secret is never returned. Until its email domain is verified, it
+	// cannot provision users: the DNS TXT record EmailDomainRecord must be
+	// set to EmailDomainRecordValue first.
+	Provider struct {
+		Id                     int           `json:"id"`
+		Name                   string        `json:"name"`
+		Issuer                 string        `json:"issuer"`
+		ClientId               string        `json:"clientId"`
+		RedirectUri            string        `json:"redirectUri"`
+		GroupsClaim            string        `json:"groupsClaim"`
+		EmailDomain            string        `json:"emailDomain"`
+		EmailDomainVerified    bool          `json:"emailDomainVerified"`
+		EmailDomainRecord      string        `json:"emailDomainRecord"`
+		EmailDomainRecordValue string        `json:"emailDomainRecordValue"`
+		Mappings               []MappingBody `json:"mappings"`
+	}
+)
+
+func init() {
+	providerExample := ProviderBody{
+		Name:         "Okta",
+		Issuer:       "https://example.okta.com",
+		ClientId:     "0oa1b2c3d4e5f6g7h8i9",
+		ClientSecret: "secret",
+		RedirectUri:  "https://re.trackit.io/sso/callback",
+		EmailDomain:  "example.com",
+		Mappings: []MappingBody{
+			{"finance", 42, users.RoleReadOnly},
+		},
+	}
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(getProviders).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.Documentation{
+				Summary:     "get the SSO providers",
+				Description: "Responds with the SSO providers of the user and their group mappings",
+			},
+		),
+		http.MethodPost: routes.H(postProvider).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{providerExample},
+			routes.Documentation{
+				Summary:     "create an SSO provider",
+				Description: "Creates an OpenID Connect SSO provider based on the body. It provisions users once its email domain is verified with /user/sso/providers/verify",
+			},
+		),
+		http.MethodPut: routes.H(putProvider).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{providerExample},
+			routes.QueryArgs{routes.SsoProviderIdQueryArg},
+			routes.Documentation{
+				Summary:     "edit an SSO provider",
+				Description: "Edits an SSO provider based on the body. Its group mappings are replaced and applied on the next login of its users. Its email domain must be verified again if it changed",
+			},
+		),
+		http.MethodDelete: routes.H(deleteProvider).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.QueryArgs{routes.SsoProviderIdQueryArg},
+			routes.Documentation{
+				Summary:     "delete an SSO provider",
+				Description: "Deletes an SSO provider and revokes the accesses it granted",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{Db: db.Db},
+		routes.Documentation{
+			Summary:     "interact with the SSO providers",
+			Description: "An SSO provider lets users log in with an OpenID Connect identity provider. The groups of the users grant them access to AWS accounts of the owner of the provider.",
+		},
+	).Register("/user/sso/providers")
+
+	routes.MethodMuxer{
+		http.MethodPost: routes.H(verifyProviderDomain).With(
+			users.RequireAuthenticatedUser{users.ViewerCannot},
+			routes.QueryArgs{routes.SsoProviderIdQueryArg},
+			routes.Documentation{
+				Summary:     "verify the email domain of an SSO provider",
+				Description: "Verifies that the DNS TXT record returned as emailDomainRecord by /user/sso/providers holds emailDomainRecordValue, proving the owner of the provider controls its email domain, and responds with the provider",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{Db: db.Db},
+	).Register("/user/sso/providers/verify")
+}
+
+// providerFromDbProvider builds a Provider from its database representation.
+func providerFromDbProvider(dbProvider *models.SSOProvider, dbMappings []*models.SSOGroupMapping) Provider {
+	provider := Provider{
+		Id:                     dbProvider.ID,
+		Name:                   dbProvider.Name,
+		Issuer:                 dbProvider.Issuer,
+		ClientId:               dbProvider.ClientID,
+		RedirectUri:            dbProvider.RedirectURI,
+		GroupsClaim:            dbProvider.GroupsClaim,
+		EmailDomain:            dbProvider.EmailDomain,
+		EmailDomainVerified:    dbProvider.EmailDomainVerified,
+		EmailDomainRecord:      domainRecordName(dbProvider.EmailDomain),
+		EmailDomainRecordValue: domainRecordValue(dbProvider.EmailDomainToken),
+		Mappings:               make([]MappingBody, len(dbMappings)),
+	}
+	for i, m := range dbMappings {
+		provider.Mappings[i] = MappingBody{m.GroupName, m.AwsAccountID, m.AccessRoleID}
+	}
+	return provider
+}
+
+// getProviders is a route handler which returns the SSO providers of the
+// user.
+func getProviders(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbProviders, err := models.SSOProvidersByUserID(tx, user.Id)
+	if err != nil {
+		l.Error("Failed to get SSO providers", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to retrieve SSO providers.")
+	}
+	providers := make([]Provider, len(dbProviders))
+	for i, dbProvider := range dbProviders {
+		dbMappings, err := models.SSOGroupMappingsBySSOProviderID(tx, dbProvider.ID)
+		if err != nil {
+			l.Error("Failed to get SSO group mappings", map[string]interface{}{
+				"ssoProviderId": dbProvider.ID,
+				"error":         err.Error(),
+			})
+			return http.StatusInternalServerError, errors.New("Failed to retrieve SSO providers.")
+		}
+		providers[i] = providerFromDbProvider(dbProvider, dbMappings)
+	}
+	return http.StatusOK, providers
+}
+
+// postProvider is a route handler which creates an SSO provider.
+func postProvider(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body ProviderBody
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	if body.ClientSecret == "" {
+		return http.StatusBadRequest, errors.New("The client secret is required.")
+	}
+	dbProvider := &models.SSOProvider{
+		UserID: user.Id,
+	}
+	return saveProvider(r, tx, user, dbProvider, body)
+}
+
+// putProvider is a route handler which edits an SSO provider.
+func putProvider(r *http.Request, a routes.Arguments) (int, interface{}) {
+	var body ProviderBody
+	routes.MustRequestBody(a, &body)
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbProvider, code, err := getUserProvider(r, tx, user, a[routes.SsoProviderIdQueryArg].(int))
+	if err != nil {
+		return code, err
+	}
+	return saveProvider(r, tx, user, dbProvider, body)
+}
+
+// deleteProvider is a route handler which deletes an SSO provider. The
+// accounts it shared are unshared by the database.
+func deleteProvider(r *http.Request, a routes.Arguments) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	tx := a[db.Transaction].(*sql.Tx)
+	user := a[users.AuthenticatedUser].(users.User)
+	dbProvider, code, err := getUserProvider(r, tx, user, a[routes.SsoProviderIdQueryArg].(int))
+	if err != nil {
+		return code, err
+	}
+	if err := dbProvider.Delete(tx); err != nil {
+		l.Error("Failed to delete SSO provider", map[string]interface{}{
+			"ssoProviderId": dbProvider.ID,
+			"error":         err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to delete SSO provider.")
+	}
+	return http.StatusOK, nil
+}
+
+// getUserProvider retrieves an SSO provider owned by user.
+func getUserProvider(r *http.Request, tx *sql.Tx, user users.User, providerId int) (*models.SSOProvider, int, error) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	dbProvider, err := models.SSOProviderByID(tx, providerId)
+	if err == sql.ErrNoRows || (err == nil && dbProvider.UserID != user.Id) {
+		return nil, http.StatusNotFound, errors.New("SSO provider not found.")
+	} else if err != nil {
+		l.Error("Failed to get SSO provider", map[string]interface{}{
+			"ssoProviderId": providerId,
+			"error":         err.Error(),
+		})
+		return nil, http.StatusInternalServerError, errors.New("Failed to retrieve SSO provider.")
+	}
+	return dbProvider, http.StatusOK, nil
+}
+
+// validProvider checks the body of an SSO provider. Its issuer must be
+// reachable and its mappings must refer to AWS accounts and roles of the
+// user.
+func validProvider(r *http.Request, tx *sql.Tx, user users.User, body ProviderBody) error {
+	if u, err := url.Parse(body.Issuer); err != nil || u.Scheme != "https" {
+		return errors.New("The issuer must be an HTTPS URL.")
+	} else if !validDomain(body.EmailDomain) {
+		return errors.New("Invalid email domain.")
+	} else if u, err := url.Parse(body.RedirectUri); err != nil || u.Host == "" {
+		return errors.New("Invalid redirect URI.")
+	} else if _, err := discover(r.Context(), body.Issuer); err != nil {
+		return fmt.Errorf("Failed to discover the issuer: %s", err.Error())
+	}
+	for _, m := range body.Mappings {
+		if m.Group == "" {
+			return errors.New("Group mappings require a group.")
+		} else if role, err := users.GetRoleWithId(tx, m.RoleId); err != nil || !role.AvailableOn(user.Id) {
+			return fmt.Errorf("Role %d not found.", m.RoleId)
+		} else if account, err := models.AwsAccountByID(tx, m.AwsAccountId); err != nil || account.UserID != user.Id {
+			return fmt.Errorf("AWS account %d not found.", m.AwsAccountId)
+		}
+	}
+	return nil
+}
+
+// saveProvider validates an SSO provider and saves it in the database along
+// with its group mappings.
+func saveProvider(r *http.Request, tx *sql.Tx, user users.User, dbProvider *models.SSOProvider, body ProviderBody) (int, interface{}) {
+	l := jsonlog.LoggerFromContextOrDefault(r.Context())
+	if err := validProvider(r, tx, user, body); err != nil {
+		return http.StatusBadRequest, err
+	}
+	dbProvider.Name = body.Name
+	dbProvider.Issuer = body.Issuer
+	dbProvider.ClientID = body.ClientId
+	if body.ClientSecret != "" {
+		dbProvider.ClientSecret = body.ClientSecret
+	}
+	dbProvider.RedirectURI = body.RedirectUri
+	dbProvider.GroupsClaim = body.GroupsClaim
+	if dbProvider.GroupsClaim == "" {
+		dbProvider.GroupsClaim = defaultGroupsClaim
+	}
+	if domain := strings.ToLower(body.EmailDomain); domain != dbProvider.EmailDomain {
+		token, err := randomString()
+		if err != nil {
+			l.Error("Failed to generate SSO email domain token", err.Error())
+			return http.StatusInternalServerError, errors.New("Failed to save SSO provider.")
+		}
+		dbProvider.EmailDomain = domain
+		dbProvider.EmailDomainToken = token
+		dbProvider.EmailDomainVerified = false
+	}
+	if err := dbProvider.Save(tx); err != nil {
+		l.Error("Failed to save SSO provider", map[string]interface{}{
+			"userId": user.Id,
+			"error":  err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to save SSO provider.")
+	}
+	if err := models.DeleteSSOGroupMappingsBySSOProviderID(tx, dbProvider.ID); err != nil {
+		l.Error("Failed to delete SSO group mappings", map[string]interface{}{
+			"ssoProviderId": dbProvider.ID,
+			"error":         err.Error(),
+		})
+		return http.StatusInternalServerError, errors.New("Failed to save SSO provider.")
+	}
+	dbMappings := make([]*models.SSOGroupMapping, len(body.Mappings))
+	for i, m := range body.Mappings {
+		dbMappings[i] = &models.SSOGroupMapping{
+			SSOProviderID: dbProvider.ID,
+			GroupName:     m.Group,
+			AwsAccountID:  m.AwsAccountId,
+			AccessRoleID:  m.RoleId,
+		}
+		if err := dbMappings[i].Insert(tx); err != nil {
+			l.Error("Failed to insert SSO group mapping", map[string]interface{}{
+				"ssoProviderId": dbProvider.ID,
+				"error":         err.Error(),
+			})
+			return http.StatusInternalServerError, errors.New("Failed to save SSO provider.")
+		}
+	}
+	return http.StatusOK, providerFromDbProvider(dbProvider, dbMappings)
+}
@@ -0,0 +1,394 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package sso logs users in through the OpenID Connect identity providers of
+// their organization. Users are provisioned on their first login and the
+// groups asserted by the identity provider grant them access to AWS accounts
+// of the owner of the provider, so that access is managed in the identity
+// provider.
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/trackit/jsonlog"
+
+	"github.com/trackit/trackit/db"
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/routes"
+	"github.com/trackit/trackit/users"
+)
+
+// loginValidity is the duration a user has to complete a login with their
+// identity provider.
+const loginValidity = 10 * time.Minute
+
+var (
+	ErrLoginNotFound  = errors.New("SSO login not found or expired.")
+	ErrEmailDomain    = errors.New("Your email address is not allowed to log in with this identity provider.")
+	ErrUnverifiedSso  = errors.New("This identity provider cannot create accounts until the owner verifies its email domain.")
+	ErrExistingUser   = errors.New("An account already exists with this email address. Log in with your password instead.")
+	ErrAdminUser      = errors.New("Administrators cannot log in with an identity provider.")
+	ErrFailedSsoLogin = errors.New("Failed to log in with the identity provider.")
+)
+
+type (
+	// loginResponseBody is the response body of the route starting a login.
+	loginResponseBody struct {
+		Url string `json:"url"`
+	}
+
+	// callbackRequestBody is the body required to complete a login, with the
+	// parameters the identity provider redirected the user with.
+	callbackRequestBody struct {
+		State string `json:"state" req:"nonzero"`
+		Code  string `json:"code" req:"nonzero"`
+	}
+)
+
+func init() {
+	routes.MethodMuxer{
+		http.MethodGet: routes.H(startLogin).With(
+			routes.QueryArgs{routes.SsoProviderIdQueryArg},
+			routes.Documentation{
+				Summary:     "start a login with an identity provider",
+				Description: "Responds with the URL of the identity provider the user has to be sent to in order to log in.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+	).Register("/user/sso/login")
+
+	routes.MethodMuxer{
+		http.MethodPost: routes.H(completeLogin).With(
+			routes.RequestContentType{"application/json"},
+			routes.RequestBody{callbackRequestBody{"state", "code"}},
+			routes.Documentation{
+				Summary:     "complete a login with an identity provider",
+				Description: "Logs a user in with the state and code their identity provider redirected them with and returns a JWT token, a refresh token and the user's data. Users are created on their first login.",
+			},
+		),
+	}.H().With(
+		db.RequestTransaction{db.Db},
+	).Register("/user/sso/callback")
+}
+
+// randomString returns a random URL-safe string.
+func randomString() (string, error) {
+	var random [32]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(random[:]), nil
+}
+
+// hashState hashes the state of a login to store it or look it up.
+func hashState(state string) string {
+	hash := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(hash[:])
+}
+
+// startLogin is a route handler which starts a login with an identity
+// provider.
+func startLogin(request *http.Request, a routes.Arguments) (int, interface{}) {
+	ctx := request.Context()
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	tx := a[db.Transaction].(*sql.Tx)
+	provider, err := models.SSOProviderByID(tx, a[routes.SsoProviderIdQueryArg].(int))
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, errors.New("Identity provider not found.")
+	} else if err != nil {
+		logger.Error("Failed to get SSO provider.", err.Error())
+		return http.StatusInternalServerError, ErrFailedSsoLogin
+	}
+	metadata, err := discover(ctx, provider.Issuer)
+	if err != nil {
+		logger.Error("Failed to discover SSO provider.", map[string]interface{}{
+			"ssoProviderId": provider.ID,
+			"error":         err.Error(),
+		})
+		return http.StatusBadGateway, ErrFailedSsoLogin
+	}
+	var state, nonce, verifier string
+	if state, err = randomString(); err == nil {
+		if nonce, err = randomString(); err == nil {
+			verifier, err = randomString()
+		}
+	}
+	if err != nil {
+		logger.Error("Failed to generate SSO login secrets.", err.Error())
+		return http.StatusInternalServerError, ErrFailedSsoLogin
+	}
+	now := time.Now().UTC()
+	if err := models.DeleteExpiredSSOLogins(tx, now.Add(-loginValidity)); err != nil {
+		logger.Error("Failed to delete expired SSO logins.", err.Error())
+		return http.StatusInternalServerError, ErrFailedSsoLogin
+	}
+	login := models.SSOLogin{
+		SSOProviderID: provider.ID,
+		State:         hashState(state),
+		Nonce:         nonce,
+		CodeVerifier:  verifier,
+		Created:       now,
+	}
+	if err := login.Insert(tx); err != nil {
+		logger.Error("Failed to insert SSO login.", err.Error())
+		return http.StatusInternalServerError, ErrFailedSsoLogin
+	}
+	url, err := authorizationUrl(metadata, provider, state, nonce, verifier)
+	if err != nil {
+		logger.Error("Failed to build SSO authorization URL.", err.Error())
+		return http.StatusBadGateway, ErrFailedSsoLogin
+	}
+	return http.StatusOK, loginResponseBody{url}
+}
+
+// completeLogin is a route handler which completes a login with an identity
+// provider. The login can only be completed once.
+func completeLogin(request *http.Request, a routes.Arguments) (int, interface{}) {
+	var body callbackRequestBody
+	routes.MustRequestBody(a, &body)
+	ctx := request.Context()
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	tx := a[db.Transaction].(*sql.Tx)
+	login, err := models.SSOLoginByState(tx, hashState(body.State))
+	if err == sql.ErrNoRows || (err == nil && time.Since(login.Created) > loginValidity) {
+		return http.StatusBadRequest, ErrLoginNotFound
+	} else if err != nil {
+		logger.Error("Failed to get SSO login.", err.Error())
+		return http.StatusInternalServerError, ErrFailedSsoLogin
+	} else if err := login.Delete(tx); err != nil {
+		logger.Error("Failed to delete SSO login.", err.Error())
+		return http.StatusInternalServerError, ErrFailedSsoLogin
+	}
+	provider, err := login.SSOProvider(tx)
+	if err != nil {
+		logger.Error("Failed to get SSO provider.", err.Error())
+		return http.StatusInternalServerError, ErrFailedSsoLogin
+	}
+	id, err := authenticate(ctx, provider, login, body.Code)
+	if err != nil {
+		logger.Warning("SSO authentication failure.", map[string]interface{}{
+			"ssoProviderId": provider.ID,
+			"error":         err.Error(),
+		})
+		return http.StatusUnauthorized, ErrFailedSsoLogin
+	}
+	user, code, err := getOrProvisionUser(ctx, tx, provider, id)
+	if err != nil {
+		return code, err
+	}
+	if err := syncPermissions(tx, provider, user, id.Groups); err != nil {
+		logger.Error("Failed to sync SSO permissions.", map[string]interface{}{
+			"ssoProviderId": provider.ID,
+			"userId":        user.Id,
+			"error":         err.Error(),
+		})
+		return http.StatusInternalServerError, ErrFailedSsoLogin
+	}
+	// The identity provider is trusted with the second factor.
+	return users.LogAuthenticatedUserIn(request, tx, user, true)
+}
+
+// authenticate exchanges an authorization code with an identity provider and
+// returns the identity asserted by the ID token it responds with.
+func authenticate(ctx context.Context, provider *models.SSOProvider, login *models.SSOLogin, code string) (identity, error) {
+	metadata, err := discover(ctx, provider.Issuer)
+	if err != nil {
+		return identity{}, err
+	}
+	idToken, err := exchangeCode(ctx, metadata, provider, code, login.CodeVerifier)
+	if err != nil {
+		return identity{}, err
+	}
+	keys, err := getSigningKeys(ctx, metadata)
+	if err != nil {
+		return identity{}, err
+	}
+	return verifyIdToken(idToken, keys, metadata, provider, login.Nonce)
+}
+
+// allowedEmail checks whether an email address belongs to the email domain of
+// an identity provider.
+func allowedEmail(provider *models.SSOProvider, email string) bool {
+	if provider.EmailDomain == "" {
+		return false
+	}
+	return strings.HasSuffix(strings.ToLower(email), "@"+strings.ToLower(provider.EmailDomain))
+}
+
+// getOrProvisionUser returns the user an identity was linked to, or creates
+// one on its first login. Identities are never linked to existing users: an
+// identity provider is configured by a user of TrackIt and must not be able
+// to log in as anyone else, administrators above all. Users are only created
+// with an email address of the domain of the provider, once its owner proved
+// they control the domain.
+func getOrProvisionUser(ctx context.Context, tx *sql.Tx, provider *models.SSOProvider, id identity) (users.User, int, error) {
+	logger := jsonlog.LoggerFromContextOrDefault(ctx)
+	dbIdentity, err := models.SSOIdentityBySSOProviderIDSubject(tx, provider.ID, id.Subject)
+	if err == nil {
+		user, err := users.GetUserWithId(tx, dbIdentity.UserID)
+		if err != nil {
+			logger.Error("Failed to get SSO user.", err.Error())
+			return user, http.StatusInternalServerError, ErrFailedSsoLogin
+		} else if users.IsAdmin(user) {
+			return users.User{}, http.StatusForbidden, ErrAdminUser
+		}
+		return user, http.StatusOK, nil
+	} else if err != sql.ErrNoRows {
+		logger.Error("Failed to get SSO identity.", err.Error())
+		return users.User{}, http.StatusInternalServerError, ErrFailedSsoLogin
+	} else if !provider.EmailDomainVerified {
+		return users.User{}, http.StatusForbidden, ErrUnverifiedSso
+	} else if !allowedEmail(provider, id.Email) {
+		return users.User{}, http.StatusForbidden, ErrEmailDomain
+	} else if users.IsAdmin(users.User{Email: id.Email}) {
+		return users.User{}, http.StatusForbidden, ErrAdminUser
+	}
+	if _, err := users.GetUserWithEmail(ctx, tx, id.Email); err == nil {
+		return users.User{}, http.StatusConflict, ErrExistingUser
+	} else if err != users.ErrUserNotFound {
+		return users.User{}, http.StatusInternalServerError, ErrFailedSsoLogin
+	}
+	// SSO users cannot log in with a password until they reset it
+	password, err := randomString()
+	if err != nil {
+		logger.Error("Failed to generate SSO user password.", err.Error())
+		return users.User{}, http.StatusInternalServerError, ErrFailedSsoLogin
+	}
+	user, err := users.CreateUserWithPassword(ctx, tx, id.Email, password, "")
+	if err != nil {
+		return user, http.StatusInternalServerError, ErrFailedSsoLogin
+	}
+	dbIdentity = &models.SSOIdentity{
+		SSOProviderID: provider.ID,
+		Subject:       id.Subject,
+		UserID:        user.Id,
+	}
+	if err := dbIdentity.Insert(tx); err != nil {
+		logger.Error("Failed to insert SSO identity.", err.Error())
+		return user, http.StatusInternalServerError, ErrFailedSsoLogin
+	}
+	logger.Info("SSO user provisioned.", map[string]interface{}{
+		"ssoProviderId": provider.ID,
+		"userId":        user.Id,
+	})
+	return user, http.StatusOK, nil
+}
+
+// grantedRoles returns the role granted by the groups of a user on each AWS
+// account, by AWS account ID. The role with the most permissions wins when
+// several groups grant access to the same account.
+func grantedRoles(mappings []*models.SSOGroupMapping, groups []string, roles map[int]users.Role) map[int]int {
+	inGroup := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		inGroup[g] = true
+	}
+	res := make(map[int]int)
+	for _, m := range mappings {
+		if !inGroup[m.GroupName] {
+			continue
+		}
+		if roleId, ok := res[m.AwsAccountID]; !ok || morePermissive(roles[m.AccessRoleID], roles[roleId]) {
+			res[m.AwsAccountID] = m.AccessRoleID
+		}
+	}
+	return res
+}
+
+// morePermissive checks whether a role has more permissions than another
+// one. Ties go to the role created first.
+func morePermissive(role users.Role, other users.Role) bool {
+	if len(role.Permissions) != len(other.Permissions) {
+		return len(role.Permissions) > len(other.Permissions)
+	}
+	return role.Id < other.Id
+}
+
+// mappingRoles retrieves the roles of group mappings, by ID.
+func mappingRoles(tx *sql.Tx, mappings []*models.SSOGroupMapping) (map[int]users.Role, error) {
+	roles := make(map[int]users.Role)
+	for _, m := range mappings {
+		if _, ok := roles[m.AccessRoleID]; ok {
+			continue
+		} else if role, err := users.GetRoleWithId(tx, m.AccessRoleID); err != nil {
+			return nil, err
+		} else {
+			roles[m.AccessRoleID] = role
+		}
+	}
+	return roles, nil
+}
+
+// syncPermissions shares the AWS accounts granted by the groups of a user
+// with them, and revokes the accounts previously shared by the identity
+// provider that are not granted anymore. Accounts shared through invites are
+// left untouched.
+func syncPermissions(tx *sql.Tx, provider *models.SSOProvider, user users.User, groups []string) error {
+	mappings, err := models.SSOGroupMappingsBySSOProviderID(tx, provider.ID)
+	if err != nil {
+		return err
+	}
+	roles, err := mappingRoles(tx, mappings)
+	if err != nil {
+		return err
+	}
+	granted := grantedRoles(mappings, groups, roles)
+	sharedAccounts, err := models.SharedAccountsByUserID(tx, user.Id)
+	if err != nil {
+		return err
+	}
+	for _, sa := range sharedAccounts {
+		roleId, ok := granted[sa.AccountID]
+		delete(granted, sa.AccountID)
+		if !sa.SSOProviderID.Valid || int(sa.SSOProviderID.Int64) != provider.ID {
+			continue
+		} else if !ok {
+			err = sa.Delete(tx)
+		} else if sa.AccessRoleID != roleId {
+			sa.AccessRoleID = roleId
+			err = sa.Update(tx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for accountId, roleId := range granted {
+		if account, err := models.AwsAccountByID(tx, accountId); err != nil {
+			return err
+		} else if account.UserID == user.Id || account.UserID != provider.UserID {
+			continue
+		}
+		sa := models.SharedAccount{
+			AccountID:       accountId,
+			UserID:          user.Id,
+			AccessRoleID:    roleId,
+			SharingAccepted: true,
+			SSOProviderID:   sql.NullInt64{Int64: int64(provider.ID), Valid: true},
+		}
+		if err := sa.Insert(tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+//   Copyright 2020 MSolution.IO
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/trackit/trackit/models"
+	"github.com/trackit/trackit/users"
+)
+
+func TestPkceChallenge(t *testing.T) {
+	// Example of RFC 7636, appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	expected := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	if challenge := pkceChallenge(verifier); challenge != expected {
+		t.Errorf("Challenge should be %s, is %s", expected, challenge)
+	}
+}
+
+func TestGrantedRoles(t *testing.T) {
+	roles := map[int]users.Role{
+		users.RoleAdministrator: {Id: users.RoleAdministrator, Permissions: users.Permissions},
+		users.RoleReadOnly:      {Id: users.RoleReadOnly, Permissions: []users.Permission{users.PermissionViewCosts}},
+		4:                       {Id: 4, Permissions: []users.Permission{users.PermissionViewCosts, users.PermissionViewResources}},
+		5:                       {Id: 5, Permissions: []users.Permission{users.PermissionViewCosts, users.PermissionDownloadReports}},
+	}
+	mappings := []*models.SSOGroupMapping{
+		{GroupName: "finance", AwsAccountID: 1, AccessRoleID: users.RoleReadOnly},
+		{GroupName: "ops", AwsAccountID: 1, AccessRoleID: users.RoleAdministrator},
+		{GroupName: "ops", AwsAccountID: 2, AccessRoleID: 5},
+		{GroupName: "finance", AwsAccountID: 2, AccessRoleID: 4},
+		{GroupName: "other", AwsAccountID: 3, AccessRoleID: users.RoleAdministrator},
+	}
+	granted := grantedRoles(mappings, []string{"finance", "ops"}, roles)
+	expected := map[int]int{1: users.RoleAdministrator, 2: 4}
+	if len(granted) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, granted)
+	}
+	for account, roleId := range expected {
+		if granted[account] != roleId {
+			t.Errorf("Account %d: expected role %d, got %d", account, roleId, granted[account])
+		}
+	}
+}
+
+func TestAllowedEmail(t *testing.T) {
+	provider := &models.SSOProvider{EmailDomain: "example.com"}
+	if !allowedEmail(provider, "jane@Example.com") {
+		t.Error("Emails of the domain should be allowed")
+	}
+	if allowedEmail(provider, "jane@notexample.com") {
+		t.Error("Emails of other domains should not be allowed")
+	}
+	if allowedEmail(&models.SSOProvider{}, "jane@notexample.com") {
+		t.Error("No email should be allowed without a domain")
+	}
+}
+
+func TestValidDomain(t *testing.T) {
+	for domain, expected := range map[string]bool{
+		"example.com":       true,
+		"sub.example.co.uk": true,
+		"example":           false,
+		"jane@example.com":  false,
+		"-example.com":      false,
+		"":                  false,
+	} {
+		if validDomain(domain) != expected {
+			t.Errorf("Expected validDomain(%q) to be %v", domain, expected)
+		}
+	}
+}
+
+func TestDomainVerified(t *testing.T) {
+	defer func(lookup func(context.Context, string) ([]string, error)) { lookupTXT = lookup }(lookupTXT)
+	lookupTXT = func(_ context.Context, name string) ([]string, error) {
+		if name != "_trackit-sso.example.com" {
+			return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+		}
+		return []string{"v=spf1 -all", "trackit-sso-verification=token"}, nil
+	}
+	ctx := context.Background()
+	if verified, err := domainVerified(ctx, &models.SSOProvider{EmailDomain: "example.com", EmailDomainToken: "token"}); err != nil || !verified {
+		t.Errorf("Expected the domain to be verified, got %v (%v)", verified, err)
+	}
+	if verified, err := domainVerified(ctx, &models.SSOProvider{EmailDomain: "example.com", EmailDomainToken: "other"}); err != nil || verified {
+		t.Errorf("Expected another token not to verify the domain, got %v (%v)", verified, err)
+	}
+	if verified, err := domainVerified(ctx, &models.SSOProvider{EmailDomain: "example.org", EmailDomainToken: "token"}); err != nil || verified {
+		t.Errorf("Expected a domain without record not to be verified, got %v (%v)", verified, err)
+	}
+}
+
+func TestVerifyIdToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	metadata := providerMetadata{Issuer: "https://idp.example.com"}
+	provider := &models.SSOProvider{ClientID: "client", GroupsClaim: "groups"}
+	claims := jwt.MapClaims{
+		"iss":            "https://idp.example.com",
+		"aud":            []interface{}{"client", "other"},
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"nonce":          "nonce",
+		"sub":            "42",
+		"email":          "jane@example.com",
+		"groups":         []interface{}{"finance", "ops"},
+		"email_verified": true,
+	}
+	sign := func(claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "key"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("Failed to sign token: %s", err)
+		}
+		return signed
+	}
+	keys := map[string]*rsa.PublicKey{"key": &key.PublicKey}
+	id, err := verifyIdToken(sign(claims), keys, metadata, provider, "nonce")
+	if err != nil {
+		t.Fatalf("Failed to verify token: %s", err)
+	}
+	if id.Subject != "42" || id.Email != "jane@example.com" || len(id.Groups) != 2 {
+		t.Errorf("Unexpected identity %+v", id)
+	}
+	if _, err := verifyIdToken(sign(claims), keys, metadata, provider, "other"); err == nil {
+		t.Error("Tokens with another nonce should be rejected")
+	}
+	claims["aud"] = "other"
+	if _, err := verifyIdToken(sign(claims), keys, metadata, provider, "nonce"); err == nil {
+		t.Error("Tokens for another audience should be rejected")
+	}
+	delete(claims, "email_verified")
+	if _, err := verifyIdToken(sign(claims), keys, metadata, provider, "nonce"); err == nil {
+		t.Error("Tokens without a verified email should be rejected")
+	}
+	claims["email_verified"] = true
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	claims["aud"] = "client"
+	if _, err := verifyIdToken(sign(claims), map[string]*rsa.PublicKey{"key": &otherKey.PublicKey}, metadata, provider, "nonce"); err == nil {
+		t.Error("Tokens signed with another key should be rejected")
+	}
+}
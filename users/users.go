@@ -41,6 +41,34 @@ type User struct {
 	NextExternal           string `json:"-"`
 	ParentId               *int   `json:"parentId,omitempty"`
 	AwsCustomerEntitlement bool   `json:aws_customer_entitlement`
+	// Accounts restricts the accounts the user can access, to the ones of the
+	// API key they are authenticated with and the ones they have the
+	// permission the route needs on. A nil slice gives access to all of them.
+	Accounts []string `json:"-"`
+	// SessionId is the session the user is authenticated with. It is 0 when
+	// they are authenticated with an API key.
+	SessionId int `json:"-"`
+	// MfaVerified is set when the session the user is authenticated with was
+	// opened with a second factor or through single sign-on.
+	MfaVerified bool `json:"-"`
+	// ViewerId is the viewer user acting as this user, on routes which let
+	// viewers act as their parent. Their permissions are those of
+	// ViewerRole.
+	ViewerId int `json:"-"`
+}
+
+// CanAccessAccount checks whether the user is allowed to access an account,
+// given the restrictions of the API key they were authenticated with.
+func (u User) CanAccessAccount(account string) bool {
+	if u.Accounts == nil {
+		return true
+	}
+	for _, a := range u.Accounts {
+		if a == account {
+			return true
+		}
+	}
+	return false
 }
 
 // CreateUserWithPassword creates a user with an email and a password. A nil